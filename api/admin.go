@@ -0,0 +1,329 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/authz"
+	"github.com/smartatransit/feedback/db"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+//FeedbackRecord is the administrative view of a stored feedback
+type FeedbackRecord struct {
+	ID               string          `json:"id"`
+	SessionID        string          `json:"session_id"`
+	Role             string          `json:"role"`
+	Kind             string          `json:"kind"`
+	Value            *string         `json:"value,omitempty"`
+	Message          *string         `json:"message,omitempty"`
+	Email            *string         `json:"email,omitempty"`
+	EmailOptIn       bool            `json:"email_opt_in"`
+	Line             *string         `json:"line,omitempty"`
+	Details          json.RawMessage `json:"details,omitempty"`
+	Location         *LocationRecord `json:"location,omitempty"`
+	Client           *ClientRecord   `json:"client,omitempty"`
+	ReceivedAt       time.Time       `json:"received_at"`
+	Silenced         bool            `json:"silenced"`
+	ModerationStatus string          `json:"moderation_status"`
+	SpamScore        float64         `json:"spam_score"`
+	SpamReason       *string         `json:"spam_reason,omitempty"`
+	RedactedPII      *string         `json:"redacted_pii,omitempty"`
+	Triage           TriageRecord    `json:"triage"`
+}
+
+func feedbackRecordsFromFeedbackList(fbs []db.Feedback) []FeedbackRecord {
+	records := []FeedbackRecord{}
+	for _, fb := range fbs {
+		records = append(records, feedbackRecordFromFeedback(fb))
+	}
+	return records
+}
+
+func feedbackRecordFromFeedback(fb db.Feedback) FeedbackRecord {
+	return FeedbackRecord{
+		ID:               fb.ID,
+		SessionID:        fb.SessionID,
+		Role:             fb.Role,
+		Kind:             fb.Kind,
+		Value:            fb.Value,
+		Message:          fb.Message,
+		Email:            fb.Email,
+		EmailOptIn:       fb.EmailOptIn,
+		Line:             fb.Line,
+		Details:          fb.Details,
+		Location:         locationRecordFromLocation(fb.Location),
+		Client:           clientRecordFromClientInfo(fb.Client),
+		ReceivedAt:       fb.ReceivedAt,
+		Silenced:         fb.Silenced,
+		ModerationStatus: fb.ModerationStatus,
+		SpamScore:        fb.SpamScore,
+		SpamReason:       fb.SpamReason,
+		RedactedPII:      fb.RedactedPII,
+		Triage:           triageRecordFromTriage(fb.Triage),
+	}
+}
+
+//FeedbackListResponse lists feedback matching an administrative query
+type FeedbackListResponse struct {
+	Feedback []FeedbackRecord `json:"feedback"`
+}
+
+//ListFeedback serves GET /v1/admin/feedback, listing feedback newest first.
+//It takes either `email`, listing the feedback submitted with that address,
+//or any of `kind`, `bbox` and the triage filters. With `kind`,
+//`details.<field>` parameters match only feedback whose details have those
+//values; nested fields are separated by dots. `bbox` matches feedback
+//located within `min longitude,min latitude,max longitude,max latitude`.
+//`status`, `assignee`, `priority` and `tag` match feedback by triage. `q`
+//searches messages, listing the best matches first with snippets.
+func (c Client) ListFeedback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+		return
+	}
+
+	query := r.URL.Query()
+	email := query.Get("email")
+	kind := strings.ToLower(query.Get("kind"))
+	bbox := query.Get("bbox")
+	search := strings.TrimSpace(query.Get("q"))
+	if (email == "") == (kind == "" && bbox == "" && search == "" && !hasTriageFilter(query)) {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "missing_filter",
+			Message: "either the `email` query parameter or any of `kind`, `bbox`, `q`, `status`, `assignee`, `priority` and `tag` is required",
+		})
+		return
+	}
+
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
+	var fbs []db.Feedback
+	if email != "" {
+		fbs, err = c.db.ListFeedbackByEmail(r.Context(), email, limit, offset)
+	} else {
+		var filter db.FeedbackFilter
+		filter, err = c.parseFeedbackFilter(kind, query)
+		if err != nil {
+			c.writeValidationError(w, err)
+			return
+		}
+
+		if search != "" {
+			c.searchFeedback(w, r, search, filter, limit, offset)
+			return
+		}
+
+		fbs, err = c.db.ListFeedback(r.Context(), filter, limit, offset)
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to list feedback")
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, FeedbackListResponse{
+		Feedback: feedbackRecordsFromFeedbackList(fbs),
+	})
+}
+
+//parseFeedbackFilter reads the `kind`, `details.*`, `bbox` and triage
+//query parameters
+func (c Client) parseFeedbackFilter(kind string, query url.Values) (filter db.FeedbackFilter, err error) {
+	filter.Area, err = parseBoundingBox(query)
+	if err != nil {
+		return
+	}
+
+	if err = parseTriageFilter(query, &filter); err != nil {
+		return
+	}
+
+	if kind == "" {
+		for key := range query {
+			if strings.HasPrefix(key, detailsFilterPrefix) {
+				err = ValidationError{
+					Reason:  "invalid_details_filter",
+					Message: "`details` filters need a `kind`",
+				}
+				return
+			}
+		}
+		return
+	}
+
+	if _, ok := c.findKind(kind); !ok {
+		err = ValidationError{
+			Reason:  "invalid_kind",
+			Message: fmt.Sprintf("invalid value `%s` for `kind`", kind),
+		}
+		return
+	}
+	filter.Kind = &kind
+
+	filter.Details, err = c.parseDetailsFilter(kind, query)
+	return
+}
+
+//OriginalMessageResponse carries the unredacted message of a feedback
+type OriginalMessageResponse struct {
+	ID      string  `json:"id"`
+	Message *string `json:"message"`
+}
+
+//AdminFeedback serves operations on a single feedback under
+///v1/admin/feedback/{id}/. Currently:
+//
+//  GET /v1/admin/feedback/{id}/original returns the unredacted message
+//  GET /v1/admin/feedback/{id}/attachments lists its attachments
+//  GET /v1/admin/feedback/{id}/messages returns its thread
+//  POST /v1/admin/feedback/{id}/messages replies to the rider
+//  GET /v1/admin/feedback/{id}/triage returns its triage history
+//  POST /v1/admin/feedback/{id}/triage changes its triage
+//  GET /v1/admin/feedback/{id}/similar lists feedback worded like it
+func (c Client) AdminFeedback(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/admin/feedback/"), "/")
+	if len(parts) != 2 || !uuidRegexp.MatchString(parts[0]) {
+		c.writeErrorResponse(w, http.StatusNotFound, "not found")
+		return
+	}
+	id, op := parts[0], parts[1]
+
+	switch {
+	case op == "original" && r.Method == "GET":
+		if c.authorize(w, r, authz.Unredact) {
+			c.getOriginalMessage(w, r, id)
+		}
+	case op == "attachments" && r.Method == "GET":
+		c.listAttachments(w, r, id)
+	case op == "messages":
+		c.adminMessages(w, r, id)
+	case op == "triage":
+		c.adminTriage(w, r, strings.ToLower(id))
+	case op == "similar" && r.Method == "GET":
+		c.similarFeedback(w, r, strings.ToLower(id))
+	case op == "original" || op == "attachments" || op == "similar":
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+	default:
+		c.writeErrorResponse(w, http.StatusNotFound, "not found")
+	}
+}
+
+//getOriginalMessage serves the unredacted message of a feedback. Every read
+//is recorded in the audit log, and the message is withheld if it can't be.
+func (c Client) getOriginalMessage(w http.ResponseWriter, r *http.Request, id string) {
+	msg, err := c.db.GetOriginalMessage(r.Context(), id)
+	if errors.Is(err, db.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get original message")
+		return
+	}
+
+	err = c.recordAudit(r.Context(), audit.Entry{
+		ActorSession: r.Header.Get("X-Smarta-Auth-Session"),
+		ActorRole:    r.Header.Get("X-Smarta-Auth-Role"),
+		Action:       AuditReadOriginalMessage,
+		TargetType:   "feedback",
+		TargetIDs:    []string{id},
+	})
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get original message")
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, OriginalMessageResponse{ID: id, Message: msg})
+}
+
+//WithAuthorization returns a copy of c that checks the permissions of
+//X-Smarta-Auth-Role values against policy
+func (c Client) WithAuthorization(policy authz.Policy) Client {
+	c.policy = policy
+	return c
+}
+
+//Require wraps next so that it only serves requests whose role holds
+//permission. Other requests get a 401 or 403 response.
+func (c Client) Require(permission authz.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !c.authorize(w, r, permission) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+//authorize writes an error response and returns false unless r comes from a
+//role holding permission
+func (c Client) authorize(w http.ResponseWriter, r *http.Request, permission authz.Permission) bool {
+	session := r.Header.Get("X-Smarta-Auth-Session")
+	role := r.Header.Get("X-Smarta-Auth-Role")
+	if len(session) == 0 || len(role) == 0 {
+		c.writeErrorResponse(w, http.StatusUnauthorized, "expected X-Smarta-Auth-* headers not present")
+		return false
+	}
+
+	if !c.policy.Allows(role, permission) {
+		c.writeErrorResponse(w, http.StatusForbidden, fmt.Sprintf("role `%s` lacks the `%s` permission", role, permission))
+		return false
+	}
+
+	return true
+}
+
+//parsePage reads the `limit` and `offset` query parameters
+func parsePage(r *http.Request) (limit, offset int, err error) {
+	limit = defaultPageSize
+	if s := r.URL.Query().Get("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit <= 0 || limit > maxPageSize {
+			err = ValidationError{
+				Reason:  "invalid_limit",
+				Message: fmt.Sprintf("`limit` must be between 1 and %d", maxPageSize),
+			}
+			return
+		}
+	}
+
+	if s := r.URL.Query().Get("offset"); s != "" {
+		offset, err = strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			err = ValidationError{
+				Reason:  "invalid_offset",
+				Message: "`offset` must be a non-negative integer",
+			}
+			return
+		}
+	}
+
+	return limit, offset, nil
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+//feedbackIDFromPath extracts the feedback ID following prefix in the request
+//path, e.g. /v1/admin/moderation/{id}
+func feedbackIDFromPath(r *http.Request, prefix string) (string, bool) {
+	id := strings.TrimPrefix(r.URL.Path, prefix)
+	return id, uuidRegexp.MatchString(id)
+}
@@ -1,38 +1,70 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/smartatransit/feedback/attachment"
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/authz"
+	"github.com/smartatransit/feedback/blob"
+	"github.com/smartatransit/feedback/clientinfo"
 	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/gtfs"
+	"github.com/smartatransit/feedback/kinds"
+	"github.com/smartatransit/feedback/logging"
+	"github.com/smartatransit/feedback/mailer"
+	"github.com/smartatransit/feedback/ratelimit"
+	"github.com/smartatransit/feedback/redact"
+	"github.com/smartatransit/feedback/spam"
+	"github.com/smartatransit/feedback/tracing"
 )
 
-//ValidKinds enumerates valid kinds
-var ValidKinds = map[string]struct{}{
-	"outage":            {},
-	"comment":           {},
-	"service_condition": {},
-}
-
-//ValidValues enumerates valid kinds
-var ValidValues = map[string]struct{}{
-	"positive": {},
-	"negative": {},
-	"neutral":  {},
-}
-
 //SaveFeedbackRequest represents a user feedback record
 type SaveFeedbackRequest struct {
 	Kind    string `json:"kind"`
 	Value   string `json:"value"`
 	Message string `json:"message"`
 	Email   string `json:"email"`
+	Line    string `json:"line"`
+
+	//EmailOptIn agrees to be emailed about the feedback at Email. Without
+	//it, Email is stored but never written to.
+	EmailOptIn bool `json:"email_opt_in"`
+
+	//Details is an object described by the kind's details schema
+	Details json.RawMessage `json:"details"`
+
+	//Attachments lists the IDs of images uploaded beforehand through
+	///v1/feedback/attachments
+	Attachments []string `json:"attachments"`
+
+	//Latitude and Longitude locate the rider in WGS 84 degrees, and
+	//Accuracy is the radius of uncertainty in meters, as the device reports
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Accuracy  *float64 `json:"accuracy"`
+
+	//Client describes the app and device sending the feedback
+	Client *ClientInfoRequest `json:"client"`
+}
+
+//SaveFeedbackResponse describes a saved feedback. EmailUnsubscribed is set
+//when the rider opted in to email at an address that has unsubscribed, so
+//no email will be sent to it.
+type SaveFeedbackResponse struct {
+	EmailUnsubscribed bool `json:"email_unsubscribed,omitempty"`
 }
 
 //HealthResponse represents a response to the health-check endpoint
@@ -48,17 +80,77 @@ type Status struct {
 	Metadata    interface{} `json:"metadata,omitempty"`
 }
 
+//ValidationError describes why a request was rejected. Reason is a short,
+//stable code suitable for use as a metric label.
+type ValidationError struct {
+	Reason  string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+//ErrorReasonRecorder may be implemented by a ResponseWriter wrapper that
+//wants to know why a request was rejected
+type ErrorReasonRecorder interface {
+	RecordErrorReason(reason string)
+}
+
 //API exposes the API endpoints
 //go:generate counterfeiter . API
 type API interface {
 	SaveFeedback(w http.ResponseWriter, r *http.Request)
 	Health(w http.ResponseWriter, r *http.Request)
+	AdminHealth(w http.ResponseWriter, r *http.Request)
+
+	ModerationQueue(w http.ResponseWriter, r *http.Request)
+	Moderation(w http.ResponseWriter, r *http.Request)
+	AdminFeedback(w http.ResponseWriter, r *http.Request)
+	ListFeedback(w http.ResponseWriter, r *http.Request)
+	RiderData(w http.ResponseWriter, r *http.Request)
+	RetentionPolicies(w http.ResponseWriter, r *http.Request)
+	RetentionPolicy(w http.ResponseWriter, r *http.Request)
+	AuditEvents(w http.ResponseWriter, r *http.Request)
+	FeedbackKinds(w http.ResponseWriter, r *http.Request)
+	Survey(w http.ResponseWriter, r *http.Request)
+	AdminSurvey(w http.ResponseWriter, r *http.Request)
+	UploadAttachment(w http.ResponseWriter, r *http.Request)
+	Unsubscribe(w http.ResponseWriter, r *http.Request)
+	OutageResolved(w http.ResponseWriter, r *http.Request)
+	Attachment(w http.ResponseWriter, r *http.Request)
+	FeedbackBreakdown(w http.ResponseWriter, r *http.Request)
+	Threads(w http.ResponseWriter, r *http.Request)
+	Thread(w http.ResponseWriter, r *http.Request)
+	AdminThreads(w http.ResponseWriter, r *http.Request)
+	BulkTriage(w http.ResponseWriter, r *http.Request)
 }
 
 //Client implements API
 type Client struct {
-	log *logrus.Logger
-	db  db.DB
+	log     *logrus.Logger
+	db      db.DB
+	catalog kinds.Catalog
+	limiter ratelimit.Limiter
+	scorer  spam.Scorer
+
+	moderation *ModerationPolicy
+	policy     authz.Policy
+	auditor    audit.Recorder
+
+	redactor             *redact.Redactor
+	keepOriginalMessages bool
+
+	blobs            blob.Store
+	attachmentLimits attachment.Limits
+	attachmentSlots  chan struct{}
+
+	stops         gtfs.Resolver
+	clientHeaders clientinfo.Allowlist
+
+	unsubscriber *mailer.Unsubscriber
+
+	outageTTL time.Duration
 }
 
 //New returns a new Client
@@ -67,13 +159,61 @@ func New(
 	db db.DB,
 ) Client {
 	return Client{
-		log: log,
-		db:  db,
+		log:       log,
+		db:        db,
+		outageTTL: defaultOutageTTL,
 	}
 }
 
+//defaultOutageTTL is how long outage reports are listed for by default
+const defaultOutageTTL = 48 * time.Hour
+
+//WithOutageTTL returns a copy of c that lists outage reports received in the
+//last ttl in its health responses
+func (c Client) WithOutageTTL(ttl time.Duration) Client {
+	c.outageTTL = ttl
+	return c
+}
+
+//WithCatalog returns a copy of c that accepts the kinds and values of
+//feedback listed in catalog. Without one, no submission is valid.
+func (c Client) WithCatalog(catalog kinds.Catalog) Client {
+	c.catalog = catalog
+	return c
+}
+
+//WithRateLimiter returns a copy of c that enforces limiter on submissions
+func (c Client) WithRateLimiter(limiter ratelimit.Limiter) Client {
+	c.limiter = limiter
+	return c
+}
+
+//WithSpamScorer returns a copy of c that scores submissions with scorer
+//before saving them
+func (c Client) WithSpamScorer(scorer spam.Scorer) Client {
+	c.scorer = scorer
+	return c
+}
+
+//WithRedaction returns a copy of c that redacts personal data from messages
+//before saving them. When keepOriginal is set the unredacted text is stored
+//in a separate column readable only through the admin API.
+func (c Client) WithRedaction(redactor redact.Redactor, keepOriginal bool) Client {
+	c.redactor = &redactor
+	c.keepOriginalMessages = keepOriginal
+	return c
+}
+
+//logger returns the request-scoped logger, so that errors carry the request ID
+func (c Client) logger(ctx context.Context) *logrus.Entry {
+	return logging.FromContext(ctx, c.log)
+}
+
 var emailRegexp = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)
 
+//lineRegexp matches line names such as `red`, `gold` or `110`
+var lineRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9 _-]{0,31}$`)
+
 //SaveFeedback saves a feedback using information from the request body as well
 //as from headers forwarded by the API gateway.
 func (c Client) SaveFeedback(w http.ResponseWriter, r *http.Request) {
@@ -89,10 +229,16 @@ func (c Client) SaveFeedback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	_, span := tracing.StartSpan(r.Context(), "decode request")
 	var req SaveFeedbackRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
+	tracing.RecordError(span, err)
+	span.End()
 	if err != nil {
-		c.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("malformed JSON request body: %s", err.Error()))
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
 		return
 	}
 
@@ -101,65 +247,253 @@ func (c Client) SaveFeedback(w http.ResponseWriter, r *http.Request) {
 		Role:      role,
 	}
 
-	err = mapSaveFeedbackRequestFieldsOntoFeedback(&feedback, req)
+	err = c.mapSaveFeedbackRequestFieldsOntoFeedback(&feedback, req)
 	if err != nil {
-		c.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		c.writeValidationError(w, err)
 		return
 	}
 
+	feedback.Client, err = c.clientInfo(r.Header, req.Client)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
+	err = c.checkAttachments(r.Context(), session, req.Attachments)
+	var verr ValidationError
+	if errors.As(err, &verr) {
+		c.writeValidationError(w, err)
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to save feedback")
+		return
+	}
+	feedback.AttachmentIDs = req.Attachments
+
+	c.redactMessage(&feedback)
+
+	if c.limiter != nil {
+		decision, err := c.limiter.Allow(r.Context(), session, role, feedback.Kind)
+		if err != nil {
+			c.logger(r.Context()).Error(err.Error())
+		} else if !decision.Allowed {
+			retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			c.writeErrorResponse(w, http.StatusTooManyRequests, "too many submissions, try again later")
+			return
+		}
+	}
+
+	feedback.ModerationStatus = c.initialModerationStatus(feedback.Kind, role)
+
+	var resp SaveFeedbackResponse
+	resp.EmailUnsubscribed, err = c.emailUnsubscribed(r.Context(), feedback)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+	}
+	if resp.EmailUnsubscribed {
+		feedback.Acknowledge = false
+	}
+
+	if c.scorer != nil {
+		verdict, err := c.scorer.Evaluate(r.Context(), feedback)
+		if err != nil {
+			c.logger(r.Context()).Error(err.Error())
+		} else {
+			verdict.Apply(&feedback)
+		}
+	}
+
 	err = c.db.SaveFeedback(r.Context(), feedback)
 	if err != nil {
-		c.log.Error(err.Error())
+		c.logger(r.Context()).Error(err.Error())
 		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to save feedback")
 		return
 	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
 }
 
-func mapSaveFeedbackRequestFieldsOntoFeedback(feedback *db.Feedback, req SaveFeedbackRequest) (err error) {
+//emailUnsubscribed reports whether feedback opts in to email at an address
+//that has unsubscribed. Opting in again doesn't undo an unsubscribe, since
+//anyone can submit feedback with any address.
+func (c Client) emailUnsubscribed(ctx context.Context, feedback db.Feedback) (bool, error) {
+	if c.unsubscriber == nil || !feedback.EmailOptIn {
+		return false, nil
+	}
+	return c.db.IsEmailUnsubscribed(ctx, c.unsubscriber.Digest(*feedback.Email))
+}
+
+func (c Client) mapSaveFeedbackRequestFieldsOntoFeedback(feedback *db.Feedback, req SaveFeedbackRequest) (err error) {
 	req.Kind = strings.ToLower(req.Kind)
 	req.Value = strings.ToLower(req.Value)
 
-	if _, ok := ValidKinds[req.Kind]; !ok {
-		err = fmt.Errorf("invalid value `%s` for `kind`", req.Kind)
+	kind, ok := c.findKind(req.Kind)
+	if !ok || !kind.Active {
+		err = ValidationError{
+			Reason:  "invalid_kind",
+			Message: fmt.Sprintf("invalid value `%s` for `kind`", req.Kind),
+		}
 		return
 	}
 	feedback.Kind = req.Kind
 
 	if req.Value != "" {
-		if _, ok := ValidValues[req.Value]; !ok {
-			err = fmt.Errorf("invalid value `%s` for `value`", req.Value)
+		value, ok := c.findValue(req.Value)
+		if !ok || !value.Active {
+			err = ValidationError{
+				Reason:  "invalid_value",
+				Message: fmt.Sprintf("invalid value `%s` for `value`", req.Value),
+			}
 			return
 		}
 		feedback.Value = &req.Value
+	} else if kind.ValueRequired {
+		err = missingFieldError("value", kind.Name)
+		return
+	}
+
+	if req.Email == "" && kind.EmailRequired {
+		err = missingFieldError("email", kind.Name)
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" && kind.MessageRequired {
+		err = missingFieldError("message", kind.Name)
+		return
 	}
 
 	if req.Email != "" {
 		if !emailRegexp.Match([]byte(req.Email)) {
-			err = fmt.Errorf("invalid value `%s` for `email`", req.Email)
+			err = ValidationError{
+				Reason:  "invalid_email",
+				Message: fmt.Sprintf("invalid value `%s` for `email`", req.Email),
+			}
 			return
 		}
 		feedback.Email = &req.Email
 	}
 
+	if req.EmailOptIn {
+		if feedback.Email == nil {
+			err = ValidationError{
+				Reason:  "invalid_email_opt_in",
+				Message: "`email_opt_in` requires an `email`",
+			}
+			return
+		}
+		feedback.EmailOptIn = true
+		feedback.Acknowledge = c.unsubscriber != nil
+	}
+
+	if req.Line != "" {
+		line := strings.ToLower(strings.TrimSpace(req.Line))
+		if !lineRegexp.MatchString(line) {
+			err = ValidationError{
+				Reason:  "invalid_line",
+				Message: fmt.Sprintf("invalid value `%s` for `line`", req.Line),
+			}
+			return
+		}
+		feedback.Line = &line
+	}
+
 	if req.Message != "" {
 		feedback.Message = &req.Message
 	}
 
-	return nil
+	feedback.Location, err = c.locate(req)
+	if err != nil {
+		return
+	}
+
+	feedback.Details, err = c.validateDetails(kind, req.Details)
+	return err
 }
 
+func missingFieldError(field, kind string) ValidationError {
+	return ValidationError{
+		Reason:  "missing_" + field,
+		Message: fmt.Sprintf("`%s` is required for `%s` feedback", field, kind),
+	}
+}
+
+func (c Client) redactMessage(feedback *db.Feedback) {
+	if c.redactor == nil || feedback.Message == nil {
+		return
+	}
+
+	redacted, kinds := c.redactor.Redact(*feedback.Message)
+	if len(kinds) == 0 {
+		return
+	}
+
+	if c.keepOriginalMessages {
+		original := *feedback.Message
+		feedback.MessageOriginal = &original
+	}
+
+	found := strings.Join(kinds, ",")
+	feedback.Message = &redacted
+	feedback.RedactedPII = &found
+}
+
+//Outage metadata versions. Version 1 lists individual reports, and is only
+//served, by default, on the admin health endpoint. Version 2 counts reports
+//per line and hour, and is all the public health endpoint serves, since
+//report IDs and times could be correlated with riders. Message text is only
+//included on the admin health endpoint.
+const (
+	outageMetadataV1 = 1
+	outageMetadataV2 = 2
+)
+
 type outageReportMetadata struct {
+	Version int            `json:"version"`
 	Outages []outageReport `json:"outages"`
 }
 
 type outageReport struct {
 	ID         string    `json:"id"`
+	Line       *string   `json:"line,omitempty"`
 	Message    *string   `json:"message,omitempty"`
 	ReceivedAt time.Time `json:"received_at"`
 }
 
-//Health responds with a variety of internal statuses
+type outageSummaryMetadata struct {
+	Version int            `json:"version"`
+	Counts  []outageCount  `json:"counts"`
+	Outages []outageReport `json:"outages,omitempty"`
+}
+
+type outageCount struct {
+	Line  string    `json:"line"`
+	Hour  time.Time `json:"hour"`
+	Count int       `json:"count"`
+}
+
+//unknownLine groups outage reports that didn't name a line
+const unknownLine = "unknown"
+
+//Health responds with a variety of internal statuses. Outage reports are
+//listed without rider-provided text.
 func (c Client) Health(w http.ResponseWriter, r *http.Request) {
+	c.health(w, r, false)
+}
+
+//AdminHealth is Health including the full detail of each outage report
+func (c Client) AdminHealth(w http.ResponseWriter, r *http.Request) {
+	c.health(w, r, true)
+}
+
+func (c Client) health(w http.ResponseWriter, r *http.Request, detailed bool) {
+	version, err := parseMetadataVersion(r)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
 	var statuses []Status
 	defer func() {
 		if len(statuses) == 0 {
@@ -173,9 +507,9 @@ func (c Client) Health(w http.ResponseWriter, r *http.Request) {
 		c.writeJSONResponse(w, http.StatusOK, HealthResponse{Statuses: statuses})
 	}()
 
-	outageReports, err := c.db.GetRecentOutages(r.Context(), time.Now().Add(-48*time.Hour))
+	outageReports, err := c.db.GetRecentOutages(r.Context(), time.Now().Add(-c.outageTTL))
 	if err != nil {
-		c.log.Error(err.Error())
+		c.logger(r.Context()).Error(err.Error())
 		return
 	}
 
@@ -185,10 +519,24 @@ func (c Client) Health(w http.ResponseWriter, r *http.Request) {
 		Healthy:     true,
 	})
 
-	statuses = append(statuses, reportStatusFromFeedbackList(outageReports))
+	statuses = append(statuses, reportStatusFromFeedbackList(outageReports, version, detailed))
 }
 
-func reportStatusFromFeedbackList(outageReports []db.Feedback) (st Status) {
+//parseMetadataVersion reads the `metadata_version` query parameter
+func parseMetadataVersion(r *http.Request) (int, error) {
+	switch r.URL.Query().Get("metadata_version") {
+	case "", "1":
+		return outageMetadataV1, nil
+	case "2":
+		return outageMetadataV2, nil
+	}
+	return 0, ValidationError{
+		Reason:  "invalid_metadata_version",
+		Message: "`metadata_version` must be 1 or 2",
+	}
+}
+
+func reportStatusFromFeedbackList(outageReports []db.Feedback, version int, detailed bool) (st Status) {
 	st.Name = "user_outage_reports"
 	st.Description = "outage reports directly from users"
 	if len(outageReports) == 0 {
@@ -198,24 +546,80 @@ func reportStatusFromFeedbackList(outageReports []db.Feedback) (st Status) {
 
 	repList := []outageReport{}
 	for _, rep := range outageReports {
-		repList = append(repList, outageReport{
+		report := outageReport{
 			ID:         rep.ID,
-			Message:    rep.Message,
+			Line:       rep.Line,
 			ReceivedAt: rep.ReceivedAt,
-		})
+		}
+		if detailed {
+			report.Message = rep.Message
+		}
+		repList = append(repList, report)
+	}
+
+	if version == outageMetadataV1 {
+		st.Metadata = outageReportMetadata{
+			Version: outageMetadataV1,
+			Outages: repList,
+		}
+		return
 	}
 
-	st.Metadata = outageReportMetadata{
-		Outages: repList,
+	meta := outageSummaryMetadata{
+		Version: outageMetadataV2,
+		Counts:  countOutagesByLineAndHour(outageReports),
+	}
+	if detailed {
+		meta.Outages = repList
 	}
+	st.Metadata = meta
 	return
 }
 
+//countOutagesByLineAndHour aggregates reports by line and the UTC hour they
+//were received in, ordered by hour and then line
+func countOutagesByLineAndHour(outageReports []db.Feedback) []outageCount {
+	type bucket struct {
+		line string
+		hour time.Time
+	}
+
+	counts := map[bucket]int{}
+	for _, rep := range outageReports {
+		b := bucket{line: unknownLine, hour: rep.ReceivedAt.UTC().Truncate(time.Hour)}
+		if rep.Line != nil {
+			b.line = *rep.Line
+		}
+		counts[b]++
+	}
+
+	result := make([]outageCount, 0, len(counts))
+	for b, n := range counts {
+		result = append(result, outageCount{Line: b.line, Hour: b.hour, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Hour.Equal(result[j].Hour) {
+			return result[i].Hour.Before(result[j].Hour)
+		}
+		return result[i].Line < result[j].Line
+	})
+	return result
+}
+
 type errResp struct {
 	Status  int    `json:"status"`
 	Message string `json:"message"`
 }
 
+func (c Client) writeValidationError(w http.ResponseWriter, err error) {
+	var verr ValidationError
+	if rec, ok := w.(ErrorReasonRecorder); ok && errors.As(err, &verr) {
+		rec.RecordErrorReason(verr.Reason)
+	}
+
+	c.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+}
+
 func (c Client) writeErrorResponse(w http.ResponseWriter, status int, errMsg string) {
 	c.writeJSONResponse(w, status, errResp{
 		Status:  status,
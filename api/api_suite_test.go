@@ -1,8 +1,15 @@
 package api_test
 
 import (
+	"context"
 	"testing"
 
+	"github.com/smartatransit/feedback/authz"
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/kinds/kindsfakes"
+	"github.com/smartatransit/feedback/schema"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -11,3 +18,60 @@ func TestApi(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Api Suite")
 }
+
+//newFakeDB returns a fake whose transactions run their function
+func newFakeDB() *dbfakes.FakeDB {
+	database := &dbfakes.FakeDB{}
+	database.InTransactionStub = func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}
+	return database
+}
+
+//testPolicy grants admin every permission and auditor read and export only
+func testPolicy() authz.Policy {
+	return authz.NewPolicy().
+		Grant("admin", authz.Permissions...).
+		Grant("auditor", authz.Read, authz.Export)
+}
+
+//serviceConditionSchema describes the details of service_condition feedback
+const serviceConditionSchema = `{
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {
+		"crowding": {"type": "string", "enum": ["empty", "seats_available", "standing_room", "full"]},
+		"cleanliness": {"type": "integer", "minimum": 1, "maximum": 5},
+		"vehicle": {"type": "object", "properties": {"accessible": {"type": "boolean"}}}
+	}
+}`
+
+//testCatalog lists the kinds and values feedback had before they were
+//data-driven, plus a retired kind and a kind requiring every field.
+//service_condition feedback may carry details.
+func testCatalog() *kindsfakes.FakeCatalog {
+	detailsSchema, err := schema.Parse([]byte(serviceConditionSchema))
+	Expect(err).To(BeNil())
+
+	catalog := &kindsfakes.FakeCatalog{}
+	catalog.DetailsSchemaStub = func(kind string) *schema.Schema {
+		if kind == "service_condition" {
+			return detailsSchema
+		}
+		return nil
+	}
+	catalog.KindsReturns([]db.FeedbackKind{
+		{Name: "outage", Label: "Outage", Active: true},
+		{Name: "service_condition", Label: "Service condition", Active: true, DetailsSchema: []byte(serviceConditionSchema)},
+		{Name: "comment", Label: "Comment", Active: true},
+		{Name: "complaint", Label: "Complaint", Active: true, ValueRequired: true, MessageRequired: true, EmailRequired: true},
+		{Name: "suggestion", Label: "Suggestion", Active: false},
+	})
+	catalog.ValuesReturns([]db.FeedbackValue{
+		{Name: "positive", Label: "Positive", Active: true},
+		{Name: "neutral", Label: "Neutral", Active: true},
+		{Name: "negative", Label: "Negative", Active: true},
+		{Name: "meh", Label: "Meh", Active: false},
+	})
+	return catalog
+}
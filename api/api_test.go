@@ -7,13 +7,22 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/authz"
 	dbp "github.com/smartatransit/feedback/db"
 	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/gtfs"
+	"github.com/smartatransit/feedback/gtfs/gtfsfakes"
+	"github.com/smartatransit/feedback/ratelimit"
+	"github.com/smartatransit/feedback/ratelimit/ratelimitfakes"
+	"github.com/smartatransit/feedback/redact"
+	"github.com/smartatransit/feedback/spam"
+	"github.com/smartatransit/feedback/spam/spamfakes"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -22,8 +31,13 @@ import (
 
 var _ = Describe("API", func() {
 	var (
-		log *logrus.Logger
-		db  *dbfakes.FakeDB
+		log     *logrus.Logger
+		db      *dbfakes.FakeDB
+		limiter *ratelimitfakes.FakeLimiter
+		scorer  *spamfakes.FakeScorer
+		stops   *gtfsfakes.FakeResolver
+
+		keepOriginal bool
 
 		client api.Client
 
@@ -39,7 +53,13 @@ var _ = Describe("API", func() {
 	BeforeEach(func() {
 		log = logrus.New()
 		log.SetOutput(ioutil.Discard)
-		db = &dbfakes.FakeDB{}
+		db = newFakeDB()
+		limiter = &ratelimitfakes.FakeLimiter{}
+		limiter.AllowReturns(ratelimit.Decision{Allowed: true}, nil)
+		scorer = &spamfakes.FakeScorer{}
+		scorer.EvaluateReturns(spam.Verdict{Action: spam.ActionNone}, nil)
+		stops = &gtfsfakes.FakeResolver{}
+		keepOriginal = false
 
 		body = nil
 		bodyBytes = nil
@@ -48,7 +68,14 @@ var _ = Describe("API", func() {
 	})
 
 	JustBeforeEach(func() {
-		client = api.New(log, db)
+		client = api.New(log, db).
+			WithRateLimiter(limiter).
+			WithSpamScorer(scorer).
+			WithModerationPolicy(api.NewModerationPolicy([]string{"outage"}, []string{"staff"})).
+			WithAuthorization(testPolicy()).
+			WithCatalog(testCatalog()).
+			WithStopResolver(stops).
+			WithRedaction(redact.New(), keepOriginal)
 
 		if body != nil {
 			var err error
@@ -111,6 +138,48 @@ var _ = Describe("API", func() {
 				Expect(resp.StatusCode).To(BeEquivalentTo(400))
 			})
 		})
+		When("the kind is inactive", func() {
+			BeforeEach(func() {
+				body.(*api.SaveFeedbackRequest).Kind = "suggestion"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				Expect(db.SaveFeedbackCallCount()).To(Equal(0))
+			})
+		})
+		When("the value is inactive", func() {
+			BeforeEach(func() {
+				body.(*api.SaveFeedbackRequest).Value = "meh"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("the kind requires fields that are missing", func() {
+			BeforeEach(func() {
+				body.(*api.SaveFeedbackRequest).Kind = "complaint"
+				body.(*api.SaveFeedbackRequest).Message = "  "
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+
+				respBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).To(BeNil())
+				Expect(respBody).To(MatchJSON(`{
+					"status": 400,
+					"message": "` + "`message` is required for `complaint` feedback" + `"
+				}`))
+			})
+		})
+		When("the kind requires fields that are present", func() {
+			BeforeEach(func() {
+				body.(*api.SaveFeedbackRequest).Kind = "complaint"
+			})
+			It("succeeds", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+				Expect(db.SaveFeedbackCallCount()).To(Equal(1))
+			})
+		})
 		When("the value is provided and invalid", func() {
 			BeforeEach(func() {
 				body.(*api.SaveFeedbackRequest).Value = "sdf"
@@ -127,6 +196,237 @@ var _ = Describe("API", func() {
 				Expect(resp.StatusCode).To(BeEquivalentTo(400))
 			})
 		})
+		When("the session is rate limited", func() {
+			BeforeEach(func() {
+				limiter.AllowReturns(ratelimit.Decision{RetryAfter: 90500 * time.Millisecond}, nil)
+			})
+			It("fails with a retry hint", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(429))
+				Expect(resp.Header.Get("Retry-After")).To(Equal("91"))
+				Expect(db.SaveFeedbackCallCount()).To(Equal(0))
+
+				_, session, role, kind := limiter.AllowArgsForCall(0)
+				Expect(session).To(Equal("r39iefjd0q39f"))
+				Expect(role).To(Equal("anonymous"))
+				Expect(kind).To(Equal("outage"))
+			})
+		})
+		When("the rate limiter fails", func() {
+			BeforeEach(func() {
+				limiter.AllowReturns(ratelimit.Decision{}, errors.New("select failed"))
+			})
+			It("lets the submission through", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+				Expect(db.SaveFeedbackCallCount()).To(Equal(1))
+			})
+		})
+		When("the kind requires moderation", func() {
+			BeforeEach(func() {
+				body.(*api.SaveFeedbackRequest).Kind = "comment"
+			})
+			It("holds it for review", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, fb := db.SaveFeedbackArgsForCall(0)
+				Expect(fb.ModerationStatus).To(Equal("pending"))
+			})
+
+			When("the role bypasses moderation", func() {
+				BeforeEach(func() {
+					req.Header.Set("X-Smarta-Auth-Role", "staff")
+				})
+				It("approves it", func() {
+					_, fb := db.SaveFeedbackArgsForCall(0)
+					Expect(fb.ModerationStatus).To(Equal("approved"))
+				})
+			})
+		})
+		When("the line is invalid", func() {
+			BeforeEach(func() {
+				body.(*api.SaveFeedbackRequest).Line = "red; drop table"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("a line is provided", func() {
+			BeforeEach(func() {
+				body.(*api.SaveFeedbackRequest).Line = " Red "
+			})
+			It("normalizes it", func() {
+				_, fb := db.SaveFeedbackArgsForCall(0)
+				Expect(fb.Line).To(PointTo(Equal("red")))
+			})
+		})
+		When("a location is provided", func() {
+			BeforeEach(func() {
+				lat, lon, accuracy := 33.75975, -84.38735, 15.0
+				body.(*api.SaveFeedbackRequest).Latitude = &lat
+				body.(*api.SaveFeedbackRequest).Longitude = &lon
+				body.(*api.SaveFeedbackRequest).Accuracy = &accuracy
+
+				stops.NearestReturns(gtfs.Match{
+					Stop:     gtfs.Stop{ID: "PEACHTREE", Name: "PEACHTREE CENTER STATION"},
+					Distance: 7.4,
+				}, true)
+			})
+			It("stores it with the nearest stop", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				lat, lon := stops.NearestArgsForCall(0)
+				Expect(lat).To(Equal(33.75975))
+				Expect(lon).To(Equal(-84.38735))
+
+				_, fb := db.SaveFeedbackArgsForCall(0)
+				Expect(fb.Location).To(PointTo(MatchAllFields(Fields{
+					"Latitude":     Equal(33.75975),
+					"Longitude":    Equal(-84.38735),
+					"Accuracy":     PointTo(Equal(15.0)),
+					"StopID":       PointTo(Equal("PEACHTREE")),
+					"StopName":     PointTo(Equal("PEACHTREE CENTER STATION")),
+					"StopDistance": PointTo(Equal(7.0)),
+				})))
+			})
+
+			When("no stop is near enough", func() {
+				BeforeEach(func() {
+					stops.NearestReturns(gtfs.Match{}, false)
+				})
+				It("stores just the location", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+					_, fb := db.SaveFeedbackArgsForCall(0)
+					Expect(fb.Location.Latitude).To(Equal(33.75975))
+					Expect(fb.Location.StopID).To(BeNil())
+				})
+			})
+			When("the longitude is missing", func() {
+				BeforeEach(func() {
+					body.(*api.SaveFeedbackRequest).Longitude = nil
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+					Expect(db.SaveFeedbackCallCount()).To(Equal(0))
+				})
+			})
+			When("the latitude is out of range", func() {
+				BeforeEach(func() {
+					lat := 133.7
+					body.(*api.SaveFeedbackRequest).Latitude = &lat
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+					Expect(stops.NearestCallCount()).To(Equal(0))
+				})
+			})
+		})
+		When("details are provided", func() {
+			BeforeEach(func() {
+				body.(*api.SaveFeedbackRequest).Kind = "service_condition"
+				body.(*api.SaveFeedbackRequest).Details = json.RawMessage(`{"crowding": "full", "cleanliness": 2}`)
+			})
+			It("saves them", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, fb := db.SaveFeedbackArgsForCall(0)
+				Expect(fb.Details).To(MatchJSON(`{"crowding": "full", "cleanliness": 2}`))
+			})
+
+			When("they don't match the kind's schema", func() {
+				BeforeEach(func() {
+					body.(*api.SaveFeedbackRequest).Details = json.RawMessage(`{"crowding": "packed"}`)
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+
+					body, err := ioutil.ReadAll(resp.Body)
+					Expect(err).To(BeNil())
+					Expect(string(body)).To(ContainSubstring("invalid `details`: `crowding` value must be one of"))
+					Expect(db.SaveFeedbackCallCount()).To(Equal(0))
+				})
+			})
+			When("they aren't an object", func() {
+				BeforeEach(func() {
+					body.(*api.SaveFeedbackRequest).Details = json.RawMessage(`[1, 2]`)
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				})
+			})
+			When("the kind has no details schema", func() {
+				BeforeEach(func() {
+					body.(*api.SaveFeedbackRequest).Kind = "comment"
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				})
+			})
+			When("they are null", func() {
+				BeforeEach(func() {
+					body.(*api.SaveFeedbackRequest).Kind = "comment"
+					body.(*api.SaveFeedbackRequest).Details = json.RawMessage(`null`)
+				})
+				It("ignores them", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+					_, fb := db.SaveFeedbackArgsForCall(0)
+					Expect(fb.Details).To(BeNil())
+				})
+			})
+		})
+		When("the message contains personal data", func() {
+			BeforeEach(func() {
+				body.(*api.SaveFeedbackRequest).Message = "call me at (404) 555-0123 or me@example.com"
+			})
+			It("redacts it before saving", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, fb := db.SaveFeedbackArgsForCall(0)
+				Expect(fb.Message).To(PointTo(Equal("call me at [phone] or [email]")))
+				Expect(fb.RedactedPII).To(PointTo(Equal("email,phone")))
+				Expect(fb.MessageOriginal).To(BeNil())
+
+				_, scored := scorer.EvaluateArgsForCall(0)
+				Expect(scored.Message).To(PointTo(Equal("call me at [phone] or [email]")))
+			})
+			When("originals are kept", func() {
+				BeforeEach(func() {
+					keepOriginal = true
+				})
+				It("saves the unredacted message separately", func() {
+					_, fb := db.SaveFeedbackArgsForCall(0)
+					Expect(fb.MessageOriginal).To(PointTo(Equal("call me at (404) 555-0123 or me@example.com")))
+				})
+			})
+		})
+		When("the submission looks like spam", func() {
+			BeforeEach(func() {
+				scorer.EvaluateReturns(spam.Verdict{
+					Score:   1.5,
+					Reasons: []string{"2 blocklisted terms"},
+					Action:  spam.ActionHold,
+				}, nil)
+			})
+			It("holds it rather than rejecting it", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, fb := db.SaveFeedbackArgsForCall(0)
+				Expect(fb.ModerationStatus).To(Equal("pending"))
+				Expect(fb.SpamScore).To(BeEquivalentTo(1.5))
+				Expect(fb.SpamReason).To(PointTo(Equal("2 blocklisted terms")))
+			})
+		})
+		When("spam scoring fails", func() {
+			BeforeEach(func() {
+				scorer.EvaluateReturns(spam.Verdict{}, errors.New("select failed"))
+			})
+			It("saves the submission unscored", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, fb := db.SaveFeedbackArgsForCall(0)
+				Expect(fb.ModerationStatus).To(Equal("approved"))
+			})
+		})
 		When("the database update fails", func() {
 			BeforeEach(func() {
 				db.SaveFeedbackReturns(errors.New("insert failed"))
@@ -151,6 +451,91 @@ var _ = Describe("API", func() {
 					"Message":   PointTo(Equal("my message")),
 					"Value":     PointTo(Equal("positive")),
 					"Email":     PointTo(Equal("user@notsmarta.net")),
+
+					"ModerationStatus": Equal("approved"),
+					"SpamScore":        BeZero(),
+					"SpamReason":       BeNil(),
+
+					"MessageOriginal": BeNil(),
+					"RedactedPII":     BeNil(),
+					"Line":            BeNil(),
+					"Details":         BeNil(),
+					"AttachmentIDs":   BeNil(),
+					"Location":        BeNil(),
+					"Client":          BeZero(),
+					"EmailOptIn":      BeFalse(),
+					"Acknowledge":     BeFalse(),
+					"Triage":          BeZero(),
+				}))
+			})
+		})
+	})
+
+	Describe("AdminHealth", func() {
+		BeforeEach(func() {
+			req.Method = "GET"
+			req.URL = &url.URL{RawQuery: "metadata_version=2"}
+			req.Header.Set("X-Smarta-Auth-Session", "admin-session")
+			req.Header.Set("X-Smarta-Auth-Role", "admin")
+			db.GetRecentOutagesReturns([]dbp.Feedback{{
+				ID:         "fweawf",
+				Message:    ptrToString("aasdfasdf"),
+				ReceivedAt: time.Now(),
+			}}, nil)
+		})
+
+		JustBeforeEach(func() {
+			client.Require(authz.Read, client.AdminHealth)(respW, req)
+			resp = respW.Result()
+		})
+
+		When("the role lacks the permission", func() {
+			BeforeEach(func() {
+				req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(403))
+				Expect(db.GetRecentOutagesCallCount()).To(Equal(0))
+
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).To(BeNil())
+				Expect(body).To(MatchJSON(`{
+					"status": 403,
+					"message": "role ` + "`anonymous`" + ` lacks the ` + "`read`" + ` permission"
+				}`))
+			})
+		})
+		When("all goes well", func() {
+			It("includes report messages", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+				var respObj api.HealthResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+				Expect(respObj.Statuses[1].Metadata).To(MatchAllKeys(Keys{
+					"version": BeEquivalentTo(2),
+					"counts":  HaveLen(1),
+					"outages": ConsistOf(MatchAllKeys(Keys{
+						"id":          Equal("fweawf"),
+						"message":     Equal("aasdfasdf"),
+						"received_at": Ignore(),
+					})),
+				}))
+			})
+		})
+		When("no metadata version is requested", func() {
+			BeforeEach(func() {
+				req.URL = &url.URL{}
+			})
+			It("lists each report", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+				var respObj api.HealthResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+				Expect(respObj.Statuses[1].Metadata).To(MatchAllKeys(Keys{
+					"version": BeEquivalentTo(1),
+					"outages": ConsistOf(MatchAllKeys(Keys{
+						"id":          Equal("fweawf"),
+						"message":     Equal("aasdfasdf"),
+						"received_at": Ignore(),
+					})),
 				}))
 			})
 		})
@@ -190,10 +575,11 @@ var _ = Describe("API", func() {
 		When("there are recent outage reports", func() {
 			var t time.Time
 			BeforeEach(func() {
-				t = time.Now()
+				t = time.Date(2020, 3, 1, 14, 10, 0, 0, time.UTC)
 				db.GetRecentOutagesReturns([]dbp.Feedback{
 					{
 						ID:         "fweawf",
+						Line:       ptrToString("red"),
 						Message:    ptrToString("aasdfasdf"),
 						ReceivedAt: t,
 					},
@@ -202,9 +588,14 @@ var _ = Describe("API", func() {
 						Message:    ptrToString("aasdfasdf-2"),
 						ReceivedAt: t.Add(time.Hour),
 					},
+					{
+						ID:         "fweawf-3",
+						Line:       ptrToString("red"),
+						ReceivedAt: t.Add(20 * time.Minute),
+					},
 				}, nil)
 			})
-			It("succeeds", func() {
+			It("lists them without their messages", func() {
 				Expect(resp.StatusCode).To(BeEquivalentTo(200))
 				var respObj api.HealthResponse
 				err := json.NewDecoder(resp.Body).Decode(&respObj)
@@ -222,15 +613,20 @@ var _ = Describe("API", func() {
 							"Description": Equal("outage reports directly from users"),
 							"Healthy":     BeFalse(),
 							"Metadata": MatchAllKeys(Keys{
+								"version": BeEquivalentTo(1),
 								"outages": ConsistOf(
 									MatchAllKeys(Keys{
 										"id":          Equal("fweawf"),
-										"message":     Equal("aasdfasdf"),
+										"line":        Equal("red"),
 										"received_at": Ignore(),
 									}),
 									MatchAllKeys(Keys{
 										"id":          Equal("fweawf-2"),
-										"message":     Equal("aasdfasdf-2"),
+										"received_at": Ignore(),
+									}),
+									MatchAllKeys(Keys{
+										"id":          Equal("fweawf-3"),
+										"line":        Equal("red"),
 										"received_at": Ignore(),
 									}),
 								),
@@ -239,6 +635,35 @@ var _ = Describe("API", func() {
 					),
 				}))
 			})
+			It("looks back 48 hours", func() {
+				Expect(db.GetRecentOutagesCallCount()).To(Equal(1))
+				_, since := db.GetRecentOutagesArgsForCall(0)
+				Expect(since).To(BeTemporally("~", time.Now().Add(-48*time.Hour), time.Minute))
+			})
+			When("version 2 metadata is requested", func() {
+				BeforeEach(func() {
+					req.URL = &url.URL{RawQuery: "metadata_version=2"}
+				})
+				It("returns counts per line and hour", func() {
+					var respObj api.HealthResponse
+					Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+					Expect(respObj.Statuses[1].Metadata).To(MatchAllKeys(Keys{
+						"version": BeEquivalentTo(2),
+						"counts": Equal([]interface{}{
+							map[string]interface{}{"line": "red", "hour": "2020-03-01T14:00:00Z", "count": float64(2)},
+							map[string]interface{}{"line": "unknown", "hour": "2020-03-01T15:00:00Z", "count": float64(1)},
+						}),
+					}))
+				})
+			})
+			When("an unknown metadata version is requested", func() {
+				BeforeEach(func() {
+					req.URL = &url.URL{RawQuery: "metadata_version=7"}
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				})
+			})
 		})
 		When("there are no recent outage reports", func() {
 			It("succeeds", func() {
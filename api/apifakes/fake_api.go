@@ -9,22 +9,436 @@ import (
 )
 
 type FakeAPI struct {
+	AdminFeedbackStub        func(http.ResponseWriter, *http.Request)
+	adminFeedbackMutex       sync.RWMutex
+	adminFeedbackArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	AdminHealthStub        func(http.ResponseWriter, *http.Request)
+	adminHealthMutex       sync.RWMutex
+	adminHealthArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	AdminSurveyStub        func(http.ResponseWriter, *http.Request)
+	adminSurveyMutex       sync.RWMutex
+	adminSurveyArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	AdminThreadsStub        func(http.ResponseWriter, *http.Request)
+	adminThreadsMutex       sync.RWMutex
+	adminThreadsArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	AttachmentStub        func(http.ResponseWriter, *http.Request)
+	attachmentMutex       sync.RWMutex
+	attachmentArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	AuditEventsStub        func(http.ResponseWriter, *http.Request)
+	auditEventsMutex       sync.RWMutex
+	auditEventsArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	BulkTriageStub        func(http.ResponseWriter, *http.Request)
+	bulkTriageMutex       sync.RWMutex
+	bulkTriageArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	FeedbackBreakdownStub        func(http.ResponseWriter, *http.Request)
+	feedbackBreakdownMutex       sync.RWMutex
+	feedbackBreakdownArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	FeedbackKindsStub        func(http.ResponseWriter, *http.Request)
+	feedbackKindsMutex       sync.RWMutex
+	feedbackKindsArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
 	HealthStub        func(http.ResponseWriter, *http.Request)
 	healthMutex       sync.RWMutex
 	healthArgsForCall []struct {
 		arg1 http.ResponseWriter
 		arg2 *http.Request
 	}
+	ListFeedbackStub        func(http.ResponseWriter, *http.Request)
+	listFeedbackMutex       sync.RWMutex
+	listFeedbackArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	ModerationStub        func(http.ResponseWriter, *http.Request)
+	moderationMutex       sync.RWMutex
+	moderationArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	ModerationQueueStub        func(http.ResponseWriter, *http.Request)
+	moderationQueueMutex       sync.RWMutex
+	moderationQueueArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	OutageResolvedStub        func(http.ResponseWriter, *http.Request)
+	outageResolvedMutex       sync.RWMutex
+	outageResolvedArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	RetentionPoliciesStub        func(http.ResponseWriter, *http.Request)
+	retentionPoliciesMutex       sync.RWMutex
+	retentionPoliciesArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	RetentionPolicyStub        func(http.ResponseWriter, *http.Request)
+	retentionPolicyMutex       sync.RWMutex
+	retentionPolicyArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	RiderDataStub        func(http.ResponseWriter, *http.Request)
+	riderDataMutex       sync.RWMutex
+	riderDataArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
 	SaveFeedbackStub        func(http.ResponseWriter, *http.Request)
 	saveFeedbackMutex       sync.RWMutex
 	saveFeedbackArgsForCall []struct {
 		arg1 http.ResponseWriter
 		arg2 *http.Request
 	}
+	SurveyStub        func(http.ResponseWriter, *http.Request)
+	surveyMutex       sync.RWMutex
+	surveyArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	ThreadStub        func(http.ResponseWriter, *http.Request)
+	threadMutex       sync.RWMutex
+	threadArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	ThreadsStub        func(http.ResponseWriter, *http.Request)
+	threadsMutex       sync.RWMutex
+	threadsArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	UnsubscribeStub        func(http.ResponseWriter, *http.Request)
+	unsubscribeMutex       sync.RWMutex
+	unsubscribeArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
+	UploadAttachmentStub        func(http.ResponseWriter, *http.Request)
+	uploadAttachmentMutex       sync.RWMutex
+	uploadAttachmentArgsForCall []struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeAPI) AdminFeedback(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.adminFeedbackMutex.Lock()
+	fake.adminFeedbackArgsForCall = append(fake.adminFeedbackArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("AdminFeedback", []interface{}{arg1, arg2})
+	fake.adminFeedbackMutex.Unlock()
+	if fake.AdminFeedbackStub != nil {
+		fake.AdminFeedbackStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) AdminFeedbackCallCount() int {
+	fake.adminFeedbackMutex.RLock()
+	defer fake.adminFeedbackMutex.RUnlock()
+	return len(fake.adminFeedbackArgsForCall)
+}
+
+func (fake *FakeAPI) AdminFeedbackCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.adminFeedbackMutex.Lock()
+	defer fake.adminFeedbackMutex.Unlock()
+	fake.AdminFeedbackStub = stub
+}
+
+func (fake *FakeAPI) AdminFeedbackArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.adminFeedbackMutex.RLock()
+	defer fake.adminFeedbackMutex.RUnlock()
+	argsForCall := fake.adminFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) AdminHealth(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.adminHealthMutex.Lock()
+	fake.adminHealthArgsForCall = append(fake.adminHealthArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("AdminHealth", []interface{}{arg1, arg2})
+	fake.adminHealthMutex.Unlock()
+	if fake.AdminHealthStub != nil {
+		fake.AdminHealthStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) AdminHealthCallCount() int {
+	fake.adminHealthMutex.RLock()
+	defer fake.adminHealthMutex.RUnlock()
+	return len(fake.adminHealthArgsForCall)
+}
+
+func (fake *FakeAPI) AdminHealthCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.adminHealthMutex.Lock()
+	defer fake.adminHealthMutex.Unlock()
+	fake.AdminHealthStub = stub
+}
+
+func (fake *FakeAPI) AdminHealthArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.adminHealthMutex.RLock()
+	defer fake.adminHealthMutex.RUnlock()
+	argsForCall := fake.adminHealthArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) AdminSurvey(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.adminSurveyMutex.Lock()
+	fake.adminSurveyArgsForCall = append(fake.adminSurveyArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("AdminSurvey", []interface{}{arg1, arg2})
+	fake.adminSurveyMutex.Unlock()
+	if fake.AdminSurveyStub != nil {
+		fake.AdminSurveyStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) AdminSurveyCallCount() int {
+	fake.adminSurveyMutex.RLock()
+	defer fake.adminSurveyMutex.RUnlock()
+	return len(fake.adminSurveyArgsForCall)
+}
+
+func (fake *FakeAPI) AdminSurveyCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.adminSurveyMutex.Lock()
+	defer fake.adminSurveyMutex.Unlock()
+	fake.AdminSurveyStub = stub
+}
+
+func (fake *FakeAPI) AdminSurveyArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.adminSurveyMutex.RLock()
+	defer fake.adminSurveyMutex.RUnlock()
+	argsForCall := fake.adminSurveyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) AdminThreads(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.adminThreadsMutex.Lock()
+	fake.adminThreadsArgsForCall = append(fake.adminThreadsArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("AdminThreads", []interface{}{arg1, arg2})
+	fake.adminThreadsMutex.Unlock()
+	if fake.AdminThreadsStub != nil {
+		fake.AdminThreadsStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) AdminThreadsCallCount() int {
+	fake.adminThreadsMutex.RLock()
+	defer fake.adminThreadsMutex.RUnlock()
+	return len(fake.adminThreadsArgsForCall)
+}
+
+func (fake *FakeAPI) AdminThreadsCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.adminThreadsMutex.Lock()
+	defer fake.adminThreadsMutex.Unlock()
+	fake.AdminThreadsStub = stub
+}
+
+func (fake *FakeAPI) AdminThreadsArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.adminThreadsMutex.RLock()
+	defer fake.adminThreadsMutex.RUnlock()
+	argsForCall := fake.adminThreadsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) Attachment(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.attachmentMutex.Lock()
+	fake.attachmentArgsForCall = append(fake.attachmentArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("Attachment", []interface{}{arg1, arg2})
+	fake.attachmentMutex.Unlock()
+	if fake.AttachmentStub != nil {
+		fake.AttachmentStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) AttachmentCallCount() int {
+	fake.attachmentMutex.RLock()
+	defer fake.attachmentMutex.RUnlock()
+	return len(fake.attachmentArgsForCall)
+}
+
+func (fake *FakeAPI) AttachmentCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.attachmentMutex.Lock()
+	defer fake.attachmentMutex.Unlock()
+	fake.AttachmentStub = stub
+}
+
+func (fake *FakeAPI) AttachmentArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.attachmentMutex.RLock()
+	defer fake.attachmentMutex.RUnlock()
+	argsForCall := fake.attachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) AuditEvents(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.auditEventsMutex.Lock()
+	fake.auditEventsArgsForCall = append(fake.auditEventsArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("AuditEvents", []interface{}{arg1, arg2})
+	fake.auditEventsMutex.Unlock()
+	if fake.AuditEventsStub != nil {
+		fake.AuditEventsStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) AuditEventsCallCount() int {
+	fake.auditEventsMutex.RLock()
+	defer fake.auditEventsMutex.RUnlock()
+	return len(fake.auditEventsArgsForCall)
+}
+
+func (fake *FakeAPI) AuditEventsCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.auditEventsMutex.Lock()
+	defer fake.auditEventsMutex.Unlock()
+	fake.AuditEventsStub = stub
+}
+
+func (fake *FakeAPI) AuditEventsArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.auditEventsMutex.RLock()
+	defer fake.auditEventsMutex.RUnlock()
+	argsForCall := fake.auditEventsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) BulkTriage(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.bulkTriageMutex.Lock()
+	fake.bulkTriageArgsForCall = append(fake.bulkTriageArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("BulkTriage", []interface{}{arg1, arg2})
+	fake.bulkTriageMutex.Unlock()
+	if fake.BulkTriageStub != nil {
+		fake.BulkTriageStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) BulkTriageCallCount() int {
+	fake.bulkTriageMutex.RLock()
+	defer fake.bulkTriageMutex.RUnlock()
+	return len(fake.bulkTriageArgsForCall)
+}
+
+func (fake *FakeAPI) BulkTriageCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.bulkTriageMutex.Lock()
+	defer fake.bulkTriageMutex.Unlock()
+	fake.BulkTriageStub = stub
+}
+
+func (fake *FakeAPI) BulkTriageArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.bulkTriageMutex.RLock()
+	defer fake.bulkTriageMutex.RUnlock()
+	argsForCall := fake.bulkTriageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) FeedbackBreakdown(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.feedbackBreakdownMutex.Lock()
+	fake.feedbackBreakdownArgsForCall = append(fake.feedbackBreakdownArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("FeedbackBreakdown", []interface{}{arg1, arg2})
+	fake.feedbackBreakdownMutex.Unlock()
+	if fake.FeedbackBreakdownStub != nil {
+		fake.FeedbackBreakdownStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) FeedbackBreakdownCallCount() int {
+	fake.feedbackBreakdownMutex.RLock()
+	defer fake.feedbackBreakdownMutex.RUnlock()
+	return len(fake.feedbackBreakdownArgsForCall)
+}
+
+func (fake *FakeAPI) FeedbackBreakdownCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.feedbackBreakdownMutex.Lock()
+	defer fake.feedbackBreakdownMutex.Unlock()
+	fake.FeedbackBreakdownStub = stub
+}
+
+func (fake *FakeAPI) FeedbackBreakdownArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.feedbackBreakdownMutex.RLock()
+	defer fake.feedbackBreakdownMutex.RUnlock()
+	argsForCall := fake.feedbackBreakdownArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) FeedbackKinds(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.feedbackKindsMutex.Lock()
+	fake.feedbackKindsArgsForCall = append(fake.feedbackKindsArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("FeedbackKinds", []interface{}{arg1, arg2})
+	fake.feedbackKindsMutex.Unlock()
+	if fake.FeedbackKindsStub != nil {
+		fake.FeedbackKindsStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) FeedbackKindsCallCount() int {
+	fake.feedbackKindsMutex.RLock()
+	defer fake.feedbackKindsMutex.RUnlock()
+	return len(fake.feedbackKindsArgsForCall)
+}
+
+func (fake *FakeAPI) FeedbackKindsCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.feedbackKindsMutex.Lock()
+	defer fake.feedbackKindsMutex.Unlock()
+	fake.FeedbackKindsStub = stub
+}
+
+func (fake *FakeAPI) FeedbackKindsArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.feedbackKindsMutex.RLock()
+	defer fake.feedbackKindsMutex.RUnlock()
+	argsForCall := fake.feedbackKindsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeAPI) Health(arg1 http.ResponseWriter, arg2 *http.Request) {
 	fake.healthMutex.Lock()
 	fake.healthArgsForCall = append(fake.healthArgsForCall, struct {
@@ -57,6 +471,230 @@ func (fake *FakeAPI) HealthArgsForCall(i int) (http.ResponseWriter, *http.Reques
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeAPI) ListFeedback(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.listFeedbackMutex.Lock()
+	fake.listFeedbackArgsForCall = append(fake.listFeedbackArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("ListFeedback", []interface{}{arg1, arg2})
+	fake.listFeedbackMutex.Unlock()
+	if fake.ListFeedbackStub != nil {
+		fake.ListFeedbackStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) ListFeedbackCallCount() int {
+	fake.listFeedbackMutex.RLock()
+	defer fake.listFeedbackMutex.RUnlock()
+	return len(fake.listFeedbackArgsForCall)
+}
+
+func (fake *FakeAPI) ListFeedbackCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.listFeedbackMutex.Lock()
+	defer fake.listFeedbackMutex.Unlock()
+	fake.ListFeedbackStub = stub
+}
+
+func (fake *FakeAPI) ListFeedbackArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.listFeedbackMutex.RLock()
+	defer fake.listFeedbackMutex.RUnlock()
+	argsForCall := fake.listFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) Moderation(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.moderationMutex.Lock()
+	fake.moderationArgsForCall = append(fake.moderationArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("Moderation", []interface{}{arg1, arg2})
+	fake.moderationMutex.Unlock()
+	if fake.ModerationStub != nil {
+		fake.ModerationStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) ModerationCallCount() int {
+	fake.moderationMutex.RLock()
+	defer fake.moderationMutex.RUnlock()
+	return len(fake.moderationArgsForCall)
+}
+
+func (fake *FakeAPI) ModerationCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.moderationMutex.Lock()
+	defer fake.moderationMutex.Unlock()
+	fake.ModerationStub = stub
+}
+
+func (fake *FakeAPI) ModerationArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.moderationMutex.RLock()
+	defer fake.moderationMutex.RUnlock()
+	argsForCall := fake.moderationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) ModerationQueue(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.moderationQueueMutex.Lock()
+	fake.moderationQueueArgsForCall = append(fake.moderationQueueArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("ModerationQueue", []interface{}{arg1, arg2})
+	fake.moderationQueueMutex.Unlock()
+	if fake.ModerationQueueStub != nil {
+		fake.ModerationQueueStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) ModerationQueueCallCount() int {
+	fake.moderationQueueMutex.RLock()
+	defer fake.moderationQueueMutex.RUnlock()
+	return len(fake.moderationQueueArgsForCall)
+}
+
+func (fake *FakeAPI) ModerationQueueCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.moderationQueueMutex.Lock()
+	defer fake.moderationQueueMutex.Unlock()
+	fake.ModerationQueueStub = stub
+}
+
+func (fake *FakeAPI) ModerationQueueArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.moderationQueueMutex.RLock()
+	defer fake.moderationQueueMutex.RUnlock()
+	argsForCall := fake.moderationQueueArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) OutageResolved(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.outageResolvedMutex.Lock()
+	fake.outageResolvedArgsForCall = append(fake.outageResolvedArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("OutageResolved", []interface{}{arg1, arg2})
+	fake.outageResolvedMutex.Unlock()
+	if fake.OutageResolvedStub != nil {
+		fake.OutageResolvedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) OutageResolvedCallCount() int {
+	fake.outageResolvedMutex.RLock()
+	defer fake.outageResolvedMutex.RUnlock()
+	return len(fake.outageResolvedArgsForCall)
+}
+
+func (fake *FakeAPI) OutageResolvedCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.outageResolvedMutex.Lock()
+	defer fake.outageResolvedMutex.Unlock()
+	fake.OutageResolvedStub = stub
+}
+
+func (fake *FakeAPI) OutageResolvedArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.outageResolvedMutex.RLock()
+	defer fake.outageResolvedMutex.RUnlock()
+	argsForCall := fake.outageResolvedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) RetentionPolicies(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.retentionPoliciesMutex.Lock()
+	fake.retentionPoliciesArgsForCall = append(fake.retentionPoliciesArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("RetentionPolicies", []interface{}{arg1, arg2})
+	fake.retentionPoliciesMutex.Unlock()
+	if fake.RetentionPoliciesStub != nil {
+		fake.RetentionPoliciesStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) RetentionPoliciesCallCount() int {
+	fake.retentionPoliciesMutex.RLock()
+	defer fake.retentionPoliciesMutex.RUnlock()
+	return len(fake.retentionPoliciesArgsForCall)
+}
+
+func (fake *FakeAPI) RetentionPoliciesCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.retentionPoliciesMutex.Lock()
+	defer fake.retentionPoliciesMutex.Unlock()
+	fake.RetentionPoliciesStub = stub
+}
+
+func (fake *FakeAPI) RetentionPoliciesArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.retentionPoliciesMutex.RLock()
+	defer fake.retentionPoliciesMutex.RUnlock()
+	argsForCall := fake.retentionPoliciesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) RetentionPolicy(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.retentionPolicyMutex.Lock()
+	fake.retentionPolicyArgsForCall = append(fake.retentionPolicyArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("RetentionPolicy", []interface{}{arg1, arg2})
+	fake.retentionPolicyMutex.Unlock()
+	if fake.RetentionPolicyStub != nil {
+		fake.RetentionPolicyStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) RetentionPolicyCallCount() int {
+	fake.retentionPolicyMutex.RLock()
+	defer fake.retentionPolicyMutex.RUnlock()
+	return len(fake.retentionPolicyArgsForCall)
+}
+
+func (fake *FakeAPI) RetentionPolicyCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.retentionPolicyMutex.Lock()
+	defer fake.retentionPolicyMutex.Unlock()
+	fake.RetentionPolicyStub = stub
+}
+
+func (fake *FakeAPI) RetentionPolicyArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.retentionPolicyMutex.RLock()
+	defer fake.retentionPolicyMutex.RUnlock()
+	argsForCall := fake.retentionPolicyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) RiderData(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.riderDataMutex.Lock()
+	fake.riderDataArgsForCall = append(fake.riderDataArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("RiderData", []interface{}{arg1, arg2})
+	fake.riderDataMutex.Unlock()
+	if fake.RiderDataStub != nil {
+		fake.RiderDataStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) RiderDataCallCount() int {
+	fake.riderDataMutex.RLock()
+	defer fake.riderDataMutex.RUnlock()
+	return len(fake.riderDataArgsForCall)
+}
+
+func (fake *FakeAPI) RiderDataCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.riderDataMutex.Lock()
+	defer fake.riderDataMutex.Unlock()
+	fake.RiderDataStub = stub
+}
+
+func (fake *FakeAPI) RiderDataArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.riderDataMutex.RLock()
+	defer fake.riderDataMutex.RUnlock()
+	argsForCall := fake.riderDataArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeAPI) SaveFeedback(arg1 http.ResponseWriter, arg2 *http.Request) {
 	fake.saveFeedbackMutex.Lock()
 	fake.saveFeedbackArgsForCall = append(fake.saveFeedbackArgsForCall, struct {
@@ -89,13 +727,215 @@ func (fake *FakeAPI) SaveFeedbackArgsForCall(i int) (http.ResponseWriter, *http.
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeAPI) Survey(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.surveyMutex.Lock()
+	fake.surveyArgsForCall = append(fake.surveyArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("Survey", []interface{}{arg1, arg2})
+	fake.surveyMutex.Unlock()
+	if fake.SurveyStub != nil {
+		fake.SurveyStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) SurveyCallCount() int {
+	fake.surveyMutex.RLock()
+	defer fake.surveyMutex.RUnlock()
+	return len(fake.surveyArgsForCall)
+}
+
+func (fake *FakeAPI) SurveyCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.surveyMutex.Lock()
+	defer fake.surveyMutex.Unlock()
+	fake.SurveyStub = stub
+}
+
+func (fake *FakeAPI) SurveyArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.surveyMutex.RLock()
+	defer fake.surveyMutex.RUnlock()
+	argsForCall := fake.surveyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) Thread(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.threadMutex.Lock()
+	fake.threadArgsForCall = append(fake.threadArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("Thread", []interface{}{arg1, arg2})
+	fake.threadMutex.Unlock()
+	if fake.ThreadStub != nil {
+		fake.ThreadStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) ThreadCallCount() int {
+	fake.threadMutex.RLock()
+	defer fake.threadMutex.RUnlock()
+	return len(fake.threadArgsForCall)
+}
+
+func (fake *FakeAPI) ThreadCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.threadMutex.Lock()
+	defer fake.threadMutex.Unlock()
+	fake.ThreadStub = stub
+}
+
+func (fake *FakeAPI) ThreadArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.threadMutex.RLock()
+	defer fake.threadMutex.RUnlock()
+	argsForCall := fake.threadArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) Threads(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.threadsMutex.Lock()
+	fake.threadsArgsForCall = append(fake.threadsArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("Threads", []interface{}{arg1, arg2})
+	fake.threadsMutex.Unlock()
+	if fake.ThreadsStub != nil {
+		fake.ThreadsStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) ThreadsCallCount() int {
+	fake.threadsMutex.RLock()
+	defer fake.threadsMutex.RUnlock()
+	return len(fake.threadsArgsForCall)
+}
+
+func (fake *FakeAPI) ThreadsCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.threadsMutex.Lock()
+	defer fake.threadsMutex.Unlock()
+	fake.ThreadsStub = stub
+}
+
+func (fake *FakeAPI) ThreadsArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.threadsMutex.RLock()
+	defer fake.threadsMutex.RUnlock()
+	argsForCall := fake.threadsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) Unsubscribe(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.unsubscribeMutex.Lock()
+	fake.unsubscribeArgsForCall = append(fake.unsubscribeArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("Unsubscribe", []interface{}{arg1, arg2})
+	fake.unsubscribeMutex.Unlock()
+	if fake.UnsubscribeStub != nil {
+		fake.UnsubscribeStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) UnsubscribeCallCount() int {
+	fake.unsubscribeMutex.RLock()
+	defer fake.unsubscribeMutex.RUnlock()
+	return len(fake.unsubscribeArgsForCall)
+}
+
+func (fake *FakeAPI) UnsubscribeCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.unsubscribeMutex.Lock()
+	defer fake.unsubscribeMutex.Unlock()
+	fake.UnsubscribeStub = stub
+}
+
+func (fake *FakeAPI) UnsubscribeArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.unsubscribeMutex.RLock()
+	defer fake.unsubscribeMutex.RUnlock()
+	argsForCall := fake.unsubscribeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAPI) UploadAttachment(arg1 http.ResponseWriter, arg2 *http.Request) {
+	fake.uploadAttachmentMutex.Lock()
+	fake.uploadAttachmentArgsForCall = append(fake.uploadAttachmentArgsForCall, struct {
+		arg1 http.ResponseWriter
+		arg2 *http.Request
+	}{arg1, arg2})
+	fake.recordInvocation("UploadAttachment", []interface{}{arg1, arg2})
+	fake.uploadAttachmentMutex.Unlock()
+	if fake.UploadAttachmentStub != nil {
+		fake.UploadAttachmentStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAPI) UploadAttachmentCallCount() int {
+	fake.uploadAttachmentMutex.RLock()
+	defer fake.uploadAttachmentMutex.RUnlock()
+	return len(fake.uploadAttachmentArgsForCall)
+}
+
+func (fake *FakeAPI) UploadAttachmentCalls(stub func(http.ResponseWriter, *http.Request)) {
+	fake.uploadAttachmentMutex.Lock()
+	defer fake.uploadAttachmentMutex.Unlock()
+	fake.UploadAttachmentStub = stub
+}
+
+func (fake *FakeAPI) UploadAttachmentArgsForCall(i int) (http.ResponseWriter, *http.Request) {
+	fake.uploadAttachmentMutex.RLock()
+	defer fake.uploadAttachmentMutex.RUnlock()
+	argsForCall := fake.uploadAttachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeAPI) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.adminFeedbackMutex.RLock()
+	defer fake.adminFeedbackMutex.RUnlock()
+	fake.adminHealthMutex.RLock()
+	defer fake.adminHealthMutex.RUnlock()
+	fake.adminSurveyMutex.RLock()
+	defer fake.adminSurveyMutex.RUnlock()
+	fake.adminThreadsMutex.RLock()
+	defer fake.adminThreadsMutex.RUnlock()
+	fake.attachmentMutex.RLock()
+	defer fake.attachmentMutex.RUnlock()
+	fake.auditEventsMutex.RLock()
+	defer fake.auditEventsMutex.RUnlock()
+	fake.bulkTriageMutex.RLock()
+	defer fake.bulkTriageMutex.RUnlock()
+	fake.feedbackBreakdownMutex.RLock()
+	defer fake.feedbackBreakdownMutex.RUnlock()
+	fake.feedbackKindsMutex.RLock()
+	defer fake.feedbackKindsMutex.RUnlock()
 	fake.healthMutex.RLock()
 	defer fake.healthMutex.RUnlock()
+	fake.listFeedbackMutex.RLock()
+	defer fake.listFeedbackMutex.RUnlock()
+	fake.moderationMutex.RLock()
+	defer fake.moderationMutex.RUnlock()
+	fake.moderationQueueMutex.RLock()
+	defer fake.moderationQueueMutex.RUnlock()
+	fake.outageResolvedMutex.RLock()
+	defer fake.outageResolvedMutex.RUnlock()
+	fake.retentionPoliciesMutex.RLock()
+	defer fake.retentionPoliciesMutex.RUnlock()
+	fake.retentionPolicyMutex.RLock()
+	defer fake.retentionPolicyMutex.RUnlock()
+	fake.riderDataMutex.RLock()
+	defer fake.riderDataMutex.RUnlock()
 	fake.saveFeedbackMutex.RLock()
 	defer fake.saveFeedbackMutex.RUnlock()
+	fake.surveyMutex.RLock()
+	defer fake.surveyMutex.RUnlock()
+	fake.threadMutex.RLock()
+	defer fake.threadMutex.RUnlock()
+	fake.threadsMutex.RLock()
+	defer fake.threadsMutex.RUnlock()
+	fake.unsubscribeMutex.RLock()
+	defer fake.unsubscribeMutex.RUnlock()
+	fake.uploadAttachmentMutex.RLock()
+	defer fake.uploadAttachmentMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,388 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/attachment"
+	"github.com/smartatransit/feedback/blob"
+	"github.com/smartatransit/feedback/db"
+)
+
+//MaxAttachments is how many attachments a single feedback may have
+const MaxAttachments = 4
+
+//multipartOverhead allows for the headers and boundaries around the photo
+//in an upload's body
+const multipartOverhead = 64 << 10
+
+//attachmentRateLimitKind is the kind uploads are rate limited as
+const attachmentRateLimitKind = "attachment"
+
+//AttachmentRecord describes an uploaded image. Riders reference ID in the
+//`attachments` of their feedback.
+type AttachmentRecord struct {
+	ID              string    `json:"id"`
+	FeedbackID      *string   `json:"feedback_id,omitempty"`
+	ContentType     string    `json:"content_type"`
+	ByteSize        int       `json:"byte_size"`
+	Width           int       `json:"width"`
+	Height          int       `json:"height"`
+	ThumbnailWidth  int       `json:"thumbnail_width"`
+	ThumbnailHeight int       `json:"thumbnail_height"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func attachmentRecordFromAttachment(a db.Attachment) AttachmentRecord {
+	return AttachmentRecord{
+		ID:              a.ID,
+		FeedbackID:      a.FeedbackID,
+		ContentType:     a.ContentType,
+		ByteSize:        a.ByteSize,
+		Width:           a.Width,
+		Height:          a.Height,
+		ThumbnailWidth:  a.ThumbnailWidth,
+		ThumbnailHeight: a.ThumbnailHeight,
+		CreatedAt:       a.CreatedAt,
+	}
+}
+
+//AttachmentListResponse lists the attachments of a feedback
+type AttachmentListResponse struct {
+	Attachments []AttachmentRecord `json:"attachments"`
+}
+
+//WithAttachments returns a copy of c that accepts image uploads within
+//limits, keeping them in store. Without it, feedback can't have attachments.
+func (c Client) WithAttachments(store blob.Store, limits attachment.Limits) Client {
+	c.blobs = store
+	c.attachmentLimits = limits
+	c.attachmentSlots = nil
+	if limits.MaxConcurrent > 0 {
+		c.attachmentSlots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	return c
+}
+
+//UploadAttachment serves POST /v1/feedback/attachments. The body is
+//multipart/form-data with the image in a `photo` part. The image is checked,
+//stripped of its metadata and stored along with a thumbnail, and the
+//response carries the ID to submit in the feedback's `attachments`.
+func (c Client) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use POST instead")
+		return
+	}
+	if c.blobs == nil {
+		c.writeErrorResponse(w, http.StatusNotFound, "attachments are not enabled")
+		return
+	}
+
+	session := r.Header.Get("X-Smarta-Auth-Session")
+	role := r.Header.Get("X-Smarta-Auth-Role")
+	if len(session) == 0 || len(role) == 0 {
+		c.writeErrorResponse(w, http.StatusUnauthorized, "expected X-Smarta-Auth-* headers not present")
+		return
+	}
+
+	if c.limiter != nil {
+		decision, err := c.limiter.Allow(r.Context(), session, role, attachmentRateLimitKind)
+		if err != nil {
+			c.logger(r.Context()).Error(err.Error())
+		} else if !decision.Allowed {
+			retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			c.writeErrorResponse(w, http.StatusTooManyRequests, "too many uploads, try again later")
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, c.attachmentLimits.MaxBytes+multipartOverhead)
+	data, err := c.readPhoto(r)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
+	processed, err := c.processAttachment(r.Context(), data)
+	if errors.Is(err, errAttachmentsBusy) {
+		w.Header().Set("Retry-After", "1")
+		c.writeErrorResponse(w, http.StatusServiceUnavailable, "too many uploads being processed, try again later")
+		return
+	}
+	var rejection attachment.Rejection
+	if errors.As(err, &rejection) {
+		c.writeValidationError(w, ValidationError{Reason: rejection.Reason, Message: rejection.Message})
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to process attachment")
+		return
+	}
+
+	a, err := c.storeAttachment(r.Context(), session, processed)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to save attachment")
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusCreated, attachmentRecordFromAttachment(a))
+}
+
+//errAttachmentsBusy is returned by processAttachment when the request ends
+//before an upload can be processed
+var errAttachmentsBusy = errors.New("too many uploads being processed")
+
+//processAttachment processes an upload once fewer than MaxConcurrent others
+//are being processed, waiting until then or until ctx is done
+func (c Client) processAttachment(ctx context.Context, data []byte) (attachment.Processed, error) {
+	if c.attachmentSlots != nil {
+		select {
+		case c.attachmentSlots <- struct{}{}:
+			defer func() { <-c.attachmentSlots }()
+		case <-ctx.Done():
+			return attachment.Processed{}, errAttachmentsBusy
+		}
+	}
+
+	return attachment.Process(data, c.attachmentLimits)
+}
+
+//readPhoto returns the contents of the `photo` part of a multipart body
+func (c Client) readPhoto(r *http.Request) ([]byte, error) {
+	malformed := ValidationError{
+		Reason:  "malformed_multipart",
+		Message: "expected a multipart/form-data body with a `photo` part",
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, malformed
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, malformed
+		}
+		if err != nil {
+			return nil, tooLargeOr(err, malformed, c.attachmentLimits.MaxBytes)
+		}
+		if part.FormName() != "photo" {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(io.LimitReader(part, c.attachmentLimits.MaxBytes+1))
+		if err != nil {
+			return nil, tooLargeOr(err, malformed, c.attachmentLimits.MaxBytes)
+		}
+		return data, nil
+	}
+}
+
+//tooLargeOr returns a too large error if err came from http.MaxBytesReader,
+//and fallback otherwise
+func tooLargeOr(err error, fallback ValidationError, maxBytes int64) ValidationError {
+	if strings.Contains(err.Error(), "request body too large") {
+		return ValidationError{
+			Reason:  "attachment_too_large",
+			Message: fmt.Sprintf("attachments may be at most %d bytes", maxBytes),
+		}
+	}
+	return fallback
+}
+
+//storeAttachment puts the image and thumbnail in the blob store and
+//records them, deleting the images again if they can't be recorded
+func (c Client) storeAttachment(ctx context.Context, session string, p attachment.Processed) (db.Attachment, error) {
+	key, err := newBlobKey()
+	if err != nil {
+		return db.Attachment{}, err
+	}
+
+	a := db.Attachment{
+		SessionID:       session,
+		ContentType:     p.Image.ContentType,
+		ByteSize:        len(p.Image.Data),
+		Width:           p.Image.Width,
+		Height:          p.Image.Height,
+		SHA256:          p.SHA256,
+		StorageKey:      key,
+		ThumbnailKey:    key + "_thumb",
+		ThumbnailWidth:  p.Thumbnail.Width,
+		ThumbnailHeight: p.Thumbnail.Height,
+	}
+
+	if err := c.blobs.Put(ctx, a.StorageKey, p.Image.Data); err != nil {
+		return db.Attachment{}, err
+	}
+	if err := c.blobs.Put(ctx, a.ThumbnailKey, p.Thumbnail.Data); err != nil {
+		c.deleteBlobs(ctx, a.StorageKey)
+		return db.Attachment{}, err
+	}
+
+	saved, err := c.db.SaveAttachment(ctx, a)
+	if err != nil {
+		c.deleteBlobs(ctx, a.StorageKey, a.ThumbnailKey)
+		return db.Attachment{}, err
+	}
+
+	return saved, nil
+}
+
+func (c Client) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := c.blobs.Delete(ctx, key); err != nil {
+			c.logger(ctx).Error(err.Error())
+		}
+	}
+}
+
+//newBlobKey returns a random key, so that stored images can't be found by
+//guessing
+func newBlobKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed generating blob key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+//checkAttachments returns an error unless ids are distinct uploads by
+//session that aren't attached to any feedback yet
+func (c Client) checkAttachments(ctx context.Context, session string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if c.blobs == nil {
+		return ValidationError{
+			Reason:  "invalid_attachment",
+			Message: "attachments are not enabled",
+		}
+	}
+	if len(ids) > MaxAttachments {
+		return ValidationError{
+			Reason:  "too_many_attachments",
+			Message: fmt.Sprintf("feedback may have at most %d attachments", MaxAttachments),
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if !uuidRegexp.MatchString(id) || seen[strings.ToLower(id)] {
+			return invalidAttachment(id)
+		}
+		seen[strings.ToLower(id)] = true
+	}
+
+	attachments, err := c.db.GetAttachments(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	usable := map[string]bool{}
+	for _, a := range attachments {
+		if a.SessionID == session && a.FeedbackID == nil && a.DiscardedAt == nil {
+			usable[strings.ToLower(a.ID)] = true
+		}
+	}
+	for _, id := range ids {
+		if !usable[strings.ToLower(id)] {
+			return invalidAttachment(id)
+		}
+	}
+
+	return nil
+}
+
+func invalidAttachment(id string) ValidationError {
+	return ValidationError{
+		Reason:  "invalid_attachment",
+		Message: fmt.Sprintf("invalid value `%s` in `attachments`", id),
+	}
+}
+
+//listAttachments writes the attachments of a feedback
+func (c Client) listAttachments(w http.ResponseWriter, r *http.Request, feedbackID string) {
+	attachments, err := c.db.ListFeedbackAttachments(r.Context(), feedbackID)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to list attachments")
+		return
+	}
+
+	resp := AttachmentListResponse{Attachments: []AttachmentRecord{}}
+	for _, a := range attachments {
+		resp.Attachments = append(resp.Attachments, attachmentRecordFromAttachment(a))
+	}
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+//Attachment serves the images of an attachment under /v1/admin/attachments/:
+//
+//  GET /v1/admin/attachments/{id} returns the image
+//  GET /v1/admin/attachments/{id}/thumbnail returns its thumbnail
+func (c Client) Attachment(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/admin/attachments/"), "/")
+	if len(parts) > 2 || !uuidRegexp.MatchString(parts[0]) || (len(parts) == 2 && parts[1] != "thumbnail") {
+		c.writeErrorResponse(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != "GET" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+		return
+	}
+	if c.blobs == nil {
+		c.writeErrorResponse(w, http.StatusNotFound, "attachments are not enabled")
+		return
+	}
+
+	attachments, err := c.db.GetAttachments(r.Context(), parts[:1])
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get attachment")
+		return
+	}
+	if len(attachments) == 0 || attachments[0].DiscardedAt != nil {
+		c.writeErrorResponse(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+	a := attachments[0]
+
+	key := a.StorageKey
+	if len(parts) == 2 {
+		key = a.ThumbnailKey
+	}
+
+	body, err := c.blobs.Open(r.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get attachment")
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		c.logger(r.Context()).Errorf("failed writing attachment: %s", err.Error())
+	}
+}
@@ -0,0 +1,288 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/attachment"
+	"github.com/smartatransit/feedback/authz"
+	"github.com/smartatransit/feedback/blob"
+	"github.com/smartatransit/feedback/blob/blobfakes"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const attachmentID = "9b2f4f4e-3c55-4a7c-9d6b-0f3d1e2a6b7c"
+
+func multipartBody(field string, data []byte) (*bytes.Buffer, string) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile(field, "escalator.jpg")
+	Expect(err).To(BeNil())
+	_, err = part.Write(data)
+	Expect(err).To(BeNil())
+	Expect(mw.Close()).To(Succeed())
+	return &buf, mw.FormDataContentType()
+}
+
+var _ = Describe("Attachments", func() {
+	var (
+		db     *dbfakes.FakeDB
+		store  *blobfakes.FakeStore
+		client api.Client
+
+		req  *http.Request
+		resp *http.Response
+	)
+
+	BeforeEach(func() {
+		db = newFakeDB()
+		store = &blobfakes.FakeStore{}
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client = api.New(log, db).
+			WithAuthorization(testPolicy()).
+			WithCatalog(testCatalog()).
+			WithAttachments(store, attachment.DefaultLimits)
+	})
+
+	Describe("UploadAttachment", func() {
+		var data []byte
+
+		BeforeEach(func() {
+			var buf bytes.Buffer
+			Expect(jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 640, 480)), nil)).To(Succeed())
+			data = buf.Bytes()
+
+			db.SaveAttachmentStub = func(_ context.Context, a dbp.Attachment) (dbp.Attachment, error) {
+				a.ID = attachmentID
+				return a, nil
+			}
+		})
+
+		JustBeforeEach(func() {
+			body, contentType := multipartBody("photo", data)
+			req = httptest.NewRequest("POST", "/v1/feedback/attachments", body)
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("X-Smarta-Auth-Session", "r39iefjd0q39f")
+			req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+
+			respW := httptest.NewRecorder()
+			client.UploadAttachment(respW, req)
+			resp = respW.Result()
+		})
+
+		It("stores the image and its thumbnail", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(201))
+
+			Expect(store.PutCallCount()).To(Equal(2))
+			_, key, _ := store.PutArgsForCall(0)
+			_, thumbKey, _ := store.PutArgsForCall(1)
+			Expect(thumbKey).To(Equal(key + "_thumb"))
+
+			_, saved := db.SaveAttachmentArgsForCall(0)
+			Expect(saved.SessionID).To(Equal("r39iefjd0q39f"))
+			Expect(saved.StorageKey).To(Equal(key))
+			Expect(saved.ContentType).To(Equal("image/jpeg"))
+			Expect(saved.ThumbnailWidth).To(Equal(320))
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(respBody)).To(ContainSubstring(`"id":"` + attachmentID + `"`))
+			Expect(string(respBody)).To(ContainSubstring(`"width":640`))
+		})
+
+		When("the upload isn't an image", func() {
+			BeforeEach(func() {
+				data = []byte("%PDF-1.4")
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				Expect(store.PutCallCount()).To(Equal(0))
+			})
+		})
+		When("recording the attachment fails", func() {
+			BeforeEach(func() {
+				db.SaveAttachmentStub = nil
+				db.SaveAttachmentReturns(dbp.Attachment{}, errors.New("insert failed"))
+			})
+			It("deletes the stored images", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(500))
+				Expect(store.DeleteCallCount()).To(Equal(2))
+			})
+		})
+	})
+
+	Describe("UploadAttachment limited to one at a time", func() {
+		It("processes uploads one after another", func() {
+			limits := attachment.DefaultLimits
+			limits.MaxConcurrent = 1
+			client = client.WithAttachments(store, limits)
+			db.SaveAttachmentReturns(dbp.Attachment{ID: attachmentID}, nil)
+
+			var buf bytes.Buffer
+			Expect(jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 64, 48)), nil)).To(Succeed())
+
+			for i := 0; i < 3; i++ {
+				body, contentType := multipartBody("photo", buf.Bytes())
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				req = httptest.NewRequest("POST", "/v1/feedback/attachments", body).WithContext(ctx)
+				req.Header.Set("Content-Type", contentType)
+				req.Header.Set("X-Smarta-Auth-Session", "r39iefjd0q39f")
+				req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+
+				respW := httptest.NewRecorder()
+				client.UploadAttachment(respW, req)
+				cancel()
+				Expect(respW.Code).To(Equal(201))
+			}
+		})
+	})
+
+	Describe("UploadAttachment without a photo", func() {
+		BeforeEach(func() {
+			body, contentType := multipartBody("document", []byte("hello"))
+			req = httptest.NewRequest("POST", "/v1/feedback/attachments", body)
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("X-Smarta-Auth-Session", "r39iefjd0q39f")
+			req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+		})
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.UploadAttachment(respW, req)
+			resp = respW.Result()
+		})
+		It("fails", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(respBody)).To(ContainSubstring("with a `photo` part"))
+		})
+	})
+
+	Describe("SaveFeedback with attachments", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest("POST", "/v1/feedback", strings.NewReader(
+				`{"kind": "comment", "message": "broken escalator", "attachments": ["`+attachmentID+`"]}`,
+			))
+			db.GetAttachmentsReturns([]dbp.Attachment{{ID: attachmentID, SessionID: "r39iefjd0q39f"}}, nil)
+		})
+		JustBeforeEach(func() {
+			req.Header.Set("X-Smarta-Auth-Session", "r39iefjd0q39f")
+			req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+
+			respW := httptest.NewRecorder()
+			client.SaveFeedback(respW, req)
+			resp = respW.Result()
+		})
+
+		It("links them to the feedback", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, ids := db.GetAttachmentsArgsForCall(0)
+			Expect(ids).To(Equal([]string{attachmentID}))
+
+			_, fb := db.SaveFeedbackArgsForCall(0)
+			Expect(fb.AttachmentIDs).To(Equal([]string{attachmentID}))
+		})
+
+		When("another session uploaded them", func() {
+			BeforeEach(func() {
+				db.GetAttachmentsReturns([]dbp.Attachment{{ID: attachmentID, SessionID: "someone-else"}}, nil)
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				Expect(db.SaveFeedbackCallCount()).To(Equal(0))
+			})
+		})
+		When("they are already attached to a feedback", func() {
+			BeforeEach(func() {
+				feedbackID := "0d7a2b9c-1f3e-4e5d-8c6b-7a9f0e1d2c3b"
+				db.GetAttachmentsReturns([]dbp.Attachment{{ID: attachmentID, SessionID: "r39iefjd0q39f", FeedbackID: &feedbackID}}, nil)
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				Expect(db.SaveFeedbackCallCount()).To(Equal(0))
+			})
+		})
+		When("there are too many", func() {
+			BeforeEach(func() {
+				ids := `"` + strings.Repeat(attachmentID+`", "`, api.MaxAttachments) + attachmentID + `"`
+				req = httptest.NewRequest("POST", "/v1/feedback", strings.NewReader(
+					`{"kind": "comment", "message": "broken escalator", "attachments": [`+ids+`]}`,
+				))
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				Expect(db.GetAttachmentsCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("Attachment", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest("GET", "/v1/admin/attachments/"+attachmentID+"/thumbnail", nil)
+			req.Header.Set("X-Smarta-Auth-Session", "r39iefjd0q39f")
+			req.Header.Set("X-Smarta-Auth-Role", "auditor")
+
+			db.GetAttachmentsReturns([]dbp.Attachment{{
+				ID:           attachmentID,
+				ContentType:  "image/jpeg",
+				StorageKey:   "k1",
+				ThumbnailKey: "k1_thumb",
+			}}, nil)
+			store.OpenReturns(ioutil.NopCloser(strings.NewReader("thumbnail")), nil)
+		})
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.Require(authz.Read, client.Attachment)(respW, req)
+			resp = respW.Result()
+		})
+
+		It("serves the image", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("image/jpeg"))
+			Expect(resp.Header.Get("X-Content-Type-Options")).To(Equal("nosniff"))
+
+			_, key := store.OpenArgsForCall(0)
+			Expect(key).To(Equal("k1_thumb"))
+			Expect(ioutil.ReadAll(resp.Body)).To(Equal([]byte("thumbnail")))
+		})
+
+		When("the attachment was discarded", func() {
+			BeforeEach(func() {
+				db.GetAttachmentsReturns([]dbp.Attachment{}, nil)
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			})
+		})
+		When("the image is missing", func() {
+			BeforeEach(func() {
+				store.OpenReturns(nil, blob.ErrNotFound)
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			})
+		})
+	})
+})
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/logging"
+)
+
+//Audited actions
+const (
+	AuditModerateFeedback      = "feedback.moderate"
+	AuditDeleteRiderData       = "rider_data.delete"
+	AuditAnonymizeRiderData    = "rider_data.anonymize"
+	AuditSetRetentionPolicy    = "retention_policy.set"
+	AuditDeleteRetentionPolicy = "retention_policy.delete"
+	AuditCreateSurveyVersion   = "survey.create_version"
+	AuditActivateSurvey        = "survey.activate"
+	AuditReplyToFeedback       = "feedback.reply"
+	AuditNotifyOutageResolved  = "outage.notify_resolved"
+	AuditTriageFeedback        = "feedback.triage"
+	AuditSilenceFeedback       = "feedback.silence"
+	AuditReadOriginalMessage   = "feedback.read_original"
+)
+
+//AuditEventRecord is the administrative view of an audit event
+type AuditEventRecord struct {
+	Seq          int64           `json:"seq"`
+	OccurredAt   time.Time       `json:"occurred_at"`
+	ActorSession string          `json:"actor_session"`
+	ActorRole    string          `json:"actor_role"`
+	Action       string          `json:"action"`
+	TargetType   string          `json:"target_type"`
+	TargetIDs    []string        `json:"target_ids"`
+	Before       json.RawMessage `json:"before,omitempty"`
+	After        json.RawMessage `json:"after,omitempty"`
+	RequestID    string          `json:"request_id,omitempty"`
+	PrevHash     string          `json:"prev_hash"`
+	Hash         string          `json:"hash"`
+}
+
+//AuditEventsResponse lists audit events, newest first
+type AuditEventsResponse struct {
+	Events []AuditEventRecord `json:"events"`
+}
+
+//WithAuditLog returns a copy of c that records every mutating
+//administrative operation to recorder
+func (c Client) WithAuditLog(recorder audit.Recorder) Client {
+	c.auditor = recorder
+	return c
+}
+
+//recordAudit records entry, tagged with the ID of the request being served.
+//It should be called in the same transaction as the change it describes, so
+//that a change is never made without being recorded.
+func (c Client) recordAudit(ctx context.Context, entry audit.Entry) error {
+	if c.auditor == nil {
+		return nil
+	}
+
+	entry.RequestID = logging.RequestID(ctx)
+	if err := c.auditor.Record(ctx, entry); err != nil {
+		return fmt.Errorf("failed recording audit event %s: %w", entry.Action, err)
+	}
+	return nil
+}
+
+//AuditEvents serves GET /v1/admin/audit, listing audit events newest first.
+//The `action`, `target_id` and `actor_session` query parameters filter the
+//results.
+func (c Client) AuditEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+		return
+	}
+
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	events, err := c.db.ListAuditEvents(r.Context(), db.AuditFilter{
+		Action:       query.Get("action"),
+		TargetID:     query.Get("target_id"),
+		ActorSession: query.Get("actor_session"),
+	}, limit, offset)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to list audit events")
+		return
+	}
+
+	resp := AuditEventsResponse{Events: []AuditEventRecord{}}
+	for _, ev := range events {
+		resp.Events = append(resp.Events, AuditEventRecord{
+			Seq:          ev.Seq,
+			OccurredAt:   ev.OccurredAt,
+			ActorSession: ev.ActorSession,
+			ActorRole:    ev.ActorRole,
+			Action:       ev.Action,
+			TargetType:   ev.TargetType,
+			TargetIDs:    ev.TargetIDs,
+			Before:       ev.Before,
+			After:        ev.After,
+			RequestID:    ev.RequestID,
+			PrevHash:     ev.PrevHash,
+			Hash:         ev.Hash,
+		})
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
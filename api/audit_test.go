@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/authz"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AuditEvents", func() {
+	var (
+		db *dbfakes.FakeDB
+
+		req  *http.Request
+		resp *http.Response
+	)
+
+	BeforeEach(func() {
+		db = newFakeDB()
+		req = httptest.NewRequest("GET", "/v1/admin/audit?action=feedback.moderate&target_id=fb&limit=10", nil)
+		req.Header.Set("X-Smarta-Auth-Session", "admin-session")
+		req.Header.Set("X-Smarta-Auth-Role", "auditor")
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client := api.New(log, db).WithAuthorization(testPolicy())
+
+		respW := httptest.NewRecorder()
+		client.Require(authz.Read, client.AuditEvents)(respW, req)
+		resp = respW.Result()
+	})
+
+	When("the query fails", func() {
+		BeforeEach(func() {
+			db.ListAuditEventsReturns(nil, errors.New("select failed"))
+		})
+		It("fails", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(500))
+		})
+	})
+	When("all goes well", func() {
+		BeforeEach(func() {
+			db.ListAuditEventsReturns([]dbp.AuditEvent{{
+				Seq:        3,
+				OccurredAt: time.Date(2020, 3, 14, 12, 0, 0, 0, time.UTC),
+				Action:     "feedback.moderate",
+				TargetType: "feedback",
+				TargetIDs:  []string{"fb"},
+				After:      []byte(`{"moderation_status": "approved"}`),
+				PrevHash:   "p",
+				Hash:       "h",
+			}}, nil)
+		})
+		It("passes the filters on", func() {
+			_, filter, limit, offset := db.ListAuditEventsArgsForCall(0)
+			Expect(filter).To(Equal(dbp.AuditFilter{Action: "feedback.moderate", TargetID: "fb"}))
+			Expect(limit).To(Equal(10))
+			Expect(offset).To(Equal(0))
+		})
+		It("lists the events", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			var respObj api.AuditEventsResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+			Expect(respObj.Events).To(HaveLen(1))
+			Expect(respObj.Events[0].Seq).To(BeEquivalentTo(3))
+			Expect(respObj.Events[0].After).To(MatchJSON(`{"moderation_status": "approved"}`))
+			Expect(respObj.Events[0].Before).To(BeNil())
+		})
+	})
+})
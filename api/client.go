@@ -0,0 +1,230 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/clientinfo"
+	"github.com/smartatransit/feedback/db"
+)
+
+//defaultBreakdownPeriod is how far back breakdowns count when `since` isn't
+//given
+const defaultBreakdownPeriod = 30 * 24 * time.Hour
+
+//ClientInfoRequest describes the app and device a submission is sent from.
+//Fields given here take precedence over request headers.
+type ClientInfoRequest struct {
+	AppVersion string `json:"app_version"`
+	Platform   string `json:"platform"`
+	OSVersion  string `json:"os_version"`
+	Locale     string `json:"locale"`
+}
+
+//ClientRecord describes the app and device a feedback was sent from
+type ClientRecord struct {
+	AppVersion *string `json:"app_version,omitempty"`
+	Platform   *string `json:"platform,omitempty"`
+	OSVersion  *string `json:"os_version,omitempty"`
+	Locale     *string `json:"locale,omitempty"`
+}
+
+func clientRecordFromClientInfo(info db.ClientInfo) *ClientRecord {
+	if info == (db.ClientInfo{}) {
+		return nil
+	}
+	return &ClientRecord{
+		AppVersion: info.AppVersion,
+		Platform:   info.Platform,
+		OSVersion:  info.OSVersion,
+		Locale:     info.Locale,
+	}
+}
+
+//FeedbackBreakdownResponse counts feedback by the fields listed in By. Each
+//count holds those fields and a `count`. A field is null for feedback that
+//didn't report it.
+type FeedbackBreakdownResponse struct {
+	By     []string                 `json:"by"`
+	Kind   *string                  `json:"kind,omitempty"`
+	Since  time.Time                `json:"since"`
+	Until  time.Time                `json:"until"`
+	Counts []map[string]interface{} `json:"counts"`
+}
+
+//WithClientHeaders returns a copy of c that reads client metadata from the
+//request headers in allowlist. Without it, only the request body is read.
+func (c Client) WithClientHeaders(allowlist clientinfo.Allowlist) Client {
+	c.clientHeaders = allowlist
+	return c
+}
+
+//clientInfo merges the client metadata in a submission's body with that in
+//its allowlisted headers. Invalid values are an error in the body, but are
+//ignored in headers, which riders' software may set for other reasons.
+func (c Client) clientInfo(h http.Header, req *ClientInfoRequest) (db.ClientInfo, error) {
+	info := c.clientHeaders.FromHeaders(h)
+	if req == nil {
+		return info, nil
+	}
+
+	fields := map[string]string{
+		clientinfo.AppVersion: req.AppVersion,
+		clientinfo.Platform:   req.Platform,
+		clientinfo.OSVersion:  req.OSVersion,
+		clientinfo.Locale:     req.Locale,
+	}
+	for _, field := range clientinfo.Fields {
+		value, err := clientinfo.Normalize(field, fields[field])
+		if err != nil {
+			return db.ClientInfo{}, ValidationError{
+				Reason:  "invalid_client",
+				Message: fmt.Sprintf("invalid value `%s` for `client.%s`", fields[field], field),
+			}
+		}
+		if value != "" {
+			clientinfo.Set(&info, field, value)
+		}
+	}
+
+	return info, nil
+}
+
+//FeedbackBreakdown serves GET /v1/admin/feedback/breakdown, counting feedback
+//by the comma-separated client fields in `by`, e.g.
+//
+//  GET /v1/admin/feedback/breakdown?by=platform,app_version&kind=comment
+//
+//`since` and `until` are RFC 3339 times bounding when feedback was received,
+//and default to the 30 days up to now. Feedback that didn't report a field
+//is counted with it as null, since any string could also be a real value.
+func (c Client) FeedbackBreakdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+		return
+	}
+
+	query := r.URL.Query()
+	breakdown, by, err := parseClientBreakdown(query.Get("by"))
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
+	if kind := strings.ToLower(query.Get("kind")); kind != "" {
+		if _, ok := c.findKind(kind); !ok {
+			c.writeValidationError(w, ValidationError{
+				Reason:  "invalid_kind",
+				Message: fmt.Sprintf("invalid value `%s` for `kind`", kind),
+			})
+			return
+		}
+		breakdown.Kind = &kind
+	}
+
+	breakdown.Until = time.Now().UTC()
+	if breakdown.Until, err = parseTime(query.Get("until"), "until", breakdown.Until); err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+	if breakdown.Since, err = parseTime(query.Get("since"), "since", breakdown.Until.Add(-defaultBreakdownPeriod)); err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+	if !breakdown.Since.Before(breakdown.Until) {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_range",
+			Message: "`since` must be before `until`",
+		})
+		return
+	}
+
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
+	counts, err := c.db.CountFeedbackByClient(r.Context(), breakdown, limit, offset)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to count feedback")
+		return
+	}
+
+	resp := FeedbackBreakdownResponse{
+		By:     by,
+		Kind:   breakdown.Kind,
+		Since:  breakdown.Since,
+		Until:  breakdown.Until,
+		Counts: []map[string]interface{}{},
+	}
+	for _, count := range counts {
+		row := map[string]interface{}{"count": count.Count}
+		for _, field := range by {
+			row[field] = clientinfo.Get(count.Client, field)
+		}
+		resp.Counts = append(resp.Counts, row)
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+//parseClientBreakdown reads the `by` query parameter, returning the fields
+//in the order clientinfo.Fields lists them
+func parseClientBreakdown(s string) (breakdown db.ClientBreakdown, by []string, err error) {
+	selected := map[string]bool{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !clientinfo.IsField(field) {
+			err = ValidationError{
+				Reason:  "invalid_breakdown",
+				Message: fmt.Sprintf("invalid value `%s` for `by`, expected any of %s", field, strings.Join(clientinfo.Fields, ", ")),
+			}
+			return
+		}
+		selected[field] = true
+	}
+	if len(selected) == 0 {
+		err = ValidationError{
+			Reason:  "invalid_breakdown",
+			Message: fmt.Sprintf("`by` must list any of %s", strings.Join(clientinfo.Fields, ", ")),
+		}
+		return
+	}
+
+	by = []string{}
+	for _, field := range clientinfo.Fields {
+		if selected[field] {
+			by = append(by, field)
+		}
+	}
+
+	breakdown.ByAppVersion = selected[clientinfo.AppVersion]
+	breakdown.ByPlatform = selected[clientinfo.Platform]
+	breakdown.ByOSVersion = selected[clientinfo.OSVersion]
+	breakdown.ByLocale = selected[clientinfo.Locale]
+	return
+}
+
+//parseTime parses an RFC 3339 query parameter, returning fallback if it is
+//empty
+func parseTime(s, name string, fallback time.Time) (time.Time, error) {
+	if s == "" {
+		return fallback, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, ValidationError{
+			Reason:  "invalid_" + name,
+			Message: fmt.Sprintf("`%s` must be an RFC 3339 time", name),
+		}
+	}
+	return t.UTC(), nil
+}
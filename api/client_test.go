@@ -0,0 +1,211 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/authz"
+	"github.com/smartatransit/feedback/clientinfo"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+var _ = Describe("Client metadata", func() {
+	var (
+		db     *dbfakes.FakeDB
+		client api.Client
+
+		req  *http.Request
+		resp *http.Response
+	)
+
+	BeforeEach(func() {
+		db = newFakeDB()
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		allowlist, err := clientinfo.ParseAllowlist(clientinfo.DefaultAllowlist)
+		Expect(err).To(BeNil())
+		client = api.New(log, db).
+			WithAuthorization(testPolicy()).
+			WithCatalog(testCatalog()).
+			WithClientHeaders(allowlist)
+	})
+
+	Describe("SaveFeedback", func() {
+		var body string
+
+		BeforeEach(func() {
+			body = `{"kind": "comment", "message": "the app says the train is here but it isn't"}`
+		})
+
+		JustBeforeEach(func() {
+			req = httptest.NewRequest("POST", "/v1/feedback", bytes.NewBufferString(body))
+			req.Header.Set("X-Smarta-Auth-Session", "session")
+			req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+			req.Header.Set("X-App-Version", "4.2.0")
+			req.Header.Set("X-App-Platform", "iOS")
+			req.Header.Set("X-OS-Version", "not a version")
+			req.Header.Set("Accept-Language", "en-us,en;q=0.9")
+			req.Header.Set("X-Device-Model", "iPhone12,1")
+
+			w := httptest.NewRecorder()
+			client.SaveFeedback(w, req)
+			resp = w.Result()
+		})
+
+		It("reads allowlisted headers, dropping invalid values", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, fb := db.SaveFeedbackArgsForCall(0)
+			Expect(fb.Client).To(MatchAllFields(Fields{
+				"AppVersion": PointTo(Equal("4.2.0")),
+				"Platform":   PointTo(Equal("ios")),
+				"OSVersion":  BeNil(),
+				"Locale":     PointTo(Equal("en-US")),
+			}))
+		})
+
+		When("the body describes the client", func() {
+			BeforeEach(func() {
+				body = `{"kind": "comment", "client": {"app_version": "4.3.0-beta.1", "os_version": "17.1"}}`
+			})
+			It("takes precedence over the headers", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, fb := db.SaveFeedbackArgsForCall(0)
+				Expect(fb.Client.AppVersion).To(PointTo(Equal("4.3.0-beta.1")))
+				Expect(fb.Client.Platform).To(PointTo(Equal("ios")))
+				Expect(fb.Client.OSVersion).To(PointTo(Equal("17.1")))
+			})
+		})
+
+		When("the body has an invalid value", func() {
+			BeforeEach(func() {
+				body = `{"kind": "comment", "client": {"locale": "english please"}}`
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				Expect(db.SaveFeedbackCallCount()).To(Equal(0))
+
+				respBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).To(BeNil())
+				Expect(string(respBody)).To(ContainSubstring("client.locale"))
+			})
+		})
+	})
+
+	Describe("FeedbackBreakdown", func() {
+		var query string
+
+		BeforeEach(func() {
+			query = "?by=app_version,platform&kind=comment&since=2020-06-01T00:00:00Z&until=2020-07-01T00:00:00Z"
+
+			version, platform := "4.2.0", "ios"
+			db.CountFeedbackByClientReturns([]dbp.ClientCount{
+				{Client: dbp.ClientInfo{AppVersion: &version, Platform: &platform}, Count: 12},
+				{Client: dbp.ClientInfo{Platform: &platform}, Count: 3},
+			}, nil)
+		})
+
+		JustBeforeEach(func() {
+			req = httptest.NewRequest("GET", "/v1/admin/feedback/breakdown"+query, nil)
+			req.Header.Set("X-Smarta-Auth-Session", "session")
+			req.Header.Set("X-Smarta-Auth-Role", "auditor")
+
+			w := httptest.NewRecorder()
+			client.Require(authz.Read, client.FeedbackBreakdown)(w, req)
+			resp = w.Result()
+		})
+
+		It("counts feedback by the selected fields", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, breakdown, limit, offset := db.CountFeedbackByClientArgsForCall(0)
+			Expect(breakdown).To(MatchAllFields(Fields{
+				"ByAppVersion": BeTrue(),
+				"ByPlatform":   BeTrue(),
+				"ByOSVersion":  BeFalse(),
+				"ByLocale":     BeFalse(),
+				"Kind":         PointTo(Equal("comment")),
+				"Since":        Equal(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)),
+				"Until":        Equal(time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)),
+			}))
+			Expect(limit).To(Equal(50))
+			Expect(offset).To(Equal(0))
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(respBody).To(MatchJSON(`{
+				"by": ["app_version", "platform"],
+				"kind": "comment",
+				"since": "2020-06-01T00:00:00Z",
+				"until": "2020-07-01T00:00:00Z",
+				"counts": [
+					{"app_version": "4.2.0", "platform": "ios", "count": 12},
+					{"app_version": null, "platform": "ios", "count": 3}
+				]
+			}`))
+		})
+
+		When("no period is given", func() {
+			BeforeEach(func() {
+				query = "?by=locale"
+			})
+			It("counts the last 30 days", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, breakdown, _, _ := db.CountFeedbackByClientArgsForCall(0)
+				Expect(breakdown.Until).To(BeTemporally("~", time.Now(), time.Minute))
+				Expect(breakdown.Until.Sub(breakdown.Since)).To(Equal(30 * 24 * time.Hour))
+				Expect(breakdown.Kind).To(BeNil())
+			})
+		})
+
+		When("a field is unknown", func() {
+			BeforeEach(func() {
+				query = "?by=device_model"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				Expect(db.CountFeedbackByClientCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the period is empty", func() {
+			BeforeEach(func() {
+				query = "?by=platform&since=2020-07-01T00:00:00Z&until=2020-06-01T00:00:00Z"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+
+		When("the query fails", func() {
+			BeforeEach(func() {
+				db.CountFeedbackByClientReturns(nil, errors.New("select failed"))
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(500))
+
+				var respObj map[string]interface{}
+				Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+				Expect(respObj["message"]).To(Equal("failed to count feedback"))
+			})
+		})
+	})
+})
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/schema"
+)
+
+//detailsFilterPrefix introduces a query parameter filtering on a details
+//field, e.g. `details.crowding=full`
+const detailsFilterPrefix = "details."
+
+//detailsSchema returns the compiled details schema of kind, if it has one
+func (c Client) detailsSchema(kind string) *schema.Schema {
+	if c.catalog == nil {
+		return nil
+	}
+	return c.catalog.DetailsSchema(kind)
+}
+
+//validateDetails checks the details of a submission against the schema of
+//its kind. Omitted or null details are always accepted.
+func (c Client) validateDetails(kind db.FeedbackKind, details json.RawMessage) ([]byte, error) {
+	if len(details) == 0 || bytes.Equal(details, []byte("null")) {
+		return nil, nil
+	}
+
+	s := c.detailsSchema(kind.Name)
+	if s == nil {
+		return nil, ValidationError{
+			Reason:  "invalid_details",
+			Message: fmt.Sprintf("`details` aren't accepted for `%s` feedback", kind.Name),
+		}
+	}
+
+	v, err := schema.Decode(details)
+	if err != nil {
+		return nil, ValidationError{
+			Reason:  "invalid_details",
+			Message: fmt.Sprintf("malformed `details`: %s", err.Error()),
+		}
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return nil, ValidationError{
+			Reason:  "invalid_details",
+			Message: "`details` must be an object",
+		}
+	}
+
+	if err := s.Validate(v); err != nil {
+		return nil, ValidationError{
+			Reason:  "invalid_details",
+			Message: fmt.Sprintf("invalid `details`: %s", err.Error()),
+		}
+	}
+
+	return details, nil
+}
+
+//parseDetailsFilter builds a JSON object from the `details.*` query
+//parameters, which feedback details must contain to match. Values are
+//converted to the types the kind's schema declares, so that `cleanliness=2`
+//matches the number 2. It returns nil if there are no such parameters.
+func (c Client) parseDetailsFilter(kind string, query url.Values) ([]byte, error) {
+	var fields []string
+	for key := range query {
+		if strings.HasPrefix(key, detailsFilterPrefix) {
+			fields = append(fields, key)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	sort.Strings(fields)
+
+	s := c.detailsSchema(kind)
+	filter := map[string]interface{}{}
+	for _, key := range fields {
+		path := strings.Split(strings.TrimPrefix(key, detailsFilterPrefix), ".")
+		for _, name := range path {
+			if name == "" {
+				return nil, ValidationError{
+					Reason:  "invalid_details_filter",
+					Message: fmt.Sprintf("invalid details filter `%s`", key),
+				}
+			}
+		}
+
+		var types []string
+		if s != nil {
+			types = s.PropertyType(path)
+		}
+		value, err := detailsFilterValue(query.Get(key), types)
+		if err != nil {
+			return nil, ValidationError{
+				Reason:  "invalid_details_filter",
+				Message: fmt.Sprintf("`%s` %s", key, err.Error()),
+			}
+		}
+
+		if err := setPath(filter, path, value); err != nil {
+			return nil, ValidationError{
+				Reason:  "invalid_details_filter",
+				Message: fmt.Sprintf("`%s` %s", key, err.Error()),
+			}
+		}
+	}
+
+	b, err := json.Marshal(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed encoding details filter: %w", err)
+	}
+	return b, nil
+}
+
+//detailsFilterValue converts a query parameter to the first of types it
+//fits. Without types it is kept as a string.
+func detailsFilterValue(s string, types []string) (interface{}, error) {
+	if len(types) == 0 {
+		return s, nil
+	}
+
+	for _, t := range types {
+		switch t {
+		case "string":
+			return s, nil
+		case "integer", "number":
+			v, err := schema.Decode([]byte(s))
+			if n, ok := v.(json.Number); err == nil && ok {
+				return n, nil
+			}
+		case "boolean":
+			if s == "true" || s == "false" {
+				return s == "true", nil
+			}
+		case "null":
+			if s == "null" {
+				return nil, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("must be %s", strings.Join(types, " or "))
+}
+
+//setPath sets the value at path in obj, creating nested objects
+func setPath(obj map[string]interface{}, path []string, value interface{}) error {
+	for _, name := range path[:len(path)-1] {
+		next, ok := obj[name].(map[string]interface{})
+		if !ok {
+			if _, taken := obj[name]; taken {
+				return errors.New("conflicts with another details filter")
+			}
+			next = map[string]interface{}{}
+			obj[name] = next
+		}
+		obj = next
+	}
+
+	last := path[len(path)-1]
+	if _, taken := obj[last]; taken {
+		return errors.New("conflicts with another details filter")
+	}
+	obj[last] = value
+	return nil
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/mailer"
+)
+
+//defaultOutagePeriod is how far back outage reports are emailed about when
+//`since` isn't given
+const defaultOutagePeriod = 24 * time.Hour
+
+//OutageResolvedRequest announces that an outage is over. Riders who
+//reported an outage on Line, or on any line if it is empty, since Since are
+//emailed if they opted in.
+type OutageResolvedRequest struct {
+	Line  string     `json:"line"`
+	Since *time.Time `json:"since"`
+}
+
+//OutageResolvedResponse says how many emails were queued
+type OutageResolvedResponse struct {
+	Queued int `json:"queued"`
+}
+
+//WithEmail returns a copy of c that queues acknowledgements of submissions
+//whose riders opted in to email, and honors unsubscribe links made by
+//unsubscriber
+func (c Client) WithEmail(unsubscriber mailer.Unsubscriber) Client {
+	c.unsubscriber = &unsubscriber
+	return c
+}
+
+//unsubscribeConfirmation is the page riders following an unsubscribe link
+//see. Its form posts back to the link itself.
+const unsubscribeConfirmation = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+<form method="post">
+<p>Stop receiving emails about your feedback?</p>
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`
+
+//Unsubscribe serves /v1/email/unsubscribe/{token}, the link in every email.
+//GET, as sent by riders following the link, only shows a page asking them to
+//confirm, since mail scanners and link previews follow links too. POST, as
+//sent by that page and by mail clients' one-click unsubscribe buttons,
+//unsubscribes the address.
+func (c Client) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET or POST instead")
+		return
+	}
+	if c.unsubscriber == nil {
+		c.writeErrorResponse(w, http.StatusNotFound, "email is not enabled")
+		return
+	}
+
+	digest, ok := c.unsubscriber.Verify(strings.TrimPrefix(r.URL.Path, "/v1/email/unsubscribe/"))
+	if !ok {
+		c.writeErrorResponse(w, http.StatusNotFound, "invalid unsubscribe link")
+		return
+	}
+
+	if r.Method == "GET" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, unsubscribeConfirmation)
+		return
+	}
+
+	if err := c.db.UnsubscribeEmail(r.Context(), digest); err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to unsubscribe")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "You will no longer receive emails about your feedback.")
+}
+
+//OutageResolved serves POST /v1/admin/outages/resolved, queueing an email
+//to every rider who opted in when reporting the outage
+func (c Client) OutageResolved(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use POST instead")
+		return
+	}
+
+	var req OutageResolvedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
+		return
+	}
+
+	var line *string
+	if req.Line != "" {
+		l := strings.ToLower(strings.TrimSpace(req.Line))
+		if !lineRegexp.MatchString(l) {
+			c.writeValidationError(w, ValidationError{
+				Reason:  "invalid_line",
+				Message: fmt.Sprintf("invalid value `%s` for `line`", req.Line),
+			})
+			return
+		}
+		line = &l
+	}
+
+	since := time.Now().UTC().Add(-defaultOutagePeriod)
+	if req.Since != nil {
+		since = req.Since.UTC()
+	}
+
+	var queued int
+	err := c.db.InTransaction(r.Context(), func(ctx context.Context) (err error) {
+		queued, err = c.db.QueueOutageResolvedEmails(ctx, line, since)
+		if err != nil {
+			return err
+		}
+
+		return c.recordAudit(ctx, audit.Entry{
+			ActorSession: r.Header.Get("X-Smarta-Auth-Session"),
+			ActorRole:    r.Header.Get("X-Smarta-Auth-Role"),
+			Action:       AuditNotifyOutageResolved,
+			TargetType:   "line",
+			TargetIDs:    targetLines(line),
+			After: map[string]interface{}{
+				"since":  since,
+				"queued": queued,
+			},
+		})
+	})
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to queue emails")
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, OutageResolvedResponse{Queued: queued})
+}
+
+func targetLines(line *string) []string {
+	if line == nil {
+		return []string{}
+	}
+	return []string{*line}
+}
@@ -0,0 +1,216 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/audit/auditfakes"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/mailer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+var _ = Describe("Email", func() {
+	var (
+		db           *dbfakes.FakeDB
+		auditor      *auditfakes.FakeRecorder
+		unsubscriber mailer.Unsubscriber
+		emailEnabled bool
+		client       api.Client
+
+		resp *http.Response
+	)
+
+	BeforeEach(func() {
+		db = newFakeDB()
+		auditor = &auditfakes.FakeRecorder{}
+		emailEnabled = true
+
+		var err error
+		unsubscriber, err = mailer.NewUnsubscriber(bytes.Repeat([]byte("k"), 32), "https://example.com")
+		Expect(err).To(BeNil())
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client = api.New(log, db).
+			WithAuthorization(testPolicy()).
+			WithCatalog(testCatalog()).
+			WithAuditLog(auditor)
+		if emailEnabled {
+			client = client.WithEmail(unsubscriber)
+		}
+	})
+
+	serve := func(handler http.HandlerFunc, method, path, body string) *http.Response {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Smarta-Auth-Session", "session")
+		req.Header.Set("X-Smarta-Auth-Role", "admin")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Result()
+	}
+
+	Describe("SaveFeedback", func() {
+		It("records the opt-in and queues an acknowledgement", func() {
+			resp = serve(client.SaveFeedback, "POST", "/v1/feedback",
+				`{"kind": "comment", "email": "rider@example.com", "email_opt_in": true}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, fb := db.SaveFeedbackArgsForCall(0)
+			Expect(fb.EmailOptIn).To(BeTrue())
+			Expect(fb.Acknowledge).To(BeTrue())
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(respBody).To(MatchJSON(`{}`))
+		})
+
+		When("the address has unsubscribed", func() {
+			BeforeEach(func() {
+				db.IsEmailUnsubscribedReturns(true, nil)
+			})
+			It("keeps it unsubscribed and tells the rider", func() {
+				resp = serve(client.SaveFeedback, "POST", "/v1/feedback",
+					`{"kind": "comment", "email": "Rider@Example.com", "email_opt_in": true}`)
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, digest := db.IsEmailUnsubscribedArgsForCall(0)
+				Expect(digest).To(Equal(unsubscriber.Digest("rider@example.com")))
+
+				_, fb := db.SaveFeedbackArgsForCall(0)
+				Expect(fb.EmailOptIn).To(BeTrue())
+				Expect(fb.Acknowledge).To(BeFalse())
+
+				respBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).To(BeNil())
+				Expect(respBody).To(MatchJSON(`{"email_unsubscribed": true}`))
+			})
+		})
+
+		When("email isn't enabled", func() {
+			BeforeEach(func() {
+				emailEnabled = false
+			})
+			It("records the opt-in without an acknowledgement", func() {
+				resp = serve(client.SaveFeedback, "POST", "/v1/feedback",
+					`{"kind": "comment", "email": "rider@example.com", "email_opt_in": true}`)
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, fb := db.SaveFeedbackArgsForCall(0)
+				Expect(fb.EmailOptIn).To(BeTrue())
+				Expect(fb.Acknowledge).To(BeFalse())
+				Expect(db.IsEmailUnsubscribedCallCount()).To(Equal(0))
+			})
+		})
+
+		It("requires an email to opt in", func() {
+			resp = serve(client.SaveFeedback, "POST", "/v1/feedback",
+				`{"kind": "comment", "email_opt_in": true}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			Expect(db.SaveFeedbackCallCount()).To(Equal(0))
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(respBody)).To(ContainSubstring("email_opt_in"))
+		})
+	})
+
+	Describe("Unsubscribe", func() {
+		It("unsubscribes the address the token was made for", func() {
+			url := unsubscriber.URL("Rider@Example.com")
+			resp = serve(client.Unsubscribe, "POST", strings.TrimPrefix(url, "https://example.com"), "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, digest := db.UnsubscribeEmailArgsForCall(0)
+			Expect(digest).To(Equal(unsubscriber.Digest("rider@example.com")))
+		})
+
+		It("only asks for confirmation when the link is followed", func() {
+			url := unsubscriber.URL("rider@example.com")
+			resp = serve(client.Unsubscribe, "GET", strings.TrimPrefix(url, "https://example.com"), "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+			Expect(resp.Header.Get("Content-Type")).To(HavePrefix("text/html"))
+			Expect(db.UnsubscribeEmailCallCount()).To(Equal(0))
+
+			respBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(respBody)).To(ContainSubstring(`<form method="post">`))
+		})
+
+		It("rejects forged tokens", func() {
+			token := unsubscriber.Token("rider@example.com")
+			forged := token[:64] + strings.Repeat("0", 32)
+			resp = serve(client.Unsubscribe, "GET", "/v1/email/unsubscribe/"+forged, "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			Expect(db.UnsubscribeEmailCallCount()).To(Equal(0))
+		})
+
+		It("fails if the address can't be stored", func() {
+			db.UnsubscribeEmailReturns(errors.New("insert failed"))
+			resp = serve(client.Unsubscribe, "POST", "/v1/email/unsubscribe/"+unsubscriber.Token("rider@example.com"), "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(500))
+		})
+
+		When("email isn't enabled", func() {
+			BeforeEach(func() {
+				emailEnabled = false
+			})
+			It("is not found", func() {
+				resp = serve(client.Unsubscribe, "GET", "/v1/email/unsubscribe/"+unsubscriber.Token("rider@example.com"), "")
+				Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			})
+		})
+	})
+
+	Describe("OutageResolved", func() {
+		It("queues emails for the line and audits it", func() {
+			db.QueueOutageResolvedEmailsReturns(7, nil)
+			resp = serve(client.OutageResolved, "POST", "/v1/admin/outages/resolved",
+				`{"line": "Red", "since": "2020-06-01T00:00:00Z"}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, line, since := db.QueueOutageResolvedEmailsArgsForCall(0)
+			Expect(line).To(PointTo(Equal("red")))
+			Expect(since).To(Equal(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)))
+
+			var body api.OutageResolvedResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+			Expect(body.Queued).To(Equal(7))
+
+			_, entry := auditor.RecordArgsForCall(0)
+			Expect(entry).To(MatchFields(IgnoreExtras, Fields{
+				"Action":    Equal(api.AuditNotifyOutageResolved),
+				"TargetIDs": Equal([]string{"red"}),
+			}))
+		})
+
+		It("defaults to every line over the last day", func() {
+			resp = serve(client.OutageResolved, "POST", "/v1/admin/outages/resolved", `{}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, line, since := db.QueueOutageResolvedEmailsArgsForCall(0)
+			Expect(line).To(BeNil())
+			Expect(since).To(BeTemporally("~", time.Now().Add(-24*time.Hour), time.Minute))
+		})
+
+		It("rejects invalid lines", func() {
+			resp = serve(client.OutageResolved, "POST", "/v1/admin/outages/resolved", `{"line": "red; drop"}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			Expect(db.QueueOutageResolvedEmailsCallCount()).To(Equal(0))
+		})
+	})
+})
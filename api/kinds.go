@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/kinds"
+)
+
+//FeedbackKindRecord describes a kind of feedback riders may submit, and
+//which fields it requires
+type FeedbackKindRecord struct {
+	Name            string `json:"name"`
+	Label           string `json:"label"`
+	ValueRequired   bool   `json:"value_required"`
+	MessageRequired bool   `json:"message_required"`
+	EmailRequired   bool   `json:"email_required"`
+
+	//DetailsSchema is the JSON Schema of the kind's `details` object
+	DetailsSchema json.RawMessage `json:"details_schema,omitempty"`
+}
+
+//FeedbackValueRecord describes a value feedback may carry
+type FeedbackValueRecord struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+}
+
+//FeedbackKindsResponse lists the active kinds and values of feedback, in
+//display order
+type FeedbackKindsResponse struct {
+	Kinds  []FeedbackKindRecord  `json:"kinds"`
+	Values []FeedbackValueRecord `json:"values"`
+}
+
+//FeedbackKinds serves GET /v1/feedback/kinds, so that the app can build its
+//feedback form
+func (c Client) FeedbackKinds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+		return
+	}
+
+	resp := FeedbackKindsResponse{
+		Kinds:  []FeedbackKindRecord{},
+		Values: []FeedbackValueRecord{},
+	}
+	if c.catalog != nil {
+		for _, k := range c.catalog.Kinds() {
+			if !k.Active {
+				continue
+			}
+			resp.Kinds = append(resp.Kinds, FeedbackKindRecord{
+				Name:            k.Name,
+				Label:           k.Label,
+				ValueRequired:   k.ValueRequired,
+				MessageRequired: k.MessageRequired,
+				EmailRequired:   k.EmailRequired,
+				DetailsSchema:   k.DetailsSchema,
+			})
+		}
+		for _, v := range c.catalog.Values() {
+			if !v.Active {
+				continue
+			}
+			resp.Values = append(resp.Values, FeedbackValueRecord{
+				Name:  v.Name,
+				Label: v.Label,
+			})
+		}
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+//findKind looks up a kind, active or not
+func (c Client) findKind(name string) (db.FeedbackKind, bool) {
+	if c.catalog == nil {
+		return db.FeedbackKind{}, false
+	}
+	return kinds.FindKind(c.catalog, name)
+}
+
+//findValue looks up a value, active or not
+func (c Client) findValue(name string) (db.FeedbackValue, bool) {
+	if c.catalog == nil {
+		return db.FeedbackValue{}, false
+	}
+	return kinds.FindValue(c.catalog, name)
+}
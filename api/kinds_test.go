@@ -0,0 +1,54 @@
+package api_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FeedbackKinds", func() {
+	var (
+		client api.Client
+		resp   *http.Response
+	)
+
+	BeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client = api.New(log, &dbfakes.FakeDB{}).WithCatalog(testCatalog())
+	})
+
+	JustBeforeEach(func() {
+		respW := httptest.NewRecorder()
+		client.FeedbackKinds(respW, httptest.NewRequest("GET", "/v1/feedback/kinds", nil))
+		resp = respW.Result()
+	})
+
+	It("lists the active kinds and values in order", func() {
+		Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).To(BeNil())
+		Expect(body).To(MatchJSON(`{
+			"kinds": [
+				{"name": "outage", "label": "Outage", "value_required": false, "message_required": false, "email_required": false},
+				{"name": "service_condition", "label": "Service condition", "value_required": false, "message_required": false, "email_required": false, "details_schema": ` + serviceConditionSchema + `},
+				{"name": "comment", "label": "Comment", "value_required": false, "message_required": false, "email_required": false},
+				{"name": "complaint", "label": "Complaint", "value_required": true, "message_required": true, "email_required": true}
+			],
+			"values": [
+				{"name": "positive", "label": "Positive"},
+				{"name": "neutral", "label": "Neutral"},
+				{"name": "negative", "label": "Negative"}
+			]
+		}`))
+	})
+})
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/gtfs"
+)
+
+//LocationRecord is where a rider was when submitting feedback, and the stop
+//it was resolved to, if any
+type LocationRecord struct {
+	Latitude  float64     `json:"latitude"`
+	Longitude float64     `json:"longitude"`
+	Accuracy  *float64    `json:"accuracy,omitempty"`
+	Stop      *StopRecord `json:"stop,omitempty"`
+}
+
+//StopRecord is a stop resolved from a location. Distance is in meters.
+type StopRecord struct {
+	ID       string   `json:"id"`
+	Name     *string  `json:"name,omitempty"`
+	Distance *float64 `json:"distance,omitempty"`
+}
+
+func locationRecordFromLocation(l *db.Location) *LocationRecord {
+	if l == nil {
+		return nil
+	}
+
+	record := &LocationRecord{
+		Latitude:  l.Latitude,
+		Longitude: l.Longitude,
+		Accuracy:  l.Accuracy,
+	}
+	if l.StopID != nil {
+		record.Stop = &StopRecord{
+			ID:       *l.StopID,
+			Name:     l.StopName,
+			Distance: l.StopDistance,
+		}
+	}
+	return record
+}
+
+//WithStopResolver returns a copy of c that resolves the locations of
+//submissions to the nearest stop
+func (c Client) WithStopResolver(resolver gtfs.Resolver) Client {
+	c.stops = resolver
+	return c
+}
+
+//locate validates the location of a submission and resolves it to a stop.
+//It returns nil if the submission has no location.
+func (c Client) locate(req SaveFeedbackRequest) (*db.Location, error) {
+	if req.Latitude == nil && req.Longitude == nil && req.Accuracy == nil {
+		return nil, nil
+	}
+	if req.Latitude == nil || req.Longitude == nil {
+		return nil, ValidationError{
+			Reason:  "invalid_location",
+			Message: "`latitude` and `longitude` must be given together",
+		}
+	}
+
+	lat, lon := *req.Latitude, *req.Longitude
+	if math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return nil, ValidationError{
+			Reason:  "invalid_location",
+			Message: fmt.Sprintf("invalid location %v, %v", lat, lon),
+		}
+	}
+	if req.Accuracy != nil && *req.Accuracy < 0 {
+		return nil, ValidationError{
+			Reason:  "invalid_location",
+			Message: "`accuracy` must not be negative",
+		}
+	}
+
+	location := &db.Location{
+		Latitude:  lat,
+		Longitude: lon,
+		Accuracy:  req.Accuracy,
+	}
+
+	if c.stops != nil {
+		if match, ok := c.stops.Nearest(lat, lon); ok {
+			id, name, distance := match.Stop.ID, match.Stop.Name, math.Round(match.Distance)
+			location.StopID = &id
+			location.StopName = &name
+			location.StopDistance = &distance
+		}
+	}
+
+	return location, nil
+}
+
+//parseBoundingBox reads the `bbox` query parameter, given as
+//`min longitude,min latitude,max longitude,max latitude` like a GeoJSON
+//bbox. It returns nil if there is no such parameter.
+func parseBoundingBox(query url.Values) (*db.BoundingBox, error) {
+	s := query.Get("bbox")
+	if s == "" {
+		return nil, nil
+	}
+
+	invalid := ValidationError{
+		Reason:  "invalid_bbox",
+		Message: "`bbox` must be `min longitude,min latitude,max longitude,max latitude`",
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, invalid
+	}
+
+	var coords [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, invalid
+		}
+		coords[i] = f
+	}
+
+	box := db.BoundingBox{
+		MinLongitude: coords[0],
+		MinLatitude:  coords[1],
+		MaxLongitude: coords[2],
+		MaxLatitude:  coords[3],
+	}
+	if box.MinLongitude > box.MaxLongitude || box.MinLatitude > box.MaxLatitude ||
+		math.Abs(box.MinLatitude) > 90 || math.Abs(box.MaxLatitude) > 90 ||
+		math.Abs(box.MinLongitude) > 180 || math.Abs(box.MaxLongitude) > 180 {
+		return nil, invalid
+	}
+
+	return &box, nil
+}
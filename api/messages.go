@@ -0,0 +1,315 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/authz"
+	"github.com/smartatransit/feedback/db"
+)
+
+//MaxMessageLength is how many characters a message in a thread may have
+const MaxMessageLength = 5000
+
+//messageRateLimitKind is the kind riders' replies are rate limited as
+const messageRateLimitKind = "message"
+
+//MessageRequest is a new message in the thread of a feedback. Email queues
+//an email to the rider about a staff reply, if the feedback has an email
+//address, and is ignored for riders' messages.
+type MessageRequest struct {
+	Body  string `json:"body"`
+	Email bool   `json:"email"`
+}
+
+//MessageRecord is a message in the thread of a feedback. The author's
+//session and role are only shown to staff.
+type MessageRecord struct {
+	ID            string     `json:"id"`
+	FeedbackID    string     `json:"feedback_id"`
+	Author        string     `json:"author"`
+	AuthorSession *string    `json:"author_session,omitempty"`
+	AuthorRole    *string    `json:"author_role,omitempty"`
+	Body          string     `json:"body"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReadAt        *time.Time `json:"read_at,omitempty"`
+	EmailQueued   bool       `json:"email_queued,omitempty"`
+}
+
+func messageRecordFromMessage(m db.FeedbackMessage, staff bool) MessageRecord {
+	record := MessageRecord{
+		ID:         m.ID,
+		FeedbackID: m.FeedbackID,
+		Author:     m.Author,
+		Body:       m.Body,
+		CreatedAt:  m.CreatedAt,
+		ReadAt:     m.ReadAt,
+	}
+	if staff {
+		record.AuthorSession = &m.AuthorSession
+		record.AuthorRole = &m.AuthorRole
+	}
+	return record
+}
+
+//ThreadResponse is the thread of a feedback, oldest message first
+type ThreadResponse struct {
+	FeedbackID string          `json:"feedback_id"`
+	Messages   []MessageRecord `json:"messages"`
+}
+
+//ThreadRecord summarizes the thread of a feedback. Unread counts the
+//messages from the other party not yet seen by the reader.
+type ThreadRecord struct {
+	FeedbackID    string    `json:"feedback_id"`
+	Kind          string    `json:"kind"`
+	Messages      int       `json:"messages"`
+	Unread        int       `json:"unread"`
+	LastMessageAt time.Time `json:"last_message_at"`
+}
+
+//ThreadListResponse lists threads, most recently active first
+type ThreadListResponse struct {
+	Threads []ThreadRecord `json:"threads"`
+}
+
+//Threads serves GET /v1/feedback/threads, listing the threads of the
+//rider's own feedback. With `unread=true`, only threads with unread staff
+//replies are listed.
+func (c Client) Threads(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+		return
+	}
+
+	session := r.Header.Get("X-Smarta-Auth-Session")
+	c.listThreads(w, r, db.ThreadFilter{SessionID: &session, Reader: db.AuthorRider})
+}
+
+//AdminThreads serves GET /v1/admin/threads, listing every thread. With
+//`unread=true`, only threads with unread rider messages are listed.
+func (c Client) AdminThreads(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+		return
+	}
+
+	c.listThreads(w, r, db.ThreadFilter{Reader: db.AuthorStaff})
+}
+
+func (c Client) listThreads(w http.ResponseWriter, r *http.Request, filter db.ThreadFilter) {
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
+	switch r.URL.Query().Get("unread") {
+	case "", "false":
+	case "true":
+		filter.UnreadOnly = true
+	default:
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_unread",
+			Message: "`unread` must be `true` or `false`",
+		})
+		return
+	}
+
+	threads, err := c.db.ListFeedbackThreads(r.Context(), filter, limit, offset)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to list threads")
+		return
+	}
+
+	resp := ThreadListResponse{Threads: []ThreadRecord{}}
+	for _, t := range threads {
+		resp.Threads = append(resp.Threads, ThreadRecord{
+			FeedbackID:    t.FeedbackID,
+			Kind:          t.Kind,
+			Messages:      t.Messages,
+			Unread:        t.Unread,
+			LastMessageAt: t.LastMessageAt,
+		})
+	}
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+//Thread serves the rider side of the thread of their own feedback under
+///v1/feedback/threads/:
+//
+//  GET /v1/feedback/threads/{feedback id} returns the thread, marking staff replies read
+//  POST /v1/feedback/threads/{feedback id} replies to it
+func (c Client) Thread(w http.ResponseWriter, r *http.Request) {
+	id, ok := feedbackIDFromPath(r, "/v1/feedback/threads/")
+	if !ok {
+		c.writeErrorResponse(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	session := r.Header.Get("X-Smarta-Auth-Session")
+	switch r.Method {
+	case "GET":
+		c.getThread(w, r, id, &session)
+	case "POST":
+		c.postMessage(w, r, id, db.AuthorRider)
+	default:
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET or POST instead")
+	}
+}
+
+//getThread writes the thread of a feedback and marks the other party's
+//messages in it read. Staff pass a nil session. Riders get a 404 for
+//feedback that isn't theirs, as when posting to it.
+func (c Client) getThread(w http.ResponseWriter, r *http.Request, id string, session *string) {
+	messages, err := c.db.ListFeedbackMessages(r.Context(), id, session)
+	if errors.Is(err, db.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get thread")
+		return
+	}
+
+	staff := session == nil
+	unreadAuthor := db.AuthorStaff
+	if staff {
+		unreadAuthor = db.AuthorRider
+	}
+
+	resp := ThreadResponse{FeedbackID: id, Messages: []MessageRecord{}}
+	unread := false
+	for _, m := range messages {
+		resp.Messages = append(resp.Messages, messageRecordFromMessage(m, staff))
+		unread = unread || (m.Author == unreadAuthor && m.ReadAt == nil)
+	}
+
+	if unread {
+		if err := c.db.MarkFeedbackMessagesRead(r.Context(), id, unreadAuthor, session); err != nil {
+			c.logger(r.Context()).Error(err.Error())
+		}
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+//postMessage adds a message by author to the thread of a feedback. Riders
+//may only write to the threads of their own feedback.
+func (c Client) postMessage(w http.ResponseWriter, r *http.Request, id, author string) {
+	var req MessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "missing_body",
+			Message: "`body` is required",
+		})
+		return
+	}
+	if utf8.RuneCountInString(body) > MaxMessageLength {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "body_too_long",
+			Message: fmt.Sprintf("`body` may be at most %d characters", MaxMessageLength),
+		})
+		return
+	}
+
+	session := r.Header.Get("X-Smarta-Auth-Session")
+	role := r.Header.Get("X-Smarta-Auth-Role")
+
+	if author == db.AuthorRider {
+		req.Email = false
+
+		if c.limiter != nil {
+			decision, err := c.limiter.Allow(r.Context(), session, role, messageRateLimitKind)
+			if err != nil {
+				c.logger(r.Context()).Error(err.Error())
+			} else if !decision.Allowed {
+				retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				c.writeErrorResponse(w, http.StatusTooManyRequests, "too many messages, try again later")
+				return
+			}
+		}
+
+		if c.redactor != nil {
+			body, _ = c.redactor.Redact(body)
+		}
+	}
+
+	var (
+		message db.FeedbackMessage
+		queued  bool
+	)
+	err := c.db.InTransaction(r.Context(), func(ctx context.Context) (err error) {
+		message, queued, err = c.db.SaveFeedbackMessage(ctx, db.FeedbackMessage{
+			FeedbackID:    id,
+			Author:        author,
+			AuthorSession: session,
+			AuthorRole:    role,
+			Body:          body,
+		}, req.Email)
+		if err != nil || author != db.AuthorStaff {
+			return err
+		}
+
+		return c.recordAudit(ctx, audit.Entry{
+			ActorSession: session,
+			ActorRole:    role,
+			Action:       AuditReplyToFeedback,
+			TargetType:   "feedback",
+			TargetIDs:    []string{id},
+			After: map[string]interface{}{
+				"message_id":   message.ID,
+				"email_queued": queued,
+			},
+		})
+	})
+	if errors.Is(err, db.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to save message")
+		return
+	}
+
+	record := messageRecordFromMessage(message, author == db.AuthorStaff)
+	record.EmailQueued = queued
+	c.writeJSONResponse(w, http.StatusCreated, record)
+}
+
+//adminMessages serves /v1/admin/feedback/{id}/messages. Replying also
+//requires the reply permission.
+func (c Client) adminMessages(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case "GET":
+		c.getThread(w, r, id, nil)
+	case "POST":
+		if c.authorize(w, r, authz.Reply) {
+			c.postMessage(w, r, id, db.AuthorStaff)
+		}
+	default:
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET or POST instead")
+	}
+}
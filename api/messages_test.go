@@ -0,0 +1,247 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/audit/auditfakes"
+	"github.com/smartatransit/feedback/authz"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/redact"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+const threadFeedbackID = "3f1c2a9e-7b4d-4e8a-9c21-5d6e7f8a9b0c"
+
+var _ = Describe("Threads", func() {
+	var (
+		db      *dbfakes.FakeDB
+		auditor *auditfakes.FakeRecorder
+		client  api.Client
+
+		req  *http.Request
+		resp *http.Response
+	)
+
+	BeforeEach(func() {
+		db = newFakeDB()
+		auditor = &auditfakes.FakeRecorder{}
+		db.SaveFeedbackMessageStub = func(_ context.Context, m dbp.FeedbackMessage, queueEmail bool) (dbp.FeedbackMessage, bool, error) {
+			m.ID = "message-id"
+			m.SessionID = "rider-session"
+			m.CreatedAt = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+			return m, queueEmail, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client = api.New(log, db).
+			WithAuthorization(testPolicy()).
+			WithCatalog(testCatalog()).
+			WithAuditLog(auditor).
+			WithRedaction(redact.New(), false)
+	})
+
+	serve := func(handler http.HandlerFunc, method, path, role, body string) *http.Response {
+		req = httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Smarta-Auth-Session", role+"-session")
+		req.Header.Set("X-Smarta-Auth-Role", role)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Result()
+	}
+
+	Describe("AdminFeedback messages", func() {
+		It("posts a staff reply, queueing an email and auditing it", func() {
+			resp = serve(client.AdminFeedback, "POST", "/v1/admin/feedback/"+threadFeedbackID+"/messages", "admin",
+				`{"body": " Thanks, the elevator has been fixed. ", "email": true}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(201))
+
+			_, m, queueEmail := db.SaveFeedbackMessageArgsForCall(0)
+			Expect(m).To(MatchFields(IgnoreExtras, Fields{
+				"FeedbackID":    Equal(threadFeedbackID),
+				"Author":        Equal("staff"),
+				"AuthorSession": Equal("admin-session"),
+				"AuthorRole":    Equal("admin"),
+				"Body":          Equal("Thanks, the elevator has been fixed."),
+			}))
+			Expect(queueEmail).To(BeTrue())
+
+			var record map[string]interface{}
+			Expect(json.NewDecoder(resp.Body).Decode(&record)).To(Succeed())
+			Expect(record).To(HaveKeyWithValue("email_queued", true))
+			Expect(record).To(HaveKeyWithValue("author_session", "admin-session"))
+
+			Expect(auditor.RecordCallCount()).To(Equal(1))
+			_, entry := auditor.RecordArgsForCall(0)
+			Expect(entry).To(MatchFields(IgnoreExtras, Fields{
+				"Action":    Equal(api.AuditReplyToFeedback),
+				"TargetIDs": Equal([]string{threadFeedbackID}),
+			}))
+		})
+
+		It("requires the reply permission to post", func() {
+			resp = serve(client.AdminFeedback, "POST", "/v1/admin/feedback/"+threadFeedbackID+"/messages", "auditor",
+				`{"body": "hello"}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(403))
+			Expect(db.SaveFeedbackMessageCallCount()).To(Equal(0))
+		})
+
+		It("rejects empty replies", func() {
+			resp = serve(client.AdminFeedback, "POST", "/v1/admin/feedback/"+threadFeedbackID+"/messages", "admin",
+				`{"body": "   "}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+		})
+
+		It("fails for feedback that doesn't exist", func() {
+			db.SaveFeedbackMessageReturns(dbp.FeedbackMessage{}, false, dbp.ErrNotFound)
+			db.SaveFeedbackMessageStub = nil
+			resp = serve(client.AdminFeedback, "POST", "/v1/admin/feedback/"+threadFeedbackID+"/messages", "admin",
+				`{"body": "hello"}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			Expect(auditor.RecordCallCount()).To(Equal(0))
+		})
+
+		It("returns the thread and marks the rider's messages read", func() {
+			db.ListFeedbackMessagesReturns([]dbp.FeedbackMessage{
+				{ID: "1", FeedbackID: threadFeedbackID, Author: "staff", AuthorSession: "admin-session", AuthorRole: "admin", Body: "Which station?"},
+				{ID: "2", FeedbackID: threadFeedbackID, Author: "rider", AuthorSession: "rider-session", AuthorRole: "anonymous", Body: "Five Points"},
+			}, nil)
+
+			resp = serve(client.AdminFeedback, "GET", "/v1/admin/feedback/"+threadFeedbackID+"/messages", "auditor", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, id, session := db.ListFeedbackMessagesArgsForCall(0)
+			Expect(id).To(Equal(threadFeedbackID))
+			Expect(session).To(BeNil())
+
+			_, id, author, session := db.MarkFeedbackMessagesReadArgsForCall(0)
+			Expect(id).To(Equal(threadFeedbackID))
+			Expect(author).To(Equal("rider"))
+			Expect(session).To(BeNil())
+		})
+	})
+
+	Describe("Thread", func() {
+		It("only lists the rider's own thread, hiding staff identities", func() {
+			db.ListFeedbackMessagesReturns([]dbp.FeedbackMessage{
+				{ID: "1", FeedbackID: threadFeedbackID, Author: "staff", AuthorSession: "admin-session", AuthorRole: "admin", Body: "Which station?"},
+			}, nil)
+
+			resp = serve(client.Thread, "GET", "/v1/feedback/threads/"+threadFeedbackID, "anonymous", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, _, session := db.ListFeedbackMessagesArgsForCall(0)
+			Expect(session).To(PointTo(Equal("anonymous-session")))
+
+			body, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(body).To(MatchJSON(`{
+				"feedback_id": "` + threadFeedbackID + `",
+				"messages": [{
+					"id": "1",
+					"feedback_id": "` + threadFeedbackID + `",
+					"author": "staff",
+					"body": "Which station?",
+					"created_at": "0001-01-01T00:00:00Z"
+				}]
+			}`))
+
+			_, _, author, session := db.MarkFeedbackMessagesReadArgsForCall(0)
+			Expect(author).To(Equal("staff"))
+			Expect(session).To(PointTo(Equal("anonymous-session")))
+		})
+
+		It("doesn't show another rider's thread", func() {
+			db.ListFeedbackMessagesReturns(nil, dbp.ErrNotFound)
+			resp = serve(client.Thread, "GET", "/v1/feedback/threads/"+threadFeedbackID, "anonymous", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			Expect(db.MarkFeedbackMessagesReadCallCount()).To(Equal(0))
+		})
+
+		It("doesn't mark anything read when nothing is unread", func() {
+			db.ListFeedbackMessagesReturns([]dbp.FeedbackMessage{}, nil)
+			resp = serve(client.Thread, "GET", "/v1/feedback/threads/"+threadFeedbackID, "anonymous", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+			Expect(db.MarkFeedbackMessagesReadCallCount()).To(Equal(0))
+		})
+
+		It("posts a redacted rider reply without email", func() {
+			resp = serve(client.Thread, "POST", "/v1/feedback/threads/"+threadFeedbackID, "anonymous",
+				`{"body": "Five Points, call me at 404-555-0134", "email": true}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(201))
+
+			_, m, queueEmail := db.SaveFeedbackMessageArgsForCall(0)
+			Expect(m.Author).To(Equal("rider"))
+			Expect(m.AuthorSession).To(Equal("anonymous-session"))
+			Expect(m.Body).NotTo(ContainSubstring("404-555-0134"))
+			Expect(queueEmail).To(BeFalse())
+			Expect(auditor.RecordCallCount()).To(Equal(0))
+		})
+
+		It("rejects paths that aren't a feedback ID", func() {
+			resp = serve(client.Thread, "GET", "/v1/feedback/threads/nope", "anonymous", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(404))
+		})
+	})
+
+	Describe("Threads", func() {
+		It("lists the rider's threads with unread staff replies", func() {
+			db.ListFeedbackThreadsReturns([]dbp.FeedbackThread{{
+				FeedbackID:    threadFeedbackID,
+				Kind:          "comment",
+				Messages:      2,
+				Unread:        1,
+				LastMessageAt: time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC),
+			}}, nil)
+
+			resp = serve(client.Threads, "GET", "/v1/feedback/threads?unread=true", "anonymous", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, filter, limit, offset := db.ListFeedbackThreadsArgsForCall(0)
+			Expect(filter).To(MatchAllFields(Fields{
+				"SessionID":  PointTo(Equal("anonymous-session")),
+				"Reader":     Equal("rider"),
+				"UnreadOnly": BeTrue(),
+			}))
+			Expect(limit).To(Equal(50))
+			Expect(offset).To(Equal(0))
+
+			body, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(body).To(MatchJSON(`{"threads": [{
+				"feedback_id": "` + threadFeedbackID + `",
+				"kind": "comment",
+				"messages": 2,
+				"unread": 1,
+				"last_message_at": "2020-06-01T12:00:00Z"
+			}]}`))
+		})
+
+		It("lists every thread for staff", func() {
+			db.ListFeedbackThreadsReturns(nil, errors.New("select failed"))
+			resp = serve(client.Require(authz.Read, client.AdminThreads), "GET", "/v1/admin/threads", "auditor", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(500))
+
+			_, filter, _, _ := db.ListFeedbackThreadsArgsForCall(0)
+			Expect(filter.SessionID).To(BeNil())
+			Expect(filter.Reader).To(Equal("staff"))
+			Expect(filter.UnreadOnly).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/db"
+)
+
+//ModerationPolicy decides which submissions are held for review. Feedback
+//of a bypassed kind or from a bypassed role is approved immediately.
+type ModerationPolicy struct {
+	BypassKinds map[string]struct{}
+	BypassRoles map[string]struct{}
+}
+
+//NewModerationPolicy returns a ModerationPolicy bypassing the given kinds and roles
+func NewModerationPolicy(bypassKinds, bypassRoles []string) ModerationPolicy {
+	p := ModerationPolicy{
+		BypassKinds: map[string]struct{}{},
+		BypassRoles: map[string]struct{}{},
+	}
+	for _, kind := range bypassKinds {
+		p.BypassKinds[strings.TrimSpace(kind)] = struct{}{}
+	}
+	for _, role := range bypassRoles {
+		p.BypassRoles[strings.TrimSpace(role)] = struct{}{}
+	}
+	return p
+}
+
+func (p ModerationPolicy) initialStatus(kind, role string) string {
+	if _, ok := p.BypassKinds[kind]; ok {
+		return db.ModerationApproved
+	}
+	if _, ok := p.BypassRoles[role]; ok {
+		return db.ModerationApproved
+	}
+	return db.ModerationPending
+}
+
+//WithModerationPolicy returns a copy of c that holds submissions for review
+//according to policy. Without a policy every submission is approved.
+func (c Client) WithModerationPolicy(policy ModerationPolicy) Client {
+	c.moderation = &policy
+	return c
+}
+
+func (c Client) initialModerationStatus(kind, role string) string {
+	if c.moderation == nil {
+		return db.ModerationApproved
+	}
+	return c.moderation.initialStatus(kind, role)
+}
+
+//ModerationQueueResponse lists feedback in a moderation status
+type ModerationQueueResponse struct {
+	Feedback []FeedbackRecord `json:"feedback"`
+}
+
+//ModerationDecisionRequest approves or rejects a held feedback
+type ModerationDecisionRequest struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
+
+//SilenceRequest silences or unsilences a feedback. Silenced outage reports
+//are left out of the health check.
+type SilenceRequest struct {
+	Silenced *bool `json:"silenced"`
+}
+
+//ModerationHistoryResponse lists the decisions made about a feedback
+type ModerationHistoryResponse struct {
+	Decisions []ModerationDecisionRecord `json:"decisions"`
+}
+
+//ModerationDecisionRecord is a single moderation decision
+type ModerationDecisionRecord struct {
+	ID               string    `json:"id"`
+	Decision         string    `json:"decision"`
+	Reason           *string   `json:"reason,omitempty"`
+	ModeratorSession string    `json:"moderator_session"`
+	ModeratorRole    string    `json:"moderator_role"`
+	DecidedAt        time.Time `json:"decided_at"`
+}
+
+var moderationDecisions = map[string]string{
+	"approve": db.ModerationApproved,
+	"reject":  db.ModerationRejected,
+}
+
+var moderationStatuses = map[string]struct{}{
+	db.ModerationPending:  {},
+	db.ModerationApproved: {},
+	db.ModerationRejected: {},
+}
+
+//ModerationQueue lists feedback awaiting moderation, oldest first. The
+//`status` query parameter selects approved or rejected feedback instead.
+func (c Client) ModerationQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = db.ModerationPending
+	}
+	if _, ok := moderationStatuses[status]; !ok {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_status",
+			Message: fmt.Sprintf("invalid value `%s` for `status`", status),
+		})
+		return
+	}
+
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
+	fbs, err := c.db.ListFeedbackByModerationStatus(r.Context(), status, limit, offset)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to list feedback")
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, ModerationQueueResponse{
+		Feedback: feedbackRecordsFromFeedbackList(fbs),
+	})
+}
+
+//Moderation serves /v1/admin/moderation/{id}: GET returns the decision
+//history of a feedback and POST approves or rejects it.
+func (c Client) Moderation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET or POST instead")
+		return
+	}
+
+	id, ok := feedbackIDFromPath(r, "/v1/admin/moderation/")
+	if !ok {
+		c.writeErrorResponse(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+
+	if r.Method == "GET" {
+		c.getModerationHistory(w, r, id)
+		return
+	}
+
+	var req ModerationDecisionRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
+		return
+	}
+
+	status, ok := moderationDecisions[strings.ToLower(req.Decision)]
+	if !ok {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_decision",
+			Message: fmt.Sprintf("invalid value `%s` for `decision`", req.Decision),
+		})
+		return
+	}
+
+	decision := db.ModerationDecision{
+		FeedbackID:       id,
+		Decision:         status,
+		ModeratorSession: r.Header.Get("X-Smarta-Auth-Session"),
+		ModeratorRole:    r.Header.Get("X-Smarta-Auth-Role"),
+	}
+	if req.Reason != "" {
+		decision.Reason = &req.Reason
+	} else if status == db.ModerationRejected {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "missing_reason",
+			Message: "a `reason` is required when rejecting feedback",
+		})
+		return
+	}
+
+	err = c.db.InTransaction(r.Context(), func(ctx context.Context) error {
+		previous, err := c.db.ModerateFeedback(ctx, decision)
+		if err != nil {
+			return err
+		}
+
+		return c.recordAudit(ctx, audit.Entry{
+			ActorSession: decision.ModeratorSession,
+			ActorRole:    decision.ModeratorRole,
+			Action:       AuditModerateFeedback,
+			TargetType:   "feedback",
+			TargetIDs:    []string{id},
+			Before:       map[string]interface{}{"moderation_status": previous},
+			After:        map[string]interface{}{"moderation_status": status, "reason": decision.Reason},
+		})
+	})
+	if errors.Is(err, db.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to moderate feedback")
+		return
+	}
+}
+
+//Silence serves POST /v1/admin/silence/{id}, silencing or unsilencing a
+//feedback
+func (c Client) Silence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use POST instead")
+		return
+	}
+
+	id, ok := feedbackIDFromPath(r, "/v1/admin/silence/")
+	if !ok {
+		c.writeErrorResponse(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+
+	var req SilenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
+		return
+	}
+	if req.Silenced == nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "missing_silenced",
+			Message: "`silenced` is required",
+		})
+		return
+	}
+
+	err := c.db.InTransaction(r.Context(), func(ctx context.Context) error {
+		previous, err := c.db.SilenceFeedback(ctx, id, *req.Silenced)
+		if err != nil {
+			return err
+		}
+
+		return c.recordAudit(ctx, audit.Entry{
+			ActorSession: r.Header.Get("X-Smarta-Auth-Session"),
+			ActorRole:    r.Header.Get("X-Smarta-Auth-Role"),
+			Action:       AuditSilenceFeedback,
+			TargetType:   "feedback",
+			TargetIDs:    []string{id},
+			Before:       map[string]bool{"silenced": previous},
+			After:        map[string]bool{"silenced": *req.Silenced},
+		})
+	})
+	if errors.Is(err, db.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to silence feedback")
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, req)
+}
+
+func (c Client) getModerationHistory(w http.ResponseWriter, r *http.Request, id string) {
+	decisions, err := c.db.GetModerationDecisions(r.Context(), id)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get moderation history")
+		return
+	}
+
+	resp := ModerationHistoryResponse{Decisions: []ModerationDecisionRecord{}}
+	for _, d := range decisions {
+		resp.Decisions = append(resp.Decisions, ModerationDecisionRecord{
+			ID:               d.ID,
+			Decision:         d.Decision,
+			Reason:           d.Reason,
+			ModeratorSession: d.ModeratorSession,
+			ModeratorRole:    d.ModeratorRole,
+			DecidedAt:        d.DecidedAt,
+		})
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
@@ -0,0 +1,614 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/audit/auditfakes"
+	"github.com/smartatransit/feedback/authz"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+var _ = Describe("Moderation", func() {
+	var (
+		db      *dbfakes.FakeDB
+		auditor *auditfakes.FakeRecorder
+
+		client api.Client
+
+		body interface{}
+		req  *http.Request
+		resp *http.Response
+	)
+
+	const feedbackID = "6f1d6a3e-0c43-4bb8-9c39-3b8f3e0f1e59"
+
+	BeforeEach(func() {
+		db = newFakeDB()
+		auditor = &auditfakes.FakeRecorder{}
+
+		body = nil
+		req = httptest.NewRequest("GET", "/v1/admin/moderation", nil)
+		req.Header.Set("X-Smarta-Auth-Session", "mod-session")
+		req.Header.Set("X-Smarta-Auth-Role", "admin")
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client = api.New(log, db).
+			WithAuthorization(testPolicy()).
+			WithAuditLog(auditor).
+			WithCatalog(testCatalog())
+
+		if body != nil {
+			bodyBytes, err := json.Marshal(body)
+			Expect(err).To(BeNil())
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+		}
+	})
+
+	Describe("ModerationQueue", func() {
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.Require(authz.Moderate, client.ModerationQueue)(respW, req)
+			resp = respW.Result()
+		})
+
+		When("the role lacks the permission", func() {
+			BeforeEach(func() {
+				req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(403))
+				Expect(db.ListFeedbackByModerationStatusCallCount()).To(Equal(0))
+			})
+		})
+		When("the status is invalid", func() {
+			BeforeEach(func() {
+				req.URL.RawQuery = "status=sdf"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("the limit is invalid", func() {
+			BeforeEach(func() {
+				req.URL.RawQuery = "limit=100000"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("the listing fails", func() {
+			BeforeEach(func() {
+				db.ListFeedbackByModerationStatusReturns(nil, errors.New("select failed"))
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(500))
+			})
+		})
+		When("all goes well", func() {
+			BeforeEach(func() {
+				req.URL.RawQuery = "limit=10&offset=20"
+				db.ListFeedbackByModerationStatusReturns([]dbp.Feedback{{
+					ID:               feedbackID,
+					Kind:             "comment",
+					Message:          ptrToString("hello"),
+					ModerationStatus: "pending",
+				}}, nil)
+			})
+			It("lists pending feedback", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, status, limit, offset := db.ListFeedbackByModerationStatusArgsForCall(0)
+				Expect(status).To(Equal("pending"))
+				Expect(limit).To(Equal(10))
+				Expect(offset).To(Equal(20))
+
+				var respObj api.ModerationQueueResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+				Expect(respObj.Feedback).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+					"ID":               Equal(feedbackID),
+					"Message":          PointTo(Equal("hello")),
+					"ModerationStatus": Equal("pending"),
+				})))
+			})
+		})
+	})
+
+	Describe("Moderation", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest("POST", "/v1/admin/moderation/"+feedbackID, nil)
+			req.Header.Set("X-Smarta-Auth-Session", "mod-session")
+			req.Header.Set("X-Smarta-Auth-Role", "admin")
+			body = &api.ModerationDecisionRequest{
+				Decision: "Reject",
+				Reason:   "contains a phone number",
+			}
+		})
+
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.Require(authz.Moderate, client.Moderation)(respW, req)
+			resp = respW.Result()
+		})
+
+		When("the role lacks the permission", func() {
+			BeforeEach(func() {
+				req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(403))
+			})
+		})
+		When("the ID is malformed", func() {
+			BeforeEach(func() {
+				req.URL.Path = "/v1/admin/moderation/not-an-id"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			})
+		})
+		When("the decision is invalid", func() {
+			BeforeEach(func() {
+				body.(*api.ModerationDecisionRequest).Decision = "maybe"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("a rejection has no reason", func() {
+			BeforeEach(func() {
+				body.(*api.ModerationDecisionRequest).Reason = ""
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("the feedback doesn't exist", func() {
+			BeforeEach(func() {
+				db.ModerateFeedbackReturns("", dbp.ErrNotFound)
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			})
+		})
+		When("the update fails", func() {
+			BeforeEach(func() {
+				db.ModerateFeedbackReturns("", errors.New("update failed"))
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(500))
+			})
+		})
+		When("the update fails", func() {
+			BeforeEach(func() {
+				db.ModerateFeedbackReturns("", errors.New("update failed"))
+			})
+			It("records nothing in the audit log", func() {
+				Expect(auditor.RecordCallCount()).To(Equal(0))
+			})
+		})
+		When("the change can't be audited", func() {
+			BeforeEach(func() {
+				db.ModerateFeedbackReturns("pending", nil)
+				auditor.RecordReturns(errors.New("insert failed"))
+			})
+			It("fails the transaction making the change, so it is rolled back", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(500))
+				Expect(db.InTransactionCallCount()).To(Equal(1))
+				Expect(db.ModerateFeedbackCallCount()).To(Equal(1))
+				body, err := ioutil.ReadAll(resp.Body)
+				Expect(err).To(BeNil())
+				Expect(string(body)).To(ContainSubstring("failed to moderate feedback"))
+			})
+		})
+		When("all goes well", func() {
+			BeforeEach(func() {
+				db.ModerateFeedbackReturns("pending", nil)
+			})
+			It("records the change in the audit log", func() {
+				_, entry := auditor.RecordArgsForCall(0)
+				reason := "contains a phone number"
+				Expect(entry).To(Equal(audit.Entry{
+					ActorSession: "mod-session",
+					ActorRole:    "admin",
+					Action:       api.AuditModerateFeedback,
+					TargetType:   "feedback",
+					TargetIDs:    []string{feedbackID},
+					Before:       map[string]interface{}{"moderation_status": "pending"},
+					After:        map[string]interface{}{"moderation_status": "rejected", "reason": &reason},
+				}))
+			})
+			It("records the decision", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, decision := db.ModerateFeedbackArgsForCall(0)
+				Expect(decision).To(MatchAllFields(Fields{
+					"ID":               BeEmpty(),
+					"DecidedAt":        BeZero(),
+					"FeedbackID":       Equal(feedbackID),
+					"Decision":         Equal("rejected"),
+					"Reason":           PointTo(Equal("contains a phone number")),
+					"ModeratorSession": Equal("mod-session"),
+					"ModeratorRole":    Equal("admin"),
+				}))
+			})
+		})
+		When("the history is requested", func() {
+			BeforeEach(func() {
+				req.Method = "GET"
+				body = nil
+				db.GetModerationDecisionsReturns([]dbp.ModerationDecision{{
+					ID:         "d1",
+					FeedbackID: feedbackID,
+					Decision:   "approved",
+					DecidedAt:  time.Now(),
+				}}, nil)
+			})
+			It("lists prior decisions", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				var respObj api.ModerationHistoryResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+				Expect(respObj.Decisions).To(HaveLen(1))
+				Expect(respObj.Decisions[0].Decision).To(Equal("approved"))
+			})
+		})
+	})
+
+	Describe("ListFeedback", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest("GET", "/v1/admin/feedback?email=rider@example.com", nil)
+			req.Header.Set("X-Smarta-Auth-Session", "mod-session")
+			req.Header.Set("X-Smarta-Auth-Role", "admin")
+		})
+
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.Require(authz.Read, client.ListFeedback)(respW, req)
+			resp = respW.Result()
+		})
+
+		When("the role lacks the permission", func() {
+			BeforeEach(func() {
+				req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(403))
+			})
+		})
+		When("no email or kind is given", func() {
+			BeforeEach(func() {
+				req.URL.RawQuery = ""
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("both an email and a kind are given", func() {
+			BeforeEach(func() {
+				req.URL.RawQuery = "email=rider@example.com&kind=comment"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("a kind is given", func() {
+			BeforeEach(func() {
+				req.URL.RawQuery = "kind=Service_Condition"
+				db.ListFeedbackReturns([]dbp.Feedback{{
+					ID:      feedbackID,
+					Details: []byte(`{"crowding": "full"}`),
+				}}, nil)
+			})
+			It("lists feedback of that kind", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, filter, limit, _ := db.ListFeedbackArgsForCall(0)
+				Expect(*filter.Kind).To(Equal("service_condition"))
+				Expect(filter.Details).To(BeNil())
+				Expect(filter.Area).To(BeNil())
+				Expect(limit).To(Equal(50))
+
+				var respObj api.FeedbackListResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+				Expect(respObj.Feedback).To(HaveLen(1))
+				Expect(respObj.Feedback[0].Details).To(MatchJSON(`{"crowding": "full"}`))
+			})
+
+			When("details filters are given", func() {
+				BeforeEach(func() {
+					req.URL.RawQuery += "&details.crowding=full&details.cleanliness=2&details.vehicle.accessible=true&details.note=7"
+				})
+				It("converts them to the types in the kind's schema", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+					_, filter, _, _ := db.ListFeedbackArgsForCall(0)
+					Expect(filter.Details).To(MatchJSON(`{
+						"crowding": "full",
+						"cleanliness": 2,
+						"vehicle": {"accessible": true},
+						"note": "7"
+					}`))
+				})
+			})
+			When("a details filter doesn't fit the schema", func() {
+				BeforeEach(func() {
+					req.URL.RawQuery += "&details.cleanliness=spotless"
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+					Expect(db.ListFeedbackCallCount()).To(Equal(0))
+				})
+			})
+			When("details filters conflict", func() {
+				BeforeEach(func() {
+					req.URL.RawQuery += "&details.vehicle=bus&details.vehicle.accessible=true"
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				})
+			})
+		})
+		When("a bounding box is given", func() {
+			BeforeEach(func() {
+				req.URL.RawQuery = "bbox=-84.40,33.74,-84.38,33.77"
+				stopID, distance := "PEACHTREE", 40.0
+				db.ListFeedbackReturns([]dbp.Feedback{{
+					ID: feedbackID,
+					Location: &dbp.Location{
+						Latitude:     33.7597,
+						Longitude:    -84.3874,
+						StopID:       &stopID,
+						StopDistance: &distance,
+					},
+				}}, nil)
+			})
+			It("lists feedback located within it", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, filter, _, _ := db.ListFeedbackArgsForCall(0)
+				Expect(filter.Kind).To(BeNil())
+				Expect(filter.Area).To(Equal(&dbp.BoundingBox{
+					MinLatitude:  33.74,
+					MinLongitude: -84.40,
+					MaxLatitude:  33.77,
+					MaxLongitude: -84.38,
+				}))
+
+				respBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).To(BeNil())
+				Expect(string(respBody)).To(ContainSubstring(
+					`"location":{"latitude":33.7597,"longitude":-84.3874,"stop":{"id":"PEACHTREE","distance":40}}`,
+				))
+			})
+
+			When("it is inverted", func() {
+				BeforeEach(func() {
+					req.URL.RawQuery = "bbox=-84.38,33.74,-84.40,33.77"
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+					Expect(db.ListFeedbackCallCount()).To(Equal(0))
+				})
+			})
+			When("details filters are given without a kind", func() {
+				BeforeEach(func() {
+					req.URL.RawQuery += "&details.crowding=full"
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				})
+			})
+		})
+		When("an unknown kind is given", func() {
+			BeforeEach(func() {
+				req.URL.RawQuery = "kind=sdf"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("the listing fails", func() {
+			BeforeEach(func() {
+				db.ListFeedbackByEmailReturns(nil, errors.New("select failed"))
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(500))
+			})
+		})
+		When("all goes well", func() {
+			BeforeEach(func() {
+				db.ListFeedbackByEmailReturns([]dbp.Feedback{{
+					ID:    feedbackID,
+					Email: ptrToString("rider@example.com"),
+				}}, nil)
+			})
+			It("lists the rider's feedback", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, email, limit, offset := db.ListFeedbackByEmailArgsForCall(0)
+				Expect(email).To(Equal("rider@example.com"))
+				Expect(limit).To(Equal(50))
+				Expect(offset).To(Equal(0))
+
+				var respObj api.FeedbackListResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+				Expect(respObj.Feedback).To(HaveLen(1))
+				Expect(respObj.Feedback[0].Email).To(PointTo(Equal("rider@example.com")))
+			})
+		})
+	})
+
+	Describe("AdminFeedback", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest("GET", "/v1/admin/feedback/"+feedbackID+"/original", nil)
+			req.Header.Set("X-Smarta-Auth-Session", "mod-session")
+			req.Header.Set("X-Smarta-Auth-Role", "admin")
+		})
+
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.Require(authz.Read, client.AdminFeedback)(respW, req)
+			resp = respW.Result()
+		})
+
+		When("the role lacks the permission", func() {
+			BeforeEach(func() {
+				req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(403))
+				Expect(db.GetOriginalMessageCallCount()).To(Equal(0))
+			})
+		})
+		When("the role may read but not unredact", func() {
+			BeforeEach(func() {
+				req.Header.Set("X-Smarta-Auth-Role", "auditor")
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(403))
+				Expect(db.GetOriginalMessageCallCount()).To(Equal(0))
+				Expect(auditor.RecordCallCount()).To(Equal(0))
+			})
+		})
+		When("the path is unknown", func() {
+			BeforeEach(func() {
+				req.URL.Path = "/v1/admin/feedback/" + feedbackID + "/sdf"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			})
+		})
+		When("the feedback doesn't exist", func() {
+			BeforeEach(func() {
+				db.GetOriginalMessageReturns(nil, dbp.ErrNotFound)
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(404))
+				Expect(auditor.RecordCallCount()).To(Equal(0))
+			})
+		})
+		When("the lookup fails", func() {
+			BeforeEach(func() {
+				db.GetOriginalMessageReturns(nil, errors.New("select failed"))
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(500))
+			})
+		})
+		When("the read can't be audited", func() {
+			BeforeEach(func() {
+				db.GetOriginalMessageReturns(ptrToString("call me at 404-555-0123"), nil)
+				auditor.RecordReturns(errors.New("insert failed"))
+			})
+			It("withholds the message", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(500))
+				b, _ := ioutil.ReadAll(resp.Body)
+				Expect(string(b)).NotTo(ContainSubstring("404-555-0123"))
+			})
+		})
+		When("all goes well", func() {
+			BeforeEach(func() {
+				db.GetOriginalMessageReturns(ptrToString("call me at 404-555-0123"), nil)
+			})
+			It("returns the original message", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, id := db.GetOriginalMessageArgsForCall(0)
+				Expect(id).To(Equal(feedbackID))
+
+				var respObj api.OriginalMessageResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+				Expect(respObj.Message).To(PointTo(Equal("call me at 404-555-0123")))
+			})
+			It("records the read in the audit log", func() {
+				Expect(auditor.RecordCallCount()).To(Equal(1))
+				_, entry := auditor.RecordArgsForCall(0)
+				Expect(entry.ActorSession).To(Equal("mod-session"))
+				Expect(entry.ActorRole).To(Equal("admin"))
+				Expect(entry.Action).To(Equal(api.AuditReadOriginalMessage))
+				Expect(entry.TargetIDs).To(Equal([]string{feedbackID}))
+			})
+		})
+	})
+
+	Describe("Silence", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest("POST", "/v1/admin/silence/"+feedbackID, nil)
+			req.Header.Set("X-Smarta-Auth-Session", "mod-session")
+			req.Header.Set("X-Smarta-Auth-Role", "admin")
+			body = map[string]interface{}{"silenced": true}
+		})
+
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.Require(authz.Silence, client.Silence)(respW, req)
+			resp = respW.Result()
+		})
+
+		When("the role lacks the permission", func() {
+			BeforeEach(func() {
+				req.Header.Set("X-Smarta-Auth-Role", "auditor")
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(403))
+				Expect(db.SilenceFeedbackCallCount()).To(Equal(0))
+			})
+		})
+		When("silenced is missing", func() {
+			BeforeEach(func() {
+				body = map[string]interface{}{}
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				Expect(db.SilenceFeedbackCallCount()).To(Equal(0))
+			})
+		})
+		When("the feedback doesn't exist", func() {
+			BeforeEach(func() {
+				db.SilenceFeedbackReturns(false, dbp.ErrNotFound)
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(404))
+				Expect(auditor.RecordCallCount()).To(Equal(0))
+			})
+		})
+		When("all goes well", func() {
+			It("silences the feedback and records it in the audit log", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, id, silenced := db.SilenceFeedbackArgsForCall(0)
+				Expect(id).To(Equal(feedbackID))
+				Expect(silenced).To(BeTrue())
+
+				_, entry := auditor.RecordArgsForCall(0)
+				Expect(entry).To(Equal(audit.Entry{
+					ActorSession: "mod-session",
+					ActorRole:    "admin",
+					Action:       api.AuditSilenceFeedback,
+					TargetType:   "feedback",
+					TargetIDs:    []string{feedbackID},
+					Before:       map[string]bool{"silenced": false},
+					After:        map[string]bool{"silenced": true},
+				}))
+			})
+		})
+	})
+})
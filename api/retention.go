@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/db"
+)
+
+//RetentionPolicyRecord is how long feedback of a kind is kept
+type RetentionPolicyRecord struct {
+	Kind       string    `json:"kind"`
+	MaxAgeDays int       `json:"max_age_days"`
+	Action     string    `json:"action"`
+	UpdatedBy  string    `json:"updated_by"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+//RetentionPoliciesResponse lists every retention policy
+type RetentionPoliciesResponse struct {
+	Policies []RetentionPolicyRecord `json:"policies"`
+}
+
+//SetRetentionPolicyRequest sets the retention policy of a kind
+type SetRetentionPolicyRequest struct {
+	MaxAgeDays int    `json:"max_age_days"`
+	Action     string `json:"action"`
+}
+
+var retentionActions = map[string]struct{}{
+	db.RetentionDelete:    {},
+	db.RetentionAnonymize: {},
+}
+
+//RetentionPolicies serves GET /v1/admin/retention, listing the retention
+//policies. Kinds without a policy are kept indefinitely.
+func (c Client) RetentionPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+		return
+	}
+
+	policies, err := c.db.GetRetentionPolicies(r.Context())
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get retention policies")
+		return
+	}
+
+	resp := RetentionPoliciesResponse{Policies: []RetentionPolicyRecord{}}
+	for _, p := range policies {
+		resp.Policies = append(resp.Policies, RetentionPolicyRecord{
+			Kind:       p.Kind,
+			MaxAgeDays: p.MaxAgeDays,
+			Action:     p.Action,
+			UpdatedBy:  p.UpdatedBy,
+			UpdatedAt:  p.UpdatedAt,
+		})
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+//RetentionPolicy serves /v1/admin/retention/{kind}: PUT sets the policy for
+//a kind and DELETE removes it. Changes apply from the next purge.
+func (c Client) RetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" && r.Method != "DELETE" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use PUT or DELETE instead")
+		return
+	}
+
+	kind := strings.TrimPrefix(r.URL.Path, "/v1/admin/retention/")
+	if _, ok := c.findKind(kind); !ok {
+		c.writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf("unknown kind `%s`", kind))
+		return
+	}
+
+	before, err := c.auditedRetentionPolicy(r.Context(), kind)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get retention policy")
+		return
+	}
+
+	if r.Method == "DELETE" {
+		err := c.db.InTransaction(r.Context(), func(ctx context.Context) error {
+			if err := c.db.DeleteRetentionPolicy(ctx, kind); err != nil {
+				return err
+			}
+
+			return c.recordAudit(ctx, audit.Entry{
+				ActorSession: r.Header.Get("X-Smarta-Auth-Session"),
+				ActorRole:    r.Header.Get("X-Smarta-Auth-Role"),
+				Action:       AuditDeleteRetentionPolicy,
+				TargetType:   "retention_policy",
+				TargetIDs:    []string{kind},
+				Before:       before,
+			})
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			c.writeErrorResponse(w, http.StatusNotFound, "retention policy not found")
+			return
+		}
+		if err != nil {
+			c.logger(r.Context()).Error(err.Error())
+			c.writeErrorResponse(w, http.StatusInternalServerError, "failed to delete retention policy")
+		}
+		return
+	}
+
+	var req SetRetentionPolicyRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
+		return
+	}
+
+	if req.MaxAgeDays <= 0 {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_max_age",
+			Message: "`max_age_days` must be a positive integer",
+		})
+		return
+	}
+	action := strings.ToLower(req.Action)
+	if _, ok := retentionActions[action]; !ok {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_action",
+			Message: fmt.Sprintf("invalid value `%s` for `action`", req.Action),
+		})
+		return
+	}
+
+	err = c.db.InTransaction(r.Context(), func(ctx context.Context) error {
+		err := c.db.SetRetentionPolicy(ctx, db.RetentionPolicy{
+			Kind:       kind,
+			MaxAgeDays: req.MaxAgeDays,
+			Action:     action,
+			UpdatedBy:  r.Header.Get("X-Smarta-Auth-Session"),
+		})
+		if err != nil {
+			return err
+		}
+
+		return c.recordAudit(ctx, audit.Entry{
+			ActorSession: r.Header.Get("X-Smarta-Auth-Session"),
+			ActorRole:    r.Header.Get("X-Smarta-Auth-Role"),
+			Action:       AuditSetRetentionPolicy,
+			TargetType:   "retention_policy",
+			TargetIDs:    []string{kind},
+			Before:       before,
+			After:        retentionPolicyState{MaxAgeDays: req.MaxAgeDays, Action: action},
+		})
+	})
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to set retention policy")
+	}
+}
+
+//retentionPolicyState is how retention policies appear in the audit log
+type retentionPolicyState struct {
+	MaxAgeDays int    `json:"max_age_days"`
+	Action     string `json:"action"`
+}
+
+//auditedRetentionPolicy returns the current policy for kind for the audit
+//log, or nil if there is none or nothing is audited
+func (c Client) auditedRetentionPolicy(ctx context.Context, kind string) (interface{}, error) {
+	if c.auditor == nil {
+		return nil, nil
+	}
+
+	policies, err := c.db.GetRetentionPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range policies {
+		if p.Kind == kind {
+			return retentionPolicyState{MaxAgeDays: p.MaxAgeDays, Action: p.Action}, nil
+		}
+	}
+
+	return nil, nil
+}
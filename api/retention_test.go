@@ -0,0 +1,171 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/audit/auditfakes"
+	"github.com/smartatransit/feedback/authz"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retention", func() {
+	var (
+		db      *dbfakes.FakeDB
+		auditor *auditfakes.FakeRecorder
+		client  api.Client
+
+		body interface{}
+		req  *http.Request
+		resp *http.Response
+	)
+
+	BeforeEach(func() {
+		db = newFakeDB()
+		auditor = &auditfakes.FakeRecorder{}
+		body = nil
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client = api.New(log, db).WithAuthorization(testPolicy()).WithAuditLog(auditor).WithCatalog(testCatalog())
+
+		req.Header.Set("X-Smarta-Auth-Session", "admin-session")
+		if req.Header.Get("X-Smarta-Auth-Role") == "" {
+			req.Header.Set("X-Smarta-Auth-Role", "admin")
+		}
+		if body != nil {
+			bodyBytes, err := json.Marshal(body)
+			Expect(err).To(BeNil())
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+		}
+	})
+
+	Describe("RetentionPolicies", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest("GET", "/v1/admin/retention", nil)
+			db.GetRetentionPoliciesReturns([]dbp.RetentionPolicy{{Kind: "outage", MaxAgeDays: 90, Action: "delete"}}, nil)
+		})
+
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.Require(authz.Read, client.RetentionPolicies)(respW, req)
+			resp = respW.Result()
+		})
+
+		When("the role lacks the permission", func() {
+			BeforeEach(func() {
+				req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(403))
+			})
+		})
+		When("the policies can't be read", func() {
+			BeforeEach(func() {
+				db.GetRetentionPoliciesReturns(nil, errors.New("select failed"))
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(500))
+			})
+		})
+		When("all goes well", func() {
+			It("lists the policies", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				var respObj api.RetentionPoliciesResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+				Expect(respObj.Policies).To(HaveLen(1))
+				Expect(respObj.Policies[0].MaxAgeDays).To(Equal(90))
+			})
+		})
+	})
+
+	Describe("RetentionPolicy", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest("PUT", "/v1/admin/retention/comment", nil)
+			body = &api.SetRetentionPolicyRequest{MaxAgeDays: 730, Action: "Anonymize"}
+		})
+
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.Require(authz.Delete, client.RetentionPolicy)(respW, req)
+			resp = respW.Result()
+		})
+
+		When("the kind is unknown", func() {
+			BeforeEach(func() {
+				req.URL.Path = "/v1/admin/retention/sdf"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			})
+		})
+		When("the max age isn't positive", func() {
+			BeforeEach(func() {
+				body.(*api.SetRetentionPolicyRequest).MaxAgeDays = 0
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("the action is invalid", func() {
+			BeforeEach(func() {
+				body.(*api.SetRetentionPolicyRequest).Action = "archive"
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			})
+		})
+		When("all goes well", func() {
+			BeforeEach(func() {
+				db.GetRetentionPoliciesReturns([]dbp.RetentionPolicy{{Kind: "comment", MaxAgeDays: 365, Action: "delete"}}, nil)
+			})
+			It("records the change in the audit log", func() {
+				_, entry := auditor.RecordArgsForCall(0)
+				Expect(entry.Action).To(Equal(api.AuditSetRetentionPolicy))
+				Expect(entry.TargetIDs).To(Equal([]string{"comment"}))
+
+				before, err := json.Marshal(entry.Before)
+				Expect(err).To(BeNil())
+				Expect(before).To(MatchJSON(`{"max_age_days": 365, "action": "delete"}`))
+				after, err := json.Marshal(entry.After)
+				Expect(err).To(BeNil())
+				Expect(after).To(MatchJSON(`{"max_age_days": 730, "action": "anonymize"}`))
+			})
+			It("sets the policy", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, policy := db.SetRetentionPolicyArgsForCall(0)
+				Expect(policy).To(Equal(dbp.RetentionPolicy{
+					Kind:       "comment",
+					MaxAgeDays: 730,
+					Action:     "anonymize",
+					UpdatedBy:  "admin-session",
+				}))
+			})
+		})
+		When("deleting a policy that doesn't exist", func() {
+			BeforeEach(func() {
+				req.Method = "DELETE"
+				body = nil
+				db.DeleteRetentionPolicyReturns(dbp.ErrNotFound)
+			})
+			It("fails", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			})
+		})
+	})
+})
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/authz"
+	"github.com/smartatransit/feedback/db"
+)
+
+//RiderDataRequest asks for everything a rider submitted, identified by
+//exactly one of SessionID or Email, to be exported, deleted or anonymized
+type RiderDataRequest struct {
+	Action    string `json:"action"`
+	SessionID string `json:"session_id"`
+	Email     string `json:"email"`
+}
+
+//RiderDataResponse reports the outcome of a RiderDataRequest. Feedback,
+//Attachments and SurveyResponses are only populated for exports. The images
+//of attachments are fetched with GET /v1/admin/attachments/{id}.
+type RiderDataResponse struct {
+	Action          string                      `json:"action"`
+	Affected        int                         `json:"affected"`
+	Feedback        []FeedbackRecord            `json:"feedback,omitempty"`
+	Attachments     []AttachmentRecord          `json:"attachments,omitempty"`
+	SurveyResponses []RiderSurveyResponseRecord `json:"survey_responses,omitempty"`
+	Messages        []MessageRecord             `json:"messages,omitempty"`
+}
+
+//RiderSurveyResponseRecord is a rider's response to a version of a survey
+type RiderSurveyResponseRecord struct {
+	Survey      string            `json:"survey"`
+	Version     int               `json:"version"`
+	SessionID   string            `json:"session_id"`
+	SubmittedAt time.Time         `json:"submitted_at"`
+	Answers     []db.SurveyAnswer `json:"answers"`
+}
+
+var riderDataActions = map[string]struct{}{
+	db.DataRequestExport:    {},
+	db.DataRequestDelete:    {},
+	db.DataRequestAnonymize: {},
+}
+
+//riderDataAuditActions maps the rider data actions that modify feedback to
+//the action recorded in the audit log
+var riderDataAuditActions = map[string]string{
+	db.DataRequestDelete:    AuditDeleteRiderData,
+	db.DataRequestAnonymize: AuditAnonymizeRiderData,
+}
+
+//RiderData serves POST /v1/admin/rider-data, carrying out a rider's request
+//to export, delete or anonymize their feedback. Deleting and anonymizing
+//also require the delete permission.
+func (c Client) RiderData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use POST instead")
+		return
+	}
+
+	var req RiderDataRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
+		return
+	}
+
+	switch strings.ToLower(req.Action) {
+	case db.DataRequestDelete, db.DataRequestAnonymize:
+		if !c.authorize(w, r, authz.Delete) {
+			return
+		}
+	}
+
+	resp, err := c.ProcessRiderDataRequest(r.Context(), req,
+		r.Header.Get("X-Smarta-Auth-Session"),
+		r.Header.Get("X-Smarta-Auth-Role"),
+		"api",
+	)
+	if err != nil {
+		var verr ValidationError
+		if errors.As(err, &verr) {
+			c.writeValidationError(w, err)
+			return
+		}
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to %s rider data", req.Action))
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+//ProcessRiderDataRequest carries out req on behalf of the named requester
+//and records it in the audit trail. source describes where the request
+//came from, such as `api` or `cli`. Invalid requests yield a ValidationError.
+func (c Client) ProcessRiderDataRequest(ctx context.Context, req RiderDataRequest, requestedBy, requestedByRole, source string) (RiderDataResponse, error) {
+	action := strings.ToLower(req.Action)
+	if _, ok := riderDataActions[action]; !ok {
+		return RiderDataResponse{}, ValidationError{
+			Reason:  "invalid_action",
+			Message: fmt.Sprintf("invalid value `%s` for `action`", req.Action),
+		}
+	}
+
+	subject := db.RiderSubject{
+		SessionID: strings.TrimSpace(req.SessionID),
+		Email:     strings.TrimSpace(req.Email),
+	}
+	if (subject.SessionID == "") == (subject.Email == "") {
+		return RiderDataResponse{}, ValidationError{
+			Reason:  "invalid_subject",
+			Message: "exactly one of `session_id` or `email` is required",
+		}
+	}
+
+	request := db.DataRequest{
+		Action:          action,
+		Subject:         subject,
+		RequestedBy:     requestedBy,
+		RequestedByRole: requestedByRole,
+		Source:          source,
+	}
+
+	var resp RiderDataResponse
+	err := c.db.InTransaction(ctx, func(ctx context.Context) (err error) {
+		resp, err = c.applyRiderDataRequest(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		auditAction, ok := riderDataAuditActions[action]
+		if !ok {
+			return nil
+		}
+		return c.recordAudit(ctx, audit.Entry{
+			ActorSession: requestedBy,
+			ActorRole:    requestedByRole,
+			Action:       auditAction,
+			TargetType:   "rider_" + subject.Type(),
+			TargetIDs:    []string{subject.Digest()},
+			After:        map[string]interface{}{"feedback_count": resp.Affected, "source": source},
+		})
+	})
+	if err != nil {
+		return RiderDataResponse{}, err
+	}
+
+	c.logger(ctx).
+		WithField("action", action).
+		WithField("subject_type", subject.Type()).
+		WithField("affected", resp.Affected).
+		WithField("source", source).
+		Info("processed rider data request")
+
+	return resp, nil
+}
+
+//applyRiderDataRequest carries out request, returning what it found or how
+//many feedback it changed
+func (c Client) applyRiderDataRequest(ctx context.Context, request db.DataRequest) (resp RiderDataResponse, err error) {
+	subject := request.Subject
+	resp.Action = request.Action
+	switch request.Action {
+	case db.DataRequestExport:
+		var fbs []db.Feedback
+		fbs, err = c.db.FindRiderFeedback(ctx, subject)
+		if err != nil {
+			return RiderDataResponse{}, err
+		}
+
+		var attachments []db.Attachment
+		attachments, err = c.db.FindRiderAttachments(ctx, subject)
+		if err != nil {
+			return RiderDataResponse{}, err
+		}
+
+		var messages []db.FeedbackMessage
+		messages, err = c.db.FindRiderMessages(ctx, subject)
+		if err != nil {
+			return RiderDataResponse{}, err
+		}
+
+		var responses []db.SurveyResponse
+		responses, err = c.db.FindRiderSurveyResponses(ctx, subject)
+		if err != nil {
+			return RiderDataResponse{}, err
+		}
+
+		request.FeedbackCount = len(fbs)
+		if err = c.db.RecordDataRequest(ctx, request); err != nil {
+			return RiderDataResponse{}, err
+		}
+
+		resp.Affected = len(fbs)
+		resp.Feedback = feedbackRecordsFromFeedbackList(fbs)
+		for _, a := range attachments {
+			resp.Attachments = append(resp.Attachments, attachmentRecordFromAttachment(a))
+		}
+		for _, m := range messages {
+			resp.Messages = append(resp.Messages, messageRecordFromMessage(m, false))
+		}
+		for _, r := range responses {
+			resp.SurveyResponses = append(resp.SurveyResponses, RiderSurveyResponseRecord{
+				Survey:      r.SurveyName,
+				Version:     r.Version,
+				SessionID:   r.SessionID,
+				SubmittedAt: r.SubmittedAt,
+				Answers:     r.Answers,
+			})
+		}
+	case db.DataRequestDelete:
+		resp.Affected, err = c.db.DeleteRiderFeedback(ctx, request)
+	case db.DataRequestAnonymize:
+		resp.Affected, err = c.db.AnonymizeRiderFeedback(ctx, request)
+	}
+	if err != nil {
+		return RiderDataResponse{}, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,256 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/audit/auditfakes"
+	"github.com/smartatransit/feedback/authz"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+var _ = Describe("RiderData", func() {
+	var (
+		db      *dbfakes.FakeDB
+		auditor *auditfakes.FakeRecorder
+
+		body *api.RiderDataRequest
+		req  *http.Request
+		resp *http.Response
+	)
+
+	BeforeEach(func() {
+		db = newFakeDB()
+		auditor = &auditfakes.FakeRecorder{}
+
+		body = &api.RiderDataRequest{Action: "export", SessionID: "rider-session"}
+		req = httptest.NewRequest("POST", "/v1/admin/rider-data", nil)
+		req.Header.Set("X-Smarta-Auth-Session", "admin-session")
+		req.Header.Set("X-Smarta-Auth-Role", "admin")
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client := api.New(log, db).WithAuthorization(testPolicy()).WithAuditLog(auditor)
+
+		bodyBytes, err := json.Marshal(body)
+		Expect(err).To(BeNil())
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+
+		respW := httptest.NewRecorder()
+		client.Require(authz.Export, client.RiderData)(respW, req)
+		resp = respW.Result()
+	})
+
+	When("the role lacks the permission", func() {
+		BeforeEach(func() {
+			req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+		})
+		It("fails", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(403))
+		})
+	})
+	When("the action is invalid", func() {
+		BeforeEach(func() {
+			body.Action = "shred"
+		})
+		It("fails", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+		})
+	})
+	When("both a session and an email are given", func() {
+		BeforeEach(func() {
+			body.Email = "rider@example.com"
+		})
+		It("fails", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			Expect(db.FindRiderFeedbackCallCount()).To(Equal(0))
+		})
+	})
+	When("the lookup fails", func() {
+		BeforeEach(func() {
+			db.FindRiderFeedbackReturns(nil, errors.New("select failed"))
+		})
+		It("fails", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(500))
+			Expect(db.RecordDataRequestCallCount()).To(Equal(0))
+		})
+	})
+	When("exporting", func() {
+		BeforeEach(func() {
+			db.FindRiderFeedbackReturns([]dbp.Feedback{{
+				ID:        "fb",
+				SessionID: "rider-session",
+				Kind:      "comment",
+				Message:   ptrToString("hello"),
+			}}, nil)
+			db.FindRiderAttachmentsReturns([]dbp.Attachment{{
+				ID:          "photo",
+				FeedbackID:  ptrToString("fb"),
+				SessionID:   "rider-session",
+				ContentType: "image/jpeg",
+				StorageKey:  "secret-key",
+			}}, nil)
+			four := 4
+			db.FindRiderSurveyResponsesReturns([]dbp.SurveyResponse{{
+				SurveyName: "trip",
+				Version:    2,
+				SessionID:  "rider-session",
+				Answers:    []dbp.SurveyAnswer{{QuestionID: "trip", Number: &four}},
+			}}, nil)
+			db.FindRiderMessagesReturns([]dbp.FeedbackMessage{
+				{ID: "1", FeedbackID: "fb", Author: "staff", AuthorSession: "admin-session", AuthorRole: "admin", Body: "Which station?"},
+				{ID: "2", FeedbackID: "fb", Author: "rider", AuthorSession: "rider-session", AuthorRole: "anonymous", Body: "Five Points"},
+			}, nil)
+		})
+		It("returns the feedback and records the request", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, subject := db.FindRiderFeedbackArgsForCall(0)
+			Expect(subject).To(Equal(dbp.RiderSubject{SessionID: "rider-session"}))
+
+			_, request := db.RecordDataRequestArgsForCall(0)
+			Expect(request).To(MatchAllFields(Fields{
+				"Action":          Equal("export"),
+				"Subject":         Equal(subject),
+				"RequestedBy":     Equal("admin-session"),
+				"RequestedByRole": Equal("admin"),
+				"Source":          Equal("api"),
+				"FeedbackCount":   Equal(1),
+			}))
+
+			var respObj api.RiderDataResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+			Expect(respObj.Affected).To(Equal(1))
+			Expect(respObj.Feedback[0].Message).To(PointTo(Equal("hello")))
+			Expect(auditor.RecordCallCount()).To(Equal(0))
+		})
+		It("returns the rider's attachments", func() {
+			_, subject := db.FindRiderAttachmentsArgsForCall(0)
+			Expect(subject).To(Equal(dbp.RiderSubject{SessionID: "rider-session"}))
+
+			body, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(body)).To(ContainSubstring(`"attachments":[{"id":"photo","feedback_id":"fb"`))
+			Expect(string(body)).NotTo(ContainSubstring("secret-key"))
+		})
+		It("returns the rider's survey responses", func() {
+			_, subject := db.FindRiderSurveyResponsesArgsForCall(0)
+			Expect(subject).To(Equal(dbp.RiderSubject{SessionID: "rider-session"}))
+
+			var respObj api.RiderDataResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+			Expect(respObj.SurveyResponses).To(HaveLen(1))
+			Expect(respObj.SurveyResponses[0].Survey).To(Equal("trip"))
+			Expect(respObj.SurveyResponses[0].Answers[0].Number).To(PointTo(Equal(4)))
+		})
+		It("returns the threads of the rider's feedback without staff identities", func() {
+			_, subject := db.FindRiderMessagesArgsForCall(0)
+			Expect(subject).To(Equal(dbp.RiderSubject{SessionID: "rider-session"}))
+
+			var respObj api.RiderDataResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+			Expect(respObj.Messages).To(HaveLen(2))
+			Expect(respObj.Messages[0].Body).To(Equal("Which station?"))
+			Expect(respObj.Messages[0].AuthorSession).To(BeNil())
+			Expect(respObj.Messages[1].Author).To(Equal("rider"))
+		})
+	})
+	When("the message lookup fails", func() {
+		BeforeEach(func() {
+			db.FindRiderMessagesReturns(nil, errors.New("select failed"))
+		})
+		It("fails", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(500))
+			Expect(db.RecordDataRequestCallCount()).To(Equal(0))
+		})
+	})
+	When("the attachment lookup fails", func() {
+		BeforeEach(func() {
+			db.FindRiderAttachmentsReturns(nil, errors.New("select failed"))
+		})
+		It("fails", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(500))
+			Expect(db.RecordDataRequestCallCount()).To(Equal(0))
+		})
+	})
+	When("deleting by email", func() {
+		BeforeEach(func() {
+			body = &api.RiderDataRequest{Action: "DELETE", Email: "rider@example.com"}
+			db.DeleteRiderFeedbackReturns(3, nil)
+		})
+		It("deletes the feedback", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, request := db.DeleteRiderFeedbackArgsForCall(0)
+			Expect(request.Action).To(Equal("delete"))
+			Expect(request.Subject).To(Equal(dbp.RiderSubject{Email: "rider@example.com"}))
+
+			var respObj api.RiderDataResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+			Expect(respObj).To(Equal(api.RiderDataResponse{Action: "delete", Affected: 3}))
+		})
+		It("records the deletion in the audit log", func() {
+			_, entry := auditor.RecordArgsForCall(0)
+			Expect(entry.Action).To(Equal(api.AuditDeleteRiderData))
+			Expect(entry.ActorSession).To(Equal("admin-session"))
+			Expect(entry.TargetType).To(Equal("rider_email"))
+			Expect(entry.TargetIDs).To(Equal([]string{dbp.RiderSubject{Email: "rider@example.com"}.Digest()}))
+		})
+	})
+	When("a deletion can't be audited", func() {
+		BeforeEach(func() {
+			body = &api.RiderDataRequest{Action: "delete", SessionID: "rider-session"}
+			db.DeleteRiderFeedbackReturns(3, nil)
+			auditor.RecordReturns(errors.New("insert failed"))
+		})
+		It("fails the transaction making the deletion, so it is rolled back", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(500))
+			Expect(db.InTransactionCallCount()).To(Equal(1))
+			Expect(db.DeleteRiderFeedbackCallCount()).To(Equal(1))
+			respBody, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(respBody)).To(ContainSubstring("failed to delete rider data"))
+		})
+	})
+	When("deleting without the delete permission", func() {
+		BeforeEach(func() {
+			body.Action = "delete"
+			req.Header.Set("X-Smarta-Auth-Role", "auditor")
+		})
+		It("fails", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(403))
+			Expect(db.DeleteRiderFeedbackCallCount()).To(Equal(0))
+		})
+	})
+	When("exporting with only the export permission", func() {
+		BeforeEach(func() {
+			req.Header.Set("X-Smarta-Auth-Role", "auditor")
+		})
+		It("succeeds", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+		})
+	})
+	When("anonymizing fails", func() {
+		BeforeEach(func() {
+			body.Action = "anonymize"
+			db.AnonymizeRiderFeedbackReturns(0, errors.New("update failed"))
+		})
+		It("fails", func() {
+			Expect(resp.StatusCode).To(BeEquivalentTo(500))
+		})
+	})
+})
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+//MaxSearchLength bounds the `q` parameter of feedback searches
+const MaxSearchLength = 500
+
+//SearchResultRecord is a feedback matching a search. Snippet is the best
+//matching part of its message as HTML, with matches in `<mark>` elements.
+type SearchResultRecord struct {
+	FeedbackRecord
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+//SearchResponse lists feedback matching a search, best matches first
+type SearchResponse struct {
+	Feedback []SearchResultRecord `json:"feedback"`
+}
+
+//SimilarFeedbackRecord is a feedback worded like another. Similarity is
+//between 0 and 1.
+type SimilarFeedbackRecord struct {
+	FeedbackRecord
+	Similarity float64 `json:"similarity"`
+}
+
+//SimilarFeedbackResponse lists feedback worded like another, most similar
+//first
+type SimilarFeedbackResponse struct {
+	Feedback []SimilarFeedbackRecord `json:"feedback"`
+}
+
+//highlight escapes a search snippet as HTML, marking its matches
+func highlight(snippet string) string {
+	return strings.NewReplacer(
+		db.HighlightStart, "<mark>",
+		db.HighlightStop, "</mark>",
+	).Replace(html.EscapeString(snippet))
+}
+
+func (c Client) searchFeedback(w http.ResponseWriter, r *http.Request, search string, filter db.FeedbackFilter, limit, offset int) {
+	if len(search) > MaxSearchLength {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_search",
+			Message: fmt.Sprintf("`q` may be at most %d characters", MaxSearchLength),
+		})
+		return
+	}
+
+	query, err := db.SearchQuery(search)
+	if err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_search",
+			Message: "`q` must have at least one word to look for",
+		})
+		return
+	}
+
+	results, err := c.db.SearchFeedback(r.Context(), query, filter, limit, offset)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to search feedback")
+		return
+	}
+
+	resp := SearchResponse{Feedback: []SearchResultRecord{}}
+	for _, result := range results {
+		resp.Feedback = append(resp.Feedback, SearchResultRecord{
+			FeedbackRecord: feedbackRecordFromFeedback(result.Feedback),
+			Rank:           result.Rank,
+			Snippet:        highlight(result.Snippet),
+		})
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+func (c Client) similarFeedback(w http.ResponseWriter, r *http.Request, id string) {
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
+	similar, err := c.db.SimilarFeedback(r.Context(), id, limit, offset)
+	if errors.Is(err, db.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to find similar feedback")
+		return
+	}
+
+	resp := SimilarFeedbackResponse{Feedback: []SimilarFeedbackRecord{}}
+	for _, s := range similar {
+		resp.Feedback = append(resp.Feedback, SimilarFeedbackRecord{
+			FeedbackRecord: feedbackRecordFromFeedback(s.Feedback),
+			Similarity:     s.Similarity,
+		})
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
@@ -0,0 +1,124 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+const searchFeedbackID = "8c3f5e4a-1d0f-4e9a-83b4-5d6e7f8091a2"
+
+var _ = Describe("Search", func() {
+	var (
+		db     *dbfakes.FakeDB
+		client api.Client
+
+		resp *http.Response
+	)
+
+	BeforeEach(func() {
+		db = newFakeDB()
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client = api.New(log, db).
+			WithAuthorization(testPolicy()).
+			WithCatalog(testCatalog())
+	})
+
+	serve := func(handler http.HandlerFunc, path string) *http.Response {
+		req := httptest.NewRequest("GET", path, bytes.NewBufferString(""))
+		req.Header.Set("X-Smarta-Auth-Session", "admin-session")
+		req.Header.Set("X-Smarta-Auth-Role", "admin")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Result()
+	}
+
+	Describe("ListFeedback with q", func() {
+		It("searches with the filters and returns escaped, highlighted snippets", func() {
+			db.SearchFeedbackReturns([]dbp.SearchResult{{
+				Feedback: dbp.Feedback{ID: searchFeedbackID, Kind: "comment"},
+				Rank:     0.5,
+				Snippet:  "the " + dbp.HighlightStart + "elevator" + dbp.HighlightStop + " at <Five Points>",
+			}}, nil)
+
+			resp = serve(client.ListFeedback, `/v1/admin/feedback?kind=comment&status=new&q=elevator+"five+points"`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, query, filter, limit, offset := db.SearchFeedbackArgsForCall(0)
+			Expect(query).To(Equal("'elevator' & ('five' <-> 'points')"))
+			Expect(filter.Kind).To(PointTo(Equal("comment")))
+			Expect(filter.TriageStatus).To(PointTo(Equal("new")))
+			Expect(limit).To(Equal(50))
+			Expect(offset).To(Equal(0))
+			Expect(db.ListFeedbackCallCount()).To(Equal(0))
+
+			var body api.SearchResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+			Expect(body.Feedback).To(HaveLen(1))
+			Expect(body.Feedback[0].ID).To(Equal(searchFeedbackID))
+			Expect(body.Feedback[0].Rank).To(Equal(0.5))
+			Expect(body.Feedback[0].Snippet).To(Equal("the <mark>elevator</mark> at &lt;Five Points&gt;"))
+		})
+
+		It("rejects searches without words", func() {
+			resp = serve(client.ListFeedback, "/v1/admin/feedback?q=-elevator")
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			Expect(db.SearchFeedbackCallCount()).To(Equal(0))
+		})
+
+		It("rejects long searches", func() {
+			resp = serve(client.ListFeedback, "/v1/admin/feedback?q="+strings.Repeat("a", api.MaxSearchLength+1))
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+		})
+
+		It("can't be combined with email", func() {
+			resp = serve(client.ListFeedback, "/v1/admin/feedback?email=rider@example.com&q=elevator")
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+		})
+	})
+
+	Describe("AdminFeedback similar", func() {
+		It("lists feedback worded like the given one", func() {
+			db.SimilarFeedbackReturns([]dbp.SimilarFeedback{{
+				Feedback:   dbp.Feedback{ID: "other", Kind: "comment"},
+				Similarity: 0.62,
+			}}, nil)
+
+			resp = serve(client.AdminFeedback, "/v1/admin/feedback/"+searchFeedbackID+"/similar?limit=5")
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, id, limit, _ := db.SimilarFeedbackArgsForCall(0)
+			Expect(id).To(Equal(searchFeedbackID))
+			Expect(limit).To(Equal(5))
+
+			var body api.SimilarFeedbackResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+			Expect(body.Feedback).To(HaveLen(1))
+			Expect(body.Feedback[0].ID).To(Equal("other"))
+			Expect(body.Feedback[0].Similarity).To(Equal(0.62))
+		})
+
+		It("fails for feedback that doesn't exist", func() {
+			db.SimilarFeedbackReturns(nil, dbp.ErrNotFound)
+			resp = serve(client.AdminFeedback, "/v1/admin/feedback/"+searchFeedbackID+"/similar")
+			Expect(resp.StatusCode).To(BeEquivalentTo(404))
+		})
+	})
+})
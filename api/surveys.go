@@ -0,0 +1,450 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/authz"
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/survey"
+)
+
+//SurveyRecord is a version of a survey and its questions
+type SurveyRecord struct {
+	Survey    string            `json:"survey"`
+	Version   int               `json:"version"`
+	Active    bool              `json:"active"`
+	Title     string            `json:"title"`
+	Questions []survey.Question `json:"questions"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+//SurveyResponseRequest answers the questions of a survey version, keyed by
+//question ID. Version must be the version the rider was shown.
+type SurveyResponseRequest struct {
+	Version int                        `json:"version"`
+	Answers map[string]json.RawMessage `json:"answers"`
+}
+
+//CreateSurveyVersionRequest defines the next version of a survey. When
+//Activate is set, the new version replaces the active one.
+type CreateSurveyVersionRequest struct {
+	Title     string            `json:"title"`
+	Questions []survey.Question `json:"questions"`
+	Activate  bool              `json:"activate"`
+}
+
+//SetActiveSurveyRequest chooses the version of a survey accepting
+//responses. A null version closes the survey.
+type SetActiveSurveyRequest struct {
+	Version *int `json:"version"`
+}
+
+//SurveyResultsResponse summarizes the responses to a survey version
+type SurveyResultsResponse struct {
+	Survey  string         `json:"survey"`
+	Version int            `json:"version"`
+	Title   string         `json:"title"`
+	Results survey.Results `json:"results"`
+}
+
+//Survey serves the rider side of surveys under /v1/surveys/:
+//
+//  GET /v1/surveys/{name} returns the active version of a survey
+//  POST /v1/surveys/{name}/responses answers it, once per session. Free
+//    text answers are redacted like feedback messages.
+func (c Client) Survey(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/surveys/"), "/")
+	name := parts[0]
+
+	switch {
+	case len(parts) == 1 && r.Method == "GET":
+		c.getSurvey(w, r, name, nil)
+	case len(parts) == 1:
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+	case len(parts) == 2 && parts[1] == "responses" && r.Method == "POST":
+		c.saveSurveyResponse(w, r, name)
+	case len(parts) == 2 && parts[1] == "responses":
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use POST instead")
+	default:
+		c.writeErrorResponse(w, http.StatusNotFound, "not found")
+	}
+}
+
+//getSurvey writes a version of a survey, or its active version if version
+//is nil
+func (c Client) getSurvey(w http.ResponseWriter, r *http.Request, name string, version *int) {
+	record, err := c.loadSurvey(r.Context(), name, version)
+	if errors.Is(err, db.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "survey not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get survey")
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, record)
+}
+
+//loadSurvey returns a version of a survey, or its active version if
+//version is nil
+func (c Client) loadSurvey(ctx context.Context, name string, version *int) (SurveyRecord, error) {
+	v, err := c.db.GetSurveyVersion(ctx, name, version)
+	if err != nil {
+		return SurveyRecord{}, err
+	}
+
+	record := SurveyRecord{
+		Survey:    v.SurveyName,
+		Version:   v.Version,
+		Active:    v.Active,
+		Title:     v.Title,
+		CreatedAt: v.CreatedAt,
+	}
+	if err := json.Unmarshal(v.Questions, &record.Questions); err != nil {
+		return SurveyRecord{}, fmt.Errorf("failed decoding questions of survey `%s` version %d: %w", v.SurveyName, v.Version, err)
+	}
+	return record, nil
+}
+
+//surveyRateLimitKind is the kind survey responses are rate limited as
+const surveyRateLimitKind = "survey"
+
+func (c Client) saveSurveyResponse(w http.ResponseWriter, r *http.Request, name string) {
+	session := r.Header.Get("X-Smarta-Auth-Session")
+	role := r.Header.Get("X-Smarta-Auth-Role")
+
+	if c.limiter != nil {
+		decision, err := c.limiter.Allow(r.Context(), session, role, surveyRateLimitKind)
+		if err != nil {
+			c.logger(r.Context()).Error(err.Error())
+		} else if !decision.Allowed {
+			retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			c.writeErrorResponse(w, http.StatusTooManyRequests, "too many survey responses, try again later")
+			return
+		}
+	}
+
+	var req SurveyResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
+		return
+	}
+
+	record, err := c.loadSurvey(r.Context(), name, nil)
+	if errors.Is(err, db.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "survey not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to save survey response")
+		return
+	}
+	if req.Version != record.Version {
+		c.writeErrorResponse(w, http.StatusConflict, fmt.Sprintf("version %d of `%s` is no longer accepting responses", req.Version, name))
+		return
+	}
+
+	answers, err := survey.Answers(record.Questions, req.Answers)
+	if err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_answer",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if c.redactor != nil {
+		for i, answer := range answers {
+			if answer.Text != nil {
+				text, _ := c.redactor.Redact(*answer.Text)
+				answers[i].Text = &text
+			}
+		}
+	}
+
+	err = c.db.SaveSurveyResponse(r.Context(), db.SurveyResponse{
+		SurveyName: name,
+		Version:    record.Version,
+		SessionID:  session,
+		Role:       role,
+		Answers:    answers,
+	})
+	if errors.Is(err, db.ErrAlreadyExists) {
+		c.writeErrorResponse(w, http.StatusConflict, fmt.Sprintf("this session already responded to version %d of `%s`", record.Version, name))
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to save survey response")
+		return
+	}
+}
+
+//AdminSurvey serves survey management under /v1/admin/surveys/{name}.
+//Changing a survey also requires the surveys permission.
+//
+//  GET /v1/admin/surveys/{name}?version=N returns a version, by default the active one
+//  POST /v1/admin/surveys/{name}/versions creates the next version
+//  PUT /v1/admin/surveys/{name}/active chooses the version accepting responses
+//  GET /v1/admin/surveys/{name}/results?version=N summarizes the responses to a version
+func (c Client) AdminSurvey(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/admin/surveys/"), "/")
+	name := parts[0]
+	op := ""
+	if len(parts) == 2 {
+		op = parts[1]
+	}
+	if len(parts) > 2 {
+		c.writeErrorResponse(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch {
+	case op == "" && r.Method == "GET":
+		version, err := parseSurveyVersion(r)
+		if err != nil {
+			c.writeValidationError(w, err)
+			return
+		}
+		c.getSurvey(w, r, name, version)
+	case op == "versions" && r.Method == "POST":
+		if c.authorize(w, r, authz.Surveys) {
+			c.createSurveyVersion(w, r, name)
+		}
+	case op == "active" && r.Method == "PUT":
+		if c.authorize(w, r, authz.Surveys) {
+			c.setActiveSurvey(w, r, name)
+		}
+	case op == "results" && r.Method == "GET":
+		c.surveyResults(w, r, name)
+	case op == "" || op == "results":
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET instead")
+	case op == "versions":
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use POST instead")
+	case op == "active":
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use PUT instead")
+	default:
+		c.writeErrorResponse(w, http.StatusNotFound, "not found")
+	}
+}
+
+//parseSurveyVersion reads the optional `version` query parameter
+func parseSurveyVersion(r *http.Request) (*int, error) {
+	s := r.URL.Query().Get("version")
+	if s == "" {
+		return nil, nil
+	}
+
+	version, err := strconv.Atoi(s)
+	if err != nil || version <= 0 {
+		return nil, ValidationError{
+			Reason:  "invalid_version",
+			Message: "`version` must be a positive integer",
+		}
+	}
+	return &version, nil
+}
+
+var surveyNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+func (c Client) createSurveyVersion(w http.ResponseWriter, r *http.Request, name string) {
+	if !surveyNameRegexp.MatchString(name) {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_survey",
+			Message: fmt.Sprintf("invalid survey name `%s`", name),
+		})
+		return
+	}
+
+	var req CreateSurveyVersionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
+		return
+	}
+
+	if strings.TrimSpace(req.Title) == "" {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "missing_title",
+			Message: "`title` is required",
+		})
+		return
+	}
+	if err := survey.Validate(req.Questions); err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_questions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	questions, err := json.Marshal(req.Questions)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to create survey version")
+		return
+	}
+
+	session := r.Header.Get("X-Smarta-Auth-Session")
+	role := r.Header.Get("X-Smarta-Auth-Role")
+	var version int
+	err = c.db.InTransaction(r.Context(), func(ctx context.Context) (err error) {
+		version, err = c.db.CreateSurveyVersion(ctx, db.SurveyVersion{
+			SurveyName:       name,
+			Title:            req.Title,
+			Questions:        questions,
+			CreatedBySession: session,
+			CreatedByRole:    role,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = c.recordAudit(ctx, audit.Entry{
+			ActorSession: session,
+			ActorRole:    role,
+			Action:       AuditCreateSurveyVersion,
+			TargetType:   "survey",
+			TargetIDs:    []string{name},
+			After: map[string]interface{}{
+				"version":   version,
+				"title":     req.Title,
+				"questions": req.Questions,
+			},
+		})
+		if err != nil || !req.Activate {
+			return err
+		}
+
+		return c.activateSurveyVersion(ctx, r, name, &version)
+	})
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to create survey version")
+		return
+	}
+
+	record, err := c.loadSurvey(r.Context(), name, &version)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "created survey version, but failed to read it back")
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusCreated, record)
+}
+
+func (c Client) setActiveSurvey(w http.ResponseWriter, r *http.Request, name string) {
+	var req SetActiveSurveyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
+		return
+	}
+
+	err := c.db.InTransaction(r.Context(), func(ctx context.Context) error {
+		return c.activateSurveyVersion(ctx, r, name, req.Version)
+	})
+	if errors.Is(err, db.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "survey version not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to set active survey version")
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, req)
+}
+
+//activateSurveyVersion makes version the one accepting responses, recording
+//the change in the audit log. It should be called in a transaction, so that
+//a failure to audit it undoes it.
+func (c Client) activateSurveyVersion(ctx context.Context, r *http.Request, name string, version *int) error {
+	before, err := c.activeSurveyVersion(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if err := c.db.SetActiveSurveyVersion(ctx, name, version); err != nil {
+		return err
+	}
+
+	return c.recordAudit(ctx, audit.Entry{
+		ActorSession: r.Header.Get("X-Smarta-Auth-Session"),
+		ActorRole:    r.Header.Get("X-Smarta-Auth-Role"),
+		Action:       AuditActivateSurvey,
+		TargetType:   "survey",
+		TargetIDs:    []string{name},
+		Before:       map[string]*int{"active_version": before},
+		After:        map[string]*int{"active_version": version},
+	})
+}
+
+//activeSurveyVersion returns the number of the active version of a survey,
+//or nil if it has none
+func (c Client) activeSurveyVersion(ctx context.Context, name string) (*int, error) {
+	v, err := c.db.GetSurveyVersion(ctx, name, nil)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v.Version, nil
+}
+
+func (c Client) surveyResults(w http.ResponseWriter, r *http.Request, name string) {
+	version, err := parseSurveyVersion(r)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return
+	}
+
+	record, err := c.loadSurvey(r.Context(), name, version)
+	if errors.Is(err, db.ErrNotFound) {
+		c.writeErrorResponse(w, http.StatusNotFound, "survey not found")
+		return
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get survey results")
+		return
+	}
+
+	counts, err := c.db.CountSurveyAnswers(r.Context(), name, record.Version)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get survey results")
+		return
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, SurveyResultsResponse{
+		Survey:  name,
+		Version: record.Version,
+		Title:   record.Title,
+		Results: survey.Summarize(record.Questions, counts),
+	})
+}
@@ -0,0 +1,374 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/audit/auditfakes"
+	"github.com/smartatransit/feedback/authz"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/ratelimit"
+	"github.com/smartatransit/feedback/ratelimit/ratelimitfakes"
+	"github.com/smartatransit/feedback/redact"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+const tripSurveyQuestions = `[
+	{"id": "trip", "type": "scale", "prompt": "Rate your trip", "required": true, "min": 1, "max": 5},
+	{"id": "recommend", "type": "scale", "prompt": "Would you recommend MARTA?", "required": false, "min": 0, "max": 10}
+]`
+
+var _ = Describe("Surveys", func() {
+	var (
+		db      *dbfakes.FakeDB
+		auditor *auditfakes.FakeRecorder
+		limiter *ratelimitfakes.FakeLimiter
+		client  api.Client
+
+		body interface{}
+		req  *http.Request
+		resp *http.Response
+	)
+
+	BeforeEach(func() {
+		db = newFakeDB()
+		auditor = &auditfakes.FakeRecorder{}
+		limiter = &ratelimitfakes.FakeLimiter{}
+		limiter.AllowReturns(ratelimit.Decision{Allowed: true}, nil)
+		body = nil
+
+		db.GetSurveyVersionReturns(dbp.SurveyVersion{
+			SurveyName: "trip",
+			Version:    2,
+			Active:     true,
+			Title:      "How was your trip?",
+			Questions:  []byte(tripSurveyQuestions),
+		}, nil)
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client = api.New(log, db).
+			WithAuthorization(testPolicy()).
+			WithAuditLog(auditor).
+			WithRateLimiter(limiter).
+			WithRedaction(redact.New(), false)
+
+		req.Header.Set("X-Smarta-Auth-Session", "r39iefjd0q39f")
+		if req.Header.Get("X-Smarta-Auth-Role") == "" {
+			req.Header.Set("X-Smarta-Auth-Role", "admin")
+		}
+		if body != nil {
+			bodyBytes, err := json.Marshal(body)
+			Expect(err).To(BeNil())
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+		}
+	})
+
+	Describe("Survey", func() {
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.Survey(respW, req)
+			resp = respW.Result()
+		})
+
+		Describe("getting the active version", func() {
+			BeforeEach(func() {
+				req = httptest.NewRequest("GET", "/v1/surveys/trip", nil)
+			})
+
+			It("returns it", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, name, version := db.GetSurveyVersionArgsForCall(0)
+				Expect(name).To(Equal("trip"))
+				Expect(version).To(BeNil())
+
+				var respObj api.SurveyRecord
+				Expect(json.NewDecoder(resp.Body).Decode(&respObj)).To(Succeed())
+				Expect(respObj.Version).To(Equal(2))
+				Expect(respObj.Questions).To(HaveLen(2))
+				Expect(*respObj.Questions[0].Max).To(Equal(5))
+			})
+
+			When("the survey isn't open", func() {
+				BeforeEach(func() {
+					db.GetSurveyVersionReturns(dbp.SurveyVersion{}, dbp.ErrNotFound)
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(404))
+				})
+			})
+		})
+
+		Describe("responding", func() {
+			BeforeEach(func() {
+				req = httptest.NewRequest("POST", "/v1/surveys/trip/responses", nil)
+				req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+				body = map[string]interface{}{
+					"version": 2,
+					"answers": map[string]interface{}{"trip": 4, "recommend": 9},
+				}
+			})
+
+			It("saves the answers", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, response := db.SaveSurveyResponseArgsForCall(0)
+				Expect(response.SurveyName).To(Equal("trip"))
+				Expect(response.Version).To(Equal(2))
+				Expect(response.SessionID).To(Equal("r39iefjd0q39f"))
+				Expect(response.Role).To(Equal("anonymous"))
+				Expect(response.Answers).To(HaveLen(2))
+				Expect(*response.Answers[1].Number).To(Equal(9))
+			})
+
+			When("the answers don't fit the questions", func() {
+				BeforeEach(func() {
+					body = map[string]interface{}{
+						"version": 2,
+						"answers": map[string]interface{}{"trip": 7},
+					}
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+
+					respBody, err := ioutil.ReadAll(resp.Body)
+					Expect(err).To(BeNil())
+					Expect(string(respBody)).To(ContainSubstring("`trip` must be a whole number from 1 to 5"))
+					Expect(db.SaveSurveyResponseCallCount()).To(Equal(0))
+				})
+			})
+			When("they answer a version that is no longer active", func() {
+				BeforeEach(func() {
+					body = map[string]interface{}{
+						"version": 1,
+						"answers": map[string]interface{}{"trip": 4},
+					}
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(409))
+					Expect(db.SaveSurveyResponseCallCount()).To(Equal(0))
+				})
+			})
+			When("saving fails", func() {
+				BeforeEach(func() {
+					db.SaveSurveyResponseReturns(errors.New("insert failed"))
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(500))
+				})
+			})
+			When("the session already responded", func() {
+				BeforeEach(func() {
+					db.SaveSurveyResponseReturns(dbp.ErrAlreadyExists)
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(409))
+				})
+			})
+			When("the session is rate limited", func() {
+				BeforeEach(func() {
+					limiter.AllowReturns(ratelimit.Decision{RetryAfter: 90 * time.Second}, nil)
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(429))
+					Expect(resp.Header.Get("Retry-After")).To(Equal("90"))
+					Expect(db.SaveSurveyResponseCallCount()).To(Equal(0))
+
+					_, session, role, kind := limiter.AllowArgsForCall(0)
+					Expect(session).To(Equal("r39iefjd0q39f"))
+					Expect(role).To(Equal("anonymous"))
+					Expect(kind).To(Equal("survey"))
+				})
+			})
+			When("free text answers identify the rider", func() {
+				BeforeEach(func() {
+					db.GetSurveyVersionReturns(dbp.SurveyVersion{
+						SurveyName: "trip",
+						Version:    2,
+						Active:     true,
+						Questions:  []byte(`[{"id": "improve", "type": "free_text", "prompt": "What could be better?", "max_length": 500}]`),
+					}, nil)
+					body = map[string]interface{}{
+						"version": 2,
+						"answers": map[string]interface{}{"improve": "email me at rider@example.com"},
+					}
+				})
+				It("redacts them", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+					_, response := db.SaveSurveyResponseArgsForCall(0)
+					Expect(response.Answers[0].Text).To(PointTo(Equal("email me at [email]")))
+				})
+			})
+		})
+	})
+
+	Describe("AdminSurvey", func() {
+		JustBeforeEach(func() {
+			respW := httptest.NewRecorder()
+			client.Require(authz.Read, client.AdminSurvey)(respW, req)
+			resp = respW.Result()
+		})
+
+		Describe("creating a version", func() {
+			BeforeEach(func() {
+				req = httptest.NewRequest("POST", "/v1/admin/surveys/trip/versions", nil)
+				body = map[string]interface{}{
+					"title":     "How was your trip?",
+					"questions": json.RawMessage(tripSurveyQuestions),
+					"activate":  true,
+				}
+				db.CreateSurveyVersionReturns(2, nil)
+			})
+
+			It("creates and activates it", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(201))
+
+				_, version := db.CreateSurveyVersionArgsForCall(0)
+				Expect(version.SurveyName).To(Equal("trip"))
+				Expect(version.CreatedByRole).To(Equal("admin"))
+				Expect(version.Questions).To(MatchJSON(tripSurveyQuestions))
+
+				_, name, active := db.SetActiveSurveyVersionArgsForCall(0)
+				Expect(name).To(Equal("trip"))
+				Expect(*active).To(Equal(2))
+
+				Expect(auditor.RecordCallCount()).To(Equal(2))
+				_, entry := auditor.RecordArgsForCall(0)
+				Expect(entry.Action).To(Equal(api.AuditCreateSurveyVersion))
+				_, entry = auditor.RecordArgsForCall(1)
+				Expect(entry.Action).To(Equal(api.AuditActivateSurvey))
+				Expect(entry.After).To(Equal(map[string]*int{"active_version": active}))
+			})
+
+			When("the role can't manage surveys", func() {
+				BeforeEach(func() {
+					req.Header.Set("X-Smarta-Auth-Role", "auditor")
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(403))
+					Expect(db.CreateSurveyVersionCallCount()).To(Equal(0))
+				})
+			})
+			When("the questions are invalid", func() {
+				BeforeEach(func() {
+					body = map[string]interface{}{
+						"title":     "How was your trip?",
+						"questions": []map[string]interface{}{{"id": "trip", "type": "scale", "prompt": "Rate it"}},
+					}
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+					Expect(db.CreateSurveyVersionCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Describe("closing a survey", func() {
+			BeforeEach(func() {
+				req = httptest.NewRequest("PUT", "/v1/admin/surveys/trip/active", nil)
+				body = map[string]interface{}{"version": nil}
+			})
+
+			It("clears the active version", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, _, active := db.SetActiveSurveyVersionArgsForCall(0)
+				Expect(active).To(BeNil())
+
+				_, entry := auditor.RecordArgsForCall(0)
+				two := 2
+				Expect(entry.Before).To(Equal(map[string]*int{"active_version": &two}))
+			})
+
+			When("the survey doesn't exist", func() {
+				BeforeEach(func() {
+					db.SetActiveSurveyVersionReturns(dbp.ErrNotFound)
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(404))
+					Expect(auditor.RecordCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Describe("getting results", func() {
+			BeforeEach(func() {
+				req = httptest.NewRequest("GET", "/v1/admin/surveys/trip/results?version=2", nil)
+				req.Header.Set("X-Smarta-Auth-Role", "auditor")
+
+				trip, recommend, five, ten := "trip", "recommend", "5", "10"
+				db.CountSurveyAnswersReturns([]dbp.SurveyAnswerCount{
+					{Total: true, Count: 3},
+					{QuestionID: &trip, Total: true, Count: 3},
+					{QuestionID: &trip, Answer: &five, Count: 3},
+					{QuestionID: &recommend, Total: true, Count: 1},
+					{QuestionID: &recommend, Answer: &ten, Count: 1},
+				}, nil)
+			})
+
+			It("summarizes the responses", func() {
+				Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+				_, _, version := db.GetSurveyVersionArgsForCall(0)
+				Expect(*version).To(Equal(2))
+
+				respBody, err := ioutil.ReadAll(resp.Body)
+				Expect(err).To(BeNil())
+				Expect(respBody).To(MatchJSON(`{
+					"survey": "trip",
+					"version": 2,
+					"title": "How was your trip?",
+					"results": {
+						"responses": 3,
+						"questions": [
+							{
+								"id": "trip", "type": "scale", "prompt": "Rate your trip", "answered": 3,
+								"distribution": {"1": 0, "2": 0, "3": 0, "4": 0, "5": 3},
+								"average": 5
+							},
+							{
+								"id": "recommend", "type": "scale", "prompt": "Would you recommend MARTA?", "answered": 1,
+								"distribution": {"0": 0, "1": 0, "2": 0, "3": 0, "4": 0, "5": 0, "6": 0, "7": 0, "8": 0, "9": 0, "10": 1},
+								"average": 10,
+								"nps": 100
+							}
+						]
+					}
+				}`))
+			})
+
+			When("the version is invalid", func() {
+				BeforeEach(func() {
+					req.URL.RawQuery = "version=latest"
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(400))
+				})
+			})
+			When("counting fails", func() {
+				BeforeEach(func() {
+					db.CountSurveyAnswersReturns(nil, errors.New("select failed"))
+				})
+				It("fails", func() {
+					Expect(resp.StatusCode).To(BeEquivalentTo(500))
+				})
+			})
+		})
+	})
+})
@@ -0,0 +1,459 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/authz"
+	"github.com/smartatransit/feedback/db"
+)
+
+//Limits on triage updates
+const (
+	MaxBulkTriage     = 500
+	MaxTags           = 20
+	MaxAssigneeLength = 255
+)
+
+//unassigned is the `assignee` filter matching feedback without an assignee
+const unassigned = "none"
+
+var triageStatuses = map[string]struct{}{
+	db.TriageNew:        {},
+	db.TriageInProgress: {},
+	db.TriageResolved:   {},
+	db.TriageWontFix:    {},
+}
+
+var priorities = map[string]struct{}{
+	db.PriorityLow:    {},
+	db.PriorityNormal: {},
+	db.PriorityHigh:   {},
+	db.PriorityUrgent: {},
+}
+
+var tagRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+//TriageRecord is where staff are with a feedback
+type TriageRecord struct {
+	Status    string     `json:"status"`
+	Assignee  *string    `json:"assignee,omitempty"`
+	Priority  string     `json:"priority"`
+	Tags      []string   `json:"tags"`
+	TriagedAt *time.Time `json:"triaged_at,omitempty"`
+}
+
+func triageRecordFromTriage(t db.Triage) TriageRecord {
+	tags := t.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return TriageRecord{
+		Status:    t.Status,
+		Assignee:  t.Assignee,
+		Priority:  t.Priority,
+		Tags:      tags,
+		TriagedAt: t.TriagedAt,
+	}
+}
+
+//TriageRequest changes the triage of a feedback. Fields left out are
+//unchanged. An empty `assignee` unassigns it. `tags` replaces the tags, then
+//`add_tags` are added and `remove_tags` removed.
+type TriageRequest struct {
+	Status     *string  `json:"status"`
+	Assignee   *string  `json:"assignee"`
+	Priority   *string  `json:"priority"`
+	Tags       []string `json:"tags"`
+	AddTags    []string `json:"add_tags"`
+	RemoveTags []string `json:"remove_tags"`
+	Note       string   `json:"note"`
+}
+
+//BulkTriageRequest applies the same change to the triage of each feedback
+//in IDs
+type BulkTriageRequest struct {
+	IDs []string `json:"ids"`
+	TriageRequest
+}
+
+//TriageChangeRecord is the triage of a feedback before and after an update
+type TriageChangeRecord struct {
+	ID     string       `json:"id"`
+	Before TriageRecord `json:"before"`
+	After  TriageRecord `json:"after"`
+}
+
+//BulkTriageResponse lists the feedback updated, and the IDs of any that
+//don't exist
+type BulkTriageResponse struct {
+	Updated  []TriageChangeRecord `json:"updated"`
+	NotFound []string             `json:"not_found"`
+}
+
+//TriageHistoryResponse lists the changes made to the triage of a feedback
+type TriageHistoryResponse struct {
+	Transitions []TriageTransitionRecord `json:"transitions"`
+}
+
+//TriageTransitionRecord is a change to one triage field of a feedback
+type TriageTransitionRecord struct {
+	ID           string          `json:"id"`
+	Field        string          `json:"field"`
+	OldValue     json.RawMessage `json:"old_value"`
+	NewValue     json.RawMessage `json:"new_value"`
+	Note         *string         `json:"note,omitempty"`
+	ActorSession string          `json:"actor_session"`
+	ActorRole    string          `json:"actor_role"`
+	ChangedAt    time.Time       `json:"changed_at"`
+}
+
+//triageValue normalizes a status or priority, so that e.g. `In progress`
+//and `won't fix` are accepted
+func triageValue(s string) string {
+	return strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+//normalizeTags lowercases and checks each tag, naming the request field in
+//errors
+func normalizeTags(field string, tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+	if len(tags) > MaxTags {
+		return nil, ValidationError{
+			Reason:  "invalid_tags",
+			Message: fmt.Sprintf("`%s` may have at most %d tags", field, MaxTags),
+		}
+	}
+
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		t := strings.ToLower(strings.TrimSpace(tag))
+		if !tagRegexp.MatchString(t) {
+			return nil, ValidationError{
+				Reason:  "invalid_tags",
+				Message: fmt.Sprintf("invalid tag `%s` in `%s`: tags are up to 50 letters, digits, `-` and `_`", tag, field),
+			}
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+//triageUpdate validates req and returns the update it asks for
+func triageUpdate(req TriageRequest, r *http.Request) (update db.TriageUpdate, err error) {
+	update.ActorSession = r.Header.Get("X-Smarta-Auth-Session")
+	update.ActorRole = r.Header.Get("X-Smarta-Auth-Role")
+	update.MaxTags = MaxTags
+
+	if req.Status != nil {
+		status := triageValue(*req.Status)
+		if _, ok := triageStatuses[status]; !ok {
+			err = ValidationError{
+				Reason:  "invalid_status",
+				Message: fmt.Sprintf("invalid value `%s` for `status`", *req.Status),
+			}
+			return
+		}
+		update.Status = &status
+	}
+
+	if req.Priority != nil {
+		priority := triageValue(*req.Priority)
+		if _, ok := priorities[priority]; !ok {
+			err = ValidationError{
+				Reason:  "invalid_priority",
+				Message: fmt.Sprintf("invalid value `%s` for `priority`", *req.Priority),
+			}
+			return
+		}
+		update.Priority = &priority
+	}
+
+	if req.Assignee != nil {
+		assignee := strings.TrimSpace(*req.Assignee)
+		if len(assignee) > MaxAssigneeLength {
+			err = ValidationError{
+				Reason:  "invalid_assignee",
+				Message: fmt.Sprintf("`assignee` may be at most %d characters", MaxAssigneeLength),
+			}
+			return
+		}
+		update.Assignee = &assignee
+	}
+
+	if update.Tags, err = normalizeTags("tags", req.Tags); err != nil {
+		return
+	}
+	if update.AddTags, err = normalizeTags("add_tags", req.AddTags); err != nil {
+		return
+	}
+	if update.RemoveTags, err = normalizeTags("remove_tags", req.RemoveTags); err != nil {
+		return
+	}
+
+	if update.Status == nil && update.Priority == nil && update.Assignee == nil &&
+		update.Tags == nil && len(update.AddTags) == 0 && len(update.RemoveTags) == 0 {
+		err = ValidationError{
+			Reason:  "missing_change",
+			Message: "at least one of `status`, `assignee`, `priority`, `tags`, `add_tags` and `remove_tags` is required",
+		}
+		return
+	}
+
+	if note := strings.TrimSpace(req.Note); note != "" {
+		update.Note = &note
+	}
+
+	return update, nil
+}
+
+//adminTriage serves /v1/admin/feedback/{id}/triage: GET returns the triage
+//history of a feedback and POST changes its triage
+func (c Client) adminTriage(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case "GET":
+		c.getTriageHistory(w, r, id)
+	case "POST":
+		if !c.authorize(w, r, authz.Triage) {
+			return
+		}
+
+		var req TriageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			c.writeValidationError(w, ValidationError{
+				Reason:  "malformed_json",
+				Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+			})
+			return
+		}
+
+		changes, ok := c.updateTriage(w, r, []string{id}, req)
+		if !ok {
+			return
+		}
+		if len(changes) == 0 {
+			c.writeErrorResponse(w, http.StatusNotFound, "feedback not found")
+			return
+		}
+
+		c.writeJSONResponse(w, http.StatusOK, changes[0])
+	default:
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use GET or POST instead")
+	}
+}
+
+//BulkTriage serves POST /v1/admin/triage, changing the triage of up to
+//MaxBulkTriage feedback at once
+func (c Client) BulkTriage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		c.writeErrorResponse(w, http.StatusMethodNotAllowed, "use POST instead")
+		return
+	}
+
+	var req BulkTriageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "malformed_json",
+			Message: fmt.Sprintf("malformed JSON request body: %s", err.Error()),
+		})
+		return
+	}
+
+	if len(req.IDs) == 0 || len(req.IDs) > MaxBulkTriage {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_ids",
+			Message: fmt.Sprintf("`ids` must list between 1 and %d feedback IDs", MaxBulkTriage),
+		})
+		return
+	}
+	seen := map[string]struct{}{}
+	ids := []string{}
+	for _, id := range req.IDs {
+		if !uuidRegexp.MatchString(id) {
+			c.writeValidationError(w, ValidationError{
+				Reason:  "invalid_ids",
+				Message: fmt.Sprintf("invalid feedback ID `%s` in `ids`", id),
+			})
+			return
+		}
+		id = strings.ToLower(id)
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+
+	changes, ok := c.updateTriage(w, r, ids, req.TriageRequest)
+	if !ok {
+		return
+	}
+
+	resp := BulkTriageResponse{Updated: changes, NotFound: []string{}}
+	for _, ch := range changes {
+		delete(seen, ch.ID)
+	}
+	for id := range seen {
+		resp.NotFound = append(resp.NotFound, id)
+	}
+	sort.Strings(resp.NotFound)
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+//updateTriage applies req to the feedback in ids and audits the change,
+//writing an error response and returning false if it fails
+func (c Client) updateTriage(w http.ResponseWriter, r *http.Request, ids []string, req TriageRequest) ([]TriageChangeRecord, bool) {
+	update, err := triageUpdate(req, r)
+	if err != nil {
+		c.writeValidationError(w, err)
+		return nil, false
+	}
+	update.FeedbackIDs = ids
+
+	var records []TriageChangeRecord
+	err = c.db.InTransaction(r.Context(), func(ctx context.Context) error {
+		changes, err := c.db.UpdateTriage(ctx, update)
+		if err != nil {
+			return err
+		}
+
+		records = []TriageChangeRecord{}
+		targetIDs := []string{}
+		before := map[string]TriageRecord{}
+		after := map[string]TriageRecord{}
+		for _, ch := range changes {
+			record := TriageChangeRecord{
+				ID:     ch.FeedbackID,
+				Before: triageRecordFromTriage(ch.Before),
+				After:  triageRecordFromTriage(ch.After),
+			}
+			records = append(records, record)
+			targetIDs = append(targetIDs, record.ID)
+			before[record.ID] = record.Before
+			after[record.ID] = record.After
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		return c.recordAudit(ctx, audit.Entry{
+			ActorSession: update.ActorSession,
+			ActorRole:    update.ActorRole,
+			Action:       AuditTriageFeedback,
+			TargetType:   "feedback",
+			TargetIDs:    targetIDs,
+			Before:       before,
+			After:        after,
+		})
+	})
+	if errors.Is(err, db.ErrTooManyTags) {
+		c.writeValidationError(w, ValidationError{
+			Reason:  "invalid_tags",
+			Message: fmt.Sprintf("feedback may have at most %d tags", MaxTags),
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to update triage")
+		return nil, false
+	}
+
+	return records, true
+}
+
+func (c Client) getTriageHistory(w http.ResponseWriter, r *http.Request, id string) {
+	transitions, err := c.db.GetTriageTransitions(r.Context(), id)
+	if err != nil {
+		c.logger(r.Context()).Error(err.Error())
+		c.writeErrorResponse(w, http.StatusInternalServerError, "failed to get triage history")
+		return
+	}
+
+	resp := TriageHistoryResponse{Transitions: []TriageTransitionRecord{}}
+	for _, t := range transitions {
+		resp.Transitions = append(resp.Transitions, TriageTransitionRecord{
+			ID:           t.ID,
+			Field:        t.Field,
+			OldValue:     jsonOrNull(t.OldValue),
+			NewValue:     jsonOrNull(t.NewValue),
+			Note:         t.Note,
+			ActorSession: t.ActorSession,
+			ActorRole:    t.ActorRole,
+			ChangedAt:    t.ChangedAt,
+		})
+	}
+
+	c.writeJSONResponse(w, http.StatusOK, resp)
+}
+
+//jsonOrNull returns v, or JSON null in place of nil, which would otherwise
+//fail to marshal
+func jsonOrNull(v json.RawMessage) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
+
+//hasTriageFilter reports whether query filters by triage
+func hasTriageFilter(query url.Values) bool {
+	for _, key := range []string{"status", "assignee", "priority", "tag"} {
+		if _, ok := query[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+//parseTriageFilter reads the `status`, `assignee`, `priority` and `tag`
+//query parameters into filter. An `assignee` of `none` matches unassigned
+//feedback, and `tag` may be repeated to match feedback with every tag.
+func parseTriageFilter(query url.Values, filter *db.FeedbackFilter) error {
+	if s := query.Get("status"); s != "" {
+		status := triageValue(s)
+		if _, ok := triageStatuses[status]; !ok {
+			return ValidationError{
+				Reason:  "invalid_status",
+				Message: fmt.Sprintf("invalid value `%s` for `status`", s),
+			}
+		}
+		filter.TriageStatus = &status
+	}
+
+	if s := query.Get("priority"); s != "" {
+		priority := triageValue(s)
+		if _, ok := priorities[priority]; !ok {
+			return ValidationError{
+				Reason:  "invalid_priority",
+				Message: fmt.Sprintf("invalid value `%s` for `priority`", s),
+			}
+		}
+		filter.Priority = &priority
+	}
+
+	if assignee := strings.TrimSpace(query.Get("assignee")); assignee == unassigned {
+		filter.Unassigned = true
+	} else if assignee != "" {
+		filter.Assignee = &assignee
+	}
+
+	tags, err := normalizeTags("tag", query["tag"])
+	if err != nil {
+		return err
+	}
+	filter.Tags = tags
+
+	return nil
+}
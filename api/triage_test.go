@@ -0,0 +1,238 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/audit/auditfakes"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+const (
+	triageFeedbackID      = "6a1f3c2e-9b8d-4c7e-a1f2-3b4c5d6e7f80"
+	otherTriageFeedbackID = "7b2e4d3f-0c9e-4d8f-b2a3-4c5d6e7f8091"
+)
+
+var _ = Describe("Triage", func() {
+	var (
+		db      *dbfakes.FakeDB
+		auditor *auditfakes.FakeRecorder
+		client  api.Client
+
+		resp *http.Response
+	)
+
+	BeforeEach(func() {
+		db = newFakeDB()
+		auditor = &auditfakes.FakeRecorder{}
+		db.UpdateTriageStub = func(_ context.Context, update dbp.TriageUpdate) ([]dbp.TriageChange, error) {
+			changes := []dbp.TriageChange{}
+			for _, id := range update.FeedbackIDs {
+				if id == otherTriageFeedbackID {
+					continue
+				}
+				after := dbp.Triage{Status: "new", Priority: "normal", Tags: []string{}}
+				if update.Status != nil {
+					after.Status = *update.Status
+				}
+				after.Assignee = update.Assignee
+				changes = append(changes, dbp.TriageChange{
+					FeedbackID: id,
+					Before:     dbp.Triage{Status: "new", Priority: "normal"},
+					After:      after,
+				})
+			}
+			return changes, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		client = api.New(log, db).
+			WithAuthorization(testPolicy()).
+			WithCatalog(testCatalog()).
+			WithAuditLog(auditor)
+	})
+
+	serve := func(handler http.HandlerFunc, method, path, role, body string) *http.Response {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Smarta-Auth-Session", role+"-session")
+		req.Header.Set("X-Smarta-Auth-Role", role)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Result()
+	}
+
+	Describe("AdminFeedback triage", func() {
+		It("changes the triage of a feedback and audits it", func() {
+			resp = serve(client.AdminFeedback, "POST", "/v1/admin/feedback/"+triageFeedbackID+"/triage", "admin",
+				`{"status": "In progress", "assignee": " dana ", "priority": "high", "add_tags": ["Elevator"], "note": "looking into it"}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, update := db.UpdateTriageArgsForCall(0)
+			Expect(update).To(MatchAllFields(Fields{
+				"FeedbackIDs":  Equal([]string{triageFeedbackID}),
+				"Status":       PointTo(Equal("in_progress")),
+				"Assignee":     PointTo(Equal("dana")),
+				"Priority":     PointTo(Equal("high")),
+				"Tags":         BeNil(),
+				"AddTags":      Equal([]string{"elevator"}),
+				"RemoveTags":   BeNil(),
+				"MaxTags":      Equal(api.MaxTags),
+				"Note":         PointTo(Equal("looking into it")),
+				"ActorSession": Equal("admin-session"),
+				"ActorRole":    Equal("admin"),
+			}))
+
+			var record api.TriageChangeRecord
+			Expect(json.NewDecoder(resp.Body).Decode(&record)).To(Succeed())
+			Expect(record.ID).To(Equal(triageFeedbackID))
+			Expect(record.After.Status).To(Equal("in_progress"))
+
+			_, entry := auditor.RecordArgsForCall(0)
+			Expect(entry).To(MatchFields(IgnoreExtras, Fields{
+				"Action":    Equal(api.AuditTriageFeedback),
+				"TargetIDs": Equal([]string{triageFeedbackID}),
+			}))
+		})
+
+		It("requires the triage permission to change it", func() {
+			resp = serve(client.AdminFeedback, "POST", "/v1/admin/feedback/"+triageFeedbackID+"/triage", "auditor",
+				`{"status": "resolved"}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(403))
+			Expect(db.UpdateTriageCallCount()).To(Equal(0))
+		})
+
+		It("rejects unknown statuses and priorities, bad tags and empty changes", func() {
+			for _, body := range []string{
+				`{"status": "done"}`,
+				`{"priority": "whenever"}`,
+				`{"tags": ["not a tag"]}`,
+				`{"note": "nothing to change"}`,
+			} {
+				resp = serve(client.AdminFeedback, "POST", "/v1/admin/feedback/"+triageFeedbackID+"/triage", "admin", body)
+				Expect(resp.StatusCode).To(BeEquivalentTo(400), body)
+			}
+			Expect(db.UpdateTriageCallCount()).To(Equal(0))
+		})
+
+		It("fails for feedback that doesn't exist", func() {
+			resp = serve(client.AdminFeedback, "POST", "/v1/admin/feedback/"+otherTriageFeedbackID+"/triage", "admin",
+				`{"status": "resolved"}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(404))
+			Expect(auditor.RecordCallCount()).To(Equal(0))
+		})
+
+		It("returns the triage history", func() {
+			note := "looking into it"
+			db.GetTriageTransitionsReturns([]dbp.TriageTransition{
+				{ID: "t1", Field: "status", OldValue: []byte(`"new"`), NewValue: []byte(`"in_progress"`), Note: &note,
+					ActorSession: "admin-session", ActorRole: "admin", ChangedAt: time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)},
+				{ID: "t2", Field: "assignee", NewValue: []byte(`"dana"`),
+					ActorSession: "admin-session", ActorRole: "admin", ChangedAt: time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)},
+			}, nil)
+
+			resp = serve(client.AdminFeedback, "GET", "/v1/admin/feedback/"+triageFeedbackID+"/triage", "auditor", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, id := db.GetTriageTransitionsArgsForCall(0)
+			Expect(id).To(Equal(triageFeedbackID))
+
+			body, err := ioutil.ReadAll(resp.Body)
+			Expect(err).To(BeNil())
+			Expect(string(body)).To(ContainSubstring(`"old_value":"new","new_value":"in_progress"`))
+			Expect(string(body)).To(ContainSubstring(`"old_value":null,"new_value":"dana"`))
+		})
+	})
+
+	Describe("BulkTriage", func() {
+		It("updates every feedback and lists those not found", func() {
+			resp = serve(client.BulkTriage, "POST", "/v1/admin/triage", "admin",
+				`{"ids": ["`+triageFeedbackID+`", "`+otherTriageFeedbackID+`", "`+triageFeedbackID+`"], "status": "won't fix", "assignee": ""}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, update := db.UpdateTriageArgsForCall(0)
+			Expect(update.FeedbackIDs).To(Equal([]string{triageFeedbackID, otherTriageFeedbackID}))
+			Expect(update.Status).To(PointTo(Equal("wont_fix")))
+			Expect(update.Assignee).To(PointTo(Equal("")))
+
+			var body api.BulkTriageResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+			Expect(body.Updated).To(HaveLen(1))
+			Expect(body.NotFound).To(Equal([]string{otherTriageFeedbackID}))
+		})
+
+		It("rejects invalid IDs", func() {
+			resp = serve(client.BulkTriage, "POST", "/v1/admin/triage", "admin",
+				`{"ids": ["not-an-id"], "status": "resolved"}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+			Expect(db.UpdateTriageCallCount()).To(Equal(0))
+		})
+
+		It("rejects changes that would leave a feedback with too many tags", func() {
+			db.UpdateTriageStub = nil
+			db.UpdateTriageReturns(nil, dbp.ErrTooManyTags)
+			resp = serve(client.BulkTriage, "POST", "/v1/admin/triage", "admin",
+				`{"ids": ["`+triageFeedbackID+`"], "add_tags": ["elevator"]}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+
+			var body map[string]interface{}
+			Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+			Expect(body["message"]).To(Equal("feedback may have at most 20 tags"))
+			Expect(auditor.RecordCallCount()).To(Equal(0))
+		})
+
+		It("fails if the update fails", func() {
+			db.UpdateTriageStub = nil
+			db.UpdateTriageReturns(nil, errors.New("update failed"))
+			resp = serve(client.BulkTriage, "POST", "/v1/admin/triage", "admin",
+				`{"ids": ["`+triageFeedbackID+`"], "status": "resolved"}`)
+			Expect(resp.StatusCode).To(BeEquivalentTo(500))
+			Expect(auditor.RecordCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("ListFeedback", func() {
+		It("filters by triage", func() {
+			resp = serve(client.ListFeedback, "GET",
+				"/v1/admin/feedback?status=in+progress&assignee=none&priority=urgent&tag=elevator&tag=Five-Points", "admin", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, filter, _, _ := db.ListFeedbackArgsForCall(0)
+			Expect(filter.TriageStatus).To(PointTo(Equal("in_progress")))
+			Expect(filter.Assignee).To(BeNil())
+			Expect(filter.Unassigned).To(BeTrue())
+			Expect(filter.Priority).To(PointTo(Equal("urgent")))
+			Expect(filter.Tags).To(Equal([]string{"elevator", "five-points"}))
+		})
+
+		It("filters by assignee", func() {
+			resp = serve(client.ListFeedback, "GET", "/v1/admin/feedback?assignee=dana", "admin", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(200))
+
+			_, filter, _, _ := db.ListFeedbackArgsForCall(0)
+			Expect(filter.Assignee).To(PointTo(Equal("dana")))
+			Expect(filter.Unassigned).To(BeFalse())
+		})
+
+		It("rejects unknown statuses", func() {
+			resp = serve(client.ListFeedback, "GET", "/v1/admin/feedback?status=done", "admin", "")
+			Expect(resp.StatusCode).To(BeEquivalentTo(400))
+		})
+	})
+})
@@ -0,0 +1,222 @@
+package attachment
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+//Accepted content types
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+)
+
+const jpegQuality = 85
+
+//Limits bounds the images riders may upload. Images are fully decoded, and
+//an upload being processed holds the decoded image, an RGBA copy of it and,
+//for turned JPEGs, a turned copy: up to twelve bytes per pixel, or about
+//290MB at the default MaxPixels. MaxConcurrent bounds how many uploads are
+//processed at once, and so the memory they use in all; zero means no bound.
+type Limits struct {
+	MaxBytes      int64
+	MaxDimension  int
+	MaxPixels     int
+	ThumbnailSize int
+	MaxConcurrent int
+}
+
+//DefaultLimits accepts photos from any current phone camera
+var DefaultLimits = Limits{
+	MaxBytes:      10 << 20,
+	MaxDimension:  8192,
+	MaxPixels:     24000000,
+	ThumbnailSize: 320,
+	MaxConcurrent: 2,
+}
+
+//Rejection explains why an upload isn't accepted. Reason is a short, stable
+//code suitable for use as a metric label.
+type Rejection struct {
+	Reason  string
+	Message string
+}
+
+func (r Rejection) Error() string {
+	return r.Message
+}
+
+//Image is an encoded image
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+//Processed is an upload made safe to store: the image and its thumbnail
+//are re-encoded from their pixels alone, so no metadata such as EXIF GPS
+//coordinates survives. SHA256 is the hex digest of the stored image.
+type Processed struct {
+	Image     Image
+	Thumbnail Image
+	SHA256    string
+}
+
+//Process checks an upload against limits and prepares it for storage. The
+//content type is sniffed from the data rather than trusted from the client,
+//and the dimensions are checked before the image is decoded. JPEG images
+//are turned upright according to their EXIF orientation before it is
+//stripped.
+func Process(data []byte, limits Limits) (Processed, error) {
+	if int64(len(data)) > limits.MaxBytes {
+		return Processed{}, Rejection{
+			Reason:  "attachment_too_large",
+			Message: fmt.Sprintf("attachments may be at most %d bytes", limits.MaxBytes),
+		}
+	}
+
+	contentType := http.DetectContentType(data)
+	if contentType != JPEG && contentType != PNG {
+		return Processed{}, Rejection{
+			Reason:  "unsupported_attachment_type",
+			Message: fmt.Sprintf("attachments must be JPEG or PNG images, not %s", contentType),
+		}
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, malformed()
+	}
+	if config.Width > limits.MaxDimension || config.Height > limits.MaxDimension ||
+		config.Width*config.Height > limits.MaxPixels {
+		return Processed{}, Rejection{
+			Reason: "attachment_too_large",
+			Message: fmt.Sprintf("images may be at most %d pixels wide or high, and %d pixels in all",
+				limits.MaxDimension, limits.MaxPixels),
+		}
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, malformed()
+	}
+
+	img := toRGBA(decoded)
+	if contentType == JPEG {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	var result Processed
+	result.Image, err = encode(img, contentType)
+	if err != nil {
+		return Processed{}, err
+	}
+	result.Thumbnail, err = encode(thumbnail(img, limits.ThumbnailSize), contentType)
+	if err != nil {
+		return Processed{}, err
+	}
+
+	sum := sha256.Sum256(result.Image.Data)
+	result.SHA256 = hex.EncodeToString(sum[:])
+
+	return result, nil
+}
+
+func malformed() Rejection {
+	return Rejection{
+		Reason:  "malformed_attachment",
+		Message: "the attachment couldn't be read as an image",
+	}
+}
+
+func encode(img *image.RGBA, contentType string) (Image, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == PNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return Image{}, fmt.Errorf("failed encoding image: %w", err)
+	}
+
+	return Image{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}, nil
+}
+
+//toRGBA copies img into an RGBA image whose bounds start at the origin.
+//Images that already are one are returned as they are.
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	if rgba, ok := img.(*image.RGBA); ok && b.Min == (image.Point{}) {
+		return rgba
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+//thumbnail scales img down to fit within a size by size square, averaging
+//the pixels each thumbnail pixel covers. Images that already fit are
+//returned as they are.
+func thumbnail(img *image.RGBA, size int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	tw, th := size, size
+	if w > h {
+		th = max(1, h*size/w)
+	} else {
+		tw = max(1, w*size/h)
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for ty := 0; ty < th; ty++ {
+		y0, y1 := ty*h/th, (ty+1)*h/th
+		for tx := 0; tx < tw; tx++ {
+			x0, x1 := tx*w/tw, (tx+1)*w/tw
+
+			var sum [4]int
+			for y := y0; y < y1; y++ {
+				row := img.Pix[y*img.Stride+x0*4 : y*img.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+
+			n := (x1 - x0) * (y1 - y0)
+			o := ty*thumb.Stride + tx*4
+			for c := 0; c < 4; c++ {
+				thumb.Pix[o+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+
+	return thumb
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,13 @@
+package attachment_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAttachment(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Attachment Suite")
+}
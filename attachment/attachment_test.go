@@ -0,0 +1,167 @@
+package attachment_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/smartatransit/feedback/attachment"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//testImage returns a w by h image whose left half is red and right half blue
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(img image.Image) []byte {
+	var buf bytes.Buffer
+	Expect(jpeg.Encode(&buf, img, nil)).To(Succeed())
+	return buf.Bytes()
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	Expect(png.Encode(&buf, img)).To(Succeed())
+	return buf.Bytes()
+}
+
+//withExif inserts an APP1 segment after the start of a JPEG, holding an
+//orientation tag and a GPS marker standing in for location data
+func withExif(data []byte, orientation byte) []byte {
+	tiff := []byte{
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08,
+		0x00, 0x01,
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, orientation, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	payload = append(payload, []byte("GPS 33.7490N 84.3880W")...)
+
+	length := len(payload) + 2
+	segment := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, payload...)
+
+	result := append([]byte{}, data[:2]...)
+	result = append(result, segment...)
+	return append(result, data[2:]...)
+}
+
+var _ = Describe("Process", func() {
+	var (
+		data   []byte
+		limits attachment.Limits
+
+		result  attachment.Processed
+		callErr error
+	)
+
+	BeforeEach(func() {
+		data = encodeJPEG(testImage(800, 400))
+		limits = attachment.DefaultLimits
+	})
+
+	JustBeforeEach(func() {
+		result, callErr = attachment.Process(data, limits)
+	})
+
+	It("re-encodes the image and makes a thumbnail", func() {
+		Expect(callErr).To(BeNil())
+		Expect(result.Image.ContentType).To(Equal(attachment.JPEG))
+		Expect(result.Image.Width).To(Equal(800))
+		Expect(result.Image.Height).To(Equal(400))
+		Expect(result.Thumbnail.Width).To(Equal(320))
+		Expect(result.Thumbnail.Height).To(Equal(160))
+		Expect(result.SHA256).To(HaveLen(64))
+
+		thumb, err := jpeg.Decode(bytes.NewReader(result.Thumbnail.Data))
+		Expect(err).To(BeNil())
+		r, _, b, _ := thumb.At(10, 80).RGBA()
+		Expect(r >> 8).To(BeNumerically(">", 200))
+		Expect(b >> 8).To(BeNumerically("<", 50))
+	})
+
+	When("the image has EXIF metadata", func() {
+		BeforeEach(func() {
+			data = withExif(data, 6)
+		})
+		It("turns the image upright and strips the metadata", func() {
+			Expect(callErr).To(BeNil())
+			Expect(result.Image.Width).To(Equal(400))
+			Expect(result.Image.Height).To(Equal(800))
+			Expect(result.Thumbnail.Width).To(Equal(160))
+			Expect(result.Thumbnail.Height).To(Equal(320))
+
+			Expect(bytes.Contains(result.Image.Data, []byte("Exif"))).To(BeFalse())
+			Expect(bytes.Contains(result.Image.Data, []byte("GPS"))).To(BeFalse())
+			Expect(bytes.Contains(result.Thumbnail.Data, []byte("GPS"))).To(BeFalse())
+
+			//rotating clockwise puts the red left half on top
+			img, err := jpeg.Decode(bytes.NewReader(result.Image.Data))
+			Expect(err).To(BeNil())
+			r, _, b, _ := img.At(200, 100).RGBA()
+			Expect(r >> 8).To(BeNumerically(">", 200))
+			Expect(b >> 8).To(BeNumerically("<", 50))
+		})
+	})
+	When("the image is a PNG", func() {
+		BeforeEach(func() {
+			data = encodePNG(testImage(100, 50))
+		})
+		It("keeps it a PNG, without scaling small images", func() {
+			Expect(callErr).To(BeNil())
+			Expect(result.Image.ContentType).To(Equal(attachment.PNG))
+			Expect(result.Thumbnail.ContentType).To(Equal(attachment.PNG))
+			Expect(result.Thumbnail.Width).To(Equal(100))
+		})
+	})
+	When("the upload isn't an image", func() {
+		BeforeEach(func() {
+			data = []byte("<html><script>alert(1)</script></html>")
+		})
+		It("rejects it", func() {
+			Expect(callErr).To(Equal(attachment.Rejection{
+				Reason:  "unsupported_attachment_type",
+				Message: "attachments must be JPEG or PNG images, not text/html; charset=utf-8",
+			}))
+		})
+	})
+	When("the image is truncated", func() {
+		BeforeEach(func() {
+			data = data[:len(data)/2]
+		})
+		It("rejects it", func() {
+			Expect(callErr).To(MatchError("the attachment couldn't be read as an image"))
+		})
+	})
+	When("the image has too many pixels", func() {
+		BeforeEach(func() {
+			limits.MaxPixels = 1000
+		})
+		It("rejects it without decoding it", func() {
+			Expect(callErr).To(BeAssignableToTypeOf(attachment.Rejection{}))
+			Expect(callErr.(attachment.Rejection).Reason).To(Equal("attachment_too_large"))
+		})
+	})
+	When("the upload has too many bytes", func() {
+		BeforeEach(func() {
+			limits.MaxBytes = 100
+		})
+		It("rejects it", func() {
+			Expect(callErr).To(MatchError("attachments may be at most 100 bytes"))
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package attachment
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/blob"
+	"github.com/smartatransit/feedback/db"
+)
+
+//Sweeper deletes attachments that are no longer needed: those whose
+//feedback was deleted or anonymized, and uploads never submitted with a
+//feedback. The images are deleted from the blob store before the metadata,
+//so an interrupted sweep is finished by the next one.
+type Sweeper struct {
+	db        db.DB
+	store     blob.Store
+	ttl       time.Duration
+	batchSize int
+	now       func() time.Time
+}
+
+//NewSweeper returns a Sweeper that deletes uploads left unattached for
+//longer than ttl, batchSize attachments at a time
+func NewSweeper(
+	database db.DB,
+	store blob.Store,
+	ttl time.Duration,
+	batchSize int,
+	now func() time.Time,
+) Sweeper {
+	return Sweeper{
+		db:        database,
+		store:     store,
+		ttl:       ttl,
+		batchSize: batchSize,
+		now:       now,
+	}
+}
+
+//Sweep deletes attachments until none are left to delete, returning how
+//many were deleted
+func (s Sweeper) Sweep(ctx context.Context) (int, error) {
+	deleted := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+
+		attachments, err := s.db.ListDiscardedAttachments(ctx, s.now().Add(-s.ttl), s.batchSize)
+		if err != nil {
+			return deleted, err
+		}
+		if len(attachments) == 0 {
+			return deleted, nil
+		}
+
+		for _, a := range attachments {
+			if err := s.store.Delete(ctx, a.StorageKey); err != nil {
+				return deleted, err
+			}
+			if err := s.store.Delete(ctx, a.ThumbnailKey); err != nil {
+				return deleted, err
+			}
+			if err := s.db.DeleteAttachment(ctx, a.ID); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+}
+
+//Run sweeps every interval until ctx is done
+func (s Sweeper) Run(ctx context.Context, log *logrus.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.Sweep(ctx)
+		if deleted > 0 {
+			log.WithField("deleted", deleted).Info("deleted discarded attachments")
+		}
+		if err != nil {
+			log.Errorf("failed deleting discarded attachments: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package attachment_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/smartatransit/feedback/attachment"
+	"github.com/smartatransit/feedback/blob/blobfakes"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sweeper", func() {
+	var (
+		db    *dbfakes.FakeDB
+		store *blobfakes.FakeStore
+		now   time.Time
+
+		deleted int
+		callErr error
+	)
+
+	BeforeEach(func() {
+		db = &dbfakes.FakeDB{}
+		store = &blobfakes.FakeStore{}
+		now = time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+
+		db.ListDiscardedAttachmentsReturnsOnCall(0, []dbp.Attachment{
+			{ID: "a1", StorageKey: "k1", ThumbnailKey: "k1_thumb"},
+			{ID: "a2", StorageKey: "k2", ThumbnailKey: "k2_thumb"},
+		}, nil)
+		db.ListDiscardedAttachmentsReturnsOnCall(1, []dbp.Attachment{}, nil)
+	})
+
+	JustBeforeEach(func() {
+		sweeper := attachment.NewSweeper(db, store, 24*time.Hour, 2, func() time.Time { return now })
+		deleted, callErr = sweeper.Sweep(context.Background())
+	})
+
+	It("deletes the images before the metadata", func() {
+		Expect(callErr).To(BeNil())
+		Expect(deleted).To(Equal(2))
+
+		_, before, limit := db.ListDiscardedAttachmentsArgsForCall(0)
+		Expect(before).To(Equal(now.Add(-24 * time.Hour)))
+		Expect(limit).To(Equal(2))
+
+		Expect(store.DeleteCallCount()).To(Equal(4))
+		_, key := store.DeleteArgsForCall(1)
+		Expect(key).To(Equal("k1_thumb"))
+
+		_, id := db.DeleteAttachmentArgsForCall(1)
+		Expect(id).To(Equal("a2"))
+	})
+
+	When("an image can't be deleted", func() {
+		BeforeEach(func() {
+			store.DeleteReturnsOnCall(2, errors.New("permission denied"))
+		})
+		It("keeps its metadata for the next sweep", func() {
+			Expect(callErr).To(MatchError("permission denied"))
+			Expect(deleted).To(Equal(1))
+			Expect(db.DeleteAttachmentCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,265 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+//GenesisHash is the previous hash of the first event in the chain
+var GenesisHash = strings.Repeat("0", 64)
+
+//maxAppendAttempts bounds how often Record retries when concurrent writers
+//race for the next sequence number
+const maxAppendAttempts = 10
+
+//Entry describes an administrative action to record. Before and After are
+//marshalled to JSON and may be nil.
+type Entry struct {
+	ActorSession string
+	ActorRole    string
+	Action       string
+	TargetType   string
+	TargetIDs    []string
+	Before       interface{}
+	After        interface{}
+	RequestID    string
+}
+
+//Recorder records administrative actions
+//go:generate counterfeiter . Recorder
+type Recorder interface {
+	Record(ctx context.Context, entry Entry) error
+}
+
+//Log appends events to the hash-chained audit_events table
+type Log struct {
+	db  db.DB
+	now func() time.Time
+}
+
+//New returns a Log
+func New(
+	database db.DB,
+	now func() time.Time,
+) Log {
+	return Log{
+		db:  database,
+		now: now,
+	}
+}
+
+//Record appends entry to the chain. If another replica appends first, it
+//re-reads the head of the chain and tries again.
+func (l Log) Record(ctx context.Context, entry Entry) error {
+	before, err := marshal(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshal(entry.After)
+	if err != nil {
+		return err
+	}
+
+	targetIDs := entry.TargetIDs
+	if targetIDs == nil {
+		targetIDs = []string{}
+	}
+
+	event := db.AuditEvent{
+		ActorSession: entry.ActorSession,
+		ActorRole:    entry.ActorRole,
+		Action:       entry.Action,
+		TargetType:   entry.TargetType,
+		TargetIDs:    targetIDs,
+		Before:       before,
+		After:        after,
+		RequestID:    entry.RequestID,
+	}
+
+	for attempt := 0; attempt < maxAppendAttempts; attempt++ {
+		seq, hash, err := l.db.GetLastAuditEvent(ctx)
+		if err != nil {
+			return err
+		}
+		if seq == 0 {
+			hash = GenesisHash
+		}
+
+		event.Seq = seq + 1
+		event.PrevHash = hash
+		event.OccurredAt = l.now().UTC().Truncate(time.Microsecond)
+		if event.Hash, err = Hash(event); err != nil {
+			return err
+		}
+
+		appended, err := l.db.AppendAuditEvent(ctx, event)
+		if err != nil {
+			return err
+		}
+		if appended {
+			return nil
+		}
+	}
+
+	return errors.New("failed appending audit event: too many concurrent writers")
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed encoding audit event value: %w", err)
+	}
+	return b, nil
+}
+
+//hashedEvent is the form of an event that is hashed. Its field order is
+//fixed, so the same event always hashes the same.
+type hashedEvent struct {
+	Seq          int64           `json:"seq"`
+	OccurredAt   string          `json:"occurred_at"`
+	ActorSession string          `json:"actor_session"`
+	ActorRole    string          `json:"actor_role"`
+	Action       string          `json:"action"`
+	TargetType   string          `json:"target_type"`
+	TargetIDs    []string        `json:"target_ids"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+	RequestID    string          `json:"request_id"`
+	PrevHash     string          `json:"prev_hash"`
+}
+
+//Hash returns the hex SHA-256 of every field of event except Hash itself.
+//Before and After are canonicalized first, since Postgres doesn't keep JSON
+//as it was written.
+func Hash(event db.AuditEvent) (string, error) {
+	before, err := canonicalJSON(event.Before)
+	if err != nil {
+		return "", err
+	}
+	after, err := canonicalJSON(event.After)
+	if err != nil {
+		return "", err
+	}
+
+	targetIDs := event.TargetIDs
+	if targetIDs == nil {
+		targetIDs = []string{}
+	}
+
+	b, err := json.Marshal(hashedEvent{
+		Seq:          event.Seq,
+		OccurredAt:   event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		ActorSession: event.ActorSession,
+		ActorRole:    event.ActorRole,
+		Action:       event.Action,
+		TargetType:   event.TargetType,
+		TargetIDs:    targetIDs,
+		Before:       before,
+		After:        after,
+		RequestID:    event.RequestID,
+		PrevHash:     event.PrevHash,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed encoding audit event: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+//canonicalJSON re-encodes b with sorted keys and no insignificant
+//whitespace, keeping numbers as written
+func canonicalJSON(b []byte) (json.RawMessage, error) {
+	if b == nil {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed decoding audit event value: %w", err)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed encoding audit event value: %w", err)
+	}
+	return out, nil
+}
+
+//Problem is a break in the audit chain
+type Problem struct {
+	Seq     int64  `json:"seq"`
+	Problem string `json:"problem"`
+}
+
+//Report summarizes a verification of the audit chain. HeadSeq and HeadHash
+//identify the newest event; keeping a copy of them elsewhere allows
+//detecting events removed from the end of the chain.
+type Report struct {
+	Events   int       `json:"events"`
+	HeadSeq  int64     `json:"head_seq"`
+	HeadHash string    `json:"head_hash"`
+	Problems []Problem `json:"problems"`
+}
+
+//Verify walks the whole chain, batchSize events at a time, checking that
+//sequence numbers are contiguous, that each event names the hash of the one
+//before it, and that each event's contents still match its hash
+func (l Log) Verify(ctx context.Context, batchSize int) (Report, error) {
+	report := Report{HeadHash: GenesisHash, Problems: []Problem{}}
+
+	for {
+		events, err := l.db.ListAuditEventsAfter(ctx, report.HeadSeq, batchSize)
+		if err != nil {
+			return report, err
+		}
+
+		for _, ev := range events {
+			if ev.Seq != report.HeadSeq+1 {
+				report.Problems = append(report.Problems, Problem{
+					Seq:     ev.Seq,
+					Problem: fmt.Sprintf("%d events are missing before this one", ev.Seq-report.HeadSeq-1),
+				})
+			}
+			if ev.PrevHash != report.HeadHash {
+				report.Problems = append(report.Problems, Problem{
+					Seq:     ev.Seq,
+					Problem: "previous hash doesn't match the preceding event",
+				})
+			}
+
+			hash, err := Hash(ev)
+			if err != nil {
+				return report, err
+			}
+			if hash != ev.Hash {
+				report.Problems = append(report.Problems, Problem{
+					Seq:     ev.Seq,
+					Problem: "contents don't match the event's hash",
+				})
+			}
+
+			report.Events++
+			report.HeadSeq = ev.Seq
+			report.HeadHash = ev.Hash
+		}
+
+		if len(events) < batchSize {
+			return report, nil
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Log", func() {
+	var (
+		db  *dbfakes.FakeDB
+		log audit.Log
+		now = time.Date(2020, 3, 14, 12, 0, 0, 123456789, time.UTC)
+	)
+
+	BeforeEach(func() {
+		db = &dbfakes.FakeDB{}
+		log = audit.New(db, func() time.Time { return now })
+	})
+
+	Describe("Record", func() {
+		var (
+			entry   audit.Entry
+			callErr error
+		)
+
+		BeforeEach(func() {
+			entry = audit.Entry{
+				ActorSession: "admin-session",
+				ActorRole:    "admin",
+				Action:       "feedback.moderate",
+				TargetType:   "feedback",
+				TargetIDs:    []string{"fb"},
+				Before:       map[string]string{"moderation_status": "pending"},
+				After:        map[string]string{"moderation_status": "approved"},
+				RequestID:    "req",
+			}
+			db.AppendAuditEventReturns(true, nil)
+		})
+
+		JustBeforeEach(func() {
+			callErr = log.Record(context.Background(), entry)
+		})
+
+		When("the chain is empty", func() {
+			It("starts it from the genesis hash", func() {
+				Expect(callErr).To(BeNil())
+
+				_, ev := db.AppendAuditEventArgsForCall(0)
+				Expect(ev.Seq).To(BeEquivalentTo(1))
+				Expect(ev.PrevHash).To(Equal(audit.GenesisHash))
+				Expect(ev.OccurredAt).To(Equal(now.Truncate(time.Microsecond)))
+				Expect(string(ev.Before)).To(Equal(`{"moderation_status":"pending"}`))
+
+				hash, err := audit.Hash(ev)
+				Expect(err).To(BeNil())
+				Expect(ev.Hash).To(Equal(hash))
+				Expect(ev.Hash).To(HaveLen(64))
+			})
+		})
+		When("another writer appends first", func() {
+			BeforeEach(func() {
+				db.GetLastAuditEventReturnsOnCall(0, 4, "h4", nil)
+				db.GetLastAuditEventReturnsOnCall(1, 5, "h5", nil)
+				db.AppendAuditEventReturnsOnCall(0, false, nil)
+				db.AppendAuditEventReturnsOnCall(1, true, nil)
+			})
+			It("retries after the new head", func() {
+				Expect(callErr).To(BeNil())
+				Expect(db.AppendAuditEventCallCount()).To(Equal(2))
+
+				_, ev := db.AppendAuditEventArgsForCall(1)
+				Expect(ev.Seq).To(BeEquivalentTo(6))
+				Expect(ev.PrevHash).To(Equal("h5"))
+			})
+		})
+		When("appending keeps conflicting", func() {
+			BeforeEach(func() {
+				db.AppendAuditEventReturns(false, nil)
+			})
+			It("gives up", func() {
+				Expect(callErr).To(HaveOccurred())
+				Expect(db.AppendAuditEventCallCount()).To(Equal(10))
+			})
+		})
+		When("appending fails", func() {
+			BeforeEach(func() {
+				db.AppendAuditEventReturns(false, errors.New("insert failed"))
+			})
+			It("returns the error", func() {
+				Expect(callErr).To(MatchError("insert failed"))
+			})
+		})
+	})
+
+	Describe("Hash", func() {
+		It("ignores how JSON values are formatted", func() {
+			a := dbp.AuditEvent{Seq: 1, After: []byte(`{"b":1,"a":"x"}`)}
+			b := dbp.AuditEvent{Seq: 1, After: []byte(`{"a": "x", "b": 1}`)}
+
+			hashA, err := audit.Hash(a)
+			Expect(err).To(BeNil())
+			hashB, err := audit.Hash(b)
+			Expect(err).To(BeNil())
+			Expect(hashA).To(Equal(hashB))
+		})
+		It("treats missing and empty target IDs alike", func() {
+			hashA, _ := audit.Hash(dbp.AuditEvent{Seq: 1})
+			hashB, _ := audit.Hash(dbp.AuditEvent{Seq: 1, TargetIDs: []string{}})
+			Expect(hashA).To(Equal(hashB))
+		})
+	})
+
+	Describe("Verify", func() {
+		var (
+			events []dbp.AuditEvent
+			report audit.Report
+		)
+
+		chain := func(n int) []dbp.AuditEvent {
+			var evs []dbp.AuditEvent
+			prev := audit.GenesisHash
+			for i := 1; i <= n; i++ {
+				ev := dbp.AuditEvent{
+					Seq:        int64(i),
+					OccurredAt: now,
+					Action:     "retention_policy.set",
+					TargetIDs:  []string{"outage"},
+					After:      []byte(`{"max_age_days":90}`),
+					PrevHash:   prev,
+				}
+				ev.Hash, _ = audit.Hash(ev)
+				prev = ev.Hash
+				evs = append(evs, ev)
+			}
+			return evs
+		}
+
+		BeforeEach(func() {
+			events = chain(5)
+		})
+
+		JustBeforeEach(func() {
+			db.ListAuditEventsAfterStub = func(_ context.Context, seq int64, limit int) ([]dbp.AuditEvent, error) {
+				var page []dbp.AuditEvent
+				for _, ev := range events {
+					if ev.Seq > seq && len(page) < limit {
+						page = append(page, ev)
+					}
+				}
+				return page, nil
+			}
+
+			var err error
+			report, err = log.Verify(context.Background(), 2)
+			Expect(err).To(BeNil())
+		})
+
+		It("accepts an intact chain", func() {
+			Expect(report.Problems).To(BeEmpty())
+			Expect(report.Events).To(Equal(5))
+			Expect(report.HeadSeq).To(BeEquivalentTo(5))
+			Expect(report.HeadHash).To(Equal(events[4].Hash))
+		})
+
+		When("an event was altered", func() {
+			BeforeEach(func() {
+				events[2].After = []byte(`{"max_age_days":9000}`)
+			})
+			It("reports it", func() {
+				Expect(report.Problems).To(Equal([]audit.Problem{
+					{Seq: 3, Problem: "contents don't match the event's hash"},
+				}))
+			})
+		})
+		When("an event was altered and rehashed", func() {
+			BeforeEach(func() {
+				events[2].After = []byte(`{"max_age_days":9000}`)
+				events[2].Hash, _ = audit.Hash(events[2])
+			})
+			It("reports the break in the chain", func() {
+				Expect(report.Problems).To(Equal([]audit.Problem{
+					{Seq: 4, Problem: "previous hash doesn't match the preceding event"},
+				}))
+			})
+		})
+		When("an event was removed", func() {
+			BeforeEach(func() {
+				events = append(events[:1], events[2:]...)
+			})
+			It("reports the gap", func() {
+				Expect(report.Problems).To(Equal([]audit.Problem{
+					{Seq: 3, Problem: "1 events are missing before this one"},
+					{Seq: 3, Problem: "previous hash doesn't match the preceding event"},
+				}))
+			})
+		})
+	})
+})
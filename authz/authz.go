@@ -0,0 +1,140 @@
+package authz
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//Permission is an operation a role may be granted
+type Permission string
+
+//Permissions
+const (
+	Submit   Permission = "submit"
+	Read     Permission = "read"
+	Moderate Permission = "moderate"
+	Silence  Permission = "silence"
+	Export   Permission = "export"
+	Delete   Permission = "delete"
+	Surveys  Permission = "surveys"
+	Reply    Permission = "reply"
+	Triage   Permission = "triage"
+	Unredact Permission = "unredact"
+)
+
+//Permissions lists every known permission
+var Permissions = []Permission{Submit, Read, Moderate, Silence, Export, Delete, Surveys, Reply, Triage, Unredact}
+
+//Wildcard stands for every role, or every permission, in a policy
+const Wildcard = "*"
+
+//Policy maps X-Smarta-Auth-Role values to the permissions they hold
+type Policy struct {
+	roles map[string]map[Permission]struct{}
+}
+
+//NewPolicy returns a Policy granting nothing
+func NewPolicy() Policy {
+	return Policy{roles: map[string]map[Permission]struct{}{}}
+}
+
+//Grant gives role each of permissions. role may be Wildcard to grant them
+//to every role.
+func (p Policy) Grant(role string, permissions ...Permission) Policy {
+	if p.roles == nil {
+		p.roles = map[string]map[Permission]struct{}{}
+	}
+	if p.roles[role] == nil {
+		p.roles[role] = map[Permission]struct{}{}
+	}
+	for _, permission := range permissions {
+		p.roles[role][permission] = struct{}{}
+	}
+	return p
+}
+
+//Allows reports whether role holds permission, either itself or through a
+//Wildcard grant
+func (p Policy) Allows(role string, permission Permission) bool {
+	if _, ok := p.roles[role][permission]; ok {
+		return true
+	}
+	_, ok := p.roles[Wildcard][permission]
+	return ok
+}
+
+//ReadPolicy reads a policy of one `role: permission, permission` line per
+//role, e.g.
+//
+//  *: submit
+//  moderator: read, moderate, silence
+//  admin: *
+//
+//where a role of `*` applies to every role and a permission of `*` grants
+//them all. Blank lines and lines starting with `#` are ignored.
+func ReadPolicy(r io.Reader) (Policy, error) {
+	policy := NewPolicy()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			return Policy{}, fmt.Errorf("malformed policy line `%s`: expected `role: permissions`", line)
+		}
+
+		role := strings.TrimSpace(line[:colon])
+		if role == "" {
+			return Policy{}, fmt.Errorf("malformed policy line `%s`: missing role", line)
+		}
+		if _, ok := policy.roles[role]; ok {
+			return Policy{}, fmt.Errorf("duplicate role `%s` in policy", role)
+		}
+
+		permissions, err := parsePermissions(line[colon+1:])
+		if err != nil {
+			return Policy{}, err
+		}
+		policy = policy.Grant(role, permissions...)
+	}
+	if err := scanner.Err(); err != nil {
+		return Policy{}, fmt.Errorf("failed reading policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+func parsePermissions(s string) ([]Permission, error) {
+	var permissions []Permission
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == Wildcard {
+			permissions = append(permissions, Permissions...)
+			continue
+		}
+
+		permission := Permission(name)
+		if !isKnown(permission) {
+			return nil, fmt.Errorf("unknown permission `%s`", name)
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
+
+func isKnown(permission Permission) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
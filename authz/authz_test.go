@@ -0,0 +1,85 @@
+package authz_test
+
+import (
+	"strings"
+
+	"github.com/smartatransit/feedback/authz"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReadPolicy", func() {
+	var (
+		input string
+
+		policy authz.Policy
+		err    error
+	)
+
+	BeforeEach(func() {
+		input = `
+# everyone may submit
+*: submit
+moderator: read, moderate, silence
+
+admin: *
+`
+	})
+
+	JustBeforeEach(func() {
+		policy, err = authz.ReadPolicy(strings.NewReader(input))
+	})
+
+	It("grants the listed permissions", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Allows("moderator", authz.Moderate)).To(BeTrue())
+		Expect(policy.Allows("moderator", authz.Delete)).To(BeFalse())
+	})
+
+	It("grants wildcard role permissions to every role", func() {
+		Expect(policy.Allows("anonymous", authz.Submit)).To(BeTrue())
+		Expect(policy.Allows("anonymous", authz.Read)).To(BeFalse())
+		Expect(policy.Allows("moderator", authz.Submit)).To(BeTrue())
+	})
+
+	It("expands wildcard permissions", func() {
+		for _, p := range authz.Permissions {
+			Expect(policy.Allows("admin", p)).To(BeTrue())
+		}
+	})
+
+	When("a permission is unknown", func() {
+		BeforeEach(func() {
+			input = "admin: read, destroy"
+		})
+		It("fails", func() {
+			Expect(err).To(MatchError("unknown permission `destroy`"))
+		})
+	})
+
+	When("a role is listed twice", func() {
+		BeforeEach(func() {
+			input = "admin: read\nadmin: delete"
+		})
+		It("fails", func() {
+			Expect(err).To(MatchError("duplicate role `admin` in policy"))
+		})
+	})
+
+	When("a line has no colon", func() {
+		BeforeEach(func() {
+			input = "admin read"
+		})
+		It("fails", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Policy", func() {
+	It("grants nothing by default", func() {
+		Expect(authz.NewPolicy().Allows("admin", authz.Read)).To(BeFalse())
+		Expect(authz.Policy{}.Allows("admin", authz.Read)).To(BeFalse())
+	})
+})
@@ -0,0 +1,123 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+//ErrNotFound is returned when reading a blob that doesn't exist
+var ErrNotFound = errors.New("blob not found")
+
+//Store keeps the contents of attachments. Keys are chosen by the caller and
+//must match KeyRegexp.
+//go:generate counterfeiter . Store
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+//KeyRegexp matches valid keys. Keys can't contain path separators or dots,
+//so they can be used as file names.
+var KeyRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{2,127}$`)
+
+func checkKey(key string) error {
+	if !KeyRegexp.MatchString(key) {
+		return fmt.Errorf("invalid blob key `%s`", key)
+	}
+	return nil
+}
+
+//FileStore is a Store keeping each blob in a file under a directory. Files
+//are spread over subdirectories named after the first two characters of
+//their key, so that no single directory grows too large.
+type FileStore struct {
+	dir string
+}
+
+//NewFileStore returns a FileStore rooted at dir, creating it if needed
+func NewFileStore(dir string) (FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return FileStore{}, fmt.Errorf("failed creating blob directory: %w", err)
+	}
+	return FileStore{dir: dir}, nil
+}
+
+func (s FileStore) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key)
+}
+
+//Put writes data under key, replacing any existing blob. The data is
+//written to a temporary file first, so readers never see a partial blob.
+func (s FileStore) Put(ctx context.Context, key string, data []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed creating blob directory: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+key+"-*")
+	if err != nil {
+		return fmt.Errorf("failed creating blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed writing blob: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed writing blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed writing blob: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed writing blob: %w", err)
+	}
+
+	return nil
+}
+
+//Open returns a reader for the blob under key, or ErrNotFound
+func (s FileStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed opening blob: %w", err)
+	}
+
+	return f, nil
+}
+
+//Delete removes the blob under key. Deleting a blob that doesn't exist
+//succeeds, so that interrupted cleanups can be retried.
+func (s FileStore) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed deleting blob: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package blob_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/smartatransit/feedback/blob"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileStore", func() {
+	var (
+		dir   string
+		store blob.FileStore
+		ctx   = context.Background()
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "blob")
+		Expect(err).To(BeNil())
+
+		store, err = blob.NewFileStore(filepath.Join(dir, "attachments"))
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("reads back what was put", func() {
+		Expect(store.Put(ctx, "a1b2c3", []byte("image"))).To(Succeed())
+
+		r, err := store.Open(ctx, "a1b2c3")
+		Expect(err).To(BeNil())
+		defer r.Close()
+		Expect(ioutil.ReadAll(r)).To(Equal([]byte("image")))
+
+		_, err = os.Stat(filepath.Join(dir, "attachments", "a1", "a1b2c3"))
+		Expect(err).To(BeNil())
+	})
+
+	It("replaces existing blobs", func() {
+		Expect(store.Put(ctx, "a1b2c3", []byte("old"))).To(Succeed())
+		Expect(store.Put(ctx, "a1b2c3", []byte("new"))).To(Succeed())
+
+		r, err := store.Open(ctx, "a1b2c3")
+		Expect(err).To(BeNil())
+		defer r.Close()
+		Expect(ioutil.ReadAll(r)).To(Equal([]byte("new")))
+
+		files, err := ioutil.ReadDir(filepath.Join(dir, "attachments", "a1"))
+		Expect(err).To(BeNil())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("deletes blobs, even twice", func() {
+		Expect(store.Put(ctx, "a1b2c3", []byte("image"))).To(Succeed())
+		Expect(store.Delete(ctx, "a1b2c3")).To(Succeed())
+		Expect(store.Delete(ctx, "a1b2c3")).To(Succeed())
+
+		_, err := store.Open(ctx, "a1b2c3")
+		Expect(err).To(Equal(blob.ErrNotFound))
+	})
+
+	It("rejects keys that could escape the directory", func() {
+		Expect(store.Put(ctx, "../../etc", []byte("image"))).To(MatchError("invalid blob key `../../etc`"))
+		_, err := store.Open(ctx, "a1/../../b2")
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,161 @@
+package clientinfo
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+//Fields of client metadata
+const (
+	AppVersion = "app_version"
+	Platform   = "platform"
+	OSVersion  = "os_version"
+	Locale     = "locale"
+)
+
+//Fields lists every field, in the order breakdowns report them
+var Fields = []string{AppVersion, Platform, OSVersion, Locale}
+
+//IsField reports whether name is one of Fields
+func IsField(name string) bool {
+	for _, field := range Fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+//DefaultAllowlist reads the headers sent by the SMARTA apps, and the locale
+//browsers send
+const DefaultAllowlist = "app_version=X-App-Version,platform=X-App-Platform,os_version=X-OS-Version,locale=Accept-Language"
+
+//Allowlist maps fields to the request header each is read from. No other
+//headers are read.
+type Allowlist map[string]string
+
+//ParseAllowlist parses a comma-separated list of `field=Header-Name`
+//entries, e.g. DefaultAllowlist. An empty spec reads no headers.
+func ParseAllowlist(spec string) (Allowlist, error) {
+	allowlist := Allowlist{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid client header `%s`: expected `field=Header-Name`", entry)
+		}
+
+		field := strings.TrimSpace(parts[0])
+		if !IsField(field) {
+			return nil, fmt.Errorf("invalid client header `%s`: unknown field `%s`", entry, field)
+		}
+		if _, ok := allowlist[field]; ok {
+			return nil, fmt.Errorf("invalid client header `%s`: `%s` is listed more than once", entry, field)
+		}
+		allowlist[field] = http.CanonicalHeaderKey(strings.TrimSpace(parts[1]))
+	}
+
+	return allowlist, nil
+}
+
+//FromHeaders reads the allowlisted headers of a request. Values that aren't
+//valid are left out, since any client can send any header.
+func (a Allowlist) FromHeaders(h http.Header) db.ClientInfo {
+	var info db.ClientInfo
+	for field, header := range a {
+		if value, err := Normalize(field, h.Get(header)); err == nil && value != "" {
+			Set(&info, field, value)
+		}
+	}
+	return info
+}
+
+var (
+	versionRegexp  = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z._+-]{0,31}$`)
+	platformRegexp = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,15}$`)
+	localeRegexp   = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8}){0,3}$`)
+)
+
+//Normalize checks a value of field and returns its canonical form: platforms
+//in lower case, and locales as BCP 47 tags with a lower case language and
+//upper case region. A locale may be given as an Accept-Language list, of
+//which the first tag is kept. Empty values are returned as they are.
+func Normalize(field, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	switch field {
+	case AppVersion, OSVersion:
+		if versionRegexp.MatchString(value) {
+			return value, nil
+		}
+	case Platform:
+		value = strings.ToLower(value)
+		if platformRegexp.MatchString(value) {
+			return value, nil
+		}
+	case Locale:
+		tag := strings.TrimSpace(strings.SplitN(strings.SplitN(value, ",", 2)[0], ";", 2)[0])
+		tag = strings.Replace(tag, "_", "-", -1)
+		if localeRegexp.MatchString(tag) {
+			return canonicalLocale(tag), nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid value `%s` for `%s`", value, field)
+}
+
+func canonicalLocale(tag string) string {
+	subtags := strings.Split(tag, "-")
+	subtags[0] = strings.ToLower(subtags[0])
+	for i := 1; i < len(subtags); i++ {
+		switch len(subtags[i]) {
+		case 2:
+			subtags[i] = strings.ToUpper(subtags[i])
+		case 4:
+			subtags[i] = strings.ToUpper(subtags[i][:1]) + strings.ToLower(subtags[i][1:])
+		default:
+			subtags[i] = strings.ToLower(subtags[i])
+		}
+	}
+	return strings.Join(subtags, "-")
+}
+
+//Get returns the value of field in info, if set
+func Get(info db.ClientInfo, field string) *string {
+	switch field {
+	case AppVersion:
+		return info.AppVersion
+	case Platform:
+		return info.Platform
+	case OSVersion:
+		return info.OSVersion
+	case Locale:
+		return info.Locale
+	}
+	return nil
+}
+
+//Set sets field in info to value
+func Set(info *db.ClientInfo, field, value string) {
+	switch field {
+	case AppVersion:
+		info.AppVersion = &value
+	case Platform:
+		info.Platform = &value
+	case OSVersion:
+		info.OSVersion = &value
+	case Locale:
+		info.Locale = &value
+	}
+}
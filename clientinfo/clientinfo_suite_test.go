@@ -0,0 +1,13 @@
+package clientinfo_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestClientinfo(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Clientinfo Suite")
+}
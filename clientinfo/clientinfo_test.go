@@ -0,0 +1,92 @@
+package clientinfo_test
+
+import (
+	"net/http"
+
+	"github.com/smartatransit/feedback/clientinfo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+var _ = Describe("Clientinfo", func() {
+	Describe("ParseAllowlist", func() {
+		It("parses the default", func() {
+			allowlist, err := clientinfo.ParseAllowlist(clientinfo.DefaultAllowlist)
+			Expect(err).To(BeNil())
+			Expect(allowlist).To(Equal(clientinfo.Allowlist{
+				"app_version": "X-App-Version",
+				"platform":    "X-App-Platform",
+				"os_version":  "X-Os-Version",
+				"locale":      "Accept-Language",
+			}))
+		})
+
+		It("reads no headers when empty", func() {
+			allowlist, err := clientinfo.ParseAllowlist("")
+			Expect(err).To(BeNil())
+			Expect(allowlist).To(BeEmpty())
+		})
+
+		It("rejects unknown fields", func() {
+			_, err := clientinfo.ParseAllowlist("device_model=X-Device-Model")
+			Expect(err).To(MatchError("invalid client header `device_model=X-Device-Model`: unknown field `device_model`"))
+		})
+
+		It("rejects entries without a header", func() {
+			_, err := clientinfo.ParseAllowlist("platform")
+			Expect(err).To(MatchError("invalid client header `platform`: expected `field=Header-Name`"))
+		})
+
+		It("rejects fields listed twice", func() {
+			_, err := clientinfo.ParseAllowlist("platform=X-Platform,platform=X-App-Platform")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Normalize", func() {
+		It("keeps valid versions as they are", func() {
+			Expect(clientinfo.Normalize(clientinfo.AppVersion, " 4.2.0+1093 ")).To(Equal("4.2.0+1093"))
+			Expect(clientinfo.Normalize(clientinfo.OSVersion, "17.1")).To(Equal("17.1"))
+		})
+
+		It("lowercases platforms", func() {
+			Expect(clientinfo.Normalize(clientinfo.Platform, "Android")).To(Equal("android"))
+		})
+
+		It("keeps the first tag of a locale list in canonical case", func() {
+			Expect(clientinfo.Normalize(clientinfo.Locale, "es_us")).To(Equal("es-US"))
+			Expect(clientinfo.Normalize(clientinfo.Locale, "ZH-hant-tw, en;q=0.8")).To(Equal("zh-Hant-TW"))
+		})
+
+		It("rejects invalid values", func() {
+			for field, value := range map[string]string{
+				clientinfo.AppVersion: "<script>",
+				clientinfo.Platform:   "windows phone",
+				clientinfo.OSVersion:  "a very long version string that nobody would send",
+				clientinfo.Locale:     "*",
+			} {
+				_, err := clientinfo.Normalize(field, value)
+				Expect(err).To(HaveOccurred(), field)
+			}
+		})
+	})
+
+	Describe("FromHeaders", func() {
+		It("reads only allowlisted headers with valid values", func() {
+			allowlist, err := clientinfo.ParseAllowlist("app_version=X-App-Version,platform=X-App-Platform")
+			Expect(err).To(BeNil())
+
+			h := http.Header{}
+			h.Set("X-App-Version", "4.2.0")
+			h.Set("X-App-Platform", "not a platform!")
+			h.Set("Accept-Language", "en-US")
+
+			info := allowlist.FromHeaders(h)
+			Expect(info.AppVersion).To(PointTo(Equal("4.2.0")))
+			Expect(info.Platform).To(BeNil())
+			Expect(info.Locale).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,29 @@
+ALTER TABLE feedbacks RENAME TO feedbacks_partitioned;
+
+CREATE TABLE feedbacks
+	(LIKE feedbacks_partitioned INCLUDING DEFAULTS INCLUDING CONSTRAINTS);
+
+INSERT INTO feedbacks SELECT * FROM feedbacks_partitioned;
+
+DROP TABLE feedbacks_partitioned;
+
+DROP FUNCTION delete_feedback_moderation_decisions();
+DROP FUNCTION drop_feedbacks_partition(date);
+DROP FUNCTION feedbacks_partition_rows(date);
+DROP FUNCTION detach_feedbacks_partition(date);
+DROP FUNCTION create_feedbacks_partition(date);
+DROP FUNCTION feedbacks_partition_name(date);
+
+ALTER TABLE feedbacks ADD PRIMARY KEY (id);
+
+CREATE INDEX feedbacks_kind_received_idx ON feedbacks (kind, received_moment);
+CREATE INDEX feedbacks_session_received_idx ON feedbacks (session_id, received_moment);
+CREATE INDEX feedbacks_moderation_received_idx ON feedbacks (moderation_status, received_moment);
+CREATE INDEX feedbacks_email_index_idx ON feedbacks (email_index);
+
+DELETE FROM moderation_decisions
+	WHERE feedback_id NOT IN (SELECT id FROM feedbacks);
+
+ALTER TABLE moderation_decisions
+	ADD CONSTRAINT moderation_decisions_feedback_id_fkey
+	FOREIGN KEY (feedback_id) REFERENCES feedbacks (id) ON DELETE CASCADE;
@@ -0,0 +1,104 @@
+-- Foreign keys can't reference a partitioned table by id alone, so deleting
+-- a feedback's moderation decisions is done by trigger instead.
+ALTER TABLE moderation_decisions DROP CONSTRAINT moderation_decisions_feedback_id_fkey;
+
+ALTER TABLE feedbacks RENAME TO feedbacks_unpartitioned;
+
+CREATE TABLE feedbacks
+	(LIKE feedbacks_unpartitioned INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+	PARTITION BY RANGE (received_moment);
+
+CREATE FUNCTION feedbacks_partition_name(month date) RETURNS text AS $$
+	SELECT 'feedbacks_y' || to_char(month, 'YYYY') || 'm' || to_char(month, 'MM')
+$$ LANGUAGE sql IMMUTABLE;
+
+-- create_feedbacks_partition creates the partition holding the month of the
+-- given date, if it doesn't exist yet, and returns its name
+CREATE FUNCTION create_feedbacks_partition(month date) RETURNS text AS $$
+DECLARE
+	first_day date := date_trunc('month', month);
+	part_name text := feedbacks_partition_name(first_day);
+BEGIN
+	IF to_regclass(part_name) IS NULL THEN
+		EXECUTE format('CREATE TABLE %I PARTITION OF feedbacks FOR VALUES FROM (%L) TO (%L)',
+			part_name, first_day, first_day + interval '1 month');
+	END IF;
+	RETURN part_name;
+END
+$$ LANGUAGE plpgsql;
+
+-- detach_feedbacks_partition detaches the partition holding the month of the
+-- given date, if it is still attached, and returns its name
+CREATE FUNCTION detach_feedbacks_partition(month date) RETURNS text AS $$
+DECLARE
+	part_name text := feedbacks_partition_name(date_trunc('month', month)::date);
+BEGIN
+	IF to_regclass(part_name) IS NULL THEN
+		RAISE EXCEPTION 'partition % does not exist', part_name;
+	END IF;
+	IF EXISTS (SELECT 1 FROM pg_inherits WHERE inhrelid = to_regclass(part_name)) THEN
+		EXECUTE format('ALTER TABLE feedbacks DETACH PARTITION %I', part_name);
+	END IF;
+	RETURN part_name;
+END
+$$ LANGUAGE plpgsql;
+
+-- feedbacks_partition_rows returns every row of a detached partition
+CREATE FUNCTION feedbacks_partition_rows(month date) RETURNS SETOF feedbacks AS $$
+BEGIN
+	RETURN QUERY EXECUTE format('SELECT * FROM %I ORDER BY received_moment',
+		feedbacks_partition_name(date_trunc('month', month)::date));
+END
+$$ LANGUAGE plpgsql;
+
+-- drop_feedbacks_partition drops a detached partition along with the
+-- moderation decisions about its rows
+CREATE FUNCTION drop_feedbacks_partition(month date) RETURNS text AS $$
+DECLARE
+	part_name text := feedbacks_partition_name(date_trunc('month', month)::date);
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_inherits WHERE inhrelid = to_regclass(part_name)) THEN
+		RAISE EXCEPTION 'partition % must be detached before it is dropped', part_name;
+	END IF;
+	EXECUTE format('DELETE FROM moderation_decisions WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DROP TABLE %I', part_name);
+	RETURN part_name;
+END
+$$ LANGUAGE plpgsql;
+
+-- partitions for every month with existing data, and three months ahead
+DO $$
+DECLARE
+	month timestamp;
+BEGIN
+	FOR month IN SELECT generate_series(
+		date_trunc('month', LEAST(COALESCE((SELECT MIN(received_moment) FROM feedbacks_unpartitioned), NOW()), NOW())),
+		date_trunc('month', GREATEST(COALESCE((SELECT MAX(received_moment) FROM feedbacks_unpartitioned), NOW()), NOW())) + interval '3 months',
+		interval '1 month'
+	) LOOP
+		PERFORM create_feedbacks_partition(month::date);
+	END LOOP;
+END
+$$;
+
+INSERT INTO feedbacks SELECT * FROM feedbacks_unpartitioned;
+
+DROP TABLE feedbacks_unpartitioned;
+
+ALTER TABLE feedbacks ADD PRIMARY KEY (id, received_moment);
+
+CREATE INDEX feedbacks_kind_received_idx ON feedbacks (kind, received_moment);
+CREATE INDEX feedbacks_session_received_idx ON feedbacks (session_id, received_moment);
+CREATE INDEX feedbacks_moderation_received_idx ON feedbacks (moderation_status, received_moment);
+CREATE INDEX feedbacks_email_index_idx ON feedbacks (email_index);
+
+CREATE FUNCTION delete_feedback_moderation_decisions() RETURNS trigger AS $$
+BEGIN
+	DELETE FROM moderation_decisions WHERE feedback_id = OLD.id;
+	RETURN OLD;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER feedbacks_delete_moderation_decisions
+	AFTER DELETE ON feedbacks
+	FOR EACH ROW EXECUTE FUNCTION delete_feedback_moderation_decisions();
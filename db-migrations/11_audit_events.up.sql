@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS audit_events
+(	seq bigint PRIMARY KEY CHECK (seq > 0),
+	occurred_moment timestamp NOT NULL,
+	actor_session varchar NOT NULL,
+	actor_role varchar NOT NULL,
+	action varchar NOT NULL,
+	target_type varchar NOT NULL,
+	target_ids text[] NOT NULL,
+	before jsonb,
+	after jsonb,
+	request_id varchar NOT NULL,
+
+	prev_hash char(64) NOT NULL,
+	hash char(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX audit_events_action_idx ON audit_events (action, seq);
+CREATE INDEX audit_events_actor_session_idx ON audit_events (actor_session, seq);
+CREATE INDEX audit_events_target_ids_idx ON audit_events USING GIN (target_ids);
+
+-- the hash chain detects tampering; this makes it harder to begin with
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+	BEFORE UPDATE OR DELETE ON audit_events
+	FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+	BEFORE TRUNCATE ON audit_events
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,19 @@
+ALTER TABLE retention_policies DROP CONSTRAINT retention_policies_kind_fkey;
+ALTER TABLE feedbacks
+	DROP CONSTRAINT feedbacks_kind_fkey,
+	DROP CONSTRAINT feedbacks_value_fkey;
+
+CREATE TYPE kind AS ENUM ('outage', 'comment', 'service_condition');
+CREATE TYPE value AS ENUM ('positive', 'negative', 'neutral');
+
+-- rows of kinds or values added since can't be converted back, and make
+-- this fail
+ALTER TABLE feedbacks
+	ALTER COLUMN kind TYPE kind USING kind::kind,
+	ALTER COLUMN value TYPE value USING value::value;
+
+ALTER TABLE retention_policies
+	ALTER COLUMN kind TYPE kind USING kind::kind;
+
+DROP TABLE IF EXISTS feedback_values;
+DROP TABLE IF EXISTS feedback_kinds;
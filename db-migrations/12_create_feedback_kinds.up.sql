@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS feedback_kinds
+(	name varchar PRIMARY KEY CHECK (name ~ '^[a-z][a-z0-9_]{0,31}$'),
+	label varchar NOT NULL,
+	active boolean DEFAULT TRUE NOT NULL,
+	value_required boolean DEFAULT FALSE NOT NULL,
+	message_required boolean DEFAULT FALSE NOT NULL,
+	email_required boolean DEFAULT FALSE NOT NULL,
+	sort_order integer DEFAULT 0 NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS feedback_values
+(	name varchar PRIMARY KEY CHECK (name ~ '^[a-z][a-z0-9_]{0,31}$'),
+	label varchar NOT NULL,
+	active boolean DEFAULT TRUE NOT NULL,
+	sort_order integer DEFAULT 0 NOT NULL
+);
+
+INSERT INTO feedback_kinds (name, label, sort_order) VALUES
+	('outage', 'Outage', 10),
+	('service_condition', 'Service condition', 20),
+	('comment', 'Comment', 30);
+
+INSERT INTO feedback_values (name, label, sort_order) VALUES
+	('positive', 'Positive', 10),
+	('neutral', 'Neutral', 20),
+	('negative', 'Negative', 30);
+
+-- this fails if a partition detached by an unfinished archive still uses the
+-- enum types; finish or re-run the archive first
+ALTER TABLE feedbacks
+	ALTER COLUMN kind TYPE varchar USING kind::text,
+	ALTER COLUMN value TYPE varchar USING value::text;
+
+ALTER TABLE retention_policies
+	ALTER COLUMN kind TYPE varchar USING kind::text;
+
+DROP TYPE kind;
+DROP TYPE value;
+
+ALTER TABLE feedbacks
+	ADD CONSTRAINT feedbacks_kind_fkey FOREIGN KEY (kind) REFERENCES feedback_kinds (name),
+	ADD CONSTRAINT feedbacks_value_fkey FOREIGN KEY (value) REFERENCES feedback_values (name);
+
+ALTER TABLE retention_policies
+	ADD CONSTRAINT retention_policies_kind_fkey FOREIGN KEY (kind) REFERENCES feedback_kinds (name);
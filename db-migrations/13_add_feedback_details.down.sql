@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS feedbacks_details_idx;
+
+ALTER TABLE feedbacks
+	DROP COLUMN details;
+
+ALTER TABLE feedback_kinds
+	DROP COLUMN details_schema;
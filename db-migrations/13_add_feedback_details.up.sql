@@ -0,0 +1,39 @@
+ALTER TABLE feedback_kinds
+	ADD COLUMN details_schema jsonb;
+
+-- this fails to apply to a partition detached by an unfinished archive;
+-- finish or re-run the archive first
+ALTER TABLE feedbacks
+	ADD COLUMN details jsonb;
+
+CREATE INDEX feedbacks_details_idx ON feedbacks USING GIN (details jsonb_path_ops);
+
+UPDATE feedback_kinds SET details_schema = '{
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {
+		"crowding": {
+			"title": "Crowding",
+			"type": "string",
+			"enum": ["empty", "seats_available", "standing_room", "full"]
+		},
+		"cleanliness": {
+			"title": "Cleanliness",
+			"type": "integer",
+			"minimum": 1,
+			"maximum": 5
+		},
+		"temperature": {
+			"title": "Temperature",
+			"type": "string",
+			"enum": ["too_cold", "comfortable", "too_hot"]
+		},
+		"safety": {
+			"title": "Feeling of safety",
+			"type": "integer",
+			"minimum": 1,
+			"maximum": 5
+		}
+	}
+}'
+	WHERE name = 'service_condition';
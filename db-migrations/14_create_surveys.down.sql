@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS survey_answers;
+DROP TABLE IF EXISTS survey_responses;
+ALTER TABLE surveys DROP CONSTRAINT surveys_active_version_fkey;
+DROP TABLE IF EXISTS survey_versions;
+DROP TABLE IF EXISTS surveys;
@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS surveys
+(	name varchar PRIMARY KEY CHECK (name ~ '^[a-z][a-z0-9_]{0,31}$'),
+	active_version integer,
+
+	created_moment timestamp DEFAULT NOW() NOT NULL
+);
+
+-- versions are never changed once created, so that every response can be
+-- read against the questions it answered
+CREATE TABLE IF NOT EXISTS survey_versions
+(	survey_name varchar NOT NULL REFERENCES surveys (name) ON DELETE CASCADE,
+	version integer NOT NULL CHECK (version > 0),
+	title varchar NOT NULL,
+	questions jsonb NOT NULL,
+	created_by_session varchar NOT NULL,
+	created_by_role varchar NOT NULL,
+
+	created_moment timestamp DEFAULT NOW() NOT NULL,
+	PRIMARY KEY (survey_name, version)
+);
+
+ALTER TABLE surveys
+	ADD CONSTRAINT surveys_active_version_fkey FOREIGN KEY (name, active_version)
+		REFERENCES survey_versions (survey_name, version);
+
+CREATE TABLE IF NOT EXISTS survey_responses
+(	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	survey_name varchar NOT NULL,
+	version integer NOT NULL,
+	session_id varchar NOT NULL,
+	role varchar NOT NULL,
+
+	submitted_moment timestamp DEFAULT NOW() NOT NULL,
+	FOREIGN KEY (survey_name, version) REFERENCES survey_versions (survey_name, version) ON DELETE CASCADE
+);
+
+CREATE INDEX survey_responses_version_idx ON survey_responses (survey_name, version);
+CREATE INDEX survey_responses_session_idx ON survey_responses (session_id);
+
+-- exactly one of number_value (scale questions), choices (single and multi
+-- choice questions) and text_value (free text questions) is set
+CREATE TABLE IF NOT EXISTS survey_answers
+(	response_id UUID NOT NULL REFERENCES survey_responses (id) ON DELETE CASCADE,
+	question_id varchar NOT NULL,
+	number_value integer,
+	choices varchar[],
+	text_value varchar,
+
+	PRIMARY KEY (response_id, question_id),
+	CHECK (num_nonnulls(number_value, choices, text_value) = 1)
+);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS feedbacks_anonymize_attachments ON feedbacks;
+DROP TRIGGER IF EXISTS feedbacks_delete_attachments ON feedbacks;
+DROP FUNCTION IF EXISTS discard_feedback_attachments();
+DROP TABLE IF EXISTS feedback_attachments;
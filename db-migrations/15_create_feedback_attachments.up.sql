@@ -0,0 +1,48 @@
+-- attachments are uploaded before the feedback they illustrate is submitted,
+-- so feedback_id is NULL until then. Foreign keys can't reference a
+-- partitioned table by id alone, and the stored images must be removed along
+-- with the row, so deleting or anonymizing a feedback only marks its
+-- attachments as discarded; the application deletes them from the blob store
+-- and then from this table.
+CREATE TABLE IF NOT EXISTS feedback_attachments
+(	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	feedback_id UUID,
+	session_id varchar NOT NULL,
+	content_type varchar NOT NULL,
+	byte_size integer NOT NULL,
+	width integer NOT NULL,
+	height integer NOT NULL,
+	sha256 char(64) NOT NULL,
+	storage_key varchar NOT NULL UNIQUE,
+	thumbnail_key varchar NOT NULL UNIQUE,
+	thumbnail_width integer NOT NULL,
+	thumbnail_height integer NOT NULL,
+
+	created_moment timestamp DEFAULT NOW() NOT NULL,
+	attached_moment timestamp,
+	discarded_moment timestamp
+);
+
+CREATE INDEX feedback_attachments_feedback_idx ON feedback_attachments (feedback_id);
+CREATE INDEX feedback_attachments_unattached_idx ON feedback_attachments (created_moment)
+	WHERE feedback_id IS NULL;
+CREATE INDEX feedback_attachments_discarded_idx ON feedback_attachments (discarded_moment)
+	WHERE discarded_moment IS NOT NULL;
+
+CREATE FUNCTION discard_feedback_attachments() RETURNS trigger AS $$
+BEGIN
+	UPDATE feedback_attachments SET discarded_moment = NOW()
+		WHERE feedback_id = OLD.id AND discarded_moment IS NULL;
+	RETURN OLD;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER feedbacks_delete_attachments
+	AFTER DELETE ON feedbacks
+	FOR EACH ROW EXECUTE FUNCTION discard_feedback_attachments();
+
+CREATE TRIGGER feedbacks_anonymize_attachments
+	AFTER UPDATE OF anonymized_moment ON feedbacks
+	FOR EACH ROW
+	WHEN (OLD.anonymized_moment IS NULL AND NEW.anonymized_moment IS NOT NULL)
+	EXECUTE FUNCTION discard_feedback_attachments();
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS feedbacks_stop_received_idx;
+DROP INDEX IF EXISTS feedbacks_location_idx;
+
+ALTER TABLE feedbacks
+	DROP CONSTRAINT feedbacks_location_check,
+	DROP COLUMN stop_distance,
+	DROP COLUMN stop_name,
+	DROP COLUMN stop_id,
+	DROP COLUMN location_accuracy,
+	DROP COLUMN longitude,
+	DROP COLUMN latitude;
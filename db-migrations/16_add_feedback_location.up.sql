@@ -0,0 +1,17 @@
+-- the raw location reported by the rider's device, and the stop it was
+-- resolved to, if any was close enough
+ALTER TABLE feedbacks
+	ADD COLUMN latitude double precision,
+	ADD COLUMN longitude double precision,
+	ADD COLUMN location_accuracy real,
+	ADD COLUMN stop_id varchar,
+	ADD COLUMN stop_name varchar,
+	ADD COLUMN stop_distance real,
+	ADD CONSTRAINT feedbacks_location_check CHECK ((latitude IS NULL) = (longitude IS NULL));
+
+-- supports bounding box queries of the form
+-- point(longitude, latitude) <@ box(...)
+CREATE INDEX feedbacks_location_idx ON feedbacks USING gist (point(longitude, latitude))
+	WHERE latitude IS NOT NULL;
+CREATE INDEX feedbacks_stop_received_idx ON feedbacks (stop_id, received_moment)
+	WHERE stop_id IS NOT NULL;
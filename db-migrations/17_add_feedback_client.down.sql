@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS feedbacks_platform_app_version_idx;
+
+ALTER TABLE feedbacks
+	DROP COLUMN locale,
+	DROP COLUMN os_version,
+	DROP COLUMN platform,
+	DROP COLUMN app_version;
@@ -0,0 +1,9 @@
+-- the app and device a feedback was sent from, as reported by the client
+ALTER TABLE feedbacks
+	ADD COLUMN app_version varchar,
+	ADD COLUMN platform varchar,
+	ADD COLUMN os_version varchar,
+	ADD COLUMN locale varchar;
+
+-- supports breakdowns of recent feedback by release
+CREATE INDEX feedbacks_platform_app_version_idx ON feedbacks (platform, app_version, received_moment);
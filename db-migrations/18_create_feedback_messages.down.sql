@@ -0,0 +1,18 @@
+CREATE OR REPLACE FUNCTION drop_feedbacks_partition(month date) RETURNS text AS $$
+DECLARE
+	part_name text := feedbacks_partition_name(date_trunc('month', month)::date);
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_inherits WHERE inhrelid = to_regclass(part_name)) THEN
+		RAISE EXCEPTION 'partition % must be detached before it is dropped', part_name;
+	END IF;
+	EXECUTE format('DELETE FROM moderation_decisions WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DROP TABLE %I', part_name);
+	RETURN part_name;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS feedbacks_anonymize_messages ON feedbacks;
+DROP TRIGGER IF EXISTS feedbacks_delete_messages ON feedbacks;
+DROP FUNCTION IF EXISTS delete_feedback_messages();
+DROP TABLE IF EXISTS email_queue;
+DROP TABLE IF EXISTS feedback_messages;
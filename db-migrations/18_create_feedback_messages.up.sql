@@ -0,0 +1,78 @@
+-- a conversation between staff and the rider who sent a feedback. session_id
+-- is the rider's, copied from the feedback so that riders' threads can be
+-- listed without scanning every partition. read_moment is set once the other
+-- party has seen the message.
+CREATE TABLE IF NOT EXISTS feedback_messages
+(	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	feedback_id UUID NOT NULL,
+	session_id varchar NOT NULL,
+	author varchar NOT NULL CHECK (author IN ('staff', 'rider')),
+	author_session varchar NOT NULL,
+	author_role varchar NOT NULL,
+	body text NOT NULL,
+
+	created_moment timestamp DEFAULT NOW() NOT NULL,
+	read_moment timestamp
+);
+
+CREATE INDEX feedback_messages_feedback_idx ON feedback_messages (feedback_id, created_moment);
+CREATE INDEX feedback_messages_session_idx ON feedback_messages (session_id, created_moment);
+CREATE INDEX feedback_messages_unread_idx ON feedback_messages (author, feedback_id)
+	WHERE read_moment IS NULL;
+
+-- emails waiting to be sent about a feedback. The recipient is read from the
+-- feedback when sending, so no address is copied here.
+CREATE TABLE IF NOT EXISTS email_queue
+(	id bigserial PRIMARY KEY,
+	feedback_id UUID NOT NULL,
+	message_id UUID,
+	template varchar NOT NULL,
+	status varchar DEFAULT 'pending' NOT NULL CHECK (status IN ('pending', 'sent', 'failed', 'dropped')),
+	attempts integer DEFAULT 0 NOT NULL,
+
+	created_moment timestamp DEFAULT NOW() NOT NULL,
+	next_attempt_moment timestamp DEFAULT NOW() NOT NULL,
+	sent_moment timestamp,
+	last_error text
+);
+
+CREATE INDEX email_queue_pending_idx ON email_queue (next_attempt_moment)
+	WHERE status = 'pending';
+CREATE INDEX email_queue_feedback_idx ON email_queue (feedback_id);
+
+-- as with attachments, foreign keys can't reference the partitioned
+-- feedbacks, so a feedback's thread and unsent emails go with it when it is
+-- deleted or anonymized
+CREATE FUNCTION delete_feedback_messages() RETURNS trigger AS $$
+BEGIN
+	DELETE FROM feedback_messages WHERE feedback_id = OLD.id;
+	DELETE FROM email_queue WHERE feedback_id = OLD.id AND status = 'pending';
+	RETURN OLD;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER feedbacks_delete_messages
+	AFTER DELETE ON feedbacks
+	FOR EACH ROW EXECUTE FUNCTION delete_feedback_messages();
+
+CREATE TRIGGER feedbacks_anonymize_messages
+	AFTER UPDATE OF anonymized_moment ON feedbacks
+	FOR EACH ROW
+	WHEN (OLD.anonymized_moment IS NULL AND NEW.anonymized_moment IS NOT NULL)
+	EXECUTE FUNCTION delete_feedback_messages();
+
+-- dropping an archived partition doesn't fire row triggers
+CREATE OR REPLACE FUNCTION drop_feedbacks_partition(month date) RETURNS text AS $$
+DECLARE
+	part_name text := feedbacks_partition_name(date_trunc('month', month)::date);
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_inherits WHERE inhrelid = to_regclass(part_name)) THEN
+		RAISE EXCEPTION 'partition % must be detached before it is dropped', part_name;
+	END IF;
+	EXECUTE format('DELETE FROM moderation_decisions WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DELETE FROM feedback_messages WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DELETE FROM email_queue WHERE feedback_id IN (SELECT id FROM %I) AND status = ''pending''', part_name);
+	EXECUTE format('DROP TABLE %I', part_name);
+	RETURN part_name;
+END
+$$ LANGUAGE plpgsql;
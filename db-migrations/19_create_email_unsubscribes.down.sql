@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS email_queue_outage_resolved_idx;
+DROP TABLE IF EXISTS email_unsubscribes;
+ALTER TABLE feedbacks DROP COLUMN email_opt_in_moment;
@@ -0,0 +1,14 @@
+-- riders opt in to emails per submission; feedback without an opt-in is
+-- never emailed, even if it has an address
+ALTER TABLE feedbacks ADD COLUMN email_opt_in_moment timestamp;
+
+-- addresses that asked for no more email, by their keyed digest as carried
+-- in unsubscribe links. Every send checks this table.
+CREATE TABLE IF NOT EXISTS email_unsubscribes
+(	digest char(64) PRIMARY KEY,
+	created_moment timestamp DEFAULT NOW() NOT NULL
+);
+
+-- emails to riders' outage reports are queued once per report
+CREATE UNIQUE INDEX email_queue_outage_resolved_idx ON email_queue (feedback_id)
+	WHERE template = 'outage_resolved';
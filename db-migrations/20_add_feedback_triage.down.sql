@@ -0,0 +1,28 @@
+CREATE OR REPLACE FUNCTION drop_feedbacks_partition(month date) RETURNS text AS $$
+DECLARE
+	part_name text := feedbacks_partition_name(date_trunc('month', month)::date);
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_inherits WHERE inhrelid = to_regclass(part_name)) THEN
+		RAISE EXCEPTION 'partition % must be detached before it is dropped', part_name;
+	END IF;
+	EXECUTE format('DELETE FROM moderation_decisions WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DELETE FROM feedback_messages WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DELETE FROM email_queue WHERE feedback_id IN (SELECT id FROM %I) AND status = ''pending''', part_name);
+	EXECUTE format('DROP TABLE %I', part_name);
+	RETURN part_name;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS feedbacks_delete_triage_transitions ON feedbacks;
+DROP FUNCTION IF EXISTS delete_feedback_triage_transitions();
+DROP TABLE IF EXISTS triage_transitions;
+
+DROP INDEX IF EXISTS feedbacks_tags_idx;
+DROP INDEX IF EXISTS feedbacks_assignee_received_idx;
+DROP INDEX IF EXISTS feedbacks_triage_received_idx;
+ALTER TABLE feedbacks
+	DROP COLUMN triaged_moment,
+	DROP COLUMN tags,
+	DROP COLUMN priority,
+	DROP COLUMN assignee,
+	DROP COLUMN triage_status;
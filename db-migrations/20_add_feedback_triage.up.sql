@@ -0,0 +1,60 @@
+-- where staff are with a feedback. tags are free-form labels, kept sorted and
+-- without duplicates.
+ALTER TABLE feedbacks
+	ADD COLUMN triage_status varchar DEFAULT 'new' NOT NULL
+		CHECK (triage_status IN ('new', 'in_progress', 'resolved', 'wont_fix')),
+	ADD COLUMN assignee varchar,
+	ADD COLUMN priority varchar DEFAULT 'normal' NOT NULL
+		CHECK (priority IN ('low', 'normal', 'high', 'urgent')),
+	ADD COLUMN tags text[] DEFAULT '{}' NOT NULL,
+	ADD COLUMN triaged_moment timestamp;
+
+CREATE INDEX feedbacks_triage_received_idx ON feedbacks (triage_status, received_moment);
+CREATE INDEX feedbacks_assignee_received_idx ON feedbacks (assignee, received_moment)
+	WHERE assignee IS NOT NULL;
+CREATE INDEX feedbacks_tags_idx ON feedbacks USING GIN (tags);
+
+-- every change to a feedback's triage, one row per field changed. The values
+-- are JSON so that tags and cleared assignees fit the same columns.
+CREATE TABLE IF NOT EXISTS triage_transitions
+(	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	feedback_id UUID NOT NULL,
+	field varchar NOT NULL CHECK (field IN ('status', 'assignee', 'priority', 'tags')),
+	old_value jsonb,
+	new_value jsonb,
+	note text,
+	actor_session varchar NOT NULL,
+	actor_role varchar NOT NULL,
+
+	changed_moment timestamp DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX triage_transitions_feedback_idx ON triage_transitions (feedback_id, changed_moment);
+
+CREATE FUNCTION delete_feedback_triage_transitions() RETURNS trigger AS $$
+BEGIN
+	DELETE FROM triage_transitions WHERE feedback_id = OLD.id;
+	RETURN OLD;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER feedbacks_delete_triage_transitions
+	AFTER DELETE ON feedbacks
+	FOR EACH ROW EXECUTE FUNCTION delete_feedback_triage_transitions();
+
+-- dropping an archived partition doesn't fire row triggers
+CREATE OR REPLACE FUNCTION drop_feedbacks_partition(month date) RETURNS text AS $$
+DECLARE
+	part_name text := feedbacks_partition_name(date_trunc('month', month)::date);
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_inherits WHERE inhrelid = to_regclass(part_name)) THEN
+		RAISE EXCEPTION 'partition % must be detached before it is dropped', part_name;
+	END IF;
+	EXECUTE format('DELETE FROM moderation_decisions WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DELETE FROM feedback_messages WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DELETE FROM email_queue WHERE feedback_id IN (SELECT id FROM %I) AND status = ''pending''', part_name);
+	EXECUTE format('DELETE FROM triage_transitions WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DROP TABLE %I', part_name);
+	RETURN part_name;
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS feedbacks_message_trgm_idx;
+DROP INDEX IF EXISTS feedbacks_message_tsv_idx;
+ALTER TABLE feedbacks DROP COLUMN message_tsv;
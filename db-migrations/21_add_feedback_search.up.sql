@@ -0,0 +1,13 @@
+-- full-text search over the redacted message. The original is never indexed.
+ALTER TABLE feedbacks
+	ADD COLUMN message_tsv tsvector
+		GENERATED ALWAYS AS (to_tsvector('english', coalesce(message, ''))) STORED;
+
+CREATE INDEX feedbacks_message_tsv_idx ON feedbacks USING GIN (message_tsv);
+
+-- trigram similarity finds feedback worded like a given one. Creating the
+-- extension takes privileges the service's role may lack; if so, a superuser
+-- installs it beforehand and this does nothing.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX feedbacks_message_trgm_idx ON feedbacks USING GIN (message gin_trgm_ops);
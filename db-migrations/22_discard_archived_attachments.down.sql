@@ -0,0 +1,15 @@
+CREATE OR REPLACE FUNCTION drop_feedbacks_partition(month date) RETURNS text AS $$
+DECLARE
+	part_name text := feedbacks_partition_name(date_trunc('month', month)::date);
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_inherits WHERE inhrelid = to_regclass(part_name)) THEN
+		RAISE EXCEPTION 'partition % must be detached before it is dropped', part_name;
+	END IF;
+	EXECUTE format('DELETE FROM moderation_decisions WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DELETE FROM feedback_messages WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DELETE FROM email_queue WHERE feedback_id IN (SELECT id FROM %I) AND status = ''pending''', part_name);
+	EXECUTE format('DELETE FROM triage_transitions WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DROP TABLE %I', part_name);
+	RETURN part_name;
+END
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,24 @@
+-- dropping an archived partition doesn't fire the trigger that discards the
+-- attachments of deleted feedback, so it discards them itself
+CREATE OR REPLACE FUNCTION drop_feedbacks_partition(month date) RETURNS text AS $$
+DECLARE
+	part_name text := feedbacks_partition_name(date_trunc('month', month)::date);
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_inherits WHERE inhrelid = to_regclass(part_name)) THEN
+		RAISE EXCEPTION 'partition % must be detached before it is dropped', part_name;
+	END IF;
+	EXECUTE format('DELETE FROM moderation_decisions WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DELETE FROM feedback_messages WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('DELETE FROM email_queue WHERE feedback_id IN (SELECT id FROM %I) AND status = ''pending''', part_name);
+	EXECUTE format('DELETE FROM triage_transitions WHERE feedback_id IN (SELECT id FROM %I)', part_name);
+	EXECUTE format('UPDATE feedback_attachments SET discarded_moment = NOW() WHERE feedback_id IN (SELECT id FROM %I) AND discarded_moment IS NULL', part_name);
+	EXECUTE format('DROP TABLE %I', part_name);
+	RETURN part_name;
+END
+$$ LANGUAGE plpgsql;
+
+-- attachments of partitions already archived point at feedback that is gone
+UPDATE feedback_attachments a SET discarded_moment = NOW()
+	WHERE a.feedback_id IS NOT NULL
+		AND a.discarded_moment IS NULL
+		AND NOT EXISTS (SELECT 1 FROM feedbacks f WHERE f.id = a.feedback_id);
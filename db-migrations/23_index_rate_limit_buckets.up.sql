@@ -0,0 +1,2 @@
+-- idle buckets are pruned by age
+CREATE INDEX rate_limit_buckets_updated_idx ON rate_limit_buckets (updated_moment);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS survey_responses_session_version_idx;
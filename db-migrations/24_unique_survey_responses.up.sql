@@ -0,0 +1,14 @@
+-- each session answers each version of a survey once. Earlier duplicates
+-- are dropped, keeping the first response. Anonymized responses all share
+-- a session, so they are left out.
+DELETE FROM survey_responses r
+	USING survey_responses first
+	WHERE r.survey_name = first.survey_name
+		AND r.version = first.version
+		AND r.session_id = first.session_id
+		AND r.session_id <> 'anonymized'
+		AND (r.submitted_moment, r.id) > (first.submitted_moment, first.id);
+
+CREATE UNIQUE INDEX survey_responses_session_version_idx
+	ON survey_responses (survey_name, version, session_id)
+	WHERE session_id <> 'anonymized';
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS rate_limit_buckets
+(	key varchar PRIMARY KEY,
+	tokens double precision NOT NULL,
+	updated_moment timestamp DEFAULT NOW() NOT NULL
+);
@@ -0,0 +1,9 @@
+DROP INDEX feedbacks_moderation_received_idx;
+DROP INDEX feedbacks_session_received_idx;
+
+ALTER TABLE feedbacks
+	DROP COLUMN spam_score,
+	DROP COLUMN spam_reason,
+	DROP COLUMN moderation_status;
+
+DROP TYPE moderation_status;
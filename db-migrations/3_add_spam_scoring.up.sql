@@ -0,0 +1,11 @@
+-- feedback scored as spam may be held as pending until a moderator approves
+-- or rejects it
+CREATE TYPE moderation_status AS ENUM ('pending', 'approved', 'rejected');
+
+ALTER TABLE feedbacks
+	ADD COLUMN spam_score real DEFAULT 0 NOT NULL,
+	ADD COLUMN spam_reason varchar,
+	ADD COLUMN moderation_status moderation_status DEFAULT 'approved' NOT NULL;
+
+CREATE INDEX feedbacks_session_received_idx ON feedbacks (session_id, received_moment);
+CREATE INDEX feedbacks_moderation_received_idx ON feedbacks (moderation_status, received_moment);
@@ -0,0 +1 @@
+DROP TABLE moderation_decisions;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS moderation_decisions
+(	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	feedback_id UUID NOT NULL REFERENCES feedbacks (id) ON DELETE CASCADE,
+	decision moderation_status NOT NULL,
+	reason varchar,
+	moderator_session varchar NOT NULL,
+	moderator_role varchar NOT NULL,
+
+	decided_moment timestamp DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX moderation_decisions_feedback_idx ON moderation_decisions (feedback_id, decided_moment);
@@ -0,0 +1,3 @@
+ALTER TABLE feedbacks
+	DROP COLUMN message_original,
+	DROP COLUMN redacted_pii;
@@ -0,0 +1,3 @@
+ALTER TABLE feedbacks
+	ADD COLUMN message_original varchar,
+	ADD COLUMN redacted_pii varchar;
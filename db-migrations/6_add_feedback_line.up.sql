@@ -0,0 +1,2 @@
+ALTER TABLE feedbacks
+	ADD COLUMN line varchar(32);
@@ -0,0 +1,4 @@
+DROP INDEX feedbacks_email_index_idx;
+
+ALTER TABLE feedbacks
+	DROP COLUMN email_index;
@@ -0,0 +1,4 @@
+ALTER TABLE feedbacks
+	ADD COLUMN email_index varchar(64);
+
+CREATE INDEX feedbacks_email_index_idx ON feedbacks (email_index);
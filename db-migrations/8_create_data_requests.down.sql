@@ -0,0 +1,3 @@
+DROP TABLE data_requests;
+
+DROP TYPE data_request_action;
@@ -0,0 +1,16 @@
+CREATE TYPE data_request_action AS ENUM ('export', 'delete', 'anonymize');
+
+CREATE TABLE IF NOT EXISTS data_requests
+(	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	action data_request_action NOT NULL,
+	subject_type varchar NOT NULL,
+	subject_digest varchar(64) NOT NULL,
+	feedback_count integer NOT NULL,
+	requested_by_session varchar NOT NULL,
+	requested_by_role varchar NOT NULL,
+	source varchar NOT NULL,
+
+	requested_moment timestamp DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX data_requests_subject_idx ON data_requests (subject_digest, requested_moment);
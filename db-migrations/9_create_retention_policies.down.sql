@@ -0,0 +1,6 @@
+ALTER TABLE feedbacks
+	DROP COLUMN anonymized_moment;
+
+DROP TABLE retention_policies;
+
+DROP TYPE retention_action;
@@ -0,0 +1,20 @@
+CREATE TYPE retention_action AS ENUM ('delete', 'anonymize');
+
+CREATE TABLE IF NOT EXISTS retention_policies
+(	kind kind PRIMARY KEY,
+	max_age_days integer NOT NULL CHECK (max_age_days > 0),
+	action retention_action NOT NULL,
+	updated_by varchar NOT NULL,
+
+	updated_moment timestamp DEFAULT NOW() NOT NULL
+);
+
+INSERT INTO retention_policies (kind, max_age_days, action, updated_by) VALUES
+	('outage', 90, 'delete', 'migration'),
+	('comment', 730, 'delete', 'migration');
+
+ALTER TABLE feedbacks
+	ADD COLUMN anonymized_moment timestamp;
+
+UPDATE feedbacks SET anonymized_moment = NOW()
+	WHERE session_id = 'anonymized';
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	//SaveAttachmentSQL a prepared Postgres statement for recording an
+	//uploaded attachment that isn't linked to a feedback yet
+	SaveAttachmentSQL = `
+INSERT INTO feedback_attachments
+  (session_id, content_type, byte_size, width, height, sha256,
+   storage_key, thumbnail_key, thumbnail_width, thumbnail_height)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+  RETURNING id, created_moment`
+
+	//GetAttachmentsSQL a prepared Postgres statement for getting attachments
+	//by ID, including discarded ones
+	GetAttachmentsSQL = `
+SELECT ` + attachmentColumns + ` FROM feedback_attachments
+  WHERE id = ANY($1::uuid[])
+  ORDER BY created_moment`
+
+	//ListFeedbackAttachmentsSQL a prepared Postgres statement for listing the
+	//attachments of a feedback in the order they were uploaded
+	ListFeedbackAttachmentsSQL = `
+SELECT ` + attachmentColumns + ` FROM feedback_attachments
+  WHERE feedback_id = $1
+    AND discarded_moment IS NULL
+  ORDER BY created_moment`
+
+	//ListDiscardedAttachmentsSQL a prepared Postgres statement for listing
+	//attachments to delete: those whose feedback was deleted or anonymized,
+	//and uploads never linked to a feedback
+	ListDiscardedAttachmentsSQL = `
+SELECT ` + attachmentColumns + ` FROM feedback_attachments
+  WHERE discarded_moment IS NOT NULL
+     OR (feedback_id IS NULL AND created_moment < $1)
+  LIMIT $2`
+
+	//DeleteAttachmentSQL a prepared Postgres statement for deleting an
+	//attachment's metadata once its images are gone
+	DeleteAttachmentSQL = `
+DELETE FROM feedback_attachments WHERE id = $1`
+)
+
+const attachmentColumns = `id, feedback_id, session_id, content_type, byte_size, width, height, sha256,
+  storage_key, thumbnail_key, thumbnail_width, thumbnail_height, created_moment, discarded_moment`
+
+//Attachment describes an image uploaded by a rider. The image itself and
+//its thumbnail are kept in a blob store under StorageKey and ThumbnailKey.
+type Attachment struct {
+	ID         string
+	FeedbackID *string
+	SessionID  string
+
+	ContentType string
+	ByteSize    int
+	Width       int
+	Height      int
+	SHA256      string
+
+	StorageKey      string
+	ThumbnailKey    string
+	ThumbnailWidth  int
+	ThumbnailHeight int
+
+	CreatedAt   time.Time
+	DiscardedAt *time.Time
+}
+
+//SaveAttachment records an uploaded attachment and returns it with its ID
+//and creation time set. It is linked to a feedback when the feedback is
+//saved with its ID.
+func (c Client) SaveAttachment(ctx context.Context, a Attachment) (Attachment, error) {
+	rows, err := c.db.QueryContext(ctx, SaveAttachmentSQL,
+		a.SessionID, a.ContentType, a.ByteSize, a.Width, a.Height, a.SHA256,
+		a.StorageKey, a.ThumbnailKey, a.ThumbnailWidth, a.ThumbnailHeight,
+	)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed saving attachment: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err = rows.Scan(&a.ID, &a.CreatedAt); err != nil {
+			return Attachment{}, fmt.Errorf("failed scanning attachment ID: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return Attachment{}, fmt.Errorf("failed saving attachment: %w", err)
+	}
+
+	return a, nil
+}
+
+//GetAttachments returns the attachments with the given IDs. IDs that don't
+//exist are left out.
+func (c Client) GetAttachments(ctx context.Context, ids []string) ([]Attachment, error) {
+	rows, err := c.db.QueryContext(ctx, GetAttachmentsSQL, pq.Array(nonNilStrings(ids)))
+	if err != nil {
+		return nil, fmt.Errorf("failed getting attachments: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAttachments(rows)
+}
+
+//ListFeedbackAttachments returns the attachments of a feedback
+func (c Client) ListFeedbackAttachments(ctx context.Context, feedbackID string) ([]Attachment, error) {
+	rows, err := c.db.QueryContext(ctx, ListFeedbackAttachmentsSQL, feedbackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing feedback attachments: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAttachments(rows)
+}
+
+//ListDiscardedAttachments returns up to limit attachments that should be
+//deleted: those discarded along with their feedback, and those uploaded
+//before unattachedBefore but never linked to a feedback
+func (c Client) ListDiscardedAttachments(ctx context.Context, unattachedBefore time.Time, limit int) ([]Attachment, error) {
+	rows, err := c.db.QueryContext(ctx, ListDiscardedAttachmentsSQL, unattachedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing discarded attachments: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAttachments(rows)
+}
+
+//DeleteAttachment deletes an attachment's metadata. Its images should be
+//deleted from the blob store first.
+func (c Client) DeleteAttachment(ctx context.Context, id string) error {
+	_, err := c.db.ExecContext(ctx, DeleteAttachmentSQL, id)
+	if err != nil {
+		return fmt.Errorf("failed deleting attachment: %w", err)
+	}
+
+	return nil
+}
+
+func scanAttachments(rows *sql.Rows) ([]Attachment, error) {
+	result := []Attachment{}
+	for rows.Next() {
+		var a Attachment
+		err := rows.Scan(
+			&a.ID, &a.FeedbackID, &a.SessionID, &a.ContentType, &a.ByteSize, &a.Width, &a.Height, &a.SHA256,
+			&a.StorageKey, &a.ThumbnailKey, &a.ThumbnailWidth, &a.ThumbnailHeight, &a.CreatedAt, &a.DiscardedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning attachment: %w", err)
+		}
+		result = append(result, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading attachments: %w", err)
+	}
+
+	return result, nil
+}
+
+//nonNilStrings returns s, or an empty list in place of nil so that an array
+//parameter is never NULL
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	//GetLastAuditEventSQL a prepared Postgres statement for getting the
+	//sequence number and hash at the head of the audit chain
+	GetLastAuditEventSQL = `
+SELECT seq, hash FROM audit_events
+  ORDER BY seq DESC
+  LIMIT 1`
+
+	//AppendAuditEventSQL a prepared Postgres statement for appending an
+	//event to the audit chain. Nothing is inserted if another event took the
+	//sequence number first.
+	AppendAuditEventSQL = `
+INSERT INTO audit_events
+  (seq, occurred_moment, actor_session, actor_role, action, target_type, target_ids,
+   before, after, request_id, prev_hash, hash)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (seq) DO NOTHING`
+
+	//ListAuditEventsSQL a prepared Postgres statement for listing audit
+	//events, newest first, optionally filtered by action, target ID and
+	//actor session
+	ListAuditEventsSQL = `
+SELECT ` + auditEventColumns + ` FROM audit_events
+  WHERE ($1::varchar = '' OR action = $1)
+    AND ($2::varchar = '' OR target_ids @> ARRAY[$2::text])
+    AND ($3::varchar = '' OR actor_session = $3)
+  ORDER BY seq DESC
+  LIMIT $4 OFFSET $5`
+
+	//ListAuditEventsAfterSQL a prepared Postgres statement for reading the
+	//audit chain in order, starting after a sequence number
+	ListAuditEventsAfterSQL = `
+SELECT ` + auditEventColumns + ` FROM audit_events
+  WHERE seq > $1
+  ORDER BY seq
+  LIMIT $2`
+)
+
+//auditEventColumns lists the columns read by scanAuditEvents, in order
+const auditEventColumns = `seq, occurred_moment, actor_session, actor_role, action, target_type,
+  target_ids, before, after, request_id, prev_hash, hash`
+
+//AuditEvent records an administrative action. Each event carries the hash
+//of the one before it, so that altering or removing an event breaks the
+//chain.
+type AuditEvent struct {
+	Seq          int64
+	OccurredAt   time.Time
+	ActorSession string
+	ActorRole    string
+	Action       string
+	TargetType   string
+	TargetIDs    []string
+
+	//Before and After hold JSON, and are nil when there is no value
+	Before []byte
+	After  []byte
+
+	RequestID string
+	PrevHash  string
+	Hash      string
+}
+
+//AuditFilter narrows a listing of audit events. Empty fields match anything.
+type AuditFilter struct {
+	Action       string
+	TargetID     string
+	ActorSession string
+}
+
+//GetLastAuditEvent returns the sequence number and hash of the newest audit
+//event, or zero values if there are none
+func (c Client) GetLastAuditEvent(ctx context.Context) (int64, string, error) {
+	rows, err := c.db.QueryContext(ctx, GetLastAuditEventSQL)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed getting last audit event: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		seq  int64
+		hash string
+	)
+	if rows.Next() {
+		if err = rows.Scan(&seq, &hash); err != nil {
+			return 0, "", fmt.Errorf("failed scanning last audit event: %w", err)
+		}
+	}
+
+	return seq, hash, nil
+}
+
+//AppendAuditEvent inserts event, which must already be hashed. It returns
+//false if another event already holds event.Seq.
+func (c Client) AppendAuditEvent(ctx context.Context, event AuditEvent) (bool, error) {
+	res, err := c.db.ExecContext(ctx, AppendAuditEventSQL,
+		event.Seq, event.OccurredAt, event.ActorSession, event.ActorRole,
+		event.Action, event.TargetType, pq.Array(event.TargetIDs),
+		jsonParam(event.Before), jsonParam(event.After),
+		event.RequestID, event.PrevHash, event.Hash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed appending audit event: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed appending audit event: %w", err)
+	}
+
+	return n == 1, nil
+}
+
+//ListAuditEvents returns a page of audit events matching filter, newest first
+func (c Client) ListAuditEvents(ctx context.Context, filter AuditFilter, limit, offset int) ([]AuditEvent, error) {
+	rows, err := c.db.QueryContext(ctx, ListAuditEventsSQL,
+		filter.Action, filter.TargetID, filter.ActorSession, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing audit events: %w", err)
+	}
+
+	return scanAuditEvents(rows)
+}
+
+//ListAuditEventsAfter returns up to limit audit events following seq, in
+//chain order
+func (c Client) ListAuditEventsAfter(ctx context.Context, seq int64, limit int) ([]AuditEvent, error) {
+	rows, err := c.db.QueryContext(ctx, ListAuditEventsAfterSQL, seq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing audit events: %w", err)
+	}
+
+	return scanAuditEvents(rows)
+}
+
+func scanAuditEvents(rows *sql.Rows) ([]AuditEvent, error) {
+	defer rows.Close()
+
+	result := []AuditEvent{}
+	for rows.Next() {
+		var ev AuditEvent
+		err := rows.Scan(
+			&ev.Seq,
+			&ev.OccurredAt,
+			&ev.ActorSession,
+			&ev.ActorRole,
+			&ev.Action,
+			&ev.TargetType,
+			pq.Array(&ev.TargetIDs),
+			&ev.Before,
+			&ev.After,
+			&ev.RequestID,
+			&ev.PrevHash,
+			&ev.Hash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning audit events: %w", err)
+		}
+
+		result = append(result, ev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading audit events: %w", err)
+	}
+
+	return result, nil
+}
+
+//jsonParam passes JSON to a jsonb parameter. A []byte would be sent as bytea.
+func jsonParam(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	//CountFeedbackByClientSQL a prepared Postgres statement for counting
+	//feedback grouped by the client fields selected by $1 through $4. Fields
+	//that aren't selected are NULL in every row, so they don't split groups.
+	CountFeedbackByClientSQL = `
+SELECT CASE WHEN $1::boolean THEN app_version END,
+       CASE WHEN $2::boolean THEN platform END,
+       CASE WHEN $3::boolean THEN os_version END,
+       CASE WHEN $4::boolean THEN locale END,
+       COUNT(*)
+  FROM feedbacks
+  WHERE received_moment >= $5
+    AND received_moment < $6
+    AND ($7::varchar IS NULL OR kind = $7)
+  GROUP BY 1, 2, 3, 4
+  ORDER BY 5 DESC, 1, 2, 3, 4
+  LIMIT $8 OFFSET $9`
+)
+
+//ClientBreakdown selects the client fields to group feedback counts by, and
+//the feedback to count
+type ClientBreakdown struct {
+	ByAppVersion bool
+	ByPlatform   bool
+	ByOSVersion  bool
+	ByLocale     bool
+
+	//Kind only counts feedback of this kind, if set
+	Kind *string
+
+	//Since and Until bound when the feedback was received. Since is
+	//inclusive and Until exclusive.
+	Since time.Time
+	Until time.Time
+}
+
+//ClientCount is the number of feedback sent from clients matching Client.
+//Fields that weren't grouped by are nil, as are those that weren't reported.
+type ClientCount struct {
+	Client ClientInfo
+	Count  int
+}
+
+//CountFeedbackByClient returns a page of feedback counts grouped as
+//selected by breakdown, largest first
+func (c Client) CountFeedbackByClient(ctx context.Context, breakdown ClientBreakdown, limit, offset int) ([]ClientCount, error) {
+	rows, err := c.db.QueryContext(ctx, CountFeedbackByClientSQL,
+		breakdown.ByAppVersion, breakdown.ByPlatform, breakdown.ByOSVersion, breakdown.ByLocale,
+		breakdown.Since, breakdown.Until, breakdown.Kind, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed counting feedback by client: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ClientCount{}
+	for rows.Next() {
+		var count ClientCount
+		err = rows.Scan(
+			&count.Client.AppVersion,
+			&count.Client.Platform,
+			&count.Client.OSVersion,
+			&count.Client.Locale,
+			&count.Count,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning feedback count: %w", err)
+		}
+		result = append(result, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading feedback counts: %w", err)
+	}
+
+	return result, nil
+}
@@ -3,25 +3,149 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 const (
-	//SaveFeedbackSQL a prepared Postgres statements for saving a new feedback record
+	//SaveFeedbackSQL a prepared Postgres statements for saving a new feedback record,
+	//linking the rider's unattached uploads to it and queueing its
+	//acknowledgement email
 	SaveFeedbackSQL = `
-INSERT INTO feedbacks
-  (session_id, role, kind, message, value, email)
-  VALUES ($1, $2, $3, $4, $5, $6)`
+WITH feedback AS (
+  INSERT INTO feedbacks
+    (session_id, role, kind, message, value, email, silenced, moderation_status, spam_score, spam_reason,
+     message_original, redacted_pii, line, email_index, details,
+     latitude, longitude, location_accuracy, stop_id, stop_name, stop_distance,
+     app_version, platform, os_version, locale, email_opt_in_moment)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+            $17, $18, $19, $20, $21, $22, $23, $24, $25, $26,
+            CASE WHEN $27::boolean THEN NOW() END)
+    RETURNING id
+), acknowledgement AS (
+  INSERT INTO email_queue (feedback_id, template)
+    SELECT id, 'acknowledgement' FROM feedback
+      WHERE $28::boolean
+)
+UPDATE feedback_attachments a
+  SET feedback_id = feedback.id, attached_moment = NOW()
+  FROM feedback
+  WHERE a.id = ANY($16::uuid[])
+    AND a.session_id = $1
+    AND a.feedback_id IS NULL
+    AND a.discarded_moment IS NULL`
 
 	//GetRecentOutagesSQL a prepared Postgres statements for getting recent outages
 	GetRecentOutagesSQL = `
-SELECT id, session_id, role, kind, message, received_moment, silenced FROM feedbacks
+SELECT id, session_id, role, kind, message, received_moment, silenced, line FROM feedbacks
   WHERE kind = 'outage'
     AND received_moment > $1
-    AND NOT silenced`
+    AND NOT silenced
+    AND moderation_status = 'approved'`
+
+	//CountDuplicateMessagesSQL a prepared Postgres statement for counting a
+	//session's recent feedback with identical text
+	CountDuplicateMessagesSQL = `
+SELECT COUNT(*) FROM feedbacks
+  WHERE session_id = $1
+    AND received_moment > $2
+    AND lower(message) = lower($3)`
+
+	//GetOriginalMessageSQL a prepared Postgres statement for getting the
+	//unredacted message of a feedback
+	GetOriginalMessageSQL = `
+SELECT message_original FROM feedbacks
+  WHERE id = $1`
 )
 
+//StatementNames maps each prepared statement to a short name for instrumentation
+var StatementNames = map[string]string{
+	SaveFeedbackSQL:     "SaveFeedbackSQL",
+	GetRecentOutagesSQL: "GetRecentOutagesSQL",
+
+	CountDuplicateMessagesSQL: "CountDuplicateMessagesSQL",
+	GetOriginalMessageSQL:     "GetOriginalMessageSQL",
+
+	ListFeedbackByModerationStatusSQL: "ListFeedbackByModerationStatusSQL",
+	ModerateFeedbackSQL:               "ModerateFeedbackSQL",
+	SilenceFeedbackSQL:                "SilenceFeedbackSQL",
+	GetModerationDecisionsSQL:         "GetModerationDecisionsSQL",
+
+	UpdateTriageSQL:         "UpdateTriageSQL",
+	GetTriageTransitionsSQL: "GetTriageTransitionsSQL",
+
+	ListFeedbackByEmailSQL:   "ListFeedbackByEmailSQL",
+	ListFeedbackSQL:          "ListFeedbackSQL",
+	SearchFeedbackSQL:        "SearchFeedbackSQL",
+	GetFeedbackMessageSQL:    "GetFeedbackMessageSQL",
+	SimilarFeedbackSQL:       "SimilarFeedbackSQL",
+	CountFeedbackByClientSQL: "CountFeedbackByClientSQL",
+	ListEmailsToRotateSQL:    "ListEmailsToRotateSQL",
+	UpdateEmailSQL:           "UpdateEmailSQL",
+
+	FindRiderFeedbackSQL:        "FindRiderFeedbackSQL",
+	FindRiderAttachmentsSQL:     "FindRiderAttachmentsSQL",
+	FindRiderSurveyResponsesSQL: "FindRiderSurveyResponsesSQL",
+	FindRiderMessagesSQL:        "FindRiderMessagesSQL",
+	DeleteRiderFeedbackSQL:      "DeleteRiderFeedbackSQL",
+	AnonymizeRiderFeedbackSQL:   "AnonymizeRiderFeedbackSQL",
+	RecordDataRequestSQL:        "RecordDataRequestSQL",
+
+	GetRetentionPoliciesSQL:     "GetRetentionPoliciesSQL",
+	SetRetentionPolicySQL:       "SetRetentionPolicySQL",
+	DeleteRetentionPolicySQL:    "DeleteRetentionPolicySQL",
+	CountExpiredFeedbackSQL:     "CountExpiredFeedbackSQL",
+	DeleteExpiredFeedbackSQL:    "DeleteExpiredFeedbackSQL",
+	AnonymizeExpiredFeedbackSQL: "AnonymizeExpiredFeedbackSQL",
+
+	CreateFeedbackPartitionSQL: "CreateFeedbackPartitionSQL",
+	DetachFeedbackPartitionSQL: "DetachFeedbackPartitionSQL",
+	StreamFeedbackPartitionSQL: "StreamFeedbackPartitionSQL",
+	DropFeedbackPartitionSQL:   "DropFeedbackPartitionSQL",
+
+	GetLastAuditEventSQL:    "GetLastAuditEventSQL",
+	AppendAuditEventSQL:     "AppendAuditEventSQL",
+	ListAuditEventsSQL:      "ListAuditEventsSQL",
+	ListAuditEventsAfterSQL: "ListAuditEventsAfterSQL",
+
+	ListFeedbackKindsSQL:  "ListFeedbackKindsSQL",
+	ListFeedbackValuesSQL: "ListFeedbackValuesSQL",
+
+	CreateSurveyVersionSQL:    "CreateSurveyVersionSQL",
+	SetActiveSurveyVersionSQL: "SetActiveSurveyVersionSQL",
+	GetSurveyVersionSQL:       "GetSurveyVersionSQL",
+	SaveSurveyResponseSQL:     "SaveSurveyResponseSQL",
+	CountSurveyAnswersSQL:     "CountSurveyAnswersSQL",
+
+	SaveAttachmentSQL:           "SaveAttachmentSQL",
+	GetAttachmentsSQL:           "GetAttachmentsSQL",
+	ListFeedbackAttachmentsSQL:  "ListFeedbackAttachmentsSQL",
+	ListDiscardedAttachmentsSQL: "ListDiscardedAttachmentsSQL",
+	DeleteAttachmentSQL:         "DeleteAttachmentSQL",
+
+	SaveFeedbackMessageSQL:      "SaveFeedbackMessageSQL",
+	ListFeedbackMessagesSQL:     "ListFeedbackMessagesSQL",
+	MarkFeedbackMessagesReadSQL: "MarkFeedbackMessagesReadSQL",
+	ListFeedbackThreadsSQL:      "ListFeedbackThreadsSQL",
+
+	ClaimEmailsSQL:               "ClaimEmailsSQL",
+	MarkEmailSentSQL:             "MarkEmailSentSQL",
+	MarkEmailFailedSQL:           "MarkEmailFailedSQL",
+	DropEmailSQL:                 "DropEmailSQL",
+	QueueOutageResolvedEmailsSQL: "QueueOutageResolvedEmailsSQL",
+	UnsubscribeEmailSQL:          "UnsubscribeEmailSQL",
+	IsEmailUnsubscribedSQL:       "IsEmailUnsubscribedSQL",
+
+	TakeRateLimitTokenSQL:    "TakeRateLimitTokenSQL",
+	GetRateLimitTokensSQL:    "GetRateLimitTokensSQL",
+	RefundRateLimitTokenSQL:  "RefundRateLimitTokenSQL",
+	PruneRateLimitBucketsSQL: "PruneRateLimitBucketsSQL",
+}
+
 //Feedback represents a user feedback record
 type Feedback struct {
 	ID         string
@@ -33,12 +157,96 @@ type Feedback struct {
 	Message    *string
 	Value      *string
 	Email      *string
+
+	//Line is the transit line the rider reported on, if any
+	Line *string
+
+	//Details holds the JSON object described by the kind's details schema,
+	//and is nil when none was given
+	Details []byte
+
+	//AttachmentIDs lists uploaded attachments to link to the feedback when
+	//it is saved. It is not read back by list queries.
+	AttachmentIDs []string
+
+	//Location is where the rider's device was, if it shared its location
+	Location *Location
+
+	//Client describes the app and device the feedback was sent from
+	Client ClientInfo
+
+	//EmailOptIn is set when the rider agreed to be emailed at Email.
+	//Acknowledge queues an acknowledgement email when the feedback is saved,
+	//and is not read back.
+	EmailOptIn  bool
+	Acknowledge bool
+
+	//Triage is where staff are with the feedback. It is set by UpdateTriage
+	//and ignored by SaveFeedback.
+	Triage Triage
+
+	//ModerationStatus is one of the Moderation* constants. Only approved
+	//feedback is included in health reports.
+	ModerationStatus string
+	SpamScore        float64
+	SpamReason       *string
+
+	//Message holds the redacted text. MessageOriginal is only written when
+	//the unredacted text is retained, and is never read by list queries.
+	MessageOriginal *string
+	RedactedPII     *string
+}
+
+//Location is a position reported by a rider's device, in WGS 84 degrees.
+//Accuracy is the radius of uncertainty in meters, if known. The Stop fields
+//are set when a stop was found near enough, with StopDistance in meters.
+type Location struct {
+	Latitude  float64
+	Longitude float64
+	Accuracy  *float64
+
+	StopID       *string
+	StopName     *string
+	StopDistance *float64
+}
+
+//locationParams returns the values of the location columns, which are all
+//NULL when l is nil
+func locationParams(l *Location) []interface{} {
+	if l == nil {
+		return []interface{}{nil, nil, nil, nil, nil, nil}
+	}
+	return []interface{}{l.Latitude, l.Longitude, l.Accuracy, l.StopID, l.StopName, l.StopDistance}
+}
+
+//ClientInfo describes the app and device a feedback was sent from. Each
+//field is nil when it wasn't reported.
+type ClientInfo struct {
+	AppVersion *string
+	Platform   *string
+	OSVersion  *string
+	Locale     *string
 }
 
+//Moderation statuses
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+//ErrNotFound is returned when an operation targets a record that doesn't exist
+var ErrNotFound = errors.New("not found")
+
+//ErrAlreadyExists is returned when saving something that may only be saved
+//once, such as a survey response, a second time
+var ErrAlreadyExists = errors.New("already exists")
+
 //Client implements DB
 type Client struct {
 	db       DBDriver
 	migrator Migrator
+	cipher   EmailCipher
 }
 
 //New returns a new Client with the speficied dependencies
@@ -56,8 +264,70 @@ func New(
 //go:generate counterfeiter . DB
 type DB interface {
 	Migrate(ctx context.Context) error
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	SaveFeedback(ctx context.Context, fb Feedback) error
 	GetRecentOutages(ctx context.Context, since time.Time) ([]Feedback, error)
+	CountDuplicateMessages(ctx context.Context, sessionID, message string, since time.Time) (int, error)
+	GetOriginalMessage(ctx context.Context, id string) (*string, error)
+	ListFeedbackByModerationStatus(ctx context.Context, status string, limit, offset int) ([]Feedback, error)
+	ModerateFeedback(ctx context.Context, decision ModerationDecision) (string, error)
+	SilenceFeedback(ctx context.Context, feedbackID string, silenced bool) (bool, error)
+	GetModerationDecisions(ctx context.Context, feedbackID string) ([]ModerationDecision, error)
+	TakeRateLimitToken(ctx context.Context, key string, capacity, refillPerSecond float64) (bool, float64, error)
+	RefundRateLimitToken(ctx context.Context, key string, capacity, refillPerSecond float64) error
+	PruneRateLimitBuckets(ctx context.Context, before time.Time) (int, error)
+	ListFeedbackByEmail(ctx context.Context, email string, limit, offset int) ([]Feedback, error)
+	ListFeedback(ctx context.Context, filter FeedbackFilter, limit, offset int) ([]Feedback, error)
+	CountFeedbackByClient(ctx context.Context, breakdown ClientBreakdown, limit, offset int) ([]ClientCount, error)
+	ListEmailsToRotate(ctx context.Context, keyID string, limit int) ([]StoredEmail, error)
+	UpdateEmail(ctx context.Context, feedbackID, email, emailIndex string) error
+	FindRiderFeedback(ctx context.Context, subject RiderSubject) ([]Feedback, error)
+	FindRiderAttachments(ctx context.Context, subject RiderSubject) ([]Attachment, error)
+	FindRiderSurveyResponses(ctx context.Context, subject RiderSubject) ([]SurveyResponse, error)
+	FindRiderMessages(ctx context.Context, subject RiderSubject) ([]FeedbackMessage, error)
+	DeleteRiderFeedback(ctx context.Context, request DataRequest) (int, error)
+	AnonymizeRiderFeedback(ctx context.Context, request DataRequest) (int, error)
+	RecordDataRequest(ctx context.Context, request DataRequest) error
+	GetRetentionPolicies(ctx context.Context) ([]RetentionPolicy, error)
+	SetRetentionPolicy(ctx context.Context, policy RetentionPolicy) error
+	DeleteRetentionPolicy(ctx context.Context, kind string) error
+	CountExpiredFeedback(ctx context.Context, kind, action string, before time.Time) (int, error)
+	PurgeExpiredFeedback(ctx context.Context, kind, action string, before time.Time, limit int) (int, error)
+	CreateFeedbackPartition(ctx context.Context, month time.Time) error
+	DetachFeedbackPartition(ctx context.Context, month time.Time) error
+	StreamFeedbackPartition(ctx context.Context, month time.Time, w io.Writer) (int, error)
+	DropFeedbackPartition(ctx context.Context, month time.Time) error
+	GetLastAuditEvent(ctx context.Context) (int64, string, error)
+	AppendAuditEvent(ctx context.Context, event AuditEvent) (bool, error)
+	ListAuditEvents(ctx context.Context, filter AuditFilter, limit, offset int) ([]AuditEvent, error)
+	ListAuditEventsAfter(ctx context.Context, seq int64, limit int) ([]AuditEvent, error)
+	ListFeedbackKinds(ctx context.Context) ([]FeedbackKind, error)
+	ListFeedbackValues(ctx context.Context) ([]FeedbackValue, error)
+	CreateSurveyVersion(ctx context.Context, version SurveyVersion) (int, error)
+	SetActiveSurveyVersion(ctx context.Context, name string, version *int) error
+	GetSurveyVersion(ctx context.Context, name string, version *int) (SurveyVersion, error)
+	SaveSurveyResponse(ctx context.Context, response SurveyResponse) error
+	CountSurveyAnswers(ctx context.Context, name string, version int) ([]SurveyAnswerCount, error)
+	SaveAttachment(ctx context.Context, attachment Attachment) (Attachment, error)
+	GetAttachments(ctx context.Context, ids []string) ([]Attachment, error)
+	ListFeedbackAttachments(ctx context.Context, feedbackID string) ([]Attachment, error)
+	ListDiscardedAttachments(ctx context.Context, unattachedBefore time.Time, limit int) ([]Attachment, error)
+	DeleteAttachment(ctx context.Context, id string) error
+	SaveFeedbackMessage(ctx context.Context, message FeedbackMessage, queueEmail bool) (FeedbackMessage, bool, error)
+	ListFeedbackMessages(ctx context.Context, feedbackID string, sessionID *string) ([]FeedbackMessage, error)
+	MarkFeedbackMessagesRead(ctx context.Context, feedbackID, author string, sessionID *string) error
+	ListFeedbackThreads(ctx context.Context, filter ThreadFilter, limit, offset int) ([]FeedbackThread, error)
+	ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]QueuedEmail, error)
+	MarkEmailSent(ctx context.Context, id int64) error
+	MarkEmailFailed(ctx context.Context, id int64, reason string, retryAt *time.Time) error
+	DropEmail(ctx context.Context, id int64, reason string) error
+	QueueOutageResolvedEmails(ctx context.Context, line *string, since time.Time) (int, error)
+	UnsubscribeEmail(ctx context.Context, digest string) error
+	IsEmailUnsubscribed(ctx context.Context, digest string) (bool, error)
+	UpdateTriage(ctx context.Context, update TriageUpdate) ([]TriageChange, error)
+	GetTriageTransitions(ctx context.Context, feedbackID string) ([]TriageTransition, error)
+	SearchFeedback(ctx context.Context, query string, filter FeedbackFilter, limit, offset int) ([]SearchResult, error)
+	SimilarFeedback(ctx context.Context, id string, limit, offset int) ([]SimilarFeedback, error)
 }
 
 //Migrate runs any pending migrations
@@ -71,9 +341,22 @@ func (c Client) Migrate(ctx context.Context) error {
 
 //SaveFeedback saves a single new feedback record
 func (c Client) SaveFeedback(ctx context.Context, fb Feedback) error {
-	_, err := c.db.ExecContext(ctx, SaveFeedbackSQL,
-		fb.SessionID, fb.Role, fb.Kind, fb.Message, fb.Value, fb.Email,
-	)
+	email, emailIndex, err := c.encryptEmail(fb.Email)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{
+		fb.SessionID, fb.Role, fb.Kind, fb.Message, fb.Value, email,
+		fb.Silenced, fb.ModerationStatus, fb.SpamScore, fb.SpamReason,
+		fb.MessageOriginal, fb.RedactedPII, fb.Line, emailIndex, jsonParam(fb.Details),
+		pq.Array(nonNilStrings(fb.AttachmentIDs)),
+	}
+	args = append(args, locationParams(fb.Location)...)
+	args = append(args, fb.Client.AppVersion, fb.Client.Platform, fb.Client.OSVersion, fb.Client.Locale)
+	args = append(args, fb.EmailOptIn, fb.Acknowledge)
+
+	_, err = c.db.ExecContext(ctx, SaveFeedbackSQL, args...)
 	if err != nil {
 		return fmt.Errorf("failed saving feedback: %w", err)
 	}
@@ -99,6 +382,7 @@ func (c Client) GetRecentOutages(ctx context.Context, since time.Time) ([]Feedba
 			&fb.Message,
 			&fb.ReceivedAt,
 			&fb.Silenced,
+			&fb.Line,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed scanning feedback results: %w", err)
@@ -110,6 +394,131 @@ func (c Client) GetRecentOutages(ctx context.Context, since time.Time) ([]Feedba
 	return result, nil
 }
 
+//CountDuplicateMessages counts feedback from sessionID since `since` whose
+//message matches message, ignoring case
+func (c Client) CountDuplicateMessages(ctx context.Context, sessionID, message string, since time.Time) (int, error) {
+	rows, err := c.db.QueryContext(ctx, CountDuplicateMessagesSQL, sessionID, since, message)
+	if err != nil {
+		return 0, fmt.Errorf("failed counting duplicate messages: %w", err)
+	}
+	defer rows.Close()
+
+	var count int
+	if rows.Next() {
+		if err = rows.Scan(&count); err != nil {
+			return 0, fmt.Errorf("failed scanning duplicate message count: %w", err)
+		}
+	}
+
+	return count, nil
+}
+
+//GetOriginalMessage returns the unredacted message of a feedback, which is
+//nil if none was retained. It returns ErrNotFound if the feedback doesn't exist.
+func (c Client) GetOriginalMessage(ctx context.Context, id string) (*string, error) {
+	rows, err := c.db.QueryContext(ctx, GetOriginalMessageSQL, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting original message: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	var msg *string
+	if err = rows.Scan(&msg); err != nil {
+		return nil, fmt.Errorf("failed scanning original message: %w", err)
+	}
+
+	return msg, nil
+}
+
+//feedbackColumns lists the columns read by scanFeedbacks, in order
+const feedbackColumns = `id, session_id, role, kind, value, message, email,
+  received_moment, silenced, moderation_status, spam_score, spam_reason, redacted_pii, line,
+  details, latitude, longitude, location_accuracy, stop_id, stop_name, stop_distance,
+  app_version, platform, os_version, locale, email_opt_in_moment IS NOT NULL,
+  triage_status, assignee, priority, tags, triaged_moment`
+
+//scanFeedbacks reads every row of a query selecting feedbackColumns,
+//decrypting emails
+func (c Client) scanFeedbacks(rows *sql.Rows) ([]Feedback, error) {
+	defer rows.Close()
+
+	result := []Feedback{}
+	for rows.Next() {
+		fb, err := c.scanFeedback(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, fb)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading feedback results: %w", err)
+	}
+
+	return result, nil
+}
+
+//scanFeedback reads the current row of a query selecting feedbackColumns
+//followed by a column for each of extra, decrypting the email
+func (c Client) scanFeedback(rows *sql.Rows, extra ...interface{}) (Feedback, error) {
+	var fb Feedback
+	var latitude, longitude *float64
+	var loc Location
+	dest := []interface{}{
+		&fb.ID,
+		&fb.SessionID,
+		&fb.Role,
+		&fb.Kind,
+		&fb.Value,
+		&fb.Message,
+		&fb.Email,
+		&fb.ReceivedAt,
+		&fb.Silenced,
+		&fb.ModerationStatus,
+		&fb.SpamScore,
+		&fb.SpamReason,
+		&fb.RedactedPII,
+		&fb.Line,
+		&fb.Details,
+		&latitude,
+		&longitude,
+		&loc.Accuracy,
+		&loc.StopID,
+		&loc.StopName,
+		&loc.StopDistance,
+		&fb.Client.AppVersion,
+		&fb.Client.Platform,
+		&fb.Client.OSVersion,
+		&fb.Client.Locale,
+		&fb.EmailOptIn,
+		&fb.Triage.Status,
+		&fb.Triage.Assignee,
+		&fb.Triage.Priority,
+		pq.Array(&fb.Triage.Tags),
+		&fb.Triage.TriagedAt,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return Feedback{}, fmt.Errorf("failed scanning feedback results: %w", err)
+	}
+
+	if latitude != nil && longitude != nil {
+		loc.Latitude, loc.Longitude = *latitude, *longitude
+		fb.Location = &loc
+	}
+
+	var err error
+	if fb.Email, err = c.decryptEmail(fb.Email); err != nil {
+		return Feedback{}, err
+	}
+
+	return fb, nil
+}
+
 //Migrator is for generating fakes
 //go:generate counterfeiter . Migrator
 type Migrator interface {
@@ -121,4 +530,5 @@ type Migrator interface {
 type DBDriver interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	BeginTx(ctx context.Context) (context.Context, Tx, error)
 }
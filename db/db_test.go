@@ -2,7 +2,12 @@ package db_test
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
+	"io/ioutil"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/smartatransit/feedback/db"
 	"github.com/smartatransit/feedback/db/dbfakes"
@@ -54,6 +59,56 @@ var _ = Describe("DB", func() {
 		})
 	})
 
+	Describe("InTransaction", func() {
+		var (
+			tx    *dbfakes.FakeTx
+			txCtx context.Context
+		)
+
+		BeforeEach(func() {
+			tx = &dbfakes.FakeTx{}
+			txCtx = context.WithValue(context.Background(), struct{}{}, "tx")
+			database.BeginTxReturns(txCtx, tx, nil)
+		})
+
+		It("runs fn in the transaction and commits it", func() {
+			var nested context.Context
+			err := client.InTransaction(context.Background(), func(ctx context.Context) error {
+				Expect(ctx.Value(struct{}{})).To(Equal("tx"))
+				return client.InTransaction(ctx, func(ctx context.Context) error {
+					nested = ctx
+					return nil
+				})
+			})
+			Expect(err).To(BeNil())
+			Expect(nested.Value(struct{}{})).To(Equal("tx"))
+			Expect(database.BeginTxCallCount()).To(Equal(1))
+			Expect(tx.CommitCallCount()).To(Equal(1))
+			Expect(tx.RollbackCallCount()).To(Equal(0))
+		})
+
+		When("fn fails", func() {
+			It("rolls back and returns its error", func() {
+				err := client.InTransaction(context.Background(), func(ctx context.Context) error {
+					return errors.New("audit failed")
+				})
+				Expect(err).To(MatchError("audit failed"))
+				Expect(tx.RollbackCallCount()).To(Equal(1))
+				Expect(tx.CommitCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the commit fails", func() {
+			It("returns an error", func() {
+				tx.CommitReturns(errors.New("serialization failure"))
+				err := client.InTransaction(context.Background(), func(ctx context.Context) error {
+					return nil
+				})
+				Expect(err).To(MatchError("failed committing transaction: serialization failure"))
+			})
+		})
+	})
+
 	Describe("SaveFeedback", func() {
 		var callErr error
 		JustBeforeEach(func() {
@@ -74,4 +129,621 @@ var _ = Describe("DB", func() {
 			})
 		})
 	})
+
+	Describe("SaveFeedback with an email cipher", func() {
+		var (
+			cipher  *dbfakes.FakeEmailCipher
+			callErr error
+		)
+		BeforeEach(func() {
+			cipher = &dbfakes.FakeEmailCipher{}
+			cipher.EncryptReturns("k1:sealed", nil)
+			cipher.BlindIndexReturns("index")
+		})
+		JustBeforeEach(func() {
+			email := "rider@example.com"
+			callErr = client.WithEmailCipher(cipher).SaveFeedback(context.Background(), db.Feedback{Email: &email})
+		})
+
+		When("encryption fails", func() {
+			BeforeEach(func() {
+				cipher.EncryptReturns("", errors.New("no entropy"))
+			})
+			It("returns an error", func() {
+				Expect(callErr).To(MatchError("failed encrypting email: no entropy"))
+				Expect(database.ExecContextCallCount()).To(Equal(0))
+			})
+		})
+		When("all goes well", func() {
+			It("stores the ciphertext and blind index", func() {
+				Expect(callErr).To(BeNil())
+				Expect(cipher.EncryptArgsForCall(0)).To(Equal("rider@example.com"))
+
+				_, _, args := database.ExecContextArgsForCall(0)
+				Expect(*args[5].(*string)).To(Equal("k1:sealed"))
+				Expect(*args[13].(*string)).To(Equal("index"))
+			})
+		})
+	})
+
+	Describe("ListFeedbackByEmail", func() {
+		var callErr error
+		JustBeforeEach(func() {
+			cipher := &dbfakes.FakeEmailCipher{}
+			cipher.BlindIndexReturns("index")
+			_, callErr = client.WithEmailCipher(cipher).ListFeedbackByEmail(context.Background(), "rider@example.com", 10, 0)
+		})
+
+		When("it fails", func() {
+			BeforeEach(func() {
+				database.QueryContextReturns(nil, errors.New("select failed"))
+			})
+			It("looks up by blind index and returns an error", func() {
+				Expect(callErr).To(MatchError("failed listing feedback by email: select failed"))
+
+				_, query, args := database.QueryContextArgsForCall(0)
+				Expect(query).To(Equal(db.ListFeedbackByEmailSQL))
+				Expect(args).To(Equal([]interface{}{"index", "rider@example.com", 10, 0}))
+			})
+		})
+	})
+
+	Describe("ListFeedback", func() {
+		It("passes the details as text and returns an error when it fails", func() {
+			database.QueryContextReturns(nil, errors.New("select failed"))
+			kind := "service_condition"
+			_, err := client.ListFeedback(context.Background(), db.FeedbackFilter{
+				Kind:    &kind,
+				Details: []byte(`{"crowding":"full"}`),
+			}, 10, 0)
+			Expect(err).To(MatchError("failed listing feedback: select failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.ListFeedbackSQL))
+			Expect(args[:2]).To(Equal([]interface{}{&kind, `{"crowding":"full"}`}))
+			Expect(args[2:6]).To(Equal([]interface{}{(*float64)(nil), (*float64)(nil), (*float64)(nil), (*float64)(nil)}))
+			Expect(args[6:8]).To(Equal([]interface{}{10, 0}))
+			Expect(args[8:12]).To(Equal([]interface{}{(*string)(nil), (*string)(nil), false, (*string)(nil)}))
+			Expect(args[12]).To(Equal(pq.Array([]string{})))
+		})
+
+		It("passes the corners of the area", func() {
+			database.QueryContextReturns(nil, errors.New("select failed"))
+			_, _ = client.ListFeedback(context.Background(), db.FeedbackFilter{
+				Area: &db.BoundingBox{MinLatitude: 33.7, MinLongitude: -84.4, MaxLatitude: 33.8, MaxLongitude: -84.3},
+			}, 10, 0)
+
+			_, _, args := database.QueryContextArgsForCall(0)
+			Expect(args[0]).To(BeNil())
+			Expect(*args[2].(*float64)).To(Equal(33.7))
+			Expect(*args[3].(*float64)).To(Equal(-84.4))
+			Expect(*args[4].(*float64)).To(Equal(33.8))
+			Expect(*args[5].(*float64)).To(Equal(-84.3))
+		})
+	})
+
+	Describe("SaveFeedback with attachments", func() {
+		It("passes the attachment IDs as an array, never NULL", func() {
+			Expect(client.SaveFeedback(context.Background(), db.Feedback{})).To(Succeed())
+			Expect(client.SaveFeedback(context.Background(), db.Feedback{AttachmentIDs: []string{"a1", "a2"}})).To(Succeed())
+
+			_, _, args := database.ExecContextArgsForCall(0)
+			Expect(args[15]).To(Equal(pq.Array([]string{})))
+			_, _, args = database.ExecContextArgsForCall(1)
+			Expect(args[15]).To(Equal(pq.Array([]string{"a1", "a2"})))
+		})
+	})
+
+	Describe("SaveFeedback with a location", func() {
+		It("passes the raw and resolved location", func() {
+			accuracy, stopID, stopName, distance := 12.5, "PEACHTREE", "PEACHTREE CENTER STATION", 40.0
+			Expect(client.SaveFeedback(context.Background(), db.Feedback{Location: &db.Location{
+				Latitude:     33.7597,
+				Longitude:    -84.3874,
+				Accuracy:     &accuracy,
+				StopID:       &stopID,
+				StopName:     &stopName,
+				StopDistance: &distance,
+			}})).To(Succeed())
+			Expect(client.SaveFeedback(context.Background(), db.Feedback{})).To(Succeed())
+
+			_, _, args := database.ExecContextArgsForCall(0)
+			Expect(args[16:22]).To(Equal([]interface{}{33.7597, -84.3874, &accuracy, &stopID, &stopName, &distance}))
+			_, _, args = database.ExecContextArgsForCall(1)
+			Expect(args[16:22]).To(Equal([]interface{}{nil, nil, nil, nil, nil, nil}))
+		})
+	})
+
+	Describe("SaveFeedback with client metadata", func() {
+		It("passes each field, or NULL when it wasn't reported", func() {
+			version, platform := "4.2.0", "ios"
+			Expect(client.SaveFeedback(context.Background(), db.Feedback{Client: db.ClientInfo{
+				AppVersion: &version,
+				Platform:   &platform,
+			}})).To(Succeed())
+
+			_, _, args := database.ExecContextArgsForCall(0)
+			Expect(args[22:26]).To(Equal([]interface{}{&version, &platform, (*string)(nil), (*string)(nil)}))
+		})
+	})
+
+	Describe("SaveFeedback with an email opt-in", func() {
+		It("passes the opt-in and whether to acknowledge", func() {
+			Expect(client.SaveFeedback(context.Background(), db.Feedback{EmailOptIn: true, Acknowledge: true})).To(Succeed())
+			Expect(client.SaveFeedback(context.Background(), db.Feedback{})).To(Succeed())
+
+			_, _, args := database.ExecContextArgsForCall(0)
+			Expect(args[26:]).To(Equal([]interface{}{true, true}))
+			_, _, args = database.ExecContextArgsForCall(1)
+			Expect(args[26:]).To(Equal([]interface{}{false, false}))
+		})
+	})
+
+	Describe("MarkEmailFailed", func() {
+		It("passes when to retry", func() {
+			retryAt := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+			Expect(client.MarkEmailFailed(context.Background(), 7, "421 try later", &retryAt)).To(Succeed())
+
+			_, query, args := database.ExecContextArgsForCall(0)
+			Expect(query).To(Equal(db.MarkEmailFailedSQL))
+			Expect(args).To(Equal([]interface{}{int64(7), "421 try later", &retryAt}))
+		})
+	})
+
+	Describe("UpdateTriage", func() {
+		It("passes NULL for tags that aren't replaced and empty arrays for those not added or removed", func() {
+			database.QueryContextReturns(nil, errors.New("update failed"))
+			status := "resolved"
+			_, err := client.UpdateTriage(context.Background(), db.TriageUpdate{
+				FeedbackIDs:  []string{"f1", "f2"},
+				Status:       &status,
+				AddTags:      []string{"elevator"},
+				ActorSession: "session",
+				ActorRole:    "admin",
+				MaxTags:      20,
+			})
+			Expect(err).To(MatchError("failed updating triage: update failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.UpdateTriageSQL))
+			Expect(args[0]).To(Equal(pq.Array([]string{"f1", "f2"})))
+			Expect(args[1:5]).To(Equal([]interface{}{&status, (*string)(nil), (*string)(nil), nil}))
+			Expect(args[5:7]).To(Equal([]interface{}{pq.Array([]string{"elevator"}), pq.Array([]string{})}))
+			Expect(args[7:]).To(Equal([]interface{}{(*string)(nil), "session", "admin", 20}))
+		})
+	})
+
+	Describe("SearchQuery", func() {
+		It("turns words, phrases, prefixes, exclusions and alternatives into a text search query", func() {
+			for search, expected := range map[string]string{
+				"elevator":                     "'elevator'",
+				`elevator "Five Points"`:       "'elevator' & ('Five' <-> 'Points')",
+				"elev* -escalator":             "'elev':* & !'escalator'",
+				"wi-fi OR wifi broken":         "(('wi' <-> 'fi') | 'wifi') & 'broken'",
+				"broken wifi OR wlan OR net*":  "'broken' & ('wifi' | 'wlan' | 'net':*)",
+				"-late OR delayed train":       "(!'late' | 'delayed') & 'train'",
+				`"five poi*`:                   "('five' <-> 'poi':*)",
+				"'); DROP TABLE feedbacks; --": "'DROP' & 'TABLE' & 'feedbacks'",
+				"OR train":                     "'train'",
+				"caf\u00e9 St\u00f6rung":       "'caf\u00e9' & 'St\u00f6rung'",
+			} {
+				query, err := db.SearchQuery(search)
+				Expect(err).To(BeNil(), search)
+				Expect(query).To(Equal(expected), search)
+			}
+		})
+
+		It("fails for searches without words to look for", func() {
+			for _, search := range []string{"", "  ", "-elevator", `"" * -`, "OR"} {
+				_, err := db.SearchQuery(search)
+				Expect(err).To(MatchError(db.ErrEmptySearch), search)
+			}
+		})
+	})
+
+	Describe("SearchFeedback", func() {
+		It("passes the filter, then the query and snippet options", func() {
+			database.QueryContextReturns(nil, errors.New("select failed"))
+			_, err := client.SearchFeedback(context.Background(), "'elevator'", db.FeedbackFilter{}, 10, 0)
+			Expect(err).To(MatchError("failed searching feedback: select failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.SearchFeedbackSQL))
+			Expect(args).To(HaveLen(15))
+			Expect(args[6:8]).To(Equal([]interface{}{10, 0}))
+			Expect(args[13]).To(Equal("'elevator'"))
+			Expect(args[14]).To(ContainSubstring(`StartSel="` + db.HighlightStart + `"`))
+		})
+	})
+
+	Describe("SimilarFeedback", func() {
+		It("returns an error if the message can't be read", func() {
+			database.QueryContextReturns(nil, errors.New("select failed"))
+			_, err := client.SimilarFeedback(context.Background(), "f1", 10, 0)
+			Expect(err).To(MatchError("failed getting feedback message: select failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.GetFeedbackMessageSQL))
+			Expect(args).To(Equal([]interface{}{"f1"}))
+		})
+	})
+
+	Describe("QueueOutageResolvedEmails", func() {
+		It("returns how many were queued", func() {
+			database.ExecContextReturns(driver.RowsAffected(3), nil)
+			line := "red"
+			since := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+			Expect(client.QueueOutageResolvedEmails(context.Background(), &line, since)).To(Equal(3))
+
+			_, query, args := database.ExecContextArgsForCall(0)
+			Expect(query).To(Equal(db.QueueOutageResolvedEmailsSQL))
+			Expect(args).To(Equal([]interface{}{&line, since}))
+		})
+	})
+
+	Describe("CountFeedbackByClient", func() {
+		It("passes the selected fields and bounds, and returns an error when it fails", func() {
+			database.QueryContextReturns(nil, errors.New("select failed"))
+			kind := "comment"
+			since := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+			until := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+			_, err := client.CountFeedbackByClient(context.Background(), db.ClientBreakdown{
+				ByPlatform: true,
+				ByLocale:   true,
+				Kind:       &kind,
+				Since:      since,
+				Until:      until,
+			}, 50, 0)
+			Expect(err).To(MatchError("failed counting feedback by client: select failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.CountFeedbackByClientSQL))
+			Expect(args).To(Equal([]interface{}{false, true, false, true, since, until, &kind, 50, 0}))
+		})
+	})
+
+	Describe("SaveFeedbackMessage", func() {
+		It("passes the message and whether to queue an email", func() {
+			database.QueryContextReturns(nil, errors.New("insert failed"))
+			_, _, err := client.SaveFeedbackMessage(context.Background(), db.FeedbackMessage{
+				FeedbackID:    "feedback-id",
+				Author:        db.AuthorStaff,
+				AuthorSession: "session",
+				AuthorRole:    "admin",
+				Body:          "Which station?",
+			}, true)
+			Expect(err).To(MatchError("failed saving feedback message: insert failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.SaveFeedbackMessageSQL))
+			Expect(args).To(Equal([]interface{}{"feedback-id", "staff", "session", "admin", "Which station?", true}))
+		})
+	})
+
+	Describe("ListFeedbackThreads", func() {
+		It("passes the filter", func() {
+			database.QueryContextReturns(nil, errors.New("select failed"))
+			session := "session"
+			_, err := client.ListFeedbackThreads(context.Background(), db.ThreadFilter{
+				SessionID:  &session,
+				Reader:     db.AuthorRider,
+				UnreadOnly: true,
+			}, 50, 0)
+			Expect(err).To(MatchError("failed listing feedback threads: select failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.ListFeedbackThreadsSQL))
+			Expect(args).To(Equal([]interface{}{&session, "rider", true, 50, 0}))
+		})
+	})
+
+	Describe("ListDiscardedAttachments", func() {
+		It("returns an error when it fails", func() {
+			database.QueryContextReturns(nil, errors.New("select failed"))
+			before := time.Date(2020, 6, 14, 12, 0, 0, 0, time.UTC)
+			_, err := client.ListDiscardedAttachments(context.Background(), before, 100)
+			Expect(err).To(MatchError("failed listing discarded attachments: select failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.ListDiscardedAttachmentsSQL))
+			Expect(args).To(Equal([]interface{}{before, 100}))
+		})
+	})
+
+	Describe("SaveSurveyResponse", func() {
+		It("passes the answers as JSON text and returns an error when it fails", func() {
+			database.QueryContextReturns(nil, errors.New("insert failed"))
+			four := 4
+			err := client.SaveSurveyResponse(context.Background(), db.SurveyResponse{
+				SurveyName: "trip",
+				Version:    2,
+				SessionID:  "session",
+				Role:       "anonymous",
+				Answers: []db.SurveyAnswer{
+					{QuestionID: "trip", Number: &four},
+					{QuestionID: "improve", Choices: []string{"safety"}},
+				},
+			})
+			Expect(err).To(MatchError("failed saving survey response: insert failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.SaveSurveyResponseSQL))
+			Expect(args[:4]).To(Equal([]interface{}{"trip", 2, "session", "anonymous"}))
+			Expect(args[4]).To(MatchJSON(`[
+				{"question_id": "trip", "number_value": 4},
+				{"question_id": "improve", "choices": ["safety"]}
+			]`))
+		})
+	})
+
+	Describe("UpdateEmail", func() {
+		It("returns an error when it fails", func() {
+			database.ExecContextReturns(nil, errors.New("update failed"))
+			err := db.New(database, migrator).UpdateEmail(context.Background(), "fb", "k2:sealed", "index")
+			Expect(err).To(MatchError("failed updating email: update failed"))
+		})
+	})
+
+	Describe("DeleteRiderFeedback", func() {
+		var callErr error
+		JustBeforeEach(func() {
+			_, callErr = client.DeleteRiderFeedback(context.Background(), db.DataRequest{
+				Action:  db.DataRequestDelete,
+				Subject: db.RiderSubject{SessionID: "rider"},
+			})
+		})
+
+		When("it fails", func() {
+			BeforeEach(func() {
+				database.QueryContextReturns(nil, errors.New("delete failed"))
+			})
+			It("matches on the session and returns an error", func() {
+				Expect(callErr).To(MatchError("failed applying delete request: delete failed"))
+
+				_, query, args := database.QueryContextArgsForCall(0)
+				Expect(query).To(ContainSubstring("DELETE FROM survey_responses"))
+				Expect(args[:4]).To(Equal([]interface{}{"rider", nil, nil, "session"}))
+			})
+		})
+	})
+
+	Describe("FindRiderAttachments", func() {
+		It("matches on the session and returns an error when it fails", func() {
+			database.QueryContextReturns(nil, errors.New("select failed"))
+			_, err := client.FindRiderAttachments(context.Background(), db.RiderSubject{SessionID: "rider"})
+			Expect(err).To(MatchError("failed finding rider attachments: select failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.FindRiderAttachmentsSQL))
+			Expect(args).To(Equal([]interface{}{"rider", nil, nil}))
+		})
+	})
+
+	Describe("FindRiderSurveyResponses", func() {
+		It("matches on the email and returns an error when it fails", func() {
+			database.QueryContextReturns(nil, errors.New("select failed"))
+			_, err := client.FindRiderSurveyResponses(context.Background(), db.RiderSubject{Email: "rider@example.com"})
+			Expect(err).To(MatchError("failed finding rider survey responses: select failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.FindRiderSurveyResponsesSQL))
+			Expect(args).To(Equal([]interface{}{nil, nil, "rider@example.com"}))
+		})
+	})
+
+	Describe("FindRiderMessages", func() {
+		It("matches on the session and returns an error when it fails", func() {
+			database.QueryContextReturns(nil, errors.New("select failed"))
+			_, err := client.FindRiderMessages(context.Background(), db.RiderSubject{SessionID: "rider"})
+			Expect(err).To(MatchError("failed finding rider messages: select failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.FindRiderMessagesSQL))
+			Expect(args).To(Equal([]interface{}{"rider", nil, nil}))
+		})
+	})
+
+	Describe("RecordDataRequest", func() {
+		It("returns an error when it fails", func() {
+			database.ExecContextReturns(nil, errors.New("insert failed"))
+			err := db.New(database, migrator).RecordDataRequest(context.Background(), db.DataRequest{
+				Action:  db.DataRequestExport,
+				Subject: db.RiderSubject{Email: "rider@example.com"},
+			})
+			Expect(err).To(MatchError("failed recording export request: insert failed"))
+		})
+	})
+
+	Describe("RiderSubject", func() {
+		It("digests identifiers without keeping them", func() {
+			a := db.RiderSubject{Email: "Rider@Example.com"}
+			Expect(a.Type()).To(Equal("email"))
+			Expect(a.Digest()).To(Equal(db.RiderSubject{Email: "rider@example.com"}.Digest()))
+			Expect(a.Digest()).NotTo(ContainSubstring("rider"))
+			Expect(a.Digest()).NotTo(Equal(db.RiderSubject{SessionID: "rider@example.com"}.Digest()))
+		})
+	})
+
+	Describe("PurgeExpiredFeedback", func() {
+		var callErr error
+		JustBeforeEach(func() {
+			_, callErr = client.PurgeExpiredFeedback(context.Background(), "comment", db.RetentionAnonymize, time.Now(), 100)
+		})
+
+		When("it fails", func() {
+			BeforeEach(func() {
+				database.ExecContextReturns(nil, errors.New("update failed"))
+			})
+			It("anonymizes and returns an error", func() {
+				Expect(callErr).To(MatchError("failed purging expired feedback: update failed"))
+
+				_, query, _ := database.ExecContextArgsForCall(0)
+				Expect(query).To(Equal(db.AnonymizeExpiredFeedbackSQL))
+			})
+		})
+	})
+
+	Describe("CreateFeedbackPartition", func() {
+		It("passes the first day of the month as a date", func() {
+			err := client.CreateFeedbackPartition(context.Background(), time.Date(2020, 3, 31, 23, 0, 0, 0, time.UTC))
+			Expect(err).To(BeNil())
+
+			_, _, args := database.ExecContextArgsForCall(0)
+			Expect(args).To(Equal([]interface{}{"2020-03-01"}))
+		})
+		It("returns an error when it fails", func() {
+			database.ExecContextReturns(nil, errors.New("create failed"))
+			err := client.CreateFeedbackPartition(context.Background(), time.Now())
+			Expect(err).To(MatchError("failed creating feedback partition: create failed"))
+		})
+	})
+
+	Describe("TakeRateLimitToken", func() {
+		var callErr error
+		JustBeforeEach(func() {
+			_, _, callErr = client.TakeRateLimitToken(context.Background(), "k", 5, 0.1)
+		})
+
+		When("it fails", func() {
+			BeforeEach(func() {
+				database.QueryContextReturns(nil, errors.New("upsert failed"))
+			})
+			It("returns an error", func() {
+				Expect(callErr).To(MatchError("failed taking rate limit token: upsert failed"))
+			})
+		})
+	})
+
+	Describe("PruneRateLimitBuckets", func() {
+		It("returns how many buckets were deleted", func() {
+			database.ExecContextReturns(driver.RowsAffected(7), nil)
+			before := time.Date(2020, 6, 14, 12, 0, 0, 0, time.UTC)
+			pruned, err := client.PruneRateLimitBuckets(context.Background(), before)
+			Expect(err).To(BeNil())
+			Expect(pruned).To(Equal(7))
+
+			_, query, args := database.ExecContextArgsForCall(0)
+			Expect(query).To(Equal(db.PruneRateLimitBucketsSQL))
+			Expect(args).To(Equal([]interface{}{before}))
+		})
+	})
+
+	Describe("CountDuplicateMessages", func() {
+		var callErr error
+		JustBeforeEach(func() {
+			_, callErr = client.CountDuplicateMessages(context.Background(), "sess", "hi", time.Now())
+		})
+
+		When("it fails", func() {
+			BeforeEach(func() {
+				database.QueryContextReturns(nil, errors.New("select failed"))
+			})
+			It("returns an error", func() {
+				Expect(callErr).To(MatchError("failed counting duplicate messages: select failed"))
+			})
+		})
+	})
+
+	Describe("GetOriginalMessage", func() {
+		var callErr error
+		JustBeforeEach(func() {
+			_, callErr = client.GetOriginalMessage(context.Background(), "fb")
+		})
+
+		When("it fails", func() {
+			BeforeEach(func() {
+				database.QueryContextReturns(nil, errors.New("select failed"))
+			})
+			It("returns an error", func() {
+				Expect(callErr).To(MatchError("failed getting original message: select failed"))
+			})
+		})
+	})
+
+	Describe("StreamFeedbackPartition", func() {
+		var callErr error
+		JustBeforeEach(func() {
+			_, callErr = client.StreamFeedbackPartition(context.Background(), time.Date(2020, 3, 14, 0, 0, 0, 0, time.UTC), ioutil.Discard)
+		})
+
+		When("it fails", func() {
+			BeforeEach(func() {
+				database.QueryContextReturns(nil, errors.New("select failed"))
+			})
+			It("returns an error", func() {
+				Expect(callErr).To(MatchError("failed reading feedback partition: select failed"))
+			})
+			It("leaves the unredacted message and the email out of the archive", func() {
+				_, query, args := database.QueryContextArgsForCall(0)
+				Expect(query).To(ContainSubstring("- 'message_original' - 'email' - 'email_index'"))
+				Expect(args).To(Equal([]interface{}{"2020-03-01"}))
+			})
+		})
+	})
+
+	Describe("ModerateFeedback", func() {
+		It("returns an error when it fails", func() {
+			database.QueryContextReturns(nil, errors.New("update failed"))
+			_, err := client.ModerateFeedback(context.Background(), db.ModerationDecision{FeedbackID: "fb"})
+			Expect(err).To(MatchError("failed moderating feedback: update failed"))
+		})
+	})
+
+	Describe("SilenceFeedback", func() {
+		It("passes the new state and returns an error when it fails", func() {
+			database.QueryContextReturns(nil, errors.New("update failed"))
+			_, err := client.SilenceFeedback(context.Background(), "fb", true)
+			Expect(err).To(MatchError("failed silencing feedback: update failed"))
+
+			_, query, args := database.QueryContextArgsForCall(0)
+			Expect(query).To(Equal(db.SilenceFeedbackSQL))
+			Expect(args).To(Equal([]interface{}{"fb", true}))
+		})
+	})
+
+	Describe("AppendAuditEvent", func() {
+		var (
+			appended bool
+			callErr  error
+		)
+		JustBeforeEach(func() {
+			appended, callErr = client.AppendAuditEvent(context.Background(), db.AuditEvent{
+				Seq:       7,
+				TargetIDs: []string{"fb"},
+				After:     []byte(`{"moderation_status":"approved"}`),
+			})
+		})
+
+		When("it fails", func() {
+			BeforeEach(func() {
+				database.ExecContextReturns(nil, errors.New("insert failed"))
+			})
+			It("returns an error", func() {
+				Expect(callErr).To(MatchError("failed appending audit event: insert failed"))
+			})
+		})
+		When("another event took the sequence number", func() {
+			BeforeEach(func() {
+				database.ExecContextReturns(driver.RowsAffected(0), nil)
+			})
+			It("reports that nothing was appended", func() {
+				Expect(callErr).To(BeNil())
+				Expect(appended).To(BeFalse())
+			})
+		})
+		When("all goes well", func() {
+			BeforeEach(func() {
+				database.ExecContextReturns(driver.RowsAffected(1), nil)
+			})
+			It("passes JSON as text", func() {
+				Expect(callErr).To(BeNil())
+				Expect(appended).To(BeTrue())
+
+				_, query, args := database.ExecContextArgsForCall(0)
+				Expect(query).To(Equal(db.AppendAuditEventSQL))
+				Expect(args[7]).To(BeNil())
+				Expect(args[8]).To(Equal(`{"moderation_status":"approved"}`))
+			})
+		})
+	})
 })
@@ -3,6 +3,7 @@ package dbfakes
 
 import (
 	"context"
+	"io"
 	"sync"
 	"time"
 
@@ -10,241 +11,5222 @@ import (
 )
 
 type FakeDB struct {
+	AnonymizeRiderFeedbackStub        func(context.Context, db.DataRequest) (int, error)
+	anonymizeRiderFeedbackMutex       sync.RWMutex
+	anonymizeRiderFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.DataRequest
+	}
+	anonymizeRiderFeedbackReturns struct {
+		result1 int
+		result2 error
+	}
+	anonymizeRiderFeedbackReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	AppendAuditEventStub        func(context.Context, db.AuditEvent) (bool, error)
+	appendAuditEventMutex       sync.RWMutex
+	appendAuditEventArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.AuditEvent
+	}
+	appendAuditEventReturns struct {
+		result1 bool
+		result2 error
+	}
+	appendAuditEventReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	ClaimEmailsStub        func(context.Context, int, time.Duration) ([]db.QueuedEmail, error)
+	claimEmailsMutex       sync.RWMutex
+	claimEmailsArgsForCall []struct {
+		arg1 context.Context
+		arg2 int
+		arg3 time.Duration
+	}
+	claimEmailsReturns struct {
+		result1 []db.QueuedEmail
+		result2 error
+	}
+	claimEmailsReturnsOnCall map[int]struct {
+		result1 []db.QueuedEmail
+		result2 error
+	}
+	CountDuplicateMessagesStub        func(context.Context, string, string, time.Time) (int, error)
+	countDuplicateMessagesMutex       sync.RWMutex
+	countDuplicateMessagesArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 time.Time
+	}
+	countDuplicateMessagesReturns struct {
+		result1 int
+		result2 error
+	}
+	countDuplicateMessagesReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	CountExpiredFeedbackStub        func(context.Context, string, string, time.Time) (int, error)
+	countExpiredFeedbackMutex       sync.RWMutex
+	countExpiredFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 time.Time
+	}
+	countExpiredFeedbackReturns struct {
+		result1 int
+		result2 error
+	}
+	countExpiredFeedbackReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	CountFeedbackByClientStub        func(context.Context, db.ClientBreakdown, int, int) ([]db.ClientCount, error)
+	countFeedbackByClientMutex       sync.RWMutex
+	countFeedbackByClientArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.ClientBreakdown
+		arg3 int
+		arg4 int
+	}
+	countFeedbackByClientReturns struct {
+		result1 []db.ClientCount
+		result2 error
+	}
+	countFeedbackByClientReturnsOnCall map[int]struct {
+		result1 []db.ClientCount
+		result2 error
+	}
+	CountSurveyAnswersStub        func(context.Context, string, int) ([]db.SurveyAnswerCount, error)
+	countSurveyAnswersMutex       sync.RWMutex
+	countSurveyAnswersArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}
+	countSurveyAnswersReturns struct {
+		result1 []db.SurveyAnswerCount
+		result2 error
+	}
+	countSurveyAnswersReturnsOnCall map[int]struct {
+		result1 []db.SurveyAnswerCount
+		result2 error
+	}
+	CreateFeedbackPartitionStub        func(context.Context, time.Time) error
+	createFeedbackPartitionMutex       sync.RWMutex
+	createFeedbackPartitionArgsForCall []struct {
+		arg1 context.Context
+		arg2 time.Time
+	}
+	createFeedbackPartitionReturns struct {
+		result1 error
+	}
+	createFeedbackPartitionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CreateSurveyVersionStub        func(context.Context, db.SurveyVersion) (int, error)
+	createSurveyVersionMutex       sync.RWMutex
+	createSurveyVersionArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.SurveyVersion
+	}
+	createSurveyVersionReturns struct {
+		result1 int
+		result2 error
+	}
+	createSurveyVersionReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	DeleteAttachmentStub        func(context.Context, string) error
+	deleteAttachmentMutex       sync.RWMutex
+	deleteAttachmentArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteAttachmentReturns struct {
+		result1 error
+	}
+	deleteAttachmentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteRetentionPolicyStub        func(context.Context, string) error
+	deleteRetentionPolicyMutex       sync.RWMutex
+	deleteRetentionPolicyArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteRetentionPolicyReturns struct {
+		result1 error
+	}
+	deleteRetentionPolicyReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteRiderFeedbackStub        func(context.Context, db.DataRequest) (int, error)
+	deleteRiderFeedbackMutex       sync.RWMutex
+	deleteRiderFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.DataRequest
+	}
+	deleteRiderFeedbackReturns struct {
+		result1 int
+		result2 error
+	}
+	deleteRiderFeedbackReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	DetachFeedbackPartitionStub        func(context.Context, time.Time) error
+	detachFeedbackPartitionMutex       sync.RWMutex
+	detachFeedbackPartitionArgsForCall []struct {
+		arg1 context.Context
+		arg2 time.Time
+	}
+	detachFeedbackPartitionReturns struct {
+		result1 error
+	}
+	detachFeedbackPartitionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DropEmailStub        func(context.Context, int64, string) error
+	dropEmailMutex       sync.RWMutex
+	dropEmailArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 string
+	}
+	dropEmailReturns struct {
+		result1 error
+	}
+	dropEmailReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DropFeedbackPartitionStub        func(context.Context, time.Time) error
+	dropFeedbackPartitionMutex       sync.RWMutex
+	dropFeedbackPartitionArgsForCall []struct {
+		arg1 context.Context
+		arg2 time.Time
+	}
+	dropFeedbackPartitionReturns struct {
+		result1 error
+	}
+	dropFeedbackPartitionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	FindRiderAttachmentsStub        func(context.Context, db.RiderSubject) ([]db.Attachment, error)
+	findRiderAttachmentsMutex       sync.RWMutex
+	findRiderAttachmentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.RiderSubject
+	}
+	findRiderAttachmentsReturns struct {
+		result1 []db.Attachment
+		result2 error
+	}
+	findRiderAttachmentsReturnsOnCall map[int]struct {
+		result1 []db.Attachment
+		result2 error
+	}
+	FindRiderFeedbackStub        func(context.Context, db.RiderSubject) ([]db.Feedback, error)
+	findRiderFeedbackMutex       sync.RWMutex
+	findRiderFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.RiderSubject
+	}
+	findRiderFeedbackReturns struct {
+		result1 []db.Feedback
+		result2 error
+	}
+	findRiderFeedbackReturnsOnCall map[int]struct {
+		result1 []db.Feedback
+		result2 error
+	}
+	FindRiderMessagesStub        func(context.Context, db.RiderSubject) ([]db.FeedbackMessage, error)
+	findRiderMessagesMutex       sync.RWMutex
+	findRiderMessagesArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.RiderSubject
+	}
+	findRiderMessagesReturns struct {
+		result1 []db.FeedbackMessage
+		result2 error
+	}
+	findRiderMessagesReturnsOnCall map[int]struct {
+		result1 []db.FeedbackMessage
+		result2 error
+	}
+	FindRiderSurveyResponsesStub        func(context.Context, db.RiderSubject) ([]db.SurveyResponse, error)
+	findRiderSurveyResponsesMutex       sync.RWMutex
+	findRiderSurveyResponsesArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.RiderSubject
+	}
+	findRiderSurveyResponsesReturns struct {
+		result1 []db.SurveyResponse
+		result2 error
+	}
+	findRiderSurveyResponsesReturnsOnCall map[int]struct {
+		result1 []db.SurveyResponse
+		result2 error
+	}
+	GetAttachmentsStub        func(context.Context, []string) ([]db.Attachment, error)
+	getAttachmentsMutex       sync.RWMutex
+	getAttachmentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 []string
+	}
+	getAttachmentsReturns struct {
+		result1 []db.Attachment
+		result2 error
+	}
+	getAttachmentsReturnsOnCall map[int]struct {
+		result1 []db.Attachment
+		result2 error
+	}
+	GetLastAuditEventStub        func(context.Context) (int64, string, error)
+	getLastAuditEventMutex       sync.RWMutex
+	getLastAuditEventArgsForCall []struct {
+		arg1 context.Context
+	}
+	getLastAuditEventReturns struct {
+		result1 int64
+		result2 string
+		result3 error
+	}
+	getLastAuditEventReturnsOnCall map[int]struct {
+		result1 int64
+		result2 string
+		result3 error
+	}
+	GetModerationDecisionsStub        func(context.Context, string) ([]db.ModerationDecision, error)
+	getModerationDecisionsMutex       sync.RWMutex
+	getModerationDecisionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getModerationDecisionsReturns struct {
+		result1 []db.ModerationDecision
+		result2 error
+	}
+	getModerationDecisionsReturnsOnCall map[int]struct {
+		result1 []db.ModerationDecision
+		result2 error
+	}
+	GetOriginalMessageStub        func(context.Context, string) (*string, error)
+	getOriginalMessageMutex       sync.RWMutex
+	getOriginalMessageArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getOriginalMessageReturns struct {
+		result1 *string
+		result2 error
+	}
+	getOriginalMessageReturnsOnCall map[int]struct {
+		result1 *string
+		result2 error
+	}
 	GetRecentOutagesStub        func(context.Context, time.Time) ([]db.Feedback, error)
 	getRecentOutagesMutex       sync.RWMutex
 	getRecentOutagesArgsForCall []struct {
 		arg1 context.Context
-		arg2 time.Time
+		arg2 time.Time
+	}
+	getRecentOutagesReturns struct {
+		result1 []db.Feedback
+		result2 error
+	}
+	getRecentOutagesReturnsOnCall map[int]struct {
+		result1 []db.Feedback
+		result2 error
+	}
+	GetRetentionPoliciesStub        func(context.Context) ([]db.RetentionPolicy, error)
+	getRetentionPoliciesMutex       sync.RWMutex
+	getRetentionPoliciesArgsForCall []struct {
+		arg1 context.Context
+	}
+	getRetentionPoliciesReturns struct {
+		result1 []db.RetentionPolicy
+		result2 error
+	}
+	getRetentionPoliciesReturnsOnCall map[int]struct {
+		result1 []db.RetentionPolicy
+		result2 error
+	}
+	GetSurveyVersionStub        func(context.Context, string, *int) (db.SurveyVersion, error)
+	getSurveyVersionMutex       sync.RWMutex
+	getSurveyVersionArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *int
+	}
+	getSurveyVersionReturns struct {
+		result1 db.SurveyVersion
+		result2 error
+	}
+	getSurveyVersionReturnsOnCall map[int]struct {
+		result1 db.SurveyVersion
+		result2 error
+	}
+	GetTriageTransitionsStub        func(context.Context, string) ([]db.TriageTransition, error)
+	getTriageTransitionsMutex       sync.RWMutex
+	getTriageTransitionsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getTriageTransitionsReturns struct {
+		result1 []db.TriageTransition
+		result2 error
+	}
+	getTriageTransitionsReturnsOnCall map[int]struct {
+		result1 []db.TriageTransition
+		result2 error
+	}
+	InTransactionStub        func(context.Context, func(ctx context.Context) error) error
+	inTransactionMutex       sync.RWMutex
+	inTransactionArgsForCall []struct {
+		arg1 context.Context
+		arg2 func(ctx context.Context) error
+	}
+	inTransactionReturns struct {
+		result1 error
+	}
+	inTransactionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	IsEmailUnsubscribedStub        func(context.Context, string) (bool, error)
+	isEmailUnsubscribedMutex       sync.RWMutex
+	isEmailUnsubscribedArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	isEmailUnsubscribedReturns struct {
+		result1 bool
+		result2 error
+	}
+	isEmailUnsubscribedReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	ListAuditEventsStub        func(context.Context, db.AuditFilter, int, int) ([]db.AuditEvent, error)
+	listAuditEventsMutex       sync.RWMutex
+	listAuditEventsArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.AuditFilter
+		arg3 int
+		arg4 int
+	}
+	listAuditEventsReturns struct {
+		result1 []db.AuditEvent
+		result2 error
+	}
+	listAuditEventsReturnsOnCall map[int]struct {
+		result1 []db.AuditEvent
+		result2 error
+	}
+	ListAuditEventsAfterStub        func(context.Context, int64, int) ([]db.AuditEvent, error)
+	listAuditEventsAfterMutex       sync.RWMutex
+	listAuditEventsAfterArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 int
+	}
+	listAuditEventsAfterReturns struct {
+		result1 []db.AuditEvent
+		result2 error
+	}
+	listAuditEventsAfterReturnsOnCall map[int]struct {
+		result1 []db.AuditEvent
+		result2 error
+	}
+	ListDiscardedAttachmentsStub        func(context.Context, time.Time, int) ([]db.Attachment, error)
+	listDiscardedAttachmentsMutex       sync.RWMutex
+	listDiscardedAttachmentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 time.Time
+		arg3 int
+	}
+	listDiscardedAttachmentsReturns struct {
+		result1 []db.Attachment
+		result2 error
+	}
+	listDiscardedAttachmentsReturnsOnCall map[int]struct {
+		result1 []db.Attachment
+		result2 error
+	}
+	ListEmailsToRotateStub        func(context.Context, string, int) ([]db.StoredEmail, error)
+	listEmailsToRotateMutex       sync.RWMutex
+	listEmailsToRotateArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}
+	listEmailsToRotateReturns struct {
+		result1 []db.StoredEmail
+		result2 error
+	}
+	listEmailsToRotateReturnsOnCall map[int]struct {
+		result1 []db.StoredEmail
+		result2 error
+	}
+	ListFeedbackStub        func(context.Context, db.FeedbackFilter, int, int) ([]db.Feedback, error)
+	listFeedbackMutex       sync.RWMutex
+	listFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.FeedbackFilter
+		arg3 int
+		arg4 int
+	}
+	listFeedbackReturns struct {
+		result1 []db.Feedback
+		result2 error
+	}
+	listFeedbackReturnsOnCall map[int]struct {
+		result1 []db.Feedback
+		result2 error
+	}
+	ListFeedbackAttachmentsStub        func(context.Context, string) ([]db.Attachment, error)
+	listFeedbackAttachmentsMutex       sync.RWMutex
+	listFeedbackAttachmentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	listFeedbackAttachmentsReturns struct {
+		result1 []db.Attachment
+		result2 error
+	}
+	listFeedbackAttachmentsReturnsOnCall map[int]struct {
+		result1 []db.Attachment
+		result2 error
+	}
+	ListFeedbackByEmailStub        func(context.Context, string, int, int) ([]db.Feedback, error)
+	listFeedbackByEmailMutex       sync.RWMutex
+	listFeedbackByEmailArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+		arg4 int
+	}
+	listFeedbackByEmailReturns struct {
+		result1 []db.Feedback
+		result2 error
+	}
+	listFeedbackByEmailReturnsOnCall map[int]struct {
+		result1 []db.Feedback
+		result2 error
+	}
+	ListFeedbackByModerationStatusStub        func(context.Context, string, int, int) ([]db.Feedback, error)
+	listFeedbackByModerationStatusMutex       sync.RWMutex
+	listFeedbackByModerationStatusArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+		arg4 int
+	}
+	listFeedbackByModerationStatusReturns struct {
+		result1 []db.Feedback
+		result2 error
+	}
+	listFeedbackByModerationStatusReturnsOnCall map[int]struct {
+		result1 []db.Feedback
+		result2 error
+	}
+	ListFeedbackKindsStub        func(context.Context) ([]db.FeedbackKind, error)
+	listFeedbackKindsMutex       sync.RWMutex
+	listFeedbackKindsArgsForCall []struct {
+		arg1 context.Context
+	}
+	listFeedbackKindsReturns struct {
+		result1 []db.FeedbackKind
+		result2 error
+	}
+	listFeedbackKindsReturnsOnCall map[int]struct {
+		result1 []db.FeedbackKind
+		result2 error
+	}
+	ListFeedbackMessagesStub        func(context.Context, string, *string) ([]db.FeedbackMessage, error)
+	listFeedbackMessagesMutex       sync.RWMutex
+	listFeedbackMessagesArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *string
+	}
+	listFeedbackMessagesReturns struct {
+		result1 []db.FeedbackMessage
+		result2 error
+	}
+	listFeedbackMessagesReturnsOnCall map[int]struct {
+		result1 []db.FeedbackMessage
+		result2 error
+	}
+	ListFeedbackThreadsStub        func(context.Context, db.ThreadFilter, int, int) ([]db.FeedbackThread, error)
+	listFeedbackThreadsMutex       sync.RWMutex
+	listFeedbackThreadsArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.ThreadFilter
+		arg3 int
+		arg4 int
+	}
+	listFeedbackThreadsReturns struct {
+		result1 []db.FeedbackThread
+		result2 error
+	}
+	listFeedbackThreadsReturnsOnCall map[int]struct {
+		result1 []db.FeedbackThread
+		result2 error
+	}
+	ListFeedbackValuesStub        func(context.Context) ([]db.FeedbackValue, error)
+	listFeedbackValuesMutex       sync.RWMutex
+	listFeedbackValuesArgsForCall []struct {
+		arg1 context.Context
+	}
+	listFeedbackValuesReturns struct {
+		result1 []db.FeedbackValue
+		result2 error
+	}
+	listFeedbackValuesReturnsOnCall map[int]struct {
+		result1 []db.FeedbackValue
+		result2 error
+	}
+	MarkEmailFailedStub        func(context.Context, int64, string, *time.Time) error
+	markEmailFailedMutex       sync.RWMutex
+	markEmailFailedArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 string
+		arg4 *time.Time
+	}
+	markEmailFailedReturns struct {
+		result1 error
+	}
+	markEmailFailedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	MarkEmailSentStub        func(context.Context, int64) error
+	markEmailSentMutex       sync.RWMutex
+	markEmailSentArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+	}
+	markEmailSentReturns struct {
+		result1 error
+	}
+	markEmailSentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	MarkFeedbackMessagesReadStub        func(context.Context, string, string, *string) error
+	markFeedbackMessagesReadMutex       sync.RWMutex
+	markFeedbackMessagesReadArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 *string
+	}
+	markFeedbackMessagesReadReturns struct {
+		result1 error
+	}
+	markFeedbackMessagesReadReturnsOnCall map[int]struct {
+		result1 error
+	}
+	MigrateStub        func(context.Context) error
+	migrateMutex       sync.RWMutex
+	migrateArgsForCall []struct {
+		arg1 context.Context
+	}
+	migrateReturns struct {
+		result1 error
+	}
+	migrateReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ModerateFeedbackStub        func(context.Context, db.ModerationDecision) (string, error)
+	moderateFeedbackMutex       sync.RWMutex
+	moderateFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.ModerationDecision
+	}
+	moderateFeedbackReturns struct {
+		result1 string
+		result2 error
+	}
+	moderateFeedbackReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	PruneRateLimitBucketsStub        func(context.Context, time.Time) (int, error)
+	pruneRateLimitBucketsMutex       sync.RWMutex
+	pruneRateLimitBucketsArgsForCall []struct {
+		arg1 context.Context
+		arg2 time.Time
+	}
+	pruneRateLimitBucketsReturns struct {
+		result1 int
+		result2 error
+	}
+	pruneRateLimitBucketsReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	PurgeExpiredFeedbackStub        func(context.Context, string, string, time.Time, int) (int, error)
+	purgeExpiredFeedbackMutex       sync.RWMutex
+	purgeExpiredFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 time.Time
+		arg5 int
+	}
+	purgeExpiredFeedbackReturns struct {
+		result1 int
+		result2 error
+	}
+	purgeExpiredFeedbackReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	QueueOutageResolvedEmailsStub        func(context.Context, *string, time.Time) (int, error)
+	queueOutageResolvedEmailsMutex       sync.RWMutex
+	queueOutageResolvedEmailsArgsForCall []struct {
+		arg1 context.Context
+		arg2 *string
+		arg3 time.Time
+	}
+	queueOutageResolvedEmailsReturns struct {
+		result1 int
+		result2 error
+	}
+	queueOutageResolvedEmailsReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	RecordDataRequestStub        func(context.Context, db.DataRequest) error
+	recordDataRequestMutex       sync.RWMutex
+	recordDataRequestArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.DataRequest
+	}
+	recordDataRequestReturns struct {
+		result1 error
+	}
+	recordDataRequestReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RefundRateLimitTokenStub        func(context.Context, string, float64, float64) error
+	refundRateLimitTokenMutex       sync.RWMutex
+	refundRateLimitTokenArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 float64
+		arg4 float64
+	}
+	refundRateLimitTokenReturns struct {
+		result1 error
+	}
+	refundRateLimitTokenReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SaveAttachmentStub        func(context.Context, db.Attachment) (db.Attachment, error)
+	saveAttachmentMutex       sync.RWMutex
+	saveAttachmentArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.Attachment
+	}
+	saveAttachmentReturns struct {
+		result1 db.Attachment
+		result2 error
+	}
+	saveAttachmentReturnsOnCall map[int]struct {
+		result1 db.Attachment
+		result2 error
+	}
+	SaveFeedbackStub        func(context.Context, db.Feedback) error
+	saveFeedbackMutex       sync.RWMutex
+	saveFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.Feedback
+	}
+	saveFeedbackReturns struct {
+		result1 error
+	}
+	saveFeedbackReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SaveFeedbackMessageStub        func(context.Context, db.FeedbackMessage, bool) (db.FeedbackMessage, bool, error)
+	saveFeedbackMessageMutex       sync.RWMutex
+	saveFeedbackMessageArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.FeedbackMessage
+		arg3 bool
+	}
+	saveFeedbackMessageReturns struct {
+		result1 db.FeedbackMessage
+		result2 bool
+		result3 error
+	}
+	saveFeedbackMessageReturnsOnCall map[int]struct {
+		result1 db.FeedbackMessage
+		result2 bool
+		result3 error
+	}
+	SaveSurveyResponseStub        func(context.Context, db.SurveyResponse) error
+	saveSurveyResponseMutex       sync.RWMutex
+	saveSurveyResponseArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.SurveyResponse
+	}
+	saveSurveyResponseReturns struct {
+		result1 error
+	}
+	saveSurveyResponseReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SearchFeedbackStub        func(context.Context, string, db.FeedbackFilter, int, int) ([]db.SearchResult, error)
+	searchFeedbackMutex       sync.RWMutex
+	searchFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 db.FeedbackFilter
+		arg4 int
+		arg5 int
+	}
+	searchFeedbackReturns struct {
+		result1 []db.SearchResult
+		result2 error
+	}
+	searchFeedbackReturnsOnCall map[int]struct {
+		result1 []db.SearchResult
+		result2 error
+	}
+	SetActiveSurveyVersionStub        func(context.Context, string, *int) error
+	setActiveSurveyVersionMutex       sync.RWMutex
+	setActiveSurveyVersionArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *int
+	}
+	setActiveSurveyVersionReturns struct {
+		result1 error
+	}
+	setActiveSurveyVersionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetRetentionPolicyStub        func(context.Context, db.RetentionPolicy) error
+	setRetentionPolicyMutex       sync.RWMutex
+	setRetentionPolicyArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.RetentionPolicy
+	}
+	setRetentionPolicyReturns struct {
+		result1 error
+	}
+	setRetentionPolicyReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SilenceFeedbackStub        func(context.Context, string, bool) (bool, error)
+	silenceFeedbackMutex       sync.RWMutex
+	silenceFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 bool
+	}
+	silenceFeedbackReturns struct {
+		result1 bool
+		result2 error
+	}
+	silenceFeedbackReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	SimilarFeedbackStub        func(context.Context, string, int, int) ([]db.SimilarFeedback, error)
+	similarFeedbackMutex       sync.RWMutex
+	similarFeedbackArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+		arg4 int
+	}
+	similarFeedbackReturns struct {
+		result1 []db.SimilarFeedback
+		result2 error
+	}
+	similarFeedbackReturnsOnCall map[int]struct {
+		result1 []db.SimilarFeedback
+		result2 error
+	}
+	StreamFeedbackPartitionStub        func(context.Context, time.Time, io.Writer) (int, error)
+	streamFeedbackPartitionMutex       sync.RWMutex
+	streamFeedbackPartitionArgsForCall []struct {
+		arg1 context.Context
+		arg2 time.Time
+		arg3 io.Writer
+	}
+	streamFeedbackPartitionReturns struct {
+		result1 int
+		result2 error
+	}
+	streamFeedbackPartitionReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	TakeRateLimitTokenStub        func(context.Context, string, float64, float64) (bool, float64, error)
+	takeRateLimitTokenMutex       sync.RWMutex
+	takeRateLimitTokenArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 float64
+		arg4 float64
+	}
+	takeRateLimitTokenReturns struct {
+		result1 bool
+		result2 float64
+		result3 error
+	}
+	takeRateLimitTokenReturnsOnCall map[int]struct {
+		result1 bool
+		result2 float64
+		result3 error
+	}
+	UnsubscribeEmailStub        func(context.Context, string) error
+	unsubscribeEmailMutex       sync.RWMutex
+	unsubscribeEmailArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	unsubscribeEmailReturns struct {
+		result1 error
+	}
+	unsubscribeEmailReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateEmailStub        func(context.Context, string, string, string) error
+	updateEmailMutex       sync.RWMutex
+	updateEmailArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}
+	updateEmailReturns struct {
+		result1 error
+	}
+	updateEmailReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateTriageStub        func(context.Context, db.TriageUpdate) ([]db.TriageChange, error)
+	updateTriageMutex       sync.RWMutex
+	updateTriageArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.TriageUpdate
+	}
+	updateTriageReturns struct {
+		result1 []db.TriageChange
+		result2 error
+	}
+	updateTriageReturnsOnCall map[int]struct {
+		result1 []db.TriageChange
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDB) AnonymizeRiderFeedback(arg1 context.Context, arg2 db.DataRequest) (int, error) {
+	fake.anonymizeRiderFeedbackMutex.Lock()
+	ret, specificReturn := fake.anonymizeRiderFeedbackReturnsOnCall[len(fake.anonymizeRiderFeedbackArgsForCall)]
+	fake.anonymizeRiderFeedbackArgsForCall = append(fake.anonymizeRiderFeedbackArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.DataRequest
+	}{arg1, arg2})
+	fake.recordInvocation("AnonymizeRiderFeedback", []interface{}{arg1, arg2})
+	fake.anonymizeRiderFeedbackMutex.Unlock()
+	if fake.AnonymizeRiderFeedbackStub != nil {
+		return fake.AnonymizeRiderFeedbackStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.anonymizeRiderFeedbackReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) AnonymizeRiderFeedbackCallCount() int {
+	fake.anonymizeRiderFeedbackMutex.RLock()
+	defer fake.anonymizeRiderFeedbackMutex.RUnlock()
+	return len(fake.anonymizeRiderFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) AnonymizeRiderFeedbackCalls(stub func(context.Context, db.DataRequest) (int, error)) {
+	fake.anonymizeRiderFeedbackMutex.Lock()
+	defer fake.anonymizeRiderFeedbackMutex.Unlock()
+	fake.AnonymizeRiderFeedbackStub = stub
+}
+
+func (fake *FakeDB) AnonymizeRiderFeedbackArgsForCall(i int) (context.Context, db.DataRequest) {
+	fake.anonymizeRiderFeedbackMutex.RLock()
+	defer fake.anonymizeRiderFeedbackMutex.RUnlock()
+	argsForCall := fake.anonymizeRiderFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) AnonymizeRiderFeedbackReturns(result1 int, result2 error) {
+	fake.anonymizeRiderFeedbackMutex.Lock()
+	defer fake.anonymizeRiderFeedbackMutex.Unlock()
+	fake.AnonymizeRiderFeedbackStub = nil
+	fake.anonymizeRiderFeedbackReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) AnonymizeRiderFeedbackReturnsOnCall(i int, result1 int, result2 error) {
+	fake.anonymizeRiderFeedbackMutex.Lock()
+	defer fake.anonymizeRiderFeedbackMutex.Unlock()
+	fake.AnonymizeRiderFeedbackStub = nil
+	if fake.anonymizeRiderFeedbackReturnsOnCall == nil {
+		fake.anonymizeRiderFeedbackReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.anonymizeRiderFeedbackReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) AppendAuditEvent(arg1 context.Context, arg2 db.AuditEvent) (bool, error) {
+	fake.appendAuditEventMutex.Lock()
+	ret, specificReturn := fake.appendAuditEventReturnsOnCall[len(fake.appendAuditEventArgsForCall)]
+	fake.appendAuditEventArgsForCall = append(fake.appendAuditEventArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.AuditEvent
+	}{arg1, arg2})
+	fake.recordInvocation("AppendAuditEvent", []interface{}{arg1, arg2})
+	fake.appendAuditEventMutex.Unlock()
+	if fake.AppendAuditEventStub != nil {
+		return fake.AppendAuditEventStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.appendAuditEventReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) AppendAuditEventCallCount() int {
+	fake.appendAuditEventMutex.RLock()
+	defer fake.appendAuditEventMutex.RUnlock()
+	return len(fake.appendAuditEventArgsForCall)
+}
+
+func (fake *FakeDB) AppendAuditEventCalls(stub func(context.Context, db.AuditEvent) (bool, error)) {
+	fake.appendAuditEventMutex.Lock()
+	defer fake.appendAuditEventMutex.Unlock()
+	fake.AppendAuditEventStub = stub
+}
+
+func (fake *FakeDB) AppendAuditEventArgsForCall(i int) (context.Context, db.AuditEvent) {
+	fake.appendAuditEventMutex.RLock()
+	defer fake.appendAuditEventMutex.RUnlock()
+	argsForCall := fake.appendAuditEventArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) AppendAuditEventReturns(result1 bool, result2 error) {
+	fake.appendAuditEventMutex.Lock()
+	defer fake.appendAuditEventMutex.Unlock()
+	fake.AppendAuditEventStub = nil
+	fake.appendAuditEventReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) AppendAuditEventReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.appendAuditEventMutex.Lock()
+	defer fake.appendAuditEventMutex.Unlock()
+	fake.AppendAuditEventStub = nil
+	if fake.appendAuditEventReturnsOnCall == nil {
+		fake.appendAuditEventReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.appendAuditEventReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ClaimEmails(arg1 context.Context, arg2 int, arg3 time.Duration) ([]db.QueuedEmail, error) {
+	fake.claimEmailsMutex.Lock()
+	ret, specificReturn := fake.claimEmailsReturnsOnCall[len(fake.claimEmailsArgsForCall)]
+	fake.claimEmailsArgsForCall = append(fake.claimEmailsArgsForCall, struct {
+		arg1 context.Context
+		arg2 int
+		arg3 time.Duration
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("ClaimEmails", []interface{}{arg1, arg2, arg3})
+	fake.claimEmailsMutex.Unlock()
+	if fake.ClaimEmailsStub != nil {
+		return fake.ClaimEmailsStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.claimEmailsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ClaimEmailsCallCount() int {
+	fake.claimEmailsMutex.RLock()
+	defer fake.claimEmailsMutex.RUnlock()
+	return len(fake.claimEmailsArgsForCall)
+}
+
+func (fake *FakeDB) ClaimEmailsCalls(stub func(context.Context, int, time.Duration) ([]db.QueuedEmail, error)) {
+	fake.claimEmailsMutex.Lock()
+	defer fake.claimEmailsMutex.Unlock()
+	fake.ClaimEmailsStub = stub
+}
+
+func (fake *FakeDB) ClaimEmailsArgsForCall(i int) (context.Context, int, time.Duration) {
+	fake.claimEmailsMutex.RLock()
+	defer fake.claimEmailsMutex.RUnlock()
+	argsForCall := fake.claimEmailsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) ClaimEmailsReturns(result1 []db.QueuedEmail, result2 error) {
+	fake.claimEmailsMutex.Lock()
+	defer fake.claimEmailsMutex.Unlock()
+	fake.ClaimEmailsStub = nil
+	fake.claimEmailsReturns = struct {
+		result1 []db.QueuedEmail
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ClaimEmailsReturnsOnCall(i int, result1 []db.QueuedEmail, result2 error) {
+	fake.claimEmailsMutex.Lock()
+	defer fake.claimEmailsMutex.Unlock()
+	fake.ClaimEmailsStub = nil
+	if fake.claimEmailsReturnsOnCall == nil {
+		fake.claimEmailsReturnsOnCall = make(map[int]struct {
+			result1 []db.QueuedEmail
+			result2 error
+		})
+	}
+	fake.claimEmailsReturnsOnCall[i] = struct {
+		result1 []db.QueuedEmail
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) CountDuplicateMessages(arg1 context.Context, arg2 string, arg3 string, arg4 time.Time) (int, error) {
+	fake.countDuplicateMessagesMutex.Lock()
+	ret, specificReturn := fake.countDuplicateMessagesReturnsOnCall[len(fake.countDuplicateMessagesArgsForCall)]
+	fake.countDuplicateMessagesArgsForCall = append(fake.countDuplicateMessagesArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 time.Time
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("CountDuplicateMessages", []interface{}{arg1, arg2, arg3, arg4})
+	fake.countDuplicateMessagesMutex.Unlock()
+	if fake.CountDuplicateMessagesStub != nil {
+		return fake.CountDuplicateMessagesStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.countDuplicateMessagesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) CountDuplicateMessagesCallCount() int {
+	fake.countDuplicateMessagesMutex.RLock()
+	defer fake.countDuplicateMessagesMutex.RUnlock()
+	return len(fake.countDuplicateMessagesArgsForCall)
+}
+
+func (fake *FakeDB) CountDuplicateMessagesCalls(stub func(context.Context, string, string, time.Time) (int, error)) {
+	fake.countDuplicateMessagesMutex.Lock()
+	defer fake.countDuplicateMessagesMutex.Unlock()
+	fake.CountDuplicateMessagesStub = stub
+}
+
+func (fake *FakeDB) CountDuplicateMessagesArgsForCall(i int) (context.Context, string, string, time.Time) {
+	fake.countDuplicateMessagesMutex.RLock()
+	defer fake.countDuplicateMessagesMutex.RUnlock()
+	argsForCall := fake.countDuplicateMessagesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) CountDuplicateMessagesReturns(result1 int, result2 error) {
+	fake.countDuplicateMessagesMutex.Lock()
+	defer fake.countDuplicateMessagesMutex.Unlock()
+	fake.CountDuplicateMessagesStub = nil
+	fake.countDuplicateMessagesReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) CountDuplicateMessagesReturnsOnCall(i int, result1 int, result2 error) {
+	fake.countDuplicateMessagesMutex.Lock()
+	defer fake.countDuplicateMessagesMutex.Unlock()
+	fake.CountDuplicateMessagesStub = nil
+	if fake.countDuplicateMessagesReturnsOnCall == nil {
+		fake.countDuplicateMessagesReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.countDuplicateMessagesReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) CountExpiredFeedback(arg1 context.Context, arg2 string, arg3 string, arg4 time.Time) (int, error) {
+	fake.countExpiredFeedbackMutex.Lock()
+	ret, specificReturn := fake.countExpiredFeedbackReturnsOnCall[len(fake.countExpiredFeedbackArgsForCall)]
+	fake.countExpiredFeedbackArgsForCall = append(fake.countExpiredFeedbackArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 time.Time
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("CountExpiredFeedback", []interface{}{arg1, arg2, arg3, arg4})
+	fake.countExpiredFeedbackMutex.Unlock()
+	if fake.CountExpiredFeedbackStub != nil {
+		return fake.CountExpiredFeedbackStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.countExpiredFeedbackReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) CountExpiredFeedbackCallCount() int {
+	fake.countExpiredFeedbackMutex.RLock()
+	defer fake.countExpiredFeedbackMutex.RUnlock()
+	return len(fake.countExpiredFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) CountExpiredFeedbackCalls(stub func(context.Context, string, string, time.Time) (int, error)) {
+	fake.countExpiredFeedbackMutex.Lock()
+	defer fake.countExpiredFeedbackMutex.Unlock()
+	fake.CountExpiredFeedbackStub = stub
+}
+
+func (fake *FakeDB) CountExpiredFeedbackArgsForCall(i int) (context.Context, string, string, time.Time) {
+	fake.countExpiredFeedbackMutex.RLock()
+	defer fake.countExpiredFeedbackMutex.RUnlock()
+	argsForCall := fake.countExpiredFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) CountExpiredFeedbackReturns(result1 int, result2 error) {
+	fake.countExpiredFeedbackMutex.Lock()
+	defer fake.countExpiredFeedbackMutex.Unlock()
+	fake.CountExpiredFeedbackStub = nil
+	fake.countExpiredFeedbackReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) CountExpiredFeedbackReturnsOnCall(i int, result1 int, result2 error) {
+	fake.countExpiredFeedbackMutex.Lock()
+	defer fake.countExpiredFeedbackMutex.Unlock()
+	fake.CountExpiredFeedbackStub = nil
+	if fake.countExpiredFeedbackReturnsOnCall == nil {
+		fake.countExpiredFeedbackReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.countExpiredFeedbackReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) CountFeedbackByClient(arg1 context.Context, arg2 db.ClientBreakdown, arg3 int, arg4 int) ([]db.ClientCount, error) {
+	fake.countFeedbackByClientMutex.Lock()
+	ret, specificReturn := fake.countFeedbackByClientReturnsOnCall[len(fake.countFeedbackByClientArgsForCall)]
+	fake.countFeedbackByClientArgsForCall = append(fake.countFeedbackByClientArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.ClientBreakdown
+		arg3 int
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("CountFeedbackByClient", []interface{}{arg1, arg2, arg3, arg4})
+	fake.countFeedbackByClientMutex.Unlock()
+	if fake.CountFeedbackByClientStub != nil {
+		return fake.CountFeedbackByClientStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.countFeedbackByClientReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) CountFeedbackByClientCallCount() int {
+	fake.countFeedbackByClientMutex.RLock()
+	defer fake.countFeedbackByClientMutex.RUnlock()
+	return len(fake.countFeedbackByClientArgsForCall)
+}
+
+func (fake *FakeDB) CountFeedbackByClientCalls(stub func(context.Context, db.ClientBreakdown, int, int) ([]db.ClientCount, error)) {
+	fake.countFeedbackByClientMutex.Lock()
+	defer fake.countFeedbackByClientMutex.Unlock()
+	fake.CountFeedbackByClientStub = stub
+}
+
+func (fake *FakeDB) CountFeedbackByClientArgsForCall(i int) (context.Context, db.ClientBreakdown, int, int) {
+	fake.countFeedbackByClientMutex.RLock()
+	defer fake.countFeedbackByClientMutex.RUnlock()
+	argsForCall := fake.countFeedbackByClientArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) CountFeedbackByClientReturns(result1 []db.ClientCount, result2 error) {
+	fake.countFeedbackByClientMutex.Lock()
+	defer fake.countFeedbackByClientMutex.Unlock()
+	fake.CountFeedbackByClientStub = nil
+	fake.countFeedbackByClientReturns = struct {
+		result1 []db.ClientCount
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) CountFeedbackByClientReturnsOnCall(i int, result1 []db.ClientCount, result2 error) {
+	fake.countFeedbackByClientMutex.Lock()
+	defer fake.countFeedbackByClientMutex.Unlock()
+	fake.CountFeedbackByClientStub = nil
+	if fake.countFeedbackByClientReturnsOnCall == nil {
+		fake.countFeedbackByClientReturnsOnCall = make(map[int]struct {
+			result1 []db.ClientCount
+			result2 error
+		})
+	}
+	fake.countFeedbackByClientReturnsOnCall[i] = struct {
+		result1 []db.ClientCount
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) CountSurveyAnswers(arg1 context.Context, arg2 string, arg3 int) ([]db.SurveyAnswerCount, error) {
+	fake.countSurveyAnswersMutex.Lock()
+	ret, specificReturn := fake.countSurveyAnswersReturnsOnCall[len(fake.countSurveyAnswersArgsForCall)]
+	fake.countSurveyAnswersArgsForCall = append(fake.countSurveyAnswersArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("CountSurveyAnswers", []interface{}{arg1, arg2, arg3})
+	fake.countSurveyAnswersMutex.Unlock()
+	if fake.CountSurveyAnswersStub != nil {
+		return fake.CountSurveyAnswersStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.countSurveyAnswersReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) CountSurveyAnswersCallCount() int {
+	fake.countSurveyAnswersMutex.RLock()
+	defer fake.countSurveyAnswersMutex.RUnlock()
+	return len(fake.countSurveyAnswersArgsForCall)
+}
+
+func (fake *FakeDB) CountSurveyAnswersCalls(stub func(context.Context, string, int) ([]db.SurveyAnswerCount, error)) {
+	fake.countSurveyAnswersMutex.Lock()
+	defer fake.countSurveyAnswersMutex.Unlock()
+	fake.CountSurveyAnswersStub = stub
+}
+
+func (fake *FakeDB) CountSurveyAnswersArgsForCall(i int) (context.Context, string, int) {
+	fake.countSurveyAnswersMutex.RLock()
+	defer fake.countSurveyAnswersMutex.RUnlock()
+	argsForCall := fake.countSurveyAnswersArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) CountSurveyAnswersReturns(result1 []db.SurveyAnswerCount, result2 error) {
+	fake.countSurveyAnswersMutex.Lock()
+	defer fake.countSurveyAnswersMutex.Unlock()
+	fake.CountSurveyAnswersStub = nil
+	fake.countSurveyAnswersReturns = struct {
+		result1 []db.SurveyAnswerCount
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) CountSurveyAnswersReturnsOnCall(i int, result1 []db.SurveyAnswerCount, result2 error) {
+	fake.countSurveyAnswersMutex.Lock()
+	defer fake.countSurveyAnswersMutex.Unlock()
+	fake.CountSurveyAnswersStub = nil
+	if fake.countSurveyAnswersReturnsOnCall == nil {
+		fake.countSurveyAnswersReturnsOnCall = make(map[int]struct {
+			result1 []db.SurveyAnswerCount
+			result2 error
+		})
+	}
+	fake.countSurveyAnswersReturnsOnCall[i] = struct {
+		result1 []db.SurveyAnswerCount
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) CreateFeedbackPartition(arg1 context.Context, arg2 time.Time) error {
+	fake.createFeedbackPartitionMutex.Lock()
+	ret, specificReturn := fake.createFeedbackPartitionReturnsOnCall[len(fake.createFeedbackPartitionArgsForCall)]
+	fake.createFeedbackPartitionArgsForCall = append(fake.createFeedbackPartitionArgsForCall, struct {
+		arg1 context.Context
+		arg2 time.Time
+	}{arg1, arg2})
+	fake.recordInvocation("CreateFeedbackPartition", []interface{}{arg1, arg2})
+	fake.createFeedbackPartitionMutex.Unlock()
+	if fake.CreateFeedbackPartitionStub != nil {
+		return fake.CreateFeedbackPartitionStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.createFeedbackPartitionReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) CreateFeedbackPartitionCallCount() int {
+	fake.createFeedbackPartitionMutex.RLock()
+	defer fake.createFeedbackPartitionMutex.RUnlock()
+	return len(fake.createFeedbackPartitionArgsForCall)
+}
+
+func (fake *FakeDB) CreateFeedbackPartitionCalls(stub func(context.Context, time.Time) error) {
+	fake.createFeedbackPartitionMutex.Lock()
+	defer fake.createFeedbackPartitionMutex.Unlock()
+	fake.CreateFeedbackPartitionStub = stub
+}
+
+func (fake *FakeDB) CreateFeedbackPartitionArgsForCall(i int) (context.Context, time.Time) {
+	fake.createFeedbackPartitionMutex.RLock()
+	defer fake.createFeedbackPartitionMutex.RUnlock()
+	argsForCall := fake.createFeedbackPartitionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) CreateFeedbackPartitionReturns(result1 error) {
+	fake.createFeedbackPartitionMutex.Lock()
+	defer fake.createFeedbackPartitionMutex.Unlock()
+	fake.CreateFeedbackPartitionStub = nil
+	fake.createFeedbackPartitionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) CreateFeedbackPartitionReturnsOnCall(i int, result1 error) {
+	fake.createFeedbackPartitionMutex.Lock()
+	defer fake.createFeedbackPartitionMutex.Unlock()
+	fake.CreateFeedbackPartitionStub = nil
+	if fake.createFeedbackPartitionReturnsOnCall == nil {
+		fake.createFeedbackPartitionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createFeedbackPartitionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) CreateSurveyVersion(arg1 context.Context, arg2 db.SurveyVersion) (int, error) {
+	fake.createSurveyVersionMutex.Lock()
+	ret, specificReturn := fake.createSurveyVersionReturnsOnCall[len(fake.createSurveyVersionArgsForCall)]
+	fake.createSurveyVersionArgsForCall = append(fake.createSurveyVersionArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.SurveyVersion
+	}{arg1, arg2})
+	fake.recordInvocation("CreateSurveyVersion", []interface{}{arg1, arg2})
+	fake.createSurveyVersionMutex.Unlock()
+	if fake.CreateSurveyVersionStub != nil {
+		return fake.CreateSurveyVersionStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.createSurveyVersionReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) CreateSurveyVersionCallCount() int {
+	fake.createSurveyVersionMutex.RLock()
+	defer fake.createSurveyVersionMutex.RUnlock()
+	return len(fake.createSurveyVersionArgsForCall)
+}
+
+func (fake *FakeDB) CreateSurveyVersionCalls(stub func(context.Context, db.SurveyVersion) (int, error)) {
+	fake.createSurveyVersionMutex.Lock()
+	defer fake.createSurveyVersionMutex.Unlock()
+	fake.CreateSurveyVersionStub = stub
+}
+
+func (fake *FakeDB) CreateSurveyVersionArgsForCall(i int) (context.Context, db.SurveyVersion) {
+	fake.createSurveyVersionMutex.RLock()
+	defer fake.createSurveyVersionMutex.RUnlock()
+	argsForCall := fake.createSurveyVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) CreateSurveyVersionReturns(result1 int, result2 error) {
+	fake.createSurveyVersionMutex.Lock()
+	defer fake.createSurveyVersionMutex.Unlock()
+	fake.CreateSurveyVersionStub = nil
+	fake.createSurveyVersionReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) CreateSurveyVersionReturnsOnCall(i int, result1 int, result2 error) {
+	fake.createSurveyVersionMutex.Lock()
+	defer fake.createSurveyVersionMutex.Unlock()
+	fake.CreateSurveyVersionStub = nil
+	if fake.createSurveyVersionReturnsOnCall == nil {
+		fake.createSurveyVersionReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.createSurveyVersionReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) DeleteAttachment(arg1 context.Context, arg2 string) error {
+	fake.deleteAttachmentMutex.Lock()
+	ret, specificReturn := fake.deleteAttachmentReturnsOnCall[len(fake.deleteAttachmentArgsForCall)]
+	fake.deleteAttachmentArgsForCall = append(fake.deleteAttachmentArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("DeleteAttachment", []interface{}{arg1, arg2})
+	fake.deleteAttachmentMutex.Unlock()
+	if fake.DeleteAttachmentStub != nil {
+		return fake.DeleteAttachmentStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.deleteAttachmentReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) DeleteAttachmentCallCount() int {
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	return len(fake.deleteAttachmentArgsForCall)
+}
+
+func (fake *FakeDB) DeleteAttachmentCalls(stub func(context.Context, string) error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = stub
+}
+
+func (fake *FakeDB) DeleteAttachmentArgsForCall(i int) (context.Context, string) {
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	argsForCall := fake.deleteAttachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) DeleteAttachmentReturns(result1 error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = nil
+	fake.deleteAttachmentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) DeleteAttachmentReturnsOnCall(i int, result1 error) {
+	fake.deleteAttachmentMutex.Lock()
+	defer fake.deleteAttachmentMutex.Unlock()
+	fake.DeleteAttachmentStub = nil
+	if fake.deleteAttachmentReturnsOnCall == nil {
+		fake.deleteAttachmentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteAttachmentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) DeleteRetentionPolicy(arg1 context.Context, arg2 string) error {
+	fake.deleteRetentionPolicyMutex.Lock()
+	ret, specificReturn := fake.deleteRetentionPolicyReturnsOnCall[len(fake.deleteRetentionPolicyArgsForCall)]
+	fake.deleteRetentionPolicyArgsForCall = append(fake.deleteRetentionPolicyArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("DeleteRetentionPolicy", []interface{}{arg1, arg2})
+	fake.deleteRetentionPolicyMutex.Unlock()
+	if fake.DeleteRetentionPolicyStub != nil {
+		return fake.DeleteRetentionPolicyStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.deleteRetentionPolicyReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) DeleteRetentionPolicyCallCount() int {
+	fake.deleteRetentionPolicyMutex.RLock()
+	defer fake.deleteRetentionPolicyMutex.RUnlock()
+	return len(fake.deleteRetentionPolicyArgsForCall)
+}
+
+func (fake *FakeDB) DeleteRetentionPolicyCalls(stub func(context.Context, string) error) {
+	fake.deleteRetentionPolicyMutex.Lock()
+	defer fake.deleteRetentionPolicyMutex.Unlock()
+	fake.DeleteRetentionPolicyStub = stub
+}
+
+func (fake *FakeDB) DeleteRetentionPolicyArgsForCall(i int) (context.Context, string) {
+	fake.deleteRetentionPolicyMutex.RLock()
+	defer fake.deleteRetentionPolicyMutex.RUnlock()
+	argsForCall := fake.deleteRetentionPolicyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) DeleteRetentionPolicyReturns(result1 error) {
+	fake.deleteRetentionPolicyMutex.Lock()
+	defer fake.deleteRetentionPolicyMutex.Unlock()
+	fake.DeleteRetentionPolicyStub = nil
+	fake.deleteRetentionPolicyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) DeleteRetentionPolicyReturnsOnCall(i int, result1 error) {
+	fake.deleteRetentionPolicyMutex.Lock()
+	defer fake.deleteRetentionPolicyMutex.Unlock()
+	fake.DeleteRetentionPolicyStub = nil
+	if fake.deleteRetentionPolicyReturnsOnCall == nil {
+		fake.deleteRetentionPolicyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteRetentionPolicyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) DeleteRiderFeedback(arg1 context.Context, arg2 db.DataRequest) (int, error) {
+	fake.deleteRiderFeedbackMutex.Lock()
+	ret, specificReturn := fake.deleteRiderFeedbackReturnsOnCall[len(fake.deleteRiderFeedbackArgsForCall)]
+	fake.deleteRiderFeedbackArgsForCall = append(fake.deleteRiderFeedbackArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.DataRequest
+	}{arg1, arg2})
+	fake.recordInvocation("DeleteRiderFeedback", []interface{}{arg1, arg2})
+	fake.deleteRiderFeedbackMutex.Unlock()
+	if fake.DeleteRiderFeedbackStub != nil {
+		return fake.DeleteRiderFeedbackStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.deleteRiderFeedbackReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) DeleteRiderFeedbackCallCount() int {
+	fake.deleteRiderFeedbackMutex.RLock()
+	defer fake.deleteRiderFeedbackMutex.RUnlock()
+	return len(fake.deleteRiderFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) DeleteRiderFeedbackCalls(stub func(context.Context, db.DataRequest) (int, error)) {
+	fake.deleteRiderFeedbackMutex.Lock()
+	defer fake.deleteRiderFeedbackMutex.Unlock()
+	fake.DeleteRiderFeedbackStub = stub
+}
+
+func (fake *FakeDB) DeleteRiderFeedbackArgsForCall(i int) (context.Context, db.DataRequest) {
+	fake.deleteRiderFeedbackMutex.RLock()
+	defer fake.deleteRiderFeedbackMutex.RUnlock()
+	argsForCall := fake.deleteRiderFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) DeleteRiderFeedbackReturns(result1 int, result2 error) {
+	fake.deleteRiderFeedbackMutex.Lock()
+	defer fake.deleteRiderFeedbackMutex.Unlock()
+	fake.DeleteRiderFeedbackStub = nil
+	fake.deleteRiderFeedbackReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) DeleteRiderFeedbackReturnsOnCall(i int, result1 int, result2 error) {
+	fake.deleteRiderFeedbackMutex.Lock()
+	defer fake.deleteRiderFeedbackMutex.Unlock()
+	fake.DeleteRiderFeedbackStub = nil
+	if fake.deleteRiderFeedbackReturnsOnCall == nil {
+		fake.deleteRiderFeedbackReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.deleteRiderFeedbackReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) DetachFeedbackPartition(arg1 context.Context, arg2 time.Time) error {
+	fake.detachFeedbackPartitionMutex.Lock()
+	ret, specificReturn := fake.detachFeedbackPartitionReturnsOnCall[len(fake.detachFeedbackPartitionArgsForCall)]
+	fake.detachFeedbackPartitionArgsForCall = append(fake.detachFeedbackPartitionArgsForCall, struct {
+		arg1 context.Context
+		arg2 time.Time
+	}{arg1, arg2})
+	fake.recordInvocation("DetachFeedbackPartition", []interface{}{arg1, arg2})
+	fake.detachFeedbackPartitionMutex.Unlock()
+	if fake.DetachFeedbackPartitionStub != nil {
+		return fake.DetachFeedbackPartitionStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.detachFeedbackPartitionReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) DetachFeedbackPartitionCallCount() int {
+	fake.detachFeedbackPartitionMutex.RLock()
+	defer fake.detachFeedbackPartitionMutex.RUnlock()
+	return len(fake.detachFeedbackPartitionArgsForCall)
+}
+
+func (fake *FakeDB) DetachFeedbackPartitionCalls(stub func(context.Context, time.Time) error) {
+	fake.detachFeedbackPartitionMutex.Lock()
+	defer fake.detachFeedbackPartitionMutex.Unlock()
+	fake.DetachFeedbackPartitionStub = stub
+}
+
+func (fake *FakeDB) DetachFeedbackPartitionArgsForCall(i int) (context.Context, time.Time) {
+	fake.detachFeedbackPartitionMutex.RLock()
+	defer fake.detachFeedbackPartitionMutex.RUnlock()
+	argsForCall := fake.detachFeedbackPartitionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) DetachFeedbackPartitionReturns(result1 error) {
+	fake.detachFeedbackPartitionMutex.Lock()
+	defer fake.detachFeedbackPartitionMutex.Unlock()
+	fake.DetachFeedbackPartitionStub = nil
+	fake.detachFeedbackPartitionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) DetachFeedbackPartitionReturnsOnCall(i int, result1 error) {
+	fake.detachFeedbackPartitionMutex.Lock()
+	defer fake.detachFeedbackPartitionMutex.Unlock()
+	fake.DetachFeedbackPartitionStub = nil
+	if fake.detachFeedbackPartitionReturnsOnCall == nil {
+		fake.detachFeedbackPartitionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.detachFeedbackPartitionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) DropEmail(arg1 context.Context, arg2 int64, arg3 string) error {
+	fake.dropEmailMutex.Lock()
+	ret, specificReturn := fake.dropEmailReturnsOnCall[len(fake.dropEmailArgsForCall)]
+	fake.dropEmailArgsForCall = append(fake.dropEmailArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("DropEmail", []interface{}{arg1, arg2, arg3})
+	fake.dropEmailMutex.Unlock()
+	if fake.DropEmailStub != nil {
+		return fake.DropEmailStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.dropEmailReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) DropEmailCallCount() int {
+	fake.dropEmailMutex.RLock()
+	defer fake.dropEmailMutex.RUnlock()
+	return len(fake.dropEmailArgsForCall)
+}
+
+func (fake *FakeDB) DropEmailCalls(stub func(context.Context, int64, string) error) {
+	fake.dropEmailMutex.Lock()
+	defer fake.dropEmailMutex.Unlock()
+	fake.DropEmailStub = stub
+}
+
+func (fake *FakeDB) DropEmailArgsForCall(i int) (context.Context, int64, string) {
+	fake.dropEmailMutex.RLock()
+	defer fake.dropEmailMutex.RUnlock()
+	argsForCall := fake.dropEmailArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) DropEmailReturns(result1 error) {
+	fake.dropEmailMutex.Lock()
+	defer fake.dropEmailMutex.Unlock()
+	fake.DropEmailStub = nil
+	fake.dropEmailReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) DropEmailReturnsOnCall(i int, result1 error) {
+	fake.dropEmailMutex.Lock()
+	defer fake.dropEmailMutex.Unlock()
+	fake.DropEmailStub = nil
+	if fake.dropEmailReturnsOnCall == nil {
+		fake.dropEmailReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.dropEmailReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) DropFeedbackPartition(arg1 context.Context, arg2 time.Time) error {
+	fake.dropFeedbackPartitionMutex.Lock()
+	ret, specificReturn := fake.dropFeedbackPartitionReturnsOnCall[len(fake.dropFeedbackPartitionArgsForCall)]
+	fake.dropFeedbackPartitionArgsForCall = append(fake.dropFeedbackPartitionArgsForCall, struct {
+		arg1 context.Context
+		arg2 time.Time
+	}{arg1, arg2})
+	fake.recordInvocation("DropFeedbackPartition", []interface{}{arg1, arg2})
+	fake.dropFeedbackPartitionMutex.Unlock()
+	if fake.DropFeedbackPartitionStub != nil {
+		return fake.DropFeedbackPartitionStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.dropFeedbackPartitionReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) DropFeedbackPartitionCallCount() int {
+	fake.dropFeedbackPartitionMutex.RLock()
+	defer fake.dropFeedbackPartitionMutex.RUnlock()
+	return len(fake.dropFeedbackPartitionArgsForCall)
+}
+
+func (fake *FakeDB) DropFeedbackPartitionCalls(stub func(context.Context, time.Time) error) {
+	fake.dropFeedbackPartitionMutex.Lock()
+	defer fake.dropFeedbackPartitionMutex.Unlock()
+	fake.DropFeedbackPartitionStub = stub
+}
+
+func (fake *FakeDB) DropFeedbackPartitionArgsForCall(i int) (context.Context, time.Time) {
+	fake.dropFeedbackPartitionMutex.RLock()
+	defer fake.dropFeedbackPartitionMutex.RUnlock()
+	argsForCall := fake.dropFeedbackPartitionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) DropFeedbackPartitionReturns(result1 error) {
+	fake.dropFeedbackPartitionMutex.Lock()
+	defer fake.dropFeedbackPartitionMutex.Unlock()
+	fake.DropFeedbackPartitionStub = nil
+	fake.dropFeedbackPartitionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) DropFeedbackPartitionReturnsOnCall(i int, result1 error) {
+	fake.dropFeedbackPartitionMutex.Lock()
+	defer fake.dropFeedbackPartitionMutex.Unlock()
+	fake.DropFeedbackPartitionStub = nil
+	if fake.dropFeedbackPartitionReturnsOnCall == nil {
+		fake.dropFeedbackPartitionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.dropFeedbackPartitionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) FindRiderAttachments(arg1 context.Context, arg2 db.RiderSubject) ([]db.Attachment, error) {
+	fake.findRiderAttachmentsMutex.Lock()
+	ret, specificReturn := fake.findRiderAttachmentsReturnsOnCall[len(fake.findRiderAttachmentsArgsForCall)]
+	fake.findRiderAttachmentsArgsForCall = append(fake.findRiderAttachmentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.RiderSubject
+	}{arg1, arg2})
+	fake.recordInvocation("FindRiderAttachments", []interface{}{arg1, arg2})
+	fake.findRiderAttachmentsMutex.Unlock()
+	if fake.FindRiderAttachmentsStub != nil {
+		return fake.FindRiderAttachmentsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.findRiderAttachmentsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) FindRiderAttachmentsCallCount() int {
+	fake.findRiderAttachmentsMutex.RLock()
+	defer fake.findRiderAttachmentsMutex.RUnlock()
+	return len(fake.findRiderAttachmentsArgsForCall)
+}
+
+func (fake *FakeDB) FindRiderAttachmentsCalls(stub func(context.Context, db.RiderSubject) ([]db.Attachment, error)) {
+	fake.findRiderAttachmentsMutex.Lock()
+	defer fake.findRiderAttachmentsMutex.Unlock()
+	fake.FindRiderAttachmentsStub = stub
+}
+
+func (fake *FakeDB) FindRiderAttachmentsArgsForCall(i int) (context.Context, db.RiderSubject) {
+	fake.findRiderAttachmentsMutex.RLock()
+	defer fake.findRiderAttachmentsMutex.RUnlock()
+	argsForCall := fake.findRiderAttachmentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) FindRiderAttachmentsReturns(result1 []db.Attachment, result2 error) {
+	fake.findRiderAttachmentsMutex.Lock()
+	defer fake.findRiderAttachmentsMutex.Unlock()
+	fake.FindRiderAttachmentsStub = nil
+	fake.findRiderAttachmentsReturns = struct {
+		result1 []db.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) FindRiderAttachmentsReturnsOnCall(i int, result1 []db.Attachment, result2 error) {
+	fake.findRiderAttachmentsMutex.Lock()
+	defer fake.findRiderAttachmentsMutex.Unlock()
+	fake.FindRiderAttachmentsStub = nil
+	if fake.findRiderAttachmentsReturnsOnCall == nil {
+		fake.findRiderAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 []db.Attachment
+			result2 error
+		})
+	}
+	fake.findRiderAttachmentsReturnsOnCall[i] = struct {
+		result1 []db.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) FindRiderFeedback(arg1 context.Context, arg2 db.RiderSubject) ([]db.Feedback, error) {
+	fake.findRiderFeedbackMutex.Lock()
+	ret, specificReturn := fake.findRiderFeedbackReturnsOnCall[len(fake.findRiderFeedbackArgsForCall)]
+	fake.findRiderFeedbackArgsForCall = append(fake.findRiderFeedbackArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.RiderSubject
+	}{arg1, arg2})
+	fake.recordInvocation("FindRiderFeedback", []interface{}{arg1, arg2})
+	fake.findRiderFeedbackMutex.Unlock()
+	if fake.FindRiderFeedbackStub != nil {
+		return fake.FindRiderFeedbackStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.findRiderFeedbackReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) FindRiderFeedbackCallCount() int {
+	fake.findRiderFeedbackMutex.RLock()
+	defer fake.findRiderFeedbackMutex.RUnlock()
+	return len(fake.findRiderFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) FindRiderFeedbackCalls(stub func(context.Context, db.RiderSubject) ([]db.Feedback, error)) {
+	fake.findRiderFeedbackMutex.Lock()
+	defer fake.findRiderFeedbackMutex.Unlock()
+	fake.FindRiderFeedbackStub = stub
+}
+
+func (fake *FakeDB) FindRiderFeedbackArgsForCall(i int) (context.Context, db.RiderSubject) {
+	fake.findRiderFeedbackMutex.RLock()
+	defer fake.findRiderFeedbackMutex.RUnlock()
+	argsForCall := fake.findRiderFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) FindRiderFeedbackReturns(result1 []db.Feedback, result2 error) {
+	fake.findRiderFeedbackMutex.Lock()
+	defer fake.findRiderFeedbackMutex.Unlock()
+	fake.FindRiderFeedbackStub = nil
+	fake.findRiderFeedbackReturns = struct {
+		result1 []db.Feedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) FindRiderFeedbackReturnsOnCall(i int, result1 []db.Feedback, result2 error) {
+	fake.findRiderFeedbackMutex.Lock()
+	defer fake.findRiderFeedbackMutex.Unlock()
+	fake.FindRiderFeedbackStub = nil
+	if fake.findRiderFeedbackReturnsOnCall == nil {
+		fake.findRiderFeedbackReturnsOnCall = make(map[int]struct {
+			result1 []db.Feedback
+			result2 error
+		})
+	}
+	fake.findRiderFeedbackReturnsOnCall[i] = struct {
+		result1 []db.Feedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) FindRiderMessages(arg1 context.Context, arg2 db.RiderSubject) ([]db.FeedbackMessage, error) {
+	fake.findRiderMessagesMutex.Lock()
+	ret, specificReturn := fake.findRiderMessagesReturnsOnCall[len(fake.findRiderMessagesArgsForCall)]
+	fake.findRiderMessagesArgsForCall = append(fake.findRiderMessagesArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.RiderSubject
+	}{arg1, arg2})
+	fake.recordInvocation("FindRiderMessages", []interface{}{arg1, arg2})
+	fake.findRiderMessagesMutex.Unlock()
+	if fake.FindRiderMessagesStub != nil {
+		return fake.FindRiderMessagesStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.findRiderMessagesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) FindRiderMessagesCallCount() int {
+	fake.findRiderMessagesMutex.RLock()
+	defer fake.findRiderMessagesMutex.RUnlock()
+	return len(fake.findRiderMessagesArgsForCall)
+}
+
+func (fake *FakeDB) FindRiderMessagesCalls(stub func(context.Context, db.RiderSubject) ([]db.FeedbackMessage, error)) {
+	fake.findRiderMessagesMutex.Lock()
+	defer fake.findRiderMessagesMutex.Unlock()
+	fake.FindRiderMessagesStub = stub
+}
+
+func (fake *FakeDB) FindRiderMessagesArgsForCall(i int) (context.Context, db.RiderSubject) {
+	fake.findRiderMessagesMutex.RLock()
+	defer fake.findRiderMessagesMutex.RUnlock()
+	argsForCall := fake.findRiderMessagesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) FindRiderMessagesReturns(result1 []db.FeedbackMessage, result2 error) {
+	fake.findRiderMessagesMutex.Lock()
+	defer fake.findRiderMessagesMutex.Unlock()
+	fake.FindRiderMessagesStub = nil
+	fake.findRiderMessagesReturns = struct {
+		result1 []db.FeedbackMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) FindRiderMessagesReturnsOnCall(i int, result1 []db.FeedbackMessage, result2 error) {
+	fake.findRiderMessagesMutex.Lock()
+	defer fake.findRiderMessagesMutex.Unlock()
+	fake.FindRiderMessagesStub = nil
+	if fake.findRiderMessagesReturnsOnCall == nil {
+		fake.findRiderMessagesReturnsOnCall = make(map[int]struct {
+			result1 []db.FeedbackMessage
+			result2 error
+		})
+	}
+	fake.findRiderMessagesReturnsOnCall[i] = struct {
+		result1 []db.FeedbackMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) FindRiderSurveyResponses(arg1 context.Context, arg2 db.RiderSubject) ([]db.SurveyResponse, error) {
+	fake.findRiderSurveyResponsesMutex.Lock()
+	ret, specificReturn := fake.findRiderSurveyResponsesReturnsOnCall[len(fake.findRiderSurveyResponsesArgsForCall)]
+	fake.findRiderSurveyResponsesArgsForCall = append(fake.findRiderSurveyResponsesArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.RiderSubject
+	}{arg1, arg2})
+	fake.recordInvocation("FindRiderSurveyResponses", []interface{}{arg1, arg2})
+	fake.findRiderSurveyResponsesMutex.Unlock()
+	if fake.FindRiderSurveyResponsesStub != nil {
+		return fake.FindRiderSurveyResponsesStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.findRiderSurveyResponsesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) FindRiderSurveyResponsesCallCount() int {
+	fake.findRiderSurveyResponsesMutex.RLock()
+	defer fake.findRiderSurveyResponsesMutex.RUnlock()
+	return len(fake.findRiderSurveyResponsesArgsForCall)
+}
+
+func (fake *FakeDB) FindRiderSurveyResponsesCalls(stub func(context.Context, db.RiderSubject) ([]db.SurveyResponse, error)) {
+	fake.findRiderSurveyResponsesMutex.Lock()
+	defer fake.findRiderSurveyResponsesMutex.Unlock()
+	fake.FindRiderSurveyResponsesStub = stub
+}
+
+func (fake *FakeDB) FindRiderSurveyResponsesArgsForCall(i int) (context.Context, db.RiderSubject) {
+	fake.findRiderSurveyResponsesMutex.RLock()
+	defer fake.findRiderSurveyResponsesMutex.RUnlock()
+	argsForCall := fake.findRiderSurveyResponsesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) FindRiderSurveyResponsesReturns(result1 []db.SurveyResponse, result2 error) {
+	fake.findRiderSurveyResponsesMutex.Lock()
+	defer fake.findRiderSurveyResponsesMutex.Unlock()
+	fake.FindRiderSurveyResponsesStub = nil
+	fake.findRiderSurveyResponsesReturns = struct {
+		result1 []db.SurveyResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) FindRiderSurveyResponsesReturnsOnCall(i int, result1 []db.SurveyResponse, result2 error) {
+	fake.findRiderSurveyResponsesMutex.Lock()
+	defer fake.findRiderSurveyResponsesMutex.Unlock()
+	fake.FindRiderSurveyResponsesStub = nil
+	if fake.findRiderSurveyResponsesReturnsOnCall == nil {
+		fake.findRiderSurveyResponsesReturnsOnCall = make(map[int]struct {
+			result1 []db.SurveyResponse
+			result2 error
+		})
+	}
+	fake.findRiderSurveyResponsesReturnsOnCall[i] = struct {
+		result1 []db.SurveyResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetAttachments(arg1 context.Context, arg2 []string) ([]db.Attachment, error) {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.getAttachmentsMutex.Lock()
+	ret, specificReturn := fake.getAttachmentsReturnsOnCall[len(fake.getAttachmentsArgsForCall)]
+	fake.getAttachmentsArgsForCall = append(fake.getAttachmentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 []string
+	}{arg1, arg2Copy})
+	fake.recordInvocation("GetAttachments", []interface{}{arg1, arg2Copy})
+	fake.getAttachmentsMutex.Unlock()
+	if fake.GetAttachmentsStub != nil {
+		return fake.GetAttachmentsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getAttachmentsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) GetAttachmentsCallCount() int {
+	fake.getAttachmentsMutex.RLock()
+	defer fake.getAttachmentsMutex.RUnlock()
+	return len(fake.getAttachmentsArgsForCall)
+}
+
+func (fake *FakeDB) GetAttachmentsCalls(stub func(context.Context, []string) ([]db.Attachment, error)) {
+	fake.getAttachmentsMutex.Lock()
+	defer fake.getAttachmentsMutex.Unlock()
+	fake.GetAttachmentsStub = stub
+}
+
+func (fake *FakeDB) GetAttachmentsArgsForCall(i int) (context.Context, []string) {
+	fake.getAttachmentsMutex.RLock()
+	defer fake.getAttachmentsMutex.RUnlock()
+	argsForCall := fake.getAttachmentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) GetAttachmentsReturns(result1 []db.Attachment, result2 error) {
+	fake.getAttachmentsMutex.Lock()
+	defer fake.getAttachmentsMutex.Unlock()
+	fake.GetAttachmentsStub = nil
+	fake.getAttachmentsReturns = struct {
+		result1 []db.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetAttachmentsReturnsOnCall(i int, result1 []db.Attachment, result2 error) {
+	fake.getAttachmentsMutex.Lock()
+	defer fake.getAttachmentsMutex.Unlock()
+	fake.GetAttachmentsStub = nil
+	if fake.getAttachmentsReturnsOnCall == nil {
+		fake.getAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 []db.Attachment
+			result2 error
+		})
+	}
+	fake.getAttachmentsReturnsOnCall[i] = struct {
+		result1 []db.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetLastAuditEvent(arg1 context.Context) (int64, string, error) {
+	fake.getLastAuditEventMutex.Lock()
+	ret, specificReturn := fake.getLastAuditEventReturnsOnCall[len(fake.getLastAuditEventArgsForCall)]
+	fake.getLastAuditEventArgsForCall = append(fake.getLastAuditEventArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("GetLastAuditEvent", []interface{}{arg1})
+	fake.getLastAuditEventMutex.Unlock()
+	if fake.GetLastAuditEventStub != nil {
+		return fake.GetLastAuditEventStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	fakeReturns := fake.getLastAuditEventReturns
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeDB) GetLastAuditEventCallCount() int {
+	fake.getLastAuditEventMutex.RLock()
+	defer fake.getLastAuditEventMutex.RUnlock()
+	return len(fake.getLastAuditEventArgsForCall)
+}
+
+func (fake *FakeDB) GetLastAuditEventCalls(stub func(context.Context) (int64, string, error)) {
+	fake.getLastAuditEventMutex.Lock()
+	defer fake.getLastAuditEventMutex.Unlock()
+	fake.GetLastAuditEventStub = stub
+}
+
+func (fake *FakeDB) GetLastAuditEventArgsForCall(i int) context.Context {
+	fake.getLastAuditEventMutex.RLock()
+	defer fake.getLastAuditEventMutex.RUnlock()
+	argsForCall := fake.getLastAuditEventArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDB) GetLastAuditEventReturns(result1 int64, result2 string, result3 error) {
+	fake.getLastAuditEventMutex.Lock()
+	defer fake.getLastAuditEventMutex.Unlock()
+	fake.GetLastAuditEventStub = nil
+	fake.getLastAuditEventReturns = struct {
+		result1 int64
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDB) GetLastAuditEventReturnsOnCall(i int, result1 int64, result2 string, result3 error) {
+	fake.getLastAuditEventMutex.Lock()
+	defer fake.getLastAuditEventMutex.Unlock()
+	fake.GetLastAuditEventStub = nil
+	if fake.getLastAuditEventReturnsOnCall == nil {
+		fake.getLastAuditEventReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 string
+			result3 error
+		})
+	}
+	fake.getLastAuditEventReturnsOnCall[i] = struct {
+		result1 int64
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDB) GetModerationDecisions(arg1 context.Context, arg2 string) ([]db.ModerationDecision, error) {
+	fake.getModerationDecisionsMutex.Lock()
+	ret, specificReturn := fake.getModerationDecisionsReturnsOnCall[len(fake.getModerationDecisionsArgsForCall)]
+	fake.getModerationDecisionsArgsForCall = append(fake.getModerationDecisionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetModerationDecisions", []interface{}{arg1, arg2})
+	fake.getModerationDecisionsMutex.Unlock()
+	if fake.GetModerationDecisionsStub != nil {
+		return fake.GetModerationDecisionsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getModerationDecisionsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) GetModerationDecisionsCallCount() int {
+	fake.getModerationDecisionsMutex.RLock()
+	defer fake.getModerationDecisionsMutex.RUnlock()
+	return len(fake.getModerationDecisionsArgsForCall)
+}
+
+func (fake *FakeDB) GetModerationDecisionsCalls(stub func(context.Context, string) ([]db.ModerationDecision, error)) {
+	fake.getModerationDecisionsMutex.Lock()
+	defer fake.getModerationDecisionsMutex.Unlock()
+	fake.GetModerationDecisionsStub = stub
+}
+
+func (fake *FakeDB) GetModerationDecisionsArgsForCall(i int) (context.Context, string) {
+	fake.getModerationDecisionsMutex.RLock()
+	defer fake.getModerationDecisionsMutex.RUnlock()
+	argsForCall := fake.getModerationDecisionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) GetModerationDecisionsReturns(result1 []db.ModerationDecision, result2 error) {
+	fake.getModerationDecisionsMutex.Lock()
+	defer fake.getModerationDecisionsMutex.Unlock()
+	fake.GetModerationDecisionsStub = nil
+	fake.getModerationDecisionsReturns = struct {
+		result1 []db.ModerationDecision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetModerationDecisionsReturnsOnCall(i int, result1 []db.ModerationDecision, result2 error) {
+	fake.getModerationDecisionsMutex.Lock()
+	defer fake.getModerationDecisionsMutex.Unlock()
+	fake.GetModerationDecisionsStub = nil
+	if fake.getModerationDecisionsReturnsOnCall == nil {
+		fake.getModerationDecisionsReturnsOnCall = make(map[int]struct {
+			result1 []db.ModerationDecision
+			result2 error
+		})
+	}
+	fake.getModerationDecisionsReturnsOnCall[i] = struct {
+		result1 []db.ModerationDecision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetOriginalMessage(arg1 context.Context, arg2 string) (*string, error) {
+	fake.getOriginalMessageMutex.Lock()
+	ret, specificReturn := fake.getOriginalMessageReturnsOnCall[len(fake.getOriginalMessageArgsForCall)]
+	fake.getOriginalMessageArgsForCall = append(fake.getOriginalMessageArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetOriginalMessage", []interface{}{arg1, arg2})
+	fake.getOriginalMessageMutex.Unlock()
+	if fake.GetOriginalMessageStub != nil {
+		return fake.GetOriginalMessageStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getOriginalMessageReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) GetOriginalMessageCallCount() int {
+	fake.getOriginalMessageMutex.RLock()
+	defer fake.getOriginalMessageMutex.RUnlock()
+	return len(fake.getOriginalMessageArgsForCall)
+}
+
+func (fake *FakeDB) GetOriginalMessageCalls(stub func(context.Context, string) (*string, error)) {
+	fake.getOriginalMessageMutex.Lock()
+	defer fake.getOriginalMessageMutex.Unlock()
+	fake.GetOriginalMessageStub = stub
+}
+
+func (fake *FakeDB) GetOriginalMessageArgsForCall(i int) (context.Context, string) {
+	fake.getOriginalMessageMutex.RLock()
+	defer fake.getOriginalMessageMutex.RUnlock()
+	argsForCall := fake.getOriginalMessageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) GetOriginalMessageReturns(result1 *string, result2 error) {
+	fake.getOriginalMessageMutex.Lock()
+	defer fake.getOriginalMessageMutex.Unlock()
+	fake.GetOriginalMessageStub = nil
+	fake.getOriginalMessageReturns = struct {
+		result1 *string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetOriginalMessageReturnsOnCall(i int, result1 *string, result2 error) {
+	fake.getOriginalMessageMutex.Lock()
+	defer fake.getOriginalMessageMutex.Unlock()
+	fake.GetOriginalMessageStub = nil
+	if fake.getOriginalMessageReturnsOnCall == nil {
+		fake.getOriginalMessageReturnsOnCall = make(map[int]struct {
+			result1 *string
+			result2 error
+		})
+	}
+	fake.getOriginalMessageReturnsOnCall[i] = struct {
+		result1 *string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetRecentOutages(arg1 context.Context, arg2 time.Time) ([]db.Feedback, error) {
+	fake.getRecentOutagesMutex.Lock()
+	ret, specificReturn := fake.getRecentOutagesReturnsOnCall[len(fake.getRecentOutagesArgsForCall)]
+	fake.getRecentOutagesArgsForCall = append(fake.getRecentOutagesArgsForCall, struct {
+		arg1 context.Context
+		arg2 time.Time
+	}{arg1, arg2})
+	fake.recordInvocation("GetRecentOutages", []interface{}{arg1, arg2})
+	fake.getRecentOutagesMutex.Unlock()
+	if fake.GetRecentOutagesStub != nil {
+		return fake.GetRecentOutagesStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getRecentOutagesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) GetRecentOutagesCallCount() int {
+	fake.getRecentOutagesMutex.RLock()
+	defer fake.getRecentOutagesMutex.RUnlock()
+	return len(fake.getRecentOutagesArgsForCall)
+}
+
+func (fake *FakeDB) GetRecentOutagesCalls(stub func(context.Context, time.Time) ([]db.Feedback, error)) {
+	fake.getRecentOutagesMutex.Lock()
+	defer fake.getRecentOutagesMutex.Unlock()
+	fake.GetRecentOutagesStub = stub
+}
+
+func (fake *FakeDB) GetRecentOutagesArgsForCall(i int) (context.Context, time.Time) {
+	fake.getRecentOutagesMutex.RLock()
+	defer fake.getRecentOutagesMutex.RUnlock()
+	argsForCall := fake.getRecentOutagesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) GetRecentOutagesReturns(result1 []db.Feedback, result2 error) {
+	fake.getRecentOutagesMutex.Lock()
+	defer fake.getRecentOutagesMutex.Unlock()
+	fake.GetRecentOutagesStub = nil
+	fake.getRecentOutagesReturns = struct {
+		result1 []db.Feedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetRecentOutagesReturnsOnCall(i int, result1 []db.Feedback, result2 error) {
+	fake.getRecentOutagesMutex.Lock()
+	defer fake.getRecentOutagesMutex.Unlock()
+	fake.GetRecentOutagesStub = nil
+	if fake.getRecentOutagesReturnsOnCall == nil {
+		fake.getRecentOutagesReturnsOnCall = make(map[int]struct {
+			result1 []db.Feedback
+			result2 error
+		})
+	}
+	fake.getRecentOutagesReturnsOnCall[i] = struct {
+		result1 []db.Feedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetRetentionPolicies(arg1 context.Context) ([]db.RetentionPolicy, error) {
+	fake.getRetentionPoliciesMutex.Lock()
+	ret, specificReturn := fake.getRetentionPoliciesReturnsOnCall[len(fake.getRetentionPoliciesArgsForCall)]
+	fake.getRetentionPoliciesArgsForCall = append(fake.getRetentionPoliciesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("GetRetentionPolicies", []interface{}{arg1})
+	fake.getRetentionPoliciesMutex.Unlock()
+	if fake.GetRetentionPoliciesStub != nil {
+		return fake.GetRetentionPoliciesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getRetentionPoliciesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) GetRetentionPoliciesCallCount() int {
+	fake.getRetentionPoliciesMutex.RLock()
+	defer fake.getRetentionPoliciesMutex.RUnlock()
+	return len(fake.getRetentionPoliciesArgsForCall)
+}
+
+func (fake *FakeDB) GetRetentionPoliciesCalls(stub func(context.Context) ([]db.RetentionPolicy, error)) {
+	fake.getRetentionPoliciesMutex.Lock()
+	defer fake.getRetentionPoliciesMutex.Unlock()
+	fake.GetRetentionPoliciesStub = stub
+}
+
+func (fake *FakeDB) GetRetentionPoliciesArgsForCall(i int) context.Context {
+	fake.getRetentionPoliciesMutex.RLock()
+	defer fake.getRetentionPoliciesMutex.RUnlock()
+	argsForCall := fake.getRetentionPoliciesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDB) GetRetentionPoliciesReturns(result1 []db.RetentionPolicy, result2 error) {
+	fake.getRetentionPoliciesMutex.Lock()
+	defer fake.getRetentionPoliciesMutex.Unlock()
+	fake.GetRetentionPoliciesStub = nil
+	fake.getRetentionPoliciesReturns = struct {
+		result1 []db.RetentionPolicy
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetRetentionPoliciesReturnsOnCall(i int, result1 []db.RetentionPolicy, result2 error) {
+	fake.getRetentionPoliciesMutex.Lock()
+	defer fake.getRetentionPoliciesMutex.Unlock()
+	fake.GetRetentionPoliciesStub = nil
+	if fake.getRetentionPoliciesReturnsOnCall == nil {
+		fake.getRetentionPoliciesReturnsOnCall = make(map[int]struct {
+			result1 []db.RetentionPolicy
+			result2 error
+		})
+	}
+	fake.getRetentionPoliciesReturnsOnCall[i] = struct {
+		result1 []db.RetentionPolicy
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetSurveyVersion(arg1 context.Context, arg2 string, arg3 *int) (db.SurveyVersion, error) {
+	fake.getSurveyVersionMutex.Lock()
+	ret, specificReturn := fake.getSurveyVersionReturnsOnCall[len(fake.getSurveyVersionArgsForCall)]
+	fake.getSurveyVersionArgsForCall = append(fake.getSurveyVersionArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *int
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("GetSurveyVersion", []interface{}{arg1, arg2, arg3})
+	fake.getSurveyVersionMutex.Unlock()
+	if fake.GetSurveyVersionStub != nil {
+		return fake.GetSurveyVersionStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getSurveyVersionReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) GetSurveyVersionCallCount() int {
+	fake.getSurveyVersionMutex.RLock()
+	defer fake.getSurveyVersionMutex.RUnlock()
+	return len(fake.getSurveyVersionArgsForCall)
+}
+
+func (fake *FakeDB) GetSurveyVersionCalls(stub func(context.Context, string, *int) (db.SurveyVersion, error)) {
+	fake.getSurveyVersionMutex.Lock()
+	defer fake.getSurveyVersionMutex.Unlock()
+	fake.GetSurveyVersionStub = stub
+}
+
+func (fake *FakeDB) GetSurveyVersionArgsForCall(i int) (context.Context, string, *int) {
+	fake.getSurveyVersionMutex.RLock()
+	defer fake.getSurveyVersionMutex.RUnlock()
+	argsForCall := fake.getSurveyVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) GetSurveyVersionReturns(result1 db.SurveyVersion, result2 error) {
+	fake.getSurveyVersionMutex.Lock()
+	defer fake.getSurveyVersionMutex.Unlock()
+	fake.GetSurveyVersionStub = nil
+	fake.getSurveyVersionReturns = struct {
+		result1 db.SurveyVersion
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetSurveyVersionReturnsOnCall(i int, result1 db.SurveyVersion, result2 error) {
+	fake.getSurveyVersionMutex.Lock()
+	defer fake.getSurveyVersionMutex.Unlock()
+	fake.GetSurveyVersionStub = nil
+	if fake.getSurveyVersionReturnsOnCall == nil {
+		fake.getSurveyVersionReturnsOnCall = make(map[int]struct {
+			result1 db.SurveyVersion
+			result2 error
+		})
+	}
+	fake.getSurveyVersionReturnsOnCall[i] = struct {
+		result1 db.SurveyVersion
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetTriageTransitions(arg1 context.Context, arg2 string) ([]db.TriageTransition, error) {
+	fake.getTriageTransitionsMutex.Lock()
+	ret, specificReturn := fake.getTriageTransitionsReturnsOnCall[len(fake.getTriageTransitionsArgsForCall)]
+	fake.getTriageTransitionsArgsForCall = append(fake.getTriageTransitionsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetTriageTransitions", []interface{}{arg1, arg2})
+	fake.getTriageTransitionsMutex.Unlock()
+	if fake.GetTriageTransitionsStub != nil {
+		return fake.GetTriageTransitionsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getTriageTransitionsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) GetTriageTransitionsCallCount() int {
+	fake.getTriageTransitionsMutex.RLock()
+	defer fake.getTriageTransitionsMutex.RUnlock()
+	return len(fake.getTriageTransitionsArgsForCall)
+}
+
+func (fake *FakeDB) GetTriageTransitionsCalls(stub func(context.Context, string) ([]db.TriageTransition, error)) {
+	fake.getTriageTransitionsMutex.Lock()
+	defer fake.getTriageTransitionsMutex.Unlock()
+	fake.GetTriageTransitionsStub = stub
+}
+
+func (fake *FakeDB) GetTriageTransitionsArgsForCall(i int) (context.Context, string) {
+	fake.getTriageTransitionsMutex.RLock()
+	defer fake.getTriageTransitionsMutex.RUnlock()
+	argsForCall := fake.getTriageTransitionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) GetTriageTransitionsReturns(result1 []db.TriageTransition, result2 error) {
+	fake.getTriageTransitionsMutex.Lock()
+	defer fake.getTriageTransitionsMutex.Unlock()
+	fake.GetTriageTransitionsStub = nil
+	fake.getTriageTransitionsReturns = struct {
+		result1 []db.TriageTransition
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) GetTriageTransitionsReturnsOnCall(i int, result1 []db.TriageTransition, result2 error) {
+	fake.getTriageTransitionsMutex.Lock()
+	defer fake.getTriageTransitionsMutex.Unlock()
+	fake.GetTriageTransitionsStub = nil
+	if fake.getTriageTransitionsReturnsOnCall == nil {
+		fake.getTriageTransitionsReturnsOnCall = make(map[int]struct {
+			result1 []db.TriageTransition
+			result2 error
+		})
+	}
+	fake.getTriageTransitionsReturnsOnCall[i] = struct {
+		result1 []db.TriageTransition
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) InTransaction(arg1 context.Context, arg2 func(ctx context.Context) error) error {
+	fake.inTransactionMutex.Lock()
+	ret, specificReturn := fake.inTransactionReturnsOnCall[len(fake.inTransactionArgsForCall)]
+	fake.inTransactionArgsForCall = append(fake.inTransactionArgsForCall, struct {
+		arg1 context.Context
+		arg2 func(ctx context.Context) error
+	}{arg1, arg2})
+	fake.recordInvocation("InTransaction", []interface{}{arg1, arg2})
+	fake.inTransactionMutex.Unlock()
+	if fake.InTransactionStub != nil {
+		return fake.InTransactionStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.inTransactionReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) InTransactionCallCount() int {
+	fake.inTransactionMutex.RLock()
+	defer fake.inTransactionMutex.RUnlock()
+	return len(fake.inTransactionArgsForCall)
+}
+
+func (fake *FakeDB) InTransactionCalls(stub func(context.Context, func(ctx context.Context) error) error) {
+	fake.inTransactionMutex.Lock()
+	defer fake.inTransactionMutex.Unlock()
+	fake.InTransactionStub = stub
+}
+
+func (fake *FakeDB) InTransactionArgsForCall(i int) (context.Context, func(ctx context.Context) error) {
+	fake.inTransactionMutex.RLock()
+	defer fake.inTransactionMutex.RUnlock()
+	argsForCall := fake.inTransactionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) InTransactionReturns(result1 error) {
+	fake.inTransactionMutex.Lock()
+	defer fake.inTransactionMutex.Unlock()
+	fake.InTransactionStub = nil
+	fake.inTransactionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) InTransactionReturnsOnCall(i int, result1 error) {
+	fake.inTransactionMutex.Lock()
+	defer fake.inTransactionMutex.Unlock()
+	fake.InTransactionStub = nil
+	if fake.inTransactionReturnsOnCall == nil {
+		fake.inTransactionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.inTransactionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) IsEmailUnsubscribed(arg1 context.Context, arg2 string) (bool, error) {
+	fake.isEmailUnsubscribedMutex.Lock()
+	ret, specificReturn := fake.isEmailUnsubscribedReturnsOnCall[len(fake.isEmailUnsubscribedArgsForCall)]
+	fake.isEmailUnsubscribedArgsForCall = append(fake.isEmailUnsubscribedArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("IsEmailUnsubscribed", []interface{}{arg1, arg2})
+	fake.isEmailUnsubscribedMutex.Unlock()
+	if fake.IsEmailUnsubscribedStub != nil {
+		return fake.IsEmailUnsubscribedStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.isEmailUnsubscribedReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) IsEmailUnsubscribedCallCount() int {
+	fake.isEmailUnsubscribedMutex.RLock()
+	defer fake.isEmailUnsubscribedMutex.RUnlock()
+	return len(fake.isEmailUnsubscribedArgsForCall)
+}
+
+func (fake *FakeDB) IsEmailUnsubscribedCalls(stub func(context.Context, string) (bool, error)) {
+	fake.isEmailUnsubscribedMutex.Lock()
+	defer fake.isEmailUnsubscribedMutex.Unlock()
+	fake.IsEmailUnsubscribedStub = stub
+}
+
+func (fake *FakeDB) IsEmailUnsubscribedArgsForCall(i int) (context.Context, string) {
+	fake.isEmailUnsubscribedMutex.RLock()
+	defer fake.isEmailUnsubscribedMutex.RUnlock()
+	argsForCall := fake.isEmailUnsubscribedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) IsEmailUnsubscribedReturns(result1 bool, result2 error) {
+	fake.isEmailUnsubscribedMutex.Lock()
+	defer fake.isEmailUnsubscribedMutex.Unlock()
+	fake.IsEmailUnsubscribedStub = nil
+	fake.isEmailUnsubscribedReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) IsEmailUnsubscribedReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.isEmailUnsubscribedMutex.Lock()
+	defer fake.isEmailUnsubscribedMutex.Unlock()
+	fake.IsEmailUnsubscribedStub = nil
+	if fake.isEmailUnsubscribedReturnsOnCall == nil {
+		fake.isEmailUnsubscribedReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.isEmailUnsubscribedReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListAuditEvents(arg1 context.Context, arg2 db.AuditFilter, arg3 int, arg4 int) ([]db.AuditEvent, error) {
+	fake.listAuditEventsMutex.Lock()
+	ret, specificReturn := fake.listAuditEventsReturnsOnCall[len(fake.listAuditEventsArgsForCall)]
+	fake.listAuditEventsArgsForCall = append(fake.listAuditEventsArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.AuditFilter
+		arg3 int
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("ListAuditEvents", []interface{}{arg1, arg2, arg3, arg4})
+	fake.listAuditEventsMutex.Unlock()
+	if fake.ListAuditEventsStub != nil {
+		return fake.ListAuditEventsStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listAuditEventsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListAuditEventsCallCount() int {
+	fake.listAuditEventsMutex.RLock()
+	defer fake.listAuditEventsMutex.RUnlock()
+	return len(fake.listAuditEventsArgsForCall)
+}
+
+func (fake *FakeDB) ListAuditEventsCalls(stub func(context.Context, db.AuditFilter, int, int) ([]db.AuditEvent, error)) {
+	fake.listAuditEventsMutex.Lock()
+	defer fake.listAuditEventsMutex.Unlock()
+	fake.ListAuditEventsStub = stub
+}
+
+func (fake *FakeDB) ListAuditEventsArgsForCall(i int) (context.Context, db.AuditFilter, int, int) {
+	fake.listAuditEventsMutex.RLock()
+	defer fake.listAuditEventsMutex.RUnlock()
+	argsForCall := fake.listAuditEventsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) ListAuditEventsReturns(result1 []db.AuditEvent, result2 error) {
+	fake.listAuditEventsMutex.Lock()
+	defer fake.listAuditEventsMutex.Unlock()
+	fake.ListAuditEventsStub = nil
+	fake.listAuditEventsReturns = struct {
+		result1 []db.AuditEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListAuditEventsReturnsOnCall(i int, result1 []db.AuditEvent, result2 error) {
+	fake.listAuditEventsMutex.Lock()
+	defer fake.listAuditEventsMutex.Unlock()
+	fake.ListAuditEventsStub = nil
+	if fake.listAuditEventsReturnsOnCall == nil {
+		fake.listAuditEventsReturnsOnCall = make(map[int]struct {
+			result1 []db.AuditEvent
+			result2 error
+		})
+	}
+	fake.listAuditEventsReturnsOnCall[i] = struct {
+		result1 []db.AuditEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListAuditEventsAfter(arg1 context.Context, arg2 int64, arg3 int) ([]db.AuditEvent, error) {
+	fake.listAuditEventsAfterMutex.Lock()
+	ret, specificReturn := fake.listAuditEventsAfterReturnsOnCall[len(fake.listAuditEventsAfterArgsForCall)]
+	fake.listAuditEventsAfterArgsForCall = append(fake.listAuditEventsAfterArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 int
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("ListAuditEventsAfter", []interface{}{arg1, arg2, arg3})
+	fake.listAuditEventsAfterMutex.Unlock()
+	if fake.ListAuditEventsAfterStub != nil {
+		return fake.ListAuditEventsAfterStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listAuditEventsAfterReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListAuditEventsAfterCallCount() int {
+	fake.listAuditEventsAfterMutex.RLock()
+	defer fake.listAuditEventsAfterMutex.RUnlock()
+	return len(fake.listAuditEventsAfterArgsForCall)
+}
+
+func (fake *FakeDB) ListAuditEventsAfterCalls(stub func(context.Context, int64, int) ([]db.AuditEvent, error)) {
+	fake.listAuditEventsAfterMutex.Lock()
+	defer fake.listAuditEventsAfterMutex.Unlock()
+	fake.ListAuditEventsAfterStub = stub
+}
+
+func (fake *FakeDB) ListAuditEventsAfterArgsForCall(i int) (context.Context, int64, int) {
+	fake.listAuditEventsAfterMutex.RLock()
+	defer fake.listAuditEventsAfterMutex.RUnlock()
+	argsForCall := fake.listAuditEventsAfterArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) ListAuditEventsAfterReturns(result1 []db.AuditEvent, result2 error) {
+	fake.listAuditEventsAfterMutex.Lock()
+	defer fake.listAuditEventsAfterMutex.Unlock()
+	fake.ListAuditEventsAfterStub = nil
+	fake.listAuditEventsAfterReturns = struct {
+		result1 []db.AuditEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListAuditEventsAfterReturnsOnCall(i int, result1 []db.AuditEvent, result2 error) {
+	fake.listAuditEventsAfterMutex.Lock()
+	defer fake.listAuditEventsAfterMutex.Unlock()
+	fake.ListAuditEventsAfterStub = nil
+	if fake.listAuditEventsAfterReturnsOnCall == nil {
+		fake.listAuditEventsAfterReturnsOnCall = make(map[int]struct {
+			result1 []db.AuditEvent
+			result2 error
+		})
+	}
+	fake.listAuditEventsAfterReturnsOnCall[i] = struct {
+		result1 []db.AuditEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListDiscardedAttachments(arg1 context.Context, arg2 time.Time, arg3 int) ([]db.Attachment, error) {
+	fake.listDiscardedAttachmentsMutex.Lock()
+	ret, specificReturn := fake.listDiscardedAttachmentsReturnsOnCall[len(fake.listDiscardedAttachmentsArgsForCall)]
+	fake.listDiscardedAttachmentsArgsForCall = append(fake.listDiscardedAttachmentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 time.Time
+		arg3 int
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("ListDiscardedAttachments", []interface{}{arg1, arg2, arg3})
+	fake.listDiscardedAttachmentsMutex.Unlock()
+	if fake.ListDiscardedAttachmentsStub != nil {
+		return fake.ListDiscardedAttachmentsStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listDiscardedAttachmentsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListDiscardedAttachmentsCallCount() int {
+	fake.listDiscardedAttachmentsMutex.RLock()
+	defer fake.listDiscardedAttachmentsMutex.RUnlock()
+	return len(fake.listDiscardedAttachmentsArgsForCall)
+}
+
+func (fake *FakeDB) ListDiscardedAttachmentsCalls(stub func(context.Context, time.Time, int) ([]db.Attachment, error)) {
+	fake.listDiscardedAttachmentsMutex.Lock()
+	defer fake.listDiscardedAttachmentsMutex.Unlock()
+	fake.ListDiscardedAttachmentsStub = stub
+}
+
+func (fake *FakeDB) ListDiscardedAttachmentsArgsForCall(i int) (context.Context, time.Time, int) {
+	fake.listDiscardedAttachmentsMutex.RLock()
+	defer fake.listDiscardedAttachmentsMutex.RUnlock()
+	argsForCall := fake.listDiscardedAttachmentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) ListDiscardedAttachmentsReturns(result1 []db.Attachment, result2 error) {
+	fake.listDiscardedAttachmentsMutex.Lock()
+	defer fake.listDiscardedAttachmentsMutex.Unlock()
+	fake.ListDiscardedAttachmentsStub = nil
+	fake.listDiscardedAttachmentsReturns = struct {
+		result1 []db.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListDiscardedAttachmentsReturnsOnCall(i int, result1 []db.Attachment, result2 error) {
+	fake.listDiscardedAttachmentsMutex.Lock()
+	defer fake.listDiscardedAttachmentsMutex.Unlock()
+	fake.ListDiscardedAttachmentsStub = nil
+	if fake.listDiscardedAttachmentsReturnsOnCall == nil {
+		fake.listDiscardedAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 []db.Attachment
+			result2 error
+		})
+	}
+	fake.listDiscardedAttachmentsReturnsOnCall[i] = struct {
+		result1 []db.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListEmailsToRotate(arg1 context.Context, arg2 string, arg3 int) ([]db.StoredEmail, error) {
+	fake.listEmailsToRotateMutex.Lock()
+	ret, specificReturn := fake.listEmailsToRotateReturnsOnCall[len(fake.listEmailsToRotateArgsForCall)]
+	fake.listEmailsToRotateArgsForCall = append(fake.listEmailsToRotateArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("ListEmailsToRotate", []interface{}{arg1, arg2, arg3})
+	fake.listEmailsToRotateMutex.Unlock()
+	if fake.ListEmailsToRotateStub != nil {
+		return fake.ListEmailsToRotateStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listEmailsToRotateReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListEmailsToRotateCallCount() int {
+	fake.listEmailsToRotateMutex.RLock()
+	defer fake.listEmailsToRotateMutex.RUnlock()
+	return len(fake.listEmailsToRotateArgsForCall)
+}
+
+func (fake *FakeDB) ListEmailsToRotateCalls(stub func(context.Context, string, int) ([]db.StoredEmail, error)) {
+	fake.listEmailsToRotateMutex.Lock()
+	defer fake.listEmailsToRotateMutex.Unlock()
+	fake.ListEmailsToRotateStub = stub
+}
+
+func (fake *FakeDB) ListEmailsToRotateArgsForCall(i int) (context.Context, string, int) {
+	fake.listEmailsToRotateMutex.RLock()
+	defer fake.listEmailsToRotateMutex.RUnlock()
+	argsForCall := fake.listEmailsToRotateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) ListEmailsToRotateReturns(result1 []db.StoredEmail, result2 error) {
+	fake.listEmailsToRotateMutex.Lock()
+	defer fake.listEmailsToRotateMutex.Unlock()
+	fake.ListEmailsToRotateStub = nil
+	fake.listEmailsToRotateReturns = struct {
+		result1 []db.StoredEmail
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListEmailsToRotateReturnsOnCall(i int, result1 []db.StoredEmail, result2 error) {
+	fake.listEmailsToRotateMutex.Lock()
+	defer fake.listEmailsToRotateMutex.Unlock()
+	fake.ListEmailsToRotateStub = nil
+	if fake.listEmailsToRotateReturnsOnCall == nil {
+		fake.listEmailsToRotateReturnsOnCall = make(map[int]struct {
+			result1 []db.StoredEmail
+			result2 error
+		})
+	}
+	fake.listEmailsToRotateReturnsOnCall[i] = struct {
+		result1 []db.StoredEmail
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedback(arg1 context.Context, arg2 db.FeedbackFilter, arg3 int, arg4 int) ([]db.Feedback, error) {
+	fake.listFeedbackMutex.Lock()
+	ret, specificReturn := fake.listFeedbackReturnsOnCall[len(fake.listFeedbackArgsForCall)]
+	fake.listFeedbackArgsForCall = append(fake.listFeedbackArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.FeedbackFilter
+		arg3 int
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("ListFeedback", []interface{}{arg1, arg2, arg3, arg4})
+	fake.listFeedbackMutex.Unlock()
+	if fake.ListFeedbackStub != nil {
+		return fake.ListFeedbackStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listFeedbackReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListFeedbackCallCount() int {
+	fake.listFeedbackMutex.RLock()
+	defer fake.listFeedbackMutex.RUnlock()
+	return len(fake.listFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) ListFeedbackCalls(stub func(context.Context, db.FeedbackFilter, int, int) ([]db.Feedback, error)) {
+	fake.listFeedbackMutex.Lock()
+	defer fake.listFeedbackMutex.Unlock()
+	fake.ListFeedbackStub = stub
+}
+
+func (fake *FakeDB) ListFeedbackArgsForCall(i int) (context.Context, db.FeedbackFilter, int, int) {
+	fake.listFeedbackMutex.RLock()
+	defer fake.listFeedbackMutex.RUnlock()
+	argsForCall := fake.listFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) ListFeedbackReturns(result1 []db.Feedback, result2 error) {
+	fake.listFeedbackMutex.Lock()
+	defer fake.listFeedbackMutex.Unlock()
+	fake.ListFeedbackStub = nil
+	fake.listFeedbackReturns = struct {
+		result1 []db.Feedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackReturnsOnCall(i int, result1 []db.Feedback, result2 error) {
+	fake.listFeedbackMutex.Lock()
+	defer fake.listFeedbackMutex.Unlock()
+	fake.ListFeedbackStub = nil
+	if fake.listFeedbackReturnsOnCall == nil {
+		fake.listFeedbackReturnsOnCall = make(map[int]struct {
+			result1 []db.Feedback
+			result2 error
+		})
+	}
+	fake.listFeedbackReturnsOnCall[i] = struct {
+		result1 []db.Feedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackAttachments(arg1 context.Context, arg2 string) ([]db.Attachment, error) {
+	fake.listFeedbackAttachmentsMutex.Lock()
+	ret, specificReturn := fake.listFeedbackAttachmentsReturnsOnCall[len(fake.listFeedbackAttachmentsArgsForCall)]
+	fake.listFeedbackAttachmentsArgsForCall = append(fake.listFeedbackAttachmentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("ListFeedbackAttachments", []interface{}{arg1, arg2})
+	fake.listFeedbackAttachmentsMutex.Unlock()
+	if fake.ListFeedbackAttachmentsStub != nil {
+		return fake.ListFeedbackAttachmentsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listFeedbackAttachmentsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListFeedbackAttachmentsCallCount() int {
+	fake.listFeedbackAttachmentsMutex.RLock()
+	defer fake.listFeedbackAttachmentsMutex.RUnlock()
+	return len(fake.listFeedbackAttachmentsArgsForCall)
+}
+
+func (fake *FakeDB) ListFeedbackAttachmentsCalls(stub func(context.Context, string) ([]db.Attachment, error)) {
+	fake.listFeedbackAttachmentsMutex.Lock()
+	defer fake.listFeedbackAttachmentsMutex.Unlock()
+	fake.ListFeedbackAttachmentsStub = stub
+}
+
+func (fake *FakeDB) ListFeedbackAttachmentsArgsForCall(i int) (context.Context, string) {
+	fake.listFeedbackAttachmentsMutex.RLock()
+	defer fake.listFeedbackAttachmentsMutex.RUnlock()
+	argsForCall := fake.listFeedbackAttachmentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) ListFeedbackAttachmentsReturns(result1 []db.Attachment, result2 error) {
+	fake.listFeedbackAttachmentsMutex.Lock()
+	defer fake.listFeedbackAttachmentsMutex.Unlock()
+	fake.ListFeedbackAttachmentsStub = nil
+	fake.listFeedbackAttachmentsReturns = struct {
+		result1 []db.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackAttachmentsReturnsOnCall(i int, result1 []db.Attachment, result2 error) {
+	fake.listFeedbackAttachmentsMutex.Lock()
+	defer fake.listFeedbackAttachmentsMutex.Unlock()
+	fake.ListFeedbackAttachmentsStub = nil
+	if fake.listFeedbackAttachmentsReturnsOnCall == nil {
+		fake.listFeedbackAttachmentsReturnsOnCall = make(map[int]struct {
+			result1 []db.Attachment
+			result2 error
+		})
+	}
+	fake.listFeedbackAttachmentsReturnsOnCall[i] = struct {
+		result1 []db.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackByEmail(arg1 context.Context, arg2 string, arg3 int, arg4 int) ([]db.Feedback, error) {
+	fake.listFeedbackByEmailMutex.Lock()
+	ret, specificReturn := fake.listFeedbackByEmailReturnsOnCall[len(fake.listFeedbackByEmailArgsForCall)]
+	fake.listFeedbackByEmailArgsForCall = append(fake.listFeedbackByEmailArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("ListFeedbackByEmail", []interface{}{arg1, arg2, arg3, arg4})
+	fake.listFeedbackByEmailMutex.Unlock()
+	if fake.ListFeedbackByEmailStub != nil {
+		return fake.ListFeedbackByEmailStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listFeedbackByEmailReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListFeedbackByEmailCallCount() int {
+	fake.listFeedbackByEmailMutex.RLock()
+	defer fake.listFeedbackByEmailMutex.RUnlock()
+	return len(fake.listFeedbackByEmailArgsForCall)
+}
+
+func (fake *FakeDB) ListFeedbackByEmailCalls(stub func(context.Context, string, int, int) ([]db.Feedback, error)) {
+	fake.listFeedbackByEmailMutex.Lock()
+	defer fake.listFeedbackByEmailMutex.Unlock()
+	fake.ListFeedbackByEmailStub = stub
+}
+
+func (fake *FakeDB) ListFeedbackByEmailArgsForCall(i int) (context.Context, string, int, int) {
+	fake.listFeedbackByEmailMutex.RLock()
+	defer fake.listFeedbackByEmailMutex.RUnlock()
+	argsForCall := fake.listFeedbackByEmailArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) ListFeedbackByEmailReturns(result1 []db.Feedback, result2 error) {
+	fake.listFeedbackByEmailMutex.Lock()
+	defer fake.listFeedbackByEmailMutex.Unlock()
+	fake.ListFeedbackByEmailStub = nil
+	fake.listFeedbackByEmailReturns = struct {
+		result1 []db.Feedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackByEmailReturnsOnCall(i int, result1 []db.Feedback, result2 error) {
+	fake.listFeedbackByEmailMutex.Lock()
+	defer fake.listFeedbackByEmailMutex.Unlock()
+	fake.ListFeedbackByEmailStub = nil
+	if fake.listFeedbackByEmailReturnsOnCall == nil {
+		fake.listFeedbackByEmailReturnsOnCall = make(map[int]struct {
+			result1 []db.Feedback
+			result2 error
+		})
+	}
+	fake.listFeedbackByEmailReturnsOnCall[i] = struct {
+		result1 []db.Feedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackByModerationStatus(arg1 context.Context, arg2 string, arg3 int, arg4 int) ([]db.Feedback, error) {
+	fake.listFeedbackByModerationStatusMutex.Lock()
+	ret, specificReturn := fake.listFeedbackByModerationStatusReturnsOnCall[len(fake.listFeedbackByModerationStatusArgsForCall)]
+	fake.listFeedbackByModerationStatusArgsForCall = append(fake.listFeedbackByModerationStatusArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("ListFeedbackByModerationStatus", []interface{}{arg1, arg2, arg3, arg4})
+	fake.listFeedbackByModerationStatusMutex.Unlock()
+	if fake.ListFeedbackByModerationStatusStub != nil {
+		return fake.ListFeedbackByModerationStatusStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listFeedbackByModerationStatusReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListFeedbackByModerationStatusCallCount() int {
+	fake.listFeedbackByModerationStatusMutex.RLock()
+	defer fake.listFeedbackByModerationStatusMutex.RUnlock()
+	return len(fake.listFeedbackByModerationStatusArgsForCall)
+}
+
+func (fake *FakeDB) ListFeedbackByModerationStatusCalls(stub func(context.Context, string, int, int) ([]db.Feedback, error)) {
+	fake.listFeedbackByModerationStatusMutex.Lock()
+	defer fake.listFeedbackByModerationStatusMutex.Unlock()
+	fake.ListFeedbackByModerationStatusStub = stub
+}
+
+func (fake *FakeDB) ListFeedbackByModerationStatusArgsForCall(i int) (context.Context, string, int, int) {
+	fake.listFeedbackByModerationStatusMutex.RLock()
+	defer fake.listFeedbackByModerationStatusMutex.RUnlock()
+	argsForCall := fake.listFeedbackByModerationStatusArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) ListFeedbackByModerationStatusReturns(result1 []db.Feedback, result2 error) {
+	fake.listFeedbackByModerationStatusMutex.Lock()
+	defer fake.listFeedbackByModerationStatusMutex.Unlock()
+	fake.ListFeedbackByModerationStatusStub = nil
+	fake.listFeedbackByModerationStatusReturns = struct {
+		result1 []db.Feedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackByModerationStatusReturnsOnCall(i int, result1 []db.Feedback, result2 error) {
+	fake.listFeedbackByModerationStatusMutex.Lock()
+	defer fake.listFeedbackByModerationStatusMutex.Unlock()
+	fake.ListFeedbackByModerationStatusStub = nil
+	if fake.listFeedbackByModerationStatusReturnsOnCall == nil {
+		fake.listFeedbackByModerationStatusReturnsOnCall = make(map[int]struct {
+			result1 []db.Feedback
+			result2 error
+		})
+	}
+	fake.listFeedbackByModerationStatusReturnsOnCall[i] = struct {
+		result1 []db.Feedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackKinds(arg1 context.Context) ([]db.FeedbackKind, error) {
+	fake.listFeedbackKindsMutex.Lock()
+	ret, specificReturn := fake.listFeedbackKindsReturnsOnCall[len(fake.listFeedbackKindsArgsForCall)]
+	fake.listFeedbackKindsArgsForCall = append(fake.listFeedbackKindsArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("ListFeedbackKinds", []interface{}{arg1})
+	fake.listFeedbackKindsMutex.Unlock()
+	if fake.ListFeedbackKindsStub != nil {
+		return fake.ListFeedbackKindsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listFeedbackKindsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListFeedbackKindsCallCount() int {
+	fake.listFeedbackKindsMutex.RLock()
+	defer fake.listFeedbackKindsMutex.RUnlock()
+	return len(fake.listFeedbackKindsArgsForCall)
+}
+
+func (fake *FakeDB) ListFeedbackKindsCalls(stub func(context.Context) ([]db.FeedbackKind, error)) {
+	fake.listFeedbackKindsMutex.Lock()
+	defer fake.listFeedbackKindsMutex.Unlock()
+	fake.ListFeedbackKindsStub = stub
+}
+
+func (fake *FakeDB) ListFeedbackKindsArgsForCall(i int) context.Context {
+	fake.listFeedbackKindsMutex.RLock()
+	defer fake.listFeedbackKindsMutex.RUnlock()
+	argsForCall := fake.listFeedbackKindsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDB) ListFeedbackKindsReturns(result1 []db.FeedbackKind, result2 error) {
+	fake.listFeedbackKindsMutex.Lock()
+	defer fake.listFeedbackKindsMutex.Unlock()
+	fake.ListFeedbackKindsStub = nil
+	fake.listFeedbackKindsReturns = struct {
+		result1 []db.FeedbackKind
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackKindsReturnsOnCall(i int, result1 []db.FeedbackKind, result2 error) {
+	fake.listFeedbackKindsMutex.Lock()
+	defer fake.listFeedbackKindsMutex.Unlock()
+	fake.ListFeedbackKindsStub = nil
+	if fake.listFeedbackKindsReturnsOnCall == nil {
+		fake.listFeedbackKindsReturnsOnCall = make(map[int]struct {
+			result1 []db.FeedbackKind
+			result2 error
+		})
+	}
+	fake.listFeedbackKindsReturnsOnCall[i] = struct {
+		result1 []db.FeedbackKind
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackMessages(arg1 context.Context, arg2 string, arg3 *string) ([]db.FeedbackMessage, error) {
+	fake.listFeedbackMessagesMutex.Lock()
+	ret, specificReturn := fake.listFeedbackMessagesReturnsOnCall[len(fake.listFeedbackMessagesArgsForCall)]
+	fake.listFeedbackMessagesArgsForCall = append(fake.listFeedbackMessagesArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("ListFeedbackMessages", []interface{}{arg1, arg2, arg3})
+	fake.listFeedbackMessagesMutex.Unlock()
+	if fake.ListFeedbackMessagesStub != nil {
+		return fake.ListFeedbackMessagesStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listFeedbackMessagesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListFeedbackMessagesCallCount() int {
+	fake.listFeedbackMessagesMutex.RLock()
+	defer fake.listFeedbackMessagesMutex.RUnlock()
+	return len(fake.listFeedbackMessagesArgsForCall)
+}
+
+func (fake *FakeDB) ListFeedbackMessagesCalls(stub func(context.Context, string, *string) ([]db.FeedbackMessage, error)) {
+	fake.listFeedbackMessagesMutex.Lock()
+	defer fake.listFeedbackMessagesMutex.Unlock()
+	fake.ListFeedbackMessagesStub = stub
+}
+
+func (fake *FakeDB) ListFeedbackMessagesArgsForCall(i int) (context.Context, string, *string) {
+	fake.listFeedbackMessagesMutex.RLock()
+	defer fake.listFeedbackMessagesMutex.RUnlock()
+	argsForCall := fake.listFeedbackMessagesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) ListFeedbackMessagesReturns(result1 []db.FeedbackMessage, result2 error) {
+	fake.listFeedbackMessagesMutex.Lock()
+	defer fake.listFeedbackMessagesMutex.Unlock()
+	fake.ListFeedbackMessagesStub = nil
+	fake.listFeedbackMessagesReturns = struct {
+		result1 []db.FeedbackMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackMessagesReturnsOnCall(i int, result1 []db.FeedbackMessage, result2 error) {
+	fake.listFeedbackMessagesMutex.Lock()
+	defer fake.listFeedbackMessagesMutex.Unlock()
+	fake.ListFeedbackMessagesStub = nil
+	if fake.listFeedbackMessagesReturnsOnCall == nil {
+		fake.listFeedbackMessagesReturnsOnCall = make(map[int]struct {
+			result1 []db.FeedbackMessage
+			result2 error
+		})
+	}
+	fake.listFeedbackMessagesReturnsOnCall[i] = struct {
+		result1 []db.FeedbackMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackThreads(arg1 context.Context, arg2 db.ThreadFilter, arg3 int, arg4 int) ([]db.FeedbackThread, error) {
+	fake.listFeedbackThreadsMutex.Lock()
+	ret, specificReturn := fake.listFeedbackThreadsReturnsOnCall[len(fake.listFeedbackThreadsArgsForCall)]
+	fake.listFeedbackThreadsArgsForCall = append(fake.listFeedbackThreadsArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.ThreadFilter
+		arg3 int
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("ListFeedbackThreads", []interface{}{arg1, arg2, arg3, arg4})
+	fake.listFeedbackThreadsMutex.Unlock()
+	if fake.ListFeedbackThreadsStub != nil {
+		return fake.ListFeedbackThreadsStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listFeedbackThreadsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListFeedbackThreadsCallCount() int {
+	fake.listFeedbackThreadsMutex.RLock()
+	defer fake.listFeedbackThreadsMutex.RUnlock()
+	return len(fake.listFeedbackThreadsArgsForCall)
+}
+
+func (fake *FakeDB) ListFeedbackThreadsCalls(stub func(context.Context, db.ThreadFilter, int, int) ([]db.FeedbackThread, error)) {
+	fake.listFeedbackThreadsMutex.Lock()
+	defer fake.listFeedbackThreadsMutex.Unlock()
+	fake.ListFeedbackThreadsStub = stub
+}
+
+func (fake *FakeDB) ListFeedbackThreadsArgsForCall(i int) (context.Context, db.ThreadFilter, int, int) {
+	fake.listFeedbackThreadsMutex.RLock()
+	defer fake.listFeedbackThreadsMutex.RUnlock()
+	argsForCall := fake.listFeedbackThreadsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) ListFeedbackThreadsReturns(result1 []db.FeedbackThread, result2 error) {
+	fake.listFeedbackThreadsMutex.Lock()
+	defer fake.listFeedbackThreadsMutex.Unlock()
+	fake.ListFeedbackThreadsStub = nil
+	fake.listFeedbackThreadsReturns = struct {
+		result1 []db.FeedbackThread
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackThreadsReturnsOnCall(i int, result1 []db.FeedbackThread, result2 error) {
+	fake.listFeedbackThreadsMutex.Lock()
+	defer fake.listFeedbackThreadsMutex.Unlock()
+	fake.ListFeedbackThreadsStub = nil
+	if fake.listFeedbackThreadsReturnsOnCall == nil {
+		fake.listFeedbackThreadsReturnsOnCall = make(map[int]struct {
+			result1 []db.FeedbackThread
+			result2 error
+		})
+	}
+	fake.listFeedbackThreadsReturnsOnCall[i] = struct {
+		result1 []db.FeedbackThread
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackValues(arg1 context.Context) ([]db.FeedbackValue, error) {
+	fake.listFeedbackValuesMutex.Lock()
+	ret, specificReturn := fake.listFeedbackValuesReturnsOnCall[len(fake.listFeedbackValuesArgsForCall)]
+	fake.listFeedbackValuesArgsForCall = append(fake.listFeedbackValuesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("ListFeedbackValues", []interface{}{arg1})
+	fake.listFeedbackValuesMutex.Unlock()
+	if fake.ListFeedbackValuesStub != nil {
+		return fake.ListFeedbackValuesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listFeedbackValuesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ListFeedbackValuesCallCount() int {
+	fake.listFeedbackValuesMutex.RLock()
+	defer fake.listFeedbackValuesMutex.RUnlock()
+	return len(fake.listFeedbackValuesArgsForCall)
+}
+
+func (fake *FakeDB) ListFeedbackValuesCalls(stub func(context.Context) ([]db.FeedbackValue, error)) {
+	fake.listFeedbackValuesMutex.Lock()
+	defer fake.listFeedbackValuesMutex.Unlock()
+	fake.ListFeedbackValuesStub = stub
+}
+
+func (fake *FakeDB) ListFeedbackValuesArgsForCall(i int) context.Context {
+	fake.listFeedbackValuesMutex.RLock()
+	defer fake.listFeedbackValuesMutex.RUnlock()
+	argsForCall := fake.listFeedbackValuesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDB) ListFeedbackValuesReturns(result1 []db.FeedbackValue, result2 error) {
+	fake.listFeedbackValuesMutex.Lock()
+	defer fake.listFeedbackValuesMutex.Unlock()
+	fake.ListFeedbackValuesStub = nil
+	fake.listFeedbackValuesReturns = struct {
+		result1 []db.FeedbackValue
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ListFeedbackValuesReturnsOnCall(i int, result1 []db.FeedbackValue, result2 error) {
+	fake.listFeedbackValuesMutex.Lock()
+	defer fake.listFeedbackValuesMutex.Unlock()
+	fake.ListFeedbackValuesStub = nil
+	if fake.listFeedbackValuesReturnsOnCall == nil {
+		fake.listFeedbackValuesReturnsOnCall = make(map[int]struct {
+			result1 []db.FeedbackValue
+			result2 error
+		})
+	}
+	fake.listFeedbackValuesReturnsOnCall[i] = struct {
+		result1 []db.FeedbackValue
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) MarkEmailFailed(arg1 context.Context, arg2 int64, arg3 string, arg4 *time.Time) error {
+	fake.markEmailFailedMutex.Lock()
+	ret, specificReturn := fake.markEmailFailedReturnsOnCall[len(fake.markEmailFailedArgsForCall)]
+	fake.markEmailFailedArgsForCall = append(fake.markEmailFailedArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 string
+		arg4 *time.Time
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("MarkEmailFailed", []interface{}{arg1, arg2, arg3, arg4})
+	fake.markEmailFailedMutex.Unlock()
+	if fake.MarkEmailFailedStub != nil {
+		return fake.MarkEmailFailedStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.markEmailFailedReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) MarkEmailFailedCallCount() int {
+	fake.markEmailFailedMutex.RLock()
+	defer fake.markEmailFailedMutex.RUnlock()
+	return len(fake.markEmailFailedArgsForCall)
+}
+
+func (fake *FakeDB) MarkEmailFailedCalls(stub func(context.Context, int64, string, *time.Time) error) {
+	fake.markEmailFailedMutex.Lock()
+	defer fake.markEmailFailedMutex.Unlock()
+	fake.MarkEmailFailedStub = stub
+}
+
+func (fake *FakeDB) MarkEmailFailedArgsForCall(i int) (context.Context, int64, string, *time.Time) {
+	fake.markEmailFailedMutex.RLock()
+	defer fake.markEmailFailedMutex.RUnlock()
+	argsForCall := fake.markEmailFailedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) MarkEmailFailedReturns(result1 error) {
+	fake.markEmailFailedMutex.Lock()
+	defer fake.markEmailFailedMutex.Unlock()
+	fake.MarkEmailFailedStub = nil
+	fake.markEmailFailedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) MarkEmailFailedReturnsOnCall(i int, result1 error) {
+	fake.markEmailFailedMutex.Lock()
+	defer fake.markEmailFailedMutex.Unlock()
+	fake.MarkEmailFailedStub = nil
+	if fake.markEmailFailedReturnsOnCall == nil {
+		fake.markEmailFailedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.markEmailFailedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) MarkEmailSent(arg1 context.Context, arg2 int64) error {
+	fake.markEmailSentMutex.Lock()
+	ret, specificReturn := fake.markEmailSentReturnsOnCall[len(fake.markEmailSentArgsForCall)]
+	fake.markEmailSentArgsForCall = append(fake.markEmailSentArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+	}{arg1, arg2})
+	fake.recordInvocation("MarkEmailSent", []interface{}{arg1, arg2})
+	fake.markEmailSentMutex.Unlock()
+	if fake.MarkEmailSentStub != nil {
+		return fake.MarkEmailSentStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.markEmailSentReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) MarkEmailSentCallCount() int {
+	fake.markEmailSentMutex.RLock()
+	defer fake.markEmailSentMutex.RUnlock()
+	return len(fake.markEmailSentArgsForCall)
+}
+
+func (fake *FakeDB) MarkEmailSentCalls(stub func(context.Context, int64) error) {
+	fake.markEmailSentMutex.Lock()
+	defer fake.markEmailSentMutex.Unlock()
+	fake.MarkEmailSentStub = stub
+}
+
+func (fake *FakeDB) MarkEmailSentArgsForCall(i int) (context.Context, int64) {
+	fake.markEmailSentMutex.RLock()
+	defer fake.markEmailSentMutex.RUnlock()
+	argsForCall := fake.markEmailSentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) MarkEmailSentReturns(result1 error) {
+	fake.markEmailSentMutex.Lock()
+	defer fake.markEmailSentMutex.Unlock()
+	fake.MarkEmailSentStub = nil
+	fake.markEmailSentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) MarkEmailSentReturnsOnCall(i int, result1 error) {
+	fake.markEmailSentMutex.Lock()
+	defer fake.markEmailSentMutex.Unlock()
+	fake.MarkEmailSentStub = nil
+	if fake.markEmailSentReturnsOnCall == nil {
+		fake.markEmailSentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.markEmailSentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) MarkFeedbackMessagesRead(arg1 context.Context, arg2 string, arg3 string, arg4 *string) error {
+	fake.markFeedbackMessagesReadMutex.Lock()
+	ret, specificReturn := fake.markFeedbackMessagesReadReturnsOnCall[len(fake.markFeedbackMessagesReadArgsForCall)]
+	fake.markFeedbackMessagesReadArgsForCall = append(fake.markFeedbackMessagesReadArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 *string
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("MarkFeedbackMessagesRead", []interface{}{arg1, arg2, arg3, arg4})
+	fake.markFeedbackMessagesReadMutex.Unlock()
+	if fake.MarkFeedbackMessagesReadStub != nil {
+		return fake.MarkFeedbackMessagesReadStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.markFeedbackMessagesReadReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) MarkFeedbackMessagesReadCallCount() int {
+	fake.markFeedbackMessagesReadMutex.RLock()
+	defer fake.markFeedbackMessagesReadMutex.RUnlock()
+	return len(fake.markFeedbackMessagesReadArgsForCall)
+}
+
+func (fake *FakeDB) MarkFeedbackMessagesReadCalls(stub func(context.Context, string, string, *string) error) {
+	fake.markFeedbackMessagesReadMutex.Lock()
+	defer fake.markFeedbackMessagesReadMutex.Unlock()
+	fake.MarkFeedbackMessagesReadStub = stub
+}
+
+func (fake *FakeDB) MarkFeedbackMessagesReadArgsForCall(i int) (context.Context, string, string, *string) {
+	fake.markFeedbackMessagesReadMutex.RLock()
+	defer fake.markFeedbackMessagesReadMutex.RUnlock()
+	argsForCall := fake.markFeedbackMessagesReadArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) MarkFeedbackMessagesReadReturns(result1 error) {
+	fake.markFeedbackMessagesReadMutex.Lock()
+	defer fake.markFeedbackMessagesReadMutex.Unlock()
+	fake.MarkFeedbackMessagesReadStub = nil
+	fake.markFeedbackMessagesReadReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) MarkFeedbackMessagesReadReturnsOnCall(i int, result1 error) {
+	fake.markFeedbackMessagesReadMutex.Lock()
+	defer fake.markFeedbackMessagesReadMutex.Unlock()
+	fake.MarkFeedbackMessagesReadStub = nil
+	if fake.markFeedbackMessagesReadReturnsOnCall == nil {
+		fake.markFeedbackMessagesReadReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.markFeedbackMessagesReadReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) Migrate(arg1 context.Context) error {
+	fake.migrateMutex.Lock()
+	ret, specificReturn := fake.migrateReturnsOnCall[len(fake.migrateArgsForCall)]
+	fake.migrateArgsForCall = append(fake.migrateArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("Migrate", []interface{}{arg1})
+	fake.migrateMutex.Unlock()
+	if fake.MigrateStub != nil {
+		return fake.MigrateStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.migrateReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) MigrateCallCount() int {
+	fake.migrateMutex.RLock()
+	defer fake.migrateMutex.RUnlock()
+	return len(fake.migrateArgsForCall)
+}
+
+func (fake *FakeDB) MigrateCalls(stub func(context.Context) error) {
+	fake.migrateMutex.Lock()
+	defer fake.migrateMutex.Unlock()
+	fake.MigrateStub = stub
+}
+
+func (fake *FakeDB) MigrateArgsForCall(i int) context.Context {
+	fake.migrateMutex.RLock()
+	defer fake.migrateMutex.RUnlock()
+	argsForCall := fake.migrateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDB) MigrateReturns(result1 error) {
+	fake.migrateMutex.Lock()
+	defer fake.migrateMutex.Unlock()
+	fake.MigrateStub = nil
+	fake.migrateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) MigrateReturnsOnCall(i int, result1 error) {
+	fake.migrateMutex.Lock()
+	defer fake.migrateMutex.Unlock()
+	fake.MigrateStub = nil
+	if fake.migrateReturnsOnCall == nil {
+		fake.migrateReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.migrateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) ModerateFeedback(arg1 context.Context, arg2 db.ModerationDecision) (string, error) {
+	fake.moderateFeedbackMutex.Lock()
+	ret, specificReturn := fake.moderateFeedbackReturnsOnCall[len(fake.moderateFeedbackArgsForCall)]
+	fake.moderateFeedbackArgsForCall = append(fake.moderateFeedbackArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.ModerationDecision
+	}{arg1, arg2})
+	fake.recordInvocation("ModerateFeedback", []interface{}{arg1, arg2})
+	fake.moderateFeedbackMutex.Unlock()
+	if fake.ModerateFeedbackStub != nil {
+		return fake.ModerateFeedbackStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.moderateFeedbackReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) ModerateFeedbackCallCount() int {
+	fake.moderateFeedbackMutex.RLock()
+	defer fake.moderateFeedbackMutex.RUnlock()
+	return len(fake.moderateFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) ModerateFeedbackCalls(stub func(context.Context, db.ModerationDecision) (string, error)) {
+	fake.moderateFeedbackMutex.Lock()
+	defer fake.moderateFeedbackMutex.Unlock()
+	fake.ModerateFeedbackStub = stub
+}
+
+func (fake *FakeDB) ModerateFeedbackArgsForCall(i int) (context.Context, db.ModerationDecision) {
+	fake.moderateFeedbackMutex.RLock()
+	defer fake.moderateFeedbackMutex.RUnlock()
+	argsForCall := fake.moderateFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) ModerateFeedbackReturns(result1 string, result2 error) {
+	fake.moderateFeedbackMutex.Lock()
+	defer fake.moderateFeedbackMutex.Unlock()
+	fake.ModerateFeedbackStub = nil
+	fake.moderateFeedbackReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) ModerateFeedbackReturnsOnCall(i int, result1 string, result2 error) {
+	fake.moderateFeedbackMutex.Lock()
+	defer fake.moderateFeedbackMutex.Unlock()
+	fake.ModerateFeedbackStub = nil
+	if fake.moderateFeedbackReturnsOnCall == nil {
+		fake.moderateFeedbackReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.moderateFeedbackReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) PruneRateLimitBuckets(arg1 context.Context, arg2 time.Time) (int, error) {
+	fake.pruneRateLimitBucketsMutex.Lock()
+	ret, specificReturn := fake.pruneRateLimitBucketsReturnsOnCall[len(fake.pruneRateLimitBucketsArgsForCall)]
+	fake.pruneRateLimitBucketsArgsForCall = append(fake.pruneRateLimitBucketsArgsForCall, struct {
+		arg1 context.Context
+		arg2 time.Time
+	}{arg1, arg2})
+	fake.recordInvocation("PruneRateLimitBuckets", []interface{}{arg1, arg2})
+	fake.pruneRateLimitBucketsMutex.Unlock()
+	if fake.PruneRateLimitBucketsStub != nil {
+		return fake.PruneRateLimitBucketsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.pruneRateLimitBucketsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) PruneRateLimitBucketsCallCount() int {
+	fake.pruneRateLimitBucketsMutex.RLock()
+	defer fake.pruneRateLimitBucketsMutex.RUnlock()
+	return len(fake.pruneRateLimitBucketsArgsForCall)
+}
+
+func (fake *FakeDB) PruneRateLimitBucketsCalls(stub func(context.Context, time.Time) (int, error)) {
+	fake.pruneRateLimitBucketsMutex.Lock()
+	defer fake.pruneRateLimitBucketsMutex.Unlock()
+	fake.PruneRateLimitBucketsStub = stub
+}
+
+func (fake *FakeDB) PruneRateLimitBucketsArgsForCall(i int) (context.Context, time.Time) {
+	fake.pruneRateLimitBucketsMutex.RLock()
+	defer fake.pruneRateLimitBucketsMutex.RUnlock()
+	argsForCall := fake.pruneRateLimitBucketsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) PruneRateLimitBucketsReturns(result1 int, result2 error) {
+	fake.pruneRateLimitBucketsMutex.Lock()
+	defer fake.pruneRateLimitBucketsMutex.Unlock()
+	fake.PruneRateLimitBucketsStub = nil
+	fake.pruneRateLimitBucketsReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) PruneRateLimitBucketsReturnsOnCall(i int, result1 int, result2 error) {
+	fake.pruneRateLimitBucketsMutex.Lock()
+	defer fake.pruneRateLimitBucketsMutex.Unlock()
+	fake.PruneRateLimitBucketsStub = nil
+	if fake.pruneRateLimitBucketsReturnsOnCall == nil {
+		fake.pruneRateLimitBucketsReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.pruneRateLimitBucketsReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) PurgeExpiredFeedback(arg1 context.Context, arg2 string, arg3 string, arg4 time.Time, arg5 int) (int, error) {
+	fake.purgeExpiredFeedbackMutex.Lock()
+	ret, specificReturn := fake.purgeExpiredFeedbackReturnsOnCall[len(fake.purgeExpiredFeedbackArgsForCall)]
+	fake.purgeExpiredFeedbackArgsForCall = append(fake.purgeExpiredFeedbackArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 time.Time
+		arg5 int
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("PurgeExpiredFeedback", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.purgeExpiredFeedbackMutex.Unlock()
+	if fake.PurgeExpiredFeedbackStub != nil {
+		return fake.PurgeExpiredFeedbackStub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.purgeExpiredFeedbackReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) PurgeExpiredFeedbackCallCount() int {
+	fake.purgeExpiredFeedbackMutex.RLock()
+	defer fake.purgeExpiredFeedbackMutex.RUnlock()
+	return len(fake.purgeExpiredFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) PurgeExpiredFeedbackCalls(stub func(context.Context, string, string, time.Time, int) (int, error)) {
+	fake.purgeExpiredFeedbackMutex.Lock()
+	defer fake.purgeExpiredFeedbackMutex.Unlock()
+	fake.PurgeExpiredFeedbackStub = stub
+}
+
+func (fake *FakeDB) PurgeExpiredFeedbackArgsForCall(i int) (context.Context, string, string, time.Time, int) {
+	fake.purgeExpiredFeedbackMutex.RLock()
+	defer fake.purgeExpiredFeedbackMutex.RUnlock()
+	argsForCall := fake.purgeExpiredFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeDB) PurgeExpiredFeedbackReturns(result1 int, result2 error) {
+	fake.purgeExpiredFeedbackMutex.Lock()
+	defer fake.purgeExpiredFeedbackMutex.Unlock()
+	fake.PurgeExpiredFeedbackStub = nil
+	fake.purgeExpiredFeedbackReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) PurgeExpiredFeedbackReturnsOnCall(i int, result1 int, result2 error) {
+	fake.purgeExpiredFeedbackMutex.Lock()
+	defer fake.purgeExpiredFeedbackMutex.Unlock()
+	fake.PurgeExpiredFeedbackStub = nil
+	if fake.purgeExpiredFeedbackReturnsOnCall == nil {
+		fake.purgeExpiredFeedbackReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.purgeExpiredFeedbackReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) QueueOutageResolvedEmails(arg1 context.Context, arg2 *string, arg3 time.Time) (int, error) {
+	fake.queueOutageResolvedEmailsMutex.Lock()
+	ret, specificReturn := fake.queueOutageResolvedEmailsReturnsOnCall[len(fake.queueOutageResolvedEmailsArgsForCall)]
+	fake.queueOutageResolvedEmailsArgsForCall = append(fake.queueOutageResolvedEmailsArgsForCall, struct {
+		arg1 context.Context
+		arg2 *string
+		arg3 time.Time
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("QueueOutageResolvedEmails", []interface{}{arg1, arg2, arg3})
+	fake.queueOutageResolvedEmailsMutex.Unlock()
+	if fake.QueueOutageResolvedEmailsStub != nil {
+		return fake.QueueOutageResolvedEmailsStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.queueOutageResolvedEmailsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) QueueOutageResolvedEmailsCallCount() int {
+	fake.queueOutageResolvedEmailsMutex.RLock()
+	defer fake.queueOutageResolvedEmailsMutex.RUnlock()
+	return len(fake.queueOutageResolvedEmailsArgsForCall)
+}
+
+func (fake *FakeDB) QueueOutageResolvedEmailsCalls(stub func(context.Context, *string, time.Time) (int, error)) {
+	fake.queueOutageResolvedEmailsMutex.Lock()
+	defer fake.queueOutageResolvedEmailsMutex.Unlock()
+	fake.QueueOutageResolvedEmailsStub = stub
+}
+
+func (fake *FakeDB) QueueOutageResolvedEmailsArgsForCall(i int) (context.Context, *string, time.Time) {
+	fake.queueOutageResolvedEmailsMutex.RLock()
+	defer fake.queueOutageResolvedEmailsMutex.RUnlock()
+	argsForCall := fake.queueOutageResolvedEmailsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) QueueOutageResolvedEmailsReturns(result1 int, result2 error) {
+	fake.queueOutageResolvedEmailsMutex.Lock()
+	defer fake.queueOutageResolvedEmailsMutex.Unlock()
+	fake.QueueOutageResolvedEmailsStub = nil
+	fake.queueOutageResolvedEmailsReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) QueueOutageResolvedEmailsReturnsOnCall(i int, result1 int, result2 error) {
+	fake.queueOutageResolvedEmailsMutex.Lock()
+	defer fake.queueOutageResolvedEmailsMutex.Unlock()
+	fake.QueueOutageResolvedEmailsStub = nil
+	if fake.queueOutageResolvedEmailsReturnsOnCall == nil {
+		fake.queueOutageResolvedEmailsReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.queueOutageResolvedEmailsReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) RecordDataRequest(arg1 context.Context, arg2 db.DataRequest) error {
+	fake.recordDataRequestMutex.Lock()
+	ret, specificReturn := fake.recordDataRequestReturnsOnCall[len(fake.recordDataRequestArgsForCall)]
+	fake.recordDataRequestArgsForCall = append(fake.recordDataRequestArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.DataRequest
+	}{arg1, arg2})
+	fake.recordInvocation("RecordDataRequest", []interface{}{arg1, arg2})
+	fake.recordDataRequestMutex.Unlock()
+	if fake.RecordDataRequestStub != nil {
+		return fake.RecordDataRequestStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.recordDataRequestReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) RecordDataRequestCallCount() int {
+	fake.recordDataRequestMutex.RLock()
+	defer fake.recordDataRequestMutex.RUnlock()
+	return len(fake.recordDataRequestArgsForCall)
+}
+
+func (fake *FakeDB) RecordDataRequestCalls(stub func(context.Context, db.DataRequest) error) {
+	fake.recordDataRequestMutex.Lock()
+	defer fake.recordDataRequestMutex.Unlock()
+	fake.RecordDataRequestStub = stub
+}
+
+func (fake *FakeDB) RecordDataRequestArgsForCall(i int) (context.Context, db.DataRequest) {
+	fake.recordDataRequestMutex.RLock()
+	defer fake.recordDataRequestMutex.RUnlock()
+	argsForCall := fake.recordDataRequestArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) RecordDataRequestReturns(result1 error) {
+	fake.recordDataRequestMutex.Lock()
+	defer fake.recordDataRequestMutex.Unlock()
+	fake.RecordDataRequestStub = nil
+	fake.recordDataRequestReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) RecordDataRequestReturnsOnCall(i int, result1 error) {
+	fake.recordDataRequestMutex.Lock()
+	defer fake.recordDataRequestMutex.Unlock()
+	fake.RecordDataRequestStub = nil
+	if fake.recordDataRequestReturnsOnCall == nil {
+		fake.recordDataRequestReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.recordDataRequestReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) RefundRateLimitToken(arg1 context.Context, arg2 string, arg3 float64, arg4 float64) error {
+	fake.refundRateLimitTokenMutex.Lock()
+	ret, specificReturn := fake.refundRateLimitTokenReturnsOnCall[len(fake.refundRateLimitTokenArgsForCall)]
+	fake.refundRateLimitTokenArgsForCall = append(fake.refundRateLimitTokenArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 float64
+		arg4 float64
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("RefundRateLimitToken", []interface{}{arg1, arg2, arg3, arg4})
+	fake.refundRateLimitTokenMutex.Unlock()
+	if fake.RefundRateLimitTokenStub != nil {
+		return fake.RefundRateLimitTokenStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.refundRateLimitTokenReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) RefundRateLimitTokenCallCount() int {
+	fake.refundRateLimitTokenMutex.RLock()
+	defer fake.refundRateLimitTokenMutex.RUnlock()
+	return len(fake.refundRateLimitTokenArgsForCall)
+}
+
+func (fake *FakeDB) RefundRateLimitTokenCalls(stub func(context.Context, string, float64, float64) error) {
+	fake.refundRateLimitTokenMutex.Lock()
+	defer fake.refundRateLimitTokenMutex.Unlock()
+	fake.RefundRateLimitTokenStub = stub
+}
+
+func (fake *FakeDB) RefundRateLimitTokenArgsForCall(i int) (context.Context, string, float64, float64) {
+	fake.refundRateLimitTokenMutex.RLock()
+	defer fake.refundRateLimitTokenMutex.RUnlock()
+	argsForCall := fake.refundRateLimitTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) RefundRateLimitTokenReturns(result1 error) {
+	fake.refundRateLimitTokenMutex.Lock()
+	defer fake.refundRateLimitTokenMutex.Unlock()
+	fake.RefundRateLimitTokenStub = nil
+	fake.refundRateLimitTokenReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) RefundRateLimitTokenReturnsOnCall(i int, result1 error) {
+	fake.refundRateLimitTokenMutex.Lock()
+	defer fake.refundRateLimitTokenMutex.Unlock()
+	fake.RefundRateLimitTokenStub = nil
+	if fake.refundRateLimitTokenReturnsOnCall == nil {
+		fake.refundRateLimitTokenReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.refundRateLimitTokenReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) SaveAttachment(arg1 context.Context, arg2 db.Attachment) (db.Attachment, error) {
+	fake.saveAttachmentMutex.Lock()
+	ret, specificReturn := fake.saveAttachmentReturnsOnCall[len(fake.saveAttachmentArgsForCall)]
+	fake.saveAttachmentArgsForCall = append(fake.saveAttachmentArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.Attachment
+	}{arg1, arg2})
+	fake.recordInvocation("SaveAttachment", []interface{}{arg1, arg2})
+	fake.saveAttachmentMutex.Unlock()
+	if fake.SaveAttachmentStub != nil {
+		return fake.SaveAttachmentStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.saveAttachmentReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) SaveAttachmentCallCount() int {
+	fake.saveAttachmentMutex.RLock()
+	defer fake.saveAttachmentMutex.RUnlock()
+	return len(fake.saveAttachmentArgsForCall)
+}
+
+func (fake *FakeDB) SaveAttachmentCalls(stub func(context.Context, db.Attachment) (db.Attachment, error)) {
+	fake.saveAttachmentMutex.Lock()
+	defer fake.saveAttachmentMutex.Unlock()
+	fake.SaveAttachmentStub = stub
+}
+
+func (fake *FakeDB) SaveAttachmentArgsForCall(i int) (context.Context, db.Attachment) {
+	fake.saveAttachmentMutex.RLock()
+	defer fake.saveAttachmentMutex.RUnlock()
+	argsForCall := fake.saveAttachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) SaveAttachmentReturns(result1 db.Attachment, result2 error) {
+	fake.saveAttachmentMutex.Lock()
+	defer fake.saveAttachmentMutex.Unlock()
+	fake.SaveAttachmentStub = nil
+	fake.saveAttachmentReturns = struct {
+		result1 db.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) SaveAttachmentReturnsOnCall(i int, result1 db.Attachment, result2 error) {
+	fake.saveAttachmentMutex.Lock()
+	defer fake.saveAttachmentMutex.Unlock()
+	fake.SaveAttachmentStub = nil
+	if fake.saveAttachmentReturnsOnCall == nil {
+		fake.saveAttachmentReturnsOnCall = make(map[int]struct {
+			result1 db.Attachment
+			result2 error
+		})
+	}
+	fake.saveAttachmentReturnsOnCall[i] = struct {
+		result1 db.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) SaveFeedback(arg1 context.Context, arg2 db.Feedback) error {
+	fake.saveFeedbackMutex.Lock()
+	ret, specificReturn := fake.saveFeedbackReturnsOnCall[len(fake.saveFeedbackArgsForCall)]
+	fake.saveFeedbackArgsForCall = append(fake.saveFeedbackArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.Feedback
+	}{arg1, arg2})
+	fake.recordInvocation("SaveFeedback", []interface{}{arg1, arg2})
+	fake.saveFeedbackMutex.Unlock()
+	if fake.SaveFeedbackStub != nil {
+		return fake.SaveFeedbackStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.saveFeedbackReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) SaveFeedbackCallCount() int {
+	fake.saveFeedbackMutex.RLock()
+	defer fake.saveFeedbackMutex.RUnlock()
+	return len(fake.saveFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) SaveFeedbackCalls(stub func(context.Context, db.Feedback) error) {
+	fake.saveFeedbackMutex.Lock()
+	defer fake.saveFeedbackMutex.Unlock()
+	fake.SaveFeedbackStub = stub
+}
+
+func (fake *FakeDB) SaveFeedbackArgsForCall(i int) (context.Context, db.Feedback) {
+	fake.saveFeedbackMutex.RLock()
+	defer fake.saveFeedbackMutex.RUnlock()
+	argsForCall := fake.saveFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) SaveFeedbackReturns(result1 error) {
+	fake.saveFeedbackMutex.Lock()
+	defer fake.saveFeedbackMutex.Unlock()
+	fake.SaveFeedbackStub = nil
+	fake.saveFeedbackReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) SaveFeedbackReturnsOnCall(i int, result1 error) {
+	fake.saveFeedbackMutex.Lock()
+	defer fake.saveFeedbackMutex.Unlock()
+	fake.SaveFeedbackStub = nil
+	if fake.saveFeedbackReturnsOnCall == nil {
+		fake.saveFeedbackReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveFeedbackReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) SaveFeedbackMessage(arg1 context.Context, arg2 db.FeedbackMessage, arg3 bool) (db.FeedbackMessage, bool, error) {
+	fake.saveFeedbackMessageMutex.Lock()
+	ret, specificReturn := fake.saveFeedbackMessageReturnsOnCall[len(fake.saveFeedbackMessageArgsForCall)]
+	fake.saveFeedbackMessageArgsForCall = append(fake.saveFeedbackMessageArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.FeedbackMessage
+		arg3 bool
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("SaveFeedbackMessage", []interface{}{arg1, arg2, arg3})
+	fake.saveFeedbackMessageMutex.Unlock()
+	if fake.SaveFeedbackMessageStub != nil {
+		return fake.SaveFeedbackMessageStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	fakeReturns := fake.saveFeedbackMessageReturns
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeDB) SaveFeedbackMessageCallCount() int {
+	fake.saveFeedbackMessageMutex.RLock()
+	defer fake.saveFeedbackMessageMutex.RUnlock()
+	return len(fake.saveFeedbackMessageArgsForCall)
+}
+
+func (fake *FakeDB) SaveFeedbackMessageCalls(stub func(context.Context, db.FeedbackMessage, bool) (db.FeedbackMessage, bool, error)) {
+	fake.saveFeedbackMessageMutex.Lock()
+	defer fake.saveFeedbackMessageMutex.Unlock()
+	fake.SaveFeedbackMessageStub = stub
+}
+
+func (fake *FakeDB) SaveFeedbackMessageArgsForCall(i int) (context.Context, db.FeedbackMessage, bool) {
+	fake.saveFeedbackMessageMutex.RLock()
+	defer fake.saveFeedbackMessageMutex.RUnlock()
+	argsForCall := fake.saveFeedbackMessageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) SaveFeedbackMessageReturns(result1 db.FeedbackMessage, result2 bool, result3 error) {
+	fake.saveFeedbackMessageMutex.Lock()
+	defer fake.saveFeedbackMessageMutex.Unlock()
+	fake.SaveFeedbackMessageStub = nil
+	fake.saveFeedbackMessageReturns = struct {
+		result1 db.FeedbackMessage
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDB) SaveFeedbackMessageReturnsOnCall(i int, result1 db.FeedbackMessage, result2 bool, result3 error) {
+	fake.saveFeedbackMessageMutex.Lock()
+	defer fake.saveFeedbackMessageMutex.Unlock()
+	fake.SaveFeedbackMessageStub = nil
+	if fake.saveFeedbackMessageReturnsOnCall == nil {
+		fake.saveFeedbackMessageReturnsOnCall = make(map[int]struct {
+			result1 db.FeedbackMessage
+			result2 bool
+			result3 error
+		})
+	}
+	fake.saveFeedbackMessageReturnsOnCall[i] = struct {
+		result1 db.FeedbackMessage
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDB) SaveSurveyResponse(arg1 context.Context, arg2 db.SurveyResponse) error {
+	fake.saveSurveyResponseMutex.Lock()
+	ret, specificReturn := fake.saveSurveyResponseReturnsOnCall[len(fake.saveSurveyResponseArgsForCall)]
+	fake.saveSurveyResponseArgsForCall = append(fake.saveSurveyResponseArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.SurveyResponse
+	}{arg1, arg2})
+	fake.recordInvocation("SaveSurveyResponse", []interface{}{arg1, arg2})
+	fake.saveSurveyResponseMutex.Unlock()
+	if fake.SaveSurveyResponseStub != nil {
+		return fake.SaveSurveyResponseStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.saveSurveyResponseReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) SaveSurveyResponseCallCount() int {
+	fake.saveSurveyResponseMutex.RLock()
+	defer fake.saveSurveyResponseMutex.RUnlock()
+	return len(fake.saveSurveyResponseArgsForCall)
+}
+
+func (fake *FakeDB) SaveSurveyResponseCalls(stub func(context.Context, db.SurveyResponse) error) {
+	fake.saveSurveyResponseMutex.Lock()
+	defer fake.saveSurveyResponseMutex.Unlock()
+	fake.SaveSurveyResponseStub = stub
+}
+
+func (fake *FakeDB) SaveSurveyResponseArgsForCall(i int) (context.Context, db.SurveyResponse) {
+	fake.saveSurveyResponseMutex.RLock()
+	defer fake.saveSurveyResponseMutex.RUnlock()
+	argsForCall := fake.saveSurveyResponseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) SaveSurveyResponseReturns(result1 error) {
+	fake.saveSurveyResponseMutex.Lock()
+	defer fake.saveSurveyResponseMutex.Unlock()
+	fake.SaveSurveyResponseStub = nil
+	fake.saveSurveyResponseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) SaveSurveyResponseReturnsOnCall(i int, result1 error) {
+	fake.saveSurveyResponseMutex.Lock()
+	defer fake.saveSurveyResponseMutex.Unlock()
+	fake.SaveSurveyResponseStub = nil
+	if fake.saveSurveyResponseReturnsOnCall == nil {
+		fake.saveSurveyResponseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.saveSurveyResponseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) SearchFeedback(arg1 context.Context, arg2 string, arg3 db.FeedbackFilter, arg4 int, arg5 int) ([]db.SearchResult, error) {
+	fake.searchFeedbackMutex.Lock()
+	ret, specificReturn := fake.searchFeedbackReturnsOnCall[len(fake.searchFeedbackArgsForCall)]
+	fake.searchFeedbackArgsForCall = append(fake.searchFeedbackArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 db.FeedbackFilter
+		arg4 int
+		arg5 int
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("SearchFeedback", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.searchFeedbackMutex.Unlock()
+	if fake.SearchFeedbackStub != nil {
+		return fake.SearchFeedbackStub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.searchFeedbackReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) SearchFeedbackCallCount() int {
+	fake.searchFeedbackMutex.RLock()
+	defer fake.searchFeedbackMutex.RUnlock()
+	return len(fake.searchFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) SearchFeedbackCalls(stub func(context.Context, string, db.FeedbackFilter, int, int) ([]db.SearchResult, error)) {
+	fake.searchFeedbackMutex.Lock()
+	defer fake.searchFeedbackMutex.Unlock()
+	fake.SearchFeedbackStub = stub
+}
+
+func (fake *FakeDB) SearchFeedbackArgsForCall(i int) (context.Context, string, db.FeedbackFilter, int, int) {
+	fake.searchFeedbackMutex.RLock()
+	defer fake.searchFeedbackMutex.RUnlock()
+	argsForCall := fake.searchFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeDB) SearchFeedbackReturns(result1 []db.SearchResult, result2 error) {
+	fake.searchFeedbackMutex.Lock()
+	defer fake.searchFeedbackMutex.Unlock()
+	fake.SearchFeedbackStub = nil
+	fake.searchFeedbackReturns = struct {
+		result1 []db.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) SearchFeedbackReturnsOnCall(i int, result1 []db.SearchResult, result2 error) {
+	fake.searchFeedbackMutex.Lock()
+	defer fake.searchFeedbackMutex.Unlock()
+	fake.SearchFeedbackStub = nil
+	if fake.searchFeedbackReturnsOnCall == nil {
+		fake.searchFeedbackReturnsOnCall = make(map[int]struct {
+			result1 []db.SearchResult
+			result2 error
+		})
+	}
+	fake.searchFeedbackReturnsOnCall[i] = struct {
+		result1 []db.SearchResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) SetActiveSurveyVersion(arg1 context.Context, arg2 string, arg3 *int) error {
+	fake.setActiveSurveyVersionMutex.Lock()
+	ret, specificReturn := fake.setActiveSurveyVersionReturnsOnCall[len(fake.setActiveSurveyVersionArgsForCall)]
+	fake.setActiveSurveyVersionArgsForCall = append(fake.setActiveSurveyVersionArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *int
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("SetActiveSurveyVersion", []interface{}{arg1, arg2, arg3})
+	fake.setActiveSurveyVersionMutex.Unlock()
+	if fake.SetActiveSurveyVersionStub != nil {
+		return fake.SetActiveSurveyVersionStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.setActiveSurveyVersionReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) SetActiveSurveyVersionCallCount() int {
+	fake.setActiveSurveyVersionMutex.RLock()
+	defer fake.setActiveSurveyVersionMutex.RUnlock()
+	return len(fake.setActiveSurveyVersionArgsForCall)
+}
+
+func (fake *FakeDB) SetActiveSurveyVersionCalls(stub func(context.Context, string, *int) error) {
+	fake.setActiveSurveyVersionMutex.Lock()
+	defer fake.setActiveSurveyVersionMutex.Unlock()
+	fake.SetActiveSurveyVersionStub = stub
+}
+
+func (fake *FakeDB) SetActiveSurveyVersionArgsForCall(i int) (context.Context, string, *int) {
+	fake.setActiveSurveyVersionMutex.RLock()
+	defer fake.setActiveSurveyVersionMutex.RUnlock()
+	argsForCall := fake.setActiveSurveyVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) SetActiveSurveyVersionReturns(result1 error) {
+	fake.setActiveSurveyVersionMutex.Lock()
+	defer fake.setActiveSurveyVersionMutex.Unlock()
+	fake.SetActiveSurveyVersionStub = nil
+	fake.setActiveSurveyVersionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) SetActiveSurveyVersionReturnsOnCall(i int, result1 error) {
+	fake.setActiveSurveyVersionMutex.Lock()
+	defer fake.setActiveSurveyVersionMutex.Unlock()
+	fake.SetActiveSurveyVersionStub = nil
+	if fake.setActiveSurveyVersionReturnsOnCall == nil {
+		fake.setActiveSurveyVersionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setActiveSurveyVersionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) SetRetentionPolicy(arg1 context.Context, arg2 db.RetentionPolicy) error {
+	fake.setRetentionPolicyMutex.Lock()
+	ret, specificReturn := fake.setRetentionPolicyReturnsOnCall[len(fake.setRetentionPolicyArgsForCall)]
+	fake.setRetentionPolicyArgsForCall = append(fake.setRetentionPolicyArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.RetentionPolicy
+	}{arg1, arg2})
+	fake.recordInvocation("SetRetentionPolicy", []interface{}{arg1, arg2})
+	fake.setRetentionPolicyMutex.Unlock()
+	if fake.SetRetentionPolicyStub != nil {
+		return fake.SetRetentionPolicyStub(arg1, arg2)
 	}
-	getRecentOutagesReturns struct {
-		result1 []db.Feedback
-		result2 error
+	if specificReturn {
+		return ret.result1
 	}
-	getRecentOutagesReturnsOnCall map[int]struct {
-		result1 []db.Feedback
-		result2 error
+	fakeReturns := fake.setRetentionPolicyReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDB) SetRetentionPolicyCallCount() int {
+	fake.setRetentionPolicyMutex.RLock()
+	defer fake.setRetentionPolicyMutex.RUnlock()
+	return len(fake.setRetentionPolicyArgsForCall)
+}
+
+func (fake *FakeDB) SetRetentionPolicyCalls(stub func(context.Context, db.RetentionPolicy) error) {
+	fake.setRetentionPolicyMutex.Lock()
+	defer fake.setRetentionPolicyMutex.Unlock()
+	fake.SetRetentionPolicyStub = stub
+}
+
+func (fake *FakeDB) SetRetentionPolicyArgsForCall(i int) (context.Context, db.RetentionPolicy) {
+	fake.setRetentionPolicyMutex.RLock()
+	defer fake.setRetentionPolicyMutex.RUnlock()
+	argsForCall := fake.setRetentionPolicyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) SetRetentionPolicyReturns(result1 error) {
+	fake.setRetentionPolicyMutex.Lock()
+	defer fake.setRetentionPolicyMutex.Unlock()
+	fake.SetRetentionPolicyStub = nil
+	fake.setRetentionPolicyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) SetRetentionPolicyReturnsOnCall(i int, result1 error) {
+	fake.setRetentionPolicyMutex.Lock()
+	defer fake.setRetentionPolicyMutex.Unlock()
+	fake.SetRetentionPolicyStub = nil
+	if fake.setRetentionPolicyReturnsOnCall == nil {
+		fake.setRetentionPolicyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
 	}
-	MigrateStub        func(context.Context) error
-	migrateMutex       sync.RWMutex
-	migrateArgsForCall []struct {
+	fake.setRetentionPolicyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDB) SilenceFeedback(arg1 context.Context, arg2 string, arg3 bool) (bool, error) {
+	fake.silenceFeedbackMutex.Lock()
+	ret, specificReturn := fake.silenceFeedbackReturnsOnCall[len(fake.silenceFeedbackArgsForCall)]
+	fake.silenceFeedbackArgsForCall = append(fake.silenceFeedbackArgsForCall, struct {
 		arg1 context.Context
+		arg2 string
+		arg3 bool
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("SilenceFeedback", []interface{}{arg1, arg2, arg3})
+	fake.silenceFeedbackMutex.Unlock()
+	if fake.SilenceFeedbackStub != nil {
+		return fake.SilenceFeedbackStub(arg1, arg2, arg3)
 	}
-	migrateReturns struct {
-		result1 error
+	if specificReturn {
+		return ret.result1, ret.result2
 	}
-	migrateReturnsOnCall map[int]struct {
-		result1 error
+	fakeReturns := fake.silenceFeedbackReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) SilenceFeedbackCallCount() int {
+	fake.silenceFeedbackMutex.RLock()
+	defer fake.silenceFeedbackMutex.RUnlock()
+	return len(fake.silenceFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) SilenceFeedbackCalls(stub func(context.Context, string, bool) (bool, error)) {
+	fake.silenceFeedbackMutex.Lock()
+	defer fake.silenceFeedbackMutex.Unlock()
+	fake.SilenceFeedbackStub = stub
+}
+
+func (fake *FakeDB) SilenceFeedbackArgsForCall(i int) (context.Context, string, bool) {
+	fake.silenceFeedbackMutex.RLock()
+	defer fake.silenceFeedbackMutex.RUnlock()
+	argsForCall := fake.silenceFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDB) SilenceFeedbackReturns(result1 bool, result2 error) {
+	fake.silenceFeedbackMutex.Lock()
+	defer fake.silenceFeedbackMutex.Unlock()
+	fake.SilenceFeedbackStub = nil
+	fake.silenceFeedbackReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) SilenceFeedbackReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.silenceFeedbackMutex.Lock()
+	defer fake.silenceFeedbackMutex.Unlock()
+	fake.SilenceFeedbackStub = nil
+	if fake.silenceFeedbackReturnsOnCall == nil {
+		fake.silenceFeedbackReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
 	}
-	SaveFeedbackStub        func(context.Context, db.Feedback) error
-	saveFeedbackMutex       sync.RWMutex
-	saveFeedbackArgsForCall []struct {
+	fake.silenceFeedbackReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) SimilarFeedback(arg1 context.Context, arg2 string, arg3 int, arg4 int) ([]db.SimilarFeedback, error) {
+	fake.similarFeedbackMutex.Lock()
+	ret, specificReturn := fake.similarFeedbackReturnsOnCall[len(fake.similarFeedbackArgsForCall)]
+	fake.similarFeedbackArgsForCall = append(fake.similarFeedbackArgsForCall, struct {
 		arg1 context.Context
-		arg2 db.Feedback
+		arg2 string
+		arg3 int
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("SimilarFeedback", []interface{}{arg1, arg2, arg3, arg4})
+	fake.similarFeedbackMutex.Unlock()
+	if fake.SimilarFeedbackStub != nil {
+		return fake.SimilarFeedbackStub(arg1, arg2, arg3, arg4)
 	}
-	saveFeedbackReturns struct {
-		result1 error
+	if specificReturn {
+		return ret.result1, ret.result2
 	}
-	saveFeedbackReturnsOnCall map[int]struct {
-		result1 error
+	fakeReturns := fake.similarFeedbackReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) SimilarFeedbackCallCount() int {
+	fake.similarFeedbackMutex.RLock()
+	defer fake.similarFeedbackMutex.RUnlock()
+	return len(fake.similarFeedbackArgsForCall)
+}
+
+func (fake *FakeDB) SimilarFeedbackCalls(stub func(context.Context, string, int, int) ([]db.SimilarFeedback, error)) {
+	fake.similarFeedbackMutex.Lock()
+	defer fake.similarFeedbackMutex.Unlock()
+	fake.SimilarFeedbackStub = stub
+}
+
+func (fake *FakeDB) SimilarFeedbackArgsForCall(i int) (context.Context, string, int, int) {
+	fake.similarFeedbackMutex.RLock()
+	defer fake.similarFeedbackMutex.RUnlock()
+	argsForCall := fake.similarFeedbackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) SimilarFeedbackReturns(result1 []db.SimilarFeedback, result2 error) {
+	fake.similarFeedbackMutex.Lock()
+	defer fake.similarFeedbackMutex.Unlock()
+	fake.SimilarFeedbackStub = nil
+	fake.similarFeedbackReturns = struct {
+		result1 []db.SimilarFeedback
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) SimilarFeedbackReturnsOnCall(i int, result1 []db.SimilarFeedback, result2 error) {
+	fake.similarFeedbackMutex.Lock()
+	defer fake.similarFeedbackMutex.Unlock()
+	fake.SimilarFeedbackStub = nil
+	if fake.similarFeedbackReturnsOnCall == nil {
+		fake.similarFeedbackReturnsOnCall = make(map[int]struct {
+			result1 []db.SimilarFeedback
+			result2 error
+		})
 	}
-	invocations      map[string][][]interface{}
-	invocationsMutex sync.RWMutex
+	fake.similarFeedbackReturnsOnCall[i] = struct {
+		result1 []db.SimilarFeedback
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeDB) GetRecentOutages(arg1 context.Context, arg2 time.Time) ([]db.Feedback, error) {
-	fake.getRecentOutagesMutex.Lock()
-	ret, specificReturn := fake.getRecentOutagesReturnsOnCall[len(fake.getRecentOutagesArgsForCall)]
-	fake.getRecentOutagesArgsForCall = append(fake.getRecentOutagesArgsForCall, struct {
+func (fake *FakeDB) StreamFeedbackPartition(arg1 context.Context, arg2 time.Time, arg3 io.Writer) (int, error) {
+	fake.streamFeedbackPartitionMutex.Lock()
+	ret, specificReturn := fake.streamFeedbackPartitionReturnsOnCall[len(fake.streamFeedbackPartitionArgsForCall)]
+	fake.streamFeedbackPartitionArgsForCall = append(fake.streamFeedbackPartitionArgsForCall, struct {
 		arg1 context.Context
 		arg2 time.Time
-	}{arg1, arg2})
-	fake.recordInvocation("GetRecentOutages", []interface{}{arg1, arg2})
-	fake.getRecentOutagesMutex.Unlock()
-	if fake.GetRecentOutagesStub != nil {
-		return fake.GetRecentOutagesStub(arg1, arg2)
+		arg3 io.Writer
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("StreamFeedbackPartition", []interface{}{arg1, arg2, arg3})
+	fake.streamFeedbackPartitionMutex.Unlock()
+	if fake.StreamFeedbackPartitionStub != nil {
+		return fake.StreamFeedbackPartitionStub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getRecentOutagesReturns
+	fakeReturns := fake.streamFeedbackPartitionReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeDB) GetRecentOutagesCallCount() int {
-	fake.getRecentOutagesMutex.RLock()
-	defer fake.getRecentOutagesMutex.RUnlock()
-	return len(fake.getRecentOutagesArgsForCall)
+func (fake *FakeDB) StreamFeedbackPartitionCallCount() int {
+	fake.streamFeedbackPartitionMutex.RLock()
+	defer fake.streamFeedbackPartitionMutex.RUnlock()
+	return len(fake.streamFeedbackPartitionArgsForCall)
 }
 
-func (fake *FakeDB) GetRecentOutagesCalls(stub func(context.Context, time.Time) ([]db.Feedback, error)) {
-	fake.getRecentOutagesMutex.Lock()
-	defer fake.getRecentOutagesMutex.Unlock()
-	fake.GetRecentOutagesStub = stub
+func (fake *FakeDB) StreamFeedbackPartitionCalls(stub func(context.Context, time.Time, io.Writer) (int, error)) {
+	fake.streamFeedbackPartitionMutex.Lock()
+	defer fake.streamFeedbackPartitionMutex.Unlock()
+	fake.StreamFeedbackPartitionStub = stub
 }
 
-func (fake *FakeDB) GetRecentOutagesArgsForCall(i int) (context.Context, time.Time) {
-	fake.getRecentOutagesMutex.RLock()
-	defer fake.getRecentOutagesMutex.RUnlock()
-	argsForCall := fake.getRecentOutagesArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+func (fake *FakeDB) StreamFeedbackPartitionArgsForCall(i int) (context.Context, time.Time, io.Writer) {
+	fake.streamFeedbackPartitionMutex.RLock()
+	defer fake.streamFeedbackPartitionMutex.RUnlock()
+	argsForCall := fake.streamFeedbackPartitionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeDB) GetRecentOutagesReturns(result1 []db.Feedback, result2 error) {
-	fake.getRecentOutagesMutex.Lock()
-	defer fake.getRecentOutagesMutex.Unlock()
-	fake.GetRecentOutagesStub = nil
-	fake.getRecentOutagesReturns = struct {
-		result1 []db.Feedback
+func (fake *FakeDB) StreamFeedbackPartitionReturns(result1 int, result2 error) {
+	fake.streamFeedbackPartitionMutex.Lock()
+	defer fake.streamFeedbackPartitionMutex.Unlock()
+	fake.StreamFeedbackPartitionStub = nil
+	fake.streamFeedbackPartitionReturns = struct {
+		result1 int
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeDB) GetRecentOutagesReturnsOnCall(i int, result1 []db.Feedback, result2 error) {
-	fake.getRecentOutagesMutex.Lock()
-	defer fake.getRecentOutagesMutex.Unlock()
-	fake.GetRecentOutagesStub = nil
-	if fake.getRecentOutagesReturnsOnCall == nil {
-		fake.getRecentOutagesReturnsOnCall = make(map[int]struct {
-			result1 []db.Feedback
+func (fake *FakeDB) StreamFeedbackPartitionReturnsOnCall(i int, result1 int, result2 error) {
+	fake.streamFeedbackPartitionMutex.Lock()
+	defer fake.streamFeedbackPartitionMutex.Unlock()
+	fake.StreamFeedbackPartitionStub = nil
+	if fake.streamFeedbackPartitionReturnsOnCall == nil {
+		fake.streamFeedbackPartitionReturnsOnCall = make(map[int]struct {
+			result1 int
 			result2 error
 		})
 	}
-	fake.getRecentOutagesReturnsOnCall[i] = struct {
-		result1 []db.Feedback
+	fake.streamFeedbackPartitionReturnsOnCall[i] = struct {
+		result1 int
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeDB) Migrate(arg1 context.Context) error {
-	fake.migrateMutex.Lock()
-	ret, specificReturn := fake.migrateReturnsOnCall[len(fake.migrateArgsForCall)]
-	fake.migrateArgsForCall = append(fake.migrateArgsForCall, struct {
+func (fake *FakeDB) TakeRateLimitToken(arg1 context.Context, arg2 string, arg3 float64, arg4 float64) (bool, float64, error) {
+	fake.takeRateLimitTokenMutex.Lock()
+	ret, specificReturn := fake.takeRateLimitTokenReturnsOnCall[len(fake.takeRateLimitTokenArgsForCall)]
+	fake.takeRateLimitTokenArgsForCall = append(fake.takeRateLimitTokenArgsForCall, struct {
 		arg1 context.Context
-	}{arg1})
-	fake.recordInvocation("Migrate", []interface{}{arg1})
-	fake.migrateMutex.Unlock()
-	if fake.MigrateStub != nil {
-		return fake.MigrateStub(arg1)
+		arg2 string
+		arg3 float64
+		arg4 float64
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("TakeRateLimitToken", []interface{}{arg1, arg2, arg3, arg4})
+	fake.takeRateLimitTokenMutex.Unlock()
+	if fake.TakeRateLimitTokenStub != nil {
+		return fake.TakeRateLimitTokenStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	fakeReturns := fake.takeRateLimitTokenReturns
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeDB) TakeRateLimitTokenCallCount() int {
+	fake.takeRateLimitTokenMutex.RLock()
+	defer fake.takeRateLimitTokenMutex.RUnlock()
+	return len(fake.takeRateLimitTokenArgsForCall)
+}
+
+func (fake *FakeDB) TakeRateLimitTokenCalls(stub func(context.Context, string, float64, float64) (bool, float64, error)) {
+	fake.takeRateLimitTokenMutex.Lock()
+	defer fake.takeRateLimitTokenMutex.Unlock()
+	fake.TakeRateLimitTokenStub = stub
+}
+
+func (fake *FakeDB) TakeRateLimitTokenArgsForCall(i int) (context.Context, string, float64, float64) {
+	fake.takeRateLimitTokenMutex.RLock()
+	defer fake.takeRateLimitTokenMutex.RUnlock()
+	argsForCall := fake.takeRateLimitTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDB) TakeRateLimitTokenReturns(result1 bool, result2 float64, result3 error) {
+	fake.takeRateLimitTokenMutex.Lock()
+	defer fake.takeRateLimitTokenMutex.Unlock()
+	fake.TakeRateLimitTokenStub = nil
+	fake.takeRateLimitTokenReturns = struct {
+		result1 bool
+		result2 float64
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDB) TakeRateLimitTokenReturnsOnCall(i int, result1 bool, result2 float64, result3 error) {
+	fake.takeRateLimitTokenMutex.Lock()
+	defer fake.takeRateLimitTokenMutex.Unlock()
+	fake.TakeRateLimitTokenStub = nil
+	if fake.takeRateLimitTokenReturnsOnCall == nil {
+		fake.takeRateLimitTokenReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 float64
+			result3 error
+		})
+	}
+	fake.takeRateLimitTokenReturnsOnCall[i] = struct {
+		result1 bool
+		result2 float64
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDB) UnsubscribeEmail(arg1 context.Context, arg2 string) error {
+	fake.unsubscribeEmailMutex.Lock()
+	ret, specificReturn := fake.unsubscribeEmailReturnsOnCall[len(fake.unsubscribeEmailArgsForCall)]
+	fake.unsubscribeEmailArgsForCall = append(fake.unsubscribeEmailArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("UnsubscribeEmail", []interface{}{arg1, arg2})
+	fake.unsubscribeEmailMutex.Unlock()
+	if fake.UnsubscribeEmailStub != nil {
+		return fake.UnsubscribeEmailStub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.migrateReturns
+	fakeReturns := fake.unsubscribeEmailReturns
 	return fakeReturns.result1
 }
 
-func (fake *FakeDB) MigrateCallCount() int {
-	fake.migrateMutex.RLock()
-	defer fake.migrateMutex.RUnlock()
-	return len(fake.migrateArgsForCall)
+func (fake *FakeDB) UnsubscribeEmailCallCount() int {
+	fake.unsubscribeEmailMutex.RLock()
+	defer fake.unsubscribeEmailMutex.RUnlock()
+	return len(fake.unsubscribeEmailArgsForCall)
 }
 
-func (fake *FakeDB) MigrateCalls(stub func(context.Context) error) {
-	fake.migrateMutex.Lock()
-	defer fake.migrateMutex.Unlock()
-	fake.MigrateStub = stub
+func (fake *FakeDB) UnsubscribeEmailCalls(stub func(context.Context, string) error) {
+	fake.unsubscribeEmailMutex.Lock()
+	defer fake.unsubscribeEmailMutex.Unlock()
+	fake.UnsubscribeEmailStub = stub
 }
 
-func (fake *FakeDB) MigrateArgsForCall(i int) context.Context {
-	fake.migrateMutex.RLock()
-	defer fake.migrateMutex.RUnlock()
-	argsForCall := fake.migrateArgsForCall[i]
-	return argsForCall.arg1
+func (fake *FakeDB) UnsubscribeEmailArgsForCall(i int) (context.Context, string) {
+	fake.unsubscribeEmailMutex.RLock()
+	defer fake.unsubscribeEmailMutex.RUnlock()
+	argsForCall := fake.unsubscribeEmailArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeDB) MigrateReturns(result1 error) {
-	fake.migrateMutex.Lock()
-	defer fake.migrateMutex.Unlock()
-	fake.MigrateStub = nil
-	fake.migrateReturns = struct {
+func (fake *FakeDB) UnsubscribeEmailReturns(result1 error) {
+	fake.unsubscribeEmailMutex.Lock()
+	defer fake.unsubscribeEmailMutex.Unlock()
+	fake.UnsubscribeEmailStub = nil
+	fake.unsubscribeEmailReturns = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *FakeDB) MigrateReturnsOnCall(i int, result1 error) {
-	fake.migrateMutex.Lock()
-	defer fake.migrateMutex.Unlock()
-	fake.MigrateStub = nil
-	if fake.migrateReturnsOnCall == nil {
-		fake.migrateReturnsOnCall = make(map[int]struct {
+func (fake *FakeDB) UnsubscribeEmailReturnsOnCall(i int, result1 error) {
+	fake.unsubscribeEmailMutex.Lock()
+	defer fake.unsubscribeEmailMutex.Unlock()
+	fake.UnsubscribeEmailStub = nil
+	if fake.unsubscribeEmailReturnsOnCall == nil {
+		fake.unsubscribeEmailReturnsOnCall = make(map[int]struct {
 			result1 error
 		})
 	}
-	fake.migrateReturnsOnCall[i] = struct {
+	fake.unsubscribeEmailReturnsOnCall[i] = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *FakeDB) SaveFeedback(arg1 context.Context, arg2 db.Feedback) error {
-	fake.saveFeedbackMutex.Lock()
-	ret, specificReturn := fake.saveFeedbackReturnsOnCall[len(fake.saveFeedbackArgsForCall)]
-	fake.saveFeedbackArgsForCall = append(fake.saveFeedbackArgsForCall, struct {
+func (fake *FakeDB) UpdateEmail(arg1 context.Context, arg2 string, arg3 string, arg4 string) error {
+	fake.updateEmailMutex.Lock()
+	ret, specificReturn := fake.updateEmailReturnsOnCall[len(fake.updateEmailArgsForCall)]
+	fake.updateEmailArgsForCall = append(fake.updateEmailArgsForCall, struct {
 		arg1 context.Context
-		arg2 db.Feedback
-	}{arg1, arg2})
-	fake.recordInvocation("SaveFeedback", []interface{}{arg1, arg2})
-	fake.saveFeedbackMutex.Unlock()
-	if fake.SaveFeedbackStub != nil {
-		return fake.SaveFeedbackStub(arg1, arg2)
+		arg2 string
+		arg3 string
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("UpdateEmail", []interface{}{arg1, arg2, arg3, arg4})
+	fake.updateEmailMutex.Unlock()
+	if fake.UpdateEmailStub != nil {
+		return fake.UpdateEmailStub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.saveFeedbackReturns
+	fakeReturns := fake.updateEmailReturns
 	return fakeReturns.result1
 }
 
-func (fake *FakeDB) SaveFeedbackCallCount() int {
-	fake.saveFeedbackMutex.RLock()
-	defer fake.saveFeedbackMutex.RUnlock()
-	return len(fake.saveFeedbackArgsForCall)
+func (fake *FakeDB) UpdateEmailCallCount() int {
+	fake.updateEmailMutex.RLock()
+	defer fake.updateEmailMutex.RUnlock()
+	return len(fake.updateEmailArgsForCall)
 }
 
-func (fake *FakeDB) SaveFeedbackCalls(stub func(context.Context, db.Feedback) error) {
-	fake.saveFeedbackMutex.Lock()
-	defer fake.saveFeedbackMutex.Unlock()
-	fake.SaveFeedbackStub = stub
+func (fake *FakeDB) UpdateEmailCalls(stub func(context.Context, string, string, string) error) {
+	fake.updateEmailMutex.Lock()
+	defer fake.updateEmailMutex.Unlock()
+	fake.UpdateEmailStub = stub
 }
 
-func (fake *FakeDB) SaveFeedbackArgsForCall(i int) (context.Context, db.Feedback) {
-	fake.saveFeedbackMutex.RLock()
-	defer fake.saveFeedbackMutex.RUnlock()
-	argsForCall := fake.saveFeedbackArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+func (fake *FakeDB) UpdateEmailArgsForCall(i int) (context.Context, string, string, string) {
+	fake.updateEmailMutex.RLock()
+	defer fake.updateEmailMutex.RUnlock()
+	argsForCall := fake.updateEmailArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
-func (fake *FakeDB) SaveFeedbackReturns(result1 error) {
-	fake.saveFeedbackMutex.Lock()
-	defer fake.saveFeedbackMutex.Unlock()
-	fake.SaveFeedbackStub = nil
-	fake.saveFeedbackReturns = struct {
+func (fake *FakeDB) UpdateEmailReturns(result1 error) {
+	fake.updateEmailMutex.Lock()
+	defer fake.updateEmailMutex.Unlock()
+	fake.UpdateEmailStub = nil
+	fake.updateEmailReturns = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *FakeDB) SaveFeedbackReturnsOnCall(i int, result1 error) {
-	fake.saveFeedbackMutex.Lock()
-	defer fake.saveFeedbackMutex.Unlock()
-	fake.SaveFeedbackStub = nil
-	if fake.saveFeedbackReturnsOnCall == nil {
-		fake.saveFeedbackReturnsOnCall = make(map[int]struct {
+func (fake *FakeDB) UpdateEmailReturnsOnCall(i int, result1 error) {
+	fake.updateEmailMutex.Lock()
+	defer fake.updateEmailMutex.Unlock()
+	fake.UpdateEmailStub = nil
+	if fake.updateEmailReturnsOnCall == nil {
+		fake.updateEmailReturnsOnCall = make(map[int]struct {
 			result1 error
 		})
 	}
-	fake.saveFeedbackReturnsOnCall[i] = struct {
+	fake.updateEmailReturnsOnCall[i] = struct {
 		result1 error
 	}{result1}
 }
 
+func (fake *FakeDB) UpdateTriage(arg1 context.Context, arg2 db.TriageUpdate) ([]db.TriageChange, error) {
+	fake.updateTriageMutex.Lock()
+	ret, specificReturn := fake.updateTriageReturnsOnCall[len(fake.updateTriageArgsForCall)]
+	fake.updateTriageArgsForCall = append(fake.updateTriageArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.TriageUpdate
+	}{arg1, arg2})
+	fake.recordInvocation("UpdateTriage", []interface{}{arg1, arg2})
+	fake.updateTriageMutex.Unlock()
+	if fake.UpdateTriageStub != nil {
+		return fake.UpdateTriageStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.updateTriageReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDB) UpdateTriageCallCount() int {
+	fake.updateTriageMutex.RLock()
+	defer fake.updateTriageMutex.RUnlock()
+	return len(fake.updateTriageArgsForCall)
+}
+
+func (fake *FakeDB) UpdateTriageCalls(stub func(context.Context, db.TriageUpdate) ([]db.TriageChange, error)) {
+	fake.updateTriageMutex.Lock()
+	defer fake.updateTriageMutex.Unlock()
+	fake.UpdateTriageStub = stub
+}
+
+func (fake *FakeDB) UpdateTriageArgsForCall(i int) (context.Context, db.TriageUpdate) {
+	fake.updateTriageMutex.RLock()
+	defer fake.updateTriageMutex.RUnlock()
+	argsForCall := fake.updateTriageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDB) UpdateTriageReturns(result1 []db.TriageChange, result2 error) {
+	fake.updateTriageMutex.Lock()
+	defer fake.updateTriageMutex.Unlock()
+	fake.UpdateTriageStub = nil
+	fake.updateTriageReturns = struct {
+		result1 []db.TriageChange
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDB) UpdateTriageReturnsOnCall(i int, result1 []db.TriageChange, result2 error) {
+	fake.updateTriageMutex.Lock()
+	defer fake.updateTriageMutex.Unlock()
+	fake.UpdateTriageStub = nil
+	if fake.updateTriageReturnsOnCall == nil {
+		fake.updateTriageReturnsOnCall = make(map[int]struct {
+			result1 []db.TriageChange
+			result2 error
+		})
+	}
+	fake.updateTriageReturnsOnCall[i] = struct {
+		result1 []db.TriageChange
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeDB) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.anonymizeRiderFeedbackMutex.RLock()
+	defer fake.anonymizeRiderFeedbackMutex.RUnlock()
+	fake.appendAuditEventMutex.RLock()
+	defer fake.appendAuditEventMutex.RUnlock()
+	fake.claimEmailsMutex.RLock()
+	defer fake.claimEmailsMutex.RUnlock()
+	fake.countDuplicateMessagesMutex.RLock()
+	defer fake.countDuplicateMessagesMutex.RUnlock()
+	fake.countExpiredFeedbackMutex.RLock()
+	defer fake.countExpiredFeedbackMutex.RUnlock()
+	fake.countFeedbackByClientMutex.RLock()
+	defer fake.countFeedbackByClientMutex.RUnlock()
+	fake.countSurveyAnswersMutex.RLock()
+	defer fake.countSurveyAnswersMutex.RUnlock()
+	fake.createFeedbackPartitionMutex.RLock()
+	defer fake.createFeedbackPartitionMutex.RUnlock()
+	fake.createSurveyVersionMutex.RLock()
+	defer fake.createSurveyVersionMutex.RUnlock()
+	fake.deleteAttachmentMutex.RLock()
+	defer fake.deleteAttachmentMutex.RUnlock()
+	fake.deleteRetentionPolicyMutex.RLock()
+	defer fake.deleteRetentionPolicyMutex.RUnlock()
+	fake.deleteRiderFeedbackMutex.RLock()
+	defer fake.deleteRiderFeedbackMutex.RUnlock()
+	fake.detachFeedbackPartitionMutex.RLock()
+	defer fake.detachFeedbackPartitionMutex.RUnlock()
+	fake.dropEmailMutex.RLock()
+	defer fake.dropEmailMutex.RUnlock()
+	fake.dropFeedbackPartitionMutex.RLock()
+	defer fake.dropFeedbackPartitionMutex.RUnlock()
+	fake.findRiderAttachmentsMutex.RLock()
+	defer fake.findRiderAttachmentsMutex.RUnlock()
+	fake.findRiderFeedbackMutex.RLock()
+	defer fake.findRiderFeedbackMutex.RUnlock()
+	fake.findRiderMessagesMutex.RLock()
+	defer fake.findRiderMessagesMutex.RUnlock()
+	fake.findRiderSurveyResponsesMutex.RLock()
+	defer fake.findRiderSurveyResponsesMutex.RUnlock()
+	fake.getAttachmentsMutex.RLock()
+	defer fake.getAttachmentsMutex.RUnlock()
+	fake.getLastAuditEventMutex.RLock()
+	defer fake.getLastAuditEventMutex.RUnlock()
+	fake.getModerationDecisionsMutex.RLock()
+	defer fake.getModerationDecisionsMutex.RUnlock()
+	fake.getOriginalMessageMutex.RLock()
+	defer fake.getOriginalMessageMutex.RUnlock()
 	fake.getRecentOutagesMutex.RLock()
 	defer fake.getRecentOutagesMutex.RUnlock()
+	fake.getRetentionPoliciesMutex.RLock()
+	defer fake.getRetentionPoliciesMutex.RUnlock()
+	fake.getSurveyVersionMutex.RLock()
+	defer fake.getSurveyVersionMutex.RUnlock()
+	fake.getTriageTransitionsMutex.RLock()
+	defer fake.getTriageTransitionsMutex.RUnlock()
+	fake.inTransactionMutex.RLock()
+	defer fake.inTransactionMutex.RUnlock()
+	fake.isEmailUnsubscribedMutex.RLock()
+	defer fake.isEmailUnsubscribedMutex.RUnlock()
+	fake.listAuditEventsMutex.RLock()
+	defer fake.listAuditEventsMutex.RUnlock()
+	fake.listAuditEventsAfterMutex.RLock()
+	defer fake.listAuditEventsAfterMutex.RUnlock()
+	fake.listDiscardedAttachmentsMutex.RLock()
+	defer fake.listDiscardedAttachmentsMutex.RUnlock()
+	fake.listEmailsToRotateMutex.RLock()
+	defer fake.listEmailsToRotateMutex.RUnlock()
+	fake.listFeedbackMutex.RLock()
+	defer fake.listFeedbackMutex.RUnlock()
+	fake.listFeedbackAttachmentsMutex.RLock()
+	defer fake.listFeedbackAttachmentsMutex.RUnlock()
+	fake.listFeedbackByEmailMutex.RLock()
+	defer fake.listFeedbackByEmailMutex.RUnlock()
+	fake.listFeedbackByModerationStatusMutex.RLock()
+	defer fake.listFeedbackByModerationStatusMutex.RUnlock()
+	fake.listFeedbackKindsMutex.RLock()
+	defer fake.listFeedbackKindsMutex.RUnlock()
+	fake.listFeedbackMessagesMutex.RLock()
+	defer fake.listFeedbackMessagesMutex.RUnlock()
+	fake.listFeedbackThreadsMutex.RLock()
+	defer fake.listFeedbackThreadsMutex.RUnlock()
+	fake.listFeedbackValuesMutex.RLock()
+	defer fake.listFeedbackValuesMutex.RUnlock()
+	fake.markEmailFailedMutex.RLock()
+	defer fake.markEmailFailedMutex.RUnlock()
+	fake.markEmailSentMutex.RLock()
+	defer fake.markEmailSentMutex.RUnlock()
+	fake.markFeedbackMessagesReadMutex.RLock()
+	defer fake.markFeedbackMessagesReadMutex.RUnlock()
 	fake.migrateMutex.RLock()
 	defer fake.migrateMutex.RUnlock()
+	fake.moderateFeedbackMutex.RLock()
+	defer fake.moderateFeedbackMutex.RUnlock()
+	fake.pruneRateLimitBucketsMutex.RLock()
+	defer fake.pruneRateLimitBucketsMutex.RUnlock()
+	fake.purgeExpiredFeedbackMutex.RLock()
+	defer fake.purgeExpiredFeedbackMutex.RUnlock()
+	fake.queueOutageResolvedEmailsMutex.RLock()
+	defer fake.queueOutageResolvedEmailsMutex.RUnlock()
+	fake.recordDataRequestMutex.RLock()
+	defer fake.recordDataRequestMutex.RUnlock()
+	fake.refundRateLimitTokenMutex.RLock()
+	defer fake.refundRateLimitTokenMutex.RUnlock()
+	fake.saveAttachmentMutex.RLock()
+	defer fake.saveAttachmentMutex.RUnlock()
 	fake.saveFeedbackMutex.RLock()
 	defer fake.saveFeedbackMutex.RUnlock()
+	fake.saveFeedbackMessageMutex.RLock()
+	defer fake.saveFeedbackMessageMutex.RUnlock()
+	fake.saveSurveyResponseMutex.RLock()
+	defer fake.saveSurveyResponseMutex.RUnlock()
+	fake.searchFeedbackMutex.RLock()
+	defer fake.searchFeedbackMutex.RUnlock()
+	fake.setActiveSurveyVersionMutex.RLock()
+	defer fake.setActiveSurveyVersionMutex.RUnlock()
+	fake.setRetentionPolicyMutex.RLock()
+	defer fake.setRetentionPolicyMutex.RUnlock()
+	fake.silenceFeedbackMutex.RLock()
+	defer fake.silenceFeedbackMutex.RUnlock()
+	fake.similarFeedbackMutex.RLock()
+	defer fake.similarFeedbackMutex.RUnlock()
+	fake.streamFeedbackPartitionMutex.RLock()
+	defer fake.streamFeedbackPartitionMutex.RUnlock()
+	fake.takeRateLimitTokenMutex.RLock()
+	defer fake.takeRateLimitTokenMutex.RUnlock()
+	fake.unsubscribeEmailMutex.RLock()
+	defer fake.unsubscribeEmailMutex.RUnlock()
+	fake.updateEmailMutex.RLock()
+	defer fake.updateEmailMutex.RUnlock()
+	fake.updateTriageMutex.RLock()
+	defer fake.updateTriageMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -10,6 +10,21 @@ import (
 )
 
 type FakeDBDriver struct {
+	BeginTxStub        func(context.Context) (context.Context, db.Tx, error)
+	beginTxMutex       sync.RWMutex
+	beginTxArgsForCall []struct {
+		arg1 context.Context
+	}
+	beginTxReturns struct {
+		result1 context.Context
+		result2 db.Tx
+		result3 error
+	}
+	beginTxReturnsOnCall map[int]struct {
+		result1 context.Context
+		result2 db.Tx
+		result3 error
+	}
 	ExecContextStub        func(context.Context, string, ...interface{}) (sql.Result, error)
 	execContextMutex       sync.RWMutex
 	execContextArgsForCall []struct {
@@ -44,6 +59,72 @@ type FakeDBDriver struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeDBDriver) BeginTx(arg1 context.Context) (context.Context, db.Tx, error) {
+	fake.beginTxMutex.Lock()
+	ret, specificReturn := fake.beginTxReturnsOnCall[len(fake.beginTxArgsForCall)]
+	fake.beginTxArgsForCall = append(fake.beginTxArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	fake.recordInvocation("BeginTx", []interface{}{arg1})
+	fake.beginTxMutex.Unlock()
+	if fake.BeginTxStub != nil {
+		return fake.BeginTxStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	fakeReturns := fake.beginTxReturns
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeDBDriver) BeginTxCallCount() int {
+	fake.beginTxMutex.RLock()
+	defer fake.beginTxMutex.RUnlock()
+	return len(fake.beginTxArgsForCall)
+}
+
+func (fake *FakeDBDriver) BeginTxCalls(stub func(context.Context) (context.Context, db.Tx, error)) {
+	fake.beginTxMutex.Lock()
+	defer fake.beginTxMutex.Unlock()
+	fake.BeginTxStub = stub
+}
+
+func (fake *FakeDBDriver) BeginTxArgsForCall(i int) context.Context {
+	fake.beginTxMutex.RLock()
+	defer fake.beginTxMutex.RUnlock()
+	argsForCall := fake.beginTxArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDBDriver) BeginTxReturns(result1 context.Context, result2 db.Tx, result3 error) {
+	fake.beginTxMutex.Lock()
+	defer fake.beginTxMutex.Unlock()
+	fake.BeginTxStub = nil
+	fake.beginTxReturns = struct {
+		result1 context.Context
+		result2 db.Tx
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDBDriver) BeginTxReturnsOnCall(i int, result1 context.Context, result2 db.Tx, result3 error) {
+	fake.beginTxMutex.Lock()
+	defer fake.beginTxMutex.Unlock()
+	fake.BeginTxStub = nil
+	if fake.beginTxReturnsOnCall == nil {
+		fake.beginTxReturnsOnCall = make(map[int]struct {
+			result1 context.Context
+			result2 db.Tx
+			result3 error
+		})
+	}
+	fake.beginTxReturnsOnCall[i] = struct {
+		result1 context.Context
+		result2 db.Tx
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *FakeDBDriver) ExecContext(arg1 context.Context, arg2 string, arg3 ...interface{}) (sql.Result, error) {
 	fake.execContextMutex.Lock()
 	ret, specificReturn := fake.execContextReturnsOnCall[len(fake.execContextArgsForCall)]
@@ -177,6 +258,8 @@ func (fake *FakeDBDriver) QueryContextReturnsOnCall(i int, result1 *sql.Rows, re
 func (fake *FakeDBDriver) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.beginTxMutex.RLock()
+	defer fake.beginTxMutex.RUnlock()
 	fake.execContextMutex.RLock()
 	defer fake.execContextMutex.RUnlock()
 	fake.queryContextMutex.RLock()
@@ -0,0 +1,266 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package dbfakes
+
+import (
+	"sync"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+type FakeEmailCipher struct {
+	BlindIndexStub        func(string) string
+	blindIndexMutex       sync.RWMutex
+	blindIndexArgsForCall []struct {
+		arg1 string
+	}
+	blindIndexReturns struct {
+		result1 string
+	}
+	blindIndexReturnsOnCall map[int]struct {
+		result1 string
+	}
+	DecryptStub        func(string) (string, error)
+	decryptMutex       sync.RWMutex
+	decryptArgsForCall []struct {
+		arg1 string
+	}
+	decryptReturns struct {
+		result1 string
+		result2 error
+	}
+	decryptReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	EncryptStub        func(string) (string, error)
+	encryptMutex       sync.RWMutex
+	encryptArgsForCall []struct {
+		arg1 string
+	}
+	encryptReturns struct {
+		result1 string
+		result2 error
+	}
+	encryptReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeEmailCipher) BlindIndex(arg1 string) string {
+	fake.blindIndexMutex.Lock()
+	ret, specificReturn := fake.blindIndexReturnsOnCall[len(fake.blindIndexArgsForCall)]
+	fake.blindIndexArgsForCall = append(fake.blindIndexArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("BlindIndex", []interface{}{arg1})
+	fake.blindIndexMutex.Unlock()
+	if fake.BlindIndexStub != nil {
+		return fake.BlindIndexStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.blindIndexReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeEmailCipher) BlindIndexCallCount() int {
+	fake.blindIndexMutex.RLock()
+	defer fake.blindIndexMutex.RUnlock()
+	return len(fake.blindIndexArgsForCall)
+}
+
+func (fake *FakeEmailCipher) BlindIndexCalls(stub func(string) string) {
+	fake.blindIndexMutex.Lock()
+	defer fake.blindIndexMutex.Unlock()
+	fake.BlindIndexStub = stub
+}
+
+func (fake *FakeEmailCipher) BlindIndexArgsForCall(i int) string {
+	fake.blindIndexMutex.RLock()
+	defer fake.blindIndexMutex.RUnlock()
+	argsForCall := fake.blindIndexArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEmailCipher) BlindIndexReturns(result1 string) {
+	fake.blindIndexMutex.Lock()
+	defer fake.blindIndexMutex.Unlock()
+	fake.BlindIndexStub = nil
+	fake.blindIndexReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeEmailCipher) BlindIndexReturnsOnCall(i int, result1 string) {
+	fake.blindIndexMutex.Lock()
+	defer fake.blindIndexMutex.Unlock()
+	fake.BlindIndexStub = nil
+	if fake.blindIndexReturnsOnCall == nil {
+		fake.blindIndexReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.blindIndexReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeEmailCipher) Decrypt(arg1 string) (string, error) {
+	fake.decryptMutex.Lock()
+	ret, specificReturn := fake.decryptReturnsOnCall[len(fake.decryptArgsForCall)]
+	fake.decryptArgsForCall = append(fake.decryptArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Decrypt", []interface{}{arg1})
+	fake.decryptMutex.Unlock()
+	if fake.DecryptStub != nil {
+		return fake.DecryptStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.decryptReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEmailCipher) DecryptCallCount() int {
+	fake.decryptMutex.RLock()
+	defer fake.decryptMutex.RUnlock()
+	return len(fake.decryptArgsForCall)
+}
+
+func (fake *FakeEmailCipher) DecryptCalls(stub func(string) (string, error)) {
+	fake.decryptMutex.Lock()
+	defer fake.decryptMutex.Unlock()
+	fake.DecryptStub = stub
+}
+
+func (fake *FakeEmailCipher) DecryptArgsForCall(i int) string {
+	fake.decryptMutex.RLock()
+	defer fake.decryptMutex.RUnlock()
+	argsForCall := fake.decryptArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEmailCipher) DecryptReturns(result1 string, result2 error) {
+	fake.decryptMutex.Lock()
+	defer fake.decryptMutex.Unlock()
+	fake.DecryptStub = nil
+	fake.decryptReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEmailCipher) DecryptReturnsOnCall(i int, result1 string, result2 error) {
+	fake.decryptMutex.Lock()
+	defer fake.decryptMutex.Unlock()
+	fake.DecryptStub = nil
+	if fake.decryptReturnsOnCall == nil {
+		fake.decryptReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.decryptReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEmailCipher) Encrypt(arg1 string) (string, error) {
+	fake.encryptMutex.Lock()
+	ret, specificReturn := fake.encryptReturnsOnCall[len(fake.encryptArgsForCall)]
+	fake.encryptArgsForCall = append(fake.encryptArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Encrypt", []interface{}{arg1})
+	fake.encryptMutex.Unlock()
+	if fake.EncryptStub != nil {
+		return fake.EncryptStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.encryptReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeEmailCipher) EncryptCallCount() int {
+	fake.encryptMutex.RLock()
+	defer fake.encryptMutex.RUnlock()
+	return len(fake.encryptArgsForCall)
+}
+
+func (fake *FakeEmailCipher) EncryptCalls(stub func(string) (string, error)) {
+	fake.encryptMutex.Lock()
+	defer fake.encryptMutex.Unlock()
+	fake.EncryptStub = stub
+}
+
+func (fake *FakeEmailCipher) EncryptArgsForCall(i int) string {
+	fake.encryptMutex.RLock()
+	defer fake.encryptMutex.RUnlock()
+	argsForCall := fake.encryptArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeEmailCipher) EncryptReturns(result1 string, result2 error) {
+	fake.encryptMutex.Lock()
+	defer fake.encryptMutex.Unlock()
+	fake.EncryptStub = nil
+	fake.encryptReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEmailCipher) EncryptReturnsOnCall(i int, result1 string, result2 error) {
+	fake.encryptMutex.Lock()
+	defer fake.encryptMutex.Unlock()
+	fake.EncryptStub = nil
+	if fake.encryptReturnsOnCall == nil {
+		fake.encryptReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.encryptReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeEmailCipher) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.blindIndexMutex.RLock()
+	defer fake.blindIndexMutex.RUnlock()
+	fake.decryptMutex.RLock()
+	defer fake.decryptMutex.RUnlock()
+	fake.encryptMutex.RLock()
+	defer fake.encryptMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeEmailCipher) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ db.EmailCipher = new(FakeEmailCipher)
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	//ListFeedbackByEmailSQL a prepared Postgres statement for listing
+	//feedback by email, newest first. Rows written before encryption was
+	//enabled have no blind index and are matched on the plain text instead.
+	ListFeedbackByEmailSQL = `
+SELECT ` + feedbackColumns + ` FROM feedbacks
+  WHERE email_index = $1
+     OR (email_index IS NULL AND lower(email) = lower($2))
+  ORDER BY received_moment DESC
+  LIMIT $3 OFFSET $4`
+
+	//ListEmailsToRotateSQL a prepared Postgres statement for listing emails
+	//not yet encrypted under a key. The key ID is compared exactly rather
+	//than with LIKE, whose `_` wildcard key IDs may contain.
+	ListEmailsToRotateSQL = `
+SELECT id, email FROM feedbacks
+  WHERE email IS NOT NULL
+    AND split_part(email, ':', 1) <> $1
+  ORDER BY id
+  LIMIT $2`
+
+	//UpdateEmailSQL a prepared Postgres statement for replacing a feedback's
+	//stored email and blind index
+	UpdateEmailSQL = `
+UPDATE feedbacks SET email = $2, email_index = $3
+  WHERE id = $1`
+)
+
+//EmailCipher encrypts emails at rest
+//go:generate counterfeiter . EmailCipher
+type EmailCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+	BlindIndex(plaintext string) string
+}
+
+//StoredEmail is a feedback's email as stored, which may be encrypted
+type StoredEmail struct {
+	FeedbackID string
+	Email      string
+}
+
+//WithEmailCipher returns a copy of c that encrypts emails when saving
+//feedback and decrypts them when reading it
+func (c Client) WithEmailCipher(cipher EmailCipher) Client {
+	c.cipher = cipher
+	return c
+}
+
+func (c Client) encryptEmail(email *string) (stored, index *string, err error) {
+	if c.cipher == nil || email == nil {
+		return email, nil, nil
+	}
+
+	encrypted, err := c.cipher.Encrypt(*email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed encrypting email: %w", err)
+	}
+	blindIndex := c.cipher.BlindIndex(*email)
+
+	return &encrypted, &blindIndex, nil
+}
+
+func (c Client) decryptEmail(email *string) (*string, error) {
+	if c.cipher == nil || email == nil {
+		return email, nil
+	}
+
+	decrypted, err := c.cipher.Decrypt(*email)
+	if err != nil {
+		return nil, fmt.Errorf("failed decrypting email: %w", err)
+	}
+
+	return &decrypted, nil
+}
+
+//ListFeedbackByEmail lists feedback submitted with email, newest first
+func (c Client) ListFeedbackByEmail(ctx context.Context, email string, limit, offset int) ([]Feedback, error) {
+	var index string
+	if c.cipher != nil {
+		index = c.cipher.BlindIndex(email)
+	}
+
+	rows, err := c.db.QueryContext(ctx, ListFeedbackByEmailSQL, index, email, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing feedback by email: %w", err)
+	}
+
+	return c.scanFeedbacks(rows)
+}
+
+//ListEmailsToRotate returns up to limit stored emails that aren't encrypted
+//under keyID, including any that aren't encrypted at all
+func (c Client) ListEmailsToRotate(ctx context.Context, keyID string, limit int) ([]StoredEmail, error) {
+	rows, err := c.db.QueryContext(ctx, ListEmailsToRotateSQL, keyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing emails to rotate: %w", err)
+	}
+	defer rows.Close()
+
+	result := []StoredEmail{}
+	for rows.Next() {
+		var e StoredEmail
+		if err = rows.Scan(&e.FeedbackID, &e.Email); err != nil {
+			return nil, fmt.Errorf("failed scanning emails to rotate: %w", err)
+		}
+		result = append(result, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading emails to rotate: %w", err)
+	}
+
+	return result, nil
+}
+
+//UpdateEmail replaces the stored email and blind index of a feedback. The
+//email is written as given, without further encryption.
+func (c Client) UpdateEmail(ctx context.Context, feedbackID, email, emailIndex string) error {
+	_, err := c.db.ExecContext(ctx, UpdateEmailSQL, feedbackID, email, emailIndex)
+	if err != nil {
+		return fmt.Errorf("failed updating email: %w", err)
+	}
+
+	return nil
+}
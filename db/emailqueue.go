@@ -0,0 +1,227 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	//ClaimEmailsSQL a prepared Postgres statement for claiming a batch of
+	//due emails for $2 seconds, counting the attempt, along with what is
+	//needed to write them. Emails whose feedback is gone have no kind.
+	ClaimEmailsSQL = `
+WITH claimed AS (
+  UPDATE email_queue
+    SET attempts = attempts + 1,
+        next_attempt_moment = NOW() + $2 * interval '1 second'
+    WHERE id IN (
+      SELECT id FROM email_queue
+        WHERE status = 'pending'
+          AND next_attempt_moment <= NOW()
+        ORDER BY next_attempt_moment
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING id, feedback_id, message_id, template, attempts
+)
+SELECT c.id, c.feedback_id, c.template, c.attempts,
+       f.email, COALESCE(f.email_opt_in_moment IS NOT NULL, false), f.kind, f.line, f.received_moment,
+       m.body
+  FROM claimed c
+  LEFT JOIN feedbacks f ON f.id = c.feedback_id
+  LEFT JOIN feedback_messages m ON m.id = c.message_id
+  ORDER BY c.id`
+
+	//MarkEmailSentSQL a prepared Postgres statement for recording that an
+	//email was sent
+	MarkEmailSentSQL = `
+UPDATE email_queue
+  SET status = 'sent', sent_moment = NOW(), last_error = NULL
+  WHERE id = $1`
+
+	//MarkEmailFailedSQL a prepared Postgres statement for recording a failed
+	//attempt to send an email, which is retried at $3 or, if that is NULL,
+	//given up on
+	MarkEmailFailedSQL = `
+UPDATE email_queue
+  SET status = CASE WHEN $3::timestamp IS NULL THEN 'failed' ELSE 'pending' END,
+      next_attempt_moment = COALESCE($3::timestamp, next_attempt_moment),
+      last_error = $2
+  WHERE id = $1`
+
+	//DropEmailSQL a prepared Postgres statement for giving up on an email
+	//that must not be sent
+	DropEmailSQL = `
+UPDATE email_queue
+  SET status = 'dropped', last_error = $2
+  WHERE id = $1`
+
+	//QueueOutageResolvedEmailsSQL a prepared Postgres statement for queueing
+	//an email to every rider who opted in when reporting an outage, on a line
+	//if $1 is set, since $2. Each report is only emailed once.
+	QueueOutageResolvedEmailsSQL = `
+INSERT INTO email_queue (feedback_id, template)
+  SELECT id, 'outage_resolved' FROM feedbacks
+    WHERE kind = 'outage'
+      AND ($1::varchar IS NULL OR line = $1)
+      AND received_moment >= $2
+      AND email IS NOT NULL
+      AND email_opt_in_moment IS NOT NULL
+  ON CONFLICT (feedback_id) WHERE template = 'outage_resolved' DO NOTHING`
+
+	//UnsubscribeEmailSQL a prepared Postgres statement for recording that an
+	//address asked for no more email
+	UnsubscribeEmailSQL = `
+INSERT INTO email_unsubscribes (digest) VALUES ($1)
+  ON CONFLICT DO NOTHING`
+
+	//IsEmailUnsubscribedSQL a prepared Postgres statement for checking
+	//whether an address asked for no more email
+	IsEmailUnsubscribedSQL = `
+SELECT EXISTS (SELECT 1 FROM email_unsubscribes WHERE digest = $1)`
+)
+
+//Email templates
+const (
+	EmailTemplateAcknowledgement = "acknowledgement"
+	EmailTemplateOutageResolved  = "outage_resolved"
+	EmailTemplateReply           = "reply"
+)
+
+//QueuedEmail is an email claimed for sending, with the details of its
+//feedback. The feedback fields are empty if the feedback no longer exists.
+type QueuedEmail struct {
+	ID         int64
+	FeedbackID string
+	Template   string
+	Attempts   int
+
+	//Email is the decrypted address of the rider, and OptedIn whether they
+	//agreed to be emailed
+	Email   *string
+	OptedIn bool
+
+	Kind       *string
+	Line       *string
+	ReceivedAt *time.Time
+
+	//Reply is the staff message a reply email is about
+	Reply *string
+}
+
+//ClaimEmails returns up to limit emails that are due, hiding them from
+//other claims for lease in case sending is interrupted
+func (c Client) ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]QueuedEmail, error) {
+	rows, err := c.db.QueryContext(ctx, ClaimEmailsSQL, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed claiming emails: %w", err)
+	}
+	defer rows.Close()
+
+	result := []QueuedEmail{}
+	for rows.Next() {
+		var e QueuedEmail
+		err = rows.Scan(
+			&e.ID, &e.FeedbackID, &e.Template, &e.Attempts,
+			&e.Email, &e.OptedIn, &e.Kind, &e.Line, &e.ReceivedAt,
+			&e.Reply,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning email: %w", err)
+		}
+
+		if e.Email, err = c.decryptEmail(e.Email); err != nil {
+			return nil, err
+		}
+
+		result = append(result, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading emails: %w", err)
+	}
+
+	return result, nil
+}
+
+//MarkEmailSent records that an email was sent
+func (c Client) MarkEmailSent(ctx context.Context, id int64) error {
+	_, err := c.db.ExecContext(ctx, MarkEmailSentSQL, id)
+	if err != nil {
+		return fmt.Errorf("failed marking email sent: %w", err)
+	}
+
+	return nil
+}
+
+//MarkEmailFailed records why sending an email failed. It is retried at
+//retryAt, or never if retryAt is nil.
+func (c Client) MarkEmailFailed(ctx context.Context, id int64, reason string, retryAt *time.Time) error {
+	_, err := c.db.ExecContext(ctx, MarkEmailFailedSQL, id, reason, retryAt)
+	if err != nil {
+		return fmt.Errorf("failed marking email failed: %w", err)
+	}
+
+	return nil
+}
+
+//DropEmail gives up on an email that must not be sent, recording why
+func (c Client) DropEmail(ctx context.Context, id int64, reason string) error {
+	_, err := c.db.ExecContext(ctx, DropEmailSQL, id, reason)
+	if err != nil {
+		return fmt.Errorf("failed dropping email: %w", err)
+	}
+
+	return nil
+}
+
+//QueueOutageResolvedEmails queues an email to every rider who opted in when
+//reporting an outage since `since`, on line if it is set. It returns how
+//many were queued.
+func (c Client) QueueOutageResolvedEmails(ctx context.Context, line *string, since time.Time) (int, error) {
+	result, err := c.db.ExecContext(ctx, QueueOutageResolvedEmailsSQL, line, since)
+	if err != nil {
+		return 0, fmt.Errorf("failed queueing outage resolved emails: %w", err)
+	}
+
+	queued, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed counting queued emails: %w", err)
+	}
+
+	return int(queued), nil
+}
+
+//UnsubscribeEmail records that the address with digest asked for no more
+//email
+func (c Client) UnsubscribeEmail(ctx context.Context, digest string) error {
+	_, err := c.db.ExecContext(ctx, UnsubscribeEmailSQL, digest)
+	if err != nil {
+		return fmt.Errorf("failed unsubscribing email: %w", err)
+	}
+
+	return nil
+}
+
+//IsEmailUnsubscribed reports whether the address with digest asked for no
+//more email
+func (c Client) IsEmailUnsubscribed(ctx context.Context, digest string) (bool, error) {
+	rows, err := c.db.QueryContext(ctx, IsEmailUnsubscribedSQL, digest)
+	if err != nil {
+		return false, fmt.Errorf("failed checking email unsubscription: %w", err)
+	}
+	defer rows.Close()
+
+	var unsubscribed bool
+	if rows.Next() {
+		if err = rows.Scan(&unsubscribed); err != nil {
+			return false, fmt.Errorf("failed scanning email unsubscription: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed checking email unsubscription: %w", err)
+	}
+
+	return unsubscribed, nil
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+//feedbackFilterConditions matches the FeedbackFilter in the parameters
+//returned by filterParams. Each condition is skipped when its parameters are
+//NULL.
+const feedbackFilterConditions = `($1::varchar IS NULL OR kind = $1)
+    AND ($2::jsonb IS NULL OR details @> $2::jsonb)
+    AND ($3::float8 IS NULL OR point(longitude, latitude) <@ box(point($4::float8, $3::float8), point($6::float8, $5::float8)))
+    AND ($9::varchar IS NULL OR triage_status = $9)
+    AND ($10::varchar IS NULL OR assignee = $10)
+    AND (NOT $11::boolean OR assignee IS NULL)
+    AND ($12::varchar IS NULL OR priority = $12)
+    AND tags @> $13::text[]`
+
+const (
+	//ListFeedbackSQL a prepared Postgres statement for listing feedback
+	//matching a FeedbackFilter, newest first
+	ListFeedbackSQL = `
+SELECT ` + feedbackColumns + ` FROM feedbacks
+  WHERE ` + feedbackFilterConditions + `
+  ORDER BY received_moment DESC
+  LIMIT $7 OFFSET $8`
+)
+
+//FeedbackFilter narrows a feedback listing. Every field set must match.
+type FeedbackFilter struct {
+	Kind *string
+
+	//Details matches feedback whose details contain this JSON object
+	Details []byte
+
+	//Area matches feedback whose location lies within it
+	Area *BoundingBox
+
+	//TriageStatus, Assignee and Priority match feedback with that triage.
+	//Unassigned matches feedback without an assignee, and Tags feedback with
+	//every one of the tags.
+	TriageStatus *string
+	Assignee     *string
+	Unassigned   bool
+	Priority     *string
+	Tags         []string
+}
+
+//BoundingBox is an area between two latitudes and two longitudes, in
+//degrees. It can't cross the antimeridian.
+type BoundingBox struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+//filterParams returns the parameters of feedbackFilterConditions, with the
+//page in $7 and $8
+func filterParams(filter FeedbackFilter, limit, offset int) []interface{} {
+	var minLat, minLon, maxLat, maxLon *float64
+	if a := filter.Area; a != nil {
+		minLat, minLon, maxLat, maxLon = &a.MinLatitude, &a.MinLongitude, &a.MaxLatitude, &a.MaxLongitude
+	}
+
+	return []interface{}{
+		filter.Kind, jsonParam(filter.Details), minLat, minLon, maxLat, maxLon, limit, offset,
+		filter.TriageStatus, filter.Assignee, filter.Unassigned, filter.Priority, pq.Array(nonNilStrings(filter.Tags)),
+	}
+}
+
+//ListFeedback returns a page of feedback matching filter, newest first
+func (c Client) ListFeedback(ctx context.Context, filter FeedbackFilter, limit, offset int) ([]Feedback, error) {
+	rows, err := c.db.QueryContext(ctx, ListFeedbackSQL, filterParams(filter, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing feedback: %w", err)
+	}
+
+	return c.scanFeedbacks(rows)
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	//ListFeedbackKindsSQL a prepared Postgres statement for listing the kinds
+	//of feedback, in display order
+	ListFeedbackKindsSQL = `
+SELECT name, label, active, value_required, message_required, email_required, details_schema
+  FROM feedback_kinds
+  ORDER BY sort_order, name`
+
+	//ListFeedbackValuesSQL a prepared Postgres statement for listing the
+	//values feedback may carry, in display order
+	ListFeedbackValuesSQL = `
+SELECT name, label, active
+  FROM feedback_values
+  ORDER BY sort_order, name`
+)
+
+//FeedbackKind is a kind of feedback riders may submit, and which of the
+//optional fields it requires. Inactive kinds are no longer accepted, but
+//existing feedback may still have them.
+type FeedbackKind struct {
+	Name            string
+	Label           string
+	Active          bool
+	ValueRequired   bool
+	MessageRequired bool
+	EmailRequired   bool
+
+	//DetailsSchema is the JSON Schema of the details feedback of this kind
+	//may carry. Without one, details aren't accepted.
+	DetailsSchema []byte
+}
+
+//FeedbackValue is a value, such as a sentiment, feedback may carry
+type FeedbackValue struct {
+	Name   string
+	Label  string
+	Active bool
+}
+
+//ListFeedbackKinds returns every kind of feedback, in display order
+func (c Client) ListFeedbackKinds(ctx context.Context) ([]FeedbackKind, error) {
+	rows, err := c.db.QueryContext(ctx, ListFeedbackKindsSQL)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing feedback kinds: %w", err)
+	}
+	defer rows.Close()
+
+	result := []FeedbackKind{}
+	for rows.Next() {
+		var k FeedbackKind
+		err = rows.Scan(&k.Name, &k.Label, &k.Active, &k.ValueRequired, &k.MessageRequired, &k.EmailRequired, &k.DetailsSchema)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning feedback kinds: %w", err)
+		}
+		result = append(result, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading feedback kinds: %w", err)
+	}
+
+	return result, nil
+}
+
+//ListFeedbackValues returns every feedback value, in display order
+func (c Client) ListFeedbackValues(ctx context.Context) ([]FeedbackValue, error) {
+	rows, err := c.db.QueryContext(ctx, ListFeedbackValuesSQL)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing feedback values: %w", err)
+	}
+	defer rows.Close()
+
+	result := []FeedbackValue{}
+	for rows.Next() {
+		var v FeedbackValue
+		if err = rows.Scan(&v.Name, &v.Label, &v.Active); err != nil {
+			return nil, fmt.Errorf("failed scanning feedback values: %w", err)
+		}
+		result = append(result, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading feedback values: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	//SaveFeedbackMessageSQL a prepared Postgres statement for adding a message
+	//to a feedback's thread, and optionally queueing an email about it when
+	//the rider opted in to email. Riders may only write to threads of their
+	//own feedback.
+	SaveFeedbackMessageSQL = `
+WITH feedback AS (
+  SELECT id, session_id, email IS NOT NULL AND email_opt_in_moment IS NOT NULL AS has_email FROM feedbacks
+    WHERE id = $1
+      AND anonymized_moment IS NULL
+      AND ($2::varchar = 'staff' OR session_id = $3)
+), message AS (
+  INSERT INTO feedback_messages (feedback_id, session_id, author, author_session, author_role, body)
+    SELECT id, session_id, $2, $3, $4, $5 FROM feedback
+    RETURNING id, created_moment
+), email AS (
+  INSERT INTO email_queue (feedback_id, message_id, template)
+    SELECT feedback.id, message.id, 'reply' FROM feedback, message
+      WHERE $6::boolean AND feedback.has_email
+    RETURNING id
+)
+SELECT message.id, message.created_moment, feedback.session_id, EXISTS (SELECT 1 FROM email)
+  FROM message, feedback`
+
+	//ListFeedbackMessagesSQL a prepared Postgres statement for listing a
+	//feedback's thread, oldest first, optionally only if it belongs to a
+	//rider's session. A feedback without messages gives one row of NULL
+	//message columns, and one that isn't found gives none.
+	ListFeedbackMessagesSQL = `
+WITH feedback AS (
+  SELECT id FROM feedbacks
+    WHERE id = $1
+      AND ($2::varchar IS NULL OR session_id = $2)
+)
+SELECT m.id, feedback.id, m.session_id, m.author, m.author_session, m.author_role, m.body, m.created_moment, m.read_moment
+  FROM feedback
+  LEFT JOIN feedback_messages m ON m.feedback_id = feedback.id
+  ORDER BY m.created_moment`
+
+	//MarkFeedbackMessagesReadSQL a prepared Postgres statement for marking
+	//the unread messages of one author in a thread as read
+	MarkFeedbackMessagesReadSQL = `
+UPDATE feedback_messages
+  SET read_moment = NOW()
+  WHERE feedback_id = $1
+    AND author = $2
+    AND read_moment IS NULL
+    AND ($3::varchar IS NULL OR session_id = $3)`
+
+	//ListFeedbackThreadsSQL a prepared Postgres statement for summarizing
+	//threads, most recently active first. Messages not written by $2 and not
+	//yet read count as unread.
+	ListFeedbackThreadsSQL = `
+SELECT m.feedback_id, f.kind, COUNT(*),
+       COUNT(*) FILTER (WHERE m.author <> $2 AND m.read_moment IS NULL),
+       MAX(m.created_moment)
+  FROM feedback_messages m
+  JOIN feedbacks f ON f.id = m.feedback_id
+  WHERE ($1::varchar IS NULL OR m.session_id = $1)
+  GROUP BY m.feedback_id, f.kind
+  HAVING NOT $3::boolean OR COUNT(*) FILTER (WHERE m.author <> $2 AND m.read_moment IS NULL) > 0
+  ORDER BY MAX(m.created_moment) DESC
+  LIMIT $4 OFFSET $5`
+)
+
+//feedbackMessageColumns lists the columns of a feedback message, in the
+//order they are scanned
+const feedbackMessageColumns = `id, feedback_id, session_id, author, author_session, author_role, body, created_moment, read_moment`
+
+//Authors of feedback messages
+const (
+	AuthorStaff = "staff"
+	AuthorRider = "rider"
+)
+
+//FeedbackMessage is a message in the thread of a feedback. SessionID is the
+//session of the rider who sent the feedback, and AuthorSession that of the
+//message's author.
+type FeedbackMessage struct {
+	ID            string
+	FeedbackID    string
+	SessionID     string
+	Author        string
+	AuthorSession string
+	AuthorRole    string
+	Body          string
+	CreatedAt     time.Time
+
+	//ReadAt is when the other party first saw the message
+	ReadAt *time.Time
+}
+
+//ThreadFilter selects the threads to summarize
+type ThreadFilter struct {
+	//SessionID only includes the threads of a rider's feedback, if set
+	SessionID *string
+
+	//Reader is the author whose unread messages aren't counted, since they
+	//are the ones reading
+	Reader string
+
+	//UnreadOnly only includes threads with unread messages
+	UnreadOnly bool
+}
+
+//FeedbackThread summarizes the thread of a feedback
+type FeedbackThread struct {
+	FeedbackID    string
+	Kind          string
+	Messages      int
+	Unread        int
+	LastMessageAt time.Time
+}
+
+//SaveFeedbackMessage adds m to the thread of its feedback, returning it with
+//its ID, creation time and rider session set. When queueEmail is set and the
+//rider opted in to email, an email about the message is queued, and the
+//returned flag is set. It returns ErrNotFound if the feedback doesn't
+//exist, was anonymized, or, for riders, isn't theirs.
+func (c Client) SaveFeedbackMessage(ctx context.Context, m FeedbackMessage, queueEmail bool) (FeedbackMessage, bool, error) {
+	rows, err := c.db.QueryContext(ctx, SaveFeedbackMessageSQL,
+		m.FeedbackID, m.Author, m.AuthorSession, m.AuthorRole, m.Body, queueEmail,
+	)
+	if err != nil {
+		return FeedbackMessage{}, false, fmt.Errorf("failed saving feedback message: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return FeedbackMessage{}, false, fmt.Errorf("failed saving feedback message: %w", err)
+		}
+		return FeedbackMessage{}, false, ErrNotFound
+	}
+
+	var queued bool
+	if err = rows.Scan(&m.ID, &m.CreatedAt, &m.SessionID, &queued); err != nil {
+		return FeedbackMessage{}, false, fmt.Errorf("failed scanning feedback message: %w", err)
+	}
+
+	return m, queued, nil
+}
+
+//ListFeedbackMessages returns the thread of a feedback, oldest first. It
+//returns ErrNotFound if the feedback doesn't exist or, when sessionID is set,
+//belongs to another rider.
+func (c Client) ListFeedbackMessages(ctx context.Context, feedbackID string, sessionID *string) ([]FeedbackMessage, error) {
+	rows, err := c.db.QueryContext(ctx, ListFeedbackMessagesSQL, feedbackID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing feedback messages: %w", err)
+	}
+	defer rows.Close()
+
+	found := false
+	result := []FeedbackMessage{}
+	for rows.Next() {
+		found = true
+
+		var (
+			id, session, author, authorSession, authorRole, body *string
+			createdAt                                            *time.Time
+			m                                                    FeedbackMessage
+		)
+		err = rows.Scan(
+			&id, &m.FeedbackID, &session, &author, &authorSession, &authorRole,
+			&body, &createdAt, &m.ReadAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning feedback message: %w", err)
+		}
+		if id == nil {
+			continue
+		}
+
+		m.ID, m.SessionID, m.Author = *id, *session, *author
+		m.AuthorSession, m.AuthorRole, m.Body = *authorSession, *authorRole, *body
+		m.CreatedAt = *createdAt
+		result = append(result, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading feedback messages: %w", err)
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	return result, nil
+}
+
+//MarkFeedbackMessagesRead marks the messages written by author in the thread
+//of a feedback as read. When sessionID is set, only threads of that rider's
+//feedback are changed.
+func (c Client) MarkFeedbackMessagesRead(ctx context.Context, feedbackID, author string, sessionID *string) error {
+	_, err := c.db.ExecContext(ctx, MarkFeedbackMessagesReadSQL, feedbackID, author, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed marking feedback messages read: %w", err)
+	}
+
+	return nil
+}
+
+//ListFeedbackThreads returns a page of threads matching filter, most
+//recently active first
+func (c Client) ListFeedbackThreads(ctx context.Context, filter ThreadFilter, limit, offset int) ([]FeedbackThread, error) {
+	rows, err := c.db.QueryContext(ctx, ListFeedbackThreadsSQL,
+		filter.SessionID, filter.Reader, filter.UnreadOnly, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing feedback threads: %w", err)
+	}
+	defer rows.Close()
+
+	result := []FeedbackThread{}
+	for rows.Next() {
+		var t FeedbackThread
+		if err = rows.Scan(&t.FeedbackID, &t.Kind, &t.Messages, &t.Unread, &t.LastMessageAt); err != nil {
+			return nil, fmt.Errorf("failed scanning feedback thread: %w", err)
+		}
+		result = append(result, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading feedback threads: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package db_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//upMigrations returns the contents of every up migration, oldest first
+func upMigrations() []string {
+	paths, err := filepath.Glob("../db-migrations/*.up.sql")
+	Expect(err).To(BeNil())
+	Expect(paths).NotTo(BeEmpty())
+
+	version := func(path string) int {
+		n, err := strconv.Atoi(strings.SplitN(filepath.Base(path), "_", 2)[0])
+		Expect(err).To(BeNil())
+		return n
+	}
+	sort.Slice(paths, func(i, j int) bool { return version(paths[i]) < version(paths[j]) })
+
+	migrations := make([]string, len(paths))
+	for i, path := range paths {
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).To(BeNil())
+		migrations[i] = string(contents)
+	}
+	return migrations
+}
+
+var (
+	functionRegexp      = regexp.MustCompile(`(?s)CREATE (?:OR REPLACE )?FUNCTION (\w+)\(.*?\$\$(.*?)\$\$`)
+	deleteTriggerRegexp = regexp.MustCompile(`(?s)AFTER DELETE ON feedbacks\s+FOR EACH ROW EXECUTE FUNCTION (\w+)\(`)
+	touchedTableRegexp  = regexp.MustCompile(`(?:DELETE FROM|UPDATE) (\w+)`)
+)
+
+var _ = Describe("Migrations", func() {
+	Describe("drop_feedbacks_partition", func() {
+		It("cleans up after every row trigger that dropping a partition skips", func() {
+			functions := map[string]string{}
+			triggers := []string{}
+			for _, migration := range upMigrations() {
+				for _, m := range functionRegexp.FindAllStringSubmatch(migration, -1) {
+					functions[m[1]] = m[2]
+				}
+				for _, m := range deleteTriggerRegexp.FindAllStringSubmatch(migration, -1) {
+					triggers = append(triggers, m[1])
+				}
+			}
+
+			drop, ok := functions["drop_feedbacks_partition"]
+			Expect(ok).To(BeTrue())
+			Expect(triggers).To(ContainElement("discard_feedback_attachments"))
+
+			for _, trigger := range triggers {
+				body, ok := functions[trigger]
+				Expect(ok).To(BeTrue(), trigger)
+				for _, m := range touchedTableRegexp.FindAllStringSubmatch(body, -1) {
+					Expect(drop).To(MatchRegexp(`(?:DELETE FROM|UPDATE) %s .*WHERE feedback_id IN \(SELECT id FROM %%I\)`, m[1]),
+						"%s touches %s", trigger, m[1])
+				}
+			}
+		})
+
+		It("discards the attachments of the archived month rather than deleting them", func() {
+			drop := functionRegexp.FindAllStringSubmatch(strings.Join(upMigrations(), "\n"), -1)
+			var body string
+			for _, m := range drop {
+				if m[1] == "drop_feedbacks_partition" {
+					body = m[2]
+				}
+			}
+			Expect(body).To(ContainSubstring("UPDATE feedback_attachments SET discarded_moment = NOW()"))
+			Expect(body).NotTo(ContainSubstring("DELETE FROM feedback_attachments"))
+			Expect(strings.Index(body, "feedback_attachments")).To(BeNumerically("<", strings.Index(body, "DROP TABLE")))
+		})
+	})
+})
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	//ListFeedbackByModerationStatusSQL a prepared Postgres statement for
+	//listing feedback in a moderation status, oldest first
+	ListFeedbackByModerationStatusSQL = `
+SELECT ` + feedbackColumns + ` FROM feedbacks
+  WHERE moderation_status = $1
+  ORDER BY received_moment
+  LIMIT $2 OFFSET $3`
+
+	//ModerateFeedbackSQL a prepared Postgres statement for setting a
+	//feedback's moderation status and recording the decision, returning the
+	//previous status
+	ModerateFeedbackSQL = `
+WITH previous AS (
+  SELECT id, moderation_status FROM feedbacks
+    WHERE id = $1
+    FOR UPDATE
+), updated AS (
+  UPDATE feedbacks SET moderation_status = $2
+    WHERE id IN (SELECT id FROM previous)
+    RETURNING id
+), decided AS (
+  INSERT INTO moderation_decisions
+    (feedback_id, decision, reason, moderator_session, moderator_role)
+    SELECT id, $2, $3, $4, $5 FROM updated
+)
+SELECT moderation_status FROM previous`
+
+	//SilenceFeedbackSQL a prepared Postgres statement for silencing or
+	//unsilencing a feedback, returning whether it was silenced before
+	SilenceFeedbackSQL = `
+WITH previous AS (
+  SELECT id, silenced FROM feedbacks
+    WHERE id = $1
+    FOR UPDATE
+), updated AS (
+  UPDATE feedbacks SET silenced = $2
+    WHERE id IN (SELECT id FROM previous)
+)
+SELECT silenced FROM previous`
+
+	//GetModerationDecisionsSQL a prepared Postgres statement for getting the
+	//decision history of a feedback
+	GetModerationDecisionsSQL = `
+SELECT id, feedback_id, decision, reason, moderator_session, moderator_role, decided_moment
+  FROM moderation_decisions
+  WHERE feedback_id = $1
+  ORDER BY decided_moment`
+)
+
+//ModerationDecision records a moderator approving or rejecting a feedback
+type ModerationDecision struct {
+	ID               string
+	FeedbackID       string
+	Decision         string
+	Reason           *string
+	ModeratorSession string
+	ModeratorRole    string
+	DecidedAt        time.Time
+}
+
+//ListFeedbackByModerationStatus returns a page of feedback in status, oldest first
+func (c Client) ListFeedbackByModerationStatus(ctx context.Context, status string, limit, offset int) ([]Feedback, error) {
+	rows, err := c.db.QueryContext(ctx, ListFeedbackByModerationStatusSQL, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing feedback by moderation status: %w", err)
+	}
+
+	return c.scanFeedbacks(rows)
+}
+
+//ModerateFeedback applies decision to its feedback and records it in the
+//audit trail, returning the feedback's previous moderation status. It
+//returns ErrNotFound if the feedback doesn't exist.
+func (c Client) ModerateFeedback(ctx context.Context, decision ModerationDecision) (string, error) {
+	rows, err := c.db.QueryContext(ctx, ModerateFeedbackSQL,
+		decision.FeedbackID, decision.Decision, decision.Reason,
+		decision.ModeratorSession, decision.ModeratorRole,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed moderating feedback: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", fmt.Errorf("failed moderating feedback: %w", err)
+		}
+		return "", ErrNotFound
+	}
+
+	var previous string
+	if err = rows.Scan(&previous); err != nil {
+		return "", fmt.Errorf("failed scanning previous moderation status: %w", err)
+	}
+
+	return previous, nil
+}
+
+//SilenceFeedback sets whether a feedback is silenced, returning whether it
+//was before. It returns ErrNotFound if the feedback doesn't exist.
+func (c Client) SilenceFeedback(ctx context.Context, feedbackID string, silenced bool) (bool, error) {
+	rows, err := c.db.QueryContext(ctx, SilenceFeedbackSQL, feedbackID, silenced)
+	if err != nil {
+		return false, fmt.Errorf("failed silencing feedback: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return false, fmt.Errorf("failed silencing feedback: %w", err)
+		}
+		return false, ErrNotFound
+	}
+
+	var previous bool
+	if err = rows.Scan(&previous); err != nil {
+		return false, fmt.Errorf("failed scanning previous silenced state: %w", err)
+	}
+
+	return previous, nil
+}
+
+//GetModerationDecisions returns every decision made about a feedback, oldest first
+func (c Client) GetModerationDecisions(ctx context.Context, feedbackID string) ([]ModerationDecision, error) {
+	rows, err := c.db.QueryContext(ctx, GetModerationDecisionsSQL, feedbackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting moderation decisions: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ModerationDecision{}
+	for rows.Next() {
+		var d ModerationDecision
+		err = rows.Scan(
+			&d.ID,
+			&d.FeedbackID,
+			&d.Decision,
+			&d.Reason,
+			&d.ModeratorSession,
+			&d.ModeratorRole,
+			&d.DecidedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning moderation decisions: %w", err)
+		}
+
+		result = append(result, d)
+	}
+
+	return result, nil
+}
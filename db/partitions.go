@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	//CreateFeedbackPartitionSQL a prepared Postgres statement for creating
+	//the partition of feedbacks holding a month, if it doesn't exist
+	CreateFeedbackPartitionSQL = `
+SELECT create_feedbacks_partition($1::date)`
+
+	//DetachFeedbackPartitionSQL a prepared Postgres statement for detaching
+	//the partition of feedbacks holding a month
+	DetachFeedbackPartitionSQL = `
+SELECT detach_feedbacks_partition($1::date)`
+
+	//StreamFeedbackPartitionSQL a prepared Postgres statement for reading
+	//every row of a detached partition as JSON, oldest first, without the
+	//unredacted message or the rider's email
+	StreamFeedbackPartitionSQL = `
+SELECT (to_jsonb(f) - 'message_original' - 'email' - 'email_index')::text
+FROM feedbacks_partition_rows($1::date) AS f`
+
+	//DropFeedbackPartitionSQL a prepared Postgres statement for dropping a
+	//detached partition
+	DropFeedbackPartitionSQL = `
+SELECT drop_feedbacks_partition($1::date)`
+)
+
+//monthDate formats the first day of month as a date, so that the session
+//time zone can't shift it into a neighbouring month
+func monthDate(month time.Time) string {
+	return time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+}
+
+//CreateFeedbackPartition creates the partition of feedbacks holding month,
+//if it doesn't already exist
+func (c Client) CreateFeedbackPartition(ctx context.Context, month time.Time) error {
+	_, err := c.db.ExecContext(ctx, CreateFeedbackPartitionSQL, monthDate(month))
+	if err != nil {
+		return fmt.Errorf("failed creating feedback partition: %w", err)
+	}
+
+	return nil
+}
+
+//DetachFeedbackPartition detaches the partition of feedbacks holding month.
+//Detaching a partition that is already detached does nothing.
+func (c Client) DetachFeedbackPartition(ctx context.Context, month time.Time) error {
+	_, err := c.db.ExecContext(ctx, DetachFeedbackPartitionSQL, monthDate(month))
+	if err != nil {
+		return fmt.Errorf("failed detaching feedback partition: %w", err)
+	}
+
+	return nil
+}
+
+//StreamFeedbackPartition writes every row of the detached partition holding
+//month to w as newline-delimited JSON. The unredacted message and the
+//rider's email are left out, since archives are beyond the reach of rider
+//data requests and retention policies. It returns the number of rows written.
+func (c Client) StreamFeedbackPartition(ctx context.Context, month time.Time, w io.Writer) (int, error) {
+	rows, err := c.db.QueryContext(ctx, StreamFeedbackPartitionSQL, monthDate(month))
+	if err != nil {
+		return 0, fmt.Errorf("failed reading feedback partition: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var line []byte
+		if err = rows.Scan(&line); err != nil {
+			return count, fmt.Errorf("failed scanning feedback partition: %w", err)
+		}
+		if _, err = w.Write(append(line, '\n')); err != nil {
+			return count, fmt.Errorf("failed writing feedback partition: %w", err)
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("failed reading feedback partition: %w", err)
+	}
+
+	return count, nil
+}
+
+//DropFeedbackPartition drops the detached partition holding month, along
+//with the moderation decisions, replies and triage history of its rows.
+//Their attachments are discarded, for the sweeper to delete.
+func (c Client) DropFeedbackPartition(ctx context.Context, month time.Time) error {
+	_, err := c.db.ExecContext(ctx, DropFeedbackPartitionSQL, monthDate(month))
+	if err != nil {
+		return fmt.Errorf("failed dropping feedback partition: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	//TakeRateLimitTokenSQL a prepared Postgres statement for refilling a token
+	//bucket and taking one token from it, if one is available
+	TakeRateLimitTokenSQL = `
+INSERT INTO rate_limit_buckets AS b
+  (key, tokens, updated_moment)
+  VALUES ($1, $2::float8 - 1, NOW())
+ON CONFLICT (key) DO UPDATE
+  SET tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_moment)::float8 * $3::float8) - 1,
+      updated_moment = NOW()
+  WHERE LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_moment)::float8 * $3::float8) >= 1
+RETURNING tokens`
+
+	//GetRateLimitTokensSQL a prepared Postgres statement for getting the
+	//refilled number of tokens in a bucket
+	GetRateLimitTokensSQL = `
+SELECT LEAST($2::float8, tokens + EXTRACT(EPOCH FROM NOW() - updated_moment)::float8 * $3::float8)
+  FROM rate_limit_buckets
+  WHERE key = $1`
+
+	//RefundRateLimitTokenSQL a prepared Postgres statement for refilling a
+	//token bucket and returning a token to it
+	RefundRateLimitTokenSQL = `
+UPDATE rate_limit_buckets
+  SET tokens = LEAST($2::float8, tokens + EXTRACT(EPOCH FROM NOW() - updated_moment)::float8 * $3::float8 + 1),
+      updated_moment = NOW()
+  WHERE key = $1`
+
+	//PruneRateLimitBucketsSQL a prepared Postgres statement for deleting the
+	//token buckets untouched since a time
+	PruneRateLimitBucketsSQL = `
+DELETE FROM rate_limit_buckets
+  WHERE updated_moment < $1`
+)
+
+//TakeRateLimitToken refills the bucket identified by key at refillPerSecond
+//up to capacity, then takes a token from it if one is available. It returns
+//whether a token was taken and how many remain.
+func (c Client) TakeRateLimitToken(ctx context.Context, key string, capacity, refillPerSecond float64) (bool, float64, error) {
+	rows, err := c.db.QueryContext(ctx, TakeRateLimitTokenSQL, key, capacity, refillPerSecond)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed taking rate limit token: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens float64
+	if rows.Next() {
+		if err = rows.Scan(&tokens); err != nil {
+			return false, 0, fmt.Errorf("failed scanning rate limit token: %w", err)
+		}
+		return true, tokens, nil
+	}
+	rows.Close()
+
+	rows, err = c.db.QueryContext(ctx, GetRateLimitTokensSQL, key, capacity, refillPerSecond)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed getting rate limit tokens: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err = rows.Scan(&tokens); err != nil {
+			return false, 0, fmt.Errorf("failed scanning rate limit tokens: %w", err)
+		}
+	}
+
+	return false, tokens, nil
+}
+
+//RefundRateLimitToken refills the bucket identified by key like
+//TakeRateLimitToken, then returns a token to it
+func (c Client) RefundRateLimitToken(ctx context.Context, key string, capacity, refillPerSecond float64) error {
+	_, err := c.db.ExecContext(ctx, RefundRateLimitTokenSQL, key, capacity, refillPerSecond)
+	if err != nil {
+		return fmt.Errorf("failed refunding rate limit token: %w", err)
+	}
+
+	return nil
+}
+
+//PruneRateLimitBuckets deletes the token buckets untouched since before,
+//returning how many were deleted
+func (c Client) PruneRateLimitBuckets(ctx context.Context, before time.Time) (int, error) {
+	result, err := c.db.ExecContext(ctx, PruneRateLimitBucketsSQL, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed pruning rate limit buckets: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed counting pruned rate limit buckets: %w", err)
+	}
+
+	return int(count), nil
+}
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	//GetRetentionPoliciesSQL a prepared Postgres statement for listing
+	//retention policies
+	GetRetentionPoliciesSQL = `
+SELECT kind, max_age_days, action, updated_by, updated_moment
+  FROM retention_policies
+  ORDER BY kind`
+
+	//SetRetentionPolicySQL a prepared Postgres statement for creating or
+	//replacing the retention policy of a kind
+	SetRetentionPolicySQL = `
+INSERT INTO retention_policies
+  (kind, max_age_days, action, updated_by, updated_moment)
+  VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (kind) DO UPDATE
+  SET max_age_days = $2, action = $3, updated_by = $4, updated_moment = NOW()`
+
+	//DeleteRetentionPolicySQL a prepared Postgres statement for removing the
+	//retention policy of a kind, so that its feedback is kept indefinitely
+	DeleteRetentionPolicySQL = `
+DELETE FROM retention_policies
+  WHERE kind = $1`
+
+	//CountExpiredFeedbackSQL a prepared Postgres statement for counting
+	//feedback of a kind received before a cutoff that a retention action
+	//would still apply to
+	CountExpiredFeedbackSQL = `
+SELECT COUNT(*) FROM feedbacks
+  WHERE kind = $1
+    AND received_moment < $2
+    AND ($3 = 'delete' OR anonymized_moment IS NULL)`
+
+	//DeleteExpiredFeedbackSQL a prepared Postgres statement for deleting a
+	//batch of feedback of a kind received before a cutoff
+	DeleteExpiredFeedbackSQL = `
+DELETE FROM feedbacks
+  WHERE id IN (
+    SELECT id FROM feedbacks
+      WHERE kind = $1
+        AND received_moment < $2
+      ORDER BY received_moment
+      LIMIT $3
+      FOR UPDATE SKIP LOCKED
+  )`
+
+	//AnonymizeExpiredFeedbackSQL a prepared Postgres statement for removing
+	//identifying data from a batch of feedback of a kind received before a
+	//cutoff
+	AnonymizeExpiredFeedbackSQL = `
+UPDATE feedbacks
+  SET session_id = 'anonymized', email = NULL, email_index = NULL, email_opt_in_moment = NULL,
+      message = NULL, message_original = NULL,
+      latitude = NULL, longitude = NULL, location_accuracy = NULL, stop_distance = NULL,
+      anonymized_moment = NOW()
+  WHERE id IN (
+    SELECT id FROM feedbacks
+      WHERE kind = $1
+        AND received_moment < $2
+        AND anonymized_moment IS NULL
+      ORDER BY received_moment
+      LIMIT $3
+      FOR UPDATE SKIP LOCKED
+  )`
+)
+
+//Retention actions
+const (
+	RetentionDelete    = "delete"
+	RetentionAnonymize = "anonymize"
+)
+
+//RetentionPolicy says how long feedback of a kind is kept, and whether it
+//is deleted or anonymized afterwards
+type RetentionPolicy struct {
+	Kind       string
+	MaxAgeDays int
+	Action     string
+	UpdatedBy  string
+	UpdatedAt  time.Time
+}
+
+//GetRetentionPolicies returns every retention policy
+func (c Client) GetRetentionPolicies(ctx context.Context) ([]RetentionPolicy, error) {
+	rows, err := c.db.QueryContext(ctx, GetRetentionPoliciesSQL)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	result := []RetentionPolicy{}
+	for rows.Next() {
+		var p RetentionPolicy
+		err = rows.Scan(&p.Kind, &p.MaxAgeDays, &p.Action, &p.UpdatedBy, &p.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning retention policies: %w", err)
+		}
+		result = append(result, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading retention policies: %w", err)
+	}
+
+	return result, nil
+}
+
+//SetRetentionPolicy creates or replaces the retention policy for policy.Kind
+func (c Client) SetRetentionPolicy(ctx context.Context, policy RetentionPolicy) error {
+	_, err := c.db.ExecContext(ctx, SetRetentionPolicySQL,
+		policy.Kind, policy.MaxAgeDays, policy.Action, policy.UpdatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed setting retention policy: %w", err)
+	}
+
+	return nil
+}
+
+//DeleteRetentionPolicy removes the retention policy for kind. It returns
+//ErrNotFound if there is none.
+func (c Client) DeleteRetentionPolicy(ctx context.Context, kind string) error {
+	res, err := c.db.ExecContext(ctx, DeleteRetentionPolicySQL, kind)
+	if err != nil {
+		return fmt.Errorf("failed deleting retention policy: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed deleting retention policy: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+//CountExpiredFeedback counts feedback of kind received before `before` that
+//action has yet to be applied to
+func (c Client) CountExpiredFeedback(ctx context.Context, kind, action string, before time.Time) (int, error) {
+	rows, err := c.db.QueryContext(ctx, CountExpiredFeedbackSQL, kind, before, action)
+	if err != nil {
+		return 0, fmt.Errorf("failed counting expired feedback: %w", err)
+	}
+	defer rows.Close()
+
+	var count int
+	if rows.Next() {
+		if err = rows.Scan(&count); err != nil {
+			return 0, fmt.Errorf("failed scanning expired feedback count: %w", err)
+		}
+	}
+
+	return count, nil
+}
+
+//PurgeExpiredFeedback deletes or anonymizes, according to action, up to
+//limit rows of kind received before `before`. It returns the number of rows
+//purged.
+func (c Client) PurgeExpiredFeedback(ctx context.Context, kind, action string, before time.Time, limit int) (int, error) {
+	query := DeleteExpiredFeedbackSQL
+	if action == RetentionAnonymize {
+		query = AnonymizeExpiredFeedbackSQL
+	}
+
+	res, err := c.db.ExecContext(ctx, query, kind, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed purging expired feedback: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed purging expired feedback: %w", err)
+	}
+
+	return int(n), nil
+}
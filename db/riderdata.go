@@ -0,0 +1,296 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//riderSubjectClause matches the feedback of a rider identified by session ID
+//($1) or by email, through its blind index ($2) or, for rows written before
+//encryption was enabled, its plain text ($3). Unused parameters are NULL.
+const riderSubjectClause = `(session_id = $1 OR email_index = $2 OR (email_index IS NULL AND lower(email) = lower($3)))`
+
+//riderSessionsClause matches the survey responses of a rider: those of the
+//session ($1), and, for riders identified by email, of the sessions their
+//feedback was submitted from. Anonymized responses are never matched.
+const riderSessionsClause = `(session_id = $1 OR session_id IN (
+      SELECT session_id FROM feedbacks WHERE ` + riderSubjectClause + `))
+    AND session_id <> 'anonymized'`
+
+const (
+	//FindRiderFeedbackSQL a prepared Postgres statement for listing all
+	//feedback submitted by a rider, oldest first
+	FindRiderFeedbackSQL = `
+SELECT ` + feedbackColumns + ` FROM feedbacks
+  WHERE ` + riderSubjectClause + `
+  ORDER BY received_moment`
+
+	//FindRiderAttachmentsSQL a prepared Postgres statement for listing the
+	//attachments a rider uploaded, whether or not they were submitted with
+	//feedback, oldest first
+	FindRiderAttachmentsSQL = `
+SELECT ` + attachmentColumns + ` FROM feedback_attachments
+  WHERE discarded_moment IS NULL
+    AND (session_id = $1 OR feedback_id IN (
+      SELECT id FROM feedbacks WHERE ` + riderSubjectClause + `))
+  ORDER BY created_moment`
+
+	//FindRiderSurveyResponsesSQL a prepared Postgres statement for listing
+	//a rider's survey responses with their answers as a JSON array, oldest
+	//first
+	FindRiderSurveyResponsesSQL = `
+SELECT r.id, r.survey_name, r.version, r.session_id, r.role, r.submitted_moment,
+    COALESCE(jsonb_agg(jsonb_strip_nulls(to_jsonb(a) - 'response_id') ORDER BY a.question_id)
+      FILTER (WHERE a.response_id IS NOT NULL), '[]')
+  FROM survey_responses r
+  LEFT JOIN survey_answers a ON a.response_id = r.id
+  WHERE r.id IN (SELECT id FROM survey_responses WHERE ` + riderSessionsClause + `)
+  GROUP BY r.id
+  ORDER BY r.submitted_moment`
+
+	//FindRiderMessagesSQL a prepared Postgres statement for listing the
+	//threads of a rider's feedback, both the rider's messages and staff's,
+	//oldest first within each thread
+	FindRiderMessagesSQL = `
+SELECT ` + feedbackMessageColumns + ` FROM feedback_messages
+  WHERE feedback_id IN (SELECT id FROM feedbacks WHERE ` + riderSubjectClause + `)
+  ORDER BY feedback_id, created_moment`
+
+	//DeleteRiderFeedbackSQL a prepared Postgres statement for deleting all
+	//feedback and survey responses submitted by a rider and recording the
+	//request
+	DeleteRiderFeedbackSQL = `
+WITH surveys AS (
+  DELETE FROM survey_responses
+    WHERE ` + riderSessionsClause + `
+), affected AS (
+  DELETE FROM feedbacks
+    WHERE ` + riderSubjectClause + `
+    RETURNING id
+)
+INSERT INTO data_requests
+  (action, subject_type, subject_digest, requested_by_session, requested_by_role, source, feedback_count)
+  SELECT 'delete', $4, $5, $6, $7, $8, COUNT(*) FROM affected
+RETURNING feedback_count`
+
+	//AnonymizeRiderFeedbackSQL a prepared Postgres statement for removing
+	//identifying data from a rider's feedback and survey responses, keeping
+	//kind, value, scale and choice answers and timestamps for statistics, and
+	//recording the request
+	AnonymizeRiderFeedbackSQL = `
+WITH surveys AS (
+  UPDATE survey_responses SET session_id = 'anonymized'
+    WHERE ` + riderSessionsClause + `
+    RETURNING id
+), survey_texts AS (
+  DELETE FROM survey_answers
+    WHERE response_id IN (SELECT id FROM surveys)
+      AND text_value IS NOT NULL
+), affected AS (
+  UPDATE feedbacks
+    SET session_id = 'anonymized', email = NULL, email_index = NULL, email_opt_in_moment = NULL,
+        message = NULL, message_original = NULL,
+        latitude = NULL, longitude = NULL, location_accuracy = NULL, stop_distance = NULL,
+        anonymized_moment = NOW()
+    WHERE ` + riderSubjectClause + `
+    RETURNING id
+)
+INSERT INTO data_requests
+  (action, subject_type, subject_digest, requested_by_session, requested_by_role, source, feedback_count)
+  SELECT 'anonymize', $4, $5, $6, $7, $8, COUNT(*) FROM affected
+RETURNING feedback_count`
+
+	//RecordDataRequestSQL a prepared Postgres statement for recording a
+	//rider data request that doesn't modify feedback
+	RecordDataRequestSQL = `
+INSERT INTO data_requests
+  (action, subject_type, subject_digest, requested_by_session, requested_by_role, source, feedback_count)
+  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+)
+
+//Rider data request actions
+const (
+	DataRequestExport    = "export"
+	DataRequestDelete    = "delete"
+	DataRequestAnonymize = "anonymize"
+)
+
+//RiderSubject identifies a rider by exactly one of session ID or email
+type RiderSubject struct {
+	SessionID string
+	Email     string
+}
+
+//Type returns `session` or `email`
+func (s RiderSubject) Type() string {
+	if s.SessionID != "" {
+		return "session"
+	}
+	return "email"
+}
+
+//Digest returns a SHA-256 digest of the identifier, so that requests about
+//the same rider can be correlated without retaining the identifier itself
+func (s RiderSubject) Digest() string {
+	sum := sha256.Sum256([]byte(s.Type() + ":" + strings.ToLower(strings.TrimSpace(s.SessionID+s.Email))))
+	return hex.EncodeToString(sum[:])
+}
+
+//DataRequest records who asked for a rider's data to be exported, deleted
+//or anonymized. Source is `api` or `cli`.
+type DataRequest struct {
+	Action          string
+	Subject         RiderSubject
+	RequestedBy     string
+	RequestedByRole string
+	Source          string
+	FeedbackCount   int
+}
+
+func (c Client) riderSubjectArgs(s RiderSubject) []interface{} {
+	var session, index, email interface{}
+	if s.SessionID != "" {
+		session = s.SessionID
+	} else {
+		email = s.Email
+		if c.cipher != nil {
+			index = c.cipher.BlindIndex(s.Email)
+		}
+	}
+	return []interface{}{session, index, email}
+}
+
+//FindRiderFeedback returns all feedback submitted by subject
+func (c Client) FindRiderFeedback(ctx context.Context, subject RiderSubject) ([]Feedback, error) {
+	rows, err := c.db.QueryContext(ctx, FindRiderFeedbackSQL, c.riderSubjectArgs(subject)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed finding rider feedback: %w", err)
+	}
+
+	return c.scanFeedbacks(rows)
+}
+
+//FindRiderAttachments returns the attachments uploaded by subject that
+//haven't been discarded
+func (c Client) FindRiderAttachments(ctx context.Context, subject RiderSubject) ([]Attachment, error) {
+	rows, err := c.db.QueryContext(ctx, FindRiderAttachmentsSQL, c.riderSubjectArgs(subject)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed finding rider attachments: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAttachments(rows)
+}
+
+//FindRiderMessages returns the messages in the threads of subject's feedback
+func (c Client) FindRiderMessages(ctx context.Context, subject RiderSubject) ([]FeedbackMessage, error) {
+	rows, err := c.db.QueryContext(ctx, FindRiderMessagesSQL, c.riderSubjectArgs(subject)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed finding rider messages: %w", err)
+	}
+	defer rows.Close()
+
+	result := []FeedbackMessage{}
+	for rows.Next() {
+		var m FeedbackMessage
+		err = rows.Scan(
+			&m.ID, &m.FeedbackID, &m.SessionID, &m.Author, &m.AuthorSession, &m.AuthorRole,
+			&m.Body, &m.CreatedAt, &m.ReadAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning rider messages: %w", err)
+		}
+		result = append(result, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading rider messages: %w", err)
+	}
+
+	return result, nil
+}
+
+//FindRiderSurveyResponses returns the survey responses of subject
+func (c Client) FindRiderSurveyResponses(ctx context.Context, subject RiderSubject) ([]SurveyResponse, error) {
+	rows, err := c.db.QueryContext(ctx, FindRiderSurveyResponsesSQL, c.riderSubjectArgs(subject)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed finding rider survey responses: %w", err)
+	}
+	defer rows.Close()
+
+	result := []SurveyResponse{}
+	for rows.Next() {
+		var r SurveyResponse
+		var answers []byte
+		err = rows.Scan(&r.ID, &r.SurveyName, &r.Version, &r.SessionID, &r.Role, &r.SubmittedAt, &answers)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning rider survey responses: %w", err)
+		}
+		if err = json.Unmarshal(answers, &r.Answers); err != nil {
+			return nil, fmt.Errorf("failed decoding survey answers: %w", err)
+		}
+
+		result = append(result, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading rider survey responses: %w", err)
+	}
+
+	return result, nil
+}
+
+//DeleteRiderFeedback deletes all feedback and survey responses submitted by
+//request.Subject and records the request. It returns the number of feedback
+//deleted.
+func (c Client) DeleteRiderFeedback(ctx context.Context, request DataRequest) (int, error) {
+	return c.applyDataRequest(ctx, DeleteRiderFeedbackSQL, request)
+}
+
+//AnonymizeRiderFeedback removes identifying data from all feedback and
+//survey responses submitted by request.Subject and records the request. Free
+//text survey answers are deleted. It returns the number of feedback
+//anonymized.
+func (c Client) AnonymizeRiderFeedback(ctx context.Context, request DataRequest) (int, error) {
+	return c.applyDataRequest(ctx, AnonymizeRiderFeedbackSQL, request)
+}
+
+func (c Client) applyDataRequest(ctx context.Context, query string, request DataRequest) (int, error) {
+	args := append(c.riderSubjectArgs(request.Subject),
+		request.Subject.Type(), request.Subject.Digest(),
+		request.RequestedBy, request.RequestedByRole, request.Source,
+	)
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed applying %s request: %w", request.Action, err)
+	}
+	defer rows.Close()
+
+	var count int
+	if rows.Next() {
+		if err = rows.Scan(&count); err != nil {
+			return 0, fmt.Errorf("failed scanning %s request result: %w", request.Action, err)
+		}
+	}
+
+	return count, nil
+}
+
+//RecordDataRequest records a request that doesn't modify feedback, such as
+//an export
+func (c Client) RecordDataRequest(ctx context.Context, request DataRequest) error {
+	_, err := c.db.ExecContext(ctx, RecordDataRequestSQL,
+		request.Action, request.Subject.Type(), request.Subject.Digest(),
+		request.RequestedBy, request.RequestedByRole, request.Source, request.FeedbackCount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed recording %s request: %w", request.Action, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,267 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	//SearchFeedbackSQL a prepared Postgres statement for searching the
+	//messages of feedback matching a FeedbackFilter, best matches first, with
+	//a highlighted snippet of each. $14 is a query made by SearchQuery and
+	//$15 the ts_headline options.
+	SearchFeedbackSQL = `
+SELECT ` + feedbackColumns + `,
+    ts_rank_cd(message_tsv, query) AS search_rank,
+    ts_headline('english', coalesce(message, ''), query, $15) AS search_snippet
+  FROM feedbacks, to_tsquery('english', $14) AS query
+  WHERE message_tsv @@ query
+    AND ` + feedbackFilterConditions + `
+  ORDER BY search_rank DESC, received_moment DESC
+  LIMIT $7 OFFSET $8`
+
+	//GetFeedbackMessageSQL a prepared Postgres statement for getting the
+	//redacted message of a feedback
+	GetFeedbackMessageSQL = `
+SELECT message FROM feedbacks
+  WHERE id = $1`
+
+	//SimilarFeedbackSQL a prepared Postgres statement for listing feedback
+	//whose messages are similar to a text by trigrams, most similar first.
+	//Similarity is bounded below by pg_trgm.similarity_threshold.
+	SimilarFeedbackSQL = `
+SELECT ` + feedbackColumns + `,
+    similarity(message, $2) AS search_similarity
+  FROM feedbacks
+  WHERE message % $2
+    AND id <> $1
+  ORDER BY search_similarity DESC, received_moment DESC
+  LIMIT $3 OFFSET $4`
+)
+
+//HighlightStart and HighlightStop surround the matching words in search
+//snippets. They are control characters so that they can't be confused with
+//the message's own text.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+//headlineOptions are the ts_headline options of search snippets
+var headlineOptions = fmt.Sprintf(
+	`StartSel="%s", StopSel="%s", MinWords=15, MaxWords=35, MaxFragments=2, FragmentDelimiter=" ... "`,
+	HighlightStart, HighlightStop,
+)
+
+//ErrEmptySearch is returned by SearchQuery for searches without any words
+//to look for
+var ErrEmptySearch = errors.New("search has no words to look for")
+
+//SearchResult is a feedback matching a search. Snippet is the best
+//matching part of its message, with matches between HighlightStart and
+//HighlightStop.
+type SearchResult struct {
+	Feedback Feedback
+	Rank     float64
+	Snippet  string
+}
+
+//SimilarFeedback is a feedback whose message is similar to another's.
+//Similarity is between 0 and 1.
+type SimilarFeedback struct {
+	Feedback   Feedback
+	Similarity float64
+}
+
+//SearchQuery turns a search as staff would type it into a Postgres text
+//search query. Words must all match, in any form with the same stem.
+//Quoted phrases match words in order, a trailing `*` matches any word
+//starting with the ones before it, a leading `-` excludes matches, and `OR`
+//between two terms matches either. `OR` binds tighter than the implicit
+//AND between terms, so `wi-fi OR wifi broken` matches either spelling along
+//with `broken`. Punctuation is ignored.
+func SearchQuery(search string) (string, error) {
+	//groups are ANDed together, and the clauses within each ORed
+	var groups [][]string
+	positive := false
+	or := false
+	rest := search
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+
+		negate := strings.HasPrefix(rest, "-")
+		if negate {
+			rest = rest[1:]
+		}
+
+		var term string
+		quoted := strings.HasPrefix(rest, `"`)
+		if quoted {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				term, rest = rest[1:], ""
+			} else {
+				term, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			term, rest = rest[:end], rest[end:]
+		}
+
+		if term == "OR" && !quoted && !negate {
+			or = len(groups) > 0
+			continue
+		}
+
+		clause := phraseQuery(term)
+		if clause == "" {
+			continue
+		}
+		if negate {
+			clause = "!" + clause
+		} else {
+			positive = true
+		}
+
+		if or {
+			groups[len(groups)-1] = append(groups[len(groups)-1], clause)
+		} else {
+			groups = append(groups, []string{clause})
+		}
+		or = false
+	}
+
+	if !positive {
+		return "", ErrEmptySearch
+	}
+
+	terms := make([]string, len(groups))
+	for i, group := range groups {
+		terms[i] = group[0]
+		if len(group) > 1 {
+			terms[i] = "(" + strings.Join(group, " | ") + ")"
+		}
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+//phraseQuery returns a query matching the words of term in order, or "" if
+//it has none. Only letters and digits are kept, so the words can be quoted
+//safely.
+func phraseQuery(term string) string {
+	prefix := strings.HasSuffix(strings.TrimSpace(term), "*")
+	words := strings.FieldsFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	for i, word := range words {
+		words[i] = "'" + word + "'"
+	}
+	if prefix {
+		words[len(words)-1] += ":*"
+	}
+
+	if len(words) == 1 {
+		return words[0]
+	}
+	return "(" + strings.Join(words, " <-> ") + ")"
+}
+
+//SearchFeedback returns a page of feedback matching filter whose messages
+//match query, a query made by SearchQuery, best matches first
+func (c Client) SearchFeedback(ctx context.Context, query string, filter FeedbackFilter, limit, offset int) ([]SearchResult, error) {
+	params := append(filterParams(filter, limit, offset), query, headlineOptions)
+	rows, err := c.db.QueryContext(ctx, SearchFeedbackSQL, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed searching feedback: %w", err)
+	}
+	defer rows.Close()
+
+	result := []SearchResult{}
+	for rows.Next() {
+		var r SearchResult
+		if r.Feedback, err = c.scanFeedback(rows, &r.Rank, &r.Snippet); err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading search results: %w", err)
+	}
+
+	return result, nil
+}
+
+//SimilarFeedback returns a page of feedback whose messages are similar to
+//that of the feedback with id, most similar first. It returns ErrNotFound if
+//the feedback doesn't exist, and nothing if it has no message.
+func (c Client) SimilarFeedback(ctx context.Context, id string, limit, offset int) ([]SimilarFeedback, error) {
+	message, err := c.getFeedbackMessage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []SimilarFeedback{}
+	if message == nil || strings.TrimSpace(*message) == "" {
+		return result, nil
+	}
+
+	rows, err := c.db.QueryContext(ctx, SimilarFeedbackSQL, id, *message, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed finding similar feedback: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s SimilarFeedback
+		if s.Feedback, err = c.scanFeedback(rows, &s.Similarity); err != nil {
+			return nil, err
+		}
+
+		result = append(result, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading similar feedback: %w", err)
+	}
+
+	return result, nil
+}
+
+//getFeedbackMessage returns the redacted message of a feedback, or
+//ErrNotFound if it doesn't exist
+func (c Client) getFeedbackMessage(ctx context.Context, id string) (*string, error) {
+	rows, err := c.db.QueryContext(ctx, GetFeedbackMessageSQL, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting feedback message: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed getting feedback message: %w", err)
+		}
+		return nil, ErrNotFound
+	}
+
+	var message *string
+	if err = rows.Scan(&message); err != nil {
+		return nil, fmt.Errorf("failed scanning feedback message: %w", err)
+	}
+
+	return message, nil
+}
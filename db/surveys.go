@@ -0,0 +1,248 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	//CreateSurveyVersionSQL a prepared Postgres statement for adding the
+	//next version of a survey, creating the survey if it doesn't exist
+	CreateSurveyVersionSQL = `
+WITH survey AS (
+  INSERT INTO surveys (name) VALUES ($1)
+  ON CONFLICT (name) DO NOTHING
+), next AS (
+  SELECT COALESCE(MAX(version), 0) + 1 AS version FROM survey_versions
+    WHERE survey_name = $1
+)
+INSERT INTO survey_versions (survey_name, version, title, questions, created_by_session, created_by_role)
+  SELECT $1, next.version, $2, $3, $4, $5 FROM next
+  RETURNING version`
+
+	//SetActiveSurveyVersionSQL a prepared Postgres statement for choosing
+	//which version of a survey accepts responses, if any
+	SetActiveSurveyVersionSQL = `
+UPDATE surveys SET active_version = $2
+  WHERE name = $1
+    AND ($2::integer IS NULL OR EXISTS (
+      SELECT 1 FROM survey_versions WHERE survey_name = $1 AND version = $2
+    ))`
+
+	//GetSurveyVersionSQL a prepared Postgres statement for getting a version
+	//of a survey, or its active version when none is given
+	GetSurveyVersionSQL = `
+SELECT v.survey_name, v.version, COALESCE(v.version = s.active_version, FALSE), v.title, v.questions, v.created_moment
+  FROM survey_versions v
+  JOIN surveys s ON s.name = v.survey_name
+  WHERE v.survey_name = $1
+    AND v.version = COALESCE($2::integer, s.active_version)`
+
+	//SaveSurveyResponseSQL a prepared Postgres statement for saving a
+	//response and its answers, which are passed as a JSON array. It returns
+	//nothing if the session already responded to the version.
+	SaveSurveyResponseSQL = `
+WITH response AS (
+  INSERT INTO survey_responses (survey_name, version, session_id, role)
+    VALUES ($1, $2, $3, $4)
+    ON CONFLICT (survey_name, version, session_id) WHERE session_id <> 'anonymized' DO NOTHING
+    RETURNING id
+), answers AS (
+  INSERT INTO survey_answers (response_id, question_id, number_value, choices, text_value)
+    SELECT response.id, a.question_id, a.number_value, a.choices, a.text_value
+      FROM response, jsonb_to_recordset($5::jsonb)
+        AS a(question_id varchar, number_value integer, choices varchar[], text_value varchar)
+)
+SELECT id FROM response`
+
+	//CountSurveyAnswersSQL a prepared Postgres statement for counting the
+	//responses to a survey version choosing each answer to each question.
+	//Rows for a whole question have no answer and total set, and the row
+	//for the whole survey also has no question.
+	CountSurveyAnswersSQL = `
+SELECT a.question_id, v.answer, GROUPING(v.answer) = 1, COUNT(DISTINCT a.response_id)
+  FROM survey_answers a
+  JOIN survey_responses r ON r.id = a.response_id
+  CROSS JOIN LATERAL unnest(CASE
+    WHEN a.number_value IS NOT NULL THEN ARRAY[a.number_value::varchar]
+    WHEN a.choices IS NOT NULL THEN a.choices
+    ELSE ARRAY[NULL::varchar]
+  END) AS v(answer)
+  WHERE r.survey_name = $1
+    AND r.version = $2
+  GROUP BY GROUPING SETS ((a.question_id, v.answer), (a.question_id), ())`
+)
+
+//SurveyVersion is a version of a survey. Questions holds the JSON array of
+//questions, and is never changed once the version is created.
+type SurveyVersion struct {
+	SurveyName string
+	Version    int
+	Active     bool
+	Title      string
+	Questions  []byte
+	CreatedAt  time.Time
+
+	CreatedBySession string
+	CreatedByRole    string
+}
+
+//SurveyResponse is a rider's answers to a version of a survey. ID and
+//SubmittedAt are set when it is read back.
+type SurveyResponse struct {
+	ID          string
+	SurveyName  string
+	Version     int
+	SessionID   string
+	Role        string
+	Answers     []SurveyAnswer
+	SubmittedAt time.Time
+}
+
+//SurveyAnswer is the answer to one question. Exactly one of Number,
+//Choices and Text is set, depending on the type of question.
+type SurveyAnswer struct {
+	QuestionID string   `json:"question_id"`
+	Number     *int     `json:"number_value,omitempty"`
+	Choices    []string `json:"choices,omitempty"`
+	Text       *string  `json:"text_value,omitempty"`
+}
+
+//SurveyAnswerCount is how many responses to a survey version gave Answer to
+//QuestionID. When Total is set, it is how many answered the question at all,
+//or, when QuestionID is also nil, how many responses there are. Answer is
+//nil for free text questions.
+type SurveyAnswerCount struct {
+	QuestionID *string
+	Answer     *string
+	Total      bool
+	Count      int
+}
+
+//CreateSurveyVersion adds the next version of version.SurveyName, creating
+//the survey if needed, and returns its number. The new version isn't
+//active until SetActiveSurveyVersion is called.
+func (c Client) CreateSurveyVersion(ctx context.Context, version SurveyVersion) (int, error) {
+	rows, err := c.db.QueryContext(ctx, CreateSurveyVersionSQL,
+		version.SurveyName, version.Title, jsonParam(version.Questions),
+		version.CreatedBySession, version.CreatedByRole,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed creating survey version: %w", err)
+	}
+	defer rows.Close()
+
+	var number int
+	if rows.Next() {
+		if err = rows.Scan(&number); err != nil {
+			return 0, fmt.Errorf("failed scanning survey version: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed creating survey version: %w", err)
+	}
+
+	return number, nil
+}
+
+//SetActiveSurveyVersion makes version the one accepting responses to a
+//survey. A nil version closes the survey. It returns ErrNotFound if the
+//survey or version doesn't exist.
+func (c Client) SetActiveSurveyVersion(ctx context.Context, name string, version *int) error {
+	res, err := c.db.ExecContext(ctx, SetActiveSurveyVersionSQL, name, version)
+	if err != nil {
+		return fmt.Errorf("failed setting active survey version: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed setting active survey version: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+//GetSurveyVersion returns a version of a survey, or the active version if
+//version is nil. It returns ErrNotFound if there is no such version.
+func (c Client) GetSurveyVersion(ctx context.Context, name string, version *int) (SurveyVersion, error) {
+	rows, err := c.db.QueryContext(ctx, GetSurveyVersionSQL, name, version)
+	if err != nil {
+		return SurveyVersion{}, fmt.Errorf("failed getting survey version: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return SurveyVersion{}, fmt.Errorf("failed getting survey version: %w", err)
+		}
+		return SurveyVersion{}, ErrNotFound
+	}
+
+	var v SurveyVersion
+	err = rows.Scan(&v.SurveyName, &v.Version, &v.Active, &v.Title, &v.Questions, &v.CreatedAt)
+	if err != nil {
+		return SurveyVersion{}, fmt.Errorf("failed scanning survey version: %w", err)
+	}
+
+	return v, nil
+}
+
+//SaveSurveyResponse saves a response along with its answers. It returns
+//ErrAlreadyExists if the session already responded to the version.
+func (c Client) SaveSurveyResponse(ctx context.Context, response SurveyResponse) error {
+	answers := response.Answers
+	if answers == nil {
+		answers = []SurveyAnswer{}
+	}
+	b, err := json.Marshal(answers)
+	if err != nil {
+		return fmt.Errorf("failed encoding survey answers: %w", err)
+	}
+
+	rows, err := c.db.QueryContext(ctx, SaveSurveyResponseSQL,
+		response.SurveyName, response.Version, response.SessionID, response.Role, string(b),
+	)
+	if err != nil {
+		return fmt.Errorf("failed saving survey response: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed saving survey response: %w", err)
+		}
+		return ErrAlreadyExists
+	}
+
+	return nil
+}
+
+//CountSurveyAnswers counts the responses to a survey version giving each
+//answer to each question
+func (c Client) CountSurveyAnswers(ctx context.Context, name string, version int) ([]SurveyAnswerCount, error) {
+	rows, err := c.db.QueryContext(ctx, CountSurveyAnswersSQL, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed counting survey answers: %w", err)
+	}
+	defer rows.Close()
+
+	result := []SurveyAnswerCount{}
+	for rows.Next() {
+		var count SurveyAnswerCount
+		if err = rows.Scan(&count.QuestionID, &count.Answer, &count.Total, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed scanning survey answer counts: %w", err)
+		}
+		result = append(result, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading survey answer counts: %w", err)
+	}
+
+	return result, nil
+}
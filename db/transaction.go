@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+//Tx is a database transaction
+//go:generate counterfeiter . Tx
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+type txKey struct{}
+
+//NewDriver returns a DBDriver for conn. Statements executed with a context
+//returned by its BeginTx run in that transaction.
+func NewDriver(conn *sql.DB) DBDriver {
+	return sqlDriver{conn: conn}
+}
+
+type sqlDriver struct {
+	conn *sql.DB
+}
+
+func (d sqlDriver) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx.ExecContext(ctx, query, args...)
+	}
+	return d.conn.ExecContext(ctx, query, args...)
+}
+
+func (d sqlDriver) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx.QueryContext(ctx, query, args...)
+	}
+	return d.conn.QueryContext(ctx, query, args...)
+}
+
+func (d sqlDriver) BeginTx(ctx context.Context) (context.Context, Tx, error) {
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return context.WithValue(ctx, txKey{}, tx), tx, nil
+}
+
+type inTransactionKey struct{}
+
+//InTransaction calls fn with a context whose statements run in a single
+//transaction, which is committed if fn succeeds and rolled back otherwise.
+//Calls nested in fn join the outer transaction.
+func (c Client) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(inTransactionKey{}) != nil {
+		return fn(ctx)
+	}
+
+	txCtx, tx, err := c.db.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed starting transaction: %w", err)
+	}
+
+	if err := fn(context.WithValue(txCtx, inTransactionKey{}, true)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed committing transaction: %w", err)
+	}
+	return nil
+}
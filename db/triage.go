@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	//UpdateTriageSQL a prepared Postgres statement for changing the triage
+	//of a set of feedback and recording each field changed, returning the
+	//triage of each feedback before and after. NULL parameters leave their
+	//fields unchanged, and an empty assignee clears it. If any feedback would
+	//end up with more than $11 tags, nothing is changed, and the rows
+	//returned are flagged and carry the previous triage as the after.
+	UpdateTriageSQL = `
+WITH previous AS (
+  SELECT id, triage_status, assignee, priority, tags, triaged_moment,
+      ARRAY(
+        SELECT DISTINCT t FROM unnest(COALESCE($5::text[], tags) || $6::text[]) AS t
+          WHERE NOT t = ANY($7::text[])
+          ORDER BY t) AS new_tags
+    FROM feedbacks
+    WHERE id = ANY($1::uuid[])
+    FOR UPDATE
+), over_limit AS (
+  SELECT id FROM previous WHERE cardinality(new_tags) > $11
+), updated AS (
+  UPDATE feedbacks f SET
+      triage_status = COALESCE($2::varchar, f.triage_status),
+      assignee = CASE WHEN $3::varchar IS NULL THEN f.assignee ELSE NULLIF($3, '') END,
+      priority = COALESCE($4::varchar, f.priority),
+      tags = previous.new_tags,
+      triaged_moment = NOW()
+    FROM previous
+    WHERE f.id = previous.id
+      AND NOT EXISTS (SELECT 1 FROM over_limit)
+    RETURNING f.id, f.triage_status, f.assignee, f.priority, f.tags, f.triaged_moment
+), transitions AS (
+  INSERT INTO triage_transitions
+    (feedback_id, field, old_value, new_value, note, actor_session, actor_role)
+    SELECT u.id, c.field, c.old_value, c.new_value, $8, $9, $10
+      FROM updated u
+      JOIN previous p ON p.id = u.id
+      CROSS JOIN LATERAL (VALUES
+        ('status', to_jsonb(p.triage_status), to_jsonb(u.triage_status)),
+        ('assignee', to_jsonb(p.assignee), to_jsonb(u.assignee)),
+        ('priority', to_jsonb(p.priority), to_jsonb(u.priority)),
+        ('tags', to_jsonb(p.tags), to_jsonb(u.tags))
+      ) AS c (field, old_value, new_value)
+      WHERE c.old_value IS DISTINCT FROM c.new_value
+)
+SELECT p.id, cardinality(p.new_tags) > $11,
+    p.triage_status, p.assignee, p.priority, p.tags, p.triaged_moment,
+    COALESCE(u.triage_status, p.triage_status), u.assignee, COALESCE(u.priority, p.priority),
+    COALESCE(u.tags, p.tags), u.triaged_moment
+  FROM previous p
+  LEFT JOIN updated u ON u.id = p.id
+  ORDER BY p.id`
+
+	//GetTriageTransitionsSQL a prepared Postgres statement for getting the
+	//triage history of a feedback
+	GetTriageTransitionsSQL = `
+SELECT id, feedback_id, field, old_value, new_value, note, actor_session, actor_role, changed_moment
+  FROM triage_transitions
+  WHERE feedback_id = $1
+  ORDER BY changed_moment, field`
+)
+
+//Triage statuses
+const (
+	TriageNew        = "new"
+	TriageInProgress = "in_progress"
+	TriageResolved   = "resolved"
+	TriageWontFix    = "wont_fix"
+)
+
+//Triage priorities
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+//Triage is where staff are with a feedback. Status is one of the Triage*
+//constants and Priority one of the Priority* constants. TriagedAt is when it
+//was last changed, and is nil for untouched feedback.
+type Triage struct {
+	Status    string
+	Assignee  *string
+	Priority  string
+	Tags      []string
+	TriagedAt *time.Time
+}
+
+//TriageUpdate changes the triage of a set of feedback. Nil fields are left
+//unchanged. An empty Assignee unassigns the feedback. Tags replaces the
+//tags, then AddTags are added and RemoveTags removed. No feedback may end up
+//with more than MaxTags tags.
+type TriageUpdate struct {
+	FeedbackIDs []string
+
+	Status     *string
+	Assignee   *string
+	Priority   *string
+	Tags       []string
+	AddTags    []string
+	RemoveTags []string
+	MaxTags    int
+
+	Note         *string
+	ActorSession string
+	ActorRole    string
+}
+
+//TriageChange is the triage of a feedback before and after an update
+type TriageChange struct {
+	FeedbackID string
+	Before     Triage
+	After      Triage
+}
+
+//TriageTransition records a change to one triage field of a feedback. The
+//values are JSON, and are nil when the field was unset.
+type TriageTransition struct {
+	ID           string
+	FeedbackID   string
+	Field        string
+	OldValue     json.RawMessage
+	NewValue     json.RawMessage
+	Note         *string
+	ActorSession string
+	ActorRole    string
+	ChangedAt    time.Time
+}
+
+//ErrTooManyTags is returned when a triage update would leave a feedback with
+//more than the maximum number of tags
+var ErrTooManyTags = errors.New("too many tags")
+
+//UpdateTriage applies update to each of its feedback and records every field
+//changed, returning the feedback that exist, ordered by ID. If any feedback
+//would end up with more than update.MaxTags tags, nothing is changed and it
+//returns ErrTooManyTags.
+func (c Client) UpdateTriage(ctx context.Context, update TriageUpdate) ([]TriageChange, error) {
+	var tags interface{}
+	if update.Tags != nil {
+		tags = pq.Array(update.Tags)
+	}
+
+	rows, err := c.db.QueryContext(ctx, UpdateTriageSQL,
+		pq.Array(update.FeedbackIDs),
+		update.Status, update.Assignee, update.Priority,
+		tags, pq.Array(nonNilStrings(update.AddTags)), pq.Array(nonNilStrings(update.RemoveTags)),
+		update.Note, update.ActorSession, update.ActorRole, update.MaxTags,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed updating triage: %w", err)
+	}
+	defer rows.Close()
+
+	result := []TriageChange{}
+	tooManyTags := false
+	for rows.Next() {
+		var (
+			ch   TriageChange
+			over bool
+		)
+		err = rows.Scan(
+			&ch.FeedbackID,
+			&over,
+			&ch.Before.Status,
+			&ch.Before.Assignee,
+			&ch.Before.Priority,
+			pq.Array(&ch.Before.Tags),
+			&ch.Before.TriagedAt,
+			&ch.After.Status,
+			&ch.After.Assignee,
+			&ch.After.Priority,
+			pq.Array(&ch.After.Tags),
+			&ch.After.TriagedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning triage changes: %w", err)
+		}
+
+		tooManyTags = tooManyTags || over
+		result = append(result, ch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading triage changes: %w", err)
+	}
+	if tooManyTags {
+		return nil, ErrTooManyTags
+	}
+
+	return result, nil
+}
+
+//GetTriageTransitions returns every change made to the triage of a
+//feedback, oldest first
+func (c Client) GetTriageTransitions(ctx context.Context, feedbackID string) ([]TriageTransition, error) {
+	rows, err := c.db.QueryContext(ctx, GetTriageTransitionsSQL, feedbackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting triage transitions: %w", err)
+	}
+	defer rows.Close()
+
+	result := []TriageTransition{}
+	for rows.Next() {
+		var t TriageTransition
+		var oldValue, newValue []byte
+		err = rows.Scan(
+			&t.ID,
+			&t.FeedbackID,
+			&t.Field,
+			&oldValue,
+			&newValue,
+			&t.Note,
+			&t.ActorSession,
+			&t.ActorRole,
+			&t.ChangedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning triage transitions: %w", err)
+		}
+		t.OldValue, t.NewValue = oldValue, newValue
+
+		result = append(result, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading triage transitions: %w", err)
+	}
+
+	return result, nil
+}
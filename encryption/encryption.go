@@ -0,0 +1,235 @@
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+//KeySize is the length in bytes of key-encryption keys and the blind index
+//key. Keys are used for AES-256-GCM.
+const KeySize = 32
+
+var keyIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+//Keyring encrypts values using envelope encryption. Each value is sealed
+//with its own random data key, and that data key is in turn sealed with the
+//current key-encryption key. Ciphertexts take the form
+//
+//  <key ID>:<base64 sealed data key>:<base64 sealed value>
+//
+//so that any key in the ring can decrypt values written under it.
+type Keyring struct {
+	keys     map[string][]byte
+	current  string
+	indexKey []byte
+	rand     io.Reader
+}
+
+//New returns a Keyring encrypting with the key named current. indexKey
+//keys the blind index and must not change when keys are rotated.
+func New(keys map[string][]byte, current string, indexKey []byte) (Keyring, error) {
+	if _, ok := keys[current]; !ok {
+		return Keyring{}, fmt.Errorf("current key `%s` is not in the keyring", current)
+	}
+	for id, key := range keys {
+		if len(key) != KeySize {
+			return Keyring{}, fmt.Errorf("key `%s` is %d bytes, expected %d", id, len(key), KeySize)
+		}
+	}
+	if len(indexKey) != KeySize {
+		return Keyring{}, fmt.Errorf("blind index key is %d bytes, expected %d", len(indexKey), KeySize)
+	}
+
+	return Keyring{
+		keys:     keys,
+		current:  current,
+		indexKey: indexKey,
+		rand:     rand.Reader,
+	}, nil
+}
+
+//CurrentKeyID returns the ID of the key new values are encrypted under
+func (k Keyring) CurrentKeyID() string {
+	return k.current
+}
+
+//Encrypt seals plaintext under the current key
+func (k Keyring) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(k.rand, dataKey); err != nil {
+		return "", fmt.Errorf("failed generating data key: %w", err)
+	}
+
+	wrapped, err := k.seal(k.keys[k.current], dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := k.seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return k.current + ":" + base64.StdEncoding.EncodeToString(wrapped) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+//Decrypt opens a value produced by Encrypt. Values that aren't encrypted,
+//such as those written before encryption was enabled, are returned unchanged.
+func (k Keyring) Decrypt(ciphertext string) (string, error) {
+	if !IsEncrypted(ciphertext) {
+		return ciphertext, nil
+	}
+
+	parts := strings.Split(ciphertext, ":")
+	key, ok := k.keys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown encryption key `%s`", parts[0])
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("failed decoding data key: %w", err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("failed decoding ciphertext: %w", err)
+	}
+
+	dataKey, err := open(key, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed unwrapping data key: %w", err)
+	}
+	plaintext, err := open(dataKey, sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed decrypting value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+//BlindIndex returns a keyed hash of plaintext that allows equality lookups
+//without decrypting. Case and surrounding whitespace are ignored.
+func (k Keyring) BlindIndex(plaintext string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(plaintext))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+//IsEncrypted reports whether s looks like a value produced by Encrypt. Email
+//addresses never do, since base64 and key IDs can't contain an `@`.
+func IsEncrypted(s string) bool {
+	parts := strings.Split(s, ":")
+	return len(parts) == 3 && keyIDRegexp.MatchString(parts[0]) && !strings.Contains(s, "@")
+}
+
+//KeyID returns the ID of the key that ciphertext was encrypted under
+func KeyID(ciphertext string) (string, bool) {
+	if !IsEncrypted(ciphertext) {
+		return "", false
+	}
+	return strings.Split(ciphertext, ":")[0], true
+}
+
+func (k Keyring) seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(k.rand, nonce); err != nil {
+		return nil, fmt.Errorf("failed generating nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, sealed := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+//ParseKeys reads a comma-separated list of `id:base64key` pairs
+func ParseKeys(s string) (map[string][]byte, error) {
+	keys := map[string][]byte{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if err := parseKey(keys, entry); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+//ReadKeyFile reads `id:base64key` pairs, one per line. Blank lines and lines
+//starting with `#` are ignored.
+func ReadKeyFile(r io.Reader) (map[string][]byte, error) {
+	keys := map[string][]byte{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := parseKey(keys, line); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading key file: %w", err)
+	}
+	return keys, nil
+}
+
+func parseKey(keys map[string][]byte, entry string) error {
+	i := strings.Index(entry, ":")
+	if i < 0 {
+		return fmt.Errorf("malformed key entry: expected `id:base64key`")
+	}
+
+	id := entry[:i]
+	if !keyIDRegexp.MatchString(id) {
+		return fmt.Errorf("invalid key ID `%s`", id)
+	}
+	if _, ok := keys[id]; ok {
+		return fmt.Errorf("duplicate key ID `%s`", id)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(entry[i+1:])
+	if err != nil {
+		return fmt.Errorf("failed decoding key `%s`: %w", id, err)
+	}
+
+	keys[id] = key
+	return nil
+}
@@ -0,0 +1,163 @@
+package encryption_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/encryption"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encryption", func() {
+	var (
+		oldKey   = bytes.Repeat([]byte{1}, encryption.KeySize)
+		newKey   = bytes.Repeat([]byte{2}, encryption.KeySize)
+		indexKey = bytes.Repeat([]byte{3}, encryption.KeySize)
+	)
+
+	keyring := func(current string) encryption.Keyring {
+		k, err := encryption.New(map[string][]byte{"k1": oldKey, "k2": newKey}, current, indexKey)
+		Expect(err).To(BeNil())
+		return k
+	}
+
+	Describe("New", func() {
+		It("rejects an unknown current key", func() {
+			_, err := encryption.New(map[string][]byte{"k1": oldKey}, "k2", indexKey)
+			Expect(err).To(MatchError("current key `k2` is not in the keyring"))
+		})
+		It("rejects keys of the wrong size", func() {
+			_, err := encryption.New(map[string][]byte{"k1": oldKey[:16]}, "k1", indexKey)
+			Expect(err).To(MatchError("key `k1` is 16 bytes, expected 32"))
+		})
+	})
+
+	Describe("Keyring", func() {
+		It("round-trips values under a key ID prefix", func() {
+			k := keyring("k1")
+			ciphertext, err := k.Encrypt("rider@example.com")
+			Expect(err).To(BeNil())
+			Expect(ciphertext).To(HavePrefix("k1:"))
+			Expect(ciphertext).NotTo(ContainSubstring("rider"))
+
+			id, ok := encryption.KeyID(ciphertext)
+			Expect(ok).To(BeTrue())
+			Expect(id).To(Equal("k1"))
+
+			plaintext, err := keyring("k2").Decrypt(ciphertext)
+			Expect(err).To(BeNil())
+			Expect(plaintext).To(Equal("rider@example.com"))
+		})
+		It("uses a fresh data key for each value", func() {
+			k := keyring("k1")
+			a, _ := k.Encrypt("rider@example.com")
+			b, _ := k.Encrypt("rider@example.com")
+			Expect(a).NotTo(Equal(b))
+		})
+		It("passes plain text through", func() {
+			plaintext, err := keyring("k1").Decrypt("rider@example.com")
+			Expect(err).To(BeNil())
+			Expect(plaintext).To(Equal("rider@example.com"))
+		})
+		It("fails on unknown keys and tampering", func() {
+			k := keyring("k1")
+			ciphertext, _ := k.Encrypt("rider@example.com")
+
+			_, err := k.Decrypt("k9" + strings.TrimPrefix(ciphertext, "k1"))
+			Expect(err).To(MatchError("unknown encryption key `k9`"))
+
+			parts := strings.Split(ciphertext, ":")
+			sealed, _ := base64.StdEncoding.DecodeString(parts[2])
+			sealed[len(sealed)-1] ^= 1
+			parts[2] = base64.StdEncoding.EncodeToString(sealed)
+			_, err = k.Decrypt(strings.Join(parts, ":"))
+			Expect(err).NotTo(BeNil())
+		})
+		It("computes a stable blind index ignoring case", func() {
+			a := keyring("k1").BlindIndex("Rider@Example.com ")
+			b := keyring("k2").BlindIndex("rider@example.com")
+			Expect(a).To(Equal(b))
+			Expect(a).To(HaveLen(64))
+			Expect(keyring("k1").BlindIndex("other@example.com")).NotTo(Equal(a))
+		})
+	})
+
+	Describe("ParseKeys and ReadKeyFile", func() {
+		It("reads id:base64 pairs", func() {
+			encoded := base64.StdEncoding.EncodeToString(oldKey)
+
+			keys, err := encryption.ParseKeys("k1:" + encoded + ", k2:" + encoded)
+			Expect(err).To(BeNil())
+			Expect(keys).To(HaveLen(2))
+			Expect(keys["k2"]).To(Equal(oldKey))
+
+			keys, err = encryption.ReadKeyFile(strings.NewReader("# old\nk1:" + encoded + "\n\nk2:" + encoded + "\n"))
+			Expect(err).To(BeNil())
+			Expect(keys).To(HaveLen(2))
+		})
+		It("rejects malformed entries", func() {
+			_, err := encryption.ParseKeys("k1")
+			Expect(err).NotTo(BeNil())
+			_, err = encryption.ParseKeys("k1:AAAA,k1:AAAA")
+			Expect(err).To(MatchError("duplicate key ID `k1`"))
+		})
+	})
+
+	Describe("RotateEmails", func() {
+		var (
+			database *dbfakes.FakeDB
+			k        encryption.Keyring
+			rotated  int
+			callErr  error
+		)
+
+		BeforeEach(func() {
+			database = &dbfakes.FakeDB{}
+			old, _ := keyring("k1").Encrypt("old@example.com")
+			database.ListEmailsToRotateReturnsOnCall(0, []db.StoredEmail{
+				{FeedbackID: "a", Email: old},
+				{FeedbackID: "b", Email: "plain@example.com"},
+			}, nil)
+			database.ListEmailsToRotateReturnsOnCall(1, []db.StoredEmail{}, nil)
+			k = keyring("k2")
+		})
+
+		JustBeforeEach(func() {
+			rotated, callErr = encryption.RotateEmails(context.Background(), database, k, 2)
+		})
+
+		When("listing fails", func() {
+			BeforeEach(func() {
+				database.ListEmailsToRotateReturnsOnCall(0, nil, errors.New("select failed"))
+			})
+			It("returns an error", func() {
+				Expect(callErr).To(MatchError("select failed"))
+			})
+		})
+		When("all goes well", func() {
+			It("re-encrypts every row under the current key", func() {
+				Expect(callErr).To(BeNil())
+				Expect(rotated).To(Equal(2))
+
+				_, keyID, limit := database.ListEmailsToRotateArgsForCall(0)
+				Expect(keyID).To(Equal("k2"))
+				Expect(limit).To(Equal(2))
+
+				Expect(database.UpdateEmailCallCount()).To(Equal(2))
+				for i, want := range []string{"old@example.com", "plain@example.com"} {
+					_, _, email, index := database.UpdateEmailArgsForCall(i)
+					Expect(email).To(HavePrefix("k2:"))
+					Expect(k.Decrypt(email)).To(Equal(want))
+					Expect(index).To(Equal(k.BlindIndex(want)))
+				}
+			})
+		})
+	})
+})
@@ -0,0 +1,46 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+//RotateEmails re-encrypts stored emails under the keyring's current key,
+//batchSize rows at a time, and refreshes their blind indexes. Emails stored
+//in plain text are encrypted. It returns the number of rows rotated.
+func RotateEmails(ctx context.Context, database db.DB, keyring Keyring, batchSize int) (int, error) {
+	rotated := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return rotated, err
+		}
+
+		batch, err := database.ListEmailsToRotate(ctx, keyring.CurrentKeyID(), batchSize)
+		if err != nil {
+			return rotated, err
+		}
+		if len(batch) == 0 {
+			return rotated, nil
+		}
+
+		for _, stored := range batch {
+			plaintext, err := keyring.Decrypt(stored.Email)
+			if err != nil {
+				return rotated, fmt.Errorf("failed decrypting email of feedback %s: %w", stored.FeedbackID, err)
+			}
+
+			encrypted, err := keyring.Encrypt(plaintext)
+			if err != nil {
+				return rotated, fmt.Errorf("failed encrypting email of feedback %s: %w", stored.FeedbackID, err)
+			}
+
+			err = database.UpdateEmail(ctx, stored.FeedbackID, encrypted, keyring.BlindIndex(plaintext))
+			if err != nil {
+				return rotated, err
+			}
+			rotated++
+		}
+	}
+}
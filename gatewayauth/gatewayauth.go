@@ -0,0 +1,181 @@
+package gatewayauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/logging"
+)
+
+//Headers set by the API gateway. The signature covers the session, role and
+//timestamp headers, along with the request method and path.
+const (
+	SessionHeader   = "X-Smarta-Auth-Session"
+	RoleHeader      = "X-Smarta-Auth-Role"
+	TimestampHeader = "X-Smarta-Auth-Timestamp"
+	SignatureHeader = "X-Smarta-Auth-Signature"
+)
+
+//MinKeySize is the shortest signing key accepted, in bytes
+const MinKeySize = 32
+
+//Reasons a request fails verification
+var (
+	ErrUnsigned         = errors.New("request is not signed")
+	ErrMalformed        = errors.New("malformed signature headers")
+	ErrOutsideWindow    = errors.New("timestamp is outside the replay window")
+	ErrInvalidSignature = errors.New("signature does not match")
+)
+
+//Verifier checks that the identity headers of a request were signed by the
+//API gateway. The gateway sets X-Smarta-Auth-Timestamp to the current Unix
+//time in seconds and X-Smarta-Auth-Signature to one or more comma-separated
+//`<key id>=<hex HMAC-SHA256>` entries, computed over
+//
+//  <timestamp>\n<method>\n<path>\n<session>\n<role>
+//
+//where path is the escaped URL path without the query. Binding the method and
+//path means captured headers can't be replayed against other endpoints, and
+//the timestamp bounds how long they can be replayed against the same one.
+//
+//A request passes if any entry names a known key and matches. This lets the
+//gateway sign with both the old and new key while keys are rotated.
+type Verifier struct {
+	keys   map[string][]byte
+	window time.Duration
+	now    func() time.Time
+}
+
+//New returns a Verifier accepting signatures under any of keys whose
+//timestamp is no further than window from now
+func New(
+	keys map[string][]byte,
+	window time.Duration,
+	now func() time.Time,
+) (Verifier, error) {
+	if len(keys) == 0 {
+		return Verifier{}, errors.New("at least one signing key is required")
+	}
+	for id, key := range keys {
+		if len(key) < MinKeySize {
+			return Verifier{}, fmt.Errorf("signing key `%s` is %d bytes, expected at least %d", id, len(key), MinKeySize)
+		}
+	}
+	if window <= 0 {
+		return Verifier{}, errors.New("replay window must be positive")
+	}
+
+	return Verifier{
+		keys:   keys,
+		window: window,
+		now:    now,
+	}, nil
+}
+
+//Sign returns the signature entry for the given headers under key. It is
+//what the gateway computes, and is exported for tests and tooling.
+func Sign(keyID string, key []byte, timestamp, method, path, session, role string) string {
+	return keyID + "=" + hex.EncodeToString(mac(key, timestamp, method, path, session, role))
+}
+
+func mac(key []byte, timestamp, method, path, session, role string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(timestamp + "\n" + method + "\n" + path + "\n" + session + "\n" + role))
+	return h.Sum(nil)
+}
+
+//Verify checks the signature headers of r. It succeeds if any of the
+//signatures is valid, and reports ErrMalformed only if none is and one
+//couldn't be parsed.
+func (v Verifier) Verify(r *http.Request) error {
+	timestamp := r.Header.Get(TimestampHeader)
+	signature := r.Header.Get(SignatureHeader)
+	if timestamp == "" || signature == "" {
+		return ErrUnsigned
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrMalformed
+	}
+	skew := v.now().Sub(time.Unix(seconds, 0))
+	if skew > v.window || skew < -v.window {
+		return ErrOutsideWindow
+	}
+
+	//an entry that can't be parsed mustn't hide a valid one after it, such as
+	//a signature under a new key during a rotation
+	session := r.Header.Get(SessionHeader)
+	role := r.Header.Get(RoleHeader)
+	malformed := false
+	for _, entry := range strings.Split(signature, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 {
+			malformed = true
+			continue
+		}
+
+		key, ok := v.keys[parts[0]]
+		if !ok {
+			continue
+		}
+		sum, err := hex.DecodeString(parts[1])
+		if err != nil {
+			malformed = true
+			continue
+		}
+		if hmac.Equal(sum, mac(key, timestamp, r.Method, r.URL.EscapedPath(), session, role)) {
+			return nil
+		}
+	}
+
+	if malformed {
+		return ErrMalformed
+	}
+	return ErrInvalidSignature
+}
+
+type errResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+//Middleware rejects requests carrying identity headers that don't pass
+//verification. Requests with no identity headers at all are passed on, since
+//public endpoints don't need them and the rest already reject them.
+func (v Verifier) Middleware(log *logrus.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(SessionHeader) == "" &&
+			r.Header.Get(RoleHeader) == "" &&
+			r.Header.Get(SignatureHeader) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := v.Verify(r); err != nil {
+			logging.FromContext(r.Context(), log).
+				WithField("reason", err.Error()).
+				Warn("rejected request with unverified gateway headers")
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(errResp{
+				Status:  http.StatusUnauthorized,
+				Message: "gateway signature verification failed: " + err.Error(),
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,13 @@
+package gatewayauth_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestGatewayauth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Gatewayauth Suite")
+}
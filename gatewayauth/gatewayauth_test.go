@@ -0,0 +1,217 @@
+package gatewayauth_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/gatewayauth"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Verifier", func() {
+	var (
+		oldKey = bytes.Repeat([]byte{1}, 32)
+		newKey = bytes.Repeat([]byte{2}, 32)
+		now    = time.Date(2020, 3, 14, 12, 0, 0, 0, time.UTC)
+
+		verifier gatewayauth.Verifier
+		req      *http.Request
+	)
+
+	BeforeEach(func() {
+		var err error
+		verifier, err = gatewayauth.New(
+			map[string][]byte{"old": oldKey, "new": newKey},
+			5*time.Minute,
+			func() time.Time { return now },
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		timestamp := strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)
+		req = httptest.NewRequest("POST", "/v1/feedback", nil)
+		req.Header.Set(gatewayauth.SessionHeader, "r39iefjd0q39f")
+		req.Header.Set(gatewayauth.RoleHeader, "anonymous")
+		req.Header.Set(gatewayauth.TimestampHeader, timestamp)
+		req.Header.Set(gatewayauth.SignatureHeader,
+			gatewayauth.Sign("new", newKey, timestamp, "POST", "/v1/feedback", "r39iefjd0q39f", "anonymous"))
+	})
+
+	Describe("New", func() {
+		It("rejects short keys", func() {
+			_, err := gatewayauth.New(map[string][]byte{"k": []byte("short")}, time.Minute, time.Now)
+			Expect(err).To(MatchError("signing key `k` is 5 bytes, expected at least 32"))
+		})
+		It("requires a key", func() {
+			_, err := gatewayauth.New(nil, time.Minute, time.Now)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Verify", func() {
+		It("accepts a valid signature", func() {
+			Expect(verifier.Verify(req)).To(Succeed())
+		})
+
+		When("signed with several keys during rotation", func() {
+			BeforeEach(func() {
+				ts := req.Header.Get(gatewayauth.TimestampHeader)
+				req.Header.Set(gatewayauth.SignatureHeader,
+					gatewayauth.Sign("retired", newKey, ts, "POST", "/v1/feedback", "r39iefjd0q39f", "anonymous")+", "+
+						gatewayauth.Sign("old", oldKey, ts, "POST", "/v1/feedback", "r39iefjd0q39f", "anonymous"))
+			})
+			It("accepts any matching known key", func() {
+				Expect(verifier.Verify(req)).To(Succeed())
+			})
+		})
+		When("an earlier signature is malformed", func() {
+			BeforeEach(func() {
+				ts := req.Header.Get(gatewayauth.TimestampHeader)
+				req.Header.Set(gatewayauth.SignatureHeader,
+					"v2:new, new=zz, "+gatewayauth.Sign("old", oldKey, ts, "POST", "/v1/feedback", "r39iefjd0q39f", "anonymous"))
+			})
+			It("accepts a later valid one", func() {
+				Expect(verifier.Verify(req)).To(Succeed())
+			})
+		})
+
+		When("the signature is missing", func() {
+			BeforeEach(func() {
+				req.Header.Del(gatewayauth.SignatureHeader)
+			})
+			It("fails", func() {
+				Expect(verifier.Verify(req)).To(Equal(gatewayauth.ErrUnsigned))
+			})
+		})
+
+		When("the headers are replayed against another endpoint", func() {
+			BeforeEach(func() {
+				headers := req.Header
+				req = httptest.NewRequest("DELETE", "/v1/admin/feedback/4f8b2e1c", nil)
+				req.Header = headers
+			})
+			It("fails", func() {
+				Expect(verifier.Verify(req)).To(Equal(gatewayauth.ErrInvalidSignature))
+			})
+		})
+
+		When("the query changes", func() {
+			BeforeEach(func() {
+				req.URL.RawQuery = "page=2"
+			})
+			It("still accepts the signature", func() {
+				Expect(verifier.Verify(req)).To(Succeed())
+			})
+		})
+
+		When("the role was changed", func() {
+			BeforeEach(func() {
+				req.Header.Set(gatewayauth.RoleHeader, "admin")
+			})
+			It("fails", func() {
+				Expect(verifier.Verify(req)).To(Equal(gatewayauth.ErrInvalidSignature))
+			})
+		})
+
+		When("the key is unknown", func() {
+			BeforeEach(func() {
+				ts := req.Header.Get(gatewayauth.TimestampHeader)
+				req.Header.Set(gatewayauth.SignatureHeader,
+					gatewayauth.Sign("other", newKey, ts, "POST", "/v1/feedback", "r39iefjd0q39f", "anonymous"))
+			})
+			It("fails", func() {
+				Expect(verifier.Verify(req)).To(Equal(gatewayauth.ErrInvalidSignature))
+			})
+		})
+
+		When("the timestamp is too old", func() {
+			BeforeEach(func() {
+				ts := strconv.FormatInt(now.Add(-6*time.Minute).Unix(), 10)
+				req.Header.Set(gatewayauth.TimestampHeader, ts)
+				req.Header.Set(gatewayauth.SignatureHeader,
+					gatewayauth.Sign("new", newKey, ts, "POST", "/v1/feedback", "r39iefjd0q39f", "anonymous"))
+			})
+			It("fails", func() {
+				Expect(verifier.Verify(req)).To(Equal(gatewayauth.ErrOutsideWindow))
+			})
+		})
+
+		When("the timestamp is too far in the future", func() {
+			BeforeEach(func() {
+				ts := strconv.FormatInt(now.Add(6*time.Minute).Unix(), 10)
+				req.Header.Set(gatewayauth.TimestampHeader, ts)
+				req.Header.Set(gatewayauth.SignatureHeader,
+					gatewayauth.Sign("new", newKey, ts, "POST", "/v1/feedback", "r39iefjd0q39f", "anonymous"))
+			})
+			It("fails", func() {
+				Expect(verifier.Verify(req)).To(Equal(gatewayauth.ErrOutsideWindow))
+			})
+		})
+
+		When("the signature isn't hex", func() {
+			BeforeEach(func() {
+				req.Header.Set(gatewayauth.SignatureHeader, "new=zz")
+			})
+			It("fails", func() {
+				Expect(verifier.Verify(req)).To(Equal(gatewayauth.ErrMalformed))
+			})
+		})
+	})
+
+	Describe("Middleware", func() {
+		var (
+			respW  *httptest.ResponseRecorder
+			called bool
+		)
+
+		BeforeEach(func() {
+			respW = httptest.NewRecorder()
+			called = false
+		})
+
+		JustBeforeEach(func() {
+			log := logrus.New()
+			log.SetOutput(ioutil.Discard)
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			})
+			verifier.Middleware(log, next).ServeHTTP(respW, req)
+		})
+
+		It("passes signed requests on", func() {
+			Expect(called).To(BeTrue())
+			Expect(respW.Code).To(Equal(http.StatusOK))
+		})
+
+		When("the request carries no identity headers", func() {
+			BeforeEach(func() {
+				req = httptest.NewRequest("GET", "/v1/health", nil)
+			})
+			It("passes it on", func() {
+				Expect(called).To(BeTrue())
+			})
+		})
+
+		When("the request is unsigned", func() {
+			BeforeEach(func() {
+				req.Header.Del(gatewayauth.TimestampHeader)
+				req.Header.Del(gatewayauth.SignatureHeader)
+			})
+			It("rejects it", func() {
+				Expect(called).To(BeFalse())
+				Expect(respW.Code).To(Equal(http.StatusUnauthorized))
+				Expect(respW.Body.String()).To(MatchJSON(`{
+					"status": 401,
+					"message": "gateway signature verification failed: request is not signed"
+				}`))
+			})
+		})
+	})
+})
@@ -0,0 +1,222 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//GTFS location types of the stops considered when resolving a location.
+//Entrances, generic nodes and boarding areas are ignored.
+const (
+	LocationTypeStop    = 0
+	LocationTypeStation = 1
+)
+
+//Stop is a row of a GTFS feed's stops.txt
+type Stop struct {
+	ID            string
+	Name          string
+	Latitude      float64
+	Longitude     float64
+	LocationType  int
+	ParentStation string
+}
+
+//LoadStops reads the stops of the GTFS feed at path, which is either the
+//feed's zip archive or its stops.txt
+func LoadStops(path string) ([]Stop, error) {
+	if strings.HasSuffix(strings.ToLower(path), ".zip") {
+		return loadZippedStops(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening GTFS stops: %w", err)
+	}
+	defer f.Close()
+
+	return ReadStops(f)
+}
+
+func loadZippedStops(path string) ([]Stop, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening GTFS feed: %w", err)
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.Name != "stops.txt" {
+			continue
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed opening GTFS stops: %w", err)
+		}
+		defer f.Close()
+
+		return ReadStops(f)
+	}
+
+	return nil, errors.New("GTFS feed has no stops.txt")
+}
+
+//ReadStops parses a stops.txt file. Stops without coordinates, which GTFS
+//allows for some location types, are left out.
+func ReadStops(r io.Reader) ([]Stop, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading GTFS stops header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, required := range []string{"stop_id", "stop_lat", "stop_lon"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("GTFS stops have no `%s` column", required)
+		}
+	}
+
+	stops := []Stop{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return stops, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed reading GTFS stops: %w", err)
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		if field("stop_lat") == "" || field("stop_lon") == "" {
+			continue
+		}
+
+		stop := Stop{
+			ID:            field("stop_id"),
+			Name:          field("stop_name"),
+			ParentStation: field("parent_station"),
+		}
+		stop.Latitude, err = strconv.ParseFloat(field("stop_lat"), 64)
+		if err != nil || math.Abs(stop.Latitude) > 90 {
+			return nil, fmt.Errorf("invalid `stop_lat` for GTFS stop `%s`", stop.ID)
+		}
+		stop.Longitude, err = strconv.ParseFloat(field("stop_lon"), 64)
+		if err != nil || math.Abs(stop.Longitude) > 180 {
+			return nil, fmt.Errorf("invalid `stop_lon` for GTFS stop `%s`", stop.ID)
+		}
+		if s := field("location_type"); s != "" {
+			stop.LocationType, err = strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid `location_type` for GTFS stop `%s`", stop.ID)
+			}
+		}
+
+		stops = append(stops, stop)
+	}
+}
+
+//Match is the stop resolved from a location, and its distance in meters
+type Match struct {
+	Stop     Stop
+	Distance float64
+}
+
+//Resolver finds the stop nearest a location
+//go:generate counterfeiter . Resolver
+type Resolver interface {
+	Nearest(latitude, longitude float64) (Match, bool)
+}
+
+//Index resolves locations to the nearest stop within a radius. Stops are
+//scanned in full, which takes well under a millisecond for a feed of ten
+//thousand stops.
+type Index struct {
+	stops    []Stop
+	stations map[string]Stop
+	radius   float64
+}
+
+//NewIndex returns an Index resolving locations to stops at most radius
+//meters away
+func NewIndex(stops []Stop, radius float64) Index {
+	idx := Index{
+		stations: map[string]Stop{},
+		radius:   radius,
+	}
+	for _, stop := range stops {
+		switch stop.LocationType {
+		case LocationTypeStation:
+			idx.stations[stop.ID] = stop
+			idx.stops = append(idx.stops, stop)
+		case LocationTypeStop:
+			idx.stops = append(idx.stops, stop)
+		}
+	}
+	return idx
+}
+
+//Nearest returns the stop nearest the given location, if one is within the
+//radius. A platform belonging to a station resolves to the station, with
+//the distance to the platform.
+func (idx Index) Nearest(latitude, longitude float64) (Match, bool) {
+	//a degree of latitude is never shorter than this many meters, so stops
+	//further apart in latitude can be skipped without measuring
+	const minMetersPerDegree = 110000
+	maxDegrees := idx.radius / minMetersPerDegree
+
+	var best Match
+	found := false
+	for _, stop := range idx.stops {
+		if math.Abs(stop.Latitude-latitude) > maxDegrees {
+			continue
+		}
+
+		d := Distance(latitude, longitude, stop.Latitude, stop.Longitude)
+		if d > idx.radius || (found && d >= best.Distance) {
+			continue
+		}
+		best = Match{Stop: stop, Distance: d}
+		found = true
+	}
+
+	if station, ok := idx.stations[best.Stop.ParentStation]; found && ok {
+		best.Stop = station
+	}
+
+	return best, found
+}
+
+//earthRadius is the mean radius of the Earth in meters
+const earthRadius = 6371008.8
+
+//Distance returns the great-circle distance in meters between two points
+//given in degrees
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	rad1, rad2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad1)*math.Cos(rad2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,13 @@
+package gtfs_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestGTFS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GTFS Suite")
+}
@@ -0,0 +1,80 @@
+package gtfs_test
+
+import (
+	"strings"
+
+	"github.com/smartatransit/feedback/gtfs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const stopsTxt = "\ufeffstop_id,stop_code,stop_name,stop_lat,stop_lon,location_type,parent_station\n" +
+	"PEACHTREE,,PEACHTREE CENTER STATION,33.759532,-84.387564,1,\n" +
+	"PEACHTREE_N,907933,PEACHTREE CENTER STATION NORTHBOUND,33.759700,-84.387400,0,PEACHTREE\n" +
+	"PEACHTREE_E,,PEACHTREE CENTER ENTRANCE,33.759100,-84.387900,2,PEACHTREE\n" +
+	"212112,212112,PEACHTREE ST NE @ ELLIS ST NE,33.760880,-84.386590,,\n" +
+	"NODE,,PATHWAY NODE,,,3,PEACHTREE\n"
+
+var _ = Describe("GTFS", func() {
+	Describe("ReadStops", func() {
+		It("reads stops with coordinates", func() {
+			stops, err := gtfs.ReadStops(strings.NewReader(stopsTxt))
+			Expect(err).To(BeNil())
+			Expect(stops).To(HaveLen(4))
+			Expect(stops[1]).To(Equal(gtfs.Stop{
+				ID:            "PEACHTREE_N",
+				Name:          "PEACHTREE CENTER STATION NORTHBOUND",
+				Latitude:      33.7597,
+				Longitude:     -84.3874,
+				LocationType:  gtfs.LocationTypeStop,
+				ParentStation: "PEACHTREE",
+			}))
+		})
+
+		It("rejects files without coordinates", func() {
+			_, err := gtfs.ReadStops(strings.NewReader("stop_id,stop_name\n1,A\n"))
+			Expect(err).To(MatchError("GTFS stops have no `stop_lat` column"))
+		})
+
+		It("rejects invalid coordinates", func() {
+			_, err := gtfs.ReadStops(strings.NewReader("stop_id,stop_lat,stop_lon\n1,95,-84\n"))
+			Expect(err).To(MatchError("invalid `stop_lat` for GTFS stop `1`"))
+		})
+	})
+
+	Describe("Index", func() {
+		var idx gtfs.Index
+
+		BeforeEach(func() {
+			stops, err := gtfs.ReadStops(strings.NewReader(stopsTxt))
+			Expect(err).To(BeNil())
+			idx = gtfs.NewIndex(stops, 100)
+		})
+
+		It("resolves platforms to their station", func() {
+			match, ok := idx.Nearest(33.75975, -84.38735)
+			Expect(ok).To(BeTrue())
+			Expect(match.Stop.ID).To(Equal("PEACHTREE"))
+			Expect(match.Distance).To(BeNumerically("~", 7, 2))
+		})
+
+		It("resolves to the nearest stop", func() {
+			match, ok := idx.Nearest(33.76090, -84.38660)
+			Expect(ok).To(BeTrue())
+			Expect(match.Stop.ID).To(Equal("212112"))
+		})
+
+		It("ignores stops beyond the radius", func() {
+			_, ok := idx.Nearest(33.7490, -84.3880)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("Distance", func() {
+		It("measures great-circle distances", func() {
+			//Five Points to Airport stations
+			Expect(gtfs.Distance(33.753899, -84.391974, 33.640758, -84.446341)).To(BeNumerically("~", 13600, 200))
+		})
+	})
+})
@@ -0,0 +1,117 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package gtfsfakes
+
+import (
+	"sync"
+
+	"github.com/smartatransit/feedback/gtfs"
+)
+
+type FakeResolver struct {
+	NearestStub        func(float64, float64) (gtfs.Match, bool)
+	nearestMutex       sync.RWMutex
+	nearestArgsForCall []struct {
+		arg1 float64
+		arg2 float64
+	}
+	nearestReturns struct {
+		result1 gtfs.Match
+		result2 bool
+	}
+	nearestReturnsOnCall map[int]struct {
+		result1 gtfs.Match
+		result2 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeResolver) Nearest(arg1 float64, arg2 float64) (gtfs.Match, bool) {
+	fake.nearestMutex.Lock()
+	ret, specificReturn := fake.nearestReturnsOnCall[len(fake.nearestArgsForCall)]
+	fake.nearestArgsForCall = append(fake.nearestArgsForCall, struct {
+		arg1 float64
+		arg2 float64
+	}{arg1, arg2})
+	fake.recordInvocation("Nearest", []interface{}{arg1, arg2})
+	fake.nearestMutex.Unlock()
+	if fake.NearestStub != nil {
+		return fake.NearestStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.nearestReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeResolver) NearestCallCount() int {
+	fake.nearestMutex.RLock()
+	defer fake.nearestMutex.RUnlock()
+	return len(fake.nearestArgsForCall)
+}
+
+func (fake *FakeResolver) NearestCalls(stub func(float64, float64) (gtfs.Match, bool)) {
+	fake.nearestMutex.Lock()
+	defer fake.nearestMutex.Unlock()
+	fake.NearestStub = stub
+}
+
+func (fake *FakeResolver) NearestArgsForCall(i int) (float64, float64) {
+	fake.nearestMutex.RLock()
+	defer fake.nearestMutex.RUnlock()
+	argsForCall := fake.nearestArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeResolver) NearestReturns(result1 gtfs.Match, result2 bool) {
+	fake.nearestMutex.Lock()
+	defer fake.nearestMutex.Unlock()
+	fake.NearestStub = nil
+	fake.nearestReturns = struct {
+		result1 gtfs.Match
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeResolver) NearestReturnsOnCall(i int, result1 gtfs.Match, result2 bool) {
+	fake.nearestMutex.Lock()
+	defer fake.nearestMutex.Unlock()
+	fake.NearestStub = nil
+	if fake.nearestReturnsOnCall == nil {
+		fake.nearestReturnsOnCall = make(map[int]struct {
+			result1 gtfs.Match
+			result2 bool
+		})
+	}
+	fake.nearestReturnsOnCall[i] = struct {
+		result1 gtfs.Match
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeResolver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.nearestMutex.RLock()
+	defer fake.nearestMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeResolver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ gtfs.Resolver = new(FakeResolver)
@@ -0,0 +1,137 @@
+package kinds
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/schema"
+)
+
+//Catalog lists the kinds and values of feedback, including inactive ones,
+//in display order. DetailsSchema returns the compiled details schema of a
+//kind, or nil if it has none.
+//go:generate counterfeiter . Catalog
+type Catalog interface {
+	Kinds() []db.FeedbackKind
+	Values() []db.FeedbackValue
+	DetailsSchema(kind string) *schema.Schema
+}
+
+//Cache is a Catalog holding the feedback_kinds and feedback_values tables in
+//memory, so that validating a submission doesn't need a query. Changes to
+//the tables show up after the next Refresh.
+type Cache struct {
+	db  db.DB
+	log *logrus.Logger
+
+	mu      sync.RWMutex
+	kinds   []db.FeedbackKind
+	values  []db.FeedbackValue
+	schemas map[string]*schema.Schema
+}
+
+//New returns an empty Cache; call Refresh to load it. Details schemas that
+//don't compile are logged to log.
+func New(database db.DB, log *logrus.Logger) *Cache {
+	return &Cache{db: database, log: log}
+}
+
+//Kinds implements Catalog
+func (c *Cache) Kinds() []db.FeedbackKind {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.kinds
+}
+
+//Values implements Catalog
+func (c *Cache) Values() []db.FeedbackValue {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.values
+}
+
+//DetailsSchema implements Catalog
+func (c *Cache) DetailsSchema(kind string) *schema.Schema {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.schemas[kind]
+}
+
+//Refresh reloads the kinds and values, and compiles the details schemas.
+//A schema that doesn't compile is logged and left out, so that its kind
+//accepts no details until it is fixed, while every other kind is served as
+//usual. If the tables can't be read, the cache keeps what it had.
+func (c *Cache) Refresh(ctx context.Context) error {
+	kinds, err := c.db.ListFeedbackKinds(ctx)
+	if err != nil {
+		return err
+	}
+	values, err := c.db.ListFeedbackValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	schemas := map[string]*schema.Schema{}
+	for _, k := range kinds {
+		if k.DetailsSchema == nil {
+			continue
+		}
+		s, err := schema.Parse(k.DetailsSchema)
+		if err != nil {
+			c.log.WithField("kind", k.Name).
+				Errorf("failed compiling details schema, rejecting details until it is fixed: %s", err.Error())
+			continue
+		}
+		schemas[k.Name] = s
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.kinds = kinds
+	c.values = values
+	c.schemas = schemas
+
+	return nil
+}
+
+//Run refreshes the cache every interval until ctx is done
+func (c *Cache) Run(ctx context.Context, log *logrus.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.Refresh(ctx); err != nil {
+			log.Errorf("failed refreshing feedback kinds: %s", err.Error())
+		}
+	}
+}
+
+//FindKind returns the kind named name, if there is one
+func FindKind(catalog Catalog, name string) (db.FeedbackKind, bool) {
+	for _, k := range catalog.Kinds() {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return db.FeedbackKind{}, false
+}
+
+//FindValue returns the value named name, if there is one
+func FindValue(catalog Catalog, name string) (db.FeedbackValue, bool) {
+	for _, v := range catalog.Values() {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return db.FeedbackValue{}, false
+}
@@ -0,0 +1,13 @@
+package kinds_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestKinds(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Kinds Suite")
+}
@@ -0,0 +1,96 @@
+package kinds_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/kinds"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		db    *dbfakes.FakeDB
+		logs  *bytes.Buffer
+		cache *kinds.Cache
+	)
+
+	BeforeEach(func() {
+		db = &dbfakes.FakeDB{}
+		db.ListFeedbackKindsReturns([]dbp.FeedbackKind{{Name: "outage", Active: true}}, nil)
+		db.ListFeedbackValuesReturns([]dbp.FeedbackValue{{Name: "positive", Active: true}}, nil)
+		logs = &bytes.Buffer{}
+		log := logrus.New()
+		log.SetOutput(logs)
+		cache = kinds.New(db, log)
+	})
+
+	It("is empty until refreshed", func() {
+		Expect(cache.Kinds()).To(BeEmpty())
+		_, ok := kinds.FindKind(cache, "outage")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("serves what it loaded", func() {
+		Expect(cache.Refresh(context.Background())).To(Succeed())
+
+		kind, ok := kinds.FindKind(cache, "outage")
+		Expect(ok).To(BeTrue())
+		Expect(kind.Active).To(BeTrue())
+
+		_, ok = kinds.FindValue(cache, "positive")
+		Expect(ok).To(BeTrue())
+		_, ok = kinds.FindValue(cache, "negative")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("compiles details schemas", func() {
+		db.ListFeedbackKindsReturns([]dbp.FeedbackKind{
+			{Name: "outage", Active: true},
+			{Name: "service_condition", Active: true, DetailsSchema: []byte(`{"type": "object"}`)},
+		}, nil)
+		Expect(cache.Refresh(context.Background())).To(Succeed())
+
+		Expect(cache.DetailsSchema("outage")).To(BeNil())
+		Expect(cache.DetailsSchema("service_condition")).NotTo(BeNil())
+	})
+
+	When("a details schema doesn't compile", func() {
+		BeforeEach(func() {
+			Expect(cache.Refresh(context.Background())).To(Succeed())
+			db.ListFeedbackKindsReturns([]dbp.FeedbackKind{
+				{Name: "comment", Active: true, DetailsSchema: []byte(`{"type": "int"}`)},
+				{Name: "service_condition", Active: true, DetailsSchema: []byte(`{"type": "object"}`)},
+			}, nil)
+		})
+		It("logs it and loads everything else", func() {
+			Expect(cache.Refresh(context.Background())).To(Succeed())
+			Expect(logs.String()).To(ContainSubstring("invalid schema: at `type`: value must be one of"))
+			Expect(logs.String()).To(ContainSubstring("kind=comment"))
+
+			Expect(cache.Kinds()).To(HaveLen(2))
+			Expect(cache.DetailsSchema("comment")).To(BeNil())
+			Expect(cache.DetailsSchema("service_condition")).NotTo(BeNil())
+		})
+	})
+
+	When("a refresh fails", func() {
+		BeforeEach(func() {
+			Expect(cache.Refresh(context.Background())).To(Succeed())
+			db.ListFeedbackValuesReturns(nil, errors.New("select failed"))
+			db.ListFeedbackKindsReturns([]dbp.FeedbackKind{{Name: "comment", Active: true}}, nil)
+		})
+		It("keeps what it had", func() {
+			Expect(cache.Refresh(context.Background())).To(MatchError("select failed"))
+			Expect(cache.Kinds()).To(Equal([]dbp.FeedbackKind{{Name: "outage", Active: true}}))
+			Expect(cache.Values()).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,240 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package kindsfakes
+
+import (
+	"sync"
+
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/kinds"
+	"github.com/smartatransit/feedback/schema"
+)
+
+type FakeCatalog struct {
+	DetailsSchemaStub        func(string) *schema.Schema
+	detailsSchemaMutex       sync.RWMutex
+	detailsSchemaArgsForCall []struct {
+		arg1 string
+	}
+	detailsSchemaReturns struct {
+		result1 *schema.Schema
+	}
+	detailsSchemaReturnsOnCall map[int]struct {
+		result1 *schema.Schema
+	}
+	KindsStub        func() []db.FeedbackKind
+	kindsMutex       sync.RWMutex
+	kindsArgsForCall []struct {
+	}
+	kindsReturns struct {
+		result1 []db.FeedbackKind
+	}
+	kindsReturnsOnCall map[int]struct {
+		result1 []db.FeedbackKind
+	}
+	ValuesStub        func() []db.FeedbackValue
+	valuesMutex       sync.RWMutex
+	valuesArgsForCall []struct {
+	}
+	valuesReturns struct {
+		result1 []db.FeedbackValue
+	}
+	valuesReturnsOnCall map[int]struct {
+		result1 []db.FeedbackValue
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeCatalog) DetailsSchema(arg1 string) *schema.Schema {
+	fake.detailsSchemaMutex.Lock()
+	ret, specificReturn := fake.detailsSchemaReturnsOnCall[len(fake.detailsSchemaArgsForCall)]
+	fake.detailsSchemaArgsForCall = append(fake.detailsSchemaArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("DetailsSchema", []interface{}{arg1})
+	fake.detailsSchemaMutex.Unlock()
+	if fake.DetailsSchemaStub != nil {
+		return fake.DetailsSchemaStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.detailsSchemaReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeCatalog) DetailsSchemaCallCount() int {
+	fake.detailsSchemaMutex.RLock()
+	defer fake.detailsSchemaMutex.RUnlock()
+	return len(fake.detailsSchemaArgsForCall)
+}
+
+func (fake *FakeCatalog) DetailsSchemaCalls(stub func(string) *schema.Schema) {
+	fake.detailsSchemaMutex.Lock()
+	defer fake.detailsSchemaMutex.Unlock()
+	fake.DetailsSchemaStub = stub
+}
+
+func (fake *FakeCatalog) DetailsSchemaArgsForCall(i int) string {
+	fake.detailsSchemaMutex.RLock()
+	defer fake.detailsSchemaMutex.RUnlock()
+	argsForCall := fake.detailsSchemaArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCatalog) DetailsSchemaReturns(result1 *schema.Schema) {
+	fake.detailsSchemaMutex.Lock()
+	defer fake.detailsSchemaMutex.Unlock()
+	fake.DetailsSchemaStub = nil
+	fake.detailsSchemaReturns = struct {
+		result1 *schema.Schema
+	}{result1}
+}
+
+func (fake *FakeCatalog) DetailsSchemaReturnsOnCall(i int, result1 *schema.Schema) {
+	fake.detailsSchemaMutex.Lock()
+	defer fake.detailsSchemaMutex.Unlock()
+	fake.DetailsSchemaStub = nil
+	if fake.detailsSchemaReturnsOnCall == nil {
+		fake.detailsSchemaReturnsOnCall = make(map[int]struct {
+			result1 *schema.Schema
+		})
+	}
+	fake.detailsSchemaReturnsOnCall[i] = struct {
+		result1 *schema.Schema
+	}{result1}
+}
+
+func (fake *FakeCatalog) Kinds() []db.FeedbackKind {
+	fake.kindsMutex.Lock()
+	ret, specificReturn := fake.kindsReturnsOnCall[len(fake.kindsArgsForCall)]
+	fake.kindsArgsForCall = append(fake.kindsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Kinds", []interface{}{})
+	fake.kindsMutex.Unlock()
+	if fake.KindsStub != nil {
+		return fake.KindsStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.kindsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeCatalog) KindsCallCount() int {
+	fake.kindsMutex.RLock()
+	defer fake.kindsMutex.RUnlock()
+	return len(fake.kindsArgsForCall)
+}
+
+func (fake *FakeCatalog) KindsCalls(stub func() []db.FeedbackKind) {
+	fake.kindsMutex.Lock()
+	defer fake.kindsMutex.Unlock()
+	fake.KindsStub = stub
+}
+
+func (fake *FakeCatalog) KindsReturns(result1 []db.FeedbackKind) {
+	fake.kindsMutex.Lock()
+	defer fake.kindsMutex.Unlock()
+	fake.KindsStub = nil
+	fake.kindsReturns = struct {
+		result1 []db.FeedbackKind
+	}{result1}
+}
+
+func (fake *FakeCatalog) KindsReturnsOnCall(i int, result1 []db.FeedbackKind) {
+	fake.kindsMutex.Lock()
+	defer fake.kindsMutex.Unlock()
+	fake.KindsStub = nil
+	if fake.kindsReturnsOnCall == nil {
+		fake.kindsReturnsOnCall = make(map[int]struct {
+			result1 []db.FeedbackKind
+		})
+	}
+	fake.kindsReturnsOnCall[i] = struct {
+		result1 []db.FeedbackKind
+	}{result1}
+}
+
+func (fake *FakeCatalog) Values() []db.FeedbackValue {
+	fake.valuesMutex.Lock()
+	ret, specificReturn := fake.valuesReturnsOnCall[len(fake.valuesArgsForCall)]
+	fake.valuesArgsForCall = append(fake.valuesArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Values", []interface{}{})
+	fake.valuesMutex.Unlock()
+	if fake.ValuesStub != nil {
+		return fake.ValuesStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.valuesReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeCatalog) ValuesCallCount() int {
+	fake.valuesMutex.RLock()
+	defer fake.valuesMutex.RUnlock()
+	return len(fake.valuesArgsForCall)
+}
+
+func (fake *FakeCatalog) ValuesCalls(stub func() []db.FeedbackValue) {
+	fake.valuesMutex.Lock()
+	defer fake.valuesMutex.Unlock()
+	fake.ValuesStub = stub
+}
+
+func (fake *FakeCatalog) ValuesReturns(result1 []db.FeedbackValue) {
+	fake.valuesMutex.Lock()
+	defer fake.valuesMutex.Unlock()
+	fake.ValuesStub = nil
+	fake.valuesReturns = struct {
+		result1 []db.FeedbackValue
+	}{result1}
+}
+
+func (fake *FakeCatalog) ValuesReturnsOnCall(i int, result1 []db.FeedbackValue) {
+	fake.valuesMutex.Lock()
+	defer fake.valuesMutex.Unlock()
+	fake.ValuesStub = nil
+	if fake.valuesReturnsOnCall == nil {
+		fake.valuesReturnsOnCall = make(map[int]struct {
+			result1 []db.FeedbackValue
+		})
+	}
+	fake.valuesReturnsOnCall[i] = struct {
+		result1 []db.FeedbackValue
+	}{result1}
+}
+
+func (fake *FakeCatalog) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.detailsSchemaMutex.RLock()
+	defer fake.detailsSchemaMutex.RUnlock()
+	fake.kindsMutex.RLock()
+	defer fake.kindsMutex.RUnlock()
+	fake.valuesMutex.RLock()
+	defer fake.valuesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeCatalog) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ kinds.Catalog = new(FakeCatalog)
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//RequestIDHeader is propagated from the API gateway when present, and
+//generated otherwise
+const RequestIDHeader = "X-Request-ID"
+
+type ctxKey int
+
+const (
+	loggerKey ctxKey = iota
+	requestIDKey
+)
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+//WithLogger returns a copy of ctx carrying log
+func WithLogger(ctx context.Context, log *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey, log)
+}
+
+//FromContext returns the request-scoped logger stored in ctx, or an entry
+//of fallback if there is none
+func FromContext(ctx context.Context, fallback *logrus.Logger) *logrus.Entry {
+	if log, ok := ctx.Value(loggerKey).(*logrus.Entry); ok {
+		return log
+	}
+	return logrus.NewEntry(fallback)
+}
+
+//RequestID returns the ID of the request being served with ctx, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+//HashSession returns a short, stable digest of a session ID so that
+//sessions can be correlated in logs without recording the ID itself
+func HashSession(session string) string {
+	if session == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(session))
+	return hex.EncodeToString(sum[:6])
+}
+
+//secretPathPrefixes are the prefixes of paths that end in a secret, such as
+//the bearer token of an unsubscribe link
+var secretPathPrefixes = []string{"/v1/email/unsubscribe/"}
+
+//RedactPath returns path with any secret it ends in replaced, so that it can
+//be logged or traced
+func RedactPath(path string) string {
+	for _, prefix := range secretPathPrefixes {
+		if strings.HasPrefix(path, prefix) && len(path) > len(prefix) {
+			return prefix + "REDACTED"
+		}
+	}
+	return path
+}
+
+//Middleware assigns every request an ID, makes a logger tagged with it
+//available through the request context, and writes one access log entry
+//per request
+func Middleware(log *logrus.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		entry := log.WithField("request_id", requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		ctx = WithLogger(ctx, entry)
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		entry.WithFields(logrus.Fields{
+			"method":      r.Method,
+			"path":        RedactPath(r.URL.Path),
+			"status":      rec.status,
+			"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"bytes":       rec.bytes,
+			"role":        r.Header.Get("X-Smarta-Auth-Role"),
+			"session":     HashSession(r.Header.Get("X-Smarta-Auth-Session")),
+		}).Info("handled request")
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
@@ -0,0 +1,106 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Middleware", func() {
+	var (
+		out *bytes.Buffer
+		log *logrus.Logger
+
+		req   *http.Request
+		respW *httptest.ResponseRecorder
+
+		seenRequestID string
+		entries       []map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		out = &bytes.Buffer{}
+		log = logrus.New()
+		log.SetOutput(out)
+		log.SetFormatter(&logrus.JSONFormatter{})
+
+		req = httptest.NewRequest("POST", "/v1/feedback", nil)
+		req.Header.Set("X-Smarta-Auth-Session", "r39iefjd0q39f")
+		req.Header.Set("X-Smarta-Auth-Role", "anonymous")
+		respW = httptest.NewRecorder()
+
+		seenRequestID = ""
+		entries = nil
+	})
+
+	JustBeforeEach(func() {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seenRequestID = logging.RequestID(r.Context())
+			logging.FromContext(r.Context(), logrus.New()).Error("insert failed")
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("oops"))
+		})
+		logging.Middleware(log, handler).ServeHTTP(respW, req)
+
+		dec := json.NewDecoder(out)
+		for dec.More() {
+			var entry map[string]interface{}
+			Expect(dec.Decode(&entry)).To(Succeed())
+			entries = append(entries, entry)
+		}
+	})
+
+	When("the gateway supplies a request ID", func() {
+		BeforeEach(func() {
+			req.Header.Set("X-Request-ID", "gw-1234")
+		})
+		It("propagates it", func() {
+			Expect(seenRequestID).To(Equal("gw-1234"))
+			Expect(respW.Header().Get("X-Request-ID")).To(Equal("gw-1234"))
+		})
+	})
+	When("the supplied request ID is unusable", func() {
+		BeforeEach(func() {
+			req.Header.Set("X-Request-ID", "has spaces\nand newlines")
+		})
+		It("generates a new one", func() {
+			Expect(seenRequestID).To(HaveLen(32))
+			Expect(respW.Header().Get("X-Request-ID")).To(Equal(seenRequestID))
+		})
+	})
+	It("tags handler logs with the request ID", func() {
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0]["msg"]).To(Equal("insert failed"))
+		Expect(entries[0]["request_id"]).To(Equal(seenRequestID))
+	})
+	It("writes a structured access log entry", func() {
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[1]).To(HaveKeyWithValue("msg", "handled request"))
+		Expect(entries[1]).To(HaveKeyWithValue("request_id", seenRequestID))
+		Expect(entries[1]).To(HaveKeyWithValue("method", "POST"))
+		Expect(entries[1]).To(HaveKeyWithValue("path", "/v1/feedback"))
+		Expect(entries[1]).To(HaveKeyWithValue("status", BeEquivalentTo(500)))
+		Expect(entries[1]).To(HaveKeyWithValue("bytes", BeEquivalentTo(4)))
+		Expect(entries[1]).To(HaveKeyWithValue("role", "anonymous"))
+		Expect(entries[1]).To(HaveKeyWithValue("session", logging.HashSession("r39iefjd0q39f")))
+		Expect(entries[1]).To(HaveKey("duration_ms"))
+		Expect(out.String()).NotTo(ContainSubstring("r39iefjd0q39f"))
+	})
+	When("the path ends in a secret", func() {
+		BeforeEach(func() {
+			req = httptest.NewRequest("POST", "/v1/email/unsubscribe/c2VjcmV0LXRva2Vu", nil)
+		})
+		It("redacts it from the access log", func() {
+			Expect(entries[1]).To(HaveKeyWithValue("path", "/v1/email/unsubscribe/REDACTED"))
+			Expect(out.String()).NotTo(ContainSubstring("c2VjcmV0LXRva2Vu"))
+		})
+	})
+})
@@ -0,0 +1,195 @@
+package mailer
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+//Retry backoff: the first retry waits retryDelay, and each one after that
+//twice as long as the last, up to maxRetryDelay
+const (
+	retryDelay    = time.Minute
+	maxRetryDelay = 6 * time.Hour
+)
+
+//claimLease is how long a claimed email is hidden from other replicas. An
+//email whose sender crashed is retried after it.
+const claimLease = 10 * time.Minute
+
+//Reasons emails are dropped rather than sent
+const (
+	DropNoRecipient = "no recipient"
+	DropNotOptedIn  = "rider did not opt in"
+	DropUnsubscribe = "unsubscribed"
+)
+
+//Dispatcher sends queued emails. Every email is checked against the rider's
+//opt-in and the unsubscribed addresses just before it is sent.
+type Dispatcher struct {
+	db           db.DB
+	sender       Sender
+	templates    Templates
+	unsubscriber Unsubscriber
+	from         string
+	batchSize    int
+	maxAttempts  int
+	now          func() time.Time
+}
+
+//NewDispatcher returns a Dispatcher sending as from through sender,
+//batchSize emails at a time, and giving up on an email after maxAttempts
+func NewDispatcher(
+	database db.DB,
+	sender Sender,
+	templates Templates,
+	unsubscriber Unsubscriber,
+	from string,
+	batchSize int,
+	maxAttempts int,
+	now func() time.Time,
+) Dispatcher {
+	return Dispatcher{
+		db:           database,
+		sender:       sender,
+		templates:    templates,
+		unsubscriber: unsubscriber,
+		from:         from,
+		batchSize:    batchSize,
+		maxAttempts:  maxAttempts,
+		now:          now,
+	}
+}
+
+//Dispatch sends emails until none are due, returning how many were sent.
+//Failures of single emails are recorded for retry rather than returned.
+func (d Dispatcher) Dispatch(ctx context.Context, log *logrus.Logger) (int, error) {
+	sent := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+
+		emails, err := d.db.ClaimEmails(ctx, d.batchSize, claimLease)
+		if err != nil {
+			return sent, err
+		}
+		if len(emails) == 0 {
+			return sent, nil
+		}
+
+		for _, email := range emails {
+			ok, err := d.send(ctx, log, email)
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+}
+
+//send sends a single email, or records why it wasn't sent. It only returns
+//an error if the outcome couldn't be recorded.
+func (d Dispatcher) send(ctx context.Context, log *logrus.Logger, email db.QueuedEmail) (bool, error) {
+	if email.Email == nil || email.Kind == nil {
+		return false, d.db.DropEmail(ctx, email.ID, DropNoRecipient)
+	}
+	if !email.OptedIn {
+		return false, d.db.DropEmail(ctx, email.ID, DropNotOptedIn)
+	}
+
+	unsubscribed, err := d.db.IsEmailUnsubscribed(ctx, d.unsubscriber.Digest(*email.Email))
+	if err != nil {
+		return false, err
+	}
+	if unsubscribed {
+		return false, d.db.DropEmail(ctx, email.ID, DropUnsubscribe)
+	}
+
+	data := Data{
+		Kind:           strings.Replace(*email.Kind, "_", " ", -1),
+		UnsubscribeURL: d.unsubscriber.URL(*email.Email),
+	}
+	if email.Line != nil {
+		data.Line = *email.Line
+	}
+	if email.ReceivedAt != nil {
+		data.ReceivedAt = *email.ReceivedAt
+	}
+	if email.Reply != nil {
+		data.Reply = *email.Reply
+	}
+
+	subject, body, err := d.templates.Render(email.Template, data)
+	if err != nil {
+		return false, d.db.MarkEmailFailed(ctx, email.ID, err.Error(), nil)
+	}
+
+	message, err := Compose(Message{
+		From:           d.from,
+		To:             *email.Email,
+		Subject:        subject,
+		Body:           body,
+		UnsubscribeURL: data.UnsubscribeURL,
+	}, d.now())
+	if err != nil {
+		return false, err
+	}
+
+	if err := d.sender.Send(ctx, *email.Email, message); err != nil {
+		log.WithFields(logrus.Fields{
+			"email_id": email.ID,
+			"attempts": email.Attempts,
+		}).Warnf("failed sending email: %s", err.Error())
+		return false, d.db.MarkEmailFailed(ctx, email.ID, err.Error(), d.retryAt(email, err))
+	}
+
+	return true, d.db.MarkEmailSent(ctx, email.ID)
+}
+
+//retryAt returns when to retry an email whose sending failed with err, or
+//nil to give up on it
+func (d Dispatcher) retryAt(email db.QueuedEmail, err error) *time.Time {
+	if IsPermanent(err) || email.Attempts >= d.maxAttempts {
+		return nil
+	}
+
+	delay := retryDelay
+	for i := 1; i < email.Attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	at := d.now().Add(delay)
+	return &at
+}
+
+//Run dispatches every interval until ctx is done
+func (d Dispatcher) Run(ctx context.Context, log *logrus.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := d.Dispatch(ctx, log)
+		if sent > 0 {
+			log.WithField("sent", sent).Info("sent queued emails")
+		}
+		if err != nil {
+			log.Errorf("failed sending queued emails: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package mailer_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/textproto"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/mailer"
+	"github.com/smartatransit/feedback/mailer/mailerfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dispatcher", func() {
+	var (
+		db           *dbfakes.FakeDB
+		sender       *mailerfakes.FakeSender
+		unsubscriber mailer.Unsubscriber
+		now          time.Time
+
+		email dbp.QueuedEmail
+
+		sent    int
+		callErr error
+	)
+
+	BeforeEach(func() {
+		db = &dbfakes.FakeDB{}
+		sender = &mailerfakes.FakeSender{}
+		now = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		var err error
+		unsubscriber, err = mailer.NewUnsubscriber(bytes.Repeat([]byte("k"), 32), "https://example.com")
+		Expect(err).To(BeNil())
+
+		address, kind, line := "rider@example.com", "service_condition", "red"
+		email = dbp.QueuedEmail{
+			ID:         42,
+			FeedbackID: "feedback-id",
+			Template:   dbp.EmailTemplateAcknowledgement,
+			Attempts:   1,
+			Email:      &address,
+			OptedIn:    true,
+			Kind:       &kind,
+			Line:       &line,
+			ReceivedAt: &now,
+		}
+	})
+
+	JustBeforeEach(func() {
+		db.ClaimEmailsReturnsOnCall(0, []dbp.QueuedEmail{email}, nil)
+
+		log := logrus.New()
+		log.SetOutput(ioutil.Discard)
+		dispatcher := mailer.NewDispatcher(db, sender, mailer.DefaultTemplates(), unsubscriber,
+			"feedback@smartatransit.com", 20, 5, func() time.Time { return now })
+		sent, callErr = dispatcher.Dispatch(context.Background(), log)
+	})
+
+	It("sends due emails until there are none left", func() {
+		Expect(callErr).To(BeNil())
+		Expect(sent).To(Equal(1))
+		Expect(db.ClaimEmailsCallCount()).To(Equal(2))
+
+		_, to, message := sender.SendArgsForCall(0)
+		Expect(to).To(Equal("rider@example.com"))
+		Expect(string(message)).To(ContainSubstring("service condition report about the red line"))
+		Expect(string(message)).To(ContainSubstring(unsubscriber.URL("rider@example.com")))
+
+		_, id := db.MarkEmailSentArgsForCall(0)
+		Expect(id).To(BeEquivalentTo(42))
+	})
+
+	When("the rider didn't opt in", func() {
+		BeforeEach(func() {
+			email.OptedIn = false
+		})
+		It("drops the email", func() {
+			Expect(sender.SendCallCount()).To(Equal(0))
+			_, id, reason := db.DropEmailArgsForCall(0)
+			Expect(id).To(BeEquivalentTo(42))
+			Expect(reason).To(Equal(mailer.DropNotOptedIn))
+		})
+	})
+
+	When("the feedback is gone", func() {
+		BeforeEach(func() {
+			email.Email = nil
+			email.Kind = nil
+		})
+		It("drops the email", func() {
+			Expect(sender.SendCallCount()).To(Equal(0))
+			_, _, reason := db.DropEmailArgsForCall(0)
+			Expect(reason).To(Equal(mailer.DropNoRecipient))
+		})
+	})
+
+	When("the address unsubscribed", func() {
+		BeforeEach(func() {
+			db.IsEmailUnsubscribedReturns(true, nil)
+		})
+		It("drops the email", func() {
+			Expect(sender.SendCallCount()).To(Equal(0))
+			_, digest := db.IsEmailUnsubscribedArgsForCall(0)
+			Expect(digest).To(Equal(unsubscriber.Digest("rider@example.com")))
+			_, _, reason := db.DropEmailArgsForCall(0)
+			Expect(reason).To(Equal(mailer.DropUnsubscribe))
+		})
+	})
+
+	When("sending fails", func() {
+		BeforeEach(func() {
+			email.Attempts = 3
+			sender.SendReturns(errors.New("connection refused"))
+		})
+		It("retries later with backoff", func() {
+			Expect(callErr).To(BeNil())
+			Expect(sent).To(Equal(0))
+
+			_, id, reason, retryAt := db.MarkEmailFailedArgsForCall(0)
+			Expect(id).To(BeEquivalentTo(42))
+			Expect(reason).To(Equal("connection refused"))
+			Expect(*retryAt).To(Equal(now.Add(4 * time.Minute)))
+		})
+	})
+
+	When("sending fails for the last time", func() {
+		BeforeEach(func() {
+			email.Attempts = 5
+			sender.SendReturns(errors.New("connection refused"))
+		})
+		It("gives up", func() {
+			_, _, _, retryAt := db.MarkEmailFailedArgsForCall(0)
+			Expect(retryAt).To(BeNil())
+		})
+	})
+
+	When("the relay rejects the recipient", func() {
+		BeforeEach(func() {
+			sender.SendReturns(&textproto.Error{Code: 550, Msg: "no such user"})
+		})
+		It("gives up", func() {
+			_, _, _, retryAt := db.MarkEmailFailedArgsForCall(0)
+			Expect(retryAt).To(BeNil())
+		})
+	})
+
+	When("emails can't be claimed", func() {
+		BeforeEach(func() {
+			db.ClaimEmailsReturnsOnCall(1, nil, errors.New("select failed"))
+		})
+		It("returns an error", func() {
+			Expect(callErr).To(MatchError("select failed"))
+			Expect(sent).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,141 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//Sender delivers a composed message to a single recipient
+//go:generate counterfeiter . Sender
+type Sender interface {
+	Send(ctx context.Context, to string, message []byte) error
+}
+
+//Message is an email ready to be composed
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+
+	//UnsubscribeURL is offered in the List-Unsubscribe header, so that mail
+	//clients can show an unsubscribe button
+	UnsubscribeURL string
+}
+
+//Compose formats m as a plain text RFC 5322 message sent at now
+func Compose(m Message, now time.Time) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed generating message ID: %w", err)
+	}
+	domain := "localhost"
+	if from, err := mail.ParseAddress(m.From); err == nil {
+		if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+			domain = from.Address[at+1:]
+		}
+	}
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		buf.WriteString(name + ": " + headerValue(value) + "\r\n")
+	}
+	header("From", m.From)
+	header("To", m.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain))
+	if m.UnsubscribeURL != "" {
+		header("List-Unsubscribe", "<"+m.UnsubscribeURL+">")
+		header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	body := strings.Replace(strings.Replace(m.Body, "\r\n", "\n", -1), "\n", "\r\n", -1)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, fmt.Errorf("failed encoding message body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return nil, fmt.Errorf("failed encoding message body: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+//headerValue drops line breaks, which would let a value start new headers
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+//Unsubscriber makes and checks the tokens in unsubscribe links. A token is
+//the address's keyed digest followed by a MAC of the digest, so tokens
+//can't be forged for an address, and unsubscribing stores no address.
+type Unsubscriber struct {
+	key     []byte
+	baseURL string
+}
+
+//MinUnsubscribeKeyLength is the shortest key NewUnsubscriber accepts
+const MinUnsubscribeKeyLength = 32
+
+//NewUnsubscriber returns an Unsubscriber signing with key, whose links point
+//to the API at baseURL
+func NewUnsubscriber(key []byte, baseURL string) (Unsubscriber, error) {
+	if len(key) < MinUnsubscribeKeyLength {
+		return Unsubscriber{}, fmt.Errorf("unsubscribe key must be at least %d bytes", MinUnsubscribeKeyLength)
+	}
+	return Unsubscriber{key: key, baseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+//Digest returns the keyed digest of an address, ignoring case and
+//surrounding whitespace
+func (u Unsubscriber) Digest(email string) string {
+	return u.mac("email:" + strings.ToLower(strings.TrimSpace(email)))
+}
+
+//Token returns the unsubscribe token of an address
+func (u Unsubscriber) Token(email string) string {
+	digest := u.Digest(email)
+	return digest + u.mac("token:" + digest)[:32]
+}
+
+//URL returns the unsubscribe link of an address
+func (u Unsubscriber) URL(email string) string {
+	return u.baseURL + "/v1/email/unsubscribe/" + u.Token(email)
+}
+
+var tokenRegexp = regexp.MustCompile(`^[0-9a-f]{96}$`)
+
+//Verify returns the digest carried by token, if token is genuine
+func (u Unsubscriber) Verify(token string) (string, bool) {
+	if !tokenRegexp.MatchString(token) {
+		return "", false
+	}
+
+	digest, mac := token[:64], token[64:]
+	if !hmac.Equal([]byte(mac), []byte(u.mac("token:" + digest)[:32])) {
+		return "", false
+	}
+	return digest, true
+}
+
+func (u Unsubscriber) mac(s string) string {
+	mac := hmac.New(sha256.New, u.key)
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
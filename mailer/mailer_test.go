@@ -0,0 +1,144 @@
+package mailer_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/mailer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compose", func() {
+	It("writes headers and a quoted-printable body", func() {
+		message, err := mailer.Compose(mailer.Message{
+			From:           "SMARTA <feedback@smartatransit.com>",
+			To:             "rider@example.com",
+			Subject:        "We received your feedback",
+			Body:           "Thank you.\nCafé line\n",
+			UnsubscribeURL: "https://example.com/v1/email/unsubscribe/token",
+		}, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+		Expect(err).To(BeNil())
+
+		text := string(message)
+		Expect(text).To(ContainSubstring("From: SMARTA <feedback@smartatransit.com>\r\n"))
+		Expect(text).To(ContainSubstring("To: rider@example.com\r\n"))
+		Expect(text).To(ContainSubstring("Date: Mon, 01 Jun 2020 12:00:00 +0000\r\n"))
+		Expect(text).To(MatchRegexp(`Message-ID: <[0-9a-f]{32}@smartatransit\.com>\r\n`))
+		Expect(text).To(ContainSubstring("List-Unsubscribe: <https://example.com/v1/email/unsubscribe/token>\r\n"))
+		Expect(text).To(ContainSubstring("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n"))
+		Expect(text).To(ContainSubstring("\r\n\r\nThank you.\r\nCaf=C3=A9 line\r\n"))
+	})
+
+	It("keeps values from starting new headers", func() {
+		message, err := mailer.Compose(mailer.Message{
+			From:    "feedback@smartatransit.com",
+			To:      "rider@example.com\r\nBcc: everyone@example.com",
+			Subject: "hello",
+		}, time.Now())
+		Expect(err).To(BeNil())
+		Expect(string(message)).NotTo(ContainSubstring("\r\nBcc:"))
+	})
+})
+
+var _ = Describe("Unsubscriber", func() {
+	var unsubscriber mailer.Unsubscriber
+
+	BeforeEach(func() {
+		var err error
+		unsubscriber, err = mailer.NewUnsubscriber(bytes.Repeat([]byte("k"), 32), "https://example.com/")
+		Expect(err).To(BeNil())
+	})
+
+	It("requires a long enough key", func() {
+		_, err := mailer.NewUnsubscriber([]byte("short"), "https://example.com")
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("makes links whose tokens verify as the address's digest", func() {
+		url := unsubscriber.URL(" Rider@Example.com ")
+		Expect(url).To(HavePrefix("https://example.com/v1/email/unsubscribe/"))
+
+		digest, ok := unsubscriber.Verify(strings.TrimPrefix(url, "https://example.com/v1/email/unsubscribe/"))
+		Expect(ok).To(BeTrue())
+		Expect(digest).To(Equal(unsubscriber.Digest("rider@example.com")))
+	})
+
+	It("rejects tokens made with another key", func() {
+		other, err := mailer.NewUnsubscriber(bytes.Repeat([]byte("x"), 32), "https://example.com")
+		Expect(err).To(BeNil())
+
+		_, ok := unsubscriber.Verify(other.Token("rider@example.com"))
+		Expect(ok).To(BeFalse())
+		_, ok = unsubscriber.Verify("not a token")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("Templates", func() {
+	data := mailer.Data{
+		Kind:           "service condition",
+		Line:           "red",
+		ReceivedAt:     time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC),
+		Reply:          "The elevator has been fixed.",
+		UnsubscribeURL: "https://example.com/unsubscribe",
+	}
+
+	It("renders the built-in templates", func() {
+		subject, body, err := mailer.DefaultTemplates().Render("reply", data)
+		Expect(err).To(BeNil())
+		Expect(subject).To(Equal("We replied to your feedback"))
+		Expect(body).To(ContainSubstring("service condition report"))
+		Expect(body).To(ContainSubstring("The elevator has been fixed."))
+		Expect(body).To(ContainSubstring("https://example.com/unsubscribe"))
+	})
+
+	It("fails for unknown templates", func() {
+		_, _, err := mailer.DefaultTemplates().Render("newsletter", data)
+		Expect(err).To(MatchError("unknown email template `newsletter`"))
+	})
+
+	It("loads overrides from a directory", func() {
+		dir, err := ioutil.TempDir("", "templates")
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "acknowledgement.subject.tmpl"), []byte("Thanks for your {{.Kind}} report"), 0600)).To(Succeed())
+
+		templates, err := mailer.LoadTemplates(dir)
+		Expect(err).To(BeNil())
+
+		subject, body, err := templates.Render("acknowledgement", data)
+		Expect(err).To(BeNil())
+		Expect(subject).To(Equal("Thanks for your service condition report"))
+		Expect(body).To(ContainSubstring("about the red line, received on June 1, 2020"))
+	})
+
+	It("fails to load templates that don't parse", func() {
+		dir, err := ioutil.TempDir("", "templates")
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "reply.body.tmpl"), []byte("{{.Reply"), 0600)).To(Succeed())
+
+		_, err = mailer.LoadTemplates(dir)
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("DirSender", func() {
+	It("writes each message to its own file", func() {
+		dir, err := ioutil.TempDir("", "mail")
+		Expect(err).To(BeNil())
+
+		sender, err := mailer.NewDirSender(filepath.Join(dir, "outbox"))
+		Expect(err).To(BeNil())
+		Expect(sender.Send(context.Background(), "rider@example.com", []byte("first"))).To(Succeed())
+		Expect(sender.Send(context.Background(), "rider@example.com", []byte("second"))).To(Succeed())
+
+		files, err := filepath.Glob(filepath.Join(dir, "outbox", "*.eml"))
+		Expect(err).To(BeNil())
+		Expect(files).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,153 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//smtpTimeout bounds an SMTP conversation when the context has no deadline
+const smtpTimeout = 30 * time.Second
+
+//SMTPSender delivers messages through an SMTP relay, upgrading to TLS when
+//the relay offers it
+type SMTPSender struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+//NewSMTPSender returns an SMTPSender relaying through addr as from, which
+//may include a display name. Without a username, it doesn't authenticate.
+func NewSMTPSender(addr, from, username, password string) SMTPSender {
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		from = parsed.Address
+	}
+	return SMTPSender{
+		addr:     addr,
+		from:     from,
+		username: username,
+		password: password,
+	}
+}
+
+//Send delivers message to to
+func (s SMTPSender) Send(ctx context.Context, to string, message []byte) error {
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address `%s`: %w", s.addr, err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed connecting to SMTP relay: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed setting SMTP deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed greeting SMTP relay: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("failed starting TLS with SMTP relay: %w", err)
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, host)); err != nil {
+			return fmt.Errorf("failed authenticating with SMTP relay: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return fmt.Errorf("failed sending email: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("failed sending email: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed sending email: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("failed sending email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed sending email: %w", err)
+	}
+
+	return client.Quit()
+}
+
+//IsPermanent reports whether err is an SMTP rejection that retrying won't
+//fix, such as an unknown mailbox
+func IsPermanent(err error) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code >= 500
+}
+
+//DirSender writes each message to a `.eml` file in a directory instead of
+//sending it, for development
+type DirSender struct {
+	dir string
+}
+
+//NewDirSender returns a DirSender writing to dir, creating it if needed
+func NewDirSender(dir string) (DirSender, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return DirSender{}, fmt.Errorf("failed creating email directory: %w", err)
+	}
+	return DirSender{dir: dir}, nil
+}
+
+//Send writes message to a new file named after the current time. The
+//recipient is only in the message's headers.
+func (s DirSender) Send(ctx context.Context, to string, message []byte) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed naming email file: %w", err)
+	}
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + hex.EncodeToString(suffix) + ".eml"
+
+	tmp, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed writing email: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(message); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed writing email: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed writing email: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("failed writing email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+//Data is what templates can refer to
+type Data struct {
+	Kind       string
+	Line       string
+	ReceivedAt time.Time
+
+	//Reply is the staff message of a reply email
+	Reply string
+
+	UnsubscribeURL string
+}
+
+//Template is the subject and body of an email
+type Template struct {
+	Subject *template.Template
+	Body    *template.Template
+}
+
+//Templates maps template names, the db.EmailTemplate* constants, to
+//templates
+type Templates map[string]Template
+
+const footer = `
+--
+You are receiving this because you asked to be emailed about your feedback to MARTA through SMARTA.
+To stop receiving these emails, visit {{.UnsubscribeURL}}
+`
+
+var defaultTemplates = map[string][2]string{
+	db.EmailTemplateAcknowledgement: {
+		`We received your feedback`,
+		`Thank you for your {{.Kind}} report{{if .Line}} about the {{.Line}} line{{end}}, received on {{.ReceivedAt.Format "January 2, 2006"}}.
+
+We read every report. If we need to know more, we will write to you here.
+` + footer,
+	},
+	db.EmailTemplateOutageResolved: {
+		`The outage you reported has been resolved`,
+		`The outage you reported{{if .Line}} on the {{.Line}} line{{end}} on {{.ReceivedAt.Format "January 2, 2006"}} has been resolved.
+
+Thank you for letting us know.
+` + footer,
+	},
+	db.EmailTemplateReply: {
+		`We replied to your feedback`,
+		`SMARTA staff replied to your {{.Kind}} report:
+
+{{.Reply}}
+
+You can answer in the SMARTA app.
+` + footer,
+	},
+}
+
+//DefaultTemplates returns the built-in templates
+func DefaultTemplates() Templates {
+	templates := Templates{}
+	for name, t := range defaultTemplates {
+		templates[name] = Template{
+			Subject: template.Must(template.New(name + ".subject").Parse(t[0])),
+			Body:    template.Must(template.New(name + ".body").Parse(t[1])),
+		}
+	}
+	return templates
+}
+
+//LoadTemplates returns the built-in templates, replacing the subject or
+//body of any that have a `<name>.subject.tmpl` or `<name>.body.tmpl` file in
+//dir
+func LoadTemplates(dir string) (Templates, error) {
+	templates := DefaultTemplates()
+	for name, t := range templates {
+		for _, part := range []string{"subject", "body"} {
+			path := filepath.Join(dir, name+"."+part+".tmpl")
+			text, err := ioutil.ReadFile(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed reading email template: %w", err)
+			}
+
+			parsed, err := template.New(name + "." + part).Parse(string(text))
+			if err != nil {
+				return nil, fmt.Errorf("failed parsing email template `%s`: %w", path, err)
+			}
+			if part == "subject" {
+				t.Subject = parsed
+			} else {
+				t.Body = parsed
+			}
+		}
+		templates[name] = t
+	}
+	return templates, nil
+}
+
+//Render returns the subject and body of the named template
+func (t Templates) Render(name string, data Data) (subject, body string, err error) {
+	tmpl, ok := t[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown email template `%s`", name)
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Subject.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("failed rendering subject of email template `%s`: %w", name, err)
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err = tmpl.Body.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("failed rendering body of email template `%s`: %w", name, err)
+	}
+	body = buf.String()
+
+	return subject, body, nil
+}
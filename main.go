@@ -3,17 +3,41 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	migrate "github.com/golang-migrate/migrate/v4"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/sirupsen/logrus"
 
 	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/attachment"
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/authz"
+	"github.com/smartatransit/feedback/blob"
+	"github.com/smartatransit/feedback/clientinfo"
 	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/encryption"
+	"github.com/smartatransit/feedback/gatewayauth"
+	"github.com/smartatransit/feedback/gtfs"
+	"github.com/smartatransit/feedback/kinds"
+	"github.com/smartatransit/feedback/logging"
+	"github.com/smartatransit/feedback/mailer"
+	"github.com/smartatransit/feedback/metrics"
+	"github.com/smartatransit/feedback/partition"
+	"github.com/smartatransit/feedback/ratelimit"
+	"github.com/smartatransit/feedback/redact"
+	"github.com/smartatransit/feedback/retention"
+	"github.com/smartatransit/feedback/spam"
+	"github.com/smartatransit/feedback/tracing"
 
 	"github.com/golang-migrate/migrate/v4/database/postgres" //provides the postgres driver for migrations
 	_ "github.com/golang-migrate/migrate/v4/source/file"     //provides the driver for filesystem-backed migrations
@@ -24,18 +48,102 @@ var opts struct {
 	PostgresURL               string `long:"postgres-url" env:"POSTGRES_URL" required:"true"`
 	MigrationsPath            string `long:"migrations-path" env:"MIGRATIONS_PATH" default:"/db-migrations/"`
 	OutageReportAlertTTLHours int    `long:"outage-report-alert-ttl-hours" env:"OUTAGE_REPORT_ALERT_TTL_HOURS" default:"48"`
+
+	TraceExporter     string  `long:"trace-exporter" env:"TRACE_EXPORTER" default:"none" choice:"none" choice:"stdout" choice:"file" choice:"otlp"`
+	TraceFile         string  `long:"trace-file" env:"TRACE_FILE" default:"traces.ndjson"`
+	TraceOTLPEndpoint string  `long:"trace-otlp-endpoint" env:"TRACE_OTLP_ENDPOINT" default:"http://localhost:4318/v1/traces"`
+	TraceSampleRatio  float64 `long:"trace-sample-ratio" env:"TRACE_SAMPLE_RATIO" default:"1"`
+
+	RateLimitStore         string        `long:"rate-limit-store" env:"RATE_LIMIT_STORE" default:"memory" choice:"none" choice:"memory" choice:"postgres"`
+	RateLimitPolicy        string        `long:"rate-limit-policy" env:"RATE_LIMIT_POLICY" default:"*:outage=10/1h,*:*=60/1h"`
+	RateLimitPruneInterval time.Duration `long:"rate-limit-prune-interval" env:"RATE_LIMIT_PRUNE_INTERVAL" default:"1h"`
+
+	SpamThreshold       float64       `long:"spam-threshold" env:"SPAM_THRESHOLD" default:"1"`
+	SpamAction          string        `long:"spam-action" env:"SPAM_ACTION" default:"hold" choice:"hold" choice:"silence"`
+	SpamBlocklistPath   string        `long:"spam-blocklist-path" env:"SPAM_BLOCKLIST_PATH"`
+	SpamMaxLength       int           `long:"spam-max-length" env:"SPAM_MAX_LENGTH" default:"2000"`
+	SpamMaxLinks        int           `long:"spam-max-links" env:"SPAM_MAX_LINKS" default:"1"`
+	SpamDuplicateWindow time.Duration `long:"spam-duplicate-window" env:"SPAM_DUPLICATE_WINDOW" default:"24h"`
+
+	AuthzPolicyFile       string   `long:"authz-policy-file" env:"AUTHZ_POLICY_FILE"`
+	AdminRoles            []string `long:"admin-role" env:"ADMIN_ROLES" env-delim:"," default:"admin"`
+	ModerationBypassKinds []string `long:"moderation-bypass-kind" env:"MODERATION_BYPASS_KINDS" env-delim:"," default:"outage" default:"service_condition"`
+	ModerationBypassRoles []string `long:"moderation-bypass-role" env:"MODERATION_BYPASS_ROLES" env-delim:","`
+
+	KeepOriginalMessages bool `long:"keep-original-messages" env:"KEEP_ORIGINAL_MESSAGES"`
+
+	EmailKeys           string `long:"email-keys" env:"EMAIL_KEYS"`
+	EmailKeyFile        string `long:"email-key-file" env:"EMAIL_KEY_FILE"`
+	EmailKeyID          string `long:"email-key-id" env:"EMAIL_KEY_ID"`
+	EmailIndexKey       string `long:"email-index-key" env:"EMAIL_INDEX_KEY"`
+	RotateKeysBatchSize int    `long:"rotate-keys-batch-size" env:"ROTATE_KEYS_BATCH_SIZE" default:"500"`
+
+	RetentionPurgeInterval  time.Duration `long:"retention-purge-interval" env:"RETENTION_PURGE_INTERVAL" default:"24h"`
+	RetentionPurgeBatchSize int           `long:"retention-purge-batch-size" env:"RETENTION_PURGE_BATCH_SIZE" default:"1000"`
+	RetentionDryRun         bool          `long:"retention-dry-run" env:"RETENTION_DRY_RUN"`
+
+	PartitionMonthsAhead   int           `long:"partition-months-ahead" env:"PARTITION_MONTHS_AHEAD" default:"3"`
+	PartitionCheckInterval time.Duration `long:"partition-check-interval" env:"PARTITION_CHECK_INTERVAL" default:"24h"`
+	ArchiveDir             string        `long:"archive-dir" env:"ARCHIVE_DIR" default:"archive"`
+
+	GatewaySigningKeys     string        `long:"gateway-signing-keys" env:"GATEWAY_SIGNING_KEYS"`
+	GatewaySigningKeyFile  string        `long:"gateway-signing-key-file" env:"GATEWAY_SIGNING_KEY_FILE"`
+	GatewaySignatureWindow time.Duration `long:"gateway-signature-window" env:"GATEWAY_SIGNATURE_WINDOW" default:"5m"`
+
+	KindsRefreshInterval time.Duration `long:"kinds-refresh-interval" env:"KINDS_REFRESH_INTERVAL" default:"1m"`
+
+	GTFSPath   string  `long:"gtfs-path" env:"GTFS_PATH"`
+	StopRadius float64 `long:"stop-radius" env:"STOP_RADIUS" default:"250"`
+
+	ClientHeaders string `long:"client-headers" env:"CLIENT_HEADERS" default:"app_version=X-App-Version,platform=X-App-Platform,os_version=X-OS-Version,locale=Accept-Language"`
+
+	AttachmentDir            string        `long:"attachment-dir" env:"ATTACHMENT_DIR"`
+	AttachmentMaxBytes       int64         `long:"attachment-max-bytes" env:"ATTACHMENT_MAX_BYTES" default:"10485760"`
+	AttachmentMaxDimension   int           `long:"attachment-max-dimension" env:"ATTACHMENT_MAX_DIMENSION" default:"8192"`
+	AttachmentMaxPixels      int           `long:"attachment-max-pixels" env:"ATTACHMENT_MAX_PIXELS" default:"24000000"`
+	AttachmentThumbnailSize  int           `long:"attachment-thumbnail-size" env:"ATTACHMENT_THUMBNAIL_SIZE" default:"320"`
+	AttachmentMaxConcurrent  int           `long:"attachment-max-concurrent" env:"ATTACHMENT_MAX_CONCURRENT" default:"2"`
+	AttachmentUnattachedTTL  time.Duration `long:"attachment-unattached-ttl" env:"ATTACHMENT_UNATTACHED_TTL" default:"24h"`
+	AttachmentSweepInterval  time.Duration `long:"attachment-sweep-interval" env:"ATTACHMENT_SWEEP_INTERVAL" default:"1h"`
+	AttachmentSweepBatchSize int           `long:"attachment-sweep-batch-size" env:"ATTACHMENT_SWEEP_BATCH_SIZE" default:"100"`
+
+	AuditVerifyBatchSize int `long:"audit-verify-batch-size" env:"AUDIT_VERIFY_BATCH_SIZE" default:"1000"`
+
+	MailSender         string        `long:"mail-sender" env:"MAIL_SENDER" default:"none" choice:"none" choice:"smtp" choice:"dir"`
+	MailFrom           string        `long:"mail-from" env:"MAIL_FROM" default:"SMARTA <feedback@smartatransit.com>"`
+	MailDir            string        `long:"mail-dir" env:"MAIL_DIR" default:"mail"`
+	MailTemplateDir    string        `long:"mail-template-dir" env:"MAIL_TEMPLATE_DIR"`
+	MailUnsubscribeKey string        `long:"mail-unsubscribe-key" env:"MAIL_UNSUBSCRIBE_KEY"`
+	MailBaseURL        string        `long:"mail-base-url" env:"MAIL_BASE_URL" default:"https://api.smartatransit.com/feedback"`
+	MailSendInterval   time.Duration `long:"mail-send-interval" env:"MAIL_SEND_INTERVAL" default:"30s"`
+	MailBatchSize      int           `long:"mail-batch-size" env:"MAIL_BATCH_SIZE" default:"20"`
+	MailMaxAttempts    int           `long:"mail-max-attempts" env:"MAIL_MAX_ATTEMPTS" default:"5"`
+	SMTPAddr           string        `long:"smtp-addr" env:"SMTP_ADDR" default:"localhost:587"`
+	SMTPUsername       string        `long:"smtp-username" env:"SMTP_USERNAME"`
+	SMTPPassword       string        `long:"smtp-password" env:"SMTP_PASSWORD"`
+
+	Operator string `long:"operator" env:"USER" default:"unknown" description:"who to record as requesting CLI operations"`
 }
 
 func main() {
-	_, err := flags.Parse(&opts)
+	args, err := flags.Parse(&opts)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	var command string
+	if len(args) > 0 {
+		command = args[0]
+	}
+
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
 	logger.SetOutput(os.Stdout)
 	logger.SetLevel(logrus.InfoLevel)
+	if command != "" {
+		//keep stdout clean for command output
+		logger.SetOutput(os.Stderr)
+	}
 
 	database, err := sql.Open("postgres", opts.PostgresURL)
 	if err != nil {
@@ -58,20 +166,478 @@ func main() {
 		log.Fatal()
 	}
 
-	dbClient := db.New(database, migrator)
+	exporter, err := tracing.NewExporter(context.Background(), opts.TraceExporter, opts.TraceFile, opts.TraceOTLPEndpoint)
+	if err != nil {
+		logger.Errorf("failed to set up trace exporter: %s", err.Error())
+		log.Fatal()
+	}
+
+	m := metrics.New()
+
+	driver := m.InstrumentDBDriver(db.NewDriver(database), db.StatementNames)
+	var tracer *tracing.Tracer
+	flushTraces := func() {}
+	if exporter != nil {
+		tracer = tracing.New(exporter, "feedback", opts.TraceSampleRatio)
+		flushTraces = func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tracer.Shutdown(ctx); err != nil {
+				logger.Errorf("failed to flush traces: %s", err.Error())
+			}
+		}
+		defer flushTraces()
+
+		driver = tracing.InstrumentDBDriver(driver, db.StatementNames)
+	}
 
-	apiClient := api.New(logger, dbClient)
+	//log.Fatal skips deferred calls, so buffered spans are flushed first
+	fatal := func() {
+		flushTraces()
+		log.Fatal()
+	}
+
+	keyring, err := loadEmailKeyring()
+	if err != nil {
+		logger.Errorf("failed to load email encryption keys: %s", err.Error())
+		fatal()
+	}
+
+	verifier, err := loadGatewayVerifier()
+	if err != nil {
+		logger.Errorf("failed to load gateway signing keys: %s", err.Error())
+		fatal()
+	}
+
+	dbClient := db.New(driver, migrator)
+	if keyring != nil {
+		dbClient = dbClient.WithEmailCipher(*keyring)
+	}
+
+	auditLog := audit.New(dbClient, time.Now)
+	auditRecorder := m.InstrumentAuditRecorder(auditLog)
+	catalog := kinds.New(dbClient, logger)
+	apiClient := api.New(logger, m.InstrumentDB(dbClient)).
+		WithAuditLog(auditRecorder).
+		WithCatalog(catalog).
+		WithOutageTTL(time.Duration(opts.OutageReportAlertTTLHours) * time.Hour)
+
+	var bucketPruner *ratelimit.PostgresStore
+	var bucketIdle time.Duration
+	if opts.RateLimitStore != "none" {
+		policy, err := ratelimit.ParsePolicy(opts.RateLimitPolicy)
+		if err != nil {
+			logger.Errorf("failed to parse rate limit policy: %s", err.Error())
+			fatal()
+		}
+
+		var store ratelimit.Store = ratelimit.NewMemoryStore(time.Now)
+		if opts.RateLimitStore == "postgres" {
+			pgStore := ratelimit.NewPostgresStore(dbClient)
+			bucketPruner, bucketIdle = &pgStore, policy.LongestPeriod()
+			store = pgStore
+		}
+
+		apiClient = apiClient.WithRateLimiter(ratelimit.New(store, policy))
+	}
+
+	policy, err := loadAuthzPolicy()
+	if err != nil {
+		logger.Errorf("failed to load authorization policy: %s", err.Error())
+		fatal()
+	}
+
+	apiClient = apiClient.
+		WithAuthorization(policy).
+		WithModerationPolicy(api.NewModerationPolicy(opts.ModerationBypassKinds, opts.ModerationBypassRoles)).
+		WithRedaction(redact.New(), opts.KeepOriginalMessages)
+
+	var blocklist []string
+	if opts.SpamBlocklistPath != "" {
+		f, err := os.Open(opts.SpamBlocklistPath)
+		if err != nil {
+			logger.Errorf("failed to open spam blocklist: %s", err.Error())
+			fatal()
+		}
+		blocklist, err = spam.ReadBlocklist(f)
+		f.Close()
+		if err != nil {
+			logger.Errorf("failed to read spam blocklist: %s", err.Error())
+			fatal()
+		}
+	}
+
+	if opts.GTFSPath != "" {
+		stops, err := gtfs.LoadStops(opts.GTFSPath)
+		if err != nil {
+			logger.Errorf("failed to load GTFS stops: %s", err.Error())
+			fatal()
+		}
+		apiClient = apiClient.WithStopResolver(gtfs.NewIndex(stops, opts.StopRadius))
+	}
+
+	clientHeaders, err := clientinfo.ParseAllowlist(opts.ClientHeaders)
+	if err != nil {
+		logger.Errorf("failed to parse client headers: %s", err.Error())
+		fatal()
+	}
+	apiClient = apiClient.WithClientHeaders(clientHeaders)
+
+	var sweeper *attachment.Sweeper
+	if opts.AttachmentDir != "" {
+		store, err := blob.NewFileStore(opts.AttachmentDir)
+		if err != nil {
+			logger.Errorf("failed to open attachment store: %s", err.Error())
+			fatal()
+		}
+
+		apiClient = apiClient.WithAttachments(store, attachment.Limits{
+			MaxBytes:      opts.AttachmentMaxBytes,
+			MaxDimension:  opts.AttachmentMaxDimension,
+			MaxPixels:     opts.AttachmentMaxPixels,
+			ThumbnailSize: opts.AttachmentThumbnailSize,
+			MaxConcurrent: opts.AttachmentMaxConcurrent,
+		})
+
+		s := attachment.NewSweeper(dbClient, store, opts.AttachmentUnattachedTTL, opts.AttachmentSweepBatchSize, time.Now)
+		sweeper = &s
+	}
+
+	var dispatcher *mailer.Dispatcher
+	if opts.MailSender != "none" {
+		d, unsubscriber, err := loadMailer(dbClient)
+		if err != nil {
+			logger.Errorf("failed to configure email: %s", err.Error())
+			fatal()
+		}
+		apiClient = apiClient.WithEmail(unsubscriber)
+		dispatcher = &d
+	}
+
+	apiClient = apiClient.WithSpamScorer(spam.NewPipeline(
+		opts.SpamThreshold,
+		spam.Action(opts.SpamAction),
+		spam.NewDuplicateRule(dbClient, opts.SpamDuplicateWindow, time.Now),
+		spam.NewLinkRule(opts.SpamMaxLinks),
+		spam.NewLengthRule(opts.SpamMaxLength),
+		spam.NewBlocklistRule(blocklist),
+	))
+
+	m.RegisterRecentOutagesGauge(func() float64 {
+		since := time.Now().Add(-time.Duration(opts.OutageReportAlertTTLHours) * time.Hour)
+		outages, err := dbClient.GetRecentOutages(context.Background(), since)
+		if err != nil {
+			logger.Errorf("failed counting recent outages for metrics: %s", err.Error())
+			return math.NaN()
+		}
+		return float64(len(outages))
+	})
 
 	err = dbClient.Migrate(context.Background())
 	if err != nil && !strings.Contains(err.Error(), "no change") {
 		logger.Errorf("failed to execute pending migrations: %s", err.Error())
-		log.Fatal()
+		fatal()
+	}
+
+	if err := catalog.Refresh(context.Background()); err != nil {
+		logger.Errorf("failed to load feedback kinds: %s", err.Error())
+		fatal()
+	}
+
+	partitions := partition.New(dbClient, opts.PartitionMonthsAhead, time.Now)
+	purger := retention.New(dbClient, opts.RetentionPurgeBatchSize, time.Now).WithRecorder(m)
+
+	switch command {
+	case "":
+	case "rotate-keys":
+		if keyring == nil {
+			logger.Error("rotate-keys requires email encryption keys to be configured")
+			fatal()
+		}
+
+		rotated, err := encryption.RotateEmails(context.Background(), dbClient, *keyring, opts.RotateKeysBatchSize)
+		if err != nil {
+			logger.Errorf("failed to rotate email encryption keys after %d rows: %s", rotated, err.Error())
+			fatal()
+		}
+		logger.Infof("re-encrypted %d emails under key `%s`", rotated, keyring.CurrentKeyID())
+		return
+	case "purge":
+		results, err := purger.
+			WithAuditLog(auditRecorder, opts.Operator, "cli").
+			Purge(context.Background(), opts.RetentionDryRun)
+		retention.LogResults(logger, results, opts.RetentionDryRun)
+		if err != nil {
+			logger.Errorf("failed to enforce retention policies: %s", err.Error())
+			fatal()
+		}
+		return
+	case "archive":
+		//archive <YYYY-MM>
+		if len(args) != 2 {
+			logger.Error("usage: archive <YYYY-MM>")
+			fatal()
+		}
+
+		month, err := partition.ParseMonth(args[1])
+		if err != nil {
+			logger.Error(err.Error())
+			fatal()
+		}
+
+		path, count, err := partitions.
+			WithAuditLog(auditRecorder, opts.Operator, "cli").
+			Archive(context.Background(), month, opts.ArchiveDir)
+		if err != nil {
+			logger.Errorf("failed to archive %s to %s: %s", args[1], path, err.Error())
+			fatal()
+		}
+		logger.Infof("archived %d feedbacks from %s to %s", count, args[1], path)
+		return
+	case "verify-audit":
+		report, err := auditLog.Verify(context.Background(), opts.AuditVerifyBatchSize)
+		if err != nil {
+			logger.Errorf("failed to verify audit log after %d events: %s", report.Events, err.Error())
+			fatal()
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+		if len(report.Problems) > 0 {
+			logger.Errorf("audit log has been tampered with: %d problems found", len(report.Problems))
+			fatal()
+		}
+		return
+	case "rider-data":
+		//rider-data <export|delete|anonymize> <session|email> <value>
+		if len(args) != 4 || (args[2] != "session" && args[2] != "email") {
+			logger.Error("usage: rider-data <export|delete|anonymize> <session|email> <value>")
+			fatal()
+		}
+
+		req := api.RiderDataRequest{Action: args[1]}
+		if args[2] == "session" {
+			req.SessionID = args[3]
+		} else {
+			req.Email = args[3]
+		}
+
+		resp, err := apiClient.ProcessRiderDataRequest(context.Background(), req, opts.Operator, "cli", "cli")
+		if err != nil {
+			logger.Errorf("failed to process rider data request: %s", err.Error())
+			fatal()
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(resp)
+		return
+	default:
+		logger.Errorf("unknown command `%s`", command)
+		fatal()
+	}
+
+	//without a partition for the current month every submission would fail
+	if err := partitions.EnsureFuture(context.Background()); err != nil {
+		logger.Errorf("failed to create feedback partitions: %s", err.Error())
+		fatal()
+	}
+	if opts.PartitionCheckInterval > 0 {
+		go partitions.Run(context.Background(), logger, opts.PartitionCheckInterval)
+	}
+	if opts.KindsRefreshInterval > 0 {
+		go catalog.Run(context.Background(), logger, opts.KindsRefreshInterval)
+	}
+	if opts.RetentionPurgeInterval > 0 {
+		go purger.
+			WithAuditLog(auditRecorder, "retention-purger", "system").
+			Run(context.Background(), logger, opts.RetentionPurgeInterval, opts.RetentionDryRun)
+	}
+	if sweeper != nil && opts.AttachmentSweepInterval > 0 {
+		go sweeper.Run(context.Background(), logger, opts.AttachmentSweepInterval)
+	}
+	if dispatcher != nil && opts.MailSendInterval > 0 {
+		go dispatcher.Run(context.Background(), logger, opts.MailSendInterval)
+	}
+	if bucketPruner != nil && opts.RateLimitPruneInterval > 0 {
+		go bucketPruner.Run(context.Background(), logger, opts.RateLimitPruneInterval, bucketIdle)
 	}
 
 	srv := http.NewServeMux()
-	srv.HandleFunc("/v1/feedback", apiClient.SaveFeedback)
+	srv.HandleFunc("/v1/feedback", apiClient.Require(authz.Submit, apiClient.SaveFeedback))
+	srv.HandleFunc("/v1/feedback/kinds", apiClient.FeedbackKinds)
+	srv.HandleFunc("/v1/feedback/attachments", apiClient.Require(authz.Submit, apiClient.UploadAttachment))
+	srv.HandleFunc("/v1/feedback/threads", apiClient.Require(authz.Submit, apiClient.Threads))
+	srv.HandleFunc("/v1/feedback/threads/", apiClient.Require(authz.Submit, apiClient.Thread))
+	srv.HandleFunc("/v1/surveys/", apiClient.Require(authz.Submit, apiClient.Survey))
 	srv.HandleFunc("/v1/health", apiClient.Health)
+	srv.HandleFunc("/v1/email/unsubscribe/", apiClient.Unsubscribe)
+	srv.HandleFunc("/v1/admin/moderation", apiClient.Require(authz.Moderate, apiClient.ModerationQueue))
+	srv.HandleFunc("/v1/admin/moderation/", apiClient.Require(authz.Moderate, apiClient.Moderation))
+	srv.HandleFunc("/v1/admin/silence/", apiClient.Require(authz.Silence, apiClient.Silence))
+	srv.HandleFunc("/v1/admin/feedback", apiClient.Require(authz.Read, apiClient.ListFeedback))
+	srv.HandleFunc("/v1/admin/feedback/breakdown", apiClient.Require(authz.Read, apiClient.FeedbackBreakdown))
+	srv.HandleFunc("/v1/admin/feedback/", apiClient.Require(authz.Read, apiClient.AdminFeedback))
+	srv.HandleFunc("/v1/admin/attachments/", apiClient.Require(authz.Read, apiClient.Attachment))
+	srv.HandleFunc("/v1/admin/health", apiClient.Require(authz.Read, apiClient.AdminHealth))
+	srv.HandleFunc("/v1/admin/rider-data", apiClient.Require(authz.Export, apiClient.RiderData))
+	srv.HandleFunc("/v1/admin/retention", apiClient.Require(authz.Read, apiClient.RetentionPolicies))
+	srv.HandleFunc("/v1/admin/retention/", apiClient.Require(authz.Delete, apiClient.RetentionPolicy))
+	srv.HandleFunc("/v1/admin/audit", apiClient.Require(authz.Read, apiClient.AuditEvents))
+	srv.HandleFunc("/v1/admin/surveys/", apiClient.Require(authz.Read, apiClient.AdminSurvey))
+	srv.HandleFunc("/v1/admin/threads", apiClient.Require(authz.Read, apiClient.AdminThreads))
+	srv.HandleFunc("/v1/admin/triage", apiClient.Require(authz.Triage, apiClient.BulkTriage))
+	srv.HandleFunc("/v1/admin/outages/resolved", apiClient.Require(authz.Reply, apiClient.OutageResolved))
+	srv.Handle("/metrics", m.Handler())
+
+	handler := m.InstrumentMux(srv)
+	if verifier != nil {
+		handler = verifier.Middleware(logger, handler)
+	}
+	if tracer != nil {
+		handler = tracer.Middleware(srv, handler)
+	}
+	handler = logging.Middleware(logger, handler)
 
 	logger.Info("Starting API...")
-	_ = http.ListenAndServe(":8080", srv)
+	_ = http.ListenAndServe(":8080", handler)
+}
+
+//loadEmailKeyring builds the email encryption keyring from the EMAIL_*
+//options. It returns nil if no keys are configured.
+func loadEmailKeyring() (*encryption.Keyring, error) {
+	keys, err := readKeys(opts.EmailKeys, opts.EmailKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	if opts.EmailKeyID == "" {
+		return nil, errors.New("EMAIL_KEY_ID is required when encryption keys are configured")
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(opts.EmailIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed decoding EMAIL_INDEX_KEY: %w", err)
+	}
+
+	keyring, err := encryption.New(keys, opts.EmailKeyID, indexKey)
+	if err != nil {
+		return nil, err
+	}
+	return &keyring, nil
+}
+
+//loadAuthzPolicy reads the policy in AUTHZ_POLICY_FILE. Without one, every
+//role may submit feedback and the roles in ADMIN_ROLES hold every permission.
+func loadAuthzPolicy() (authz.Policy, error) {
+	if opts.AuthzPolicyFile == "" {
+		policy := authz.NewPolicy().Grant(authz.Wildcard, authz.Submit)
+		for _, role := range opts.AdminRoles {
+			policy = policy.Grant(role, authz.Permissions...)
+		}
+		return policy, nil
+	}
+
+	f, err := os.Open(opts.AuthzPolicyFile)
+	if err != nil {
+		return authz.Policy{}, err
+	}
+	defer f.Close()
+
+	return authz.ReadPolicy(f)
+}
+
+//loadGatewayVerifier builds the gateway signature verifier from the
+//GATEWAY_* options. It returns nil if no keys are configured, in which case
+//identity headers are trusted as they are.
+func loadGatewayVerifier() (*gatewayauth.Verifier, error) {
+	keys, err := readKeys(opts.GatewaySigningKeys, opts.GatewaySigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	verifier, err := gatewayauth.New(keys, opts.GatewaySignatureWindow, time.Now)
+	if err != nil {
+		return nil, err
+	}
+	return &verifier, nil
+}
+
+//readKeys merges the `id:base64key` pairs in list with those in the file at
+//path, if any
+func readKeys(list, path string) (map[string][]byte, error) {
+	keys, err := encryption.ParseKeys(list)
+	if err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		fileKeys, err := encryption.ReadKeyFile(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		for id, key := range fileKeys {
+			keys[id] = key
+		}
+	}
+
+	return keys, nil
+}
+
+//loadMailer builds the email dispatcher configured by the MAIL_* and SMTP_*
+//options, and the unsubscriber whose links it puts in emails
+func loadMailer(database db.DB) (mailer.Dispatcher, mailer.Unsubscriber, error) {
+	if opts.MailUnsubscribeKey == "" {
+		return mailer.Dispatcher{}, mailer.Unsubscriber{}, errors.New("MAIL_UNSUBSCRIBE_KEY is required to send email")
+	}
+	key, err := base64.StdEncoding.DecodeString(opts.MailUnsubscribeKey)
+	if err != nil {
+		return mailer.Dispatcher{}, mailer.Unsubscriber{}, fmt.Errorf("invalid MAIL_UNSUBSCRIBE_KEY: %w", err)
+	}
+	unsubscriber, err := mailer.NewUnsubscriber(key, opts.MailBaseURL)
+	if err != nil {
+		return mailer.Dispatcher{}, mailer.Unsubscriber{}, err
+	}
+
+	templates := mailer.DefaultTemplates()
+	if opts.MailTemplateDir != "" {
+		if templates, err = mailer.LoadTemplates(opts.MailTemplateDir); err != nil {
+			return mailer.Dispatcher{}, mailer.Unsubscriber{}, err
+		}
+	}
+
+	var sender mailer.Sender
+	switch opts.MailSender {
+	case "smtp":
+		sender = mailer.NewSMTPSender(opts.SMTPAddr, opts.MailFrom, opts.SMTPUsername, opts.SMTPPassword)
+	case "dir":
+		if sender, err = mailer.NewDirSender(opts.MailDir); err != nil {
+			return mailer.Dispatcher{}, mailer.Unsubscriber{}, err
+		}
+	}
+
+	dispatcher := mailer.NewDispatcher(
+		database,
+		sender,
+		templates,
+		unsubscriber,
+		opts.MailFrom,
+		opts.MailBatchSize,
+		opts.MailMaxAttempts,
+		time.Now,
+	)
+	return dispatcher, unsubscriber, nil
 }
@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/db"
+)
+
+//Metrics holds the service's instrumentation and exposes it on an HTTP handler
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpDuration        *prometheus.HistogramVec
+	dbDuration          *prometheus.HistogramVec
+	feedbackSubmissions *prometheus.CounterVec
+	validationFailures  *prometheus.CounterVec
+	retentionPurged     *prometheus.CounterVec
+	auditFailures       *prometheus.CounterVec
+}
+
+//New returns a new Metrics with every metric registered on its own registry,
+//so tests and multiple instances don't collide on the global one
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feedback_http_requests_total",
+			Help: "HTTP requests handled, by route, method and status code",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "feedback_http_request_duration_seconds",
+			Help:    "HTTP request latency, by route, method and status code",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "feedback_db_query_duration_seconds",
+			Help:    "Database statement latency, by statement and outcome",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"statement", "outcome"}),
+		feedbackSubmissions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feedback_submissions_total",
+			Help: "Feedback records saved, by kind and value",
+		}, []string{"kind", "value"}),
+		validationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feedback_validation_failures_total",
+			Help: "Feedback submissions rejected, by reason",
+		}, []string{"reason"}),
+		retentionPurged: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feedback_retention_purged_rows_total",
+			Help: "Feedback rows purged by retention policies, by kind and action",
+		}, []string{"kind", "action"}),
+		auditFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "feedback_audit_failures_total",
+			Help: "Changes rolled back because they couldn't be recorded in the audit log, by action",
+		}, []string{"action"}),
+	}
+
+	m.registry.MustRegister(
+		m.httpRequests,
+		m.httpDuration,
+		m.dbDuration,
+		m.feedbackSubmissions,
+		m.validationFailures,
+		m.retentionPurged,
+		m.auditFailures,
+	)
+
+	return m
+}
+
+//Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+//RegisterRecentOutagesGauge exposes the number of unsilenced recent outage
+//reports, as computed by count at scrape time
+func (m *Metrics) RegisterRecentOutagesGauge(count func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "feedback_recent_outage_reports",
+		Help: "Unsilenced outage reports received within the alert TTL",
+	}, count))
+}
+
+//RecordPurged implements retention.Recorder
+func (m *Metrics) RecordPurged(kind, action string, rows int) {
+	m.retentionPurged.WithLabelValues(kind, action).Add(float64(rows))
+}
+
+//InstrumentAuditRecorder counts the events recorder fails to record. Each
+//one is a change missing from the audit log, so it should be alerted on.
+func (m *Metrics) InstrumentAuditRecorder(recorder audit.Recorder) audit.Recorder {
+	return instrumentedAuditRecorder{
+		Recorder: recorder,
+		metrics:  m,
+	}
+}
+
+type instrumentedAuditRecorder struct {
+	audit.Recorder
+	metrics *Metrics
+}
+
+func (r instrumentedAuditRecorder) Record(ctx context.Context, entry audit.Entry) error {
+	err := r.Recorder.Record(ctx, entry)
+	if err != nil {
+		r.metrics.auditFailures.WithLabelValues(entry.Action).Inc()
+	}
+	return err
+}
+
+//InstrumentMux records request counts and latencies for every request served
+//by mux, labelled by the mux pattern that matched
+func (m *Metrics) InstrumentMux(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK, metrics: m}
+		start := time.Now()
+		mux.ServeHTTP(rec, r)
+
+		status := strconv.Itoa(rec.status)
+		m.httpRequests.WithLabelValues(route, r.Method, status).Inc()
+		m.httpDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status  int
+	metrics *Metrics
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+//RecordErrorReason implements api.ErrorReasonRecorder
+func (r *responseRecorder) RecordErrorReason(reason string) {
+	r.metrics.validationFailures.WithLabelValues(reason).Inc()
+}
+
+//InstrumentDBDriver times every statement executed through drv. names maps
+//known SQL statements to the label they are reported under.
+func (m *Metrics) InstrumentDBDriver(drv db.DBDriver, names map[string]string) db.DBDriver {
+	return instrumentedDriver{
+		DBDriver: drv,
+		metrics:  m,
+		names:    names,
+	}
+}
+
+type instrumentedDriver struct {
+	db.DBDriver
+	metrics *Metrics
+	names   map[string]string
+}
+
+func (d instrumentedDriver) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	res, err := d.DBDriver.ExecContext(ctx, query, args...)
+	d.observe(query, start, err)
+	return res, err
+}
+
+func (d instrumentedDriver) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := d.DBDriver.QueryContext(ctx, query, args...)
+	d.observe(query, start, err)
+	return rows, err
+}
+
+func (d instrumentedDriver) observe(query string, start time.Time, err error) {
+	name, ok := d.names[query]
+	if !ok {
+		name = "other"
+	}
+
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+
+	d.metrics.dbDuration.WithLabelValues(name, outcome).Observe(time.Since(start).Seconds())
+}
+
+//InstrumentDB counts feedback records saved through d
+func (m *Metrics) InstrumentDB(d db.DB) db.DB {
+	return instrumentedDB{
+		DB:      d,
+		metrics: m,
+	}
+}
+
+type instrumentedDB struct {
+	db.DB
+	metrics *Metrics
+}
+
+func (d instrumentedDB) SaveFeedback(ctx context.Context, fb db.Feedback) error {
+	err := d.DB.SaveFeedback(ctx, fb)
+	if err != nil {
+		return err
+	}
+
+	value := "none"
+	if fb.Value != nil {
+		value = *fb.Value
+	}
+	d.metrics.feedbackSubmissions.WithLabelValues(fb.Kind, value).Inc()
+
+	return nil
+}
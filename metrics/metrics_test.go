@@ -0,0 +1,151 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/smartatransit/feedback/api"
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/audit/auditfakes"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/metrics"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	var (
+		m *metrics.Metrics
+	)
+
+	BeforeEach(func() {
+		m = metrics.New()
+	})
+
+	scrape := func() string {
+		respW := httptest.NewRecorder()
+		m.Handler().ServeHTTP(respW, httptest.NewRequest("GET", "/metrics", nil))
+		body, err := ioutil.ReadAll(respW.Result().Body)
+		Expect(err).To(BeNil())
+		return string(body)
+	}
+
+	Describe("InstrumentMux", func() {
+		var mux *http.ServeMux
+
+		BeforeEach(func() {
+			mux = http.NewServeMux()
+			mux.HandleFunc("/v1/feedback", func(w http.ResponseWriter, r *http.Request) {
+				if rec, ok := w.(api.ErrorReasonRecorder); ok {
+					rec.RecordErrorReason("invalid_kind")
+				}
+				w.WriteHeader(http.StatusBadRequest)
+			})
+		})
+
+		JustBeforeEach(func() {
+			m.InstrumentMux(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/feedback", nil))
+			m.InstrumentMux(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nope", nil))
+		})
+
+		It("counts requests by route and status", func() {
+			out := scrape()
+			Expect(out).To(ContainSubstring(`feedback_http_requests_total{method="POST",route="/v1/feedback",status="400"} 1`))
+			Expect(out).To(ContainSubstring(`feedback_http_requests_total{method="GET",route="unmatched",status="404"} 1`))
+			Expect(out).To(ContainSubstring(`feedback_http_request_duration_seconds_count{method="POST",route="/v1/feedback",status="400"} 1`))
+		})
+		It("counts validation failures by reason", func() {
+			Expect(scrape()).To(ContainSubstring(`feedback_validation_failures_total{reason="invalid_kind"} 1`))
+		})
+	})
+
+	Describe("InstrumentDBDriver", func() {
+		var database *dbfakes.FakeDBDriver
+
+		BeforeEach(func() {
+			database = &dbfakes.FakeDBDriver{}
+			database.ExecContextReturnsOnCall(1, nil, errors.New("insert failed"))
+		})
+
+		JustBeforeEach(func() {
+			drv := m.InstrumentDBDriver(database, dbp.StatementNames)
+			_, _ = drv.ExecContext(context.Background(), dbp.SaveFeedbackSQL)
+			_, _ = drv.ExecContext(context.Background(), dbp.SaveFeedbackSQL)
+			_, _ = drv.ExecContext(context.Background(), "SELECT 1")
+		})
+
+		It("records durations per statement and outcome", func() {
+			out := scrape()
+			Expect(database.ExecContextCallCount()).To(Equal(3))
+			Expect(out).To(ContainSubstring(`feedback_db_query_duration_seconds_count{outcome="success",statement="SaveFeedbackSQL"} 1`))
+			Expect(out).To(ContainSubstring(`feedback_db_query_duration_seconds_count{outcome="error",statement="SaveFeedbackSQL"} 1`))
+			Expect(out).To(ContainSubstring(`feedback_db_query_duration_seconds_count{outcome="success",statement="other"} 1`))
+			Expect(out).To(ContainSubstring(`feedback_db_query_duration_seconds_bucket{outcome="success",statement="other",le="+Inf"} 1`))
+		})
+	})
+
+	Describe("InstrumentDB", func() {
+		var database *dbfakes.FakeDB
+
+		BeforeEach(func() {
+			database = &dbfakes.FakeDB{}
+		})
+
+		JustBeforeEach(func() {
+			positive := "positive"
+			d := m.InstrumentDB(database)
+			_ = d.SaveFeedback(context.Background(), dbp.Feedback{Kind: "comment", Value: &positive})
+			_ = d.SaveFeedback(context.Background(), dbp.Feedback{Kind: "outage"})
+		})
+
+		It("counts submissions by kind and value", func() {
+			out := scrape()
+			Expect(out).To(ContainSubstring(`feedback_submissions_total{kind="comment",value="positive"} 1`))
+			Expect(out).To(ContainSubstring(`feedback_submissions_total{kind="outage",value="none"} 1`))
+		})
+
+		When("the save fails", func() {
+			BeforeEach(func() {
+				database.SaveFeedbackReturns(errors.New("insert failed"))
+			})
+			It("doesn't count it", func() {
+				Expect(scrape()).NotTo(ContainSubstring(`feedback_submissions_total{`))
+			})
+		})
+	})
+
+	Describe("RegisterRecentOutagesGauge", func() {
+		BeforeEach(func() {
+			m.RegisterRecentOutagesGauge(func() float64 { return 3 })
+		})
+		It("reports the computed value", func() {
+			Expect(scrape()).To(ContainSubstring("feedback_recent_outage_reports 3\n"))
+		})
+	})
+
+	Describe("RecordPurged", func() {
+		It("counts purged rows by kind and action", func() {
+			m.RecordPurged("outage", "delete", 1000)
+			m.RecordPurged("outage", "delete", 12)
+			Expect(scrape()).To(ContainSubstring(`feedback_retention_purged_rows_total{action="delete",kind="outage"} 1012`))
+		})
+	})
+
+	Describe("InstrumentAuditRecorder", func() {
+		It("counts events that fail to be recorded by action", func() {
+			recorder := &auditfakes.FakeRecorder{}
+			recorder.RecordReturnsOnCall(1, errors.New("insert failed"))
+			instrumented := m.InstrumentAuditRecorder(recorder)
+
+			Expect(instrumented.Record(context.Background(), audit.Entry{Action: "feedback.moderate"})).To(Succeed())
+			Expect(instrumented.Record(context.Background(), audit.Entry{Action: "feedback.moderate"})).NotTo(Succeed())
+			Expect(recorder.RecordCallCount()).To(Equal(2))
+			Expect(scrape()).To(ContainSubstring(`feedback_audit_failures_total{action="feedback.moderate"} 1`))
+		})
+	})
+})
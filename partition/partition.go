@@ -0,0 +1,178 @@
+package partition
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/db"
+)
+
+//AuditArchive is the audited action recorded when a month is archived and
+//its partition dropped
+const AuditArchive = "partition.archive"
+
+//Manager maintains the monthly partitions of the feedbacks table: it creates
+//partitions ahead of time and archives old ones to files
+type Manager struct {
+	db          db.DB
+	monthsAhead int
+	now         func() time.Time
+
+	auditor      audit.Recorder
+	actorSession string
+	actorRole    string
+}
+
+//New returns a Manager keeping monthsAhead partitions beyond the current month
+func New(
+	database db.DB,
+	monthsAhead int,
+	now func() time.Time,
+) Manager {
+	return Manager{
+		db:          database,
+		monthsAhead: monthsAhead,
+		now:         now,
+	}
+}
+
+//WithAuditLog returns a copy of m that records each archived month to
+//recorder, attributed to the given actor
+func (m Manager) WithAuditLog(recorder audit.Recorder, actorSession, actorRole string) Manager {
+	m.auditor = recorder
+	m.actorSession = actorSession
+	m.actorRole = actorRole
+	return m
+}
+
+//ParseMonth parses a month in the form 2006-01
+func ParseMonth(s string) (time.Time, error) {
+	month, err := time.Parse("2006-01", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month `%s`, expected YYYY-MM", s)
+	}
+	return month, nil
+}
+
+func (m Manager) currentMonth() time.Time {
+	now := m.now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+//EnsureFuture creates the partitions for the current month and the
+//following monthsAhead months, if they don't exist yet
+func (m Manager) EnsureFuture(ctx context.Context) error {
+	month := m.currentMonth()
+	for i := 0; i <= m.monthsAhead; i++ {
+		if err := m.db.CreateFeedbackPartition(ctx, month.AddDate(0, i, 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Run ensures future partitions exist every interval until ctx is done
+func (m Manager) Run(ctx context.Context, log *logrus.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.EnsureFuture(ctx); err != nil {
+			log.Errorf("failed creating future feedback partitions: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//ArchivePath returns the file a month is archived to within dir
+func ArchivePath(dir string, month time.Time) string {
+	return filepath.Join(dir, month.Format("feedbacks_2006_01.ndjson.gz"))
+}
+
+//Archive detaches the partition holding month, writes its rows to a gzipped
+//NDJSON file in dir, and only then drops it. It refuses to archive the
+//current month or later, or to overwrite an existing archive. If writing
+//fails the partition is left detached and the archive can be retried. The
+//partition is dropped in the same transaction that records it in the audit
+//log, so it is kept if it can't be audited.
+func (m Manager) Archive(ctx context.Context, month time.Time, dir string) (string, int, error) {
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if !month.Before(m.currentMonth()) {
+		return "", 0, fmt.Errorf("refusing to archive %s: only past months can be archived", month.Format("2006-01"))
+	}
+
+	path := ArchivePath(dir, month)
+	if err := m.db.DetachFeedbackPartition(ctx, month); err != nil {
+		return path, 0, err
+	}
+
+	count, err := m.writeArchive(ctx, month, path)
+	if err != nil {
+		return path, count, err
+	}
+
+	err = m.db.InTransaction(ctx, func(ctx context.Context) error {
+		if err := m.db.DropFeedbackPartition(ctx, month); err != nil {
+			return err
+		}
+
+		if m.auditor == nil {
+			return nil
+		}
+		return m.auditor.Record(ctx, audit.Entry{
+			ActorSession: m.actorSession,
+			ActorRole:    m.actorRole,
+			Action:       AuditArchive,
+			TargetType:   "feedback_partition",
+			TargetIDs:    []string{month.Format("2006-01")},
+			After: map[string]interface{}{
+				"path":           path,
+				"feedback_count": count,
+			},
+		})
+	})
+	return path, count, err
+}
+
+func (m Manager) writeArchive(ctx context.Context, month time.Time, path string) (count int, err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed creating archive: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(path)
+		}
+	}()
+
+	gz := gzip.NewWriter(f)
+	count, err = m.db.StreamFeedbackPartition(ctx, month, gz)
+	if err != nil {
+		return count, err
+	}
+
+	if err = gz.Close(); err != nil {
+		return count, fmt.Errorf("failed compressing archive: %w", err)
+	}
+	if err = f.Sync(); err != nil {
+		return count, fmt.Errorf("failed syncing archive: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return count, fmt.Errorf("failed closing archive: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,158 @@
+package partition_test
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/audit/auditfakes"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/partition"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Manager", func() {
+	var (
+		db      *dbfakes.FakeDB
+		auditor *auditfakes.FakeRecorder
+		manager partition.Manager
+		dir     string
+	)
+
+	BeforeEach(func() {
+		db = &dbfakes.FakeDB{}
+		db.InTransactionStub = func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}
+		auditor = &auditfakes.FakeRecorder{}
+		now := time.Date(2020, 11, 15, 12, 0, 0, 0, time.UTC)
+		manager = partition.New(db, 2, func() time.Time { return now }).
+			WithAuditLog(auditor, "operator", "cli")
+
+		var err error
+		dir, err = ioutil.TempDir("", "archive")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("EnsureFuture", func() {
+		It("creates partitions for this month and the months ahead", func() {
+			Expect(manager.EnsureFuture(context.Background())).To(Succeed())
+			Expect(db.CreateFeedbackPartitionCallCount()).To(Equal(3))
+
+			var months []string
+			for i := 0; i < 3; i++ {
+				_, month := db.CreateFeedbackPartitionArgsForCall(i)
+				months = append(months, month.Format("2006-01"))
+			}
+			Expect(months).To(Equal([]string{"2020-11", "2020-12", "2021-01"}))
+		})
+	})
+
+	Describe("Archive", func() {
+		var (
+			month   time.Time
+			path    string
+			count   int
+			callErr error
+		)
+
+		BeforeEach(func() {
+			month, _ = partition.ParseMonth("2020-03")
+			db.StreamFeedbackPartitionStub = func(_ context.Context, _ time.Time, w io.Writer) (int, error) {
+				_, err := io.WriteString(w, "{\"id\":\"a\"}\n{\"id\":\"b\"}\n")
+				return 2, err
+			}
+		})
+
+		JustBeforeEach(func() {
+			path, count, callErr = manager.Archive(context.Background(), month, dir)
+		})
+
+		When("the month isn't over", func() {
+			BeforeEach(func() {
+				month, _ = partition.ParseMonth("2020-11")
+			})
+			It("refuses", func() {
+				Expect(callErr).To(MatchError("refusing to archive 2020-11: only past months can be archived"))
+				Expect(db.DetachFeedbackPartitionCallCount()).To(Equal(0))
+			})
+		})
+		When("reading the partition fails", func() {
+			BeforeEach(func() {
+				db.StreamFeedbackPartitionStub = nil
+				db.StreamFeedbackPartitionReturns(0, errors.New("select failed"))
+			})
+			It("keeps the partition and removes the partial file", func() {
+				Expect(callErr).To(MatchError("select failed"))
+				Expect(db.DropFeedbackPartitionCallCount()).To(Equal(0))
+				Expect(auditor.RecordCallCount()).To(Equal(0))
+				_, err := os.Stat(path)
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+		When("an archive already exists", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(partition.ArchivePath(dir, month), []byte("old"), 0600)).To(Succeed())
+			})
+			It("doesn't overwrite it", func() {
+				Expect(callErr).NotTo(BeNil())
+				Expect(db.DropFeedbackPartitionCallCount()).To(Equal(0))
+				Expect(ioutil.ReadFile(path)).To(Equal([]byte("old")))
+			})
+		})
+		When("the archive can't be audited", func() {
+			BeforeEach(func() {
+				auditor.RecordReturns(errors.New("insert failed"))
+			})
+			It("drops the partition in the transaction that audits it and returns an error", func() {
+				Expect(callErr).To(MatchError("insert failed"))
+				Expect(db.InTransactionCallCount()).To(Equal(1))
+				Expect(db.DropFeedbackPartitionCallCount()).To(Equal(1))
+			})
+		})
+		When("all goes well", func() {
+			It("writes compressed NDJSON before dropping the partition", func() {
+				Expect(callErr).To(BeNil())
+				Expect(count).To(Equal(2))
+				Expect(path).To(HaveSuffix("feedbacks_2020_03.ndjson.gz"))
+
+				_, detached := db.DetachFeedbackPartitionArgsForCall(0)
+				Expect(detached).To(Equal(month))
+				Expect(db.DropFeedbackPartitionCallCount()).To(Equal(1))
+
+				f, err := os.Open(path)
+				Expect(err).To(BeNil())
+				defer f.Close()
+				gz, err := gzip.NewReader(f)
+				Expect(err).To(BeNil())
+				Expect(ioutil.ReadAll(gz)).To(Equal([]byte("{\"id\":\"a\"}\n{\"id\":\"b\"}\n")))
+			})
+			It("audits the dropped partition", func() {
+				Expect(auditor.RecordCallCount()).To(Equal(1))
+				_, entry := auditor.RecordArgsForCall(0)
+				Expect(entry).To(Equal(audit.Entry{
+					ActorSession: "operator",
+					ActorRole:    "cli",
+					Action:       partition.AuditArchive,
+					TargetType:   "feedback_partition",
+					TargetIDs:    []string{"2020-03"},
+					After: map[string]interface{}{
+						"path":           path,
+						"feedback_count": 2,
+					},
+				}))
+			})
+		})
+	})
+})
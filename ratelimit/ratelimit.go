@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Wildcard matches any role or kind in a Rule
+const Wildcard = "*"
+
+//Limit allows Count submissions per Period, refilled continuously
+type Limit struct {
+	Count  int
+	Period time.Duration
+}
+
+//RefillPerSecond is the rate at which tokens return to a bucket
+func (l Limit) RefillPerSecond() float64 {
+	return float64(l.Count) / l.Period.Seconds()
+}
+
+//Rule applies a Limit to submissions matching Role and Kind, either of which
+//may be Wildcard
+type Rule struct {
+	Role  string
+	Kind  string
+	Limit Limit
+}
+
+func (r Rule) matches(role, kind string) bool {
+	return (r.Role == Wildcard || r.Role == role) && (r.Kind == Wildcard || r.Kind == kind)
+}
+
+//Policy is the set of rules applied to each submission. Every matching rule
+//has its own bucket per session, and a submission must fit in all of them.
+type Policy []Rule
+
+//LongestPeriod returns the longest period of any rule. A bucket left alone
+//that long is full again.
+func (p Policy) LongestPeriod() time.Duration {
+	var longest time.Duration
+	for _, rule := range p {
+		if rule.Limit.Period > longest {
+			longest = rule.Limit.Period
+		}
+	}
+	return longest
+}
+
+//ParsePolicy parses a comma-separated list of rules of the form
+//`role:kind=count/period`, e.g. `*:outage=5/1h,anonymous:*=20/1h`
+func ParsePolicy(s string) (Policy, error) {
+	var policy Policy
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		eq := strings.Index(part, "=")
+		colon := strings.Index(part, ":")
+		slash := strings.LastIndex(part, "/")
+		if eq < 0 || colon < 0 || colon > eq || slash < eq {
+			return nil, fmt.Errorf("malformed rate limit rule `%s`", part)
+		}
+
+		count, err := strconv.Atoi(part[eq+1 : slash])
+		if err != nil || count <= 0 {
+			return nil, fmt.Errorf("invalid count in rate limit rule `%s`", part)
+		}
+
+		period, err := time.ParseDuration(part[slash+1:])
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("invalid period in rate limit rule `%s`", part)
+		}
+
+		policy = append(policy, Rule{
+			Role:  part[:colon],
+			Kind:  part[colon+1 : eq],
+			Limit: Limit{Count: count, Period: period},
+		})
+	}
+
+	return policy, nil
+}
+
+//Decision is the outcome of a rate limit check
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+//Limiter decides whether a session may submit more feedback
+//go:generate counterfeiter . Limiter
+type Limiter interface {
+	Allow(ctx context.Context, session, role, kind string) (Decision, error)
+}
+
+//Store holds token buckets. Refund returns a token taken from a bucket.
+//go:generate counterfeiter . Store
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+	Refund(ctx context.Context, key string, limit Limit) error
+}
+
+//Client implements Limiter
+type Client struct {
+	store  Store
+	policy Policy
+}
+
+//New returns a new Client enforcing policy using buckets in store
+func New(
+	store Store,
+	policy Policy,
+) Client {
+	return Client{
+		store:  store,
+		policy: policy,
+	}
+}
+
+//Allow takes a token from the session's bucket for every rule matching role
+//and kind, and denies the submission if any of them is empty. Denied
+//submissions don't count against any bucket: the tokens taken from the
+//others are refunded, so that retries blocked by a narrow rule don't use up
+//a broader one.
+func (c Client) Allow(ctx context.Context, session, role, kind string) (Decision, error) {
+	decision := Decision{Allowed: true}
+	var taken []Rule
+	for _, rule := range c.policy {
+		if !rule.matches(role, kind) {
+			continue
+		}
+
+		allowed, retryAfter, err := c.store.Take(ctx, bucketKey(session, rule), rule.Limit)
+		if err != nil {
+			c.refund(ctx, session, taken)
+			return Decision{}, fmt.Errorf("failed checking rate limit: %w", err)
+		}
+
+		if allowed {
+			taken = append(taken, rule)
+		} else {
+			decision.Allowed = false
+			if retryAfter > decision.RetryAfter {
+				decision.RetryAfter = retryAfter
+			}
+		}
+	}
+
+	if !decision.Allowed {
+		c.refund(ctx, session, taken)
+	}
+
+	return decision, nil
+}
+
+//refund returns the tokens taken from the session's buckets for rules. It is
+//best effort: a bucket whose refund fails is a token short until it refills,
+//which is what it would be without refunds.
+func (c Client) refund(ctx context.Context, session string, rules []Rule) {
+	for _, rule := range rules {
+		_ = c.store.Refund(ctx, bucketKey(session, rule), rule.Limit)
+	}
+}
+
+func bucketKey(session string, rule Rule) string {
+	return strings.Join([]string{session, rule.Role, rule.Kind}, "|")
+}
@@ -0,0 +1,202 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/ratelimit"
+	"github.com/smartatransit/feedback/ratelimit/ratelimitfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ratelimit", func() {
+	Describe("ParsePolicy", func() {
+		It("parses rules", func() {
+			policy, err := ratelimit.ParsePolicy("*:outage=5/1h, anonymous:*=20/10m")
+			Expect(err).To(BeNil())
+			Expect(policy).To(Equal(ratelimit.Policy{
+				{Role: "*", Kind: "outage", Limit: ratelimit.Limit{Count: 5, Period: time.Hour}},
+				{Role: "anonymous", Kind: "*", Limit: ratelimit.Limit{Count: 20, Period: 10 * time.Minute}},
+			}))
+		})
+		It("rejects malformed rules", func() {
+			_, err := ratelimit.ParsePolicy("outage=5/1h")
+			Expect(err).To(MatchError("malformed rate limit rule `outage=5/1h`"))
+		})
+		It("rejects bad counts", func() {
+			_, err := ratelimit.ParsePolicy("*:*=0/1h")
+			Expect(err).To(MatchError("invalid count in rate limit rule `*:*=0/1h`"))
+		})
+		It("rejects bad periods", func() {
+			_, err := ratelimit.ParsePolicy("*:*=1/soon")
+			Expect(err).To(MatchError("invalid period in rate limit rule `*:*=1/soon`"))
+		})
+	})
+
+	Describe("Client", func() {
+		var (
+			store    *ratelimitfakes.FakeStore
+			client   ratelimit.Client
+			decision ratelimit.Decision
+			callErr  error
+		)
+
+		BeforeEach(func() {
+			store = &ratelimitfakes.FakeStore{}
+			store.TakeReturns(true, 0, nil)
+		})
+
+		JustBeforeEach(func() {
+			policy, _ := ratelimit.ParsePolicy("*:outage=5/1h,anonymous:*=20/1h,rider:*=100/1h")
+			client = ratelimit.New(store, policy)
+			decision, callErr = client.Allow(context.Background(), "sess", "anonymous", "outage")
+		})
+
+		It("takes from every matching bucket", func() {
+			Expect(callErr).To(BeNil())
+			Expect(decision.Allowed).To(BeTrue())
+			Expect(store.TakeCallCount()).To(Equal(2))
+
+			_, key, limit := store.TakeArgsForCall(0)
+			Expect(key).To(Equal("sess|*|outage"))
+			Expect(limit.Count).To(Equal(5))
+			_, key, _ = store.TakeArgsForCall(1)
+			Expect(key).To(Equal("sess|anonymous|*"))
+		})
+		When("any bucket is empty", func() {
+			BeforeEach(func() {
+				store.TakeReturnsOnCall(1, false, time.Minute, nil)
+			})
+			It("denies with the longest wait", func() {
+				Expect(decision.Allowed).To(BeFalse())
+				Expect(decision.RetryAfter).To(Equal(time.Minute))
+			})
+			It("refunds the buckets it took from", func() {
+				Expect(store.RefundCallCount()).To(Equal(1))
+				_, key, limit := store.RefundArgsForCall(0)
+				Expect(key).To(Equal("sess|*|outage"))
+				Expect(limit.Count).To(Equal(5))
+			})
+		})
+		When("every bucket has tokens", func() {
+			It("refunds nothing", func() {
+				Expect(store.RefundCallCount()).To(Equal(0))
+			})
+		})
+		When("the store fails", func() {
+			BeforeEach(func() {
+				store.TakeReturns(false, 0, errors.New("select failed"))
+			})
+			It("returns an error", func() {
+				Expect(callErr).To(MatchError("failed checking rate limit: select failed"))
+			})
+		})
+	})
+
+	Describe("MemoryStore", func() {
+		var (
+			now   time.Time
+			store *ratelimit.MemoryStore
+			limit ratelimit.Limit
+		)
+
+		BeforeEach(func() {
+			now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			store = ratelimit.NewMemoryStore(func() time.Time { return now })
+			limit = ratelimit.Limit{Count: 2, Period: time.Minute}
+		})
+
+		It("refills continuously", func() {
+			for i := 0; i < 2; i++ {
+				allowed, _, err := store.Take(context.Background(), "k", limit)
+				Expect(err).To(BeNil())
+				Expect(allowed).To(BeTrue())
+			}
+
+			allowed, retryAfter, _ := store.Take(context.Background(), "k", limit)
+			Expect(allowed).To(BeFalse())
+			Expect(retryAfter).To(Equal(30 * time.Second))
+
+			now = now.Add(30 * time.Second)
+			allowed, _, _ = store.Take(context.Background(), "k", limit)
+			Expect(allowed).To(BeTrue())
+
+			allowed, _, _ = store.Take(context.Background(), "other", limit)
+			Expect(allowed).To(BeTrue())
+		})
+
+		It("takes refunds up to the bucket's capacity", func() {
+			allowed, _, _ := store.Take(context.Background(), "k", limit)
+			Expect(allowed).To(BeTrue())
+			allowed, _, _ = store.Take(context.Background(), "k", limit)
+			Expect(allowed).To(BeTrue())
+
+			Expect(store.Refund(context.Background(), "k", limit)).To(Succeed())
+			Expect(store.Refund(context.Background(), "k", limit)).To(Succeed())
+			Expect(store.Refund(context.Background(), "k", limit)).To(Succeed())
+
+			for i := 0; i < 2; i++ {
+				allowed, _, _ = store.Take(context.Background(), "k", limit)
+				Expect(allowed).To(BeTrue())
+			}
+			allowed, _, _ = store.Take(context.Background(), "k", limit)
+			Expect(allowed).To(BeFalse())
+		})
+	})
+
+	Describe("Client with a MemoryStore", func() {
+		It("doesn't let retries denied by a narrow rule use up a broad one", func() {
+			now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			policy, _ := ratelimit.ParsePolicy("*:outage=1/1h,*:*=3/1h")
+			client := ratelimit.New(ratelimit.NewMemoryStore(func() time.Time { return now }), policy)
+
+			decision, _ := client.Allow(context.Background(), "sess", "rider", "outage")
+			Expect(decision.Allowed).To(BeTrue())
+			for i := 0; i < 5; i++ {
+				decision, _ = client.Allow(context.Background(), "sess", "rider", "outage")
+				Expect(decision.Allowed).To(BeFalse())
+			}
+
+			for i := 0; i < 2; i++ {
+				decision, _ = client.Allow(context.Background(), "sess", "rider", "comment")
+				Expect(decision.Allowed).To(BeTrue())
+			}
+		})
+	})
+
+	Describe("PostgresStore", func() {
+		var database *dbfakes.FakeDB
+
+		BeforeEach(func() {
+			database = &dbfakes.FakeDB{}
+		})
+
+		It("computes the wait from the remaining tokens", func() {
+			database.TakeRateLimitTokenReturns(false, 0.5, nil)
+			allowed, retryAfter, err := ratelimit.NewPostgresStore(database).
+				Take(context.Background(), "k", ratelimit.Limit{Count: 60, Period: time.Hour})
+			Expect(err).To(BeNil())
+			Expect(allowed).To(BeFalse())
+			Expect(retryAfter).To(Equal(30 * time.Second))
+
+			_, key, capacity, refill := database.TakeRateLimitTokenArgsForCall(0)
+			Expect(key).To(Equal("k"))
+			Expect(capacity).To(BeEquivalentTo(60))
+			Expect(refill).To(BeNumerically("~", 1.0/60))
+		})
+
+		It("prunes the buckets left alone for longer than idle", func() {
+			database.PruneRateLimitBucketsReturns(4, nil)
+			pruned, err := ratelimit.NewPostgresStore(database).Prune(context.Background(), time.Hour)
+			Expect(err).To(BeNil())
+			Expect(pruned).To(Equal(4))
+
+			_, before := database.PruneRateLimitBucketsArgsForCall(0)
+			Expect(before).To(BeTemporally("~", time.Now().Add(-time.Hour), time.Second))
+		})
+	})
+})
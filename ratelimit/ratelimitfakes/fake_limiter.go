@@ -0,0 +1,122 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package ratelimitfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/smartatransit/feedback/ratelimit"
+)
+
+type FakeLimiter struct {
+	AllowStub        func(context.Context, string, string, string) (ratelimit.Decision, error)
+	allowMutex       sync.RWMutex
+	allowArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}
+	allowReturns struct {
+		result1 ratelimit.Decision
+		result2 error
+	}
+	allowReturnsOnCall map[int]struct {
+		result1 ratelimit.Decision
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeLimiter) Allow(arg1 context.Context, arg2 string, arg3 string, arg4 string) (ratelimit.Decision, error) {
+	fake.allowMutex.Lock()
+	ret, specificReturn := fake.allowReturnsOnCall[len(fake.allowArgsForCall)]
+	fake.allowArgsForCall = append(fake.allowArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("Allow", []interface{}{arg1, arg2, arg3, arg4})
+	fake.allowMutex.Unlock()
+	if fake.AllowStub != nil {
+		return fake.AllowStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.allowReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLimiter) AllowCallCount() int {
+	fake.allowMutex.RLock()
+	defer fake.allowMutex.RUnlock()
+	return len(fake.allowArgsForCall)
+}
+
+func (fake *FakeLimiter) AllowCalls(stub func(context.Context, string, string, string) (ratelimit.Decision, error)) {
+	fake.allowMutex.Lock()
+	defer fake.allowMutex.Unlock()
+	fake.AllowStub = stub
+}
+
+func (fake *FakeLimiter) AllowArgsForCall(i int) (context.Context, string, string, string) {
+	fake.allowMutex.RLock()
+	defer fake.allowMutex.RUnlock()
+	argsForCall := fake.allowArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeLimiter) AllowReturns(result1 ratelimit.Decision, result2 error) {
+	fake.allowMutex.Lock()
+	defer fake.allowMutex.Unlock()
+	fake.AllowStub = nil
+	fake.allowReturns = struct {
+		result1 ratelimit.Decision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLimiter) AllowReturnsOnCall(i int, result1 ratelimit.Decision, result2 error) {
+	fake.allowMutex.Lock()
+	defer fake.allowMutex.Unlock()
+	fake.AllowStub = nil
+	if fake.allowReturnsOnCall == nil {
+		fake.allowReturnsOnCall = make(map[int]struct {
+			result1 ratelimit.Decision
+			result2 error
+		})
+	}
+	fake.allowReturnsOnCall[i] = struct {
+		result1 ratelimit.Decision
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLimiter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.allowMutex.RLock()
+	defer fake.allowMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeLimiter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ ratelimit.Limiter = new(FakeLimiter)
@@ -0,0 +1,203 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package ratelimitfakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/smartatransit/feedback/ratelimit"
+)
+
+type FakeStore struct {
+	RefundStub        func(context.Context, string, ratelimit.Limit) error
+	refundMutex       sync.RWMutex
+	refundArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 ratelimit.Limit
+	}
+	refundReturns struct {
+		result1 error
+	}
+	refundReturnsOnCall map[int]struct {
+		result1 error
+	}
+	TakeStub        func(context.Context, string, ratelimit.Limit) (bool, time.Duration, error)
+	takeMutex       sync.RWMutex
+	takeArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 ratelimit.Limit
+	}
+	takeReturns struct {
+		result1 bool
+		result2 time.Duration
+		result3 error
+	}
+	takeReturnsOnCall map[int]struct {
+		result1 bool
+		result2 time.Duration
+		result3 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeStore) Refund(arg1 context.Context, arg2 string, arg3 ratelimit.Limit) error {
+	fake.refundMutex.Lock()
+	ret, specificReturn := fake.refundReturnsOnCall[len(fake.refundArgsForCall)]
+	fake.refundArgsForCall = append(fake.refundArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 ratelimit.Limit
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("Refund", []interface{}{arg1, arg2, arg3})
+	fake.refundMutex.Unlock()
+	if fake.RefundStub != nil {
+		return fake.RefundStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.refundReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeStore) RefundCallCount() int {
+	fake.refundMutex.RLock()
+	defer fake.refundMutex.RUnlock()
+	return len(fake.refundArgsForCall)
+}
+
+func (fake *FakeStore) RefundCalls(stub func(context.Context, string, ratelimit.Limit) error) {
+	fake.refundMutex.Lock()
+	defer fake.refundMutex.Unlock()
+	fake.RefundStub = stub
+}
+
+func (fake *FakeStore) RefundArgsForCall(i int) (context.Context, string, ratelimit.Limit) {
+	fake.refundMutex.RLock()
+	defer fake.refundMutex.RUnlock()
+	argsForCall := fake.refundArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeStore) RefundReturns(result1 error) {
+	fake.refundMutex.Lock()
+	defer fake.refundMutex.Unlock()
+	fake.RefundStub = nil
+	fake.refundReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStore) RefundReturnsOnCall(i int, result1 error) {
+	fake.refundMutex.Lock()
+	defer fake.refundMutex.Unlock()
+	fake.RefundStub = nil
+	if fake.refundReturnsOnCall == nil {
+		fake.refundReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.refundReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStore) Take(arg1 context.Context, arg2 string, arg3 ratelimit.Limit) (bool, time.Duration, error) {
+	fake.takeMutex.Lock()
+	ret, specificReturn := fake.takeReturnsOnCall[len(fake.takeArgsForCall)]
+	fake.takeArgsForCall = append(fake.takeArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 ratelimit.Limit
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("Take", []interface{}{arg1, arg2, arg3})
+	fake.takeMutex.Unlock()
+	if fake.TakeStub != nil {
+		return fake.TakeStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	fakeReturns := fake.takeReturns
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeStore) TakeCallCount() int {
+	fake.takeMutex.RLock()
+	defer fake.takeMutex.RUnlock()
+	return len(fake.takeArgsForCall)
+}
+
+func (fake *FakeStore) TakeCalls(stub func(context.Context, string, ratelimit.Limit) (bool, time.Duration, error)) {
+	fake.takeMutex.Lock()
+	defer fake.takeMutex.Unlock()
+	fake.TakeStub = stub
+}
+
+func (fake *FakeStore) TakeArgsForCall(i int) (context.Context, string, ratelimit.Limit) {
+	fake.takeMutex.RLock()
+	defer fake.takeMutex.RUnlock()
+	argsForCall := fake.takeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeStore) TakeReturns(result1 bool, result2 time.Duration, result3 error) {
+	fake.takeMutex.Lock()
+	defer fake.takeMutex.Unlock()
+	fake.TakeStub = nil
+	fake.takeReturns = struct {
+		result1 bool
+		result2 time.Duration
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeStore) TakeReturnsOnCall(i int, result1 bool, result2 time.Duration, result3 error) {
+	fake.takeMutex.Lock()
+	defer fake.takeMutex.Unlock()
+	fake.TakeStub = nil
+	if fake.takeReturnsOnCall == nil {
+		fake.takeReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 time.Duration
+			result3 error
+		})
+	}
+	fake.takeReturnsOnCall[i] = struct {
+		result1 bool
+		result2 time.Duration
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.refundMutex.RLock()
+	defer fake.refundMutex.RUnlock()
+	fake.takeMutex.RLock()
+	defer fake.takeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ ratelimit.Store = new(FakeStore)
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+//MemoryStore keeps token buckets in process. Limits only hold per replica.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+//NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore(now func() time.Time) *MemoryStore {
+	return &MemoryStore{
+		now:     now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+//Take implements Store
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Count), updated: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	b.refill(now)
+
+	if b.tokens < 1 {
+		return false, retryAfter(b.tokens, limit), nil
+	}
+
+	b.tokens--
+	return true, 0, nil
+}
+
+//Refund implements Store
+func (s *MemoryStore) Refund(ctx context.Context, key string, limit Limit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		return nil
+	}
+	b.limit = limit
+	b.refill(s.now())
+	b.tokens = math.Min(float64(limit.Count), b.tokens+1)
+
+	return nil
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(float64(b.limit.Count), b.tokens+elapsed*b.limit.RefillPerSecond())
+	b.updated = now
+}
+
+//prune drops buckets that have refilled completely, since they are
+//indistinguishable from absent ones
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < time.Minute {
+		return
+	}
+	s.lastPrune = now
+
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Count) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+//PostgresStore keeps token buckets in the database so that limits hold
+//across replicas
+type PostgresStore struct {
+	db db.DB
+}
+
+//NewPostgresStore returns a new PostgresStore
+func NewPostgresStore(db db.DB) PostgresStore {
+	return PostgresStore{db: db}
+}
+
+//Take implements Store
+func (s PostgresStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	allowed, tokens, err := s.db.TakeRateLimitToken(ctx, key, float64(limit.Count), limit.RefillPerSecond())
+	if err != nil {
+		return false, 0, err
+	}
+	if allowed {
+		return true, 0, nil
+	}
+
+	return false, retryAfter(tokens, limit), nil
+}
+
+//Refund implements Store
+func (s PostgresStore) Refund(ctx context.Context, key string, limit Limit) error {
+	return s.db.RefundRateLimitToken(ctx, key, float64(limit.Count), limit.RefillPerSecond())
+}
+
+//Prune deletes the buckets left alone for longer than idle. Given the longest
+//period of the policy, they have all refilled and are indistinguishable from
+//absent ones.
+func (s PostgresStore) Prune(ctx context.Context, idle time.Duration) (int, error) {
+	return s.db.PruneRateLimitBuckets(ctx, time.Now().Add(-idle))
+}
+
+//Run prunes buckets left alone for longer than idle every interval, until
+//ctx is done
+func (s PostgresStore) Run(ctx context.Context, log *logrus.Logger, interval, idle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pruned, err := s.Prune(ctx, idle)
+		if pruned > 0 {
+			log.WithField("pruned", pruned).Info("pruned idle rate limit buckets")
+		}
+		if err != nil {
+			log.Errorf("failed pruning rate limit buckets: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func retryAfter(tokens float64, limit Limit) time.Duration {
+	seconds := (1 - tokens) / limit.RefillPerSecond()
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
@@ -0,0 +1,109 @@
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//Kinds of personal data that are redacted
+const (
+	KindEmail      = "email"
+	KindCard       = "card"
+	KindBreezeCard = "breeze_card"
+	KindPhone      = "phone"
+)
+
+var (
+	emailRegexp = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+	//digitRunRegexp finds runs of digits, optionally grouped by spaces,
+	//dashes, dots or parentheses, which are then classified by length
+	digitRunRegexp = regexp.MustCompile(`\+?\(?\d(?:[\s().-]{0,2}\d){8,}`)
+)
+
+//Redactor replaces personal data in free text with placeholders
+type Redactor struct{}
+
+//New returns a new Redactor
+func New() Redactor {
+	return Redactor{}
+}
+
+//Redact returns s with personal data replaced by placeholders such as
+//`[phone]`, along with the sorted, de-duplicated kinds of data found
+func (r Redactor) Redact(s string) (string, []string) {
+	found := map[string]struct{}{}
+
+	s = emailRegexp.ReplaceAllStringFunc(s, func(string) string {
+		found[KindEmail] = struct{}{}
+		return placeholder(KindEmail)
+	})
+
+	s = digitRunRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		kind := classifyDigits(digits(match))
+		if kind == "" {
+			return match
+		}
+		found[kind] = struct{}{}
+		return placeholder(kind)
+	})
+
+	kinds := make([]string, 0, len(found))
+	for kind := range found {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	return s, kinds
+}
+
+func placeholder(kind string) string {
+	return "[" + strings.Replace(kind, "_", " ", -1) + "]"
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+//classifyDigits decides what, if anything, a run of digits identifies.
+//Breeze card serial numbers are 20 digits; payment cards are 13-19 digits
+//and pass the Luhn check; North American phone numbers are 10 digits, or
+//11 with a leading country code of 1.
+func classifyDigits(d string) string {
+	switch {
+	case len(d) == 20:
+		return KindBreezeCard
+	case len(d) >= 13 && len(d) <= 19 && luhnValid(d):
+		return KindCard
+	case len(d) == 10 && d[0] >= '2':
+		return KindPhone
+	case len(d) == 11 && d[0] == '1' && d[1] >= '2':
+		return KindPhone
+	}
+	return ""
+}
+
+//luhnValid reports whether the digit string d passes the Luhn checksum
+func luhnValid(d string) bool {
+	sum := 0
+	double := false
+	for i := len(d) - 1; i >= 0; i-- {
+		n := int(d[i] - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+	return sum%10 == 0
+}
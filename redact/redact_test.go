@@ -0,0 +1,53 @@
+package redact_test
+
+import (
+	"github.com/smartatransit/feedback/redact"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redactor", func() {
+	var r redact.Redactor
+
+	BeforeEach(func() {
+		r = redact.New()
+	})
+
+	It("redacts email addresses", func() {
+		s, kinds := r.Redact("reach me at rider.one+smarta@example.co")
+		Expect(s).To(Equal("reach me at [email]"))
+		Expect(kinds).To(Equal([]string{"email"}))
+	})
+	It("redacts phone numbers in common formats", func() {
+		for _, phone := range []string{"404-555-0123", "(404) 555-0123", "404.555.0123", "+1 404 555 0123", "4045550123"} {
+			s, kinds := r.Redact("call " + phone + " please")
+			Expect(s).To(Equal("call [phone] please"), phone)
+			Expect(kinds).To(Equal([]string{"phone"}))
+		}
+	})
+	It("redacts card numbers that pass the Luhn check", func() {
+		s, kinds := r.Redact("charged twice on 4111 1111 1111 1111")
+		Expect(s).To(Equal("charged twice on [card]"))
+		Expect(kinds).To(Equal([]string{"card"}))
+	})
+	It("leaves long numbers that fail the Luhn check", func() {
+		s, kinds := r.Redact("ticket 4111 1111 1111 1112")
+		Expect(s).To(Equal("ticket 4111 1111 1111 1112"))
+		Expect(kinds).To(BeEmpty())
+	})
+	It("redacts Breeze card serial numbers", func() {
+		s, kinds := r.Redact("my breeze card 01234567890123456789 was charged")
+		Expect(s).To(Equal("my breeze card [breeze card] was charged"))
+		Expect(kinds).To(Equal([]string{"breeze_card"}))
+	})
+	It("leaves ordinary numbers alone", func() {
+		s, kinds := r.Redact("bus 110 was 15 minutes late at 5:45 on 2020-03-01")
+		Expect(s).To(Equal("bus 110 was 15 minutes late at 5:45 on 2020-03-01"))
+		Expect(kinds).To(BeEmpty())
+	})
+	It("reports each kind found once, sorted", func() {
+		_, kinds := r.Redact("a@b.com 404-555-0123 c@d.org 678-555-0199")
+		Expect(kinds).To(Equal([]string{"email", "phone"}))
+	})
+})
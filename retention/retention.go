@@ -0,0 +1,191 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/db"
+)
+
+//AuditPurge is the audited action recorded for each kind a purge removed or
+//anonymized feedback of
+const AuditPurge = "retention.purge"
+
+//Recorder is notified of rows purged, e.g. to export them as a metric
+//go:generate counterfeiter . Recorder
+type Recorder interface {
+	RecordPurged(kind, action string, rows int)
+}
+
+//Result describes what a purge did, or in a dry run would do, for a kind
+type Result struct {
+	Kind    string
+	Action  string
+	Cutoff  time.Time
+	Expired int
+	Purged  int
+}
+
+//Purger enforces the retention policies stored in the database. Policies are
+//read afresh on every run, so they can be changed without a redeploy.
+type Purger struct {
+	db        db.DB
+	batchSize int
+	now       func() time.Time
+	recorder  Recorder
+
+	auditor      audit.Recorder
+	actorSession string
+	actorRole    string
+}
+
+//New returns a Purger that purges batchSize rows per statement
+func New(
+	database db.DB,
+	batchSize int,
+	now func() time.Time,
+) Purger {
+	return Purger{
+		db:        database,
+		batchSize: batchSize,
+		now:       now,
+	}
+}
+
+//WithRecorder returns a copy of p that reports purged rows to recorder
+func (p Purger) WithRecorder(recorder Recorder) Purger {
+	p.recorder = recorder
+	return p
+}
+
+//WithAuditLog returns a copy of p that records what each purge did to
+//recorder, attributed to the given actor
+func (p Purger) WithAuditLog(recorder audit.Recorder, actorSession, actorRole string) Purger {
+	p.auditor = recorder
+	p.actorSession = actorSession
+	p.actorRole = actorRole
+	return p
+}
+
+//Purge applies every retention policy. Expired rows are deleted or
+//anonymized in batches until none remain, so that no single statement holds
+//locks for long. In a dry run, expired rows are only counted. Each batch is
+//recorded in the audit log in the same transaction that purges it.
+func (p Purger) Purge(ctx context.Context, dryRun bool) ([]Result, error) {
+	policies, err := p.db.GetRetentionPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []Result{}
+	for _, policy := range policies {
+		result := Result{
+			Kind:   policy.Kind,
+			Action: policy.Action,
+			Cutoff: p.now().AddDate(0, 0, -policy.MaxAgeDays),
+		}
+
+		result.Expired, err = p.db.CountExpiredFeedback(ctx, policy.Kind, policy.Action, result.Cutoff)
+		if err != nil {
+			return results, err
+		}
+
+		if !dryRun {
+			if err := p.purge(ctx, &result); err != nil {
+				return append(results, result), err
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+//purge removes or anonymizes the rows result expects, a batch at a time
+func (p Purger) purge(ctx context.Context, result *Result) error {
+	for result.Purged < result.Expired {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var n int
+		err := p.db.InTransaction(ctx, func(ctx context.Context) (err error) {
+			n, err = p.db.PurgeExpiredFeedback(ctx, result.Kind, result.Action, result.Cutoff, p.batchSize)
+			if err != nil {
+				return err
+			}
+			return p.recordAudit(ctx, *result, n)
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+
+		result.Purged += n
+		if p.recorder != nil {
+			p.recorder.RecordPurged(result.Kind, result.Action, n)
+		}
+	}
+	return nil
+}
+
+//recordAudit records a batch of n rows purged for result in the audit log,
+//if there were any
+func (p Purger) recordAudit(ctx context.Context, result Result, n int) error {
+	if p.auditor == nil || n == 0 {
+		return nil
+	}
+
+	return p.auditor.Record(ctx, audit.Entry{
+		ActorSession: p.actorSession,
+		ActorRole:    p.actorRole,
+		Action:       AuditPurge,
+		TargetType:   "feedback_kind",
+		TargetIDs:    []string{result.Kind},
+		After: map[string]interface{}{
+			"action": result.Action,
+			"cutoff": result.Cutoff,
+			"purged": n,
+		},
+	})
+}
+
+//Run purges every interval until ctx is done, logging the results
+func (p Purger) Run(ctx context.Context, log *logrus.Logger, interval time.Duration, dryRun bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		results, err := p.Purge(ctx, dryRun)
+		LogResults(log, results, dryRun)
+		if err != nil {
+			log.Errorf("failed enforcing retention policies: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//LogResults logs one entry per kind a purge considered
+func LogResults(log *logrus.Logger, results []Result, dryRun bool) {
+	for _, r := range results {
+		log.WithFields(logrus.Fields{
+			"kind":    r.Kind,
+			"action":  r.Action,
+			"cutoff":  r.Cutoff,
+			"expired": r.Expired,
+			"purged":  r.Purged,
+			"dry_run": dryRun,
+		}).Info("enforced retention policy")
+	}
+}
@@ -0,0 +1,147 @@
+package retention_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/smartatransit/feedback/audit"
+	"github.com/smartatransit/feedback/audit/auditfakes"
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/retention"
+	"github.com/smartatransit/feedback/retention/retentionfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Purger", func() {
+	var (
+		db       *dbfakes.FakeDB
+		recorder *retentionfakes.FakeRecorder
+		auditor  *auditfakes.FakeRecorder
+		now      time.Time
+
+		dryRun  bool
+		results []retention.Result
+		callErr error
+	)
+
+	BeforeEach(func() {
+		db = &dbfakes.FakeDB{}
+		db.InTransactionStub = func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}
+		recorder = &retentionfakes.FakeRecorder{}
+		auditor = &auditfakes.FakeRecorder{}
+		now = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		dryRun = false
+
+		db.GetRetentionPoliciesReturns([]dbp.RetentionPolicy{
+			{Kind: "outage", MaxAgeDays: 90, Action: "delete"},
+			{Kind: "comment", MaxAgeDays: 730, Action: "anonymize"},
+		}, nil)
+		db.CountExpiredFeedbackReturnsOnCall(0, 250, nil)
+		db.CountExpiredFeedbackReturnsOnCall(1, 0, nil)
+		db.PurgeExpiredFeedbackReturnsOnCall(0, 100, nil)
+		db.PurgeExpiredFeedbackReturnsOnCall(1, 100, nil)
+		db.PurgeExpiredFeedbackReturnsOnCall(2, 50, nil)
+	})
+
+	JustBeforeEach(func() {
+		purger := retention.New(db, 100, func() time.Time { return now }).
+			WithRecorder(recorder).
+			WithAuditLog(auditor, "retention-purger", "system")
+		results, callErr = purger.Purge(context.Background(), dryRun)
+	})
+
+	When("the policies can't be read", func() {
+		BeforeEach(func() {
+			db.GetRetentionPoliciesReturns(nil, errors.New("select failed"))
+		})
+		It("returns an error", func() {
+			Expect(callErr).To(MatchError("select failed"))
+		})
+	})
+	When("a batch fails", func() {
+		BeforeEach(func() {
+			db.PurgeExpiredFeedbackReturnsOnCall(1, 0, errors.New("delete failed"))
+		})
+		It("stops and returns an error", func() {
+			Expect(callErr).To(MatchError("delete failed"))
+			Expect(db.PurgeExpiredFeedbackCallCount()).To(Equal(2))
+			Expect(recorder.RecordPurgedCallCount()).To(Equal(1))
+		})
+		It("audits the batches purged before the failure", func() {
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Purged).To(Equal(100))
+			Expect(auditor.RecordCallCount()).To(Equal(1))
+			_, entry := auditor.RecordArgsForCall(0)
+			Expect(entry.After).To(HaveKeyWithValue("purged", 100))
+		})
+	})
+	When("the purge can't be audited", func() {
+		BeforeEach(func() {
+			auditor.RecordReturns(errors.New("insert failed"))
+		})
+		It("rolls the batch back, stops and returns an error", func() {
+			Expect(callErr).To(MatchError("insert failed"))
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Purged).To(Equal(0))
+			Expect(db.InTransactionCallCount()).To(Equal(1))
+			Expect(db.CountExpiredFeedbackCallCount()).To(Equal(1))
+			Expect(recorder.RecordPurgedCallCount()).To(Equal(0))
+		})
+	})
+	When("it's a dry run", func() {
+		BeforeEach(func() {
+			dryRun = true
+		})
+		It("only counts expired rows", func() {
+			Expect(callErr).To(BeNil())
+			Expect(db.PurgeExpiredFeedbackCallCount()).To(Equal(0))
+			Expect(auditor.RecordCallCount()).To(Equal(0))
+			Expect(results).To(Equal([]retention.Result{
+				{Kind: "outage", Action: "delete", Cutoff: now.AddDate(0, 0, -90), Expired: 250},
+				{Kind: "comment", Action: "anonymize", Cutoff: now.AddDate(0, 0, -730)},
+			}))
+		})
+	})
+	When("all goes well", func() {
+		It("purges expired rows in batches", func() {
+			Expect(callErr).To(BeNil())
+			Expect(db.PurgeExpiredFeedbackCallCount()).To(Equal(3))
+
+			_, kind, action, cutoff, limit := db.PurgeExpiredFeedbackArgsForCall(0)
+			Expect(kind).To(Equal("outage"))
+			Expect(action).To(Equal("delete"))
+			Expect(cutoff).To(Equal(now.AddDate(0, 0, -90)))
+			Expect(limit).To(Equal(100))
+
+			Expect(results[0].Purged).To(Equal(250))
+			Expect(results[1].Purged).To(Equal(0))
+
+			Expect(recorder.RecordPurgedCallCount()).To(Equal(3))
+			kind, action, n := recorder.RecordPurgedArgsForCall(2)
+			Expect([]interface{}{kind, action, n}).To(Equal([]interface{}{"outage", "delete", 50}))
+		})
+		It("audits each batch in its transaction", func() {
+			Expect(db.InTransactionCallCount()).To(Equal(3))
+			Expect(auditor.RecordCallCount()).To(Equal(3))
+			_, entry := auditor.RecordArgsForCall(2)
+			Expect(entry).To(Equal(audit.Entry{
+				ActorSession: "retention-purger",
+				ActorRole:    "system",
+				Action:       retention.AuditPurge,
+				TargetType:   "feedback_kind",
+				TargetIDs:    []string{"outage"},
+				After: map[string]interface{}{
+					"action": "delete",
+					"cutoff": now.AddDate(0, 0, -90),
+					"purged": 50,
+				},
+			}))
+		})
+	})
+})
@@ -0,0 +1,79 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package retentionfakes
+
+import (
+	"sync"
+
+	"github.com/smartatransit/feedback/retention"
+)
+
+type FakeRecorder struct {
+	RecordPurgedStub        func(string, string, int)
+	recordPurgedMutex       sync.RWMutex
+	recordPurgedArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 int
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRecorder) RecordPurged(arg1 string, arg2 string, arg3 int) {
+	fake.recordPurgedMutex.Lock()
+	fake.recordPurgedArgsForCall = append(fake.recordPurgedArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 int
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("RecordPurged", []interface{}{arg1, arg2, arg3})
+	fake.recordPurgedMutex.Unlock()
+	if fake.RecordPurgedStub != nil {
+		fake.RecordPurgedStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeRecorder) RecordPurgedCallCount() int {
+	fake.recordPurgedMutex.RLock()
+	defer fake.recordPurgedMutex.RUnlock()
+	return len(fake.recordPurgedArgsForCall)
+}
+
+func (fake *FakeRecorder) RecordPurgedCalls(stub func(string, string, int)) {
+	fake.recordPurgedMutex.Lock()
+	defer fake.recordPurgedMutex.Unlock()
+	fake.RecordPurgedStub = stub
+}
+
+func (fake *FakeRecorder) RecordPurgedArgsForCall(i int) (string, string, int) {
+	fake.recordPurgedMutex.RLock()
+	defer fake.recordPurgedMutex.RUnlock()
+	argsForCall := fake.recordPurgedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRecorder) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.recordPurgedMutex.RLock()
+	defer fake.recordPurgedMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRecorder) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ retention.Recorder = new(FakeRecorder)
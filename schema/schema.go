@@ -0,0 +1,281 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema"
+)
+
+//Draft is the only JSON Schema dialect accepted by Parse
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+//resourceURL names the schema being compiled. It is never loaded, since
+//Parse only accepts schemas that don't refer to other documents.
+const resourceURL = "feedback-details.json"
+
+//Schema is a compiled JSON Schema, draft 7
+type Schema struct {
+	compiled *jsonschema.Schema
+}
+
+//ValidationError describes the first part of a value that doesn't match a
+//schema. Path is dotted, with array indexes in brackets, and is empty for
+//the value itself.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("`%s` %s", e.Path, e.Message)
+}
+
+//Parse compiles a JSON Schema document. The document must be draft 7,
+//either by declaring it in `$schema` or by leaving `$schema` out, and may
+//only use draft 7 keywords. Its `$ref`s may only point within itself, so
+//that compiling it never reads a file or fetches a URL.
+func Parse(doc []byte) (*Schema, error) {
+	v, err := Decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed decoding schema: %w", err)
+	}
+
+	if err := checkSchema(v, ""); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	if err := compiler.AddResource(resourceURL, bytes.NewReader(doc)); err != nil {
+		return nil, fmt.Errorf("failed decoding schema: %w", err)
+	}
+
+	compiled, err := compiler.Compile(resourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %s", describeCompileError(err))
+	}
+	return &Schema{compiled: compiled}, nil
+}
+
+//Decode decodes a JSON document the way Validate expects, keeping numbers
+//as json.Number
+func Decode(doc []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+//The draft 7 keywords, by what they hold, so that a schema can be walked.
+//Validators ignore keywords they don't know, so Parse rejects any other
+//keyword rather than let a typo such as `maxLenght` accept anything.
+var (
+	schemaKeywords = map[string]bool{
+		"additionalItems": true, "additionalProperties": true, "contains": true, "else": true,
+		"if": true, "items": true, "not": true, "propertyNames": true, "then": true,
+	}
+	schemaListKeywords = map[string]bool{"allOf": true, "anyOf": true, "items": true, "oneOf": true}
+	schemaMapKeywords  = map[string]bool{
+		"definitions": true, "dependencies": true, "patternProperties": true, "properties": true,
+	}
+	valueKeywords = map[string]bool{
+		"$comment": true, "$id": true, "$ref": true, "$schema": true,
+		"const": true, "contentEncoding": true, "contentMediaType": true, "default": true,
+		"description": true, "enum": true, "examples": true, "exclusiveMaximum": true,
+		"exclusiveMinimum": true, "format": true, "maxItems": true, "maxLength": true,
+		"maxProperties": true, "maximum": true, "minItems": true, "minLength": true,
+		"minProperties": true, "minimum": true, "multipleOf": true, "pattern": true,
+		"readOnly": true, "required": true, "title": true, "type": true,
+		"uniqueItems": true, "writeOnly": true,
+	}
+)
+
+//checkSchema rejects unknown keywords, other dialects and references to
+//other documents anywhere in a schema. Malformed values are left for the
+//compiler to report.
+func checkSchema(v interface{}, path string) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, key := range sortedKeys(obj) {
+		val := obj[key]
+		str, isString := val.(string)
+		switch {
+		case key == "$schema" && isString && str != Draft:
+			return fmt.Errorf("%sunsupported `$schema` `%s`, only draft 7 is supported", at(path), str)
+		case key == "$ref" && isString && !strings.HasPrefix(str, "#"):
+			return fmt.Errorf("%s`$ref` `%s` must point within the schema", at(path), str)
+		case schemaMapKeywords[key]:
+			children, _ := val.(map[string]interface{})
+			for _, name := range sortedKeys(children) {
+				if err := checkSchema(children[name], join(path, name)); err != nil {
+					return err
+				}
+			}
+		case schemaListKeywords[key] || schemaKeywords[key]:
+			children, isList := val.([]interface{})
+			if !isList {
+				children = []interface{}{val}
+			}
+			for i, child := range children {
+				childPath := join(path, key)
+				if isList {
+					childPath = fmt.Sprintf("%s[%d]", childPath, i)
+				}
+				if err := checkSchema(child, childPath); err != nil {
+					return err
+				}
+			}
+		case !valueKeywords[key]:
+			return fmt.Errorf("%sunsupported keyword `%s`", at(path), key)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//Validate checks a value decoded by Decode against s
+func (s *Schema) Validate(v interface{}) error {
+	err := s.compiled.ValidateInterface(v)
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+
+	cause := firstCause(verr)
+	return ValidationError{
+		Path:    instancePath(v, cause.InstancePtr),
+		Message: cause.Message,
+	}
+}
+
+//PropertyType returns the types the schema declares for the property at
+//path, following nested properties. It returns nil if the schema doesn't
+//describe that property or doesn't restrict its type.
+func (s *Schema) PropertyType(path []string) []string {
+	current := resolve(s.compiled)
+	for _, name := range path {
+		next, ok := current.Properties[name]
+		if !ok {
+			return nil
+		}
+		current = resolve(next)
+	}
+	return current.Types
+}
+
+//resolve follows the `$ref`s of s to the schema that applies
+func resolve(s *jsonschema.Schema) *jsonschema.Schema {
+	for s.Ref != nil {
+		s = s.Ref
+	}
+	return s
+}
+
+//firstCause returns the innermost of the first failures that led to err,
+//which is the most specific
+func firstCause(err *jsonschema.ValidationError) *jsonschema.ValidationError {
+	for len(err.Causes) > 0 {
+		err = err.Causes[0]
+	}
+	return err
+}
+
+//describeCompileError reduces the errors of compiling a schema, which may
+//nest failures to match the draft 7 meta-schema, to the most specific one
+func describeCompileError(err error) string {
+	var serr *jsonschema.SchemaError
+	if errors.As(err, &serr) {
+		err = serr.Err
+	}
+
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err.Error()
+	}
+	cause := firstCause(verr)
+	if path := pointerPath(cause.InstancePtr); path != "" {
+		return fmt.Sprintf("at `%s`: %s", path, cause.Message)
+	}
+	return cause.Message
+}
+
+//instancePath converts a JSON pointer into v, such as `#/tags/1`, to the
+//dotted form used by ValidationError, such as `tags[1]`
+func instancePath(v interface{}, ptr string) string {
+	path := ""
+	for _, token := range pointerTokens(ptr) {
+		switch val := v.(type) {
+		case []interface{}:
+			path = fmt.Sprintf("%s[%s]", path, token)
+			if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(val) {
+				v = val[i]
+			}
+		case map[string]interface{}:
+			path = join(path, token)
+			v = val[token]
+		default:
+			path = join(path, token)
+		}
+	}
+	return path
+}
+
+//pointerPath converts a JSON pointer into a schema to a dotted path
+func pointerPath(ptr string) string {
+	return strings.Join(pointerTokens(ptr), ".")
+}
+
+func pointerTokens(ptr string) []string {
+	ptr = strings.TrimPrefix(strings.TrimPrefix(ptr, "#"), "/")
+	if ptr == "" {
+		return nil
+	}
+
+	tokens := strings.Split(ptr, "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+//at prefixes a schema error with where in the schema it was found
+func at(path string) string {
+	if path == "" {
+		return ""
+	}
+	return fmt.Sprintf("at `%s`: ", path)
+}
@@ -0,0 +1,112 @@
+package schema_test
+
+import (
+	"github.com/smartatransit/feedback/schema"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const serviceConditionSchema = `{
+	"type": "object",
+	"additionalProperties": false,
+	"required": ["crowding"],
+	"properties": {
+		"crowding": {"type": "string", "enum": ["empty", "seats", "standing", "full"]},
+		"cleanliness": {"type": "integer", "minimum": 1, "maximum": 5},
+		"temperature": {"type": "string", "enum": ["cold", "comfortable", "hot"]},
+		"note": {"type": "string", "maxLength": 5, "pattern": "^[a-z]*$"},
+		"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}},
+		"stop": {"type": "object", "properties": {"code": {"type": ["string", "null"]}}}
+	}
+}`
+
+var _ = Describe("Schema", func() {
+	var s *schema.Schema
+
+	BeforeEach(func() {
+		var err error
+		s, err = schema.Parse([]byte(serviceConditionSchema))
+		Expect(err).To(BeNil())
+	})
+
+	validate := func(doc string) error {
+		v, err := schema.Decode([]byte(doc))
+		Expect(err).To(BeNil())
+		return s.Validate(v)
+	}
+
+	It("accepts matching values", func() {
+		Expect(validate(`{"crowding": "full", "cleanliness": 4, "tags": ["ac"], "stop": {"code": null}}`)).To(Succeed())
+		Expect(validate(`{"crowding": "seats", "cleanliness": 2}`)).To(Succeed())
+	})
+	It("requires required properties", func() {
+		Expect(validate(`{"cleanliness": 4}`)).To(MatchError(`missing properties: "crowding"`))
+	})
+	It("rejects unknown properties when closed", func() {
+		Expect(validate(`{"crowding": "full", "smell": "bad"}`)).To(MatchError(`additionalProperties "smell" not allowed`))
+	})
+	It("checks types", func() {
+		Expect(validate(`[]`)).To(MatchError("expected object, but got array"))
+		Expect(validate(`{"crowding": "full", "cleanliness": 4.5}`)).To(MatchError("`cleanliness` expected integer, but got number"))
+		Expect(validate(`{"crowding": "full", "stop": {"code": 12}}`)).To(MatchError("`stop.code` expected string or null, but got number"))
+	})
+	It("checks enums", func() {
+		Expect(validate(`{"crowding": "packed"}`)).To(MatchError("`crowding` value must be one of \"empty\", \"seats\", \"standing\", \"full\""))
+	})
+	It("checks bounds", func() {
+		Expect(validate(`{"crowding": "full", "cleanliness": 0}`)).To(MatchError("`cleanliness` must be >= 1 but found 0"))
+		Expect(validate(`{"crowding": "full", "cleanliness": 6}`)).To(MatchError("`cleanliness` must be <= 5 but found 6"))
+		Expect(validate(`{"crowding": "full", "note": "toolong"}`)).To(MatchError("`note` length must be <= 5, but got 7"))
+		Expect(validate(`{"crowding": "full", "note": "AB"}`)).To(MatchError("`note` does not match pattern \"^[a-z]*$\""))
+		Expect(validate(`{"crowding": "full", "tags": ["a", "b", "c"]}`)).To(MatchError("`tags` maximum 2 items allowed, but found 3 items"))
+		Expect(validate(`{"crowding": "full", "tags": ["a", 1]}`)).To(MatchError("`tags[1]` expected string, but got number"))
+	})
+	It("supports the rest of draft 7", func() {
+		s, err := schema.Parse([]byte(`{
+			"definitions": {"level": {"type": "integer", "multipleOf": 10}},
+			"properties": {"noise": {"$ref": "#/definitions/level"}, "seat": {"oneOf": [{"const": "window"}, {"const": "aisle"}]}}
+		}`))
+		Expect(err).To(BeNil())
+
+		v, err := schema.Decode([]byte(`{"noise": 25}`))
+		Expect(err).To(BeNil())
+		Expect(s.Validate(v)).To(MatchError("`noise` 25 not multipleOf 10"))
+		v, err = schema.Decode([]byte(`{"noise": 30, "seat": "aisle"}`))
+		Expect(err).To(BeNil())
+		Expect(s.Validate(v)).To(Succeed())
+		Expect(s.PropertyType([]string{"noise"})).To(Equal([]string{"integer"}))
+	})
+	It("reports the types of properties", func() {
+		Expect(s.PropertyType([]string{"cleanliness"})).To(Equal([]string{"integer"}))
+		Expect(s.PropertyType([]string{"stop", "code"})).To(Equal([]string{"string", "null"}))
+		Expect(s.PropertyType([]string{"smell"})).To(BeNil())
+	})
+
+	Describe("Parse", func() {
+		It("rejects schemas that don't match draft 7", func() {
+			_, err := schema.Parse([]byte(`{"properties": {"a": {"minimum": "x"}}}`))
+			Expect(err).To(MatchError("invalid schema: at `properties.a.minimum`: expected number, but got string"))
+		})
+		It("rejects unknown keywords", func() {
+			_, err := schema.Parse([]byte(`{"properties": {"a": {"anyOf": [{"maxLenght": 5}]}}}`))
+			Expect(err).To(MatchError("invalid schema: at `a.anyOf[0]`: unsupported keyword `maxLenght`"))
+		})
+		It("rejects other drafts", func() {
+			_, err := schema.Parse([]byte(`{"$schema": "http://json-schema.org/draft-04/schema#"}`))
+			Expect(err).To(MatchError("invalid schema: unsupported `$schema` `http://json-schema.org/draft-04/schema#`, only draft 7 is supported"))
+		})
+		It("rejects references to other documents", func() {
+			_, err := schema.Parse([]byte(`{"properties": {"a": {"$ref": "file:///etc/passwd"}}}`))
+			Expect(err).To(MatchError("invalid schema: at `a`: `$ref` `file:///etc/passwd` must point within the schema"))
+		})
+		It("ignores annotations", func() {
+			_, err := schema.Parse([]byte(`{"title": "Details", "description": "how it was", "type": "object"}`))
+			Expect(err).To(BeNil())
+		})
+		It("rejects malformed JSON", func() {
+			_, err := schema.Parse([]byte(`{"type": `))
+			Expect(err).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,212 @@
+package spam
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+//DuplicateRule scores messages that the same session has already sent
+//recently
+type DuplicateRule struct {
+	db     db.DB
+	window time.Duration
+	now    func() time.Time
+}
+
+//NewDuplicateRule returns a new DuplicateRule looking back over window
+func NewDuplicateRule(
+	db db.DB,
+	window time.Duration,
+	now func() time.Time,
+) DuplicateRule {
+	return DuplicateRule{
+		db:     db,
+		window: window,
+		now:    now,
+	}
+}
+
+//Score implements Rule
+func (r DuplicateRule) Score(ctx context.Context, fb db.Feedback) (float64, string, error) {
+	if fb.Message == nil {
+		return 0, "", nil
+	}
+
+	count, err := r.db.CountDuplicateMessages(ctx, fb.SessionID, *fb.Message, r.now().Add(-r.window))
+	if err != nil {
+		return 0, "", err
+	}
+	if count == 0 {
+		return 0, "", nil
+	}
+
+	return math.Min(float64(count)*0.5, 1), fmt.Sprintf("duplicate of %d recent messages", count), nil
+}
+
+var linkRegexp = regexp.MustCompile(`(?i)\b(https?://|www\.)\S+|\b[a-z0-9-]+\.(com|net|org|info|biz|io|ru|xyz|top)\b`)
+
+//LinkRule scores messages made up largely of links
+type LinkRule struct {
+	maxLinks int
+}
+
+//NewLinkRule returns a new LinkRule tolerating up to maxLinks links
+func NewLinkRule(maxLinks int) LinkRule {
+	return LinkRule{maxLinks: maxLinks}
+}
+
+//Score implements Rule
+func (r LinkRule) Score(ctx context.Context, fb db.Feedback) (float64, string, error) {
+	if fb.Message == nil {
+		return 0, "", nil
+	}
+
+	links := len(linkRegexp.FindAllString(*fb.Message, -1))
+	if links == 0 {
+		return 0, "", nil
+	}
+
+	words := len(strings.Fields(*fb.Message))
+	density := float64(links) / float64(words)
+	if links <= r.maxLinks && density < 0.25 {
+		return 0, "", nil
+	}
+
+	return math.Min(0.5+density, 1), fmt.Sprintf("%d links in %d words", links, words), nil
+}
+
+//LengthRule scores overly long or highly repetitive messages
+type LengthRule struct {
+	maxLength int
+}
+
+//NewLengthRule returns a new LengthRule tolerating up to maxLength characters
+func NewLengthRule(maxLength int) LengthRule {
+	return LengthRule{maxLength: maxLength}
+}
+
+//Score implements Rule
+func (r LengthRule) Score(ctx context.Context, fb db.Feedback) (float64, string, error) {
+	if fb.Message == nil {
+		return 0, "", nil
+	}
+	msg := *fb.Message
+
+	if length := len([]rune(msg)); length > r.maxLength {
+		return 1, fmt.Sprintf("message length %d exceeds %d", length, r.maxLength), nil
+	}
+
+	if longestRun(msg) >= 10 {
+		return 0.5, "repeated characters", nil
+	}
+
+	words := strings.Fields(strings.ToLower(msg))
+	if len(words) >= 10 {
+		unique := map[string]struct{}{}
+		for _, w := range words {
+			unique[w] = struct{}{}
+		}
+		if ratio := float64(len(unique)) / float64(len(words)); ratio < 0.3 {
+			return 0.75, fmt.Sprintf("repetitive text (%d unique of %d words)", len(unique), len(words)), nil
+		}
+	}
+
+	return 0, "", nil
+}
+
+//longestRun returns the length of the longest run of a single repeated character
+func longestRun(s string) int {
+	longest, run := 0, 0
+	var prev rune
+	for i, r := range s {
+		if i > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		prev = r
+	}
+	return longest
+}
+
+//BlocklistRule scores messages containing blocklisted terms
+type BlocklistRule struct {
+	terms []*regexp.Regexp
+}
+
+//NewBlocklistRule returns a new BlocklistRule matching each term as a whole
+//word or phrase, ignoring case
+func NewBlocklistRule(terms []string) BlocklistRule {
+	var rule BlocklistRule
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		pattern := regexp.QuoteMeta(term)
+		//\b only matches next to a word character, so a term starting or
+		//ending with punctuation, such as `$$$`, is only anchored on the
+		//other side
+		if isWordByte(term[0]) {
+			pattern = `\b` + pattern
+		}
+		if isWordByte(term[len(term)-1]) {
+			pattern += `\b`
+		}
+		rule.terms = append(rule.terms, regexp.MustCompile(`(?i)`+pattern))
+	}
+	return rule
+}
+
+//isWordByte reports whether b is a word character as \b understands it
+func isWordByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+//ReadBlocklist reads one term per line, skipping blank lines and lines
+//starting with #
+func ReadBlocklist(r io.Reader) ([]string, error) {
+	var terms []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed reading blocklist: %w", err)
+	}
+	return terms, nil
+}
+
+//Score implements Rule
+func (r BlocklistRule) Score(ctx context.Context, fb db.Feedback) (float64, string, error) {
+	if fb.Message == nil {
+		return 0, "", nil
+	}
+
+	hits := 0
+	for _, term := range r.terms {
+		if term.MatchString(*fb.Message) {
+			hits++
+		}
+	}
+	if hits == 0 {
+		return 0, "", nil
+	}
+
+	return math.Min(float64(hits), 2), fmt.Sprintf("%d blocklisted terms", hits), nil
+}
@@ -0,0 +1,99 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+//Action is what should happen to feedback after scoring
+type Action string
+
+//Actions
+const (
+	ActionNone    Action = "none"
+	ActionSilence Action = "silence"
+	ActionHold    Action = "hold"
+)
+
+//Verdict is the outcome of scoring a feedback record
+type Verdict struct {
+	Score   float64
+	Reasons []string
+	Action  Action
+}
+
+//Apply records v on fb, silencing it or holding it for moderation as needed
+func (v Verdict) Apply(fb *db.Feedback) {
+	fb.SpamScore = v.Score
+	if len(v.Reasons) > 0 {
+		reason := strings.Join(v.Reasons, "; ")
+		fb.SpamReason = &reason
+	}
+
+	switch v.Action {
+	case ActionSilence:
+		fb.Silenced = true
+	case ActionHold:
+		fb.ModerationStatus = db.ModerationPending
+	}
+}
+
+//Rule contributes to a feedback record's spam score. A score of zero means
+//the rule found nothing suspicious; reason explains any positive score.
+//go:generate counterfeiter . Rule
+type Rule interface {
+	Score(ctx context.Context, fb db.Feedback) (score float64, reason string, err error)
+}
+
+//Scorer evaluates incoming feedback before it is saved
+//go:generate counterfeiter . Scorer
+type Scorer interface {
+	Evaluate(ctx context.Context, fb db.Feedback) (Verdict, error)
+}
+
+//Pipeline implements Scorer by summing the scores of a list of rules
+type Pipeline struct {
+	rules     []Rule
+	threshold float64
+	action    Action
+}
+
+//NewPipeline returns a new Pipeline that applies action to feedback whose
+//total score reaches threshold
+func NewPipeline(
+	threshold float64,
+	action Action,
+	rules ...Rule,
+) Pipeline {
+	return Pipeline{
+		rules:     rules,
+		threshold: threshold,
+		action:    action,
+	}
+}
+
+//Evaluate runs every rule against fb
+func (p Pipeline) Evaluate(ctx context.Context, fb db.Feedback) (Verdict, error) {
+	verdict := Verdict{Action: ActionNone}
+	for _, rule := range p.rules {
+		score, reason, err := rule.Score(ctx, fb)
+		if err != nil {
+			return Verdict{}, fmt.Errorf("failed scoring feedback: %w", err)
+		}
+		if score <= 0 {
+			continue
+		}
+
+		verdict.Score += score
+		verdict.Reasons = append(verdict.Reasons, reason)
+	}
+
+	if verdict.Score >= p.threshold {
+		verdict.Action = p.action
+	}
+
+	return verdict, nil
+}
@@ -0,0 +1,13 @@
+package spam_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSpam(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Spam Suite")
+}
@@ -0,0 +1,148 @@
+package spam_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/spam"
+	"github.com/smartatransit/feedback/spam/spamfakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spam", func() {
+	feedbackWith := func(msg string) dbp.Feedback {
+		return dbp.Feedback{SessionID: "sess", Kind: "comment", Message: &msg}
+	}
+
+	Describe("Pipeline", func() {
+		var (
+			ruleA, ruleB *spamfakes.FakeRule
+			verdict      spam.Verdict
+			callErr      error
+		)
+
+		BeforeEach(func() {
+			ruleA = &spamfakes.FakeRule{}
+			ruleB = &spamfakes.FakeRule{}
+			ruleA.ScoreReturns(0.5, "links", nil)
+			ruleB.ScoreReturns(0, "", nil)
+		})
+
+		JustBeforeEach(func() {
+			verdict, callErr = spam.NewPipeline(1, spam.ActionHold, ruleA, ruleB).
+				Evaluate(context.Background(), feedbackWith("hi"))
+		})
+
+		When("the score stays under the threshold", func() {
+			It("takes no action", func() {
+				Expect(callErr).To(BeNil())
+				Expect(verdict).To(Equal(spam.Verdict{Score: 0.5, Reasons: []string{"links"}, Action: spam.ActionNone}))
+			})
+		})
+		When("the score reaches the threshold", func() {
+			BeforeEach(func() {
+				ruleB.ScoreReturns(0.5, "duplicate", nil)
+			})
+			It("applies the configured action", func() {
+				Expect(verdict).To(Equal(spam.Verdict{Score: 1, Reasons: []string{"links", "duplicate"}, Action: spam.ActionHold}))
+
+				var fb dbp.Feedback
+				verdict.Apply(&fb)
+				Expect(fb.ModerationStatus).To(Equal(dbp.ModerationPending))
+				Expect(fb.Silenced).To(BeFalse())
+				Expect(fb.SpamScore).To(BeEquivalentTo(1))
+				Expect(*fb.SpamReason).To(Equal("links; duplicate"))
+			})
+		})
+		When("a rule fails", func() {
+			BeforeEach(func() {
+				ruleB.ScoreReturns(0, "", errors.New("select failed"))
+			})
+			It("returns an error", func() {
+				Expect(callErr).To(MatchError("failed scoring feedback: select failed"))
+			})
+		})
+	})
+
+	Describe("DuplicateRule", func() {
+		It("scores repeated messages from the session", func() {
+			database := &dbfakes.FakeDB{}
+			database.CountDuplicateMessagesReturns(2, nil)
+			now := time.Now()
+
+			score, reason, err := spam.NewDuplicateRule(database, time.Hour, func() time.Time { return now }).
+				Score(context.Background(), feedbackWith("the train is late"))
+			Expect(err).To(BeNil())
+			Expect(score).To(BeEquivalentTo(1))
+			Expect(reason).To(Equal("duplicate of 2 recent messages"))
+
+			_, session, msg, since := database.CountDuplicateMessagesArgsForCall(0)
+			Expect(session).To(Equal("sess"))
+			Expect(msg).To(Equal("the train is late"))
+			Expect(since).To(Equal(now.Add(-time.Hour)))
+		})
+	})
+
+	Describe("LinkRule", func() {
+		It("ignores a single link in a sentence", func() {
+			score, _, _ := spam.NewLinkRule(1).Score(context.Background(),
+				feedbackWith("the schedule at https://itsmarta.com is wrong for the red line today"))
+			Expect(score).To(BeZero())
+		})
+		It("scores link-heavy messages", func() {
+			score, reason, _ := spam.NewLinkRule(1).Score(context.Background(),
+				feedbackWith("cheap pills http://a.example www.b.example"))
+			Expect(score).To(BeNumerically(">", 0.5))
+			Expect(reason).To(Equal("2 links in 4 words"))
+		})
+	})
+
+	Describe("LengthRule", func() {
+		It("scores overly long messages", func() {
+			score, _, _ := spam.NewLengthRule(10).Score(context.Background(), feedbackWith("this is far too long"))
+			Expect(score).To(BeEquivalentTo(1))
+		})
+		It("scores repeated characters", func() {
+			_, reason, _ := spam.NewLengthRule(100).Score(context.Background(), feedbackWith("helloooooooooooo"))
+			Expect(reason).To(Equal("repeated characters"))
+		})
+		It("scores repeated words", func() {
+			_, reason, _ := spam.NewLengthRule(1000).Score(context.Background(), feedbackWith(strings.Repeat("bad train ", 10)))
+			Expect(reason).To(Equal("repetitive text (2 unique of 20 words)"))
+		})
+		It("ignores ordinary messages", func() {
+			score, _, _ := spam.NewLengthRule(1000).Score(context.Background(), feedbackWith("the escalator at Five Points is broken again"))
+			Expect(score).To(BeZero())
+		})
+	})
+
+	Describe("BlocklistRule", func() {
+		It("matches whole terms ignoring case", func() {
+			terms, err := spam.ReadBlocklist(strings.NewReader("# comment\nfree money\n\ncasino\n"))
+			Expect(err).To(BeNil())
+			rule := spam.NewBlocklistRule(terms)
+
+			score, reason, _ := rule.Score(context.Background(), feedbackWith("FREE MONEY at the Casino"))
+			Expect(score).To(BeEquivalentTo(2))
+			Expect(reason).To(Equal("2 blocklisted terms"))
+
+			score, _, _ = rule.Score(context.Background(), feedbackWith("casinos are nearby"))
+			Expect(score).To(BeZero())
+		})
+		It("matches terms starting or ending with punctuation", func() {
+			rule := spam.NewBlocklistRule([]string{"$$$", "c.o.d.", "#win"})
+
+			_, reason, _ := rule.Score(context.Background(), feedbackWith("make $$$ fast, pay c.o.d. #win"))
+			Expect(reason).To(Equal("3 blocklisted terms"))
+
+			score, _, _ := rule.Score(context.Background(), feedbackWith("#winner"))
+			Expect(score).To(BeZero())
+		})
+	})
+})
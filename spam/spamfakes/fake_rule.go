@@ -0,0 +1,124 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package spamfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/spam"
+)
+
+type FakeRule struct {
+	ScoreStub        func(context.Context, db.Feedback) (float64, string, error)
+	scoreMutex       sync.RWMutex
+	scoreArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.Feedback
+	}
+	scoreReturns struct {
+		result1 float64
+		result2 string
+		result3 error
+	}
+	scoreReturnsOnCall map[int]struct {
+		result1 float64
+		result2 string
+		result3 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRule) Score(arg1 context.Context, arg2 db.Feedback) (float64, string, error) {
+	fake.scoreMutex.Lock()
+	ret, specificReturn := fake.scoreReturnsOnCall[len(fake.scoreArgsForCall)]
+	fake.scoreArgsForCall = append(fake.scoreArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.Feedback
+	}{arg1, arg2})
+	fake.recordInvocation("Score", []interface{}{arg1, arg2})
+	fake.scoreMutex.Unlock()
+	if fake.ScoreStub != nil {
+		return fake.ScoreStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	fakeReturns := fake.scoreReturns
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeRule) ScoreCallCount() int {
+	fake.scoreMutex.RLock()
+	defer fake.scoreMutex.RUnlock()
+	return len(fake.scoreArgsForCall)
+}
+
+func (fake *FakeRule) ScoreCalls(stub func(context.Context, db.Feedback) (float64, string, error)) {
+	fake.scoreMutex.Lock()
+	defer fake.scoreMutex.Unlock()
+	fake.ScoreStub = stub
+}
+
+func (fake *FakeRule) ScoreArgsForCall(i int) (context.Context, db.Feedback) {
+	fake.scoreMutex.RLock()
+	defer fake.scoreMutex.RUnlock()
+	argsForCall := fake.scoreArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRule) ScoreReturns(result1 float64, result2 string, result3 error) {
+	fake.scoreMutex.Lock()
+	defer fake.scoreMutex.Unlock()
+	fake.ScoreStub = nil
+	fake.scoreReturns = struct {
+		result1 float64
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeRule) ScoreReturnsOnCall(i int, result1 float64, result2 string, result3 error) {
+	fake.scoreMutex.Lock()
+	defer fake.scoreMutex.Unlock()
+	fake.ScoreStub = nil
+	if fake.scoreReturnsOnCall == nil {
+		fake.scoreReturnsOnCall = make(map[int]struct {
+			result1 float64
+			result2 string
+			result3 error
+		})
+	}
+	fake.scoreReturnsOnCall[i] = struct {
+		result1 float64
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeRule) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.scoreMutex.RLock()
+	defer fake.scoreMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRule) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ spam.Rule = new(FakeRule)
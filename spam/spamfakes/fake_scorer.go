@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package spamfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/spam"
+)
+
+type FakeScorer struct {
+	EvaluateStub        func(context.Context, db.Feedback) (spam.Verdict, error)
+	evaluateMutex       sync.RWMutex
+	evaluateArgsForCall []struct {
+		arg1 context.Context
+		arg2 db.Feedback
+	}
+	evaluateReturns struct {
+		result1 spam.Verdict
+		result2 error
+	}
+	evaluateReturnsOnCall map[int]struct {
+		result1 spam.Verdict
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeScorer) Evaluate(arg1 context.Context, arg2 db.Feedback) (spam.Verdict, error) {
+	fake.evaluateMutex.Lock()
+	ret, specificReturn := fake.evaluateReturnsOnCall[len(fake.evaluateArgsForCall)]
+	fake.evaluateArgsForCall = append(fake.evaluateArgsForCall, struct {
+		arg1 context.Context
+		arg2 db.Feedback
+	}{arg1, arg2})
+	fake.recordInvocation("Evaluate", []interface{}{arg1, arg2})
+	fake.evaluateMutex.Unlock()
+	if fake.EvaluateStub != nil {
+		return fake.EvaluateStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.evaluateReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeScorer) EvaluateCallCount() int {
+	fake.evaluateMutex.RLock()
+	defer fake.evaluateMutex.RUnlock()
+	return len(fake.evaluateArgsForCall)
+}
+
+func (fake *FakeScorer) EvaluateCalls(stub func(context.Context, db.Feedback) (spam.Verdict, error)) {
+	fake.evaluateMutex.Lock()
+	defer fake.evaluateMutex.Unlock()
+	fake.EvaluateStub = stub
+}
+
+func (fake *FakeScorer) EvaluateArgsForCall(i int) (context.Context, db.Feedback) {
+	fake.evaluateMutex.RLock()
+	defer fake.evaluateMutex.RUnlock()
+	argsForCall := fake.evaluateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeScorer) EvaluateReturns(result1 spam.Verdict, result2 error) {
+	fake.evaluateMutex.Lock()
+	defer fake.evaluateMutex.Unlock()
+	fake.EvaluateStub = nil
+	fake.evaluateReturns = struct {
+		result1 spam.Verdict
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScorer) EvaluateReturnsOnCall(i int, result1 spam.Verdict, result2 error) {
+	fake.evaluateMutex.Lock()
+	defer fake.evaluateMutex.Unlock()
+	fake.EvaluateStub = nil
+	if fake.evaluateReturnsOnCall == nil {
+		fake.evaluateReturnsOnCall = make(map[int]struct {
+			result1 spam.Verdict
+			result2 error
+		})
+	}
+	fake.evaluateReturnsOnCall[i] = struct {
+		result1 spam.Verdict
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeScorer) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.evaluateMutex.RLock()
+	defer fake.evaluateMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeScorer) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ spam.Scorer = new(FakeScorer)
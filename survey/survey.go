@@ -0,0 +1,343 @@
+package survey
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/smartatransit/feedback/db"
+)
+
+//Question types
+const (
+	Scale        = "scale"
+	SingleChoice = "single_choice"
+	MultiChoice  = "multi_choice"
+	FreeText     = "free_text"
+)
+
+//Limits on survey definitions and answers
+const (
+	MaxQuestions     = 50
+	MaxChoices       = 50
+	MaxScaleSteps    = 101
+	MaxTextLength    = 2000
+	defaultMaxLength = 500
+)
+
+//Question is a single question of a survey version. Min and Max bound the
+//answers to scale questions, Choices lists the answers to choice questions,
+//and MaxLength limits the answers to free text questions.
+type Question struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Prompt    string   `json:"prompt"`
+	Required  bool     `json:"required"`
+	Min       *int     `json:"min,omitempty"`
+	Max       *int     `json:"max,omitempty"`
+	Choices   []string `json:"choices,omitempty"`
+	MaxLength int      `json:"max_length,omitempty"`
+}
+
+//IsNPS reports whether q is a 0 to 10 scale, the net promoter score question
+func (q Question) IsNPS() bool {
+	return q.Type == Scale && *q.Min == 0 && *q.Max == 10
+}
+
+var idRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+//Validate checks a survey version's questions, filling in defaults
+func Validate(questions []Question) error {
+	if len(questions) == 0 {
+		return errors.New("a survey needs at least one question")
+	}
+	if len(questions) > MaxQuestions {
+		return fmt.Errorf("a survey may have at most %d questions", MaxQuestions)
+	}
+
+	seen := map[string]bool{}
+	for i := range questions {
+		q := &questions[i]
+		if !idRegexp.MatchString(q.ID) {
+			return fmt.Errorf("invalid question ID `%s`", q.ID)
+		}
+		if seen[q.ID] {
+			return fmt.Errorf("question ID `%s` is used more than once", q.ID)
+		}
+		seen[q.ID] = true
+
+		if strings.TrimSpace(q.Prompt) == "" {
+			return fmt.Errorf("question `%s` needs a prompt", q.ID)
+		}
+
+		if err := validateQuestion(q); err != nil {
+			return fmt.Errorf("question `%s` %s", q.ID, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func validateQuestion(q *Question) error {
+	if q.Type != Scale && (q.Min != nil || q.Max != nil) {
+		return errors.New("can only have `min` and `max` if it is a scale")
+	}
+	if q.Type != SingleChoice && q.Type != MultiChoice && q.Choices != nil {
+		return errors.New("can only have `choices` if it is a choice question")
+	}
+	if q.Type != FreeText && q.MaxLength != 0 {
+		return errors.New("can only have `max_length` if it is free text")
+	}
+
+	switch q.Type {
+	case Scale:
+		if q.Min == nil || q.Max == nil {
+			return errors.New("needs `min` and `max`")
+		}
+		if *q.Min >= *q.Max || *q.Max-*q.Min+1 > MaxScaleSteps {
+			return fmt.Errorf("needs `min` below `max`, with at most %d steps", MaxScaleSteps)
+		}
+	case SingleChoice, MultiChoice:
+		if len(q.Choices) < 2 || len(q.Choices) > MaxChoices {
+			return fmt.Errorf("needs between 2 and %d choices", MaxChoices)
+		}
+		seen := map[string]bool{}
+		for _, choice := range q.Choices {
+			if !idRegexp.MatchString(choice) {
+				return fmt.Errorf("has invalid choice `%s`", choice)
+			}
+			if seen[choice] {
+				return fmt.Errorf("has choice `%s` more than once", choice)
+			}
+			seen[choice] = true
+		}
+	case FreeText:
+		if q.MaxLength == 0 {
+			q.MaxLength = defaultMaxLength
+		}
+		if q.MaxLength < 0 || q.MaxLength > MaxTextLength {
+			return fmt.Errorf("needs a `max_length` of at most %d", MaxTextLength)
+		}
+	default:
+		return fmt.Errorf("has unknown type `%s`", q.Type)
+	}
+
+	return nil
+}
+
+//Answers converts a rider's answers, keyed by question ID, to the stored
+//form, checking each against its question. Questions that aren't required
+//may be omitted or null, but at least one question must be answered.
+func Answers(questions []Question, answers map[string]json.RawMessage) ([]db.SurveyAnswer, error) {
+	known := map[string]bool{}
+	for _, q := range questions {
+		known[q.ID] = true
+	}
+	for _, id := range sortedKeys(answers) {
+		if !known[id] {
+			return nil, fmt.Errorf("unknown question `%s`", id)
+		}
+	}
+
+	result := []db.SurveyAnswer{}
+	for _, q := range questions {
+		raw, ok := answers[q.ID]
+		if !ok || bytes.Equal(raw, []byte("null")) {
+			if q.Required {
+				return nil, fmt.Errorf("`%s` is required", q.ID)
+			}
+			continue
+		}
+
+		answer, err := convert(q, raw)
+		if err != nil {
+			return nil, fmt.Errorf("`%s` %s", q.ID, err.Error())
+		}
+		result = append(result, answer)
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("at least one question must be answered")
+	}
+
+	return result, nil
+}
+
+func convert(q Question, raw json.RawMessage) (db.SurveyAnswer, error) {
+	answer := db.SurveyAnswer{QuestionID: q.ID}
+
+	switch q.Type {
+	case Scale:
+		var n int
+		if err := json.Unmarshal(raw, &n); err != nil || n < *q.Min || n > *q.Max {
+			return answer, fmt.Errorf("must be a whole number from %d to %d", *q.Min, *q.Max)
+		}
+		answer.Number = &n
+
+	case SingleChoice:
+		var choice string
+		if err := json.Unmarshal(raw, &choice); err != nil || !contains(q.Choices, choice) {
+			return answer, fmt.Errorf("must be one of %s", strings.Join(q.Choices, ", "))
+		}
+		answer.Choices = []string{choice}
+
+	case MultiChoice:
+		var choices []string
+		if err := json.Unmarshal(raw, &choices); err != nil {
+			return answer, errors.New("must be a list of choices")
+		}
+		if len(choices) == 0 {
+			return answer, errors.New("must include at least one choice")
+		}
+		seen := map[string]bool{}
+		for _, choice := range choices {
+			if !contains(q.Choices, choice) {
+				return answer, fmt.Errorf("may only include %s", strings.Join(q.Choices, ", "))
+			}
+			if seen[choice] {
+				return answer, fmt.Errorf("includes `%s` more than once", choice)
+			}
+			seen[choice] = true
+		}
+		answer.Choices = choices
+
+	case FreeText:
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return answer, errors.New("must be text")
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return answer, errors.New("must not be blank")
+		}
+		if len([]rune(text)) > q.MaxLength {
+			return answer, fmt.Errorf("must be at most %d characters", q.MaxLength)
+		}
+		answer.Text = &text
+	}
+
+	return answer, nil
+}
+
+//Results summarizes the responses to a survey version
+type Results struct {
+	Responses int               `json:"responses"`
+	Questions []QuestionResults `json:"questions"`
+}
+
+//QuestionResults summarizes the answers to one question. Distribution
+//counts the responses giving each answer, including those nobody gave; a
+//multi choice response counts once for each choice. Average is set for
+//scale questions, and NPS for 0 to 10 scales: the percentage of promoters
+//(9 or 10) less the percentage of detractors (0 to 6).
+type QuestionResults struct {
+	ID           string         `json:"id"`
+	Type         string         `json:"type"`
+	Prompt       string         `json:"prompt"`
+	Answered     int            `json:"answered"`
+	Distribution map[string]int `json:"distribution,omitempty"`
+	Average      *float64       `json:"average,omitempty"`
+	NPS          *float64       `json:"nps,omitempty"`
+}
+
+//Summarize computes the results of a survey version from its answer counts
+func Summarize(questions []Question, counts []db.SurveyAnswerCount) Results {
+	results := Results{Questions: []QuestionResults{}}
+
+	byQuestion := map[string][]db.SurveyAnswerCount{}
+	for _, count := range counts {
+		if count.QuestionID == nil {
+			results.Responses = count.Count
+			continue
+		}
+		byQuestion[*count.QuestionID] = append(byQuestion[*count.QuestionID], count)
+	}
+
+	for _, q := range questions {
+		results.Questions = append(results.Questions, summarizeQuestion(q, byQuestion[q.ID]))
+	}
+
+	return results
+}
+
+func summarizeQuestion(q Question, counts []db.SurveyAnswerCount) QuestionResults {
+	result := QuestionResults{
+		ID:     q.ID,
+		Type:   q.Type,
+		Prompt: q.Prompt,
+	}
+
+	switch q.Type {
+	case Scale:
+		result.Distribution = map[string]int{}
+		for n := *q.Min; n <= *q.Max; n++ {
+			result.Distribution[strconv.Itoa(n)] = 0
+		}
+	case SingleChoice, MultiChoice:
+		result.Distribution = map[string]int{}
+		for _, choice := range q.Choices {
+			result.Distribution[choice] = 0
+		}
+	}
+
+	for _, count := range counts {
+		if count.Total {
+			result.Answered = count.Count
+			continue
+		}
+		if count.Answer == nil || result.Distribution == nil {
+			continue
+		}
+		result.Distribution[*count.Answer] += count.Count
+	}
+
+	if q.Type != Scale || result.Answered == 0 {
+		return result
+	}
+
+	var sum, promoters, detractors int
+	for answer, n := range result.Distribution {
+		value, err := strconv.Atoi(answer)
+		if err != nil {
+			continue
+		}
+		sum += value * n
+		if value >= 9 {
+			promoters += n
+		} else if value <= 6 {
+			detractors += n
+		}
+	}
+
+	average := float64(sum) / float64(result.Answered)
+	result.Average = &average
+	if q.IsNPS() {
+		nps := float64(promoters-detractors) * 100 / float64(result.Answered)
+		result.NPS = &nps
+	}
+
+	return result
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
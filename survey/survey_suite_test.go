@@ -0,0 +1,13 @@
+package survey_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSurvey(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Survey Suite")
+}
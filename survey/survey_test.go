@@ -0,0 +1,178 @@
+package survey_test
+
+import (
+	"encoding/json"
+
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/survey"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+func ptrToInt(n int) *int {
+	return &n
+}
+
+func ptrToString(s string) *string {
+	return &s
+}
+
+var _ = Describe("Survey", func() {
+	var questions []survey.Question
+
+	BeforeEach(func() {
+		questions = []survey.Question{
+			{ID: "trip", Type: survey.Scale, Prompt: "Rate your trip", Required: true, Min: ptrToInt(1), Max: ptrToInt(5)},
+			{ID: "recommend", Type: survey.Scale, Prompt: "Would you recommend MARTA?", Min: ptrToInt(0), Max: ptrToInt(10)},
+			{ID: "mode", Type: survey.SingleChoice, Prompt: "How did you travel?", Choices: []string{"bus", "train"}},
+			{ID: "improve", Type: survey.MultiChoice, Prompt: "What should improve?", Choices: []string{"cleanliness", "frequency", "safety"}},
+			{ID: "comment", Type: survey.FreeText, Prompt: "Anything else?"},
+		}
+	})
+
+	Describe("Validate", func() {
+		It("accepts valid questions and fills in defaults", func() {
+			Expect(survey.Validate(questions)).To(Succeed())
+			Expect(questions[4].MaxLength).To(Equal(500))
+		})
+		It("rejects repeated question IDs", func() {
+			questions[1].ID = "trip"
+			Expect(survey.Validate(questions)).To(MatchError("question ID `trip` is used more than once"))
+		})
+		It("rejects scales without bounds", func() {
+			questions[0].Max = nil
+			Expect(survey.Validate(questions)).To(MatchError("question `trip` needs `min` and `max`"))
+		})
+		It("rejects choice questions with too few choices", func() {
+			questions[2].Choices = []string{"bus"}
+			Expect(survey.Validate(questions)).To(MatchError("question `mode` needs between 2 and 50 choices"))
+		})
+		It("rejects settings that don't apply to the type", func() {
+			questions[4].Choices = []string{"a", "b"}
+			Expect(survey.Validate(questions)).To(MatchError("question `comment` can only have `choices` if it is a choice question"))
+		})
+		It("rejects unknown types", func() {
+			questions[4].Type = "essay"
+			Expect(survey.Validate(questions)).To(MatchError("question `comment` has unknown type `essay`"))
+		})
+		It("rejects empty surveys", func() {
+			Expect(survey.Validate(nil)).To(MatchError("a survey needs at least one question"))
+		})
+	})
+
+	Describe("Answers", func() {
+		var answers map[string]json.RawMessage
+
+		BeforeEach(func() {
+			Expect(survey.Validate(questions)).To(Succeed())
+			answers = map[string]json.RawMessage{
+				"trip":      json.RawMessage(`4`),
+				"recommend": json.RawMessage(`null`),
+				"mode":      json.RawMessage(`"bus"`),
+				"improve":   json.RawMessage(`["safety", "cleanliness"]`),
+				"comment":   json.RawMessage(`"  more buses  "`),
+			}
+		})
+
+		It("converts valid answers", func() {
+			result, err := survey.Answers(questions, answers)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal([]db.SurveyAnswer{
+				{QuestionID: "trip", Number: ptrToInt(4)},
+				{QuestionID: "mode", Choices: []string{"bus"}},
+				{QuestionID: "improve", Choices: []string{"safety", "cleanliness"}},
+				{QuestionID: "comment", Text: ptrToString("more buses")},
+			}))
+		})
+		It("requires required questions", func() {
+			delete(answers, "trip")
+			_, err := survey.Answers(questions, answers)
+			Expect(err).To(MatchError("`trip` is required"))
+		})
+		It("rejects unknown questions", func() {
+			answers["color"] = json.RawMessage(`"blue"`)
+			_, err := survey.Answers(questions, answers)
+			Expect(err).To(MatchError("unknown question `color`"))
+		})
+		It("rejects scale answers out of range or fractional", func() {
+			for _, v := range []string{`6`, `0`, `3.5`, `"4"`} {
+				answers["trip"] = json.RawMessage(v)
+				_, err := survey.Answers(questions, answers)
+				Expect(err).To(MatchError("`trip` must be a whole number from 1 to 5"), v)
+			}
+		})
+		It("rejects unknown choices", func() {
+			answers["mode"] = json.RawMessage(`"ferry"`)
+			_, err := survey.Answers(questions, answers)
+			Expect(err).To(MatchError("`mode` must be one of bus, train"))
+		})
+		It("rejects repeated choices", func() {
+			answers["improve"] = json.RawMessage(`["safety", "safety"]`)
+			_, err := survey.Answers(questions, answers)
+			Expect(err).To(MatchError("`improve` includes `safety` more than once"))
+		})
+		It("rejects overlong text", func() {
+			questions[4].MaxLength = 5
+			_, err := survey.Answers(questions, answers)
+			Expect(err).To(MatchError("`comment` must be at most 5 characters"))
+		})
+		It("requires an answer", func() {
+			questions[0].Required = false
+			_, err := survey.Answers(questions, map[string]json.RawMessage{})
+			Expect(err).To(MatchError("at least one question must be answered"))
+		})
+	})
+
+	Describe("Summarize", func() {
+		It("computes distributions, averages and NPS", func() {
+			results := survey.Summarize(questions, []db.SurveyAnswerCount{
+				{Total: true, Count: 10},
+				{QuestionID: ptrToString("trip"), Total: true, Count: 4},
+				{QuestionID: ptrToString("trip"), Answer: ptrToString("5"), Count: 3},
+				{QuestionID: ptrToString("trip"), Answer: ptrToString("1"), Count: 1},
+				{QuestionID: ptrToString("recommend"), Total: true, Count: 5},
+				{QuestionID: ptrToString("recommend"), Answer: ptrToString("10"), Count: 2},
+				{QuestionID: ptrToString("recommend"), Answer: ptrToString("8"), Count: 1},
+				{QuestionID: ptrToString("recommend"), Answer: ptrToString("3"), Count: 2},
+				{QuestionID: ptrToString("improve"), Total: true, Count: 2},
+				{QuestionID: ptrToString("improve"), Answer: ptrToString("safety"), Count: 2},
+				{QuestionID: ptrToString("improve"), Answer: ptrToString("frequency"), Count: 1},
+				{QuestionID: ptrToString("comment"), Total: true, Count: 3},
+				{QuestionID: ptrToString("comment"), Count: 3},
+			})
+
+			Expect(results.Responses).To(Equal(10))
+			Expect(results.Questions).To(HaveLen(5))
+
+			trip := results.Questions[0]
+			Expect(trip.Answered).To(Equal(4))
+			Expect(trip.Distribution).To(Equal(map[string]int{"1": 1, "2": 0, "3": 0, "4": 0, "5": 3}))
+			Expect(trip.Average).To(PointTo(Equal(4.0)))
+			Expect(trip.NPS).To(BeNil())
+
+			recommend := results.Questions[1]
+			Expect(recommend.Average).To(PointTo(BeNumerically("~", 6.8)))
+			Expect(recommend.NPS).To(PointTo(Equal(0.0)))
+
+			mode := results.Questions[2]
+			Expect(mode.Answered).To(Equal(0))
+			Expect(mode.Distribution).To(Equal(map[string]int{"bus": 0, "train": 0}))
+			Expect(mode.Average).To(BeNil())
+
+			improve := results.Questions[3]
+			Expect(improve.Answered).To(Equal(2))
+			Expect(improve.Distribution).To(Equal(map[string]int{"cleanliness": 0, "frequency": 1, "safety": 2}))
+
+			comment := results.Questions[4]
+			Expect(comment.Answered).To(Equal(3))
+			Expect(comment.Distribution).To(BeNil())
+		})
+		It("handles versions without responses", func() {
+			results := survey.Summarize(questions, []db.SurveyAnswerCount{})
+			Expect(results.Responses).To(Equal(0))
+			Expect(results.Questions[1].NPS).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlphttp"
+	"go.opentelemetry.io/otel/exporters/stdout"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+//NewExporter builds the exporter named by kind: "none", "stdout", "file"
+//(writing to path) or "otlp" (posting OTLP/HTTP to endpoint, the full traces
+//URL, e.g. http://collector:4318/v1/traces)
+func NewExporter(ctx context.Context, kind, path, endpoint string) (sdktrace.SpanExporter, error) {
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return stdout.NewExporter(stdout.WithWriter(os.Stdout), stdout.WithoutMetricExport())
+	case "file":
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed opening trace file: %w", err)
+		}
+		return stdout.NewExporter(stdout.WithWriter(f), stdout.WithoutMetricExport())
+	case "otlp":
+		return newOTLPExporter(ctx, endpoint)
+	}
+
+	return nil, fmt.Errorf("unknown trace exporter `%s`", kind)
+}
+
+//newOTLPExporter splits endpoint into the host and path the OTLP/HTTP driver
+//takes separately
+func newOTLPExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint `%s`", endpoint)
+	}
+
+	opts := []otlphttp.Option{otlphttp.WithEndpoint(u.Host)}
+	switch u.Scheme {
+	case "http":
+		opts = append(opts, otlphttp.WithInsecure())
+	case "https":
+	default:
+		return nil, fmt.Errorf("invalid OTLP endpoint `%s`: expected an http or https URL", endpoint)
+	}
+	if u.Path != "" {
+		opts = append(opts, otlphttp.WithTracesURLPath(u.Path))
+	}
+
+	exporter, err := otlp.NewExporter(ctx, otlphttp.NewDriver(opts...))
+	if err != nil {
+		return nil, fmt.Errorf("failed starting OTLP exporter: %w", err)
+	}
+	return exporter, nil
+}
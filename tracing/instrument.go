@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/logging"
+)
+
+//Middleware starts a server span for every request served by next, parented
+//on any incoming traceparent. Spans are named after the pattern in mux that
+//matches the request.
+func (t *Tracer) Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := t.tracer.Start(ctx, fmt.Sprintf("%s %s", r.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPRouteKey.String(route),
+				semconv.HTTPTargetKey.String(logging.RedactPath(r.URL.Path)),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(rec.status))
+		if rec.status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("responded with status %d", rec.status))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+//InstrumentDBDriver starts a client span for every statement executed through
+//drv. names maps known SQL statements to the name their span is given.
+func InstrumentDBDriver(drv db.DBDriver, names map[string]string) db.DBDriver {
+	return tracedDriver{
+		DBDriver: drv,
+		names:    names,
+	}
+}
+
+type tracedDriver struct {
+	db.DBDriver
+	names map[string]string
+}
+
+func (d tracedDriver) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	res, err := d.DBDriver.ExecContext(ctx, query, args...)
+	RecordError(span, err)
+	return res, err
+}
+
+func (d tracedDriver) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	rows, err := d.DBDriver.QueryContext(ctx, query, args...)
+	RecordError(span, err)
+	return rows, err
+}
+
+func (d tracedDriver) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	name, ok := d.names[query]
+	if !ok {
+		name = "other"
+	}
+
+	parent := trace.SpanFromContext(ctx)
+	return parent.Tracer().Start(ctx, "db "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgres,
+			semconv.DBOperationKey.String(name),
+			semconv.DBStatementKey.String(query),
+		),
+	)
+}
+
+//RecordError marks span as failed with err, if there is one
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//instrumentationName identifies the spans this package starts
+const instrumentationName = "github.com/smartatransit/feedback/tracing"
+
+//Tracer starts spans for the service and batches sampled ones to an
+//exporter through the OpenTelemetry SDK
+type Tracer struct {
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+//New returns a new Tracer that exports sampled spans through exporter. Root
+//spans are sampled with probability sampleRatio; child spans, including those
+//continuing an incoming traceparent, follow their parent's decision.
+func New(
+	exporter sdktrace.SpanExporter,
+	serviceName string,
+	sampleRatio float64,
+) *Tracer {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.ServiceNameKey.String(serviceName))),
+	)
+
+	return &Tracer{
+		provider:   provider,
+		tracer:     provider.Tracer(instrumentationName),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+//Shutdown exports any buffered spans and stops the exporter. It must be
+//called before the process exits, or the last batch is lost.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	return t.provider.Shutdown(ctx)
+}
+
+//StartSpan begins an internal child span of the span in ctx. When ctx carries
+//no span it returns a no-op span, so callers need not know whether tracing is
+//configured.
+func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	return parent.Tracer().Start(ctx, name)
+}
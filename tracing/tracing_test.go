@@ -0,0 +1,139 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	dbp "github.com/smartatransit/feedback/db"
+	"github.com/smartatransit/feedback/db/dbfakes"
+	"github.com/smartatransit/feedback/tracing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//retainingExporter keeps its spans on shutdown, so they can be checked after
+//the tracer is flushed
+type retainingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (retainingExporter) Shutdown(context.Context) error { return nil }
+
+var _ = Describe("Tracing", func() {
+	Describe("Middleware and InstrumentDBDriver", func() {
+		var (
+			exporter *tracetest.InMemoryExporter
+			tracer   *tracing.Tracer
+			driver   *dbfakes.FakeDBDriver
+			req      *http.Request
+
+			exported []*sdktrace.SpanSnapshot
+		)
+
+		BeforeEach(func() {
+			exporter = tracetest.NewInMemoryExporter()
+			tracer = tracing.New(retainingExporter{exporter}, "feedback", 1)
+			driver = &dbfakes.FakeDBDriver{}
+			driver.ExecContextReturns(nil, errors.New("insert failed"))
+
+			req = httptest.NewRequest("POST", "/v1/feedback", nil)
+		})
+
+		JustBeforeEach(func() {
+			traced := tracing.InstrumentDBDriver(driver, dbp.StatementNames)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/feedback", func(w http.ResponseWriter, r *http.Request) {
+				_, span := tracing.StartSpan(r.Context(), "decode request")
+				span.End()
+				_, _ = traced.ExecContext(r.Context(), dbp.SaveFeedbackSQL)
+			})
+
+			tracer.Middleware(mux, mux).ServeHTTP(httptest.NewRecorder(), req)
+			exported = exporter.GetSpans()
+			Expect(exported).To(BeEmpty(), "spans are batched until flushed")
+
+			Expect(tracer.Shutdown(context.Background())).To(Succeed())
+			exported = exporter.GetSpans()
+		})
+
+		It("records a span per handler, step and statement", func() {
+			Expect(exported).To(HaveLen(3))
+			decode, query, server := exported[0], exported[1], exported[2]
+
+			Expect(server.Name).To(Equal("POST /v1/feedback"))
+			Expect(server.SpanKind).To(Equal(trace.SpanKindServer))
+			Expect(server.Parent.IsValid()).To(BeFalse())
+			Expect(server.Resource.Attributes()).To(ContainElement(attribute.String("service.name", "feedback")))
+
+			Expect(decode.Name).To(Equal("decode request"))
+			Expect(decode.Parent.SpanID()).To(Equal(server.SpanContext.SpanID()))
+
+			Expect(query.Name).To(Equal("db SaveFeedbackSQL"))
+			Expect(query.SpanKind).To(Equal(trace.SpanKindClient))
+			Expect(query.Parent.SpanID()).To(Equal(server.SpanContext.SpanID()))
+			Expect(query.SpanContext.TraceID()).To(Equal(server.SpanContext.TraceID()))
+			Expect(query.StatusCode).To(Equal(codes.Error))
+			Expect(query.StatusMessage).To(Equal("insert failed"))
+		})
+
+		When("the gateway supplies a traceparent", func() {
+			BeforeEach(func() {
+				req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			})
+			It("continues the trace", func() {
+				server := exported[2]
+				Expect(server.SpanContext.TraceID().String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+				Expect(server.Parent.SpanID().String()).To(Equal("00f067aa0ba902b7"))
+			})
+		})
+		When("the path ends in a secret", func() {
+			BeforeEach(func() {
+				req = httptest.NewRequest("POST", "/v1/email/unsubscribe/c2VjcmV0LXRva2Vu", nil)
+			})
+			It("redacts it from the span", func() {
+				Expect(exported).To(HaveLen(1))
+				Expect(exported[0].Attributes).To(ContainElement(attribute.String("http.target", "/v1/email/unsubscribe/REDACTED")))
+				Expect(fmt.Sprint(exported[0].Attributes)).NotTo(ContainSubstring("c2VjcmV0LXRva2Vu"))
+			})
+		})
+		When("the gateway's trace isn't sampled", func() {
+			BeforeEach(func() {
+				req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+			})
+			It("exports nothing", func() {
+				Expect(exported).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("StartSpan", func() {
+		It("is a no-op without an active span", func() {
+			ctx, span := tracing.StartSpan(context.Background(), "orphan")
+			Expect(span.IsRecording()).To(BeFalse())
+			Expect(trace.SpanFromContext(ctx).SpanContext().IsValid()).To(BeFalse())
+			span.End()
+		})
+	})
+
+	Describe("NewExporter", func() {
+		It("rejects OTLP endpoints that aren't URLs", func() {
+			_, err := tracing.NewExporter(context.Background(), "otlp", "", "collector:4318")
+			Expect(err).To(HaveOccurred())
+		})
+		It("rejects unknown exporters", func() {
+			_, err := tracing.NewExporter(context.Background(), "zipkin", "", "")
+			Expect(err).To(MatchError("unknown trace exporter `zipkin`"))
+		})
+	})
+})